@@ -2,13 +2,20 @@ package cli
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
 	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/internal/prompt"
+	"github.com/arjungandhi/money/pkg/alerts"
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
 	"github.com/arjungandhi/money/pkg/table"
 )
 
@@ -19,8 +26,13 @@ var Accounts = &Z.Cmd{
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		AccountsList,
+		AccountsTrend,
 		AccountsType,
 		AccountsNickname,
+		AccountsDefaultCategory,
+		AccountsCreditLimit,
+		AccountsStatementDue,
+		AccountsOwner,
 		AccountsDelete,
 	},
 }
@@ -45,12 +57,46 @@ var AccountsNickname = &Z.Cmd{
 	},
 }
 
+// accountsListSortColumns maps --sort values to accounts list columns
+var accountsListSortColumns = map[string]int{
+	"type":         0,
+	"organization": 1,
+	"name":         2,
+	"balance":      4,
+}
+
+func accountsListFlags(sortColumn *int, reverse *bool) *flags.Set {
+	set := flags.New("money accounts list")
+	set.Func("sort", "s", "type|organization|name|balance", func(value string) error {
+		column, ok := accountsListSortColumns[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("use type, organization, name or balance")
+		}
+		*sortColumn = column
+		return nil
+	})
+	set.BoolVar(reverse, "reverse", "r")
+	return set
+}
+
 var AccountsList = &Z.Cmd{
 	Name:     "list",
 	Aliases:  []string{"ls", "l"},
 	Summary:  "Show all accounts with their current types",
+	Usage:    "list " + accountsListFlags(new(int), new(bool)).Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Lists every account with its type, organization and balance, in the order
+they were added, plus its owner once any account has one. --sort orders
+them by a column instead: alphabetically for text, or largest first for
+balance. --reverse flips the sorted order.
+`,
 	Call: func(cmd *Z.Cmd, args ...string) error {
+		sortColumn, reverse := -1, false
+		if err := accountsListFlags(&sortColumn, &reverse).Parse(args); err != nil {
+			return err
+		}
+
 		return dbutil.WithDatabase(func(db *database.DB) error {
 			accounts, err := db.GetAccounts()
 			if err != nil {
@@ -78,7 +124,25 @@ var AccountsList = &Z.Cmd{
 			config.Title = "Account Types"
 			config.MaxColumnWidth = 30
 
-			t := table.NewWithConfig(config, "Type", "Organization", "Account Name", "Account ID")
+			// Households that record owners see them last, so the sort
+			// columns stay put
+			showOwner := false
+			for _, account := range accounts {
+				if account.Owner != nil {
+					showOwner = true
+					break
+				}
+			}
+			headers := []string{"Type", "Organization", "Account Name", "Account ID", "Balance"}
+			types := []table.ColumnType{table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency}
+			if showOwner {
+				headers = append(headers, "Owner")
+				types = append(types, table.ColumnText)
+			}
+
+			t := table.NewWithConfig(config, headers...)
+			t.SetColumnTypes(types...)
+			t.SetRowStyle(negativeAmounts(4))
 
 			for _, account := range accounts {
 				accountType := "unset"
@@ -94,20 +158,68 @@ var AccountsList = &Z.Cmd{
 				// Use DisplayName method to get nickname or original name
 				displayName := account.DisplayName()
 
-				t.AddRow(accountType, orgName, displayName, account.ID)
+				row := []string{accountType, orgName, displayName, account.ID, format.Currency(account.Balance, account.Currency)}
+				if showOwner {
+					owner := ""
+					if account.Owner != nil {
+						owner = *account.Owner
+					}
+					row = append(row, owner)
+				}
+				t.AddRow(row...)
+			}
+
+			if sortColumn >= 0 {
+				dir := table.Ascending
+				if sortColumn == accountsListSortColumns["balance"] {
+					dir = table.Descending
+				}
+				if reverse {
+					dir = dir.Reverse()
+				}
+				t.SortBy(sortColumn, dir)
 			}
 
 			if err := t.Render(); err != nil {
 				return fmt.Errorf("failed to render accounts table: %w", err)
 			}
-			fmt.Println("Available account types: checking, savings, credit, investment, loan, property, other")
-			fmt.Println("Use 'money accounts type set <account-id> <type>' to set an account type")
+			hintf("Available account types: checking, savings, credit, investment, crypto, loan, property, other\n")
+			hintf("Use 'money accounts type set <account-id> <type>' to set an account type\n")
 
 			return nil
 		})
 	},
 }
 
+func accountsTrendFlags(days *int) *flags.Set {
+	set := flags.New("money accounts trend")
+	set.Positional("<account-id>")
+	set.IntVar(days, "days", "d", "<number>", 1)
+	return set
+}
+
+var AccountsTrend = &Z.Cmd{
+	Name:     "trend",
+	Summary:  "Show one account's balance history chart and summary stats",
+	Usage:    "trend " + accountsTrendFlags(new(int)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		days := 30
+		set := accountsTrendFlags(&days)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: money accounts %s", cmd.Usage)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			return displayAccountTrend(db, positional[0], days)
+		})
+	},
+}
+
 var AccountsTypeSet = &Z.Cmd{
 	Name:     "set",
 	Summary:  "Set account type for an account",
@@ -122,7 +234,7 @@ var AccountsTypeSet = &Z.Cmd{
 		accountType := args[1]
 
 		// Validate account type
-		validTypes := []string{"checking", "savings", "credit", "investment", "loan", "property", "other"}
+		validTypes := []string{"checking", "savings", "credit", "investment", "crypto", "loan", "property", "other"}
 		isValid := false
 		for _, validType := range validTypes {
 			if accountType == validType {
@@ -134,11 +246,10 @@ var AccountsTypeSet = &Z.Cmd{
 			return fmt.Errorf("invalid account type: %s. Valid types are: %v", accountType, validTypes)
 		}
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		// Check if account exists
 		account, err := db.GetAccountByID(accountID)
@@ -170,11 +281,10 @@ var AccountsTypeClear = &Z.Cmd{
 
 		accountID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		// Check if account exists
 		account, err := db.GetAccountByID(accountID)
@@ -208,11 +318,10 @@ var AccountsNicknameSet = &Z.Cmd{
 		// Join remaining args as nickname to support multi-word nicknames
 		nickname := strings.Join(args[1:], " ")
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		// Check if account exists
 		account, err := db.GetAccountByID(accountID)
@@ -244,11 +353,10 @@ var AccountsNicknameClear = &Z.Cmd{
 
 		accountID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		// Check if account exists
 		account, err := db.GetAccountByID(accountID)
@@ -268,6 +376,500 @@ var AccountsNicknameClear = &Z.Cmd{
 	},
 }
 
+var AccountsDefaultCategory = &Z.Cmd{
+	Name:    "default-category",
+	Aliases: []string{"default"},
+	Summary: "File an account's new transactions under a category during fetch",
+	Description: `
+Gives an account a default category. When money fetch brings in new
+transactions for the account, any left uncategorized are filed under it, so
+everything in a mortgage account can land in Housing without a rule or the
+LLM. Transactions categorized by hand are never changed; use apply to file
+the account's existing uncategorized transactions too.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AccountsDefaultCategorySet,
+		AccountsDefaultCategoryClear,
+		AccountsDefaultCategoryList,
+		AccountsDefaultCategoryApply,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AccountsDefaultCategoryList.Call(cmd, args...)
+	},
+}
+
+var AccountsDefaultCategorySet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Set the category new transactions in an account get",
+	Usage:    "set <account-id> <category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("usage: money accounts default-category set <account-id> <category>")
+		}
+
+		// Join remaining args as the category to support multi-word names
+		categoryName := strings.Join(args[1:], " ")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetAccountDefaultCategory(account.ID, category.ID); err != nil {
+				return err
+			}
+
+			fmt.Printf("New transactions in %s will be categorized as %s\n", account.DisplayName(), category.Name)
+			fmt.Println("Run 'money accounts default-category apply' to categorize its existing transactions.")
+			return nil
+		})
+	},
+}
+
+var AccountsDefaultCategoryClear = &Z.Cmd{
+	Name:     "clear",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove an account's default category",
+	Usage:    "clear <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money accounts default-category clear <account-id>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearAccountDefaultCategory(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared the default category for %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var AccountsDefaultCategoryList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List account default categories",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			defaults, err := db.GetAccountDefaultCategories()
+			if err != nil {
+				return err
+			}
+
+			if len(defaults) == 0 {
+				fmt.Println("No default categories. Add one with 'money accounts default-category set <account-id> <category>'.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			accountNames := make(map[string]string)
+			for _, account := range accounts {
+				accountNames[account.ID] = account.DisplayName()
+			}
+			sort.Slice(defaults, func(i, j int) bool {
+				return accountNames[defaults[i].AccountID] < accountNames[defaults[j].AccountID]
+			})
+
+			t := table.New("Account", "ID", "Category")
+			for _, d := range defaults {
+				t.AddRow(accountNames[d.AccountID], d.AccountID, d.CategoryName)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var AccountsDefaultCategoryApply = &Z.Cmd{
+	Name:     "apply",
+	Summary:  "Categorize existing uncategorized transactions by account default",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			categorized, err := db.ApplyAccountDefaultCategories(nil)
+			if err != nil {
+				return fmt.Errorf("failed to apply default categories: %w", err)
+			}
+
+			fmt.Printf("Categorized %d transactions by account default\n", categorized)
+			return nil
+		})
+	},
+}
+
+var AccountsCreditLimit = &Z.Cmd{
+	Name:    "credit-limit",
+	Aliases: []string{"limit"},
+	Summary: "Manage credit limits for credit utilization",
+	Description: `
+Records the credit limit of a credit card, or of a line of credit, which
+SimpleFIN doesn't provide. money report utilization measures each
+account's balance against it.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AccountsCreditLimitSet,
+		AccountsCreditLimitClear,
+		AccountsCreditLimitList,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AccountsCreditLimitList.Call(cmd, args...)
+	},
+}
+
+var AccountsCreditLimitSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Set an account's credit limit",
+	Usage:    "set <account-id> <amount>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money accounts credit-limit set <account-id> <amount>")
+		}
+
+		limit, err := format.ParseCents(args[1])
+		if err != nil {
+			return err
+		}
+		if limit <= 0 {
+			return fmt.Errorf("credit limit must be positive")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetCreditLimit(account.ID, limit); err != nil {
+				return err
+			}
+
+			fmt.Printf("Set the credit limit of %s to %s\n", account.DisplayName(), format.Currency(limit, account.Currency))
+			return nil
+		})
+	},
+}
+
+var AccountsCreditLimitClear = &Z.Cmd{
+	Name:     "clear",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove an account's credit limit",
+	Usage:    "clear <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money accounts credit-limit clear <account-id>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearCreditLimit(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared the credit limit for %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var AccountsCreditLimitList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List credit limits",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			limits, err := db.GetCreditLimits()
+			if err != nil {
+				return err
+			}
+
+			if len(limits) == 0 {
+				fmt.Println("No credit limits. Add one with 'money accounts credit-limit set <account-id> <amount>'.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			sort.Slice(accounts, func(i, j int) bool {
+				return accounts[i].DisplayName() < accounts[j].DisplayName()
+			})
+
+			t := table.New("Account", "ID", "Limit")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			for _, account := range accounts {
+				if limit, exists := limits[account.ID]; exists {
+					t.AddRow(account.DisplayName(), account.ID, format.Currency(limit, account.Currency))
+				}
+			}
+			return t.Render()
+		})
+	},
+}
+
+var AccountsStatementDue = &Z.Cmd{
+	Name:    "statement-due",
+	Aliases: []string{"due"},
+	Summary: "Manage the days credit card statements are due",
+	Description: `
+Records the day of the month a credit card's statement payment is due,
+which SimpleFIN doesn't provide. money bills ical puts the due dates on the
+calendar alongside bills. A due day past the end of a month falls on its
+last day.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AccountsStatementDueSet,
+		AccountsStatementDueClear,
+		AccountsStatementDueList,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AccountsStatementDueList.Call(cmd, args...)
+	},
+}
+
+var AccountsStatementDueSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Set the day an account's statement is due",
+	Usage:    "set <account-id> <due-day>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money accounts statement-due set <account-id> <due-day>")
+		}
+
+		dueDay, err := strconv.Atoi(args[1])
+		if err != nil || dueDay < 1 || dueDay > 31 {
+			return fmt.Errorf("due day must be a day of the month between 1 and 31")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetStatementDueDay(account.ID, dueDay); err != nil {
+				return err
+			}
+
+			fmt.Printf("The statement of %s is now due on day %d\n", account.DisplayName(), dueDay)
+			return nil
+		})
+	},
+}
+
+var AccountsStatementDueClear = &Z.Cmd{
+	Name:     "clear",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove the day an account's statement is due",
+	Usage:    "clear <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money accounts statement-due clear <account-id>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearStatementDueDay(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared the statement due day for %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var AccountsStatementDueList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List statement due days",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			dueDays, err := db.GetStatementDueDays()
+			if err != nil {
+				return err
+			}
+
+			if len(dueDays) == 0 {
+				fmt.Println("No statement due days. Add one with 'money accounts statement-due set <account-id> <due-day>'.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			sort.Slice(accounts, func(i, j int) bool {
+				return accounts[i].DisplayName() < accounts[j].DisplayName()
+			})
+
+			now := time.Now()
+			t := table.New("Account", "ID", "Due Day", "Next Due")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnNumber)
+			for _, account := range accounts {
+				if dueDay, exists := dueDays[account.ID]; exists {
+					t.AddRow(account.DisplayName(), account.ID, strconv.Itoa(dueDay), alerts.NextDueDate(dueDay, now).Format("2006-01-02"))
+				}
+			}
+			return t.Render()
+		})
+	},
+}
+
+var AccountsOwner = &Z.Cmd{
+	Name:    "owner",
+	Summary: "Manage who each account belongs to in a shared household",
+	Description: `
+Records who an account belongs to when a household shares one database,
+like "me", "spouse" or "joint". Owners are free-form names, compared
+without regard to case.
+
+The global --owner flag then limits any command to the accounts of the
+owners listed, comma separated, so balance, budget and the reports answer
+for one person:
+
+  money balance --owner me,joint
+  money report income --owner spouse
+
+Accounts without an owner are left out whenever --owner is given.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AccountsOwnerSet,
+		AccountsOwnerClear,
+		AccountsOwnerList,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AccountsOwnerList.Call(cmd, args...)
+	},
+}
+
+var AccountsOwnerSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Set who an account belongs to",
+	Usage:    "set <account-id> <owner>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money accounts owner set <account-id> <owner>")
+		}
+
+		owner, err := database.NormalizeOwner(args[1])
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetAccountOwner(account.ID, owner); err != nil {
+				return err
+			}
+
+			fmt.Printf("Set the owner of %s to %s\n", account.DisplayName(), owner)
+			return nil
+		})
+	},
+}
+
+var AccountsOwnerClear = &Z.Cmd{
+	Name:     "clear",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove an account's owner",
+	Usage:    "clear <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money accounts owner clear <account-id>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.ClearAccountOwner(account.ID); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared the owner of %s\n", account.DisplayName())
+			return nil
+		})
+	},
+}
+
+var AccountsOwnerList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List accounts by owner",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+
+			var owned []database.Account
+			for _, account := range accounts {
+				if account.Owner != nil {
+					owned = append(owned, account)
+				}
+			}
+			if len(owned) == 0 {
+				fmt.Println("No account owners. Add one with 'money accounts owner set <account-id> <owner>'.")
+				return nil
+			}
+
+			sort.SliceStable(owned, func(i, j int) bool {
+				if *owned[i].Owner != *owned[j].Owner {
+					return *owned[i].Owner < *owned[j].Owner
+				}
+				return owned[i].DisplayName() < owned[j].DisplayName()
+			})
+
+			t := table.New("Owner", "Account", "ID", "Balance")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			t.SetRowStyle(negativeAmounts(3))
+			for _, account := range owned {
+				t.AddRow(*account.Owner, account.DisplayName(), account.ID, format.Currency(account.Balance, account.Currency))
+			}
+			if err := t.Render(); err != nil {
+				return err
+			}
+
+			if unowned := len(accounts) - len(owned); unowned > 0 {
+				hintf("%d account(s) without an owner are left out of --owner views\n", unowned)
+			}
+			return nil
+		})
+	},
+}
+
 var AccountsDelete = &Z.Cmd{
 	Name:    "delete",
 	Aliases: []string{"del", "rm"},
@@ -291,11 +893,10 @@ Use 'money accounts list' to see account IDs.
 
 		accountID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		// Check if account exists and get details
 		account, err := db.GetAccountByID(accountID)
@@ -311,12 +912,9 @@ Use 'money accounts list' to see account IDs.
 		if account.AccountType != nil && *account.AccountType == "property" {
 			fmt.Printf("- Property details and valuations\n")
 		}
-		fmt.Printf("\nType 'yes' to confirm: ")
-
-		var confirmation string
-		fmt.Scanln(&confirmation)
+		fmt.Println()
 
-		if strings.ToLower(confirmation) != "yes" {
+		if !prompt.Confirm("Delete this account?") {
 			fmt.Println("Account deletion cancelled.")
 			return nil
 		}
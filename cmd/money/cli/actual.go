@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/actual"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Actual = &Z.Cmd{
+	Name:    "actual",
+	Summary: "Push transactions and balances to an Actual Budget server",
+	Description: `
+Lets money be the sync engine for a self-hosted Actual Budget while you
+budget in Actual: transactions fetched from your banks are pushed to the
+Actual accounts you map them to, and each account's balance is kept in
+line with money's.
+
+Actual's server is reached through actual-http-api, which runs next to
+it and wraps Actual's API in a REST server. Once it's running:
+
+  money config set actual_url http://localhost:5007
+  money config set actual_api_key <key>
+  money config set actual_budget <sync id>
+  money actual accounts
+  money actual map <account-id> "<actual-account>"
+  money actual push
+
+The sync ID is under Settings > Advanced settings in Actual. Set
+actual_password too if the budget is end-to-end encrypted. With accounts
+mapped, 'money fetch' pushes to Actual after every fetch.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		ActualAccounts,
+		ActualMap,
+		ActualUnmap,
+		ActualPush,
+	},
+}
+
+// newActualClient returns a client for the configured Actual budget
+func newActualClient(db *database.DB) (*actual.Client, error) {
+	cfg := db.GetConfig()
+	if cfg.ActualURL == "" || cfg.ActualBudget == "" {
+		return nil, fmt.Errorf("Actual isn't set up: run 'money config set actual_url <actual-http-api url>' and 'money config set actual_budget <sync id>'")
+	}
+	return actual.NewClient(cfg.ActualURL, cfg.ActualAPIKey, cfg.ActualBudget, cfg.ActualPassword), nil
+}
+
+var ActualAccounts = &Z.Cmd{
+	Name:     "accounts",
+	Summary:  "List the Actual budget's accounts and the accounts mapped to them",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newActualClient(db)
+			if err != nil {
+				return err
+			}
+			actualAccounts, err := client.Accounts()
+			if err != nil {
+				return err
+			}
+			mappedIDs, err := db.GetActualAccounts()
+			if err != nil {
+				return err
+			}
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+
+			mapped := make(map[string]string, len(mappedIDs))
+			for _, account := range accounts {
+				if actualID, ok := mappedIDs[account.ID]; ok {
+					mapped[actualID] = fmt.Sprintf("%s (%s)", account.DisplayName(), account.ID)
+				}
+			}
+
+			if len(actualAccounts) == 0 {
+				fmt.Println("The Actual budget has no accounts.")
+				return nil
+			}
+
+			t := table.New("Actual Account", "Budget", "Mapped To", "Actual ID")
+			for _, account := range actualAccounts {
+				name := account.Name
+				if account.Closed {
+					name += " (closed)"
+				}
+				budget := "on budget"
+				if account.OffBudget {
+					budget = "off budget"
+				}
+				t.AddRow(name, budget, mapped[account.ID], account.ID)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var ActualMap = &Z.Cmd{
+	Name:     "map",
+	Summary:  "Push an account to an Actual account, or list the mappings",
+	Usage:    "[<account-id> <actual-account>]",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Maps an account to the Actual account, by name or ID as listed by
+'money actual accounts', that its transactions and balance are pushed to.
+Each Actual account can be mapped to one account. Accounts without
+transactions, like properties and assets, can be mapped to an off-budget
+Actual account to track their value.
+
+With no arguments, lists the mappings.
+
+Example:
+  money actual map ACT-123 "Joint Checking"
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) == 1 {
+			return fmt.Errorf("usage: money actual map %s", cmd.Usage)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if len(args) == 0 {
+				return listActualMappings(db)
+			}
+
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newActualClient(db)
+			if err != nil {
+				return err
+			}
+			actualAccounts, err := client.Accounts()
+			if err != nil {
+				return err
+			}
+			// Join remaining args as the Actual account to support multi-word names
+			actualAccount, err := actual.FindAccount(actualAccounts, strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+
+			mapped, err := db.GetActualAccounts()
+			if err != nil {
+				return err
+			}
+			for accountID, actualID := range mapped {
+				if actualID == actualAccount.ID && accountID != account.ID {
+					return fmt.Errorf("Actual account %s is already mapped to account %s; unmap it first with 'money actual unmap %s'", actualAccount.Name, accountID, accountID)
+				}
+			}
+
+			if err := db.SetActualAccount(account.ID, actualAccount.ID); err != nil {
+				return err
+			}
+			fmt.Printf("%s will be pushed to Actual account %s\n", account.DisplayName(), actualAccount.Name)
+			return nil
+		})
+	},
+}
+
+// listActualMappings prints each mapped account and the Actual account it's
+// pushed to
+func listActualMappings(db *database.DB) error {
+	mapped, err := db.GetActualAccounts()
+	if err != nil {
+		return err
+	}
+	if len(mapped) == 0 {
+		fmt.Println("No accounts are mapped to Actual. Map one with 'money actual map <account-id> <actual-account>'.")
+		return nil
+	}
+
+	client, err := newActualClient(db)
+	if err != nil {
+		return err
+	}
+	actualAccounts, err := client.Accounts()
+	if err != nil {
+		return err
+	}
+	actualNames := make(map[string]string, len(actualAccounts))
+	for _, account := range actualAccounts {
+		actualNames[account.ID] = account.Name
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to get accounts: %w", err)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].DisplayName() < accounts[j].DisplayName()
+	})
+	t := table.New("Account", "ID", "Actual Account")
+	for _, account := range accounts {
+		actualID, ok := mapped[account.ID]
+		if !ok {
+			continue
+		}
+		// Mappings to accounts deleted in Actual show their ID
+		name, ok := actualNames[actualID]
+		if !ok {
+			name = actualID + " (not in Actual)"
+		}
+		t.AddRow(account.DisplayName(), account.ID, name)
+	}
+	return t.Render()
+}
+
+var ActualUnmap = &Z.Cmd{
+	Name:     "unmap",
+	Summary:  "Stop pushing an account to Actual",
+	Usage:    "<account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money actual unmap %s", cmd.Usage)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearActualAccount(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Account %s will no longer be pushed to Actual\n", args[0])
+			return nil
+		})
+	},
+}
+
+func actualPushFlags(opts *actual.Options) *flags.Set {
+	set := flags.New("money actual push")
+	set.Func("since", "s", "YYYY-MM-DD", dateFlag(&opts.Since))
+	set.BoolVar(&opts.NoBalances, "no-balances", "")
+	return set
+}
+
+var ActualPush = &Z.Cmd{
+	Name:     "push",
+	Summary:  "Push posted transactions and balances to Actual",
+	Usage:    actualPushFlags(&actual.Options{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Imports posted transactions in the mapped accounts, from the last 30 days
+or since --since, into their Actual accounts as cleared transactions,
+with the Actual category of the same name. Actual matches each against
+what it already has, so pushing again, or pushing transactions Actual's
+own bank sync brought in, updates them rather than adding them twice.
+Categories set in Actual are kept.
+
+Then each Actual account whose balance differs from money's gets a
+"Balance adjustment" transaction for the difference, which covers history
+from before --since and accounts with no transactions. --no-balances
+pushes only the transactions.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts actual.Options
+		if err := actualPushFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		if opts.Since == "" {
+			opts.Since = format.Now().AddDate(0, 0, -actual.DefaultSinceDays).Format("2006-01-02")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newActualClient(db)
+			if err != nil {
+				return err
+			}
+			result, err := actual.Push(db, client, opts)
+			if err != nil {
+				return err
+			}
+			printActualResult(result, opts.Since)
+			return nil
+		})
+	},
+}
+
+// printActualResult prints what a push changed in Actual
+func printActualResult(result *actual.Result, since string) {
+	fmt.Printf("Pushed transactions since %s to Actual: %d added, %d already there", since, result.Added, result.Updated)
+	if result.Adjusted > 0 {
+		fmt.Printf(", %d balance(s) adjusted", result.Adjusted)
+	}
+	fmt.Println()
+	if len(result.Unmapped) > 0 {
+		fmt.Printf("Categories with no Actual category of the same name, pushed uncategorized: %s\n", strings.Join(result.Unmapped, ", "))
+	}
+}
+
+// pushToActual pushes the last few days to Actual after a fetch when
+// accounts are mapped to it
+func pushToActual(db *database.DB) {
+	if db.GetConfig().ActualURL == "" {
+		return
+	}
+	mapped, err := db.GetActualAccounts()
+	if err != nil || len(mapped) == 0 {
+		return
+	}
+	client, err := newActualClient(db)
+	if err != nil {
+		slog.Warn("failed to push to Actual", "err", err)
+		return
+	}
+
+	fmt.Printf("\nPushing to Actual...\n")
+	opts := actual.Options{Since: format.Now().AddDate(0, 0, -actual.DefaultSinceDays).Format("2006-01-02")}
+	result, err := actual.Push(db, client, opts)
+	if err != nil {
+		slog.Warn("failed to push to Actual", "err", err)
+		fmt.Printf("You can retry with 'money actual push'\n")
+		return
+	}
+	printActualResult(result, opts.Since)
+}
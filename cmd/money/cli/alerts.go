@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/alerts"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+func alertsFlags(sendToSinks *bool) *flags.Set {
+	set := flags.New("money alerts")
+	set.BoolVar(sendToSinks, "notify", "")
+	return set
+}
+
+var Alerts = &Z.Cmd{
+	Name:    "alerts",
+	Aliases: []string{"alert"},
+	Summary: "Check balances, upcoming bills and budgets against alert thresholds",
+	Usage:   alertsFlags(new(bool)).Usage(),
+	Description: `
+Alerts warn when an account's available balance drops below its low
+balance threshold, when the bills due in the next 14 days exceed the
+balance available to pay them, or when a category has spent 80% or all of
+its monthly budget (see 'money budget set'). Alerts are evaluated after
+every fetch; run 'money alerts' to check them at any time. With --notify,
+raised alerts are also sent to the configured notification sinks (see
+'money notify'), which suits running it from cron. Budget alerts are only
+sent the first time a category crosses each threshold in a month.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AlertsLowBalance,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		sendToSinks := false
+		if err := alertsFlags(&sendToSinks).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			now := time.Now()
+			raised, err := alerts.Evaluate(db, now)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate alerts: %w", err)
+			}
+
+			if len(raised) == 0 {
+				greenColor.Println("No alerts")
+				return nil
+			}
+
+			printAlerts(raised)
+			if sendToSinks {
+				notifyAlerts(db, raised, now)
+			}
+			return nil
+		})
+	},
+}
+
+var AlertsLowBalance = &Z.Cmd{
+	Name:    "low-balance",
+	Summary: "Manage per-account low balance thresholds",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AlertsLowBalanceSet,
+		AlertsLowBalanceClear,
+		AlertsLowBalanceList,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AlertsLowBalanceList.Call(cmd, args...)
+	},
+}
+
+var AlertsLowBalanceSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Alert when an account's balance drops below an amount",
+	Usage:    "set <account-id> <amount>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money alerts low-balance set <account-id> <amount>")
+		}
+
+		threshold, err := format.ParseCents(args[1])
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetLowBalanceThreshold(account.ID, threshold); err != nil {
+				return err
+			}
+
+			fmt.Printf("Alerting when %s drops below %s\n", account.DisplayName(), format.Currency(threshold, account.Currency))
+			return nil
+		})
+	},
+}
+
+var AlertsLowBalanceClear = &Z.Cmd{
+	Name:     "clear",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove an account's low balance threshold",
+	Usage:    "clear <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money alerts low-balance clear <account-id>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearLowBalanceThreshold(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared the low balance threshold for %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var AlertsLowBalanceList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List low balance thresholds",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			thresholds, err := db.GetLowBalanceThresholds()
+			if err != nil {
+				return err
+			}
+
+			if len(thresholds) == 0 {
+				fmt.Println("No low balance thresholds. Add one with 'money alerts low-balance set <account-id> <amount>'.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			sort.Slice(accounts, func(i, j int) bool {
+				return accounts[i].DisplayName() < accounts[j].DisplayName()
+			})
+
+			t := table.New("Account", "ID", "Threshold", "Balance")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnCurrency)
+			for _, account := range accounts {
+				threshold, exists := thresholds[account.ID]
+				if !exists {
+					continue
+				}
+
+				balance := account.Balance
+				if account.AvailableBalance != nil {
+					balance = *account.AvailableBalance
+				}
+				t.AddRow(account.DisplayName(), account.ID,
+					format.Currency(threshold, account.Currency),
+					colorizeAmount(balance, format.Currency(balance, account.Currency)))
+			}
+			return t.Render()
+		})
+	},
+}
+
+// printAlerts prints alerts as warnings
+func printAlerts(raised []alerts.Alert) {
+	for _, alert := range raised {
+		redColor.Printf("%s%s\n", icon("⚠ "), alert.Message)
+	}
+}
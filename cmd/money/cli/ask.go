@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/llm"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// askMaxRows caps how many rows an answer prints
+const askMaxRows = 200
+
+var Ask = &Z.Cmd{
+	Name:    "ask",
+	Summary: "Answer a question about your data in plain English",
+	Usage:   "ask <question>",
+	Description: `
+Asks the configured LLM to translate the question into a single
+parameterized SQLite SELECT statement, then runs it on a read-only
+connection and prints the result as a table. The generated SQL and its
+parameters are shown first so you can check what was run.
+
+Only the database schema and your question are sent to the LLM, never
+your data. Credential tables are hidden from the LLM and can't be queried.
+
+Examples:
+  money ask "how much did I spend on travel in 2023?"
+  money ask "what were my 5 largest purchases last month?"
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		question := strings.TrimSpace(strings.Join(args, " "))
+		if question == "" {
+			return fmt.Errorf("usage: money ask <question>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			schema, err := db.ReadOnlySchema()
+			if err != nil {
+				return err
+			}
+
+			llmClient := llm.NewClient()
+			llmClient.SetAuditLog(db)
+			query, err := llmClient.GenerateSQL(context.Background(), question, schema)
+			if err != nil {
+				return fmt.Errorf("failed to generate query: %w", err)
+			}
+
+			if query.Explanation != "" {
+				fmt.Println(query.Explanation)
+			}
+			grayColor.Printf("SQL: %s\n", query.SQL)
+			if len(query.Params) > 0 {
+				grayColor.Printf("Params: %v\n", query.Params)
+			}
+			fmt.Println()
+
+			result, err := db.QueryReadOnly(query.SQL, query.Params, askMaxRows)
+			if err != nil {
+				return err
+			}
+
+			if len(result.Rows) == 0 {
+				fmt.Println("No results")
+				return nil
+			}
+
+			t := table.New(result.Columns...)
+			for _, row := range result.Rows {
+				t.AddRow(row...)
+			}
+			if err := t.Render(); err != nil {
+				return err
+			}
+
+			if result.Truncated {
+				hintf("Showing the first %d rows\n", askMaxRows)
+			}
+			return nil
+		})
+	},
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/asset"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Assets = &Z.Cmd{
+	Name:    "assets",
+	Aliases: []string{"asset"},
+	Summary: "Track vehicles and other depreciating assets",
+	Description: `
+Assets are vehicles, equipment and other things you own that lose value
+over time. Each asset is an account of type other, so it counts towards
+net worth in 'money balance'.
+
+An asset's value is either set by hand with set-value, or depreciates in a
+straight line from its purchase price to a salvage value over its useful
+life. Scheduled depreciation is applied after every 'money fetch' and by
+'money assets update'.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		AssetsAdd,
+		AssetsList,
+		AssetsDepreciate,
+		AssetsSetValue,
+		AssetsUpdate,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return AssetsList.Call(cmd, args...)
+	},
+}
+
+func assetsAddFlags(kind *string, lifeMonths **int, salvage *int64) *flags.Set {
+	set := flags.New("money assets add")
+	set.Positional("<name>", "<purchase-price>", "<purchase-date>")
+	set.StringVar(kind, "kind", "", "vehicle|equipment|other")
+	set.Func("life", "", "<months>", func(value string) error {
+		months, err := strconv.Atoi(value)
+		if err != nil || months <= 0 {
+			return fmt.Errorf("must be a positive number of months")
+		}
+		*lifeMonths = &months
+		return nil
+	})
+	set.Func("salvage", "", "<amount>", centsFlag(salvage))
+	return set
+}
+
+var AssetsAdd = &Z.Cmd{
+	Name:    "add",
+	Summary: "Add an asset account",
+	Usage:   "add " + assetsAddFlags(new(string), new(*int), new(int64)).Usage(),
+	Description: `
+Adds an asset bought on <purchase-date> (YYYY-MM-DD) for <purchase-price>.
+With --life, it depreciates in a straight line over that many months down to
+the --salvage value (default 0); without it, the value stays at the purchase
+price until changed with set-value. --kind defaults to other.
+
+Examples:
+  money assets add "2019 Honda Civic" 24000 2019-06-01 --kind vehicle --life 120 --salvage 3000
+  money assets add "Camera" 1800 2023-11-20 --kind equipment --life 60
+  money assets add "Piano" 6000 2015-03-01
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		kind := "other"
+		var lifeMonths *int
+		var salvage int64
+		set := assetsAddFlags(&kind, &lifeMonths, &salvage)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) != 3 {
+			return fmt.Errorf("usage: money assets %s", cmd.Usage)
+		}
+
+		price, err := format.ParseCents(positional[1])
+		if err != nil {
+			return err
+		}
+		if price <= 0 {
+			return fmt.Errorf("purchase price must be positive")
+		}
+		if salvage < 0 || salvage > price {
+			return fmt.Errorf("salvage value must be between 0 and the purchase price")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			accountID, err := asset.NewService(db).CreateAsset(positional[0], database.Asset{
+				Kind:             kind,
+				PurchasePrice:    price,
+				PurchaseDate:     positional[2],
+				SalvageValue:     salvage,
+				UsefulLifeMonths: lifeMonths,
+			}, time.Now())
+			if err != nil {
+				return err
+			}
+
+			account, err := db.GetAccountByID(accountID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Created asset %s (%s), currently worth %s\n", positional[0], accountID, format.Currency(account.Balance, account.Currency))
+			return nil
+		})
+	},
+}
+
+var AssetsList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List assets with their values and depreciation",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			assets, err := asset.NewService(db).ListAssets()
+			if err != nil {
+				return err
+			}
+
+			if len(assets) == 0 {
+				fmt.Println("No assets. Add one with 'money assets add <name> <purchase-price> <purchase-date>'.")
+				return nil
+			}
+
+			t := table.New("Account ID", "Name", "Kind", "Purchased", "Price", "Value", "Depreciation")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnCurrency)
+			for _, a := range assets {
+				account, err := db.GetAccountByID(a.AccountID)
+				if err != nil {
+					return err
+				}
+
+				depreciation := "manual"
+				if a.UsefulLifeMonths != nil {
+					depreciation = fmt.Sprintf("%d months to %s", *a.UsefulLifeMonths, format.Currency(a.SalvageValue, account.Currency))
+				}
+
+				t.AddRow(
+					a.AccountID,
+					account.DisplayName(),
+					a.Kind,
+					a.PurchaseDate,
+					format.Currency(a.PurchasePrice, account.Currency),
+					format.Currency(account.Balance, account.Currency),
+					depreciation,
+				)
+			}
+			return t.Render()
+		})
+	},
+}
+
+func assetsDepreciateFlags(salvage *int64) *flags.Set {
+	set := flags.New("money assets depreciate")
+	set.Positional("<account-id>", "<months>")
+	set.Func("salvage", "", "<amount>", centsFlag(salvage))
+	return set
+}
+
+var AssetsDepreciate = &Z.Cmd{
+	Name:     "depreciate",
+	Summary:  "Schedule straight-line depreciation for an asset",
+	Usage:    "depreciate " + assetsDepreciateFlags(new(int64)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var salvage int64
+		set := assetsDepreciateFlags(&salvage)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) != 2 {
+			return fmt.Errorf("usage: money assets %s", cmd.Usage)
+		}
+
+		months, err := strconv.Atoi(positional[1])
+		if err != nil {
+			return fmt.Errorf("months must be a whole number")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			value, err := asset.NewService(db).SetDepreciation(positional[0], months, salvage, time.Now())
+			if err != nil {
+				return err
+			}
+			account, err := db.GetAccountByID(positional[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s now depreciates over %d months to %s, currently worth %s\n",
+				positional[0], months, format.Currency(salvage, account.Currency), format.Currency(value, account.Currency))
+			return nil
+		})
+	},
+}
+
+var AssetsSetValue = &Z.Cmd{
+	Name:     "set-value",
+	Summary:  "Set an asset's value by hand, turning off its depreciation",
+	Usage:    "set-value <account-id> <value>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money assets %s", cmd.Usage)
+		}
+
+		value, err := format.ParseCents(args[1])
+		if err != nil {
+			return err
+		}
+		if value < 0 {
+			return fmt.Errorf("asset value cannot be negative")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			service := asset.NewService(db)
+			before, err := service.GetAsset(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := service.SetValue(args[0], value); err != nil {
+				return err
+			}
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Set %s to %s\n", args[0], format.Currency(value, account.Currency))
+			if before.UsefulLifeMonths != nil {
+				fmt.Println("Its scheduled depreciation was turned off; restart it with 'money assets depreciate'.")
+			}
+			return nil
+		})
+	},
+}
+
+var AssetsUpdate = &Z.Cmd{
+	Name:     "update",
+	Summary:  "Apply scheduled depreciation to every asset",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			updates, err := asset.NewService(db).UpdateAllValues(time.Now())
+			if err != nil {
+				return err
+			}
+
+			if len(updates) == 0 {
+				fmt.Println("All asset values are up to date.")
+				return nil
+			}
+			currencies, err := accountCurrencies(db)
+			if err != nil {
+				return err
+			}
+			for _, update := range updates {
+				currency := currencies[update.Asset.AccountID]
+				fmt.Printf("%s: %s -> %s\n", update.Asset.AccountID,
+					format.Currency(update.OldValue, currency), format.Currency(update.NewValue, currency))
+			}
+			return nil
+		})
+	},
+}
@@ -2,6 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -10,33 +14,91 @@ import (
 	"github.com/guptarohit/asciigraph"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
+	"golang.org/x/term"
 
 	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/chart"
 	"github.com/arjungandhi/money/pkg/database"
 	"github.com/arjungandhi/money/pkg/format"
 	"github.com/arjungandhi/money/pkg/property"
+	"github.com/arjungandhi/money/pkg/report"
 	"github.com/arjungandhi/money/pkg/table"
 )
 
+// balanceOptions holds the flags of money balance
+type balanceOptions struct {
+	days       int
+	accountID  string
+	types      string
+	group      string
+	exportPath string
+}
+
+func balanceFlags(opts *balanceOptions) *flags.Set {
+	set := flags.New("money balance")
+	set.IntVar(&opts.days, "days", "d", "<number>", 1)
+	set.StringVar(&opts.accountID, "account", "a", "<account-id>")
+	set.StringVar(&opts.types, "type", "t", "<type,...>")
+	set.StringVar(&opts.group, "group", "g", "cash|non-cash")
+	set.StringVar(&opts.exportPath, "export", "o", "<file.png|file.svg>")
+	return set
+}
+
 var Balance = &Z.Cmd{
 	Name:     "balance",
 	Aliases:  []string{"bal", "b"},
 	Summary:  "Show current balance of all accounts and net worth with trending graph",
-	Usage:    "[--days|-d <number>]",
+	Usage:    balanceFlags(&balanceOptions{}).Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Shows each account's balance with cash, non-cash and net worth trend
+graphs over the last --days days (30 by default). With --account, shows a
+single account's trend and stats instead.
+
+--type limits the table and graphs to accounts of the given types (for
+example checking,savings), and --group to the types a graph adds up:
+cash (checking, savings, credit) or non-cash (investment, crypto,
+property, loan, other). Given both, only types in both are shown.
+
+Unfiltered, it also shows how many months of expenses checking and
+savings would cover, spending averaged over the last 6 complete months,
+and warns when that's below the emergency_fund_months setting (6 by
+default, 0 to not warn).
+
+Accounts whose bank hasn't reported a new balance in stale_account_days
+(7 by default, 0 to not warn) are listed after the table: SimpleFIN keeps
+returning the last balance it got when a bank connection breaks, so fix
+the connection at SimpleFIN Bridge.
+
+--export saves the same trends as a PNG or SVG image, picked by the
+file's extension, for sharing or dropping into notes.
+`,
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		// Parse days flag (default 30)
-		days := 30
-		for i, arg := range args {
-			if (arg == "--days" || arg == "-d") && i+1 < len(args) {
-				if parsedDays, err := strconv.Atoi(args[i+1]); err == nil && parsedDays > 0 {
-					days = parsedDays
-				}
-				break
-			}
+		opts := balanceOptions{days: 30}
+		if err := balanceFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		days, accountID, exportPath := opts.days, opts.accountID, opts.exportPath
+
+		typeFilter, err := accountTypeFilter(opts.types, opts.group)
+		if err != nil {
+			return err
 		}
 
 		return dbutil.WithDatabase(func(db *database.DB) error {
+			if accountID != "" {
+				if err := displayAccountTrend(db, accountID, days); err != nil {
+					return err
+				}
+				if exportPath != "" {
+					if err := exportAccountTrend(db, accountID, days, exportPath); err != nil {
+						return err
+					}
+					fmt.Printf("\nSaved chart to %s\n", exportPath)
+				}
+				return nil
+			}
 
 			// Get all accounts
 			accounts, err := db.GetAccounts()
@@ -44,11 +106,25 @@ var Balance = &Z.Cmd{
 				return fmt.Errorf("failed to get accounts: %w", err)
 			}
 
+			owners := db.Owners()
+			if len(accounts) == 0 && len(owners) > 0 {
+				fmt.Printf("No accounts belong to %s. Set owners with 'money accounts owner set'.\n", strings.Join(owners, ", "))
+				return nil
+			}
 			if len(accounts) == 0 {
 				fmt.Println("No accounts found. Run 'money fetch' to sync your financial data.")
 				return nil
 			}
 
+			filtered := typeFilter != nil
+			if filtered {
+				accounts = filterAccountsByType(accounts, typeFilter)
+				if len(accounts) == 0 {
+					fmt.Println("No accounts match the given types.")
+					return nil
+				}
+			}
+
 			// Get all organizations
 			orgs, err := db.GetOrganizations()
 			if err != nil {
@@ -76,7 +152,7 @@ var Balance = &Z.Cmd{
 			}
 
 			// Define account type order (unset at the end)
-			typeOrder := []string{"checking", "savings", "credit", "investment", "loan", "property", "other", "unset"}
+			typeOrder := []string{"checking", "savings", "credit", "investment", "crypto", "loan", "property", "other", "unset"}
 			var accountTypes []string
 
 			// Add types in preferred order if they exist
@@ -87,10 +163,10 @@ var Balance = &Z.Cmd{
 			}
 
 			// Display balance trend graph first
-			err = displayBalanceTrends(db, accounts, days)
+			err = displayBalanceTrends(db, accounts, days, filtered)
 			if err != nil {
 				// Don't fail the command if graph generation fails, just log a warning
-				fmt.Printf("Warning: could not generate balance trend graph: %v\n", err)
+				slog.Warn("could not generate balance trend graph", "err", err)
 			}
 
 			// Add spacing between graph and account balances table
@@ -98,10 +174,23 @@ var Balance = &Z.Cmd{
 
 			// Create account balances table
 			config := table.DefaultConfig()
-			config.Title = "💰 Account Balances"
+			config.Title = icon("💰 ") + "Account Balances"
+			if len(owners) > 0 {
+				config.Title += " for " + strings.Join(owners, ", ")
+			}
 			config.MaxColumnWidth = 30
 
-			balancesTable := table.NewWithConfig(config, "Account", "Institution", "Balance")
+			balancesTable := table.NewWithConfig(config, "Account", "Institution", "Balance", fmt.Sprintf("%d-Day Trend", days))
+			balancesTable.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			balancesTable.SetRowStyle(negativeAmounts(2))
+
+			// Sparklines are a nicety, so leave the column blank if history can't be read
+			sparklines := make(map[string]string)
+			if history, err := db.GetAllBalanceHistory(days); err == nil {
+				sparklines = accountSparklines(history)
+			} else {
+				slog.Warn("could not get balance history for sparklines", "err", err)
+			}
 
 			// Initialize property service for property account details
 			propertyService := property.NewService(db)
@@ -131,17 +220,25 @@ var Balance = &Z.Cmd{
 					}
 				}
 
-				accountDisplayName := fmt.Sprintf("%s %s", typeIcon, displayName)
-				balancesTable.AddRow(accountDisplayName, institutionName, balanceStr)
-				totalNetWorth += int64(account.Balance)
+				accountDisplayName := icon(typeIcon+" ") + displayName
+				balancesTable.AddRow(accountDisplayName, institutionName, balanceStr, sparklines[account.ID])
+				totalNetWorth += account.Balance
+			}
+			totalLabel := "Net Worth"
+			if filtered {
+				totalLabel = "Total"
 			}
+			balancesTable.SetFooter(totalLabel, "", format.Currency(totalNetWorth, format.BaseCurrency()))
 
 			if err := balancesTable.Render(); err != nil {
 				return fmt.Errorf("failed to render balances table: %w", err)
 			}
+			if err := displayStaleAccounts(db, accounts); err != nil {
+				slog.Warn("could not check for stale accounts", "err", err)
+			}
 
 			// Show totals by account type
-			fmt.Println("\n📊 Summary by Type")
+			fmt.Println("\n" + icon("📊 ") + "Summary by Type")
 			fmt.Println(strings.Repeat("─", 50))
 
 			// Calculate totals by account type
@@ -153,23 +250,25 @@ var Balance = &Z.Cmd{
 				if account.AccountType != nil {
 					accountType = *account.AccountType
 				}
-				accountTypeTotals[accountType] += int64(account.Balance)
+				accountTypeTotals[accountType] += account.Balance
 				accountTypeCounts[accountType]++
 			}
 
 			// Create summary table
 			summaryTable := table.New("Type", "Total", "Accounts")
+			summaryTable.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnNumber)
+			summaryTable.SetRowStyle(negativeAmounts(1))
 
 			// Display totals in the same order as main table
 			for _, accountType := range typeOrder {
 				if total, exists := accountTypeTotals[accountType]; exists {
 					typeIcon := getTypeIcon(accountType)
 					count := accountTypeCounts[accountType]
-					totalStr := format.Currency(int(total), "USD")
+					totalStr := format.Currency(total, format.BaseCurrency())
 
 					// Use consistent formatting for account type names
 					accountTypeName := strings.Title(accountType)
-					displayName := fmt.Sprintf("%s %s", typeIcon, accountTypeName)
+					displayName := icon(typeIcon+" ") + accountTypeName
 
 					summaryTable.AddRow(displayName, totalStr, fmt.Sprintf("%d", count))
 				}
@@ -179,11 +278,40 @@ var Balance = &Z.Cmd{
 				return fmt.Errorf("failed to render summary table: %w", err)
 			}
 
+			// Coverage counts every checking and savings account, so it
+			// only makes sense for the full view
+			if !filtered {
+				fmt.Println()
+				if err := displayEmergencyFund(db); err != nil {
+					slog.Warn("could not measure emergency fund", "err", err)
+				}
+			}
+
+			if exportPath != "" {
+				if err := exportBalanceTrends(db, accounts, days, filtered, exportPath); err != nil {
+					return err
+				}
+				fmt.Printf("\nSaved chart to %s\n", exportPath)
+			}
+
 			return nil
 		})
 	},
 }
 
+// accountTypeColors are the trend graph colors for each account type
+var accountTypeColors = map[string]asciigraph.AnsiColor{
+	"checking":   asciigraph.Green,
+	"savings":    asciigraph.Blue,
+	"investment": asciigraph.Magenta,
+	"crypto":     asciigraph.Gold,
+	"credit":     asciigraph.Red,
+	"loan":       asciigraph.Yellow,
+	"property":   asciigraph.White,
+	"other":      asciigraph.Cyan,
+	"unset":      asciigraph.Default,
+}
+
 // getTypeIcon returns the appropriate emoji for the account type
 func getTypeIcon(accountType string) string {
 	switch accountType {
@@ -195,6 +323,8 @@ func getTypeIcon(accountType string) string {
 		return "💳"
 	case "investment":
 		return "📊"
+	case "crypto":
+		return "🪙"
 	case "loan":
 		return "💸"
 	case "property":
@@ -219,6 +349,8 @@ func getTypeDisplayName(accountType string) string {
 		return "Credit Accounts"
 	case "investment":
 		return "Investment Accounts"
+	case "crypto":
+		return "Crypto Accounts"
 	case "loan":
 		return "Loan Accounts"
 	case "property":
@@ -232,20 +364,10 @@ func getTypeDisplayName(accountType string) string {
 	}
 }
 
-// displayBalanceTrends shows an ASCII graph of balance trends over time grouped by account type
-func displayBalanceTrends(db *database.DB, accounts []database.Account, days int) error {
-
-	// Get all balance history for the period
-	history, err := db.GetAllBalanceHistory(days)
-	if err != nil {
-		return fmt.Errorf("failed to get balance history: %w", err)
-	}
-
-	if len(history) == 0 {
-		fmt.Println("No historical balance data available. Run 'money fetch' to start collecting balance trends.")
-		return nil
-	}
-
+// dailyBalancesByType groups balance history by account type and day, using
+// the latest recorded balance per account per day. It returns the sorted list
+// of dates seen along with the per-type daily totals in cents.
+func dailyBalancesByType(history []database.BalanceHistory, accounts []database.Account) ([]string, map[string]map[string]int64) {
 	// Create account type lookup map
 	accountTypeMap := make(map[string]string)
 	for _, account := range accounts {
@@ -263,16 +385,10 @@ func displayBalanceTrends(db *database.DB, accounts []database.Account, days int
 
 	// First, get the latest balance per account per day
 	for _, bh := range history {
-		// Parse the recorded_at timestamp and format as date
-		recordedTime, err := time.Parse("2006-01-02 15:04:05", bh.RecordedAt)
-		if err != nil {
-			// Try alternative format
-			recordedTime, err = time.Parse(time.RFC3339, bh.RecordedAt)
-			if err != nil {
-				continue // Skip this entry if we can't parse the date
-			}
+		dateStr, ok := recordedDate(bh.RecordedAt)
+		if !ok {
+			continue // Skip this entry if we can't parse the date
 		}
-		dateStr := recordedTime.Format("2006-01-02")
 
 		if accountDailyBalances[bh.AccountID] == nil {
 			accountDailyBalances[bh.AccountID] = make(map[string]int64)
@@ -280,7 +396,7 @@ func displayBalanceTrends(db *database.DB, accounts []database.Account, days int
 
 		// Store the balance - since history is ordered by recorded_at ASC,
 		// later entries will overwrite earlier ones, giving us the latest balance for each day
-		accountDailyBalances[bh.AccountID][dateStr] = int64(bh.Balance)
+		accountDailyBalances[bh.AccountID][dateStr] = bh.Balance
 		dateSet[dateStr] = true
 	}
 
@@ -307,29 +423,437 @@ func displayBalanceTrends(db *database.DB, accounts []database.Account, days int
 	}
 	sort.Strings(dates)
 
+	return dates, typeHistoryMap
+}
+
+// recordedDate returns the day a balance history timestamp, stored in UTC,
+// falls on in the configured time zone
+func recordedDate(recordedAt string) (string, bool) {
+	recordedTime, err := time.Parse("2006-01-02 15:04:05", recordedAt)
+	if err != nil {
+		// Try alternative format
+		recordedTime, err = time.Parse(time.RFC3339, recordedAt)
+		if err != nil {
+			return "", false
+		}
+	}
+	return recordedTime.In(format.Location()).Format("2006-01-02"), true
+}
+
+// dailyAccountBalances returns the days a single account's history covers,
+// oldest first, with its latest balance on each in cents
+func dailyAccountBalances(history []database.BalanceHistory) ([]string, []int64) {
+	var dates []string
+	var balances []int64
+	for _, bh := range history {
+		date, ok := recordedDate(bh.RecordedAt)
+		if !ok {
+			continue
+		}
+		// History is ordered by recorded_at ASC, so a later entry on the
+		// same day replaces the earlier one
+		if n := len(dates); n > 0 && dates[n-1] == date {
+			balances[n-1] = bh.Balance
+			continue
+		}
+		dates = append(dates, date)
+		balances = append(balances, bh.Balance)
+	}
+	return dates, balances
+}
+
+// sparklineWidth is the most characters a balances table sparkline spans
+const sparklineWidth = 20
+
+// sparkTicks are the block characters a sparkline steps through, lowest first
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a row of block characters scaled between their
+// lowest and highest, one per value. Series longer than width are sampled
+// evenly, keeping the first and last values.
+func sparkline(values []int64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		sampled := make([]int64, width)
+		for i := range sampled {
+			index := 0
+			if width > 1 {
+				index = i * (len(values) - 1) / (width - 1)
+			}
+			sampled[i] = values[index]
+		}
+		values = sampled
+	}
+
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = min(low, value)
+		high = max(high, value)
+	}
+
+	var b strings.Builder
+	for _, value := range values {
+		level := 0
+		if high > low {
+			level = int(float64(value-low) / float64(high-low) * float64(len(sparkTicks)-1))
+		}
+		b.WriteRune(sparkTicks[level])
+	}
+	return b.String()
+}
+
+// accountSparklines draws a sparkline of each account's daily balances in
+// history, keyed by account ID
+func accountSparklines(history []database.BalanceHistory) map[string]string {
+	byAccount := make(map[string][]database.BalanceHistory)
+	for _, bh := range history {
+		byAccount[bh.AccountID] = append(byAccount[bh.AccountID], bh)
+	}
+
+	sparklines := make(map[string]string, len(byAccount))
+	for accountID, accountHistory := range byAccount {
+		_, balances := dailyAccountBalances(accountHistory)
+		sparklines[accountID] = sparkline(balances, sparklineWidth)
+	}
+	return sparklines
+}
+
+// balanceStats summarizes an account's daily balances over a period, in cents
+type balanceStats struct {
+	Start    int64
+	End      int64
+	High     int64
+	HighDate string
+	Low      int64
+	LowDate  string
+	Average  int64
+}
+
+// summarizeBalances computes the stats of a non-empty daily balance series.
+// Ties for the high or low go to the earliest day.
+func summarizeBalances(dates []string, balances []int64) balanceStats {
+	stats := balanceStats{
+		Start:    balances[0],
+		End:      balances[len(balances)-1],
+		High:     balances[0],
+		HighDate: dates[0],
+		Low:      balances[0],
+		LowDate:  dates[0],
+	}
+	var total int64
+	for i, balance := range balances {
+		total += balance
+		if balance > stats.High {
+			stats.High = balance
+			stats.HighDate = dates[i]
+		}
+		if balance < stats.Low {
+			stats.Low = balance
+			stats.LowDate = dates[i]
+		}
+	}
+	stats.Average = total / int64(len(balances))
+	return stats
+}
+
+// displayAccountTrend shows one account's balance history chart and summary
+// stats for the last days days
+func displayAccountTrend(db *database.DB, accountID string, days int) error {
+	account, err := db.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	history, err := db.GetBalanceHistory(account.ID, days)
+	if err != nil {
+		return err
+	}
+
+	accountType := "unset"
+	if account.AccountType != nil {
+		accountType = *account.AccountType
+	}
+	fmt.Printf("%s%s (Last %d Days)\n", icon(getTypeIcon(accountType)+" "), account.DisplayName(), days)
+
+	dates, balances := dailyAccountBalances(history)
+	if len(dates) == 0 {
+		fmt.Printf("No balance history in the last %d days. Run 'money fetch' to start collecting balance trends.\n", days)
+		fmt.Printf("Current balance: %s\n", format.Currency(account.Balance, account.Currency))
+		return nil
+	}
+
+	series := make([]float64, len(balances))
+	for i, balance := range balances {
+		series[i] = float64(balance) / 100.0
+	}
+	color, exists := accountTypeColors[accountType]
+	if !exists {
+		color = asciigraph.Default
+	}
+	displaySingleChart("Balance", series, dates, color, account.DisplayName(), days)
+	fmt.Println()
+
+	stats := summarizeBalances(dates, balances)
+	change := stats.End - stats.Start
+	changeStr := format.Currency(change, account.Currency)
+	if stats.Start != 0 {
+		changeStr += fmt.Sprintf(" (%+.1f%%)", float64(change)/math.Abs(float64(stats.Start))*100)
+	}
+
+	config := table.DefaultConfig()
+	config.Title = icon("📊 ") + "Summary"
+	config.ShowHeaders = false
+	t := table.NewWithConfig(config, "Stat", "Value")
+	t.AddRow("Current Balance", format.Currency(account.Balance, account.Currency))
+	t.AddRow("Start ("+dates[0]+")", format.Currency(stats.Start, account.Currency))
+	t.AddRow("Change", colorizeAmount(change, changeStr))
+	t.AddRow("High ("+stats.HighDate+")", format.Currency(stats.High, account.Currency))
+	t.AddRow("Low ("+stats.LowDate+")", format.Currency(stats.Low, account.Currency))
+	t.AddRow("Daily Average", format.Currency(stats.Average, account.Currency))
+	t.AddRow("Days Recorded", strconv.Itoa(len(dates)))
+	return t.Render()
+}
+
+// exportAccountTrend saves one account's balance trend for the last days
+// days as a PNG or SVG image at path
+func exportAccountTrend(db *database.DB, accountID string, days int, path string) error {
+	account, err := db.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	history, err := db.GetBalanceHistory(account.ID, days)
+	if err != nil {
+		return err
+	}
+
+	dates, balances := dailyAccountBalances(history)
 	if len(dates) < 2 {
-		fmt.Println("Not enough historical data points to generate a meaningful trend graph.")
+		return fmt.Errorf("not enough balance history to chart %s; run 'money fetch' to start collecting balance trends", account.DisplayName())
+	}
+
+	series := make([]float64, len(balances))
+	for i, balance := range balances {
+		series[i] = float64(balance) / 100.0
+	}
+	return chart.Save(path, &chart.Line{
+		Title:  fmt.Sprintf("%s (Last %d Days)", account.DisplayName(), days),
+		Dates:  dates,
+		Series: []chart.Series{{Name: account.DisplayName(), Values: series}},
+	})
+}
+
+// netWorthSeries sums the per-type daily totals into a single net worth
+// series in dollars, carrying each type's last known balance forward across
+// days without data.
+func netWorthSeries(dates []string, typeHistoryMap map[string]map[string]int64) []float64 {
+	lastKnown := make(map[string]int64)
+	series := make([]float64, 0, len(dates))
+	for _, date := range dates {
+		var total int64
+		for accountType, typeHistory := range typeHistoryMap {
+			if balance, exists := typeHistory[date]; exists {
+				lastKnown[accountType] = balance
+			}
+			total += lastKnown[accountType]
+		}
+		series = append(series, float64(total)/100.0)
+	}
+	return series
+}
+
+// balanceTrends are the daily cash, non-cash and net worth totals in
+// dollars, one value per day in dates
+type balanceTrends struct {
+	dates       []string
+	activeTypes []string // account types with data, in chart order
+	nonCash     []float64
+	cash        []float64
+	netWorth    []float64
+}
+
+var (
+	cashAccountTypes = map[string]bool{
+		"checking": true,
+		"savings":  true,
+		"credit":   true,
+	}
+
+	nonCashAccountTypes = map[string]bool{
+		"investment": true,
+		"crypto":     true,
+		"property":   true,
+		"loan":       true,
+		"other":      true,
+	}
+
+	// accountGroups are the groups of account types --group accepts, the
+	// same ones the cash and non-cash graphs add up
+	accountGroups = map[string]map[string]bool{
+		"cash":     cashAccountTypes,
+		"non-cash": nonCashAccountTypes,
+	}
+)
+
+// accountTypeFilter turns the comma-separated types and group name given to
+// balance into the set of account types to show, or nil to show them all.
+// Given both, only types in both are kept.
+func accountTypeFilter(types, group string) (map[string]bool, error) {
+	var filter map[string]bool
+
+	if types != "" {
+		validTypes := []string{"checking", "savings", "credit", "investment", "crypto", "loan", "property", "other", "unset"}
+		filter = make(map[string]bool)
+		for _, accountType := range strings.Split(types, ",") {
+			accountType = strings.ToLower(strings.TrimSpace(accountType))
+			if !slices.Contains(validTypes, accountType) {
+				return nil, fmt.Errorf("invalid account type: %s. Valid types are: %v", accountType, validTypes)
+			}
+			filter[accountType] = true
+		}
+	}
+
+	if group != "" {
+		groupTypes, exists := accountGroups[strings.ToLower(group)]
+		if !exists {
+			return nil, fmt.Errorf("unknown account group %q, use cash or non-cash", group)
+		}
+		if filter == nil {
+			filter = make(map[string]bool)
+			for accountType := range groupTypes {
+				filter[accountType] = true
+			}
+		} else {
+			for accountType := range filter {
+				if !groupTypes[accountType] {
+					delete(filter, accountType)
+				}
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// filterAccountsByType keeps the accounts whose type is in filter, treating
+// accounts without a type as "unset"
+func filterAccountsByType(accounts []database.Account, filter map[string]bool) []database.Account {
+	var kept []database.Account
+	for _, account := range accounts {
+		accountType := "unset"
+		if account.AccountType != nil {
+			accountType = *account.AccountType
+		}
+		if filter[accountType] {
+			kept = append(kept, account)
+		}
+	}
+	return kept
+}
+
+// displayStaleAccounts warns about the shown accounts that haven't updated
+// in stale_account_days, which usually means their bank connection needs
+// fixing at SimpleFIN Bridge
+func displayStaleAccounts(db *database.DB, accounts []database.Account) error {
+	days := db.GetConfig().StaleAccountDays
+	if days <= 0 {
+		return nil
+	}
+	now := time.Now()
+	stale, err := db.GetStaleAccounts(now.AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+
+	shown := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		shown[account.ID] = true
+	}
+	var lines []string
+	for _, account := range stale {
+		if shown[account.ID] {
+			lines = append(lines, staleAccountSummary(account, now))
+		}
+	}
+	if len(lines) == 0 {
 		return nil
 	}
 
+	noun := "accounts haven't"
+	if len(lines) == 1 {
+		noun = "account hasn't"
+	}
+	redColor.Printf("\n%s%d %s updated in %d days or more; the bank connection may need fixing at SimpleFIN Bridge:\n", icon("⚠ "), len(lines), noun, days)
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
+
+// staleAccountSummary says when a stale account last updated, like
+// "Everyday Checking, last updated 2024-03-01 (12 days ago)"
+func staleAccountSummary(account database.StaleAccount, now time.Time) string {
+	days := int(now.Sub(account.LastUpdate).Hours() / 24)
+	return fmt.Sprintf("%s, last updated %s (%d days ago)", account.DisplayName(),
+		account.LastUpdate.In(format.Location()).Format("2006-01-02"), days)
+}
+
+// displayEmergencyFund shows how many months of expenses checking and
+// savings cover, warning when it's below the configured target
+func displayEmergencyFund(db *database.DB) error {
+	fund, err := report.BuildEmergencyFund(db, format.Now(), db.GetConfig().EmergencyFundMonths)
+	if err != nil {
+		return err
+	}
+
+	config := table.DefaultConfig()
+	config.Title = icon("🛟 ") + "Emergency Fund"
+	config.ShowHeaders = false
+	fundTable := table.NewWithConfig(config, "", "")
+	fundTable.AddRow("Cash (checking and savings)", format.Currency(fund.Cash, format.BaseCurrency()))
+	fundTable.AddRow(fmt.Sprintf("Monthly expenses (%d-month average)", report.EmergencyFundHistoryMonths), format.Currency(fund.MonthlyExpenses, format.BaseCurrency()))
+	coverage := fund.Summary()
+	if fund.Target > 0 && fund.MonthlyExpenses > 0 {
+		if fund.BelowTarget() {
+			coverage = redColor.Sprint(coverage)
+		} else {
+			coverage = greenColor.Sprint(coverage)
+		}
+	}
+	fundTable.AddRow("Covers", coverage)
+	if err := fundTable.Render(); err != nil {
+		return fmt.Errorf("failed to render emergency fund table: %w", err)
+	}
+
+	if fund.BelowTarget() {
+		redColor.Printf("%sBelow your %d-month target: %s short\n", icon("⚠ "), fund.Target, format.Currency(fund.Shortfall(), format.BaseCurrency()))
+	}
+	return nil
+}
+
+// balanceTrendSeries sums the balance history of accounts into cash,
+// non-cash and net worth series, carrying each account type's last known
+// balance across days without data. History for other accounts is ignored.
+func balanceTrendSeries(history []database.BalanceHistory, accounts []database.Account) balanceTrends {
+	accountIDs := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		accountIDs[account.ID] = true
+	}
+	var accountHistory []database.BalanceHistory
+	for _, bh := range history {
+		if accountIDs[bh.AccountID] {
+			accountHistory = append(accountHistory, bh)
+		}
+	}
+
+	dates, typeHistoryMap := dailyBalancesByType(accountHistory, accounts)
+	trends := balanceTrends{dates: dates}
+
 	// Create multi-line graph with different series for each account type
-	typeOrder := []string{"checking", "savings", "investment", "credit", "loan", "property", "other", "unset"}
+	typeOrder := []string{"checking", "savings", "investment", "crypto", "credit", "loan", "property", "other", "unset"}
 	var allSeries [][]float64
-	var seriesLabels []string
-	var seriesColors []asciigraph.AnsiColor
-	var activeTypes []string // Track which types actually have data
-
-	// Define colors for each account type
-	colorMap := map[string]asciigraph.AnsiColor{
-		"checking":   asciigraph.Green,
-		"savings":    asciigraph.Blue,
-		"investment": asciigraph.Magenta,
-		"credit":     asciigraph.Red,
-		"loan":       asciigraph.Yellow,
-		"property":   asciigraph.White,
-		"other":      asciigraph.Cyan,
-		"unset":      asciigraph.Default,
-	}
 
 	// Prepare data series for each account type
 	for _, accountType := range typeOrder {
@@ -357,150 +881,125 @@ func displayBalanceTrends(db *database.DB, accounts []database.Account, days int
 		}
 
 		allSeries = append(allSeries, values)
-		activeTypes = append(activeTypes, accountType) // Track active types
-		typeDisplayName := getTypeDisplayName(accountType)
-		seriesLabels = append(seriesLabels, typeDisplayName)
+		trends.activeTypes = append(trends.activeTypes, accountType) // Track active types
+	}
 
-		if color, exists := colorMap[accountType]; exists {
-			seriesColors = append(seriesColors, color)
-		} else {
-			seriesColors = append(seriesColors, asciigraph.Default)
+	for dateIdx := range dates {
+		var dailyNonCashSum, dailyCashSum, dailyNetWorth float64
+		for i, accountType := range trends.activeTypes {
+			if nonCashAccountTypes[accountType] {
+				dailyNonCashSum += allSeries[i][dateIdx]
+			}
+			if cashAccountTypes[accountType] {
+				dailyCashSum += allSeries[i][dateIdx]
+			}
+			dailyNetWorth += allSeries[i][dateIdx]
 		}
+		trends.nonCash = append(trends.nonCash, dailyNonCashSum)
+		trends.cash = append(trends.cash, dailyCashSum)
+		trends.netWorth = append(trends.netWorth, dailyNetWorth)
 	}
 
-	// Create three separate charts: Non-Cash, Cash, and Net Worth
-	fmt.Printf("📊 Trends (Last %d Days)\n", days)
+	return trends
+}
 
-	// Define account categories
-	cashAccountTypes := map[string]bool{
-		"checking": true,
-		"savings":  true,
-		"credit":   true,
-	}
+// displayBalanceTrends shows an ASCII graph of balance trends over time grouped by account type.
+// When accounts are filtered, graphs for groups without any of their types are
+// left out and net worth is shown as the total of the accounts.
+func displayBalanceTrends(db *database.DB, accounts []database.Account, days int, filtered bool) error {
 
-	nonCashAccountTypes := map[string]bool{
-		"investment": true,
-		"property":   true,
-		"loan":       true,
-		"other":      true,
+	// Get all balance history for the period
+	history, err := db.GetAllBalanceHistory(days)
+	if err != nil {
+		return fmt.Errorf("failed to get balance history: %w", err)
 	}
 
-	// 1. NON-CASH ACCOUNTS CHART (sum all non-cash account types)
-	var nonCashSumSeries []float64
-	for dateIdx := range dates {
-		var dailyNonCashSum float64
-		for i, accountType := range activeTypes {
-			if nonCashAccountTypes[accountType] && dateIdx < len(allSeries[i]) {
-				dailyNonCashSum += allSeries[i][dateIdx]
-			}
-		}
-		nonCashSumSeries = append(nonCashSumSeries, dailyNonCashSum)
+	if len(history) == 0 {
+		fmt.Println("No historical balance data available. Run 'money fetch' to start collecting balance trends.")
+		return nil
 	}
 
-	if len(nonCashSumSeries) > 0 {
-		displaySingleChart("💰 Non-Cash", nonCashSumSeries, asciigraph.Blue, days)
+	trends := balanceTrendSeries(history, accounts)
+	dates := trends.dates
+
+	if len(dates) < 2 {
+		fmt.Println("Not enough historical data points to generate a meaningful trend graph.")
+		return nil
 	}
 
-	// 2. CASH ACCOUNTS CHART (sum all cash account types)
-	var cashSumSeries []float64
-	for dateIdx := range dates {
-		var dailyCashSum float64
-		for i, accountType := range activeTypes {
-			if cashAccountTypes[accountType] && dateIdx < len(allSeries[i]) {
-				dailyCashSum += allSeries[i][dateIdx]
-			}
-		}
-		cashSumSeries = append(cashSumSeries, dailyCashSum)
+	// Create three separate charts: Non-Cash, Cash, and Net Worth
+	fmt.Printf("%sTrends (Last %d Days)\n", icon("📊 "), days)
+
+	// 1. NON-CASH ACCOUNTS CHART (sum all non-cash account types)
+	if !filtered || hasTypeIn(trends.activeTypes, nonCashAccountTypes) {
+		displaySingleChart(icon("💰 ")+"Non-Cash", trends.nonCash, dates, asciigraph.Blue, typesLegend(trends.activeTypes, nonCashAccountTypes), days)
 	}
 
-	if len(cashSumSeries) > 0 {
-		displaySingleChart("💵 Cash", cashSumSeries, asciigraph.Green, days)
+	// 2. CASH ACCOUNTS CHART (sum all cash account types)
+	if !filtered || hasTypeIn(trends.activeTypes, cashAccountTypes) {
+		displaySingleChart(icon("💵 ")+"Cash", trends.cash, dates, asciigraph.Green, typesLegend(trends.activeTypes, cashAccountTypes), days)
 	}
 
 	// 3. NET WORTH CHART
-	// Calculate daily net worth across all account types
-	var netWorthSeries []float64
-	for dateIdx := range dates {
-		var dailyNetWorth float64
-		for _, series := range allSeries {
-			if dateIdx < len(series) {
-				dailyNetWorth += series[dateIdx]
-			}
-		}
-		netWorthSeries = append(netWorthSeries, dailyNetWorth)
+	// Check for meaningful variation in net worth
+	title, legend := "Net Worth", "All accounts"
+	if filtered {
+		title, legend = "Total", "Shown accounts"
+	}
+	minNetWorth, maxNetWorth := seriesRange(trends.netWorth)
+	if maxNetWorth-minNetWorth > 10.0 {
+		currentNetWorth := format.Currency(int64(trends.netWorth[len(trends.netWorth)-1]*100), format.BaseCurrency())
+		fmt.Printf("\n%s%s: %s%s\n", icon("🏆 "), title, currentNetWorth, trendSummary(trends.netWorth))
+		fmt.Println(plotTrend(trends.netWorth, dates, asciigraph.Green, legend))
 	}
 
-	if len(netWorthSeries) > 1 {
-		// Check for meaningful variation in net worth
-		minNetWorth := netWorthSeries[0]
-		maxNetWorth := netWorthSeries[0]
-		for _, val := range netWorthSeries {
-			if val < minNetWorth {
-				minNetWorth = val
-			}
-			if val > maxNetWorth {
-				maxNetWorth = val
-			}
-		}
-
-		variation := maxNetWorth - minNetWorth
-		if variation > 10.0 {
-			// Show net worth trend summary
-			netWorthChange := netWorthSeries[len(netWorthSeries)-1] - netWorthSeries[0]
-			netWorthChangePercent := 0.0
-			if netWorthSeries[0] != 0 {
-				netWorthChangePercent = (netWorthChange / netWorthSeries[0]) * 100
-			}
-
-			var trend string
-			if netWorthChange > 0 {
-				trend = fmt.Sprintf(" (↑ $%s, +%.1f%%)", format.WithCommas(int64(netWorthChange)), netWorthChangePercent)
-			} else if netWorthChange < 0 {
-				trend = fmt.Sprintf(" (↓ $%s, %.1f%%)", format.WithCommas(int64(-netWorthChange)), netWorthChangePercent)
-			} else {
-				trend = " (→ No change)"
-			}
+	return nil
+}
 
-			currentNetWorth := format.Currency(int(netWorthSeries[len(netWorthSeries)-1]*100), "USD")
-			fmt.Printf("\n🏆 Net Worth: %s%s\n", currentNetWorth, trend)
+// exportBalanceTrends saves the cash, non-cash and net worth trends for the
+// last days days as a PNG or SVG image at path, leaving out the same graphs
+// displayBalanceTrends does when accounts are filtered
+func exportBalanceTrends(db *database.DB, accounts []database.Account, days int, filtered bool, path string) error {
+	history, err := db.GetAllBalanceHistory(days)
+	if err != nil {
+		return fmt.Errorf("failed to get balance history: %w", err)
+	}
 
-			// Use tight bounds for net worth graph that don't start from 0
-			padding := variation * 0.05 // 5% padding on each side
-			lowerBound := minNetWorth - padding
-			upperBound := maxNetWorth + padding
+	trends := balanceTrendSeries(history, accounts)
+	if len(trends.dates) < 2 {
+		return fmt.Errorf("not enough balance history to chart; run 'money fetch' to start collecting balance trends")
+	}
 
-			netWorthGraph := asciigraph.Plot(netWorthSeries,
-				asciigraph.Height(8),
-				asciigraph.Width(70),
-				asciigraph.LowerBound(lowerBound),
-				asciigraph.UpperBound(upperBound),
-				asciigraph.SeriesColors(asciigraph.Green))
-			fmt.Println(netWorthGraph)
-		}
+	var series []chart.Series
+	if !filtered || hasTypeIn(trends.activeTypes, nonCashAccountTypes) {
+		series = append(series, chart.Series{Name: "Non-Cash", Values: trends.nonCash, Color: chart.Blue})
+	}
+	if !filtered || hasTypeIn(trends.activeTypes, cashAccountTypes) {
+		series = append(series, chart.Series{Name: "Cash", Values: trends.cash, Color: chart.Green})
 	}
+	total := "Net Worth"
+	if filtered {
+		total = "Total"
+	}
+	series = append(series, chart.Series{Name: total, Values: trends.netWorth, Color: chart.Gold})
 
-	return nil
+	return chart.Save(path, &chart.Line{
+		Title:  fmt.Sprintf("Balances (Last %d Days)", days),
+		Dates:  trends.dates,
+		Series: series,
+	})
 }
 
-// displaySingleChart shows a chart for a single summed category
-func displaySingleChart(title string, series []float64, color asciigraph.AnsiColor, days int) {
+// displaySingleChart shows a chart for a single summed category, one value
+// per day in dates, with legend naming what it adds up
+func displaySingleChart(title string, series []float64, dates []string, color asciigraph.AnsiColor, legend string, days int) {
 	if len(series) <= 1 {
 		fmt.Printf("\n%s:\n  Not enough data points\n", title)
 		return
 	}
 
 	// Check for meaningful variation
-	minVal := series[0]
-	maxVal := series[0]
-	for _, val := range series {
-		if val < minVal {
-			minVal = val
-		}
-		if val > maxVal {
-			maxVal = val
-		}
-	}
-
+	minVal, maxVal := seriesRange(series)
 	variation := maxVal - minVal
 	relativeVariation := 0.0
 	if minVal != 0 {
@@ -512,36 +1011,196 @@ func displaySingleChart(title string, series []float64, color asciigraph.AnsiCol
 		return
 	}
 
-	// Show trend summary
+	// Include current total in title
+	currentTotal := format.Currency(int64(series[len(series)-1]*100), format.BaseCurrency())
+	fmt.Printf("\n%s: %s%s\n", title, currentTotal, trendSummary(series))
+	fmt.Println(plotTrend(series, dates, color, legend))
+}
+
+// trendSummary describes how a series changed from its first to last value
+func trendSummary(series []float64) string {
 	change := series[len(series)-1] - series[0]
 	changePercent := 0.0
 	if series[0] != 0 {
 		changePercent = (change / series[0]) * 100
 	}
 
-	var trend string
 	if change > 0 {
-		trend = fmt.Sprintf(" (↑ $%s, +%.1f%%)", format.WithCommas(int64(change)), changePercent)
+		return fmt.Sprintf(" (↑ $%s, +%.1f%%)", format.WithCommas(int64(change)), changePercent)
 	} else if change < 0 {
-		trend = fmt.Sprintf(" (↓ $%s, %.1f%%)", format.WithCommas(int64(-change)), changePercent)
-	} else {
-		trend = " (→ No change)"
+		return fmt.Sprintf(" (↓ $%s, %.1f%%)", format.WithCommas(int64(-change)), changePercent)
 	}
+	return " (→ No change)"
+}
 
-	// Include current total in title
-	currentTotal := format.Currency(int(series[len(series)-1]*100), "USD")
-	fmt.Printf("\n%s: %s%s\n", title, currentTotal, trend)
+// seriesRange returns the smallest and largest values of a non-empty series
+func seriesRange(series []float64) (float64, float64) {
+	minVal, maxVal := series[0], series[0]
+	for _, val := range series {
+		if val < minVal {
+			minVal = val
+		}
+		if val > maxVal {
+			maxVal = val
+		}
+	}
+	return minVal, maxVal
+}
+
+// hasTypeIn reports whether any of activeTypes is in group
+func hasTypeIn(activeTypes []string, group map[string]bool) bool {
+	for _, accountType := range activeTypes {
+		if group[accountType] {
+			return true
+		}
+	}
+	return false
+}
+
+// typesLegend names the account types in group that have data, in chart order
+func typesLegend(activeTypes []string, group map[string]bool) string {
+	var names []string
+	for _, accountType := range activeTypes {
+		if group[accountType] {
+			names = append(names, strings.Title(accountType))
+		}
+	}
+	if len(names) == 0 {
+		return "No accounts"
+	}
+	return strings.Join(names, " + ")
+}
+
+const (
+	// graphHeight is the number of rows a trend graph spans
+	graphHeight = 8
+	// graphOffset is asciigraph's default offset, the columns between the
+	// y-axis labels and the start of the plot
+	graphOffset = 3
+	// minGraphWidth keeps graphs readable in narrow terminals
+	minGraphWidth = 20
+	// defaultTerminalWidth is used when the width can't be detected, like
+	// when output is piped
+	defaultTerminalWidth = 80
+)
+
+// terminalWidth returns the width of the terminal stdout is attached to,
+// falling back to $COLUMNS and then defaultTerminalWidth
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultTerminalWidth
+}
+
+// axisLabelWidth returns how wide asciigraph draws the y-axis labels for a
+// graph between lower and upper: whole dollars once values pass 100,
+// otherwise cents
+func axisLabelWidth(lower, upper float64) int {
+	precision := 2
+	if math.Log10(math.Max(math.Abs(lower), math.Abs(upper))) > 2 {
+		precision = 0
+	}
+	return max(len(fmt.Sprintf("%.*f", precision, lower)), len(fmt.Sprintf("%.*f", precision, upper)))
+}
 
+// plotTrend draws a series, one value per day in dates, as wide as the
+// terminal allows, with the dates along the x-axis and a legend under it
+func plotTrend(series []float64, dates []string, color asciigraph.AnsiColor, legend string) string {
 	// Use tight bounds that don't start from 0
-	padding := variation * 0.05 // 5% padding on each side
+	minVal, maxVal := seriesRange(series)
+	padding := (maxVal - minVal) * 0.05 // 5% padding on each side
 	lowerBound := minVal - padding
 	upperBound := maxVal + padding
 
+	// The plot starts after the labels and offset; leave the last column
+	// free so lines don't wrap
+	leftPad := graphOffset + axisLabelWidth(lowerBound, upperBound)
+	width := terminalWidth() - leftPad - 1
+	if width < minGraphWidth {
+		width = minGraphWidth
+	}
+
 	graph := asciigraph.Plot(series,
-		asciigraph.Height(8),
-		asciigraph.Width(70),
+		asciigraph.Height(graphHeight),
+		asciigraph.Width(width),
+		asciigraph.Offset(graphOffset),
 		asciigraph.LowerBound(lowerBound),
 		asciigraph.UpperBound(upperBound),
-		asciigraph.SeriesColors(color))
-	fmt.Println(graph)
+		graphColors(color))
+
+	var b strings.Builder
+	b.WriteString(graph)
+	b.WriteString("\n")
+	b.WriteString(dateAxis(dates, leftPad, width))
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat(" ", leftPad))
+	b.WriteString(legendKey(color) + " " + legend)
+	return b.String()
+}
+
+// dateAxis draws an x-axis under a graph width columns wide starting at
+// column leftPad, with ticks and labels for as many of dates as fit. The
+// first and last dates are always labeled, as month and day when they're in
+// the same year.
+func dateAxis(dates []string, leftPad, width int) string {
+	if len(dates) == 0 {
+		return labelAxis(nil, leftPad, width)
+	}
+
+	sameYear := dates[0][:4] == dates[len(dates)-1][:4]
+	labels := make([]string, len(dates))
+	for i, date := range dates {
+		labels[i] = date
+		if parsed, err := time.Parse("2006-01-02", date); err == nil && sameYear {
+			labels[i] = parsed.Format("Jan 2")
+		}
+	}
+	return labelAxis(labels, leftPad, width)
+}
+
+// labelAxis draws an x-axis under a graph width columns wide starting at
+// column leftPad, with ticks and labels for as many of labels, one per
+// point, as fit. The first and last are always labeled.
+func labelAxis(labels []string, leftPad, width int) string {
+	if len(labels) == 0 {
+		return strings.Repeat(" ", leftPad-1) + "└" + strings.Repeat("─", width)
+	}
+
+	labelWidth := 0
+	for _, label := range labels {
+		labelWidth = max(labelWidth, len([]rune(label)))
+	}
+
+	// Space ticks so there are at least two spaces between labels, even
+	// next to the first and last, which sit flush with the ends of the axis
+	// rather than centered on their ticks
+	count := min(len(labels), max(2, (width-1)/(labelWidth*3/2+2)+1))
+
+	ticks := []rune(strings.Repeat("─", width))
+	text := []rune(strings.Repeat(" ", width))
+	lastEnd := -1
+	for k := 0; k < count; k++ {
+		var column, index int
+		if count > 1 {
+			column = int(math.Round(float64(k*(width-1)) / float64(count-1)))
+			index = int(math.Round(float64(k*(len(labels)-1)) / float64(count-1)))
+		}
+		label := []rune(labels[index])
+
+		// Center labels on their tick, keeping them inside the axis
+		start := max(0, min(column-len(label)/2, width-len(label)))
+		if start <= lastEnd || start+len(label) > width {
+			continue
+		}
+		ticks[column] = '┬'
+		copy(text[start:], label)
+		lastEnd = start + len(label)
+	}
+
+	return strings.Repeat(" ", leftPad-1) + "└" + string(ticks) + "\n" +
+		strings.Repeat(" ", leftPad) + strings.TrimRight(string(text), " ")
 }
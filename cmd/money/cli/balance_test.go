@@ -1,7 +1,16 @@
 package cli
 
 import (
+	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
 )
 
 func TestGetTypeIcon(t *testing.T) {
@@ -53,3 +62,271 @@ func TestGetTypeDisplayName(t *testing.T) {
 		})
 	}
 }
+
+func TestNetWorthSeries(t *testing.T) {
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-03"}
+	typeHistoryMap := map[string]map[string]int64{
+		"checking": {"2024-01-01": 10000, "2024-01-03": 15000},
+		"credit":   {"2024-01-02": -2500},
+	}
+
+	series := netWorthSeries(dates, typeHistoryMap)
+	expected := []float64{100, 75, 125}
+
+	if len(series) != len(expected) {
+		t.Fatalf("netWorthSeries returned %d points; want %d", len(series), len(expected))
+	}
+	for i := range expected {
+		if series[i] != expected[i] {
+			t.Errorf("netWorthSeries()[%d] = %.2f; want %.2f", i, series[i], expected[i])
+		}
+	}
+}
+
+func TestBalanceTrendSeries(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	checking, credit, investment := "checking", "credit", "investment"
+	accounts := []database.Account{
+		{ID: "chk", AccountType: &checking},
+		{ID: "cc", AccountType: &credit},
+		{ID: "inv", AccountType: &investment},
+	}
+	history := []database.BalanceHistory{
+		{AccountID: "chk", Balance: 10000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "inv", Balance: 50000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "cc", Balance: -2500, RecordedAt: "2024-01-02 08:00:00"},
+		{AccountID: "chk", Balance: 15000, RecordedAt: "2024-01-03 08:00:00"},
+	}
+
+	trends := balanceTrendSeries(history, accounts)
+
+	expectedTypes := []string{"checking", "investment", "credit"}
+	if strings.Join(trends.activeTypes, ",") != strings.Join(expectedTypes, ",") {
+		t.Errorf("activeTypes = %v; want %v", trends.activeTypes, expectedTypes)
+	}
+
+	expected := map[string][2][]float64{
+		"cash":      {trends.cash, {100, 75, 125}},
+		"non-cash":  {trends.nonCash, {500, 500, 500}},
+		"net worth": {trends.netWorth, {600, 575, 625}},
+	}
+	for name, pair := range expected {
+		got, want := pair[0], pair[1]
+		if len(got) != len(want) {
+			t.Errorf("%s series = %v; want %v", name, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s series = %v; want %v", name, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestDailyAccountBalances(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	history := []database.BalanceHistory{
+		{AccountID: "acc-1", Balance: 10000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "acc-1", Balance: 12000, RecordedAt: "2024-01-01 20:00:00"},
+		{AccountID: "acc-1", Balance: 9000, RecordedAt: "2024-01-03T09:00:00Z"},
+		{AccountID: "acc-1", Balance: 1, RecordedAt: "not a time"},
+	}
+
+	dates, balances := dailyAccountBalances(history)
+	expectedDates := []string{"2024-01-01", "2024-01-03"}
+	expectedBalances := []int64{12000, 9000}
+	if len(dates) != len(expectedDates) || len(balances) != len(expectedBalances) {
+		t.Fatalf("dailyAccountBalances() = %v, %v; want %v, %v", dates, balances, expectedDates, expectedBalances)
+	}
+	for i := range expectedDates {
+		if dates[i] != expectedDates[i] || balances[i] != expectedBalances[i] {
+			t.Errorf("day %d = %s %d; want %s %d", i, dates[i], balances[i], expectedDates[i], expectedBalances[i])
+		}
+	}
+}
+
+func TestSummarizeBalances(t *testing.T) {
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"}
+	balances := []int64{10000, 25000, -5000, 25000}
+
+	stats := summarizeBalances(dates, balances)
+	expected := balanceStats{
+		Start:    10000,
+		End:      25000,
+		High:     25000,
+		HighDate: "2024-01-02",
+		Low:      -5000,
+		LowDate:  "2024-01-03",
+		Average:  13750,
+	}
+	if stats != expected {
+		t.Errorf("summarizeBalances() = %+v; want %+v", stats, expected)
+	}
+}
+
+func TestDateAxis(t *testing.T) {
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05"}
+
+	axis := dateAxis(dates, 4, 30)
+	lines := strings.Split(axis, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("dateAxis() returned %d lines; want 2", len(lines))
+	}
+	if want := "   └" + strings.Repeat("─", 30); len([]rune(lines[0])) != len([]rune(want)) {
+		t.Errorf("axis line is %d columns; want %d", len([]rune(lines[0])), len([]rune(want)))
+	}
+	if !strings.HasPrefix(lines[1], "    Jan 1") {
+		t.Errorf("first label = %q; want it under the first column", lines[1])
+	}
+	if !strings.HasSuffix(lines[1], "Jan 5") || len(lines[1]) != 4+30 {
+		t.Errorf("last label = %q; want it ending at the last column", lines[1])
+	}
+	if ticks := strings.Count(lines[0], "┬"); ticks != strings.Count(lines[1], "Jan") {
+		t.Errorf("got %d ticks for %d labels", ticks, strings.Count(lines[1], "Jan"))
+	}
+
+	// Dates spanning years keep the year, and narrow graphs label just the ends
+	axis = dateAxis([]string{"2023-12-31", "2024-01-01", "2024-01-02"}, 4, 24)
+	labels := strings.Fields(strings.Split(axis, "\n")[1])
+	if len(labels) != 2 || labels[0] != "2023-12-31" || labels[1] != "2024-01-02" {
+		t.Errorf("labels = %v; want the first and last full dates", labels)
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		t.Skip("stdout is a terminal, so its width wins over $COLUMNS")
+	}
+
+	t.Setenv("COLUMNS", "120")
+	if width := terminalWidth(); width != 120 {
+		t.Errorf("terminalWidth() = %d; want 120 from $COLUMNS", width)
+	}
+
+	t.Setenv("COLUMNS", "")
+	if width := terminalWidth(); width != defaultTerminalWidth {
+		t.Errorf("terminalWidth() = %d; want the default %d", width, defaultTerminalWidth)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []int64
+		width    int
+		expected string
+	}{
+		{"empty", nil, 20, ""},
+		{"flat", []int64{500, 500, 500}, 20, "▁▁▁"},
+		{"rising", []int64{0, 100, 200, 300, 400, 500, 600, 700}, 20, "▁▂▃▄▅▆▇█"},
+		{"negative", []int64{-700, 0, -350}, 20, "▁█▄"},
+		{"sampled", []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 4, "▁▃▅█"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := sparkline(tt.values, tt.width); result != tt.expected {
+				t.Errorf("sparkline(%v, %d) = %q; want %q", tt.values, tt.width, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAccountSparklines(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	history := []database.BalanceHistory{
+		{AccountID: "chk", Balance: 10000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "sav", Balance: 50000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "chk", Balance: 5000, RecordedAt: "2024-01-02 08:00:00"},
+		{AccountID: "chk", Balance: 20000, RecordedAt: "2024-01-02 20:00:00"},
+	}
+
+	sparklines := accountSparklines(history)
+	if sparklines["chk"] != "▁█" {
+		t.Errorf("chk sparkline = %q; want %q", sparklines["chk"], "▁█")
+	}
+	if sparklines["sav"] != "▁" {
+		t.Errorf("sav sparkline = %q; want %q", sparklines["sav"], "▁")
+	}
+}
+
+func TestAccountTypeFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		types    string
+		group    string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "no filter", expected: nil},
+		{name: "types", types: "Checking, savings", expected: []string{"checking", "savings"}},
+		{name: "group", group: "cash", expected: []string{"checking", "credit", "savings"}},
+		{name: "types and group", types: "checking,investment", group: "non-cash", expected: []string{"investment"}},
+		{name: "unset", types: "unset", expected: []string{"unset"}},
+		{name: "invalid type", types: "checking,brokerage", wantErr: true},
+		{name: "invalid group", group: "liquid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := accountTypeFilter(tt.types, tt.group)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.expected == nil {
+				if filter != nil {
+					t.Errorf("Expected no filter, got %v", filter)
+				}
+				return
+			}
+
+			var got []string
+			for accountType := range filter {
+				got = append(got, accountType)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("accountTypeFilter(%q, %q) = %v; want %v", tt.types, tt.group, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterAccountsByType(t *testing.T) {
+	checking, investment := "checking", "investment"
+	accounts := []database.Account{
+		{ID: "chk", AccountType: &checking},
+		{ID: "inv", AccountType: &investment},
+		{ID: "new"},
+	}
+
+	kept := filterAccountsByType(accounts, map[string]bool{"checking": true, "unset": true})
+	if len(kept) != 2 || kept[0].ID != "chk" || kept[1].ID != "new" {
+		t.Errorf("Expected checking and unset accounts, got %+v", kept)
+	}
+
+	// History for accounts that were filtered out doesn't count toward the trends
+	history := []database.BalanceHistory{
+		{AccountID: "chk", Balance: 10000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "inv", Balance: 50000, RecordedAt: "2024-01-01 08:00:00"},
+		{AccountID: "chk", Balance: 15000, RecordedAt: "2024-01-02 08:00:00"},
+	}
+	trends := balanceTrendSeries(history, kept[:1])
+	if len(trends.netWorth) != 2 || trends.netWorth[0] != 100 || trends.netWorth[1] != 150 {
+		t.Errorf("Expected only checking in the total, got %v", trends.netWorth)
+	}
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/alerts"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/ical"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Bills = &Z.Cmd{
+	Name:    "bills",
+	Aliases: []string{"bill"},
+	Summary: "Manage recurring monthly bills",
+	Description: `
+Bills are recurring monthly payments with a due day. Bills paid from a
+specific account are checked against that account's available balance;
+bills without an account are checked against the combined balance of all
+checking accounts. See 'money alerts'. 'money bills ical' puts them on a
+calendar.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		BillsAdd,
+		BillsList,
+		BillsRemove,
+		BillsICal,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return BillsList.Call(cmd, args...)
+	},
+}
+
+func billsAddFlags(accountID *string) *flags.Set {
+	set := flags.New("money bills add")
+	set.Positional("<name>", "<amount>", "<due-day>")
+	set.StringVar(accountID, "account", "", "<account-id>")
+	return set
+}
+
+var BillsAdd = &Z.Cmd{
+	Name:     "add",
+	Summary:  "Add a recurring monthly bill",
+	Usage:    "add " + billsAddFlags(new(string)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var accountID string
+		set := billsAddFlags(&accountID)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) != 3 {
+			return fmt.Errorf("usage: money bills %s", cmd.Usage)
+		}
+
+		amount, err := format.ParseCents(positional[1])
+		if err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return fmt.Errorf("bill amount must be positive")
+		}
+
+		dueDay, err := strconv.Atoi(positional[2])
+		if err != nil || dueDay < 1 || dueDay > 31 {
+			return fmt.Errorf("due day must be a day of the month between 1 and 31")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			currency := format.BaseCurrency()
+			if accountID != "" {
+				account, err := db.GetAccountByID(accountID)
+				if err != nil {
+					return err
+				}
+				currency = account.Currency
+			}
+
+			id, err := db.SaveBill(positional[0], amount, dueDay, accountID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Saved bill %d: %s, %s due on day %d\n", id, positional[0], format.Currency(amount, currency), dueDay)
+			return nil
+		})
+	},
+}
+
+var BillsList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List bills by due day",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			bills, err := db.GetBills()
+			if err != nil {
+				return err
+			}
+
+			if len(bills) == 0 {
+				fmt.Println("No bills. Add one with 'money bills add <name> <amount> <due-day>'.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			accountNames := make(map[string]string)
+			currencies := make(map[string]string)
+			for _, account := range accounts {
+				accountNames[account.ID] = account.DisplayName()
+				currencies[account.ID] = account.Currency
+			}
+
+			now := time.Now()
+			t := table.New("ID", "Name", "Amount", "Due Day", "Next Due", "Account")
+			t.SetColumnTypes(table.ColumnNumber, table.ColumnText, table.ColumnCurrency, table.ColumnNumber)
+			for _, bill := range bills {
+				accountDisplay := "Checking"
+				if bill.AccountID != "" {
+					accountDisplay = bill.AccountID
+					if name, exists := accountNames[bill.AccountID]; exists {
+						accountDisplay = name
+					}
+				}
+
+				t.AddRow(
+					strconv.Itoa(bill.ID),
+					bill.Name,
+					format.Currency(bill.Amount, currencies[bill.AccountID]),
+					strconv.Itoa(bill.DueDay),
+					alerts.NextDueDate(bill.DueDay, now).Format("2006-01-02"),
+					accountDisplay,
+				)
+			}
+			t.AddTotals("Total")
+			return t.Render()
+		})
+	},
+}
+
+var BillsRemove = &Z.Cmd{
+	Name:     "remove",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove a bill",
+	Usage:    "remove <bill-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money bills remove <bill-id>")
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid bill ID: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.DeleteBill(id); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed bill %d\n", id)
+			return nil
+		})
+	},
+}
+
+func billsICalFlags(opts *ical.Options) *flags.Set {
+	set := flags.New("money bills ical")
+	set.IntVar(&opts.PaydayMonths, "months", "m", "N", 1)
+	return set
+}
+
+var BillsICal = &Z.Cmd{
+	Name:     "ical",
+	Aliases:  []string{"ics"},
+	Summary:  "Export bills, statement due dates and paydays as a calendar",
+	Usage:    "ical " + billsICalFlags(&ical.Options{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Writes an iCalendar file to standard output with:
+
+  - each bill, repeating monthly on its due day
+  - each credit card statement due date set with
+    'money accounts statement-due set', likewise
+  - the paydays your recent paychecks point to, over the next --months
+    months (3 by default)
+
+Bills due on a day some months don't have fall on the last day of those
+months. Paydays are estimates, so export the calendar again after a fetch
+to keep them current.
+
+To see it on your phone, write the file somewhere your calendar app can
+subscribe to it, like a synced folder or a web server, and regenerate it on
+a schedule, for example from cron after 'money fetch'. Calendar apps are
+asked to check it for changes twice a day.
+
+Examples:
+  money bills ical > bills.ics
+  money bills ical --months 6 > ~/Sync/bills.ics
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts ical.Options
+		if err := billsICalFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			calendar, err := ical.Build(db, opts)
+			if err != nil {
+				return err
+			}
+			return calendar.Write(os.Stdout)
+		})
+	},
+}
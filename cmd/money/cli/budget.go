@@ -12,68 +12,148 @@ import (
 	"github.com/rwxrob/help"
 
 	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/chart"
 	"github.com/arjungandhi/money/pkg/database"
 	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/report"
 	"github.com/arjungandhi/money/pkg/table"
 )
 
+// budgetOptions holds the flags of money budget
+type budgetOptions struct {
+	days         int
+	incomeOnly   bool
+	expensesOnly bool
+	startDate    string
+	endDate      string
+	sortBy       string
+	exportPath   string
+	incomeMode   string
+}
+
+func budgetFlags(opts *budgetOptions) *flags.Set {
+	set := flags.New("money budget")
+	set.IntVar(&opts.days, "days", "d", "<number>", 1)
+	set.BoolVar(&opts.incomeOnly, "income-only", "")
+	set.BoolVar(&opts.expensesOnly, "expenses-only", "")
+	set.Func("start", "s", "YYYY-MM-DD", dateFlag(&opts.startDate))
+	set.Func("end", "e", "YYYY-MM-DD", dateFlag(&opts.endDate))
+	set.Func("month", "m", "YYYY-MM", func(value string) error {
+		month, err := time.Parse("2006-01", value)
+		if err != nil {
+			return fmt.Errorf("use YYYY-MM")
+		}
+		opts.startDate = month.Format("2006-01-02")
+		opts.endDate = month.AddDate(0, 1, -1).Format("2006-01-02")
+		return nil
+	})
+	set.Func("sort", "", "amount|category", func(value string) error {
+		if value != "amount" && value != "category" {
+			return fmt.Errorf("use amount or category")
+		}
+		opts.sortBy = value
+		return nil
+	})
+	set.StringVar(&opts.exportPath, "export", "o", "<file.png|file.svg>")
+	set.Func("income-mode", "", "actual|3-month|6-month", func(value string) error {
+		if _, err := budgetIncomeMonths(value); err != nil {
+			return fmt.Errorf("use actual, 3-month or 6-month")
+		}
+		opts.incomeMode = value
+		return nil
+	})
+	return set
+}
+
+// budgetIncomeMonths returns how many months an income mode averages over,
+// or 0 for the actual income of the period
+func budgetIncomeMonths(mode string) (int, error) {
+	switch mode {
+	case "actual":
+		return 0, nil
+	case "3-month":
+		return 3, nil
+	case "6-month":
+		return 6, nil
+	default:
+		return 0, fmt.Errorf("unknown income mode %q: use actual, 3-month or 6-month", mode)
+	}
+}
+
+// calendarMonth returns the month a budget period covers when it's exactly
+// one calendar month
+func calendarMonth(startDate, endDate string) (time.Time, bool) {
+	start, err := time.ParseInLocation("2006-01-02", startDate, format.Location())
+	if err != nil || start.Day() != 1 {
+		return time.Time{}, false
+	}
+	return start, start.AddDate(0, 1, -1).Format("2006-01-02") == endDate
+}
+
+// dateFlag returns a flag setter that stores a YYYY-MM-DD date in p
+func dateFlag(p *string) func(string) error {
+	return func(value string) error {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("use YYYY-MM-DD")
+		}
+		*p = value
+		return nil
+	}
+}
+
 var Budget = &Z.Cmd{
-	Name:     "budget",
-	Summary:  "Show comprehensive budget view with income, expenses, and net cash flow by category",
-	Usage:    "[--days|-d <number>] [--income-only] [--expenses-only] [--start YYYY-MM-DD] [--end YYYY-MM-DD] [--month YYYY-MM]",
-	Commands: []*Z.Cmd{help.Cmd},
+	Name:    "budget",
+	Summary: "Show comprehensive budget view with income, expenses, and net cash flow by category",
+	Usage:   budgetFlags(&budgetOptions{}).Usage(),
+	Description: `
+Shows income and expenses by category for a period, this month unless
+--days, --start/--end or --month is given, and the net cash flow between
+them. Internal categories, like transfers between your own accounts, are
+left out.
+
+With variable income, a month's deposits say little about what it can
+afford. --income-mode 3-month or 6-month (or the budget_income_mode
+setting) plans a calendar month against the average monthly income of the
+3 or 6 months before it instead; months without deposits count toward the
+average. Other periods always use their actual income.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		BudgetSet,
+		BudgetClear,
+		BudgetTargets,
+		BudgetCategory,
+		BudgetTUI,
+	},
 	Call: func(cmd *Z.Cmd, args ...string) error {
+		opts := budgetOptions{sortBy: "amount"}
+		if err := budgetFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		startDate, endDate, exportPath := opts.startDate, opts.endDate, opts.exportPath
+		incomeOnly, expensesOnly := opts.incomeOnly, opts.expensesOnly
+		days, sortBy := opts.days, opts.sortBy
+
 		return dbutil.WithDatabase(func(db *database.DB) error {
-			// Parse flags
-			var startDate, endDate string
-			var incomeOnly, expensesOnly bool
-			days := 0
-
-			for i, arg := range args {
-				switch arg {
-				case "--income-only":
-					incomeOnly = true
-				case "--expenses-only":
-					expensesOnly = true
-				case "--days", "-d":
-					if i+1 < len(args) {
-						if parsedDays, err := strconv.Atoi(args[i+1]); err == nil && parsedDays > 0 {
-							days = parsedDays
-						}
-					}
-				case "--start", "-s":
-					if i+1 < len(args) {
-						startDate = args[i+1]
-					}
-				case "--end", "-e":
-					if i+1 < len(args) {
-						endDate = args[i+1]
-					}
-				case "--month", "-m":
-					if i+1 < len(args) {
-						monthStr := args[i+1]
-						if monthTime, err := time.Parse("2006-01", monthStr); err == nil {
-							startDate = monthTime.Format("2006-01-02")
-							endDate = monthTime.AddDate(0, 1, -1).Format("2006-01-02")
-						}
-					}
-				}
+			if err := loadCategoryStyles(db); err != nil {
+				return err
 			}
 
 			// Handle --days flag (overrides other date options)
 			if days > 0 {
-				now := time.Now()
+				now := format.Now()
 				endDate = now.Format("2006-01-02")
 				startDate = now.AddDate(0, 0, -days).Format("2006-01-02")
 			} else if startDate == "" && endDate == "" {
 				// Default to current month if no date range specified
-				now := time.Now()
+				now := format.Now()
 				startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02")
 				endDate = time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location()).Format("2006-01-02")
 			} else if startDate != "" && endDate == "" {
-				endDate = time.Now().Format("2006-01-02")
+				endDate = format.Now().Format("2006-01-02")
 			} else if startDate == "" && endDate != "" {
-				now := time.Now()
+				now := format.Now()
 				startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02")
 			}
 
@@ -96,10 +176,10 @@ var Budget = &Z.Cmd{
 				for _, t := range transactions {
 					if t.Amount > 0 {
 						// Positive amounts are income
-						incomeTotal += int64(t.Amount)
+						incomeTotal += t.Amount
 					} else if t.Amount < 0 {
 						// Negative amounts are expenses (make positive for display)
-						expenseTotal += int64(-t.Amount)
+						expenseTotal += -t.Amount
 					}
 				}
 
@@ -113,6 +193,35 @@ var Budget = &Z.Cmd{
 				}
 			}
 
+			// Plan a month against average income, when asked to
+			incomeTitle := icon("💰 ") + "Income"
+			incomeLabel := "Total Income"
+			incomeMode := opts.incomeMode
+			if incomeMode == "" {
+				incomeMode = db.GetConfig().BudgetIncomeMode
+			}
+			averageMonths, err := budgetIncomeMonths(incomeMode)
+			if err != nil {
+				return fmt.Errorf("invalid budget_income_mode setting: %w", err)
+			}
+			var actualIncome int64
+			if month, ok := calendarMonth(startDate, endDate); ok && days == 0 && averageMonths > 0 {
+				averages, err := report.AverageIncome(db, month, averageMonths)
+				if err != nil {
+					return err
+				}
+				actualIncome = totalIncome
+				categoryIncome, totalIncome = averages, 0
+				for _, amount := range averages {
+					totalIncome += amount
+				}
+				incomeTitle += fmt.Sprintf(", %d-Month Average", averageMonths)
+				incomeLabel = fmt.Sprintf("Average Income (%d months)", averageMonths)
+			} else if averageMonths > 0 {
+				averageMonths = 0
+				hintf("Income averaging only applies to calendar months; showing actual income.\n")
+			}
+
 			// Display results
 			if len(categoryIncome) == 0 && len(categoryExpenses) == 0 {
 				fmt.Printf("No transactions found for period %s to %s\n", startDate, endDate)
@@ -123,12 +232,15 @@ var Budget = &Z.Cmd{
 
 			// Show Income section (unless expenses-only)
 			if !expensesOnly && len(categoryIncome) > 0 {
-				displayBudgetSection("💰 Income", categoryIncome, totalIncome, periodLabel)
+				displayBudgetSection(incomeTitle, categoryIncome, totalIncome, periodLabel, sortBy)
+				if averageMonths > 0 {
+					hintf("Averaged over the %d months before; %s actually deposited this month.\n\n", averageMonths, format.Currency(actualIncome, format.BaseCurrency()))
+				}
 			}
 
 			// Show Expenses section (unless income-only)
 			if !incomeOnly && len(categoryExpenses) > 0 {
-				displayBudgetSection("💸 Expenses", categoryExpenses, totalExpenses, periodLabel)
+				displayBudgetSection(icon("💸 ")+"Expenses", categoryExpenses, totalExpenses, periodLabel, sortBy)
 			}
 
 			// Show Net Cash Flow summary (unless showing only one section)
@@ -142,27 +254,27 @@ var Budget = &Z.Cmd{
 					flowIcon = "📈"
 					flowLabel = "Net Cash Flow"
 					green := color.New(color.FgGreen).SprintFunc()
-					cashFlowDisplay = green(fmt.Sprintf("+%s", format.Currency(int(netCashFlow), "USD")))
+					cashFlowDisplay = green(fmt.Sprintf("+%s", format.Currency(netCashFlow, format.BaseCurrency())))
 				} else if netCashFlow < 0 {
 					flowIcon = "📉"
 					flowLabel = "Net Cash Flow"
 					red := color.New(color.FgRed).SprintFunc()
-					cashFlowDisplay = red(format.Currency(int(netCashFlow), "USD"))
+					cashFlowDisplay = red(format.Currency(netCashFlow, format.BaseCurrency()))
 				} else {
 					flowIcon = "⚖️"
 					flowLabel = "Net Cash Flow"
-					cashFlowDisplay = format.Currency(int(netCashFlow), "USD")
+					cashFlowDisplay = format.Currency(netCashFlow, format.BaseCurrency())
 				}
 
 				config := table.DefaultConfig()
-				config.Title = fmt.Sprintf("📊 Net Cash Flow (%s)", periodLabel)
+				config.Title = fmt.Sprintf("%sNet Cash Flow (%s)", icon("📊 "), periodLabel)
 				config.ShowHeaders = false
 
 				cashFlowTable := table.NewWithConfig(config, "", "")
-				cashFlowTable.AddRow("Total Income", format.Currency(int(totalIncome), "USD"))
-				cashFlowTable.AddRow("Total Expenses", format.Currency(int(totalExpenses), "USD"))
-				cashFlowTable.AddRow("────────────", "──────────────")
-				cashFlowTable.AddRow(fmt.Sprintf("%s %s", flowIcon, flowLabel), cashFlowDisplay)
+				cashFlowTable.SetColumnTypes(table.ColumnText, table.ColumnCurrency)
+				cashFlowTable.AddRow(incomeLabel, format.Currency(totalIncome, format.BaseCurrency()))
+				cashFlowTable.AddRow("Total Expenses", format.Currency(totalExpenses, format.BaseCurrency()))
+				cashFlowTable.SetFooter(icon(flowIcon+" ")+flowLabel, cashFlowDisplay)
 
 				if err := cashFlowTable.Render(); err != nil {
 					fmt.Printf("Error rendering cash flow table: %v\n", err)
@@ -170,50 +282,334 @@ var Budget = &Z.Cmd{
 				}
 			}
 
+			if exportPath != "" {
+				var bars []chart.Bar
+				if !expensesOnly {
+					bars = append(bars, budgetBars(categoryIncome, true)...)
+				}
+				if !incomeOnly {
+					bars = append(bars, budgetBars(categoryExpenses, false)...)
+				}
+				title := fmt.Sprintf("Income and Expenses (%s)", periodLabel)
+				if incomeOnly {
+					title = fmt.Sprintf("Income (%s)", periodLabel)
+				} else if expensesOnly {
+					title = fmt.Sprintf("Expenses (%s)", periodLabel)
+				}
+
+				if err := chart.Save(exportPath, &chart.Bars{Title: title, Bars: bars}); err != nil {
+					return err
+				}
+				fmt.Printf("\nSaved chart to %s\n", exportPath)
+			}
+
+			return nil
+		})
+	},
+}
+
+var BudgetSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Set the monthly budget target for a category",
+	Usage:    "<category> <amount>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money budget set <category> <amount>")
+		}
+
+		categoryName := args[0]
+		amount, err := format.ParseCents(args[1])
+		if err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return fmt.Errorf("budget amount must be positive")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetBudget(category.ID, amount); err != nil {
+				return err
+			}
+
+			fmt.Printf("Set monthly budget for '%s' to %s\n", category.Name, format.Currency(amount, format.BaseCurrency()))
+			return nil
+		})
+	},
+}
+
+var BudgetClear = &Z.Cmd{
+	Name:     "clear",
+	Summary:  "Remove the monthly budget target for a category",
+	Usage:    "<category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money budget clear <category>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := db.ClearBudget(category.ID); err != nil {
+				return err
+			}
+
+			fmt.Printf("Cleared monthly budget for '%s'\n", category.Name)
+			return nil
+		})
+	},
+}
+
+var BudgetTargets = &Z.Cmd{
+	Name:     "targets",
+	Summary:  "List monthly budget targets by category",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			budgets, err := db.GetBudgets()
+			if err != nil {
+				return fmt.Errorf("failed to get budgets: %w", err)
+			}
+
+			if len(budgets) == 0 {
+				fmt.Println("No budget targets set. Use 'money budget set <category> <amount>' to add one.")
+				return nil
+			}
+			categories, err := db.GetCategories()
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+			setCategoryStyles(categories)
+			internal := make(map[int]bool)
+			for _, category := range categories {
+				internal[category.ID] = category.IsInternal
+			}
+
+			config := table.DefaultConfig()
+			config.Title = icon("🎯 ") + "Monthly Budget Targets"
+
+			targetsTable := table.NewWithConfig(config, "Category", "Monthly Target")
+			targetsTable.SetColumnTypes(table.ColumnText, table.ColumnCurrency)
+			for _, b := range budgets {
+				name := b.CategoryName
+				if internal[b.CategoryID] {
+					name += " (internal)"
+				}
+				targetsTable.AddRow(colorizeCategory(name), format.Currency(b.Amount, format.BaseCurrency()))
+			}
+			targetsTable.AddTotals("Total")
+
+			if err := targetsTable.Render(); err != nil {
+				return fmt.Errorf("failed to render budget targets table: %w", err)
+			}
 			return nil
 		})
 	},
 }
 
-func displayBudgetSection(title string, categoryAmounts map[string]int64, total int64, periodLabel string) {
-	// Sort categories by amount (descending)
-	type categoryData struct {
-		name   string
-		amount int64
+func budgetCategoryFlags(month *time.Time) *flags.Set {
+	set := flags.New("money budget category")
+	set.Positional("<category>")
+	set.Func("month", "m", "YYYY-MM", func(value string) error {
+		parsed, err := time.ParseInLocation("2006-01", value, format.Location())
+		if err != nil {
+			return fmt.Errorf("use YYYY-MM")
+		}
+		*month = parsed
+		return nil
+	})
+	return set
+}
+
+var BudgetCategory = &Z.Cmd{
+	Name:     "category",
+	Aliases:  []string{"cat"},
+	Summary:  "Show one category's transactions for a month, with subtotals by merchant",
+	Usage:    "category " + budgetCategoryFlags(new(time.Time)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Lists the transactions in a category for a month (the current one unless
+--month is given), under a table of subtotals by merchant, largest first,
+to answer why a budget line is as big as it is. Merchants are grouped by
+description with processor prefixes and store and reference numbers
+removed, so every Amazon order adds up to one row.
+
+Amounts are shown the way the budget shows them: what was spent is
+positive and refunds are negative, or the reverse for an income category.
+Use Uncategorized for transactions without a category.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		now := format.Now()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, format.Location())
+		set := budgetCategoryFlags(&month)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) != 1 {
+			return fmt.Errorf("usage: money budget category %s", set.Usage())
+		}
+
+		startDate := month.Format("2006-01-02")
+		endDate := month.AddDate(0, 1, -1).Format("2006-01-02")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			detail, err := report.CategoryDrillDown(db, set.Args()[0], startDate, endDate)
+			if err != nil {
+				return err
+			}
+			if len(detail.Transactions) == 0 {
+				fmt.Printf("No %s transactions in %s\n", detail.Category, month.Format("January 2006"))
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			accountNames := make(map[string]string, len(accounts))
+			currencies := make(map[string]string, len(accounts))
+			for _, account := range accounts {
+				accountNames[account.ID] = account.DisplayName()
+				currencies[account.ID] = account.Currency
+			}
+
+			defer startPager()()
+			return displayCategoryDetail(detail, month.Format("January 2006"), accountNames, currencies)
+		})
+	},
+}
+
+// displayCategoryDetail prints a category's merchant subtotals and then its
+// transactions. Amounts are flipped for spending categories so spending is
+// positive, like in the budget, and transactions are shown in their
+// account's currency.
+func displayCategoryDetail(detail *report.CategoryDetail, period string, accountNames, currencies map[string]string) error {
+	sign, amountHeader := int64(1), "Earned"
+	if detail.Total < 0 {
+		sign, amountHeader = -1, "Spent"
 	}
 
-	var sortedCategories []categoryData
-	for name, amount := range categoryAmounts {
-		sortedCategories = append(sortedCategories, categoryData{name: name, amount: amount})
+	config := table.DefaultConfig()
+	config.Title = fmt.Sprintf("%s%s by Merchant (%s)", icon("🔍 "), detail.Category, period)
+	config.MaxColumnWidth = 40
+
+	merchants := table.NewWithConfig(config, "Merchant", "Transactions", amountHeader)
+	merchants.SetColumnTypes(table.ColumnText, table.ColumnNumber, table.ColumnCurrency)
+	merchants.SetRowStyle(negativeAmounts(2))
+	for _, m := range detail.Merchants {
+		merchants.AddRow(m.Merchant, strconv.Itoa(m.Count), format.Currency(sign*m.Total, format.BaseCurrency()))
+	}
+	merchants.AddTotals("Total")
+	if err := merchants.Render(); err != nil {
+		return fmt.Errorf("failed to render merchant table: %w", err)
 	}
+	fmt.Println()
 
-	sort.Slice(sortedCategories, func(i, j int) bool {
-		return sortedCategories[i].amount > sortedCategories[j].amount
-	})
+	config = table.DefaultConfig()
+	config.Title = fmt.Sprintf("%s Transactions (%s)", detail.Category, period)
+	config.MaxColumnWidth = 50
+
+	transactions := table.NewWithConfig(config, "Date", "Description", "Account", amountHeader)
+	transactions.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency)
+	transactions.SetRowStyle(negativeAmounts(3))
+	for _, txn := range detail.Transactions {
+		account := txn.AccountID
+		if name, ok := accountNames[txn.AccountID]; ok {
+			account = name
+		}
+		transactions.AddRow(format.PostedDate(txn.Posted), txn.DisplayDescription(), account, format.Currency(sign*txn.Amount, currencies[txn.AccountID]))
+	}
+	if err := transactions.Render(); err != nil {
+		return fmt.Errorf("failed to render transactions table: %w", err)
+	}
+	return nil
+}
 
+var BudgetTUI = &Z.Cmd{
+	Name:     "tui",
+	Aliases:  []string{"interactive", "i"},
+	Summary:  "Interactive budget review with category drill-down and recategorization",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Opens an interactive budget screen for the current month. Each category
+shows what was spent against its monthly target (set with 'money budget
+set'). Press enter to drill into a category's transactions, then e to
+recategorize or u to uncategorize the highlighted transaction without
+leaving the screen. Use p/n to move between months.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return runBudgetTUI()
+	},
+}
+
+// displayBudgetSection prints one section's categories, largest first or,
+// with sortBy "category", alphabetically
+func displayBudgetSection(title string, categoryAmounts map[string]int64, total int64, periodLabel string, sortBy string) {
 	// Create budget section table
 	config := table.DefaultConfig()
 	config.Title = fmt.Sprintf("%s (%s)", title, periodLabel)
 	config.MaxColumnWidth = 30
 
 	budgetTable := table.NewWithConfig(config, "Category", "Amount", "Percentage")
+	budgetTable.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnNumber)
 
-	for _, cat := range sortedCategories {
-		percentage := float64(cat.amount) / float64(total) * 100
+	// Categories are added alphabetically, which is the order sorting by
+	// category wants and keeps equal amounts in a stable order. Sorting the
+	// column itself would put categories with an emoji last.
+	names := make([]string, 0, len(categoryAmounts))
+	for name := range categoryAmounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		percentage := float64(categoryAmounts[name]) / float64(total) * 100
 		budgetTable.AddRow(
-			cat.name,
-			format.Currency(int(cat.amount), "USD"),
+			colorizeCategory(name),
+			format.Currency(categoryAmounts[name], format.BaseCurrency()),
 			fmt.Sprintf("%.1f%%", percentage),
 		)
 	}
+	if sortBy != "category" {
+		budgetTable.SortBy(1, table.Descending)
+	}
+	budgetTable.AddTotals("Total")
 
 	if err := budgetTable.Render(); err != nil {
 		fmt.Printf("Error rendering budget table: %v\n", err)
 		return
 	}
+	if textOutput() {
+		fmt.Println(strings.Repeat("=", 60))
+	}
+}
 
-	fmt.Printf("💵 Total: %s\n", format.Currency(int(total), "USD"))
-	fmt.Println(strings.Repeat("=", 60))
+// budgetBars turns category totals into chart bars, largest first, green
+// for income and red for expenses
+func budgetBars(categoryAmounts map[string]int64, income bool) []chart.Bar {
+	bars := make([]chart.Bar, 0, len(categoryAmounts))
+	for name, amount := range categoryAmounts {
+		bar := chart.Bar{Label: name, Value: float64(amount) / 100.0, Color: chart.Red}
+		if income {
+			bar.Color = chart.Green
+		}
+		bars = append(bars, bar)
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Value != bars[j].Value {
+			return bars[i].Value > bars[j].Value
+		}
+		return bars[i].Label < bars[j].Label
+	})
+	return bars
 }
 
 func generatePeriodLabel(startDate, endDate string, days int) string {
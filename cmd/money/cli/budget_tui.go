@@ -0,0 +1,480 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// budgetRow is one category line on the budget screen
+type budgetRow struct {
+	name         string
+	spent        int64
+	budgeted     int64
+	transactions []database.Transaction
+}
+
+// BudgetModel is the interactive budget screen: category bars showing spent
+// vs budgeted for a month, with drill-down into a category's transactions
+// and inline recategorization.
+type BudgetModel struct {
+	db           *database.DB
+	month        time.Time
+	rows         []budgetRow
+	categories   []database.Category
+	accountNames map[string]string
+	currencies   map[string]string // account ID to currency
+	cursor       int
+	// Drill-down into a single category
+	detail       bool
+	detailCursor int
+	// Inline recategorization
+	inputMode bool
+	picker    categoryPicker
+	message   string
+	width     int
+	height    int
+}
+
+// NewBudgetModel loads the budget screen for the current month from db,
+// which the model keeps using for every action
+func NewBudgetModel(db *database.DB) (*BudgetModel, error) {
+	now := format.Now()
+	m := &BudgetModel{
+		db:    db,
+		month: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// load reads spending and budget targets for the model's month
+func (m *BudgetModel) load() error {
+	startDate := m.month.Format("2006-01-02")
+	endDate := m.month.AddDate(0, 1, -1).Format("2006-01-02")
+
+	categoryTransactions, err := m.db.GetTransactionsByCategory(startDate, endDate, true)
+	if err != nil {
+		return fmt.Errorf("failed to get categorized transactions: %w", err)
+	}
+
+	budgets, err := m.db.GetBudgets()
+	if err != nil {
+		return fmt.Errorf("failed to get budgets: %w", err)
+	}
+
+	categories, err := m.db.GetCategories()
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	accounts, err := m.db.GetAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	m.categories = categories
+	setCategoryStyles(categories)
+	m.accountNames = make(map[string]string)
+	m.currencies = make(map[string]string)
+	for _, account := range accounts {
+		m.accountNames[account.ID] = account.DisplayName()
+		m.currencies[account.ID] = account.Currency
+	}
+	m.rows = buildBudgetRows(categoryTransactions, budgets)
+	return nil
+}
+
+// buildBudgetRows combines a month's transactions with budget targets. Every
+// category with spending or a target gets a row; over-budget categories sort
+// first, then by amount spent.
+func buildBudgetRows(categoryTransactions map[string][]database.Transaction, budgets []database.Budget) []budgetRow {
+	rowsByName := make(map[string]*budgetRow)
+
+	for name, transactions := range categoryTransactions {
+		row := &budgetRow{name: name, transactions: transactions}
+		for _, t := range transactions {
+			if t.Amount < 0 {
+				row.spent += -t.Amount
+			}
+		}
+		rowsByName[name] = row
+	}
+
+	for _, b := range budgets {
+		row, exists := rowsByName[b.CategoryName]
+		if !exists {
+			row = &budgetRow{name: b.CategoryName}
+			rowsByName[b.CategoryName] = row
+		}
+		row.budgeted = b.Amount
+	}
+
+	var rows []budgetRow
+	for _, row := range rowsByName {
+		if row.spent == 0 && row.budgeted == 0 {
+			continue
+		}
+		rows = append(rows, *row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		iOver := rows[i].budgeted > 0 && rows[i].spent > rows[i].budgeted
+		jOver := rows[j].budgeted > 0 && rows[j].spent > rows[j].budgeted
+		if iOver != jOver {
+			return iOver
+		}
+		if rows[i].spent != rows[j].spent {
+			return rows[i].spent > rows[j].spent
+		}
+		return rows[i].name < rows[j].name
+	})
+
+	return rows
+}
+
+func (m BudgetModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m BudgetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.inputMode {
+		return m.updateInputMode(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		key := keys.resolve(msg.String())
+		if key == "ctrl+c" || key == "q" {
+			return m, tea.Quit
+		}
+
+		if m.detail {
+			return m.updateDetail(key)
+		}
+		return m.updateOverview(key)
+	}
+
+	return m, nil
+}
+
+func (m BudgetModel) updateOverview(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "j", "down":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "enter", "l", "right":
+		if len(m.rows) > 0 {
+			m.detail = true
+			m.detailCursor = 0
+			m.message = ""
+		}
+	case "n", "]":
+		m.changeMonth(1)
+	case "p", "[":
+		m.changeMonth(-1)
+	case "r":
+		m.reload()
+	}
+	return m, nil
+}
+
+func (m BudgetModel) updateDetail(key string) (tea.Model, tea.Cmd) {
+	transactions := m.selectedTransactions()
+
+	switch key {
+	case "esc", "h", "left":
+		m.detail = false
+		m.message = ""
+	case "j", "down":
+		if m.detailCursor < len(transactions)-1 {
+			m.detailCursor++
+		}
+	case "k", "up":
+		if m.detailCursor > 0 {
+			m.detailCursor--
+		}
+	case "e":
+		if len(transactions) > 0 {
+			var cmd tea.Cmd
+			m.picker, cmd = newCategoryPicker(m.categories, m.width-4, m.height-8)
+			m.inputMode = true
+			return m, cmd
+		}
+	case "u":
+		if len(transactions) > 0 {
+			tx := transactions[m.detailCursor]
+			if err := m.db.ClearTransactionCategory(tx.ID); err != nil {
+				m.message = fmt.Sprintf("Error uncategorizing: %v", err)
+			} else {
+				m.message = fmt.Sprintf("Uncategorized '%s'", tx.DisplayDescription())
+				m.reload()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m BudgetModel) updateInputMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	if !m.picker.done {
+		return m, cmd
+	}
+
+	m.inputMode = false
+	if m.picker.cancelled {
+		m.message = "Categorization cancelled"
+		return m, cmd
+	}
+
+	categoryName := m.picker.choice
+	tx := m.selectedTransactions()[m.detailCursor]
+	categoryID, err := m.db.SaveCategory(categoryName)
+	if err != nil {
+		m.message = fmt.Sprintf("Error saving category: %v", err)
+		return m, cmd
+	}
+	if err := m.db.UpdateTransactionCategory(tx.ID, categoryID); err != nil {
+		m.message = fmt.Sprintf("Error categorizing: %v", err)
+		return m, cmd
+	}
+
+	// reload also picks up a newly created category
+	m.reload()
+	m.message = fmt.Sprintf("Categorized '%s' as '%s'", tx.DisplayDescription(), categoryName)
+	if m.picker.created {
+		m.message = fmt.Sprintf("Created category '%s'. %s", categoryName, m.message)
+	}
+	return m, cmd
+}
+
+// changeMonth moves the screen by delta months and reloads
+func (m *BudgetModel) changeMonth(delta int) {
+	m.month = m.month.AddDate(0, delta, 0)
+	m.cursor = 0
+	m.reload()
+}
+
+// reload re-reads the current month, keeping the cursor and drill-down on
+// the same category where possible
+func (m *BudgetModel) reload() {
+	selectedName := ""
+	if m.cursor < len(m.rows) {
+		selectedName = m.rows[m.cursor].name
+	}
+
+	if err := m.load(); err != nil {
+		m.message = fmt.Sprintf("Error loading budget: %v", err)
+		return
+	}
+
+	m.cursor = 0
+	for i, row := range m.rows {
+		if row.name == selectedName {
+			m.cursor = i
+			break
+		}
+	}
+	if m.detail && (len(m.rows) == 0 || m.rows[m.cursor].name != selectedName) {
+		m.detail = false
+	}
+	if m.detailCursor >= len(m.selectedTransactions()) {
+		m.detailCursor = max(0, len(m.selectedTransactions())-1)
+	}
+}
+
+// selectedTransactions returns the transactions of the highlighted category
+func (m BudgetModel) selectedTransactions() []database.Transaction {
+	if m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor].transactions
+}
+
+func (m BudgetModel) View() string {
+	header := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render(fmt.Sprintf("Budget: %s", m.month.Format("January 2006")))
+
+	var instructions, content string
+	if m.detail {
+		row := m.rows[m.cursor]
+		instructions = "j/k: move  |  e: recategorize  |  u: uncategorize  |  h/Esc: back  |  q: quit"
+		content = m.renderDetail(row)
+	} else {
+		instructions = "j/k: move  |  enter/l: transactions  |  p/n: previous/next month  |  r: reload  |  q: quit"
+		content = m.renderOverview()
+	}
+
+	// The category picker takes the place of the transaction list while open
+	if m.inputMode {
+		content = m.picker.View()
+	}
+
+	return lipgloss.NewStyle().Margin(1).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			header,
+			lipgloss.NewStyle().Foreground(theme.Muted).Render(instructions),
+			"",
+			content,
+			"",
+			lipgloss.NewStyle().Foreground(theme.Status).Render(m.message),
+		),
+	)
+}
+
+func (m BudgetModel) renderOverview() string {
+	if len(m.rows) == 0 {
+		return "No spending or budget targets for this month. Set targets with 'money budget set <category> <amount>'."
+	}
+
+	barWidth := m.width - 70
+	if barWidth < 10 {
+		barWidth = 30
+	}
+
+	var maxSpent int64
+	var totalSpent, totalBudgeted int64
+	for _, row := range m.rows {
+		if row.spent > maxSpent {
+			maxSpent = row.spent
+		}
+		totalSpent += row.spent
+		totalBudgeted += row.budgeted
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(
+		fmt.Sprintf("  %-24s %14s %14s  %s", "Category", "Spent", "Budgeted", "Progress")))
+	b.WriteString("\n")
+
+	for i, row := range m.rows {
+		budgeted := "—"
+		if row.budgeted > 0 {
+			budgeted = format.Currency(row.budgeted, format.BaseCurrency())
+		}
+
+		line := fmt.Sprintf("  %s %14s %14s  %s",
+			padCategory(row.name, 24),
+			format.Currency(row.spent, format.BaseCurrency()),
+			budgeted,
+			renderBudgetBar(row.spent, row.budgeted, maxSpent, barWidth))
+
+		if i == m.cursor {
+			line = lipgloss.NewStyle().Background(theme.Highlight).Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  %-24s %14s %14s", "Total",
+		format.Currency(totalSpent, format.BaseCurrency()),
+		format.Currency(totalBudgeted, format.BaseCurrency())))
+
+	return b.String()
+}
+
+func (m BudgetModel) renderDetail(row budgetRow) string {
+	title := lipgloss.NewStyle().Bold(true).Render(categoryLabel(row.name))
+	summary := fmt.Sprintf("%s spent", format.Currency(row.spent, format.BaseCurrency()))
+	if row.budgeted > 0 {
+		summary += fmt.Sprintf(" of %s budgeted", format.Currency(row.budgeted, format.BaseCurrency()))
+	}
+
+	if len(row.transactions) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, title, summary, "", "No transactions this month.")
+	}
+
+	descriptionWidth := m.width - 60
+	if descriptionWidth < 20 {
+		descriptionWidth = 40
+	}
+
+	var b strings.Builder
+	for i, tx := range row.transactions {
+		date := format.PostedDate(tx.Posted)
+		line := fmt.Sprintf("  %-12s %-20s %s  %s",
+			date,
+			truncateString(m.accountNames[tx.AccountID], 20),
+			colorizeAmount(tx.Amount, fmt.Sprintf("%14s", format.Currency(tx.Amount, m.currencies[tx.AccountID]))),
+			truncateString(tx.DisplayDescription(), descriptionWidth))
+		if i == m.detailCursor {
+			line = lipgloss.NewStyle().Background(theme.Highlight).Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, summary, "", b.String())
+}
+
+// renderBudgetBar draws progress toward a budget target. Categories without a
+// target get a gray bar scaled against the largest spender instead.
+func renderBudgetBar(spent, budgeted, maxSpent int64, width int) string {
+	if budgeted <= 0 {
+		return renderBar(spent, maxSpent, width/2, theme.Muted)
+	}
+
+	ratio := float64(spent) / float64(budgeted)
+	filled := int(ratio * float64(width))
+	if filled > width {
+		filled = width
+	}
+
+	barColor := theme.Income
+	if ratio > 1 {
+		barColor = theme.Expense
+	} else if ratio > 0.8 {
+		barColor = theme.Status
+	}
+
+	bar := lipgloss.NewStyle().Foreground(barColor).Render(strings.Repeat("█", filled))
+	rest := lipgloss.NewStyle().Foreground(theme.BarTrack).Render(strings.Repeat("░", width-filled))
+	return fmt.Sprintf("%s%s %3.0f%%", bar, rest, ratio*100)
+}
+
+func runBudgetTUI() error {
+	if err := loadTUISettings(); err != nil {
+		return err
+	}
+
+	db, err := dbutil.Open()
+	if err != nil {
+		return err
+	}
+
+	model, err := NewBudgetModel(db)
+	if err != nil {
+		return err
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestBuildBudgetRows(t *testing.T) {
+	categoryTransactions := map[string][]database.Transaction{
+		"Groceries": {
+			{ID: "t1", Amount: -30000},
+			{ID: "t2", Amount: -25000},
+			{ID: "t3", Amount: 1000}, // refund is not counted as spending
+		},
+		"Dining Out": {
+			{ID: "t4", Amount: -8000},
+		},
+		"Salary": {
+			{ID: "t5", Amount: 500000},
+		},
+	}
+	budgets := []database.Budget{
+		{CategoryID: 1, CategoryName: "Groceries", Amount: 50000},
+		{CategoryID: 2, CategoryName: "Dining Out", Amount: 20000},
+		{CategoryID: 3, CategoryName: "Travel", Amount: 100000},
+	}
+
+	rows := buildBudgetRows(categoryTransactions, budgets)
+
+	expected := []struct {
+		name     string
+		spent    int64
+		budgeted int64
+	}{
+		{"Groceries", 55000, 50000}, // over budget sorts first
+		{"Dining Out", 8000, 20000},
+		{"Travel", 0, 100000},
+	}
+
+	if len(rows) != len(expected) {
+		t.Fatalf("buildBudgetRows returned %d rows; want %d", len(rows), len(expected))
+	}
+	for i, want := range expected {
+		if rows[i].name != want.name || rows[i].spent != want.spent || rows[i].budgeted != want.budgeted {
+			t.Errorf("row %d = {%s %d %d}; want {%s %d %d}", i,
+				rows[i].name, rows[i].spent, rows[i].budgeted,
+				want.name, want.spent, want.budgeted)
+		}
+	}
+}
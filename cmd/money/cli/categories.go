@@ -2,13 +2,18 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
 	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/report"
 	"github.com/arjungandhi/money/pkg/table"
 )
 
@@ -16,13 +21,21 @@ var Categories = &Z.Cmd{
 	Name:    "categories",
 	Aliases: []string{"category", "cat"},
 	Summary: "Manage transaction categories",
+	// Kept for scripts written before 'categories internal'
+	Hidden: []string{"set-internal", "clear-internal"},
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		CategoriesList,
 		CategoriesAdd,
 		CategoriesRemove,
+		CategoriesInternal,
 		CategoriesSetInternal,
 		CategoriesClearInternal,
+		CategoriesStyle,
+		CategoriesReport,
+		CategoriesSetTax,
+		CategoriesClearTax,
+		CategoriesTaxLines,
 		CategoriesSeed,
 	},
 }
@@ -43,13 +56,29 @@ var CategoriesList = &Z.Cmd{
 				return nil
 			}
 
-			t := table.New("Category", "Internal")
+			mappings, err := db.GetTaxMappings()
+			if err != nil {
+				return err
+			}
+			taxLines := make(map[int]string, len(mappings))
+			for _, m := range mappings {
+				taxLines[m.CategoryID] = m.TaxLine
+			}
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+
+			t := table.New("Category", "Internal", "Tax Line")
 			for _, c := range categories {
 				internal := "No"
 				if c.IsInternal {
 					internal = "Yes"
 				}
-				t.AddRow(c.Name, internal)
+				taxLine := "-"
+				if key, ok := taxLines[c.ID]; ok {
+					taxLine = key
+				}
+				t.AddRow(colorizeCategory(c.Name), internal, taxLine)
 			}
 
 			if err := t.Render(); err != nil {
@@ -61,32 +90,29 @@ var CategoriesList = &Z.Cmd{
 	},
 }
 
+func categoriesAddFlags(isInternal *bool) *flags.Set {
+	set := flags.New("money categories add")
+	set.Positional("<name>...")
+	set.BoolVar(isInternal, "internal", "")
+	return set
+}
+
 var CategoriesAdd = &Z.Cmd{
 	Name:     "add",
 	Summary:  "Add a new category, optionally marking it as internal",
-	Usage:    "<name> [--internal]",
+	Usage:    categoriesAddFlags(new(bool)).Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		if len(args) < 1 {
-			return fmt.Errorf("usage: money categories add <name> [--internal]")
-		}
-
-		// Parse flags and non-flag arguments
-		var nonFlags []string
 		isInternal := false
-		for _, arg := range args {
-			if arg == "--internal" {
-				isInternal = true
-			} else {
-				nonFlags = append(nonFlags, arg)
-			}
+		set := categoriesAddFlags(&isInternal)
+		if err := set.Parse(args); err != nil {
+			return err
 		}
-
-		if len(nonFlags) < 1 {
-			return fmt.Errorf("usage: money categories add <name> [--internal]")
+		if len(set.Args()) < 1 {
+			return fmt.Errorf("usage: money categories add %s", cmd.Usage)
 		}
 
-		categoryName := strings.Join(nonFlags, " ")
+		categoryName := strings.Join(set.Args(), " ")
 
 		return dbutil.WithDatabase(func(db *database.DB) error {
 			_, err := db.SaveCategoryWithInternal(categoryName, isInternal)
@@ -107,7 +133,7 @@ var CategoriesAdd = &Z.Cmd{
 var CategoriesRemove = &Z.Cmd{
 	Name:     "remove",
 	Summary:  "Remove a category (only if not used by any transactions)",
-	Usage:    "remove <name>",
+	Usage:    "remove <category>",
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
 		if len(args) < 1 {
@@ -145,14 +171,34 @@ var CategoriesSeed = &Z.Cmd{
 	},
 }
 
-var CategoriesSetInternal = &Z.Cmd{
-	Name:     "set-internal",
+var CategoriesInternal = &Z.Cmd{
+	Name:    "internal",
+	Summary: "Mark categories as internal, left out of budget calculations",
+	Description: `
+Internal categories hold money moving between your own accounts, like
+transfers and credit card payments. Their transactions are left out of
+budget income and spending, and are shown as "(internal)" wherever
+categories are listed. With no subcommand, lists the internal categories.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		CategoriesInternalSet,
+		CategoriesInternalUnset,
+		CategoriesInternalList,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return CategoriesInternalList.Call(cmd, args...)
+	},
+}
+
+var CategoriesInternalSet = &Z.Cmd{
+	Name:     "set",
 	Summary:  "Mark a category as internal (excludes from budget calculations)",
-	Usage:    "set-internal <name>",
+	Usage:    "set <category>",
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
 		if len(args) < 1 {
-			return fmt.Errorf("usage: money categories set-internal <name>")
+			return fmt.Errorf("usage: money categories internal set <name>")
 		}
 
 		categoryName := strings.Join(args, " ")
@@ -169,14 +215,15 @@ var CategoriesSetInternal = &Z.Cmd{
 	},
 }
 
-var CategoriesClearInternal = &Z.Cmd{
-	Name:     "clear-internal",
+var CategoriesInternalUnset = &Z.Cmd{
+	Name:     "unset",
+	Aliases:  []string{"clear"},
 	Summary:  "Remove internal flag from a category",
-	Usage:    "clear-internal <name>",
+	Usage:    "unset <category>",
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
 		if len(args) < 1 {
-			return fmt.Errorf("usage: money categories clear-internal <name>")
+			return fmt.Errorf("usage: money categories internal unset <name>")
 		}
 
 		categoryName := strings.Join(args, " ")
@@ -192,3 +239,326 @@ var CategoriesClearInternal = &Z.Cmd{
 		})
 	},
 }
+
+var CategoriesInternalList = &Z.Cmd{
+	Name:     "list",
+	Summary:  "Show the categories marked as internal",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			categories, err := db.GetCategories()
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+			setCategoryStyles(categories)
+
+			var internal []string
+			for _, c := range categories {
+				if c.IsInternal {
+					internal = append(internal, c.Name)
+				}
+			}
+			if len(internal) == 0 {
+				fmt.Println("No internal categories. Use 'money categories internal set <name>' to mark one.")
+				return nil
+			}
+
+			t := table.New("Category")
+			for _, name := range internal {
+				t.AddRow(colorizeCategory(name + " (internal)"))
+			}
+			if err := t.Render(); err != nil {
+				return fmt.Errorf("failed to render categories table: %w", err)
+			}
+			return nil
+		})
+	},
+}
+
+// CategoriesSetInternal and CategoriesClearInternal are the hidden older
+// names for 'categories internal set' and 'categories internal unset'
+var CategoriesSetInternal = &Z.Cmd{
+	Name:     "set-internal",
+	Summary:  CategoriesInternalSet.Summary,
+	Usage:    "set-internal <category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return CategoriesInternalSet.Call(cmd, args...)
+	},
+}
+
+var CategoriesClearInternal = &Z.Cmd{
+	Name:     "clear-internal",
+	Summary:  CategoriesInternalUnset.Summary,
+	Usage:    "clear-internal <category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return CategoriesInternalUnset.Call(cmd, args...)
+	},
+}
+
+func categoriesStyleFlags(color, emoji *string, clear *bool) *flags.Set {
+	set := flags.New("money categories style")
+	set.Positional("<category>...")
+	set.Func("color", "c", "COLOR", func(value string) error {
+		c, err := parseCategoryColor(value)
+		*color = c
+		return err
+	})
+	set.Func("emoji", "e", "EMOJI", func(value string) error {
+		e, err := parseCategoryEmoji(value)
+		*emoji = e
+		return err
+	})
+	set.BoolVar(clear, "clear", "")
+	return set
+}
+
+var CategoriesStyle = &Z.Cmd{
+	Name:     "style",
+	Summary:  "Set the color and emoji a category is shown with",
+	Usage:    "style " + categoriesStyleFlags(new(string), new(string), new(bool)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Sets how a category is drawn wherever categories are listed: the budget,
+'money transactions list', reports and the TUIs. --color takes red, green,
+yellow, blue, magenta, cyan, white, gray or a #rrggbb hex color; --emoji
+takes a single emoji, shown before the name. --clear goes back to the
+defaults, which show Uncategorized in red and internal categories in gray.
+With no flags, the category's current style is shown.
+
+Emoji are left out with --plain and from --format csv, tsv and markdown.
+
+Examples:
+  money categories style Groceries --color green --emoji 🛒
+  money categories style Dining Out --color "#ff8800"
+  money categories style Groceries --clear
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var color, emoji string
+		var clear bool
+		set := categoriesStyleFlags(&color, &emoji, &clear)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) == 0 {
+			return fmt.Errorf("usage: money categories %s", cmd.Usage)
+		}
+		categoryName := strings.Join(set.Args(), " ")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+
+			if !clear && !set.Changed("color") && !set.Changed("emoji") {
+				fmt.Printf("Color: %s\n", valueOrNone(category.Color))
+				fmt.Printf("Emoji: %s\n", valueOrNone(category.Emoji))
+				return nil
+			}
+
+			if clear {
+				category.Color, category.Emoji = "", ""
+			}
+			if set.Changed("color") {
+				category.Color = color
+			}
+			if set.Changed("emoji") {
+				category.Emoji = emoji
+			}
+			if err := db.SetCategoryStyle(category.ID, category.Color, category.Emoji); err != nil {
+				return err
+			}
+
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			fmt.Printf("Category '%s' is now shown as %s\n", category.Name, colorizeCategory(category.Name))
+			return nil
+		})
+	},
+}
+
+// valueOrNone returns value, or "none" when it's empty
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func categoriesReportFlags(months *int) *flags.Set {
+	set := flags.New("money categories report")
+	set.Positional("<category>...")
+	set.IntVar(months, "months", "m", "N", 1)
+	return set
+}
+
+var CategoriesReport = &Z.Cmd{
+	Name:     "report",
+	Summary:  "Show a category's monthly transaction counts and totals",
+	Usage:    "report " + categoriesReportFlags(new(int)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Lists how many transactions a category had each month over the last
+--months months (default 24), the current month included, with each
+month's net total. Use it to find categories too rarely used to keep
+apart, or busy enough to be worth splitting. "Uncategorized" reports the
+uncategorized transactions.
+
+Example:
+  money categories report Dining Out --months 12
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		months := report.DefaultUsageMonths
+		set := categoriesReportFlags(&months)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) == 0 {
+			return fmt.Errorf("usage: money categories %s", cmd.Usage)
+		}
+		categoryName := strings.Join(set.Args(), " ")
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			usage, err := report.CategoryUsageByMonth(db, categoryName, format.Now(), months)
+			if err != nil {
+				return err
+			}
+			return displayCategoryUsage(usage)
+		})
+	},
+}
+
+// displayCategoryUsage prints a category's months, oldest first, and how
+// often it was used
+func displayCategoryUsage(usage *report.CategoryUsage) error {
+	active := usage.ActiveMonths()
+	if active == 0 {
+		fmt.Printf("No %s transactions in the last %d months.\n", usage.Category, len(usage.Months))
+		return nil
+	}
+
+	first, _ := time.Parse("2006-01", usage.Months[0].Month)
+	last, _ := time.Parse("2006-01", usage.Months[len(usage.Months)-1].Month)
+	config := table.DefaultConfig()
+	config.Title = fmt.Sprintf("%s%s, %s to %s", icon("📊 "), colorizeCategory(usage.Category),
+		first.Format("Jan 2006"), last.Format("Jan 2006"))
+
+	t := table.NewWithConfig(config, "Month", "Transactions", "Total")
+	t.SetColumnTypes(table.ColumnText, table.ColumnNumber, table.ColumnCurrency)
+	t.SetRowStyle(negativeAmounts(2))
+	var count int
+	var total int64
+	for i, month := range usage.Months {
+		label, _ := time.Parse("2006-01", month.Month)
+		name := label.Format("Jan 2006")
+		if i == len(usage.Months)-1 {
+			name += " (so far)"
+		}
+		t.AddRow(name, strconv.Itoa(month.Count), format.Currency(month.Total, format.BaseCurrency()))
+		count += month.Count
+		total += month.Total
+	}
+	t.SetFooter("Total", strconv.Itoa(count), format.Currency(total, format.BaseCurrency()))
+	if err := t.Render(); err != nil {
+		return fmt.Errorf("failed to render category report: %w", err)
+	}
+
+	months := int64(len(usage.Months))
+	hintf("Used in %d of %d months, averaging %.1f transactions and %s a month.\n",
+		active, months, float64(count)/float64(months), format.Currency(total/months, format.BaseCurrency()))
+	return nil
+}
+
+var CategoriesSetTax = &Z.Cmd{
+	Name:     "set-tax",
+	Summary:  "Map a category to a tax form line for 'money report tax'",
+	Usage:    "set-tax <category> <tax-line>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Maps a category to a Schedule A or Schedule C line, so 'money report tax'
+totals its transactions on that line. A category maps to one line; several
+categories can share a line. 'money categories tax-lines' lists the lines.
+
+Example:
+  money categories set-tax Office Supplies c:supplies
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("usage: money categories %s", cmd.Usage)
+		}
+
+		categoryName := strings.Join(args[:len(args)-1], " ")
+		key := strings.ToLower(args[len(args)-1])
+		line, ok := report.LookupTaxLine(key)
+		if !ok {
+			return fmt.Errorf("unknown tax line: %s. See 'money categories tax-lines'", key)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+
+			if err := db.SetTaxLine(category.ID, line.Key); err != nil {
+				return err
+			}
+
+			fmt.Printf("Category '%s' reported on %s line %s (%s)\n", category.Name, line.Form, line.Line, line.Name)
+			return nil
+		})
+	},
+}
+
+var CategoriesClearTax = &Z.Cmd{
+	Name:     "clear-tax",
+	Summary:  "Remove a category's tax form line",
+	Usage:    "clear-tax <category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("usage: money categories %s", cmd.Usage)
+		}
+
+		categoryName := strings.Join(args, " ")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+
+			if err := db.ClearTaxLine(category.ID); err != nil {
+				return err
+			}
+
+			fmt.Printf("Tax line removed from category '%s'\n", category.Name)
+			return nil
+		})
+	},
+}
+
+var CategoriesTaxLines = &Z.Cmd{
+	Name:     "tax-lines",
+	Summary:  "List the tax form lines categories can be mapped to",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		t := table.New("Tax Line", "Form", "Line", "Description")
+		for _, line := range report.TaxLines {
+			t.AddRow(line.Key, line.Form, line.Line, line.Name)
+		}
+		return t.Render()
+	},
+}
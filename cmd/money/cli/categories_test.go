@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// runCommand runs cmd with args and returns what it printed
+func runCommand(t *testing.T, cmd *Z.Cmd, args ...string) string {
+	t.Helper()
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	err = cmd.Call(cmd, args...)
+	w.Close()
+	os.Stdout = stdout
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("money %s %v failed: %v", cmd.Name, args, err)
+	}
+	return string(out)
+}
+
+func TestCategoriesInternal(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() { categoryStyles = nil })
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for id, category := range map[string]string{"tx-1": "Groceries", "tx-2": "Credit Card Payment"} {
+		categoryID, err := db.SaveCategory(category)
+		if err != nil {
+			t.Fatalf("Failed to save category: %v", err)
+		}
+		if err := db.SaveTransaction(id, "acc-1", "2024-05-10T12:00:00Z", -12345, category, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.UpdateTransactionCategory(id, categoryID); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+	}
+
+	budget := []string{"--start", "2024-05-01", "--end", "2024-05-31"}
+	if out := runCommand(t, Budget, budget...); !strings.Contains(out, "Credit Card Payment") {
+		t.Errorf("Expected the budget to count Credit Card Payment before it's internal:\n%s", out)
+	}
+	if out := runCommand(t, CategoriesInternalList); !strings.Contains(out, "No internal categories") {
+		t.Errorf("Expected no internal categories yet:\n%s", out)
+	}
+
+	runCommand(t, CategoriesInternalSet, "Credit", "Card", "Payment")
+	out := runCommand(t, CategoriesInternalList)
+	if !strings.Contains(out, "Credit Card Payment (internal)") || strings.Contains(out, "Groceries") {
+		t.Errorf("Expected only Credit Card Payment listed as internal:\n%s", out)
+	}
+	out = runCommand(t, Budget, budget...)
+	if strings.Contains(out, "Credit Card Payment") || !strings.Contains(out, "Groceries") {
+		t.Errorf("Expected the budget to leave out the internal category:\n%s", out)
+	}
+
+	runCommand(t, CategoriesInternalUnset, "Credit Card Payment")
+	if out := runCommand(t, CategoriesInternalList); !strings.Contains(out, "No internal categories") {
+		t.Errorf("Expected no internal categories after unset:\n%s", out)
+	}
+	if out := runCommand(t, Budget, budget...); !strings.Contains(out, "Credit Card Payment") {
+		t.Errorf("Expected the budget to count Credit Card Payment again:\n%s", out)
+	}
+
+	if err := CategoriesInternalSet.Call(CategoriesInternalSet, "Nonexistent"); err == nil {
+		t.Error("Expected an error marking a category that doesn't exist")
+	}
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 
 	"github.com/arjungandhi/money/internal/dbutil"
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
 )
 
 const (
@@ -31,31 +34,50 @@ type columnWidths struct {
 	category    int
 }
 
+// defaultCategorizationPageSize is the number of rows loaded before the
+// terminal size is known
+const defaultCategorizationPageSize = 25
+
 type CategorizationModel struct {
-	table         table.Model
-	categories    []database.Category
-	categoryInput string
-	inputMode     bool
-	selectedTxID  string
-	message       string
-	// Remove db field - we'll create connections as needed
+	db           *database.DB
+	table        table.Model
+	categories   []database.Category
+	categoryByID map[int]database.Category // rebuilt only when categories change
+	picker       categoryPicker
+	inputMode    bool
+	selectedTxID string
+	message      string
+	// Only the page of transactions under the viewport is kept in memory;
+	// pages are read from the database as the cursor moves
 	transactions []database.Transaction
+	pageStart    int // position of transactions[0] among all transactions
+	pageSize     int
+	totalRows    int
+	colWidths    columnWidths
 	accounts     map[string]string // account ID to display name mapping
+	currencies   map[string]string // account ID to currency
 	width        int
 	height       int
 	// Visual selection mode
 	visualMode   bool
 	visualStart  int
-	selectedRows map[int]bool
-	currentIndex int
+	selectedRows map[int]bool // positions among all transactions
+	currentIndex int          // cursor position among all transactions
 	// Search mode
 	searchMode    bool
 	searchInput   string
-	searchMatches []int // indices of matching transactions
+	searchMatches []int // positions of matching transactions
 	searchIndex   int   // current position in searchMatches
+	// Filters, applied by the database queries that page rows in
+	filter          database.TransactionFilter
+	accountIDs      []string // accounts in display order, for cycling the account filter
+	filterInputMode bool
+	filterInput     string
+	// Batches in the operations log made this session, most recent last
+	undoStack []int64
 }
 
-func calculateOptimalColumnWidths(transactions []database.Transaction, accountMap map[string]string, categories []database.Category, db *database.DB) columnWidths {
+func calculateOptimalColumnWidths(transactions []database.Transaction, accountMap, currencies map[string]string, categoryByID map[int]database.Category) columnWidths {
 	widths := columnWidths{
 		date:        10, // "2006-01-02" + header
 		account:     7,  // "Account" header length
@@ -78,20 +100,20 @@ func calculateOptimalColumnWidths(transactions []database.Transaction, accountMa
 		}
 
 		// Amount formatting
-		amountStr := fmt.Sprintf("$%.2f", float64(tx.Amount)/100.0)
-		if len(amountStr) > widths.amount {
-			widths.amount = len(amountStr)
+		amountStr := format.Currency(tx.Amount, currencies[tx.AccountID])
+		if lipgloss.Width(amountStr) > widths.amount {
+			widths.amount = lipgloss.Width(amountStr)
 		}
 
 		// Description
-		if len(tx.Description) > widths.description {
-			widths.description = len(tx.Description)
+		if len(tx.DisplayDescription()) > widths.description {
+			widths.description = len(tx.DisplayDescription())
 		}
 
 		// Category
 		categoryStr := "Uncategorized"
-		if tx.CategoryID != nil && db != nil {
-			if category, err := db.GetCategoryByID(*tx.CategoryID); err == nil {
+		if tx.CategoryID != nil {
+			if category, ok := categoryByID[*tx.CategoryID]; ok {
 				categoryStr = category.Name
 				if category.IsInternal {
 					categoryStr += " (internal)"
@@ -122,102 +144,159 @@ func calculateOptimalColumnWidths(transactions []database.Transaction, accountMa
 	return widths
 }
 
-func NewCategorizationModel() (*CategorizationModel, error) {
-	var model *CategorizationModel
-	err := dbutil.WithDatabase(func(db *database.DB) error {
-		// Get all transactions
-		transactions, err := db.GetTransactions("", "", "")
-		if err != nil {
-			return fmt.Errorf("failed to get transactions: %w", err)
-		}
+// NewCategorizationModel loads the first page of transactions from db, which
+// the model keeps using for every action
+func NewCategorizationModel(db *database.DB) (*CategorizationModel, error) {
+	totalRows, err := db.CountTransactions(database.TransactionFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count transactions: %w", err)
+	}
 
-		// Get all categories
-		categories, err := db.GetCategories()
-		if err != nil {
-			return fmt.Errorf("failed to get categories: %w", err)
-		}
+	// Only the first page is loaded up front
+	transactions, err := db.GetTransactions(database.TransactionFilter{}, defaultCategorizationPageSize, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
 
-		// Get accounts for name lookup
-		accounts, err := db.GetAccounts()
-		if err != nil {
-			return fmt.Errorf("failed to get accounts: %w", err)
-		}
-
-		// Create account mapping
-		accountMap := make(map[string]string)
-		for _, account := range accounts {
-			accountMap[account.ID] = account.DisplayName()
-		}
-
-		// Calculate optimal column widths based on content
-		colWidths := calculateOptimalColumnWidths(transactions, accountMap, categories, db)
-
-		// Create table rows
-		rows := []table.Row{}
-		for _, tx := range transactions {
-			row := transactionToRowWithDB(tx, accountMap, db)
-			rows = append(rows, row)
-		}
-
-		// Create table with columns and highlighting using calculated widths
-		tableModel := table.New([]table.Column{
-			table.NewColumn(columnKeyDate, "Date", colWidths.date),
-			table.NewColumn(columnKeyAccount, "Account", colWidths.account),
-			table.NewColumn(columnKeyAmount, "Amount", colWidths.amount),
-			table.NewColumn(columnKeyDescription, "Description", colWidths.description),
-			table.NewColumn(columnKeyCategory, "Category", colWidths.category),
-		}).WithRows(rows).
-			BorderRounded().
-			WithPageSize(25).
-			Focused(true).
-			WithBaseStyle(lipgloss.NewStyle().
-				BorderForeground(lipgloss.Color("#00d7ff")).
-				Align(lipgloss.Left)).
-			WithRowStyleFunc(func(input table.RowStyleFuncInput) lipgloss.Style {
-				// Current row highlighting (basic for now)
-				if input.IsHighlighted {
-					return lipgloss.NewStyle().
-						Background(lipgloss.Color("#555"))
-				}
-				return lipgloss.NewStyle()
-			})
+	// Get all categories
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
 
-		model = &CategorizationModel{
-			table:      tableModel,
-			categories: categories,
-			// db field removed
-			transactions: transactions,
-			accounts:     accountMap,
-			message:      fmt.Sprintf("Found %d transactions. Use j/k to navigate, e to categorize, q to quit.", len(transactions)),
-			selectedRows: make(map[int]bool),
-		}
+	// Get accounts for name lookup
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
 
-		return nil
+	// Create account mapping
+	accountMap := make(map[string]string)
+	currencies := make(map[string]string)
+	var accountIDs []string
+	for _, account := range accounts {
+		accountMap[account.ID] = account.DisplayName()
+		currencies[account.ID] = account.Currency
+		accountIDs = append(accountIDs, account.ID)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool {
+		return accountMap[accountIDs[i]] < accountMap[accountIDs[j]]
 	})
 
-	if err != nil {
-		return nil, err
+	// Look categories up in memory rather than querying for every row
+	categoryByID := categoriesByID(categories)
+
+	// Calculate column widths based on the first page of content
+	colWidths := calculateOptimalColumnWidths(transactions, accountMap, currencies, categoryByID)
+
+	// Create table rows
+	rows := []table.Row{}
+	for _, tx := range transactions {
+		row := transactionToRowWithCategories(tx, accountMap, currencies, categoryByID)
+		rows = append(rows, row)
+	}
+
+	model := &CategorizationModel{
+		db:           db,
+		table:        newCategorizationTable(colWidths, defaultCategorizationPageSize, database.TransactionFilter{}).WithRows(rows),
+		categories:   categories,
+		categoryByID: categoryByID,
+		transactions: transactions,
+		pageSize:     defaultCategorizationPageSize,
+		totalRows:    totalRows,
+		colWidths:    colWidths,
+		accounts:     accountMap,
+		currencies:   currencies,
+		accountIDs:   accountIDs,
+		message:      fmt.Sprintf("Found %d transactions. Use j/k to navigate, e to categorize, q to quit.", totalRows),
+		selectedRows: make(map[int]bool),
 	}
+	model.updateFooter()
 
 	return model, nil
 }
 
-func transactionToRow(tx database.Transaction, accountMap map[string]string) table.Row {
-	return transactionToRowWithDB(tx, accountMap, nil)
+// newCategorizationTable creates the table used to display one page of
+// transactions, marking the column the filter sorts by
+func newCategorizationTable(colWidths columnWidths, pageSize int, filter database.TransactionFilter) table.Model {
+	return table.New([]table.Column{
+		table.NewColumn(columnKeyDate, sortedColumnTitle("Date", database.SortByDate, filter), colWidths.date),
+		table.NewColumn(columnKeyAccount, sortedColumnTitle("Account", database.SortByAccount, filter), colWidths.account),
+		table.NewColumn(columnKeyAmount, sortedColumnTitle("Amount", database.SortByAmount, filter), colWidths.amount),
+		table.NewColumn(columnKeyDescription, "Description", colWidths.description),
+		table.NewColumn(columnKeyCategory, sortedColumnTitle("Category", database.SortByCategory, filter), colWidths.category),
+	}).BorderRounded().
+		WithPageSize(pageSize).
+		Focused(true).
+		WithBaseStyle(lipgloss.NewStyle().
+			BorderForeground(theme.Accent).
+			Align(lipgloss.Left)).
+		WithRowStyleFunc(func(input table.RowStyleFuncInput) lipgloss.Style {
+			if input.IsHighlighted {
+				return lipgloss.NewStyle().Background(theme.Highlight)
+			}
+			return lipgloss.NewStyle()
+		})
+}
+
+// sortedColumnTitle adds a direction arrow to title if filter sorts by column
+func sortedColumnTitle(title string, column database.TransactionSort, filter database.TransactionFilter) string {
+	if transactionSortColumn(filter) != column {
+		return title
+	}
+	if filter.Ascending {
+		return title + " ▲"
+	}
+	return title + " ▼"
+}
+
+// transactionSortColumn returns the column filter sorts by, defaulting to date
+func transactionSortColumn(filter database.TransactionFilter) database.TransactionSort {
+	if filter.SortBy == "" {
+		return database.SortByDate
+	}
+	return filter.SortBy
+}
+
+// nextTransactionSort returns the sort column after current, cycling through
+// date, amount, account, and category
+func nextTransactionSort(current database.TransactionSort) database.TransactionSort {
+	switch current {
+	case database.SortByAmount:
+		return database.SortByAccount
+	case database.SortByAccount:
+		return database.SortByCategory
+	case database.SortByCategory:
+		return database.SortByDate
+	default:
+		return database.SortByAmount
+	}
+}
+
+// categoriesByID indexes categories by ID for building rows
+func categoriesByID(categories []database.Category) map[int]database.Category {
+	byID := make(map[int]database.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	return byID
 }
 
-func transactionToRowWithDB(tx database.Transaction, accountMap map[string]string, db *database.DB) table.Row {
+func transactionToRow(tx database.Transaction, accountMap, currencies map[string]string) table.Row {
+	return transactionToRowWithCategories(tx, accountMap, currencies, nil)
+}
+
+func transactionToRowWithCategories(tx database.Transaction, accountMap, currencies map[string]string, categoryByID map[int]database.Category) table.Row {
 	// Parse date for display
-	postedTime, _ := time.Parse(time.RFC3339, tx.Posted)
-	dateStr := postedTime.Format("2006-01-02")
+	dateStr := format.PostedDate(tx.Posted)
 
 	// Format amount
-	amountStr := fmt.Sprintf("$%.2f", float64(tx.Amount)/100.0)
+	amountStr := format.Currency(tx.Amount, currencies[tx.AccountID])
 	var styledAmount table.StyledCell
 	if tx.Amount < 0 {
-		styledAmount = table.NewStyledCell(amountStr, lipgloss.NewStyle().Foreground(lipgloss.Color("#f64")))
+		styledAmount = table.NewStyledCell(amountStr, lipgloss.NewStyle().Foreground(theme.Expense))
 	} else {
-		styledAmount = table.NewStyledCell(amountStr, lipgloss.NewStyle().Foreground(lipgloss.Color("#8c8")))
+		styledAmount = table.NewStyledCell(amountStr, lipgloss.NewStyle().Foreground(theme.Income))
 	}
 
 	// Get account name
@@ -226,25 +305,25 @@ func transactionToRowWithDB(tx database.Transaction, accountMap map[string]strin
 		accountDisplay = accountName
 	}
 	// Don't truncate - let the table handle column width
-	description := tx.Description
+	description := tx.DisplayDescription()
 
 	// Category display
 	categoryStr := "Uncategorized"
-	categoryColor := "#f64" // red for uncategorized
+	categoryColor := theme.Expense // red for uncategorized
 
-	if tx.CategoryID != nil && db != nil {
-		if category, err := db.GetCategoryByID(*tx.CategoryID); err == nil {
+	if tx.CategoryID != nil {
+		if category, ok := categoryByID[*tx.CategoryID]; ok {
 			categoryStr = category.Name
 			if category.IsInternal {
 				categoryStr += " (internal)"
-				categoryColor = "#888" // gray for internal categories
+				categoryColor = theme.Muted // gray for internal categories
 			} else {
-				categoryColor = "#8c8" // green for categorized
+				categoryColor = theme.Income // green for categorized
 			}
 		}
 	}
 
-	styledCategory := table.NewStyledCell(categoryStr, lipgloss.NewStyle().Foreground(lipgloss.Color(categoryColor)))
+	styledCategory := table.NewStyledCell(categoryStr, lipgloss.NewStyle().Foreground(categoryColor))
 
 	return table.NewRow(table.RowData{
 		columnKeyDate:            dateStr,
@@ -268,6 +347,9 @@ func (m CategorizationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.searchMode {
 		return m.updateSearchMode(msg)
 	}
+	if m.filterInputMode {
+		return m.updateFilterInputMode(msg)
+	}
 
 	// Handle window resize
 	if windowMsg, ok := msg.(tea.WindowSizeMsg); ok {
@@ -322,61 +404,58 @@ func (m CategorizationModel) handleWindowResize(windowMsg tea.WindowSizeMsg) (te
 			descriptionWidth = 30
 		}
 
-		// Update table with calculated dimensions
-		m.table = table.New([]table.Column{
-			table.NewColumn(columnKeyDate, "Date", 12),
-			table.NewColumn(columnKeyAccount, "Account", accountWidth),
-			table.NewColumn(columnKeyAmount, "Amount", 12),
-			table.NewColumn(columnKeyDescription, "Description", descriptionWidth),
-			table.NewColumn(columnKeyCategory, "Category", categoryWidth),
-		}).WithRows(m.getRebuildRows()).
-			BorderRounded().
-			WithPageSize(pageSize).
-			Focused(true).
-			WithBaseStyle(lipgloss.NewStyle().
-				BorderForeground(lipgloss.Color("#00d7ff")).
-				Align(lipgloss.Left)).
-			WithRowStyleFunc(func(input table.RowStyleFuncInput) lipgloss.Style {
-				if input.IsHighlighted {
-					return lipgloss.NewStyle().Background(lipgloss.Color("#555"))
-				}
-				return lipgloss.NewStyle()
-			})
+		// Rebuild the table with calculated dimensions and reload the page
+		// under the cursor at the new page size
+		m.colWidths = columnWidths{
+			date:        12,
+			account:     accountWidth,
+			amount:      12,
+			description: descriptionWidth,
+			category:    categoryWidth,
+		}
+		m.table = newCategorizationTable(m.colWidths, pageSize, m.filter)
+		m.pageSize = pageSize
+		m.transactions = nil
+		m.moveCursor(m.currentIndex)
 	}
 	return m, nil
 }
 
 // handleKeyMessage handles all key events and returns (handled, model, cmd)
 func (m CategorizationModel) handleKeyMessage(keyMsg tea.KeyMsg) (bool, tea.Model, tea.Cmd) {
-	key := keyMsg.String()
+	key := keys.resolve(keyMsg.String())
 
 	// Global keys (work in all modes)
 	if key == "ctrl+c" || key == "q" {
 		return true, m, tea.Quit
 	}
 
-	// Handle navigation keys (j/k) - in visual mode we handle completely, otherwise let table handle
-	if key == "j" || key == "down" {
-		if m.visualMode {
-			// In visual mode, we handle navigation completely
-			if m.currentIndex < len(m.transactions)-1 {
-				m.currentIndex++
-				m.updateVisualSelection()
-			}
-			return true, m, nil // Don't pass to table in visual mode
-		}
-		return false, m, nil // Let table handle navigation in normal mode
+	// Navigation is handled here rather than by the table, since the table
+	// only holds the current page of rows
+	switch key {
+	case "j", "down":
+		m.moveCursor(m.currentIndex + 1)
+		return true, m, nil
+	case "k", "up":
+		m.moveCursor(m.currentIndex - 1)
+		return true, m, nil
+	case "ctrl+f", "pgdown", "l", "right":
+		m.moveCursor(m.currentIndex + m.pageSize)
+		return true, m, nil
+	case "ctrl+b", "pgup", "h", "left":
+		m.moveCursor(m.currentIndex - m.pageSize)
+		return true, m, nil
+	case "g", "home":
+		m.moveCursor(0)
+		return true, m, nil
+	case "G", "end":
+		m.moveCursor(m.totalRows - 1)
+		return true, m, nil
 	}
-	if key == "k" || key == "up" {
-		if m.visualMode {
-			// In visual mode, we handle navigation completely
-			if m.currentIndex > 0 {
-				m.currentIndex--
-				m.updateVisualSelection()
-			}
-			return true, m, nil // Don't pass to table in visual mode
-		}
-		return false, m, nil // Let table handle navigation in normal mode
+
+	if key == "ctrl+z" {
+		m.undo()
+		return true, m, nil
 	}
 
 	// Mode-specific keys
@@ -393,9 +472,7 @@ func (m CategorizationModel) handleNormalModeKeys(key string) (bool, tea.Model,
 	case "v":
 		// Enter visual mode
 		m.visualMode = true
-		// Get the actual highlighted row index from the table
-		m.visualStart = m.table.GetHighlightedRowIndex()
-		m.currentIndex = m.visualStart // Keep our tracking in sync
+		m.visualStart = m.currentIndex
 		m.selectedRows = make(map[int]bool)
 		m.selectedRows[m.visualStart] = true
 		m.message = "Visual mode - use j/k to select range, e to categorize, u to uncategorize"
@@ -407,13 +484,13 @@ func (m CategorizationModel) handleNormalModeKeys(key string) (bool, tea.Model,
 		selected := m.getSelectedTransactions()
 		if len(selected) > 0 {
 			m.selectedTxID = selected[0].ID // For single transaction tracking in input mode
-			m.inputMode = true
-			m.categoryInput = ""
+			cmd := m.openCategoryPicker()
 			if len(selected) == 1 {
-				m.message = fmt.Sprintf("Enter category for: %s (or press Esc to cancel)", selected[0].Description)
+				m.message = fmt.Sprintf("Choose category for: %s", selected[0].DisplayDescription())
 			} else {
-				m.message = fmt.Sprintf("Enter category for %d transactions (or press Esc to cancel)", len(selected))
+				m.message = fmt.Sprintf("Choose category for %d transactions", len(selected))
 			}
+			return true, m, cmd
 		}
 		return true, m, nil
 
@@ -426,7 +503,7 @@ func (m CategorizationModel) handleNormalModeKeys(key string) (bool, tea.Model,
 				m.message = fmt.Sprintf("Error uncategorizing: %v", err)
 			} else {
 				if len(selected) == 1 {
-					m.message = fmt.Sprintf("Uncategorized '%s'", selected[0].Description)
+					m.message = fmt.Sprintf("Uncategorized '%s'", selected[0].DisplayDescription())
 				} else {
 					m.message = fmt.Sprintf("Uncategorized %d transactions", len(selected))
 				}
@@ -442,6 +519,61 @@ func (m CategorizationModel) handleNormalModeKeys(key string) (bool, tea.Model,
 		m.message = "Search: (press Enter to search, Esc to cancel)"
 		return true, m, nil
 
+	case "c":
+		// Toggle uncategorized-only filter
+		filter := m.filter
+		filter.UncategorizedOnly = !filter.UncategorizedOnly
+		m.setFilter(filter)
+		return true, m, nil
+
+	case "a":
+		// Cycle the account filter through all accounts
+		filter := m.filter
+		filter.AccountID = nextAccountFilter(m.accountIDs, filter.AccountID)
+		m.setFilter(filter)
+		return true, m, nil
+
+	case "s":
+		// Cycle the amount sign filter: all, expenses, income
+		filter := m.filter
+		switch filter.AmountSign {
+		case 0:
+			filter.AmountSign = -1
+		case -1:
+			filter.AmountSign = 1
+		default:
+			filter.AmountSign = 0
+		}
+		m.setFilter(filter)
+		return true, m, nil
+
+	case "d":
+		// Enter a date range filter
+		m.filterInputMode = true
+		m.filterInput = ""
+		m.message = "Date range: YYYY-MM, YYYY-MM-DD..YYYY-MM-DD, or empty to clear (Enter to apply, Esc to cancel)"
+		return true, m, nil
+
+	case "x":
+		// Clear all filters, keeping the sort order
+		m.setFilter(database.TransactionFilter{SortBy: m.filter.SortBy, Ascending: m.filter.Ascending})
+		return true, m, nil
+
+	case "o":
+		// Cycle the sort column, starting each column in descending order
+		filter := m.filter
+		filter.SortBy = nextTransactionSort(transactionSortColumn(filter))
+		filter.Ascending = false
+		m.setSort(filter)
+		return true, m, nil
+
+	case "O":
+		// Reverse the sort direction
+		filter := m.filter
+		filter.Ascending = !filter.Ascending
+		m.setSort(filter)
+		return true, m, nil
+
 	case "n":
 		// Next search result
 		if len(m.searchMatches) > 0 {
@@ -481,9 +613,9 @@ func (m CategorizationModel) handleVisualModeKeys(key string) (bool, tea.Model,
 		// Categorization (works for both single and multiple)
 		selected := m.getSelectedTransactions()
 		if len(selected) > 0 {
-			m.inputMode = true
-			m.categoryInput = ""
-			m.message = fmt.Sprintf("Enter category for %d selected transactions (or press Esc to cancel)", len(selected))
+			cmd := m.openCategoryPicker()
+			m.message = fmt.Sprintf("Choose category for %d selected transactions", len(selected))
+			return true, m, cmd
 		}
 		return true, m, nil
 
@@ -539,52 +671,104 @@ func (m CategorizationModel) handleVisualModeKeys(key string) (bool, tea.Model,
 
 // getSelectedTransactions returns the currently selected transactions
 // In normal mode: returns the highlighted transaction
-// In visual mode: returns all selected transactions
+// In visual mode: returns all selected transactions, reading any that are
+// outside the current page from the database
 func (m *CategorizationModel) getSelectedTransactions() []database.Transaction {
 	if m.visualMode && len(m.selectedRows) > 0 {
-		// Visual mode: return all selected transactions
-		var selected []database.Transaction
-		for index := range m.selectedRows {
-			if index < len(m.transactions) {
-				selected = append(selected, m.transactions[index])
-			}
+		// The visual selection is always a contiguous range
+		first, last := m.visualStart, m.currentIndex
+		if first > last {
+			first, last = last, first
 		}
-		return selected
-	} else {
-		// Normal mode: return highlighted transaction
-		if len(m.transactions) > 0 {
-			highlightedRow := m.table.HighlightedRow()
-			if highlightedRow.Data != nil {
-				tx := highlightedRow.Data[columnKeyTransactionData].(database.Transaction)
-				return []database.Transaction{tx}
-			}
+
+		if first >= m.pageStart && last < m.pageStart+len(m.transactions) {
+			selected := make([]database.Transaction, last-first+1)
+			copy(selected, m.transactions[first-m.pageStart:last-m.pageStart+1])
+			return selected
+		}
+
+		selected, err := m.db.GetTransactions(m.filter, last-first+1, first)
+		if err != nil {
+			m.message = fmt.Sprintf("Error loading selection: %v", err)
+			return []database.Transaction{}
 		}
-		return []database.Transaction{}
+		return selected
+	}
+
+	// Normal mode: return highlighted transaction
+	index := m.currentIndex - m.pageStart
+	if index >= 0 && index < len(m.transactions) {
+		return []database.Transaction{m.transactions[index]}
 	}
+	return []database.Transaction{}
 }
 
-// categorizeTransactions applies a category to a list of transactions
+// categorizeTransactions applies a category to a list of transactions as
+// one undoable operation
 func (m *CategorizationModel) categorizeTransactions(transactions []database.Transaction, categoryName string) error {
-	for _, tx := range transactions {
-		err := m.categorizeTransaction(tx.ID, categoryName)
-		if err != nil {
-			return err
-		}
+	// Save or get category
+	categoryID, err := m.db.SaveCategory(categoryName)
+	if err != nil {
+		return fmt.Errorf("failed to save category: %w", err)
 	}
+
+	batchID, err := m.db.SetTransactionCategories(transactionIDs(transactions), &categoryID)
+	if err != nil {
+		return err
+	}
+	m.undoStack = append(m.undoStack, batchID)
 	return nil
 }
 
-// uncategorizeTransactions removes categories from a list of transactions
+// uncategorizeTransactions removes categories from a list of transactions as
+// one undoable operation
 func (m *CategorizationModel) uncategorizeTransactions(transactions []database.Transaction) error {
-	return dbutil.WithDatabase(func(db *database.DB) error {
-		for _, tx := range transactions {
-			err := db.ClearTransactionCategory(tx.ID)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	batchID, err := m.db.SetTransactionCategories(transactionIDs(transactions), nil)
+	if err != nil {
+		return err
+	}
+	m.undoStack = append(m.undoStack, batchID)
+	return nil
+}
+
+// undo reverts the most recent categorize or uncategorize made this session
+func (m *CategorizationModel) undo() {
+	if len(m.undoStack) == 0 {
+		m.message = "Nothing to undo"
+		return
+	}
+
+	batchID := m.undoStack[len(m.undoStack)-1]
+	restored, err := m.db.UndoCategoryBatch(batchID)
+	if errors.Is(err, database.ErrCategoryDeleted) {
+		// The change can never be undone, so move on to the one before it
+		m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	}
+	if err != nil {
+		m.message = fmt.Sprintf("Error undoing: %v", err)
+		return
+	}
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+
+	if restored == 1 {
+		m.message = "Undid change to 1 transaction"
+	} else {
+		m.message = fmt.Sprintf("Undid change to %d transactions", restored)
+	}
+
+	if m.visualMode {
+		m.visualMode = false
+		m.selectedRows = make(map[int]bool)
+	}
+	m.refreshTransactionView()
+}
+
+func transactionIDs(transactions []database.Transaction) []string {
+	ids := make([]string, len(transactions))
+	for i, tx := range transactions {
+		ids[i] = tx.ID
+	}
+	return ids
 }
 
 // updateSearchMode handles search input
@@ -625,54 +809,17 @@ func (m *CategorizationModel) updateSearchMode(msg tea.Msg) (tea.Model, tea.Cmd)
 	return m, nil
 }
 
-// performSearch finds all transactions matching the search term
+// performSearch finds all transactions matching the search term in
+// description, account name, category, or amount
 func (m *CategorizationModel) performSearch(searchTerm string) {
 	m.searchMatches = nil
-	searchTerm = strings.ToLower(searchTerm)
-
-	for i, tx := range m.transactions {
-		// Search in description, account name, and category
-		if m.transactionMatches(tx, searchTerm) {
-			m.searchMatches = append(m.searchMatches, i)
-		}
-	}
-}
-
-// transactionMatches checks if a transaction matches the search term
-func (m *CategorizationModel) transactionMatches(tx database.Transaction, searchTerm string) bool {
-	// Search in description
-	if strings.Contains(strings.ToLower(tx.Description), searchTerm) {
-		return true
-	}
-
-	// Search in account name
-	accountName := tx.AccountID
-	if name, exists := m.accounts[tx.AccountID]; exists {
-		accountName = name
-	}
-	if strings.Contains(strings.ToLower(accountName), searchTerm) {
-		return true
-	}
-
-	// Search in category
-	if tx.CategoryID != nil {
-		for _, cat := range m.categories {
-			if cat.ID == *tx.CategoryID {
-				if strings.Contains(strings.ToLower(cat.Name), searchTerm) {
-					return true
-				}
-				break
-			}
-		}
-	}
 
-	// Search in amount (as string)
-	amountStr := fmt.Sprintf("%.2f", float64(tx.Amount)/100.0)
-	if strings.Contains(amountStr, searchTerm) {
-		return true
+	matches, err := m.db.FindTransactionPositions(m.filter, searchTerm)
+	if err != nil {
+		m.message = fmt.Sprintf("Error searching: %v", err)
+		return
 	}
-
-	return false
+	m.searchMatches = matches
 }
 
 // navigateToSearchResult moves to the current search result
@@ -681,136 +828,282 @@ func (m *CategorizationModel) navigateToSearchResult() {
 		return
 	}
 
-	targetIndex := m.searchMatches[m.searchIndex]
-	m.currentIndex = targetIndex
-	m.table = m.table.WithHighlightedRow(targetIndex)
+	m.moveCursor(m.searchMatches[m.searchIndex])
 
 	// Update message with current position
 	m.message = fmt.Sprintf("Match %d of %d", m.searchIndex+1, len(m.searchMatches))
 }
 
-func (m *CategorizationModel) updateInputMode(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "escape", "esc", tea.KeyEscape.String():
-			m.inputMode = false
-			m.categoryInput = ""
-			m.message = "Categorization cancelled"
-		case "enter":
-			// Apply categorization with best match if input doesn't exactly match
-			if m.categoryInput != "" {
-				// Find best matching category
-				bestMatch := m.findBestCategoryMatch(m.categoryInput)
-				if bestMatch != "" {
-					// Get currently selected transactions (works for both modes)
-					selected := m.getSelectedTransactions()
-					if len(selected) > 0 {
-						err := m.categorizeTransactions(selected, bestMatch)
-						if err != nil {
-							m.message = fmt.Sprintf("Error categorizing: %v", err)
-						} else {
-							if len(selected) == 1 {
-								m.message = fmt.Sprintf("Categorized '%s' as '%s'", selected[0].Description, bestMatch)
-							} else {
-								m.message = fmt.Sprintf("Categorized %d transactions as '%s'", len(selected), bestMatch)
-							}
-							// Exit visual mode after operation
-							if m.visualMode {
-								m.visualMode = false
-								m.selectedRows = make(map[int]bool)
-							}
-							m.refreshTransactionView()
-						}
-					}
-				} else {
-					m.message = fmt.Sprintf("No matching category found for '%s'", m.categoryInput)
-				}
-			}
-			m.inputMode = false
-			m.categoryInput = ""
-		case "backspace":
-			if len(m.categoryInput) > 0 {
-				m.categoryInput = m.categoryInput[:len(m.categoryInput)-1]
-			}
-		default:
-			// Add character to input
-			if len(msg.String()) == 1 {
-				m.categoryInput += msg.String()
-			}
+// updateFilterInputMode handles typing a date range filter
+func (m *CategorizationModel) updateFilterInputMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "escape", "esc", tea.KeyEscape.String():
+		m.filterInputMode = false
+		m.filterInput = ""
+		m.message = "Date filter cancelled"
+	case "enter":
+		m.filterInputMode = false
+		startDate, endDate, err := parseDateRangeFilter(m.filterInput)
+		if err != nil {
+			m.message = err.Error()
+			break
+		}
+		filter := m.filter
+		filter.StartDate = startDate
+		filter.EndDate = endDate
+		m.setFilter(filter)
+	case "backspace":
+		if len(m.filterInput) > 0 {
+			m.filterInput = m.filterInput[:len(m.filterInput)-1]
+		}
+	default:
+		if len(keyMsg.String()) == 1 {
+			m.filterInput += keyMsg.String()
 		}
 	}
 
 	return m, nil
 }
 
-func (m *CategorizationModel) categorizeTransaction(txID, categoryName string) error {
-	return dbutil.WithDatabase(func(db *database.DB) error {
-		// Save or get category
-		categoryID, err := db.SaveCategory(categoryName)
-		if err != nil {
-			return fmt.Errorf("failed to save category: %w", err)
-		}
+// setFilter applies a new filter, reloading the rows from the top
+func (m *CategorizationModel) setFilter(filter database.TransactionFilter) {
+	m.filter = filter
+	m.currentIndex = 0
+	m.searchMatches = nil
+	m.message = ""
+	m.refreshTransactionView()
+	if m.message == "" {
+		m.message = fmt.Sprintf("%d transactions match", m.totalRows)
+	}
+}
 
-		// Update transaction
-		err = db.UpdateTransactionCategory(txID, categoryID)
-		if err != nil {
-			return fmt.Errorf("failed to update transaction category: %w", err)
-		}
+// setSort reorders the transactions, returning the cursor to the top
+func (m *CategorizationModel) setSort(filter database.TransactionFilter) {
+	m.table = newCategorizationTable(m.colWidths, m.pageSize, filter)
+	m.setFilter(filter)
 
-		return nil
-	})
+	direction := "descending"
+	if filter.Ascending {
+		direction = "ascending"
+	}
+	m.message = fmt.Sprintf("Sorted by %s, %s", transactionSortColumn(filter), direction)
 }
 
-func (m *CategorizationModel) getRebuildRows() []table.Row {
-	rows := []table.Row{}
-	err := dbutil.WithDatabase(func(db *database.DB) error {
-		for _, tx := range m.transactions {
-			row := transactionToRowWithDB(tx, m.accounts, db)
-			rows = append(rows, row)
+// nextAccountFilter returns the account ID after current in accountIDs,
+// wrapping back to no account filter after the last one
+func nextAccountFilter(accountIDs []string, current string) string {
+	if current == "" {
+		if len(accountIDs) > 0 {
+			return accountIDs[0]
 		}
-		return nil
-	})
-	if err != nil {
-		// Fallback to nil db if there's an error
-		for _, tx := range m.transactions {
-			row := transactionToRowWithDB(tx, m.accounts, nil)
-			rows = append(rows, row)
+		return ""
+	}
+	for i, id := range accountIDs {
+		if id == current && i+1 < len(accountIDs) {
+			return accountIDs[i+1]
 		}
 	}
-	return rows
+	return ""
 }
 
-func (m *CategorizationModel) findBestCategoryMatch(input string) string {
+// parseDateRangeFilter parses a date range typed into the filter prompt.
+// Accepted forms are YYYY-MM (a whole month), YYYY-MM-DD (a single day),
+// and START..END where either side may be omitted. Empty input clears the
+// range.
+func parseDateRangeFilter(input string) (startDate, endDate string, err error) {
+	input = strings.TrimSpace(input)
 	if input == "" {
-		return ""
+		return "", "", nil
+	}
+
+	if month, err := time.Parse("2006-01", input); err == nil {
+		return month.Format("2006-01-02"), month.AddDate(0, 1, -1).Format("2006-01-02"), nil
+	}
+
+	start, end := input, input
+	if parts := strings.SplitN(input, "..", 2); len(parts) == 2 {
+		start, end = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	}
+
+	for _, date := range []string{start, end} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return "", "", fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+		}
+	}
+
+	if start != "" && end != "" && start > end {
+		return "", "", fmt.Errorf("start date %s is after end date %s", start, end)
 	}
 
-	inputLower := strings.ToLower(input)
+	return start, end, nil
+}
 
-	// First check for exact match (case insensitive)
-	for _, cat := range m.categories {
-		if strings.ToLower(cat.Name) == inputLower {
-			return cat.Name
+// describeFilter summarizes the active filters for display
+func (m CategorizationModel) describeFilter() string {
+	var parts []string
+	if m.filter.UncategorizedOnly {
+		parts = append(parts, "uncategorized")
+	}
+	if m.filter.AccountID != "" {
+		name := m.filter.AccountID
+		if accountName, exists := m.accounts[m.filter.AccountID]; exists {
+			name = accountName
 		}
+		parts = append(parts, "account: "+name)
+	}
+	switch {
+	case m.filter.AmountSign < 0:
+		parts = append(parts, "expenses")
+	case m.filter.AmountSign > 0:
+		parts = append(parts, "income")
+	}
+	if m.filter.StartDate != "" || m.filter.EndDate != "" {
+		parts = append(parts, fmt.Sprintf("dates: %s..%s", m.filter.StartDate, m.filter.EndDate))
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// openCategoryPicker shows the category picker for the selected transactions
+func (m *CategorizationModel) openCategoryPicker() tea.Cmd {
+	var cmd tea.Cmd
+	m.picker, cmd = newCategoryPicker(m.categories, m.width-4, m.height-8)
+	m.inputMode = true
+	return cmd
+}
+
+func (m *CategorizationModel) updateInputMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	if !m.picker.done {
+		return m, cmd
+	}
+
+	m.inputMode = false
+	if m.picker.cancelled {
+		m.message = "Categorization cancelled"
+		return m, cmd
+	}
+
+	// Get currently selected transactions (works for both modes)
+	categoryName := m.picker.choice
+	selected := m.getSelectedTransactions()
+	if len(selected) == 0 {
+		return m, cmd
+	}
+
+	if err := m.categorizeTransactions(selected, categoryName); err != nil {
+		m.message = fmt.Sprintf("Error categorizing: %v", err)
+		return m, cmd
+	}
+
+	if len(selected) == 1 {
+		m.message = fmt.Sprintf("Categorized '%s' as '%s'", selected[0].DisplayDescription(), categoryName)
+	} else {
+		m.message = fmt.Sprintf("Categorized %d transactions as '%s'", len(selected), categoryName)
+	}
+	if m.picker.created {
+		m.message = fmt.Sprintf("Created category '%s'. %s", categoryName, m.message)
+		m.reloadCategories()
+	}
+
+	// Exit visual mode after operation
+	if m.visualMode {
+		m.visualMode = false
+		m.selectedRows = make(map[int]bool)
+	}
+	m.refreshTransactionView()
+
+	return m, cmd
+}
+
+// reloadCategories refreshes the category list and the lookup rows are
+// built with after a category is created
+func (m *CategorizationModel) reloadCategories() {
+	categories, err := m.db.GetCategories()
+	if err != nil {
+		m.message = fmt.Sprintf("Error loading categories: %v", err)
+		return
+	}
+	m.categories = categories
+	m.categoryByID = categoriesByID(categories)
+}
+
+// loadPage reads the page of transactions starting at position start and
+// replaces the table rows with it
+func (m *CategorizationModel) loadPage(start int) error {
+	transactions, err := m.db.GetTransactions(m.filter, m.pageSize, start)
+	if err != nil {
+		return err
 	}
 
-	// Then check for categories that start with the input
-	for _, cat := range m.categories {
-		if strings.HasPrefix(strings.ToLower(cat.Name), inputLower) {
-			return cat.Name
+	// A category missing from the lookup was created outside the TUI
+	for _, tx := range transactions {
+		if tx.CategoryID == nil {
+			continue
+		}
+		if _, ok := m.categoryByID[*tx.CategoryID]; !ok {
+			m.reloadCategories()
+			break
 		}
 	}
 
-	// Finally check for categories that contain the input
-	for _, cat := range m.categories {
-		if strings.Contains(strings.ToLower(cat.Name), inputLower) {
-			return cat.Name
+	rows := make([]table.Row, 0, len(transactions))
+	for _, tx := range transactions {
+		rows = append(rows, transactionToRowWithCategories(tx, m.accounts, m.currencies, m.categoryByID))
+	}
+
+	m.transactions = transactions
+	m.pageStart = start
+	m.table = m.table.WithRows(rows)
+	return nil
+}
+
+// moveCursor moves the cursor to position index, clamped to the available
+// transactions, loading a different page from the database when needed
+func (m *CategorizationModel) moveCursor(index int) {
+	if index >= m.totalRows {
+		index = m.totalRows - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+
+	if m.pageSize <= 0 {
+		m.pageSize = defaultCategorizationPageSize
+	}
+	pageStart := index / m.pageSize * m.pageSize
+	if m.transactions == nil || pageStart != m.pageStart {
+		if err := m.loadPage(pageStart); err != nil {
+			m.message = fmt.Sprintf("Error loading transactions: %v", err)
+			return
 		}
 	}
 
-	// If no match found, return empty string (don't create new category)
-	return ""
+	m.currentIndex = index
+	m.table = m.table.WithHighlightedRow(index - m.pageStart)
+	m.updateFooter()
+
+	if m.visualMode {
+		m.updateVisualSelection()
+	}
+}
+
+// updateFooter shows which slice of the transaction history is on screen
+func (m *CategorizationModel) updateFooter() {
+	if m.totalRows == 0 {
+		m.table = m.table.WithStaticFooter("No transactions")
+		return
+	}
+	m.table = m.table.WithStaticFooter(fmt.Sprintf("%d-%d of %d",
+		m.pageStart+1, m.pageStart+len(m.transactions), m.totalRows))
 }
 
 func (m *CategorizationModel) getCurrentRowIndex() int {
@@ -822,12 +1115,8 @@ func (m *CategorizationModel) updateVisualSelection() {
 		return
 	}
 
-	// Use our current index and update table to match
 	currentIndex := m.currentIndex
 
-	// Move table highlight to match our position
-	m.table = m.table.WithHighlightedRow(currentIndex)
-
 	// Clear previous selection
 	m.selectedRows = make(map[int]bool)
 
@@ -839,7 +1128,7 @@ func (m *CategorizationModel) updateVisualSelection() {
 	}
 
 	for i := start; i <= end; i++ {
-		if i >= 0 && i < len(m.transactions) {
+		if i >= 0 && i < m.totalRows {
 			m.selectedRows[i] = true
 		}
 	}
@@ -857,53 +1146,48 @@ func (m *CategorizationModel) updateVisualSelection() {
 }
 
 func (m *CategorizationModel) refreshTransactionView() {
-	err := dbutil.WithDatabase(func(db *database.DB) error {
-		// Refresh transactions from database to get updated categories/transfer status
-		transactions, err := db.GetTransactions("", "", "")
-		if err != nil {
-			return err
-		}
-
-		m.transactions = transactions
-		return nil
-	})
-
+	totalRows, err := m.db.CountTransactions(m.filter)
 	if err != nil {
 		m.message = fmt.Sprintf("Error refreshing transactions: %v", err)
 		return
 	}
+	m.totalRows = totalRows
 
-	// Update rows while preserving the table styling
-	m.table = m.table.WithRows(m.getRebuildRows())
+	// Reload the current page to pick up updated categories
+	m.transactions = nil
+	m.moveCursor(m.currentIndex)
 
 	// Update visual styling if needed
 	m.updateTableStyling()
 }
 
 func (m *CategorizationModel) updateTableStyling() {
-	// Create a closure that captures the current model state for styling
+	// Capture the current selection state; table rows are offsets into the page
+	selectedRows := m.selectedRows
+	pageStart := m.pageStart
+	visualMode := m.visualMode
 	m.table = m.table.WithRowStyleFunc(func(input table.RowStyleFuncInput) lipgloss.Style {
 		// Check if this row is selected in visual mode
-		isSelected := m.selectedRows[input.Index]
+		isSelected := selectedRows[pageStart+input.Index]
 
 		// Visual selection styling (takes priority)
 		if isSelected {
 			return lipgloss.NewStyle().
 				Bold(true).
-				Foreground(lipgloss.Color("#ffffff")).
-				Background(lipgloss.Color("#555")) // Use same color as normal highlighting
+				Foreground(theme.Selection).
+				Background(theme.Highlight) // Use same color as normal highlighting
 		}
 
 		// Current row highlighting
 		if input.IsHighlighted {
-			if m.visualMode {
+			if visualMode {
 				// In visual mode, show lighter highlight for current row
 				return lipgloss.NewStyle().
-					Background(lipgloss.Color("#666"))
+					Background(theme.VisualCursor)
 			} else {
 				// Normal highlighting
 				return lipgloss.NewStyle().
-					Background(lipgloss.Color("#555"))
+					Background(theme.Highlight)
 			}
 		}
 		return lipgloss.NewStyle()
@@ -914,7 +1198,7 @@ func (m CategorizationModel) View() string {
 	style := lipgloss.NewStyle().Margin(1)
 
 	header := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#00d7ff")).
+		Foreground(theme.Accent).
 		Bold(true).
 		Render("Manual Transaction Categorization")
 
@@ -922,53 +1206,50 @@ func (m CategorizationModel) View() string {
 	if m.visualMode {
 		selectedCount := len(m.selectedRows)
 		instructions = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888")).
-			Render(fmt.Sprintf("VISUAL MODE (%d selected)  |  j/k: extend selection  |  e: bulk categorize  |  u: bulk uncategorize  |  v/Esc: exit", selectedCount))
+			Foreground(theme.Muted).
+			Render(fmt.Sprintf("VISUAL MODE (%d selected)  |  j/k: extend selection  |  e: bulk categorize  |  u: bulk uncategorize  |  ctrl+z: undo  |  v/Esc: exit", selectedCount))
 	} else {
 		instructions = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888")).
-			Render("Navigation: j/k or ↑↓  |  e: categorize  |  u: uncategorize  |  v: visual mode  |  /: search  |  q: quit")
+			Foreground(theme.Muted).
+			Render("Navigation: j/k or ↑↓  |  e: categorize  |  u: uncategorize  |  ctrl+z: undo  |  v: visual mode  |  /: search  |  c/a/s/d: filter uncategorized/account/sign/dates  |  x: clear filters  |  o/O: sort column/direction  |  q: quit")
+	}
+
+	var filters string
+	if description := m.describeFilter(); description != "" {
+		filters = lipgloss.NewStyle().
+			Foreground(theme.Status).
+			Render("Filters: " + description)
 	}
 
 	var content string
-	if len(m.transactions) == 0 {
+	if m.totalRows == 0 && m.describeFilter() != "" {
 		content = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#8c8")).
-			Render("✅ No transactions found!")
+			Foreground(theme.Muted).
+			Render("No transactions match the current filters (x to clear)")
+	} else if m.totalRows == 0 {
+		content = lipgloss.NewStyle().
+			Foreground(theme.Income).
+			Render(icon("✅ ") + "No transactions found!")
 	} else {
 		content = m.table.View()
 	}
 
-	var input string
+	// The category picker takes the place of the table while it's open
 	if m.inputMode {
-		categories := make([]string, len(m.categories))
-		for i, cat := range m.categories {
-			categories[i] = cat.Name
-		}
-
-		suggestions := ""
-		if len(categories) > 0 {
-			// Show category suggestions
-			matchingCats := []string{}
-			for _, cat := range categories {
-				if m.categoryInput == "" || strings.Contains(strings.ToLower(cat), strings.ToLower(m.categoryInput)) {
-					matchingCats = append(matchingCats, cat)
-				}
-			}
-			if len(matchingCats) > 0 {
-				suggestions = "\nSuggestions: " + strings.Join(matchingCats[:min(5, len(matchingCats))], ", ")
-			}
-		}
+		content = m.picker.View()
+	}
 
+	var input string
+	if m.filterInputMode {
 		inputStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#00d7ff")).
-			Background(lipgloss.Color("#333"))
+			Foreground(theme.Accent).
+			Background(theme.InputBg)
 
-		input = "\n" + inputStyle.Render(fmt.Sprintf("Category: %s_", m.categoryInput)) + suggestions
+		input = "\n" + inputStyle.Render(fmt.Sprintf("Date range: %s_", m.filterInput))
 	}
 
 	status := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#ff0")).
+		Foreground(theme.Status).
 		Render(m.message)
 
 	return style.Render(
@@ -976,7 +1257,7 @@ func (m CategorizationModel) View() string {
 			lipgloss.Left,
 			header,
 			instructions,
-			"",
+			filters,
 			content,
 			input,
 			"",
@@ -985,20 +1266,22 @@ func (m CategorizationModel) View() string {
 	)
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+func runManualCategorization() error {
+	if err := loadTUISettings(); err != nil {
+		return err
 	}
-	return b
-}
 
-func runManualCategorization() error {
-	model, err := NewCategorizationModel()
+	db, err := dbutil.Open()
+	if err != nil {
+		return err
+	}
+
+	model, err := NewCategorizationModel(db)
 	if err != nil {
 		return err
 	}
 
-	if len(model.transactions) == 0 {
+	if model.totalRows == 0 {
 		fmt.Println("No transactions found.")
 		return nil
 	}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/evertras/bubble-table/table"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestParseDateRangeFilter(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{"", "", "", false},
+		{"2024-02", "2024-02-01", "2024-02-29", false},
+		{"2024-03-15", "2024-03-15", "2024-03-15", false},
+		{"2024-01-01..2024-01-31", "2024-01-01", "2024-01-31", false},
+		{"2024-01-01..", "2024-01-01", "", false},
+		{"..2024-01-31", "", "2024-01-31", false},
+		{"2024-02-01..2024-01-01", "", "", true},
+		{"yesterday", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			start, end, err := parseDateRangeFilter(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDateRangeFilter(%q) expected error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDateRangeFilter(%q) unexpected error: %v", tt.input, err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("parseDateRangeFilter(%q) = (%q, %q); want (%q, %q)", tt.input, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNextAccountFilter(t *testing.T) {
+	accountIDs := []string{"acc-a", "acc-b"}
+
+	sequence := []string{"acc-a", "acc-b", ""}
+	current := ""
+	for _, want := range sequence {
+		current = nextAccountFilter(accountIDs, current)
+		if current != want {
+			t.Errorf("nextAccountFilter = %q; want %q", current, want)
+		}
+	}
+
+	if got := nextAccountFilter(nil, ""); got != "" {
+		t.Errorf("nextAccountFilter with no accounts = %q; want empty", got)
+	}
+}
+
+func TestNextTransactionSort(t *testing.T) {
+	// Cycling from the default visits every column and wraps back to date
+	expected := []database.TransactionSort{
+		database.SortByAmount,
+		database.SortByAccount,
+		database.SortByCategory,
+		database.SortByDate,
+	}
+
+	current := transactionSortColumn(database.TransactionFilter{})
+	for _, want := range expected {
+		current = nextTransactionSort(current)
+		if current != want {
+			t.Fatalf("nextTransactionSort() = %q, want %q", current, want)
+		}
+	}
+}
+
+func TestCategorizationRowsUseCategoryLookup(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+	t.Cleanup(func() { config.SetProfile(config.DefaultProfile) })
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org1", "Bank", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveAccount("acc1", "org1", "Checking", "USD", 0, nil, "2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := db.SaveTransaction(id, "acc1", "2024-01-0"+id[1:]+"T00:00:00Z", -1000, "Purchase "+id, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	groceries, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, err := db.SaveCategoryWithInternal("Transfer", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTransactionCategory("t1", groceries); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTransactionCategory("t2", transfer); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewCategorizationModel(db)
+	if err != nil {
+		t.Fatalf("NewCategorizationModel: %v", err)
+	}
+
+	categoryCell := func(tx database.Transaction) string {
+		row := transactionToRowWithCategories(tx, m.accounts, m.currencies, m.categoryByID)
+		return row.Data[columnKeyCategory].(table.StyledCell).Data.(string)
+	}
+	expected := map[string]string{
+		"t1": "Groceries",
+		"t2": "Transfer (internal)",
+		"t3": "Uncategorized",
+	}
+	for _, tx := range m.transactions {
+		if got := categoryCell(tx); got != expected[tx.ID] {
+			t.Errorf("category of %s = %q; want %q", tx.ID, got, expected[tx.ID])
+		}
+	}
+
+	// A category created outside the TUI is picked up when the page reloads
+	travel, err := db.SaveCategory("Travel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateTransactionCategory("t3", travel); err != nil {
+		t.Fatal(err)
+	}
+	m.refreshTransactionView()
+	if _, ok := m.categoryByID[travel]; !ok {
+		t.Fatal("expected the category lookup to be reloaded")
+	}
+	for _, tx := range m.transactions {
+		if tx.ID == "t3" {
+			if got := categoryCell(tx); got != "Travel" {
+				t.Errorf("category of t3 = %q; want %q", got, "Travel")
+			}
+		}
+	}
+
+	// Amounts follow the locale and accounting negatives, like the rest of
+	// money's output
+	defer format.SetLocale(format.DefaultLocale)
+	defer format.SetAccountingNegatives(false)
+	if err := format.SetLocale("de-DE"); err != nil {
+		t.Fatal(err)
+	}
+	format.SetAccountingNegatives(true)
+	row := transactionToRowWithCategories(m.transactions[0], m.accounts, m.currencies, m.categoryByID)
+	if got, want := row.Data[columnKeyAmount].(table.StyledCell).Data.(string), "($10,00)"; got != want {
+		t.Errorf("amount = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// categoryItem is a category entry in the picker list
+type categoryItem struct {
+	name     string
+	internal bool
+	emoji    string
+	create   bool // the explicit "create new category" entry
+}
+
+func (i categoryItem) FilterValue() string {
+	if i.create {
+		return "create new category"
+	}
+	return i.name
+}
+
+func (i categoryItem) Title() string {
+	if i.create {
+		return icon("➕ ") + "Create new category…"
+	}
+	title := i.name
+	if i.internal {
+		title += " (internal)"
+	}
+	if i.emoji != "" && !plainOutput {
+		title = i.emoji + " " + title
+	}
+	return title
+}
+
+func (i categoryItem) Description() string { return "" }
+
+// categoryPicker is a fuzzy-filterable list of categories used wherever the
+// TUI asks for a category. Typing filters the list, arrows move the
+// selection, and new categories are only created when explicitly chosen
+// via the "Create new category" entry or ctrl+n.
+type categoryPicker struct {
+	list       list.Model
+	nameInput  textinput.Model
+	naming     bool
+	categories []database.Category
+
+	done      bool
+	cancelled bool
+	choice    string
+	created   bool // choice is a category that doesn't exist yet
+}
+
+// newCategoryPicker builds a picker over categories, already in filter mode
+// so the user can start typing immediately.
+func newCategoryPicker(categories []database.Category, width, height int) (categoryPicker, tea.Cmd) {
+	items := []list.Item{categoryItem{create: true}}
+	for _, category := range categories {
+		items = append(items, categoryItem{name: category.Name, internal: category.IsInternal, emoji: category.Emoji})
+	}
+
+	if width <= 0 {
+		width = 60
+	}
+	if height <= 0 {
+		height = 20
+	}
+
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+
+	l := list.New(items, delegate, width, height)
+	l.Title = "Choose a category"
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+
+	nameInput := textinput.New()
+	nameInput.Placeholder = "New category name"
+	nameInput.CharLimit = 64
+
+	p := categoryPicker{
+		list:       l,
+		nameInput:  nameInput,
+		categories: categories,
+	}
+
+	// Start in filter mode
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	return p, cmd
+}
+
+func (p categoryPicker) Update(msg tea.Msg) (categoryPicker, tea.Cmd) {
+	if p.done {
+		return p, nil
+	}
+
+	if p.naming {
+		return p.updateNaming(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		p.list, cmd = p.list.Update(msg)
+		return p, cmd
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		p.cancel()
+		return p, nil
+
+	case "esc":
+		// Esc clears an active filter first, then closes the picker
+		if p.list.FilterState() == list.Unfiltered {
+			p.cancel()
+			return p, nil
+		}
+
+	case "q":
+		// Outside of filtering q closes the picker instead of the program
+		if !p.list.SettingFilter() {
+			p.cancel()
+			return p, nil
+		}
+
+	case "ctrl+n":
+		return p.startNaming(p.list.FilterValue())
+
+	case "enter":
+		if p.list.SettingFilter() {
+			filterText := strings.TrimSpace(p.list.FilterValue())
+			if filterText != "" && len(p.list.VisibleItems()) == 0 {
+				// Nothing matches: offer to create it rather than guessing
+				return p.startNaming(filterText)
+			}
+			if filterText != "" {
+				// Accept the filter so the top match is selected
+				var cmd tea.Cmd
+				p.list, cmd = p.list.Update(msg)
+				return p.choose(cmd)
+			}
+		}
+		return p.choose(nil)
+	}
+
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	return p, cmd
+}
+
+// choose picks the selected list entry
+func (p categoryPicker) choose(cmd tea.Cmd) (categoryPicker, tea.Cmd) {
+	selected, ok := p.list.SelectedItem().(categoryItem)
+	if !ok {
+		return p, cmd
+	}
+	if selected.create {
+		return p.startNaming(p.list.FilterValue())
+	}
+
+	p.done = true
+	p.choice = selected.name
+	return p, cmd
+}
+
+func (p categoryPicker) startNaming(name string) (categoryPicker, tea.Cmd) {
+	p.naming = true
+	p.nameInput.SetValue(strings.TrimSpace(name))
+	p.nameInput.CursorEnd()
+	return p, p.nameInput.Focus()
+}
+
+func (p categoryPicker) updateNaming(msg tea.Msg) (categoryPicker, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "ctrl+c":
+			p.cancel()
+			return p, nil
+		case "esc":
+			p.naming = false
+			p.nameInput.Blur()
+			return p, nil
+		case "enter":
+			name := strings.TrimSpace(p.nameInput.Value())
+			if name == "" {
+				return p, nil
+			}
+			p.done = true
+			p.choice = name
+			p.created = !categoryExists(p.categories, name)
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.nameInput, cmd = p.nameInput.Update(msg)
+	return p, cmd
+}
+
+func (p *categoryPicker) cancel() {
+	p.done = true
+	p.cancelled = true
+}
+
+func (p categoryPicker) View() string {
+	hint := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	if p.naming {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Render("Create new category"),
+			"",
+			p.nameInput.View(),
+			"",
+			hint.Render("Enter: create  |  Esc: back to list"),
+		)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		p.list.View(),
+		hint.Render("Type to filter  |  ↑↓: select  |  Enter: choose  |  ctrl+n: create new  |  Esc: cancel"),
+	)
+}
+
+// categoryExists reports whether a category with name already exists
+func categoryExists(categories []database.Category, name string) bool {
+	for _, category := range categories {
+		if category.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestCategoryPickerCreate(t *testing.T) {
+	categories := []database.Category{{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Rent"}}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantCreated bool
+	}{
+		{"new category", "Pets", true},
+		{"existing category", "Rent", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picker, _ := newCategoryPicker(categories, 60, 20)
+			picker, _ = picker.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+			if !picker.naming {
+				t.Fatal("ctrl+n should switch the picker to naming a new category")
+			}
+
+			picker, _ = picker.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.input)})
+			picker, _ = picker.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+			if !picker.done || picker.cancelled {
+				t.Fatalf("picker should be done and not cancelled, got done=%v cancelled=%v", picker.done, picker.cancelled)
+			}
+			if picker.choice != tt.input {
+				t.Errorf("choice = %q, want %q", picker.choice, tt.input)
+			}
+			if picker.created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", picker.created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestCategoryPickerCancel(t *testing.T) {
+	picker, _ := newCategoryPicker(nil, 60, 20)
+	picker, _ = picker.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !picker.done || !picker.cancelled {
+		t.Errorf("ctrl+c should cancel the picker")
+	}
+}
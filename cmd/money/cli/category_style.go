@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// categoryColorNames are the colors a category can be given by name
+var categoryColorNames = map[string]color.Attribute{
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"gray":    color.FgHiBlack,
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// categoryStyles holds each category's color and emoji by name, for
+// colorizeCategory; set by loadCategoryStyles
+var categoryStyles map[string]database.Category
+
+// loadCategoryStyles reads every category's color and emoji, so the
+// categories a command prints are drawn the way 'money categories style'
+// set them
+func loadCategoryStyles(db *database.DB) error {
+	categories, err := db.GetCategories()
+	if err != nil {
+		return fmt.Errorf("failed to load category styles: %w", err)
+	}
+	setCategoryStyles(categories)
+	return nil
+}
+
+// setCategoryStyles sets the styles colorizeCategory uses from categories
+// already read
+func setCategoryStyles(categories []database.Category) {
+	categoryStyles = make(map[string]database.Category, len(categories))
+	for _, category := range categories {
+		categoryStyles[category.Name] = category
+	}
+}
+
+// parseCategoryColor checks a category color, a name from
+// categoryColorNames or #rrggbb, and returns it in the form it's stored in
+func parseCategoryColor(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "grey" {
+		value = "gray"
+	}
+	if _, ok := categoryColorNames[value]; ok {
+		return value, nil
+	}
+	if hexColor.MatchString(value) {
+		return value, nil
+	}
+	return "", fmt.Errorf("unknown color %q, use %s or #rrggbb", value, strings.Join(categoryColorList(), ", "))
+}
+
+// categoryColorList returns the color names, sorted
+func categoryColorList() []string {
+	names := make([]string, 0, len(categoryColorNames))
+	for name := range categoryColorNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseCategoryEmoji checks a category emoji: a single symbol, short
+// enough to sit in front of a name
+func parseCategoryEmoji(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || utf8.RuneCountInString(value) > 8 || runewidth.StringWidth(value) > 2 {
+		return "", fmt.Errorf("emoji must be a single symbol, like 🛒")
+	}
+	return value, nil
+}
+
+// categoryColor returns the color a stored category color draws with, or
+// nil for none
+func categoryColor(value string) *color.Color {
+	if attr, ok := categoryColorNames[value]; ok {
+		return color.New(attr)
+	}
+	if hexColor.MatchString(value) {
+		var r, g, b int
+		fmt.Sscanf(value, "#%02x%02x%02x", &r, &g, &b)
+		return color.RGB(r, g, b)
+	}
+	return nil
+}
+
+// categoryLabel returns category, a category name as displayed, with its
+// emoji in front. Emoji are left out with --plain and from machine-readable
+// tables.
+func categoryLabel(category string) string {
+	name := strings.TrimSuffix(category, " (internal)")
+	emoji := categoryStyles[name].Emoji
+	if emoji == "" || plainOutput || table.DefaultConfig().Format != table.FormatText {
+		return category
+	}
+	return emoji + " " + category
+}
+
+// colorizeCategory returns category, a category name as displayed, with its
+// emoji and in its color
+func colorizeCategory(category string) string {
+	label := categoryLabel(category)
+	if c := categoryDrawColor(category); c != nil {
+		return c.Sprint(label)
+	}
+	return label
+}
+
+// padCategory returns category colorized and cut or padded to width
+// terminal columns, for lining up category names in the TUIs
+func padCategory(category string, width int) string {
+	label := runewidth.Truncate(categoryLabel(category), width, "…")
+	padding := strings.Repeat(" ", max(0, width-runewidth.StringWidth(label)))
+	if c := categoryDrawColor(category); c != nil {
+		return c.Sprint(label) + padding
+	}
+	return label + padding
+}
+
+// categoryDrawColor returns the color category is drawn in, or nil to leave
+// it uncolored. Categories without a color of their own show Uncategorized
+// in red and internal categories in gray.
+func categoryDrawColor(category string) *color.Color {
+	name, internal := strings.CutSuffix(category, " (internal)")
+	if c := categoryColor(categoryStyles[name].Color); c != nil {
+		return c
+	}
+	switch {
+	case category == "Uncategorized":
+		return redColor
+	case internal:
+		return grayColor
+	}
+	return nil
+}
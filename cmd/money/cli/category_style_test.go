@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+func TestParseCategoryColor(t *testing.T) {
+	tests := map[string]string{
+		"green":   "green",
+		" Blue ":  "blue",
+		"grey":    "gray",
+		"#FF8800": "#ff8800",
+	}
+	for value, want := range tests {
+		got, err := parseCategoryColor(value)
+		if err != nil || got != want {
+			t.Errorf("parseCategoryColor(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "orange", "#f80", "ff8800"} {
+		if _, err := parseCategoryColor(value); err == nil {
+			t.Errorf("Expected an error for color %q", value)
+		}
+	}
+
+	if _, err := parseCategoryEmoji("🛒"); err != nil {
+		t.Errorf("parseCategoryEmoji(🛒) failed: %v", err)
+	}
+	if _, err := parseCategoryEmoji("groceries"); err == nil {
+		t.Error("Expected an error for an emoji that's a word")
+	}
+}
+
+func TestColorizeCategory(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	t.Cleanup(func() {
+		categoryStyles = nil
+		plainOutput = false
+		table.SetDefaultFormat(table.FormatText)
+	})
+	color.NoColor = false
+
+	setCategoryStyles([]database.Category{
+		{Name: "Groceries", Color: "green", Emoji: "🛒"},
+		{Name: "Transfers", IsInternal: true},
+		{Name: "Savings", IsInternal: true, Color: "#0000ff"},
+	})
+
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"Groceries", color.New(color.FgGreen).Sprint("🛒 Groceries")},
+		{"Uncategorized", redColor.Sprint("Uncategorized")},
+		{"Transfers (internal)", grayColor.Sprint("Transfers (internal)")},
+		{"Savings (internal)", color.RGB(0, 0, 255).Sprint("Savings (internal)")},
+		{"Rent", "Rent"},
+	}
+	for _, tt := range tests {
+		if got := colorizeCategory(tt.category); got != tt.want {
+			t.Errorf("colorizeCategory(%q) = %q, want %q", tt.category, got, tt.want)
+		}
+	}
+
+	if got := padCategory("Groceries", 14); got != color.New(color.FgGreen).Sprint("🛒 Groceries")+"  " {
+		t.Errorf("padCategory = %q, want the label padded to 14 columns", got)
+	}
+
+	plainOutput = true
+	if got := categoryLabel("Groceries"); got != "Groceries" {
+		t.Errorf("categoryLabel with --plain = %q, want Groceries", got)
+	}
+	plainOutput = false
+	table.SetDefaultFormat(table.FormatCSV)
+	if got := categoryLabel("Groceries"); got != "Groceries" {
+		t.Errorf("categoryLabel with --format csv = %q, want Groceries", got)
+	}
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/report"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Close = &Z.Cmd{
+	Name:    "close",
+	Summary: "Close a finished month, locking its categories and snapshotting its totals",
+	Usage:   "close " + forceFlags("money close", new(bool), "<YYYY-MM>").Usage(),
+	Description: `
+Closes a month like a statement: records each category's income and
+spending and each account's ending balance, then locks the month so the
+categories of its transactions can't change. Category edits to a closed
+month need --force, and 'money transactions categorize auto' skips it.
+
+Compare a closed month against what it adds up to now with
+'money report closed <YYYY-MM>'. Closing an already closed month again
+needs --force and replaces its snapshot.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		CloseList,
+		CloseReopen,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var force bool
+		set := forceFlags("money close", &force, "<YYYY-MM>")
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) != 1 {
+			return fmt.Errorf("usage: money %s", cmd.Usage)
+		}
+		month := set.Args()[0]
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			existing, err := db.GetClosedMonth(month)
+			if err != nil {
+				return err
+			}
+			if existing != nil && !force {
+				return fmt.Errorf("%s was already closed on %s; use --force to replace its snapshot", month, existing.ClosedAt)
+			}
+
+			closed, err := report.CloseMonth(db, month, format.Now())
+			if err != nil {
+				return err
+			}
+
+			var income, expenses int64
+			for _, total := range closed.Categories {
+				income += total.Income
+				expenses += total.Expenses
+			}
+			fmt.Printf("Closed %s: %s income, %s expenses across %d categories, ending balances for %d accounts\n",
+				month, format.Currency(income, format.BaseCurrency()), format.Currency(expenses, format.BaseCurrency()),
+				len(closed.Categories), len(closed.Balances))
+			return nil
+		})
+	},
+}
+
+var CloseList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List closed months",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			months, err := db.GetClosedMonths()
+			if err != nil {
+				return err
+			}
+
+			if len(months) == 0 {
+				fmt.Println("No closed months. Close one with 'money close <YYYY-MM>'.")
+				return nil
+			}
+
+			t := table.New("Month", "Closed At")
+			for _, month := range months {
+				t.AddRow(month.Month, month.ClosedAt)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var CloseReopen = &Z.Cmd{
+	Name:     "reopen",
+	Summary:  "Unlock a closed month and discard its snapshot",
+	Usage:    "reopen <YYYY-MM>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money close reopen <YYYY-MM>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ReopenMonth(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Reopened %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+// closedMonthError adds a hint about --force to errors from changing the
+// category of a transaction in a closed month
+func closedMonthError(err error) error {
+	if errors.Is(err, database.ErrMonthClosed) {
+		return fmt.Errorf("%w (use --force to change it anyway, or 'money close reopen')", err)
+	}
+	return err
+}
+
+// forceFlags returns the flags of a command that takes the given
+// positional arguments and --force, to change closed months
+func forceFlags(command string, force *bool, positional ...string) *flags.Set {
+	set := flags.New(command)
+	set.Positional(positional...)
+	set.BoolVar(force, "force", "f")
+	return set
+}
+
+// openMonthTransactions drops transactions posted in closed months before
+// they're recategorized. With force they're all kept and db lets the
+// changes through.
+func openMonthTransactions(db *database.DB, transactions []database.Transaction, force bool) ([]database.Transaction, error) {
+	if force {
+		db.AllowClosedMonthEdits(true)
+		return transactions, nil
+	}
+
+	months, err := db.GetClosedMonths()
+	if err != nil {
+		return nil, err
+	}
+	if len(months) == 0 {
+		return transactions, nil
+	}
+
+	closed := make(map[string]bool, len(months))
+	for _, month := range months {
+		closed[month.Month] = true
+	}
+
+	var open []database.Transaction
+	for _, txn := range transactions {
+		if !closed[format.PostedMonth(txn.Posted)] {
+			open = append(open, txn)
+		}
+	}
+	if skipped := len(transactions) - len(open); skipped > 0 {
+		fmt.Printf("Skipping %d transaction(s) in closed months; use --force to include them.\n", skipped)
+	}
+	return open, nil
+}
@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/report"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// completeCmdName is the hidden command the completion scripts call back into
+const completeCmdName = "__complete"
+
+// completionRoot is the command tree completions are generated from. It is
+// set in init because referring to Cmd from a command it contains would be an
+// initialization cycle.
+var completionRoot *Z.Cmd
+
+func init() {
+	completionRoot = Cmd
+}
+
+var Completion = &Z.Cmd{
+	Name:    "completion",
+	Summary: "Print a shell completion script",
+	Usage:   "bash|zsh|fish",
+	Description: `
+Prints a completion script for bash, zsh or fish. Completions cover every
+subcommand and flag, plus account IDs, category names, config keys and
+profiles, which are read from the database as you type.
+
+Examples:
+  source <(money completion bash)                          # bash, add to ~/.bashrc
+  source <(money completion zsh)                           # zsh, add to ~/.zshrc
+  money completion fish > ~/.config/fish/completions/money.fish
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money completion bash|zsh|fish")
+		}
+
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			return fmt.Errorf("unsupported shell %q, use bash, zsh or fish", args[0])
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+var completeCmd = &Z.Cmd{
+	Name:    completeCmdName,
+	Summary: "Print completion candidates for the words typed so far",
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		for _, candidate := range completeWords(completionRoot, args) {
+			fmt.Println(candidate)
+		}
+		return nil
+	},
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for money
+_money_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(money __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _money_complete money
+`,
+	"zsh": `#compdef money
+# zsh completion for money
+_money() {
+    local -a candidates
+    candidates=("${(@f)$(money __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _money money
+`,
+	"fish": `# fish completion for money
+function __money_complete
+    set -l current (commandline -ct)
+    money __complete (commandline -opc)[2..-1] "$current" 2>/dev/null
+end
+complete -c money -f -a '(__money_complete)'
+`,
+}
+
+// globalValueFlags are the global flags that take a value, see applyGlobalFlags
+var globalValueFlags = []string{"--profile", "--money-dir", "--db", "--format", "--owner"}
+
+// globalSwitches are the global flags that take no value
+var globalSwitches = []string{"--verbose", "--quiet", "--no-pager", "--no-color", "--plain", "--read-only"}
+
+func isGlobalValueFlag(word string) bool {
+	for _, flag := range globalValueFlags {
+		if word == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// completeWords returns the candidates for the last of words, the words typed
+// after "money". Earlier words select the subcommand; the rest are matched
+// against its Usage to decide what kind of value comes next.
+func completeWords(root *Z.Cmd, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	cmd := root
+	var args []string
+	typed := words[:len(words)-1]
+	for i := 0; i < len(typed); i++ {
+		word := typed[i]
+		if isGlobalValueFlag(word) {
+			if i+1 < len(typed) {
+				applyCompletionGlobalFlag(word, typed[i+1])
+				i++
+				continue
+			}
+			// The value of the global flag is being completed
+			return filterPrefix(globalFlagValues(word), current)
+		}
+		if len(args) == 0 {
+			if sub := findSubcommand(cmd, word); sub != nil {
+				cmd = sub
+				continue
+			}
+		}
+		args = append(args, word)
+	}
+
+	return filterPrefix(commandCandidates(cmd, args, current), current)
+}
+
+// applyCompletionGlobalFlag applies a global flag seen while completing so
+// values are read from the database the finished command will use
+func applyCompletionGlobalFlag(flag, value string) {
+	switch flag {
+	case "--profile":
+		config.SetProfile(value)
+	case "--money-dir":
+		if dir, err := expandHome(value); err == nil {
+			config.SetMoneyDirOverride(dir)
+		}
+	case "--db":
+		if path, err := expandHome(value); err == nil {
+			config.SetDBPathOverride(path)
+		}
+	}
+}
+
+func globalFlagValues(flag string) []string {
+	if flag == "--profile" {
+		profiles, _ := config.New().Profiles()
+		return profiles
+	}
+	if flag == "--format" {
+		return table.Formats
+	}
+	if flag == "--owner" {
+		return placeholderValues("<owner>")
+	}
+	// Paths are left to the shell's file completion
+	return nil
+}
+
+func findSubcommand(cmd *Z.Cmd, name string) *Z.Cmd {
+	for _, sub := range cmd.Commands {
+		if sub.Name == name {
+			return sub
+		}
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// commandCandidates returns what may follow args for cmd: flags when the
+// current word starts with '-', the value of the flag just typed, or the next
+// positional argument, which for commands with subcommands is their names
+func commandCandidates(cmd *Z.Cmd, args []string, current string) []string {
+	usage := parseUsage(cmd)
+
+	if len(args) > 0 {
+		if placeholder, ok := usage.flagValues[args[len(args)-1]]; ok {
+			return placeholderValues(placeholder)
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		flags := append(usage.flags, globalValueFlags...)
+		return append(flags, globalSwitches...)
+	}
+
+	position := 0
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			if _, takesValue := usage.flagValues[args[i]]; takesValue {
+				i++
+			}
+			continue
+		}
+		position++
+	}
+
+	var candidates []string
+	if position == 0 {
+		for _, sub := range cmd.Commands {
+			if !isHidden(cmd, sub.Name) {
+				candidates = append(candidates, sub.Name)
+			}
+		}
+	}
+	if position < len(usage.positional) {
+		candidates = append(candidates, usage.positional[position]...)
+	}
+	return candidates
+}
+
+func isHidden(parent *Z.Cmd, name string) bool {
+	for _, hidden := range parent.Hidden {
+		if hidden == name {
+			return true
+		}
+	}
+	return false
+}
+
+// commandUsage is what completion understands of a Usage string such as
+// "set <account-id> <amount> [--days|-d <number>] [--all]"
+type commandUsage struct {
+	flags      []string
+	flagValues map[string]string // flag -> placeholder of its value
+	positional [][]string        // per position, literal choices or resolved placeholder values
+}
+
+func parseUsage(cmd *Z.Cmd) commandUsage {
+	usage := commandUsage{flagValues: make(map[string]string)}
+
+	tokens := strings.Fields(strings.NewReplacer("[", "", "]", "").Replace(cmd.Usage))
+	if len(tokens) > 0 && tokens[0] == cmd.Name {
+		tokens = tokens[1:]
+	}
+
+	var lastFlags []string
+	for _, token := range tokens {
+		parts := strings.Split(token, "|")
+
+		if strings.HasPrefix(parts[0], "-") {
+			lastFlags = nil
+			for _, part := range parts {
+				usage.flags = append(usage.flags, part)
+				lastFlags = append(lastFlags, part)
+			}
+			continue
+		}
+
+		if lastFlags != nil {
+			// The value of the preceding flag, possibly followed by more flags
+			// as in "--category <category>|--uncategorized"
+			for _, flag := range lastFlags {
+				usage.flagValues[flag] = parts[0]
+			}
+			lastFlags = nil
+			for _, part := range parts[1:] {
+				if strings.HasPrefix(part, "-") {
+					usage.flags = append(usage.flags, part)
+				}
+			}
+			continue
+		}
+
+		// A positional argument: a placeholder or a list of literal choices
+		var choices []string
+		if len(parts) > 1 {
+			choices = parts
+		} else if strings.HasPrefix(token, "<") {
+			choices = placeholderValues(token)
+		}
+		usage.positional = append(usage.positional, choices)
+	}
+
+	return usage
+}
+
+// placeholderValues returns the known values for a Usage placeholder
+func placeholderValues(placeholder string) []string {
+	switch strings.Trim(placeholder, "<>") {
+	case "account-id":
+		return databaseValues(func(db *database.DB) ([]string, error) {
+			accounts, err := db.GetAccounts()
+			ids := make([]string, len(accounts))
+			for i, account := range accounts {
+				ids[i] = account.ID
+			}
+			return ids, err
+		})
+	case "property-account-id":
+		return databaseValues(func(db *database.DB) ([]string, error) {
+			properties, err := db.GetAllProperties()
+			ids := make([]string, len(properties))
+			for i, property := range properties {
+				ids[i] = property.AccountID
+			}
+			return ids, err
+		})
+	case "loan-account-id":
+		return databaseValues(func(db *database.DB) ([]string, error) {
+			accounts, err := db.GetAccounts()
+			var ids []string
+			for _, account := range accounts {
+				if account.AccountType != nil && *account.AccountType == "loan" {
+					ids = append(ids, account.ID)
+				}
+			}
+			return ids, err
+		})
+	case "category", "category-name":
+		return databaseValues(func(db *database.DB) ([]string, error) {
+			categories, err := db.GetCategories()
+			names := make([]string, len(categories))
+			for i, category := range categories {
+				names[i] = category.Name
+			}
+			return names, err
+		})
+	case "owner":
+		return databaseValues(func(db *database.DB) ([]string, error) {
+			return db.GetAccountOwners()
+		})
+	case "key":
+		return config.KeyNames()
+	case "tax-line":
+		return report.TaxLineKeys()
+	}
+	return nil
+}
+
+// databaseValues reads completion values from an existing database. It never
+// creates one, and errors just mean there is nothing to offer.
+func databaseValues(read func(db *database.DB) ([]string, error)) []string {
+	cfg := config.New()
+	if _, err := os.Stat(cfg.DBPath()); err != nil {
+		return nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+
+	values, err := read(db)
+	if err != nil {
+		return nil
+	}
+	return values
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
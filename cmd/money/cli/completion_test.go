@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestCompleteWords(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+	t.Cleanup(func() { config.SetProfile(config.DefaultProfile) })
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	if err := db.SaveOrganization("org1", "Bank", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveAccount("acct-checking", "org1", "Checking", "USD", 1000, nil, "2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SaveCategory("Zoo Tickets"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	show := &Z.Cmd{Name: "show", Usage: "show <account-id> [--days|-d <number>] [--category <category>|--uncategorized]"}
+	root := &Z.Cmd{
+		Name:     "money",
+		Hidden:   []string{"secret"},
+		Commands: []*Z.Cmd{show, {Name: "shell", Aliases: []string{"sh"}, Usage: "bash|zsh|fish"}, {Name: "secret"}},
+	}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{""}, []string{"show", "shell"}},
+		{[]string{"s"}, []string{"show", "shell"}},
+		{[]string{"sh", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"show", "acct"}, []string{"acct-checking"}},
+		{[]string{"show", "acct-checking", "--d"}, []string{"--days", "--db"}},
+		{[]string{"show", "acct-checking", "--category", "Zoo"}, []string{"Zoo Tickets"}},
+		{[]string{"--profile", "x", "show", "--days", "3", ""}, nil},
+		{[]string{"show", "--format", "t"}, []string{"text", "tsv"}},
+	}
+	for _, tt := range tests {
+		if got := completeWords(root, tt.words); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeWords(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Config = &Z.Cmd{
+	Name:    "config",
+	Summary: "Show and change persistent settings in config.toml",
+	Description: `
+Settings are read from two TOML files, then overridden by environment
+variables:
+
+  ~/.config/money/config.toml   per-user settings ($XDG_CONFIG_HOME is honored)
+  $MONEY_DIR/config.toml        settings for one data directory, wins over
+                                the per-user file
+
+'money config set' writes to the data directory file when it exists and to
+the per-user file otherwise. money_dir is always written to the per-user
+file. Run 'money config list' to see every key and where its value comes
+from.
+
+Categories, budgets and rules live in the database rather than in
+config.toml; 'money config export-rules' and 'import-rules' carry them
+between machines as YAML.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		ConfigList,
+		ConfigGet,
+		ConfigSet,
+		ConfigUnset,
+		ConfigProfiles,
+		ConfigExportRules,
+		ConfigImportRules,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return ConfigList.Call(cmd, args...)
+	},
+}
+
+var ConfigList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List every setting with its value and source",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		cfg := config.New()
+
+		fmt.Printf("Profile:          %s\n", cfg.Profile)
+		fmt.Printf("Data directory:   %s\n", cfg.MoneyDir)
+		fmt.Printf("Database:         %s\n", cfg.DBPath())
+		fmt.Printf("User config file: %s\n", config.UserFilePath())
+		fmt.Printf("Data config file: %s\n", cfg.DataFilePath())
+		fmt.Println()
+
+		t := table.New("Key", "Value", "Source", "Env Var")
+		for _, key := range config.Keys {
+			value := cfg.Value(key)
+			if key.Secret && value != "" {
+				value = "********"
+			}
+			source := cfg.Source(key)
+			if source == "default" {
+				source = grayColor.Sprint(source)
+			}
+			t.AddRow(key.Name, value, source, key.Env)
+		}
+		return t.Render()
+	},
+}
+
+var ConfigGet = &Z.Cmd{
+	Name:     "get",
+	Summary:  "Print the effective value of a setting",
+	Usage:    "get <key>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money config get <key>")
+		}
+		key, err := lookupConfigKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(config.New().Value(key))
+		return nil
+	},
+}
+
+var ConfigSet = &Z.Cmd{
+	Name:     "set",
+	Summary:  "Save a setting to the config file",
+	Usage:    "set <key> <value>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money config set <key> <value>")
+		}
+		key, err := lookupConfigKey(args[0])
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSpace(args[1])
+		if value == "" {
+			return fmt.Errorf("value cannot be empty; use 'money config unset %s' instead", key.Name)
+		}
+		if key.Numeric {
+			if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+				return fmt.Errorf("%s must be a positive number", key.Name)
+			}
+		}
+		if key.Name == "timezone" {
+			if _, err := time.LoadLocation(value); err != nil {
+				return fmt.Errorf("unknown timezone %q, use a name like America/New_York", value)
+			}
+		}
+
+		return updateConfigFile(key, func(values map[string]string) {
+			values[key.Name] = value
+		})
+	},
+}
+
+var ConfigUnset = &Z.Cmd{
+	Name:     "unset",
+	Summary:  "Remove a setting from the config file",
+	Usage:    "unset <key>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money config unset <key>")
+		}
+		key, err := lookupConfigKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		return updateConfigFile(key, func(values map[string]string) {
+			delete(values, key.Name)
+		})
+	},
+}
+
+var ConfigProfiles = &Z.Cmd{
+	Name:    "profiles",
+	Summary: "List profiles that have their own data directory",
+	Description: `
+Profiles keep separate books, e.g. personal and small-business, each in its
+own data directory with its own database and config.toml. Select one for a
+single command with the global --profile flag, or by default with
+MONEY_PROFILE or 'money config set profile <name>'. A profile's directory is
+$MONEY_DIR/profiles/<name>; the "default" profile is $MONEY_DIR itself. Run
+'money --profile <name> init' to set up a new one.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		cfg := config.New()
+		profiles, err := cfg.Profiles()
+		if err != nil {
+			return err
+		}
+
+		for _, profile := range profiles {
+			marker := "  "
+			if profile == cfg.Profile {
+				marker = "* "
+			}
+			fmt.Printf("%s%s\n", marker, profile)
+		}
+		return nil
+	},
+}
+
+func lookupConfigKey(name string) (config.Key, error) {
+	key, ok := config.LookupKey(name)
+	if !ok {
+		return config.Key{}, fmt.Errorf("unknown config key %q, valid keys: %s", name, strings.Join(config.KeyNames(), ", "))
+	}
+	return key, nil
+}
+
+// updateConfigFile applies update to the config file that key is written to
+// and warns when an environment variable will keep overriding the result
+func updateConfigFile(key config.Key, update func(values map[string]string)) error {
+	path := config.New().FilePath(key)
+	values, err := config.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	update(values)
+	if err := config.WriteFile(path, values); err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated %s\n", path)
+	if os.Getenv(key.Env) != "" {
+		fmt.Printf("Note: %s is set in your environment and overrides the config file\n", key.Env)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/setup"
+)
+
+func configExportRulesFlags(output *string) *flags.Set {
+	set := flags.New("money config export-rules")
+	set.StringVar(output, "output", "o", "FILE")
+	return set
+}
+
+var ConfigExportRules = &Z.Cmd{
+	Name:     "export-rules",
+	Summary:  "Write categories, budgets and rules as YAML",
+	Usage:    "export-rules " + configExportRulesFlags(new(string)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Writes the setup you built by hand, rather than the data fetched from your
+bank, as YAML: every category with its internal flag, color and emoji,
+monthly budget and tax line, the rename rules in the order they apply, and
+account default categories. Keep the file in version control, and load it
+on another machine with 'money config import-rules'.
+
+The YAML goes to standard output unless --output names a file.
+
+Example:
+  money config export-rules -o ~/dotfiles/money-rules.yaml
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var output string
+		if err := configExportRulesFlags(&output).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			s, err := setup.Export(db)
+			if err != nil {
+				return err
+			}
+			data, err := s.Marshal()
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err := os.Stdout.Write(data)
+				return err
+			}
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Printf("Wrote %d categories and %d rename rules to %s\n", len(s.Categories), len(s.RenameRules), output)
+			return nil
+		})
+	},
+}
+
+var ConfigImportRules = &Z.Cmd{
+	Name:     "import-rules",
+	Summary:  "Load categories, budgets and rules from YAML",
+	Usage:    "import-rules <file>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Reads a file written by 'money config export-rules', or - for standard
+input, and adds what it lists to the database: missing categories are
+created, and existing ones get the file's internal flag, style, and any
+budget and tax line it gives. Rename rules are added or have their
+replacement updated. Nothing the file doesn't mention is removed, so
+importing twice changes nothing the second time.
+
+Account default categories are skipped for accounts the database doesn't
+have yet; import again after the first 'money fetch' to apply them. The
+whole file is checked before anything is written.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money config %s", cmd.Usage)
+		}
+
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		s, err := setup.Parse(data)
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			result, err := setup.Import(db, s)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Imported %d categories (%d budgets, %d tax lines), %d rename rules and %d account default categories\n",
+				result.Categories, result.Budgets, result.TaxLines, result.RenameRules, result.Defaults)
+			if len(result.MissingAccounts) > 0 {
+				fmt.Printf("Skipped default categories for accounts not in this database: %s\n", strings.Join(result.MissingAccounts, ", "))
+				fmt.Println("Import again after 'money fetch' to apply them.")
+			}
+			if result.RenameRules > 0 {
+				fmt.Println("Run 'money rules rename apply' to rewrite existing transactions.")
+			}
+			return nil
+		})
+	},
+}
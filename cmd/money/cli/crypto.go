@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/internal/prompt"
+	"github.com/arjungandhi/money/pkg/crypto"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Crypto = &Z.Cmd{
+	Name:    "crypto",
+	Summary: "Track crypto wallets and exchange accounts",
+	Description: `
+Crypto accounts read their balances from a public Bitcoin or Ethereum
+address, or from a Kraken account with a read-only API key. Balances are
+priced in the account's currency with CoinGecko, saved as the account's
+holdings, and counted in net worth and trends as accounts of type crypto.
+
+Wallets are refreshed after every 'money fetch' and by 'money crypto
+refresh'.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		CryptoAdd,
+		CryptoList,
+		CryptoRefresh,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return CryptoList.Call(cmd, args...)
+	},
+}
+
+func cryptoAddFlags(currency *string) *flags.Set {
+	set := flags.New("money crypto add")
+	set.Positional("<name>", "bitcoin|ethereum|kraken", "[<address>]")
+	set.Func("currency", "", "<code>", func(value string) error {
+		*currency = strings.ToUpper(value)
+		return nil
+	})
+	return set
+}
+
+var CryptoAdd = &Z.Cmd{
+	Name:    "add",
+	Summary: "Add a crypto wallet or exchange account",
+	Usage:   "add " + cryptoAddFlags(new(string)).Usage(),
+	Description: `
+Adds a crypto account named <name> and reads its balances.
+
+bitcoin and ethereum take the wallet's public address; only ETH itself is
+read for Ethereum addresses, not tokens. kraken asks for an API key and
+secret, which only need the "Query Funds" permission.
+
+--currency sets the currency the account is valued in (default the
+currency setting, USD unless set).
+
+Examples:
+  money crypto add "Cold Storage" bitcoin bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq
+  money crypto add "MetaMask" ethereum 0xde0B295669a9FD93d5F28D9Ec85E40f4cb697BAe
+  money crypto add "Kraken" kraken --currency EUR
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		currency := format.BaseCurrency()
+		set := cryptoAddFlags(&currency)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) < 2 {
+			return fmt.Errorf("usage: money crypto %s", cmd.Usage)
+		}
+
+		name := positional[0]
+		wallet := database.CryptoWallet{Source: strings.ToLower(positional[1])}
+		switch {
+		case crypto.IsAddressSource(wallet.Source):
+			if len(positional) != 3 {
+				return fmt.Errorf("a %s wallet needs its public address: money crypto add <name> %s <address>", wallet.Source, wallet.Source)
+			}
+			wallet.Address = positional[2]
+		case wallet.Source == crypto.SourceKraken:
+			if len(positional) != 2 {
+				return fmt.Errorf("kraken accounts use an API key instead of an address")
+			}
+			wallet.APIKey = prompt.MaskedInput("Enter your Kraken API key", "")
+			wallet.APISecret = prompt.MaskedInput("Enter your Kraken API secret", "")
+		default:
+			return fmt.Errorf("unknown crypto source: %s. Valid sources are: %v", wallet.Source, crypto.SourceNames)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			service := crypto.NewService(db)
+			accountID, err := service.AddWallet(name, currency, wallet)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created crypto account %s (%s)\n", name, accountID)
+
+			refreshes, err := service.Refresh()
+			if err != nil {
+				return err
+			}
+			for _, refresh := range refreshes {
+				if refresh.Wallet.AccountID == accountID {
+					printCryptoRefresh(refresh)
+				}
+			}
+			return nil
+		})
+	},
+}
+
+var CryptoList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List crypto accounts with their balances",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			wallets, err := db.GetCryptoWallets()
+			if err != nil {
+				return err
+			}
+
+			if len(wallets) == 0 {
+				fmt.Println("No crypto accounts. Add one with 'money crypto add <name> bitcoin|ethereum|kraken'.")
+				return nil
+			}
+
+			t := table.New("Account ID", "Name", "Source", "Holdings", "Value", "Updated")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			for _, wallet := range wallets {
+				account, err := db.GetAccountByID(wallet.AccountID)
+				if err != nil {
+					return err
+				}
+				holdings, err := db.GetHoldings(wallet.AccountID)
+				if err != nil {
+					return err
+				}
+
+				var amounts []string
+				for _, holding := range holdings {
+					amounts = append(amounts, strconv.FormatFloat(holding.Shares, 'f', -1, 64)+" "+holding.Symbol)
+				}
+				updated := "never"
+				if wallet.LastUpdated != nil {
+					updated = *wallet.LastUpdated
+				}
+
+				t.AddRow(
+					wallet.AccountID,
+					account.DisplayName(),
+					wallet.Source,
+					strings.Join(amounts, ", "),
+					format.Currency(account.Balance, account.Currency),
+					updated,
+				)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var CryptoRefresh = &Z.Cmd{
+	Name:     "refresh",
+	Summary:  "Read every crypto account's balances and prices",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			refreshes, err := crypto.NewService(db).Refresh()
+			if err != nil {
+				return err
+			}
+
+			if len(refreshes) == 0 {
+				fmt.Println("No crypto accounts. Add one with 'money crypto add <name> bitcoin|ethereum|kraken'.")
+				return nil
+			}
+
+			failed := 0
+			for _, refresh := range refreshes {
+				printCryptoRefresh(refresh)
+				if refresh.Err != nil {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d crypto account(s) failed to refresh", failed)
+			}
+			return nil
+		})
+	},
+}
+
+func printCryptoRefresh(refresh crypto.WalletRefresh) {
+	name := refresh.Account.DisplayName()
+	if refresh.Err != nil {
+		fmt.Printf("%s: %v\n", name, refresh.Err)
+		return
+	}
+
+	fmt.Printf("%s: %s -> %s\n", name,
+		format.Currency(refresh.OldBalance, refresh.Account.Currency),
+		format.Currency(refresh.NewBalance, refresh.Account.Currency))
+	if len(refresh.Unpriced) > 0 {
+		fmt.Printf("  No price for %s, left out of the balance\n", strings.Join(refresh.Unpriced, ", "))
+	}
+}
@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var DB = &Z.Cmd{
+	Name:    "db",
+	Aliases: []string{"database"},
+	Summary: "Database maintenance",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		DBCheck,
+		DBMaintain,
+		DBSize,
+		DBQuery,
+		DBDump,
+		DBBackups,
+		DBRollback,
+	},
+}
+
+var DBCheck = &Z.Cmd{
+	Name:    "check",
+	Summary: "Check the database for corruption and broken references",
+	Description: `
+Runs SQLite's structural check on the database file, then looks for rows
+that reference something that no longer exists: transactions and balance
+history of deleted accounts, transactions, budgets and tax lines of deleted
+categories, and so on.
+
+Foreign keys are enforced, so new orphans can't appear, but databases
+created before they were can still have some. Nothing is changed; exits
+non-zero when a problem is found, so it can be used in scripts.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			problems := 0
+
+			corruption, err := db.QuickCheck()
+			if err != nil {
+				return err
+			}
+			if len(corruption) == 0 {
+				fmt.Println("✓ Database file: ok")
+			} else {
+				fmt.Println("✗ Database file:")
+				for _, message := range corruption {
+					fmt.Printf("    %s\n", message)
+				}
+				problems += len(corruption)
+			}
+
+			orphaned, err := db.CheckForeignKeys()
+			if err != nil {
+				return err
+			}
+			if len(orphaned) == 0 {
+				fmt.Println("✓ References: ok")
+			} else {
+				fmt.Println("✗ References:")
+				for _, ref := range orphaned {
+					missing := strings.Join(ref.Missing, ", ")
+					if ref.MissingValues > len(ref.Missing) {
+						missing += fmt.Sprintf(", and %d more", ref.MissingValues-len(ref.Missing))
+					}
+					fmt.Printf("    %d %s row(s) reference missing %s in %s.%s: %s\n",
+						ref.Rows, ref.Table, ref.Parent, ref.Table, ref.Column, missing)
+				}
+				problems += len(orphaned)
+			}
+
+			if problems > 0 {
+				return fmt.Errorf("found %d problem(s)", problems)
+			}
+			return nil
+		})
+	},
+}
+
+var DBMaintain = &Z.Cmd{
+	Name:    "maintain",
+	Aliases: []string{"vacuum", "optimize"},
+	Summary: "Rebuild indexes, update statistics, and vacuum the database",
+	Description: `
+Keeps a database with years of history fast: rebuilds every index,
+refreshes the statistics SQLite's query planner uses (ANALYZE), and
+vacuums the file to reclaim space left by deleted rows and defragment it.
+Then prints how much space each table takes.
+
+Vacuuming rewrites the whole file, so it can take a while on a large
+database and needs up to its size again in free disk space. Nothing else
+should use the database meanwhile.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			before, err := db.FileSize()
+			if err != nil {
+				return err
+			}
+
+			steps, err := db.Maintain()
+			for _, step := range steps {
+				fmt.Printf("✓ %s (%s)\n", step.Name, step.Duration.Round(time.Millisecond))
+			}
+			if err != nil {
+				return err
+			}
+
+			after, err := db.FileSize()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nDatabase size: %s -> %s\n\n", formatBytes(before), formatBytes(after))
+
+			return printTableSizes(db)
+		})
+	},
+}
+
+var DBSize = &Z.Cmd{
+	Name:     "size",
+	Summary:  "Show the database size by table",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			size, err := db.FileSize()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Database size: %s\n\n", formatBytes(size))
+
+			return printTableSizes(db)
+		})
+	},
+}
+
+// dbQueryDefaultLimit caps how many rows db query prints unless --limit
+// says otherwise
+const dbQueryDefaultLimit = 1000
+
+func dbQueryFlags(write *bool, limit *int) *flags.Set {
+	set := flags.New("money db query")
+	set.BoolVar(write, "write", "w")
+	set.IntVar(limit, "limit", "n", "N", 0)
+	set.Positional("<sql>...")
+	return set
+}
+
+var DBQuery = &Z.Cmd{
+	Name:    "query",
+	Aliases: []string{"sql"},
+	Summary: "Run a SQL query against the database and print the result as a table",
+	Usage:   "query " + dbQueryFlags(new(bool), new(int)).Usage(),
+	Description: `
+Runs one SQLite statement and prints its rows as a table, or as CSV, TSV
+or Markdown with the global --format flag. Quote the statement so the
+shell leaves it alone.
+
+Queries run on a connection that refuses writes, and only SELECT (and
+WITH) statements are accepted; the credential tables can't be read. At
+most --limit rows are printed (default 1000, 0 for all).
+
+--write runs any single statement instead, like UPDATE or DELETE, and
+prints how many rows it changed. Nothing checks what it does, so run
+'money db dump > backup.sql' first.
+
+Examples:
+  money db query "SELECT name, balance / 100.0 FROM accounts ORDER BY balance DESC"
+  money --format csv db query "SELECT * FROM transactions" --limit 0 > transactions.csv
+  money db query --write "UPDATE categories SET name = 'Dining' WHERE name = 'Restaurants'"
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var write bool
+		limit := dbQueryDefaultLimit
+		set := dbQueryFlags(&write, &limit)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		query := strings.TrimSpace(strings.Join(set.Args(), " "))
+		if query == "" {
+			return fmt.Errorf("usage: money db query %s", set.Usage())
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if write {
+				changed, err := db.Exec(query)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%d row(s) changed\n", changed)
+				return nil
+			}
+
+			result, err := db.QueryReadOnly(query, nil, limit)
+			if err != nil {
+				lower := strings.ToLower(query)
+				if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+					return fmt.Errorf("%w; pass --write to run statements that change the database", err)
+				}
+				return err
+			}
+			if len(result.Rows) == 0 && table.DefaultConfig().Format == table.FormatText {
+				fmt.Println("No results")
+				return nil
+			}
+
+			t := table.New(result.Columns...)
+			for _, row := range result.Rows {
+				t.AddRow(row...)
+			}
+			if err := t.Render(); err != nil {
+				return err
+			}
+			if result.Truncated {
+				hintf("Showing the first %d rows; use --limit to see more\n", limit)
+			}
+			return nil
+		})
+	},
+}
+
+func dbDumpFlags(noSecrets *bool) *flags.Set {
+	set := flags.New("money db dump")
+	set.BoolVar(noSecrets, "no-credentials", "")
+	return set
+}
+
+var DBDump = &Z.Cmd{
+	Name:    "dump",
+	Summary: "Print the whole database as SQL statements",
+	Usage:   "dump " + dbDumpFlags(new(bool)).Usage(),
+	Description: `
+Prints SQL that rebuilds the database, schema and rows, like the sqlite3
+shell's .dump, for a plain-text backup, diffing two databases, or moving
+the data into another tool:
+
+  money db dump > money.sql
+  sqlite3 restored.db < money.sql
+
+The dump is read in one transaction, so it's consistent even while a
+fetch is running. --no-credentials leaves out the rows of the tables
+holding the SimpleFIN, RentCast and wallet credentials, for a dump that's
+safe to share.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var noSecrets bool
+		if err := dbDumpFlags(&noSecrets).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			return db.Dump(os.Stdout, noSecrets)
+		})
+	},
+}
+
+var DBBackups = &Z.Cmd{
+	Name:    "backups",
+	Summary: "List the copies of the database taken before schema migrations",
+	Description: `
+Before a new version of money migrates the database's schema, it copies
+the database to the backups folder in the money directory. The newest
+migration_backups copies (default 5) are kept; 0 turns them off.
+Restore one with 'money db rollback'.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		backups, err := database.ListMigrationBackups(config.New())
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Println("No backups. One is taken before each schema migration.")
+			return nil
+		}
+
+		t := table.New("Taken", "Size", "File")
+		t.SetColumnTypes(table.ColumnText, table.ColumnNumber, table.ColumnText)
+		for _, backup := range backups {
+			t.AddRow(backup.TakenAt.Format("2006-01-02 15:04:05"), formatBytes(backup.Size), backup.Path)
+		}
+		return t.Render()
+	},
+}
+
+var DBRollback = &Z.Cmd{
+	Name:    "rollback",
+	Summary: "Restore the database from before the last schema migration",
+	Usage:   "rollback [<backup file>]",
+	Description: `
+Replaces the database with the copy taken before the most recent schema
+migration, or with the given file from 'money db backups'. The current
+database is kept next to it as money.db.before-rollback.
+
+Works even when the database no longer opens. This version of money
+migrates the restored copy again the next time it opens it, so after a
+migration went wrong, go back to the previous version of money until the
+fix is released.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: money db rollback [<backup file>]")
+		}
+
+		cfg := config.New()
+		var path string
+		if len(args) == 1 {
+			path = args[0]
+		} else {
+			backups, err := database.ListMigrationBackups(cfg)
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				return fmt.Errorf("no backups in %s to roll back to", cfg.BackupDir())
+			}
+			path = backups[0].Path
+		}
+
+		if err := dbutil.Close(); err != nil {
+			return fmt.Errorf("failed to close database: %w", err)
+		}
+		if err := database.RestoreBackup(cfg, path); err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored %s from %s\n", cfg.DBPath(), path)
+		fmt.Printf("The replaced database was kept as %s\n", cfg.DBPath()+".before-rollback")
+		return nil
+	},
+}
+
+func printTableSizes(db *database.DB) error {
+	sizes, err := db.TableSizes()
+	if err != nil {
+		return err
+	}
+
+	t := table.New("Table", "Rows", "Size")
+	t.SetColumnTypes(table.ColumnText, table.ColumnNumber, table.ColumnNumber)
+	for _, size := range sizes {
+		bytes := "-"
+		if size.Bytes >= 0 {
+			bytes = formatBytes(size.Bytes)
+		}
+		t.AddRow(size.Name, strconv.Itoa(size.Rows), bytes)
+	}
+	return t.Render()
+}
+
+// formatBytes formats a size in bytes with a binary unit, like 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+var Debug = &Z.Cmd{
+	Name:    "debug",
+	Summary: "Tools for reporting bugs",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		DebugExport,
+	},
+}
+
+var DebugExport = &Z.Cmd{
+	Name:    "export",
+	Summary: "Write an anonymized copy of the database to attach to a bug report",
+	Usage:   "[<path>]",
+	Description: `
+Writes a copy of the database that reproduces your data's shape without
+revealing your finances. In the copy:
+
+  - credentials, LLM call logs, edit history and rename rules are removed
+  - organization, account, bill and property names, and transaction
+    descriptions, are replaced with hashes (equal text hashes equally, so
+    duplicates and rules still behave the same)
+  - amounts and balances are rounded to one significant digit
+  - property coordinates are removed
+
+Dates, categories, account types and IDs are kept. The hashes are salted
+per export, so they can't be matched against guesses.
+
+The file defaults to money-debug-YYYYMMDD.db in the current directory and
+is never overwritten.
+
+Examples:
+  money debug export
+  money debug export /tmp/money-issue-42.db
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: money debug export [<path>]")
+		}
+
+		path := "money-debug-" + time.Now().Format("20060102") + ".db"
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ExportAnonymized(path); err != nil {
+				return err
+			}
+
+			fmt.Printf("Wrote anonymized database to %s\n", path)
+			fmt.Println("Credentials and free text were removed, names hashed and amounts rounded.")
+			fmt.Println("Please check it with 'money --db " + path + " transactions list' before sharing.")
+			return nil
+		})
+	},
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/demo"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+var Demo = &Z.Cmd{
+	Name:    "demo",
+	Summary: "Try money out on made-up data",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		DemoInit,
+	},
+}
+
+var DemoInit = &Z.Cmd{
+	Name:    "init",
+	Summary: "Create a throwaway money directory filled with synthetic data",
+	Usage:   "[<dir>]",
+	Description: `
+Creates a money directory with made-up banks, accounts (checking, savings,
+a credit card, a brokerage account and a loan), a year of transactions,
+daily balance history, budgets and bills, so every command can be explored
+without linking a real bank. Transactions from the last ten days are left
+uncategorized to try categorization on.
+
+The directory defaults to a new temporary directory. It must not already
+contain a database, so your real data is never touched. Point any command
+at it with the global --money-dir flag, or export MONEY_DIR.
+
+Examples:
+  money demo init
+  money demo init ~/money-demo
+  money --money-dir ~/money-demo balance
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: money demo init [<dir>]")
+		}
+
+		var dir string
+		var err error
+		if len(args) == 1 {
+			dir, err = expandHome(args[0])
+		} else {
+			dir, err = os.MkdirTemp("", "money-demo-")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create demo directory: %w", err)
+		}
+
+		cfg := config.New()
+		cfg.SetMoneyDir(dir)
+		if cfg.DBPath() != filepath.Join(cfg.MoneyDir, "money.db") {
+			return fmt.Errorf("--db can't be used with demo init, the demo database is created in <dir>")
+		}
+		if _, err := os.Stat(cfg.DBPath()); err == nil {
+			return fmt.Errorf("%s already contains a database", cfg.MoneyDir)
+		}
+
+		db, err := database.Open(cfg)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		summary, err := demo.Generate(db, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to generate demo data: %w", err)
+		}
+
+		fmt.Printf("Created demo data in %s\n", cfg.MoneyDir)
+		fmt.Printf("  %d accounts, %s transactions, %s balance snapshots\n",
+			summary.Accounts, format.WithCommas(int64(summary.Transactions)), format.WithCommas(int64(summary.Snapshots)))
+		fmt.Println()
+		fmt.Println("Explore it with:")
+		fmt.Printf("  money --money-dir %s balance\n", dir)
+		fmt.Printf("  money --money-dir %s ui\n", dir)
+		fmt.Println("or point every command at it for this shell:")
+		fmt.Printf("  export MONEY_DIR=%q\n", dir)
+		return nil
+	},
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Diff = &Z.Cmd{
+	Name:    "diff",
+	Summary: "Show what the last fetch changed",
+	Description: `
+Lists what the most recent 'money fetch' added and changed: new
+organizations and accounts, each account's balance before and after with
+how many new transactions it got, and the new transactions themselves.
+
+Changes are matched to the fetch by when they were written, so valuations
+and crypto balances refreshed by the fetch are included. With --owner only
+those owners' accounts are shown.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			run, err := db.GetLastFetchRun()
+			if err != nil {
+				return err
+			}
+			if run == nil {
+				fmt.Println("No fetch recorded yet. Run 'money fetch' first.")
+				return nil
+			}
+
+			changes, err := db.GetFetchChanges(*run)
+			if err != nil {
+				return err
+			}
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return err
+			}
+			byID := make(map[string]database.Account, len(accounts))
+			for _, account := range accounts {
+				byID[account.ID] = account
+			}
+
+			kind := "Fetch"
+			if run.Replay {
+				kind = "Replayed fetch"
+			}
+			fmt.Printf("%s%s on %s (%s)\n", icon("🔄 "), kind,
+				run.StartedAt.In(format.Location()).Format("2006-01-02 15:04"),
+				run.FinishedAt.Sub(run.StartedAt).Round(time.Second))
+
+			printed, err := printFetchChanges(db, changes, byID)
+			if err != nil {
+				return err
+			}
+			if !printed {
+				fmt.Println("\nNothing changed.")
+			}
+			return nil
+		})
+	},
+}
+
+// printFetchChanges prints changes, leaving out accounts whose balance
+// didn't move and that got nothing new, and reports whether there was
+// anything to print. accounts names the accounts by ID.
+func printFetchChanges(db *database.DB, changes *database.FetchChanges, accounts map[string]database.Account) (bool, error) {
+	newPerAccount := make(map[string]int)
+	for _, txn := range changes.Transactions {
+		newPerAccount[txn.AccountID]++
+	}
+	var balances []database.BalanceChange
+	for _, change := range changes.Balances {
+		if change.Before == nil || *change.Before != change.After || newPerAccount[change.AccountID] > 0 {
+			balances = append(balances, change)
+		}
+	}
+
+	if len(changes.Organizations) == 0 && len(changes.AccountIDs) == 0 &&
+		len(changes.Transactions) == 0 && len(balances) == 0 {
+		return false, nil
+	}
+
+	if len(changes.Organizations) > 0 {
+		fmt.Printf("\nNew organizations:\n")
+		for _, org := range changes.Organizations {
+			fmt.Printf("  %s\n", org.Name)
+		}
+	}
+	if len(changes.AccountIDs) > 0 {
+		fmt.Printf("\nNew accounts:\n")
+		for _, id := range changes.AccountIDs {
+			fmt.Printf("  %s\n", accountName(accounts, id))
+		}
+	}
+
+	if len(balances) > 0 {
+		fmt.Printf("\nBalances:\n")
+		t := table.New("Account", "Before", "After", "Change", "New Txns")
+		t.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnNumber)
+		t.SetRowStyle(negativeAmounts(1, 2, 3))
+		for _, change := range balances {
+			currency := accounts[change.AccountID].Currency
+			before, delta := "-", "-"
+			if change.Before != nil {
+				before = format.Currency(*change.Before, currency)
+				delta = format.Currency(change.After-*change.Before, currency)
+			}
+			t.AddRow(accountName(accounts, change.AccountID), before, format.Currency(change.After, currency),
+				delta, strconv.Itoa(newPerAccount[change.AccountID]))
+		}
+		if err := t.Render(); err != nil {
+			return false, err
+		}
+	}
+
+	if len(changes.Transactions) > 0 {
+		categories, err := db.GetCategories()
+		if err != nil {
+			return false, err
+		}
+		categoryNames := make(map[int]string, len(categories))
+		for _, category := range categories {
+			categoryNames[category.ID] = category.Name
+			if category.IsInternal {
+				categoryNames[category.ID] += " (internal)"
+			}
+		}
+		setCategoryStyles(categories)
+
+		fmt.Printf("\nNew transactions (%d):\n", len(changes.Transactions))
+		t := table.New("Account", "Date", "Description", "Amount", "Category")
+		t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnText)
+		t.SetRowStyle(negativeAmounts(3))
+		for _, txn := range changes.Transactions {
+			category := ""
+			if txn.CategoryID != nil {
+				category = categoryNames[*txn.CategoryID]
+			}
+			description := txn.DisplayDescription()
+			if txn.Pending {
+				description += " (pending)"
+			}
+			t.AddRow(accountName(accounts, txn.AccountID), format.PostedDate(txn.Posted), description,
+				format.Currency(txn.Amount, accounts[txn.AccountID].Currency), colorizeCategory(category))
+		}
+		if err := t.Render(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// accountName returns an account's display name, or its ID when it's gone
+func accountName(accounts map[string]database.Account, id string) string {
+	if account, exists := accounts[id]; exists {
+		return account.DisplayName()
+	}
+	return id
+}
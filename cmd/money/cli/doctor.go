@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/property"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+var Doctor = &Z.Cmd{
+	Name:    "doctor",
+	Summary: "Check the setup and report problems",
+	Description: `
+Checks that money is set up correctly and reports what needs attention:
+
+  Config files   config.toml files parse and only use known keys
+  Database       the database exists and opens
+  SimpleFIN      credentials are stored, the bridge answers, and it speaks
+                 a SimpleFIN protocol version money handles (optional
+                 when banks are linked through GoCardless or Teller)
+  GoCardless     no linked bank's access has run out or runs out within
+                 a week (optional)
+  Teller         the client certificate loads when banks are enrolled
+                 (optional)
+  Accounts       every linked account updated in the last
+                 stale_account_days days (7 by default)
+  Valuations     a property valuation provider (RentCast or ATTOM) has an
+                 API key (optional)
+  LLM command    the categorization command is installed (optional)
+
+Exits non-zero when a required check fails, so it can be used in scripts.
+The SimpleFIN check only calls the bridge's /info endpoint, which doesn't
+count against your account's request quota.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		results := runDoctorChecks(config.New())
+		failed := 0
+		for _, result := range results {
+			fmt.Printf("%s%-13s %s\n", result.status.icon(), result.name, result.detail)
+			if result.status == checkFail {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) icon() string {
+	if plainOutput {
+		return s.label()
+	}
+	switch s {
+	case checkOK:
+		return "✅ "
+	case checkWarn:
+		return "⚠️  "
+	default:
+		return "❌ "
+	}
+}
+
+// label is the status written out, for --plain
+func (s checkStatus) label() string {
+	switch s {
+	case checkOK:
+		return "[ok]   "
+	case checkWarn:
+		return "[warn] "
+	default:
+		return "[fail] "
+	}
+}
+
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+}
+
+// runDoctorChecks runs every check in order. Checks that need the database
+// are skipped when it can't be opened.
+func runDoctorChecks(cfg *config.Config) []checkResult {
+	results := []checkResult{checkConfigFiles(cfg)}
+
+	dbResult, db := checkDatabase(cfg)
+	results = append(results, dbResult)
+	if db != nil {
+		defer db.Close()
+		results = append(results, checkSimpleFIN(db), checkGoCardless(db), checkTeller(db), checkStaleAccounts(db, time.Now()), checkValuations(db))
+	} else {
+		results = append(results,
+			checkResult{"SimpleFIN", checkFail, "skipped, needs the database"},
+			checkResult{"GoCardless", checkWarn, "skipped, needs the database"},
+			checkResult{"Teller", checkWarn, "skipped, needs the database"},
+			checkResult{"Accounts", checkWarn, "skipped, needs the database"},
+			checkResult{"Valuations", checkWarn, "skipped, needs the database"})
+	}
+
+	return append(results, checkLLMCommand(cfg))
+}
+
+func checkConfigFiles(cfg *config.Config) checkResult {
+	var found []string
+	for _, path := range []string{config.UserFilePath(), cfg.DataFilePath()} {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if _, err := config.ReadFile(path); err != nil {
+			return checkResult{"Config files", checkFail, err.Error()}
+		}
+		found = append(found, path)
+	}
+
+	if len(found) == 0 {
+		return checkResult{"Config files", checkOK, "none, using environment variables and defaults"}
+	}
+	return checkResult{"Config files", checkOK, strings.Join(found, ", ")}
+}
+
+// checkDatabase opens the database without creating it. The returned
+// database is nil when the check failed.
+func checkDatabase(cfg *config.Config) (checkResult, *database.DB) {
+	path := cfg.DBPath()
+	if _, err := os.Stat(path); err != nil {
+		return checkResult{"Database", checkFail, path + " not found, run 'money init'"}, nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return checkResult{"Database", checkFail, err.Error()}, nil
+	}
+
+	count, err := db.CountTransactions(database.TransactionFilter{})
+	if err != nil {
+		db.Close()
+		return checkResult{"Database", checkFail, err.Error()}, nil
+	}
+	return checkResult{"Database", checkOK, fmt.Sprintf("%s (%s transactions)", path, format.WithCommas(int64(count)))}, db
+}
+
+func checkSimpleFIN(db *database.DB) checkResult {
+	hasCredentials, err := db.HasCredentials()
+	if err != nil {
+		return checkResult{"SimpleFIN", checkFail, err.Error()}
+	}
+	if !hasCredentials {
+		banks, err := db.GetGoCardlessBanks()
+		if err == nil && len(banks) > 0 {
+			return checkResult{"SimpleFIN", checkOK, "not configured, banks are linked through GoCardless"}
+		}
+		enrollments, err := db.GetTellerEnrollments()
+		if err == nil && len(enrollments) > 0 {
+			return checkResult{"SimpleFIN", checkOK, "not configured, banks are enrolled through Teller"}
+		}
+		return checkResult{"SimpleFIN", checkFail, "not configured, run 'money init simplefin'"}
+	}
+
+	accessURL, username, password, err := db.GetCredentials()
+	if err != nil {
+		return checkResult{"SimpleFIN", checkFail, err.Error()}
+	}
+	host := accessURL
+	if parsed, err := url.Parse(accessURL); err == nil {
+		host = parsed.Host
+	}
+
+	client := simplefin.NewClient(accessURL, username, password)
+	info, err := client.GetInfo()
+	if err != nil {
+		return checkResult{"SimpleFIN", checkFail, fmt.Sprintf("%s: %v", host, err)}
+	}
+	if warning := simpleFINVersionWarning(info); warning != "" {
+		return checkResult{"SimpleFIN", checkWarn, fmt.Sprintf("%s: %s", host, warning)}
+	}
+	return checkResult{"SimpleFIN", checkOK, fmt.Sprintf("%s, protocol %s", host, strings.Join(info.Versions, ", "))}
+}
+
+// checkGoCardless warns about linked banks whose access has run out or is
+// about to. It reads only the stored expiry dates, since GoCardless limits
+// how often each bank can be asked for anything.
+func checkGoCardless(db *database.DB) checkResult {
+	banks, err := db.GetGoCardlessBanks()
+	if err != nil {
+		return checkResult{"GoCardless", checkFail, err.Error()}
+	}
+	if len(banks) == 0 {
+		return checkResult{"GoCardless", checkOK, "no banks linked ('money init gocardless' links EU and UK banks)"}
+	}
+
+	today := format.Now().Format("2006-01-02")
+	warnBefore := format.Now().AddDate(0, 0, goCardlessExpiryWarningDays).Format("2006-01-02")
+	var expiring []string
+	for _, bank := range banks {
+		switch {
+		case bank.ExpiresAt < today:
+			expiring = append(expiring, fmt.Sprintf("%s expired %s", bank.InstitutionName, bank.ExpiresAt))
+		case bank.ExpiresAt <= warnBefore:
+			expiring = append(expiring, fmt.Sprintf("%s expires %s", bank.InstitutionName, bank.ExpiresAt))
+		}
+	}
+	if len(expiring) > 0 {
+		return checkResult{"GoCardless", checkWarn, fmt.Sprintf("renew access with 'money init gocardless --institution <id>': %s",
+			strings.Join(expiring, "; "))}
+	}
+	return checkResult{"GoCardless", checkOK, fmt.Sprintf("%d bank(s) linked", len(banks))}
+}
+
+// checkTeller checks that the client certificate Teller requests are made
+// with loads when banks are enrolled
+func checkTeller(db *database.DB) checkResult {
+	enrollments, err := db.GetTellerEnrollments()
+	if err != nil {
+		return checkResult{"Teller", checkFail, err.Error()}
+	}
+	if len(enrollments) == 0 {
+		return checkResult{"Teller", checkOK, "no banks enrolled ('money init teller' enrolls US banks)"}
+	}
+	if _, err := newTellerClient(db); err != nil {
+		return checkResult{"Teller", checkFail, err.Error()}
+	}
+	return checkResult{"Teller", checkOK, fmt.Sprintf("%d bank(s) enrolled", len(enrollments))}
+}
+
+// checkStaleAccounts warns about linked accounts that haven't updated in
+// stale_account_days, since SimpleFIN keeps returning the last balance it
+// got when a bank connection breaks
+func checkStaleAccounts(db *database.DB, now time.Time) checkResult {
+	days := db.GetConfig().StaleAccountDays
+	if days <= 0 {
+		return checkResult{"Accounts", checkOK, "not checked for staleness (stale_account_days is 0)"}
+	}
+
+	stale, err := db.GetStaleAccounts(now.AddDate(0, 0, -days))
+	if err != nil {
+		return checkResult{"Accounts", checkFail, err.Error()}
+	}
+	if len(stale) == 0 {
+		return checkResult{"Accounts", checkOK, fmt.Sprintf("every linked account updated in the last %d days", days)}
+	}
+
+	summaries := make([]string, len(stale))
+	for i, account := range stale {
+		summaries[i] = staleAccountSummary(account, now)
+	}
+	return checkResult{"Accounts", checkWarn, fmt.Sprintf("%d not updated in %d days or more, fix the connection at SimpleFIN Bridge: %s",
+		len(stale), days, strings.Join(summaries, "; "))}
+}
+
+func checkValuations(db *database.DB) checkResult {
+	providers := property.NewService(db).Providers()
+	if len(providers) == 0 {
+		return checkResult{"Valuations", checkWarn, "no provider API key, property values won't update ('money init rentcast')"}
+	}
+	return checkResult{"Valuations", checkOK, strings.Join(providers, ", ")}
+}
+
+func checkLLMCommand(cfg *config.Config) checkResult {
+	fields := strings.Fields(cfg.LLMPromptCmd)
+	if len(fields) == 0 {
+		return checkResult{"LLM command", checkWarn, "not set, LLM categorization is unavailable"}
+	}
+
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		return checkResult{"LLM command", checkWarn, fmt.Sprintf("%q not found in PATH, LLM categorization is unavailable", fields[0])}
+	}
+	return checkResult{"LLM command", checkOK, fmt.Sprintf("%s (%s)", cfg.LLMPromptCmd, path)}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+func TestRunDoctorChecks(t *testing.T) {
+	moneyDir := t.TempDir()
+	t.Setenv("MONEY_DIR", moneyDir)
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LLM_PROMPT_CMD", "money-doctor-missing-llm")
+	t.Setenv("RENTCAST_API_KEY", "")
+
+	statuses := func() map[string]checkStatus {
+		byName := make(map[string]checkStatus)
+		for _, result := range runDoctorChecks(config.New()) {
+			byName[result.name] = result.status
+		}
+		return byName
+	}
+
+	got := statuses()
+	if got["Database"] != checkFail || got["SimpleFIN"] != checkFail {
+		t.Errorf("expected database and SimpleFIN checks to fail without a database, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(moneyDir, "money.db")); err == nil {
+		t.Error("doctor must not create the database")
+	}
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	db.Close()
+
+	got = statuses()
+	want := map[string]checkStatus{
+		"Config files": checkOK,
+		"Database":     checkOK,
+		"SimpleFIN":    checkFail,
+		"GoCardless":   checkOK,
+		"Teller":       checkOK,
+		"Accounts":     checkOK,
+		"Valuations":   checkWarn,
+		"LLM command":  checkWarn,
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: status %d, want %d", name, got[name], status)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(moneyDir, config.FileName), []byte("bogus_key = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := statuses(); got["Config files"] != checkFail {
+		t.Error("expected an unknown config key to fail the config check")
+	}
+}
+
+func TestSimpleFINVersionWarning(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{[]string{"1.0"}, ""},
+		{[]string{"1.0", "2.0"}, "also speaks SimpleFIN 2.0"},
+		{[]string{"2.0"}, "only handles"},
+		{nil, "didn't report"},
+	}
+	for _, tt := range tests {
+		warning := simpleFINVersionWarning(&simplefin.Info{Versions: tt.versions})
+		if (tt.want == "") != (warning == "") || !strings.Contains(warning, tt.want) {
+			t.Errorf("simpleFINVersionWarning(%v) = %q, want %q", tt.versions, warning, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/gnucash"
+)
+
+var Export = &Z.Cmd{
+	Name:    "export",
+	Summary: "Export your books for other accounting software",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		ExportGnuCash,
+	},
+}
+
+func exportGnuCashFlags(opts *gnucash.Options, year *int, output *string) *flags.Set {
+	set := flags.New("money export gnucash")
+	set.IntVar(year, "year", "y", "YYYY", 1900)
+	set.Func("start", "", "YYYY-MM-DD", dateFlag(&opts.StartDate))
+	set.Func("end", "", "YYYY-MM-DD", dateFlag(&opts.EndDate))
+	set.StringVar(output, "output", "o", "FILE")
+	return set
+}
+
+var ExportGnuCash = &Z.Cmd{
+	Name:     "gnucash",
+	Summary:  "Write accounts and transactions as a GnuCash book",
+	Usage:    "gnucash " + exportGnuCashFlags(&gnucash.Options{}, new(int), new(string)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Writes your accounts and posted transactions as an uncompressed GnuCash XML
+book, which GnuCash opens with File > Open, for handing to an accountant.
+
+Each account goes under Assets, or Liabilities for credit cards and loans,
+and each category under Income or Expenses by which way its money went,
+with Uncategorized as its own account. Every transaction is a split
+between its account and its category. Internal categories like Transfers
+become asset accounts that net to zero once both sides of each transfer
+are in. An opening balance from Equity makes each account's GnuCash
+balance match money's at the end of the period.
+
+All history is exported unless --year, or --start and --end, pick a
+period. The book goes to standard output unless --output names a file;
+exporting the same data again gives the same file.
+
+Example:
+  money export gnucash --year 2025 -o books-2025.gnucash
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts gnucash.Options
+		var year int
+		var output string
+		set := exportGnuCashFlags(&opts, &year, &output)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if set.Changed("year") {
+			if set.Changed("start") || set.Changed("end") {
+				return fmt.Errorf("use either --year or --start and --end")
+			}
+			opts.StartDate = fmt.Sprintf("%04d-01-01", year)
+			opts.EndDate = fmt.Sprintf("%04d-12-31", year)
+		}
+		if opts.StartDate != "" && opts.EndDate != "" && opts.StartDate > opts.EndDate {
+			return fmt.Errorf("--start must be on or before --end")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			book, err := gnucash.Build(db, opts)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				return book.Write(os.Stdout)
+			}
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", output, err)
+			}
+			if err := book.Write(file); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Printf("Wrote %d accounts and %d transactions to %s\n", book.Accounts(), book.Transactions(), output)
+			return nil
+		})
+	},
+}
@@ -1,83 +1,182 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strconv"
 	"time"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/alerts"
+	"github.com/arjungandhi/money/pkg/asset"
+	"github.com/arjungandhi/money/pkg/crypto"
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/notify"
 	"github.com/arjungandhi/money/pkg/property"
+	"github.com/arjungandhi/money/pkg/provider"
 	"github.com/arjungandhi/money/pkg/simplefin"
 )
 
+// fetchOptions holds the flags of money fetch
+type fetchOptions struct {
+	days      int
+	all       bool
+	recordDir string
+	replayDir string
+	dryRun    bool
+}
+
+func fetchFlags(opts *fetchOptions) *flags.Set {
+	set := flags.New("money fetch")
+	set.IntVar(&opts.days, "days", "d", "<number>", 1)
+	set.BoolVar(&opts.all, "all", "a")
+	set.Func("record", "", "<dir>", dirFlag(&opts.recordDir))
+	set.Func("replay", "", "<dir>", dirFlag(&opts.replayDir))
+	set.BoolVar(&opts.dryRun, "dry-run", "n")
+	return set
+}
+
+// dirFlag returns a flag setter that stores a directory in p, expanding a
+// leading ~
+func dirFlag(p *string) func(string) error {
+	return func(value string) error {
+		dir, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		*p = dir
+		return nil
+	}
+}
+
 var Fetch = &Z.Cmd{
 	Name:    "fetch",
 	Aliases: []string{"f", "sync"},
-	Summary: "Sync latest data from SimpleFIN",
-	Usage:   "[--days|-d <number>] [--all|-a]",
+	Summary: "Sync latest data from SimpleFIN, GoCardless and Teller",
+	Usage:   fetchFlags(&fetchOptions{}).Usage(),
 	Description: `
-Sync account and transaction data from SimpleFIN.
+Sync account and transaction data from SimpleFIN, GoCardless and Teller.
 
 By default, fetches complete transaction history. Use --days to limit
 to a specific number of recent days.
 
+--record saves the raw SimpleFIN responses to a directory as numbered JSON
+files (credentials are never written). --replay feeds recorded responses
+back through the sync instead of contacting SimpleFIN, for offline
+development and testing; property valuations, crypto balances,
+notifications and the push to Actual are skipped while replaying.
+
+Banks linked with 'money init gocardless' or 'money init teller' are
+fetched in the same sync, and are all that's needed if you don't use
+SimpleFIN. A bank whose access has lapsed is reported and skipped.
+
+When accounts are mapped with 'money actual map', the last 30 days and
+their balances are pushed to Actual Budget after the fetch.
+
+A progress line on stderr shows how far the sync has got; --quiet hides
+it.
+
+--dry-run contacts SimpleFIN (or replays) as usual but saves nothing:
+it lists the organizations, accounts and transactions the fetch would
+add and each balance it would change, then stops. Try it before
+connecting a new institution, or after upgrading money.
+
 Examples:
   money fetch           # Complete history (default)
   money fetch -d 7      # Last 7 days only
   money fetch --days 30 # Last 30 days only
   money fetch --all     # Complete history (explicit)
+  money fetch --dry-run # Show what would change without saving it
+  money fetch --record ~/simplefin-recordings
+  money --money-dir /tmp/money-test fetch --replay ~/simplefin-recordings
 `,
 	Commands: []*Z.Cmd{help.Cmd},
-	Call: func(cmd *Z.Cmd, args ...string) error {
-		fmt.Println("Fetching data from SimpleFIN...")
-
-		days := 30
-		fetchAll := true
-		for i, arg := range args {
-			switch {
-			case (arg == "--days" || arg == "-d") && i+1 < len(args):
-				if parsedDays, err := strconv.Atoi(args[i+1]); err == nil && parsedDays > 0 {
-					days = parsedDays
-					fetchAll = false
-				}
-			case arg == "--all" || arg == "-a":
-				fetchAll = true
-			}
+	Call: func(cmd *Z.Cmd, args ...string) (err error) {
+		opts := fetchOptions{days: 30}
+		set := fetchFlags(&opts)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		days, recordDir, replayDir := opts.days, opts.recordDir, opts.replayDir
+		fetchAll := opts.all || !set.Changed("days")
+		if recordDir != "" && replayDir != "" {
+			return fmt.Errorf("--record and --replay can't be used together")
 		}
+		replaying := replayDir != ""
+
+		defer func() {
+			if err != nil && !replaying && !opts.dryRun {
+				sendNotification(notify.Message{Title: "money: fetch failed", Body: err.Error()})
+			}
+		}()
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return fmt.Errorf("failed to initialize database: %w", err)
 		}
-		defer db.Close()
 
-		accessURL, username, password, err := db.GetCredentials()
+		connections, err := provider.Open(db, provider.Options{RecordDir: recordDir, ReplayDir: replayDir})
+		if len(connections) == 0 {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("no credentials found - run 'money init' first")
+		}
 		if err != nil {
-			return fmt.Errorf("failed to load credentials: %w", err)
+			slog.Warn("skipping sources that failed to open", "err", err)
 		}
 
-		client := simplefin.NewClient(accessURL, username, password)
-
-		fmt.Println("Connecting to SimpleFIN API...")
-
-		var options *simplefin.AccountsOptions
+		var since time.Time
 		if fetchAll {
 			fmt.Println("Fetching complete transaction history...")
-			options = nil
 		} else {
-			startDate := time.Now().AddDate(0, 0, -days)
+			since = time.Now().AddDate(0, 0, -days)
 			fmt.Printf("Fetching transactions from the last %d days...\n", days)
-			options = &simplefin.AccountsOptions{
-				StartDate: &startDate,
+		}
+
+		// A source that fails is skipped so the others are still saved;
+		// the fetch only fails when every source does
+		accountsData := &simplefin.AccountsResponse{}
+		var failures []error
+		for _, connection := range connections {
+			fmt.Printf("Fetching data from %s...\n", connection.Name)
+			waiting := startProgress("Waiting for "+connection.Name, 0)
+			accounts, err := provider.Read(connection.Provider, since)
+			waiting.Done()
+			if err != nil {
+				failures = append(failures, fmt.Errorf("failed to fetch account data from %s: %w", connection.Name, err))
+				continue
 			}
+			accountsData.Accounts = append(accountsData.Accounts, accounts...)
+		}
+		if len(failures) == len(connections) {
+			return errors.Join(failures...)
+		}
+		for _, failure := range failures {
+			slog.Warn("skipping a source that failed", "err", failure)
 		}
 
-		accountsData, err := client.GetAccountsWithOptions(options)
-		if err != nil {
-			return fmt.Errorf("failed to fetch account data from SimpleFIN: %w", err)
+		if opts.dryRun {
+			changes, accounts, err := planFetch(db, accountsData)
+			if err != nil {
+				return err
+			}
+			printed, err := printFetchChanges(db, changes, accounts)
+			if err != nil {
+				return err
+			}
+			if !printed {
+				fmt.Printf("\nDry run: all data is up to date, nothing would change.\n")
+			} else {
+				fmt.Printf("\nDry run: nothing was saved. Run 'money fetch' without --dry-run to save these changes.\n")
+			}
+			return nil
 		}
 
 		var stats syncStats
@@ -102,13 +201,15 @@ Examples:
 		}
 
 		fmt.Printf("Processing %d accounts...\n", len(accountsData.Accounts))
+		accountProgress := startProgress("Accounts", len(accountsData.Accounts))
+		defer accountProgress.Done()
 		for _, account := range accountsData.Accounts {
 			balance, err := simplefin.ParseAmountToCents(account.Balance)
 			if err != nil {
 				return fmt.Errorf("failed to parse balance for account %s: %w", account.Name, err)
 			}
 
-			var availableBalance *int
+			var availableBalance *int64
 			if account.AvailableBalance != nil {
 				availBalCents, err := simplefin.ParseAmountToCents(*account.AvailableBalance)
 				if err != nil {
@@ -138,15 +239,36 @@ Examples:
 			); err != nil {
 				return fmt.Errorf("failed to save account %s: %w", account.Name, err)
 			}
+			if err := db.MarkAccountSynced(account.ID); err != nil {
+				return err
+			}
 
 			if err := db.SaveBalanceHistory(account.ID, balance, availableBalance); err != nil {
 				return fmt.Errorf("failed to save balance history for account %s: %w", account.Name, err)
 			}
 
+			holdings, err := holdingsFromSimpleFIN(account)
+			if err != nil {
+				return err
+			}
+			if err := db.SaveHoldings(account.ID, holdings); err != nil {
+				return fmt.Errorf("failed to save holdings for account %s: %w", account.Name, err)
+			}
+			stats.holdingsProcessed += len(holdings)
+
 			stats.accountsProcessed++
+			accountProgress.Add(1)
 		}
+		accountProgress.Done()
 
 		fmt.Printf("Processing transactions...\n")
+		totalTransactions := 0
+		for _, account := range accountsData.Accounts {
+			totalTransactions += len(account.Transactions)
+		}
+		transactionProgress := startProgress("Transactions", totalTransactions)
+		defer transactionProgress.Done()
+		newTransactionIDs := []string{}
 		for _, account := range accountsData.Accounts {
 			for _, transaction := range account.Transactions {
 				exists, err := db.TransactionExists(transaction.ID)
@@ -179,44 +301,265 @@ Examples:
 
 				if !exists {
 					stats.newTransactions++
+					newTransactionIDs = append(newTransactionIDs, transaction.ID)
 				}
 				stats.transactionsProcessed++
+				transactionProgress.Add(1)
 			}
 		}
+		transactionProgress.Done()
+
+		// Give new transactions their clean display descriptions
+		if _, err := db.ApplyRenameRules(newTransactionIDs); err != nil {
+			return fmt.Errorf("failed to apply rename rules: %w", err)
+		}
+
+		// File new transactions from accounts with a default category
+		stats.defaultCategorized, err = db.ApplyAccountDefaultCategories(newTransactionIDs)
+		if err != nil {
+			return fmt.Errorf("failed to apply default categories: %w", err)
+		}
 
 		stats.duration = time.Since(stats.startTime)
 
-		// Update property valuations if API key is configured
-		propertyService := property.NewService(db)
-		if hasAPIKey, err := db.HasRentCastAPIKey(); err == nil && hasAPIKey {
-			fmt.Printf("\nUpdating property valuations...\n")
-			if err := propertyService.UpdateAllPropertyValuations(); err != nil {
-				fmt.Printf("Warning: Failed to update property valuations: %v\n", err)
-				fmt.Printf("You can manually update them later with 'money property update-all'\n")
-			} else {
-				fmt.Printf("Property valuations updated successfully.\n")
-			}
-		} else {
-			// Check if there are any properties
-			if properties, err := propertyService.ListAllProperties(); err == nil && len(properties) > 0 {
-				fmt.Printf("\nNote: You have %d property account(s) but no RentCast API key configured.\n", len(properties))
-				fmt.Printf("Run 'money property config <api-key>' to enable automatic property valuation updates.\n")
-			}
+		// Replays stay offline, so valuations are only refreshed on real fetches
+		if !replaying {
+			updatePropertyValuations(db)
+			updateAssetValues(db)
+			refreshCryptoWallets(db)
+		}
+
+		if err := db.RecordFetchRun(stats.startTime, time.Now(), replaying); err != nil {
+			slog.Warn("failed to record fetch for money diff", "err", err)
 		}
 
 		printSyncSummary(stats)
 
+		if !replaying {
+			pushToActual(db)
+		}
+
+		now := time.Now()
+		raised, err := alerts.Evaluate(db, now)
+		if err != nil {
+			slog.Warn("failed to evaluate alerts", "err", err)
+		} else if len(raised) > 0 {
+			fmt.Printf("\nAlerts:\n")
+			printAlerts(raised)
+			if !replaying {
+				notifyAlerts(db, raised, now)
+			}
+		}
+
 		return nil
 	},
 }
 
+// planFetch works out what saving accountsData would change without writing
+// anything: the organizations, accounts and transactions it would add, and
+// each reported balance against the stored one. The returned map has both
+// stored and new accounts, for naming them.
+func planFetch(db *database.DB, accountsData *simplefin.AccountsResponse) (*database.FetchChanges, map[string]database.Account, error) {
+	orgs, err := db.GetOrganizations()
+	if err != nil {
+		return nil, nil, err
+	}
+	knownOrgs := make(map[string]bool, len(orgs))
+	for _, org := range orgs {
+		knownOrgs[org.ID] = true
+	}
+	stored, err := db.GetAccounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	accounts := make(map[string]database.Account, len(stored))
+	for _, account := range stored {
+		accounts[account.ID] = account
+	}
+
+	changes := &database.FetchChanges{}
+	seen := make(map[string]bool)
+	for _, account := range accountsData.Accounts {
+		if !knownOrgs[account.Org.ID] {
+			knownOrgs[account.Org.ID] = true
+			changes.Organizations = append(changes.Organizations, database.Organization{
+				ID:   account.Org.ID,
+				Name: account.Org.Name,
+				URL:  account.Org.URL,
+			})
+		}
+
+		balance, err := simplefin.ParseAmountToCents(account.Balance)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse balance for account %s: %w", account.Name, err)
+		}
+		change := database.BalanceChange{AccountID: account.ID, After: balance}
+		if existing, exists := accounts[account.ID]; exists {
+			change.Before = &existing.Balance
+		} else {
+			currency := account.Currency
+			if currency == "" {
+				currency = "USD"
+			}
+			accounts[account.ID] = database.Account{ID: account.ID, OrgID: account.Org.ID, Name: account.Name, Currency: currency}
+			changes.AccountIDs = append(changes.AccountIDs, account.ID)
+		}
+		changes.Balances = append(changes.Balances, change)
+
+		for _, transaction := range account.Transactions {
+			if seen[transaction.ID] {
+				continue
+			}
+			seen[transaction.ID] = true
+
+			exists, err := db.TransactionExists(transaction.ID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to check transaction existence: %w", err)
+			}
+			if exists {
+				continue
+			}
+			amount, err := simplefin.ParseAmountToCents(transaction.Amount)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse amount for transaction %s: %w", transaction.ID, err)
+			}
+			changes.Transactions = append(changes.Transactions, database.Transaction{
+				ID:          transaction.ID,
+				AccountID:   account.ID,
+				Posted:      simplefin.UnixTimestampToISO(transaction.Posted),
+				Amount:      amount,
+				Description: transaction.Description,
+				Pending:     transaction.Pending != nil && *transaction.Pending,
+			})
+		}
+	}
+
+	// Same order as GetFetchChanges
+	sort.Slice(changes.Organizations, func(i, j int) bool {
+		return changes.Organizations[i].Name < changes.Organizations[j].Name
+	})
+	sort.Strings(changes.AccountIDs)
+	sort.Slice(changes.Balances, func(i, j int) bool {
+		return changes.Balances[i].AccountID < changes.Balances[j].AccountID
+	})
+	sort.SliceStable(changes.Transactions, func(i, j int) bool {
+		a, b := changes.Transactions[i], changes.Transactions[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		return a.Posted < b.Posted
+	})
+	return changes, accounts, nil
+}
+
+// holdingsFromSimpleFIN converts an account's reported holdings, so 'money
+// holdings refresh' can revalue them between syncs
+func holdingsFromSimpleFIN(account simplefin.Account) ([]database.Holding, error) {
+	var holdings []database.Holding
+	for _, h := range account.Holdings {
+		holding := database.Holding{
+			ID:          h.ID,
+			Symbol:      h.Symbol,
+			Description: h.Description,
+			Currency:    h.Currency,
+		}
+		if holding.ID == "" {
+			holding.ID = account.ID + ":" + h.Symbol
+		}
+		if h.Created != nil && *h.Created > 0 {
+			holding.Acquired = time.Unix(*h.Created, 0).UTC().Format("2006-01-02")
+		}
+
+		if h.Shares != "" {
+			shares, err := strconv.ParseFloat(h.Shares, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse shares for holding %s in account %s: %w", holding.ID, account.Name, err)
+			}
+			holding.Shares = shares
+		}
+
+		marketValue, err := simplefin.ParseAmountToCents(h.MarketValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse market value for holding %s in account %s: %w", holding.ID, account.Name, err)
+		}
+		holding.MarketValue = marketValue
+
+		if h.CostBasis != "" {
+			costBasis, err := simplefin.ParseAmountToCents(h.CostBasis)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cost basis for holding %s in account %s: %w", holding.ID, account.Name, err)
+			}
+			holding.CostBasis = &costBasis
+		}
+
+		holdings = append(holdings, holding)
+	}
+	return holdings, nil
+}
+
+// updatePropertyValuations refreshes property values after a fetch when a
+// valuation provider is configured
+func updatePropertyValuations(db *database.DB) {
+	propertyService := property.NewService(db)
+	if propertyService.HasProviders() {
+		fmt.Printf("\nUpdating property valuations...\n")
+		maxAgeDays := db.GetConfig().PropertyMaxAgeDays
+		summary, err := propertyService.UpdateAllPropertyValuations(time.Duration(maxAgeDays) * 24 * time.Hour)
+		if err != nil {
+			slog.Warn("failed to update property valuations", "err", err)
+			return
+		}
+		printPropertyUpdateSummary(summary, maxAgeDays)
+		if len(summary.Failed) > 0 {
+			fmt.Printf("You can retry them later with 'money property update-all'\n")
+		}
+	} else {
+		// Check if there are any properties
+		if properties, err := propertyService.ListAllProperties(); err == nil && len(properties) > 0 {
+			fmt.Printf("\nNote: You have %d property account(s) but no valuation provider configured.\n", len(properties))
+			fmt.Printf("Run 'money init rentcast' or 'money config set attom_api_key <key>' to enable automatic property valuation updates.\n")
+		}
+	}
+}
+
+// updateAssetValues applies scheduled depreciation to assets after a fetch
+func updateAssetValues(db *database.DB) {
+	updates, err := asset.NewService(db).UpdateAllValues(time.Now())
+	if err != nil {
+		slog.Warn("failed to update asset values", "err", err)
+		return
+	}
+	if len(updates) > 0 {
+		fmt.Printf("\nDepreciated %d asset(s).\n", len(updates))
+	}
+}
+
+// refreshCryptoWallets reads crypto account balances after a fetch
+func refreshCryptoWallets(db *database.DB) {
+	refreshes, err := crypto.NewService(db).Refresh()
+	if err != nil {
+		slog.Warn("failed to refresh crypto accounts", "err", err)
+		return
+	}
+	if len(refreshes) == 0 {
+		return
+	}
+
+	fmt.Printf("\nRefreshing crypto accounts...\n")
+	for _, refresh := range refreshes {
+		printCryptoRefresh(refresh)
+	}
+}
+
 type syncStats struct {
 	startTime             time.Time
 	duration              time.Duration
 	orgsProcessed         int
 	accountsProcessed     int
+	holdingsProcessed     int
 	transactionsProcessed int
 	newTransactions       int
+	defaultCategorized    int
 }
 
 func printSyncSummary(stats syncStats) {
@@ -224,10 +567,16 @@ func printSyncSummary(stats syncStats) {
 	fmt.Printf("  Duration: %v\n", stats.duration.Round(time.Millisecond))
 	fmt.Printf("  Organizations: %d processed\n", stats.orgsProcessed)
 	fmt.Printf("  Accounts: %d processed\n", stats.accountsProcessed)
+	if stats.holdingsProcessed > 0 {
+		fmt.Printf("  Holdings: %d processed\n", stats.holdingsProcessed)
+	}
 	fmt.Printf("  Transactions: %d processed (%d new)\n", stats.transactionsProcessed, stats.newTransactions)
+	if stats.defaultCategorized > 0 {
+		fmt.Printf("  Categorized: %d by account default\n", stats.defaultCategorized)
+	}
 
 	if stats.newTransactions > 0 {
-		fmt.Printf("\nFetch completed successfully! %d new transactions were added.\n", stats.newTransactions)
+		fmt.Printf("\nFetch completed successfully! %d new transactions were added; 'money diff' lists them.\n", stats.newTransactions)
 	} else {
 		fmt.Printf("\nFetch completed successfully! All data is up to date.\n")
 	}
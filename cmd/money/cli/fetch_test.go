@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+func TestPlanFetch(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Old Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 10000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-1", "acc-1", "2024-05-01T00:00:00Z", -500, "Coffee", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	pending := true
+	data := &simplefin.AccountsResponse{Accounts: []simplefin.Account{
+		{
+			ID: "acc-1", Name: "Checking", Currency: "USD", Balance: "75.00",
+			Org: simplefin.Organization{ID: "org-1", Name: "Old Bank"},
+			Transactions: []simplefin.Transaction{
+				{ID: "tx-1", Posted: 1714521600, Amount: "-5.00", Description: "Coffee"},
+				{ID: "tx-2", Posted: 1714608000, Amount: "-20.00", Description: "Groceries", Pending: &pending},
+			},
+		},
+		{
+			ID: "acc-2", Name: "Savings", Balance: "500.00",
+			Org: simplefin.Organization{ID: "org-2", Name: "New Bank"},
+		},
+	}}
+
+	changes, accounts, err := planFetch(db, data)
+	if err != nil {
+		t.Fatalf("planFetch failed: %v", err)
+	}
+	if len(changes.Organizations) != 1 || changes.Organizations[0].ID != "org-2" {
+		t.Errorf("Expected only org-2 to be new, got %+v", changes.Organizations)
+	}
+	if len(changes.AccountIDs) != 1 || changes.AccountIDs[0] != "acc-2" {
+		t.Errorf("Expected only acc-2 to be new, got %v", changes.AccountIDs)
+	}
+	if accounts["acc-2"].Name != "Savings" || accounts["acc-2"].Currency != "USD" {
+		t.Errorf("Expected the new account to be named, with the default currency, got %+v", accounts["acc-2"])
+	}
+	if len(changes.Transactions) != 1 || changes.Transactions[0].ID != "tx-2" ||
+		changes.Transactions[0].Amount != -2000 || !changes.Transactions[0].Pending {
+		t.Errorf("Expected only tx-2 to be new, got %+v", changes.Transactions)
+	}
+	if len(changes.Balances) != 2 {
+		t.Fatalf("Expected 2 balances, got %+v", changes.Balances)
+	}
+	if old := changes.Balances[0]; old.Before == nil || *old.Before != 10000 || old.After != 7500 {
+		t.Errorf("Expected acc-1 to go from 10000 to 7500, got %+v", old)
+	}
+	if added := changes.Balances[1]; added.Before != nil || added.After != 50000 {
+		t.Errorf("Expected acc-2 to have no previous balance, got %+v", added)
+	}
+
+	// Nothing was written
+	if stored, err := db.GetAccounts(); err != nil || len(stored) != 1 || stored[0].Balance != 10000 {
+		t.Errorf("Expected the stored account to be unchanged, got %+v (%v)", stored, err)
+	}
+	if exists, _ := db.TransactionExists("tx-2"); exists {
+		t.Error("Expected tx-2 not to be saved")
+	}
+}
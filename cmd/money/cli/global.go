@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/logging"
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// Run applies the global flags, which every command accepts anywhere on the
+// command line, then hands the remaining arguments to bonzai
+func Run() {
+	// Completion handles the global flags itself, including a flag whose
+	// value is still being typed
+	if len(os.Args) > 1 && os.Args[1] == completeCmdName {
+		logging.Setup(logging.Quiet)
+		Cmd.Run()
+		return
+	}
+
+	args, err := applyGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "money: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	cfg := config.New()
+	if cfg.ReadOnly {
+		if err := checkReadOnly(Cmd, args); err != nil {
+			fmt.Fprintf(os.Stderr, "money: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.LogToFile {
+		if _, err := logging.AddLogFile(cfg.LogDir(), time.Now()); err != nil {
+			slog.Warn("logging to file disabled", "err", err)
+		}
+	}
+	if loc, err := cfg.Location(); err != nil {
+		slog.Warn("using the system time zone", "err", err)
+	} else {
+		format.SetLocation(loc)
+	}
+	if err := format.SetLocale(cfg.Locale); err != nil {
+		slog.Warn("using the default locale for amounts", "err", err)
+	}
+	format.SetAccountingNegatives(cfg.AccountingNegatives)
+	format.SetBaseCurrency(cfg.Currency)
+	pagerCommand = resolvePager(cfg.Pager)
+	applyColorSetting()
+	slog.Debug("running command", "args", args)
+
+	Cmd.Run()
+}
+
+// applyGlobalFlags removes the global flags from args and applies them:
+//
+//	--profile <name>    use the books of a named profile (also MONEY_PROFILE)
+//	--money-dir <path>  use another money directory, as if MONEY_DIR were set
+//	--db <file>         use another database file, e.g. a restored backup
+//	--format <name>     render tables as text, csv, tsv or markdown
+//	--owner <names>     only read accounts of these comma separated owners
+//	--verbose           print debug output to stderr
+//	--quiet             print only errors to stderr, without progress
+//	--no-pager          never page long output
+//	--no-color          print without colors (also NO_COLOR)
+//	--plain             print without emoji icons
+//	--read-only         open the database read-only and refuse commands that
+//	                    make changes (also MONEY_READONLY)
+//
+// It also sets up the shared logger for the chosen verbosity.
+func applyGlobalFlags(args []string) ([]string, error) {
+	if profile := os.Getenv("MONEY_PROFILE"); profile != "" {
+		if err := config.ValidateProfileName(profile); err != nil {
+			return nil, fmt.Errorf("MONEY_PROFILE: %w", err)
+		}
+	}
+
+	verbosity := logging.Normal
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		// Everything after -- belongs to the command, flags or not
+		if args[i] == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		switch args[i] {
+		case "--verbose":
+			verbosity = logging.Verbose
+			continue
+		case "--quiet":
+			verbosity = logging.Quiet
+			noProgress = true
+			continue
+		case "--no-pager":
+			noPager = true
+			continue
+		case "--no-color":
+			noColor = true
+			continue
+		case "--plain":
+			plainOutput = true
+			continue
+		case "--read-only":
+			config.SetReadOnlyOverride(true)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !isGlobalValueFlag(name) {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value", name)
+			}
+			value = args[i+1]
+			i++
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%s requires a value", name)
+		}
+
+		switch name {
+		case "--profile":
+			if err := config.SetProfile(value); err != nil {
+				return nil, err
+			}
+		case "--money-dir":
+			dir, err := expandHome(value)
+			if err != nil {
+				return nil, err
+			}
+			config.SetMoneyDirOverride(dir)
+		case "--db":
+			path, err := expandHome(value)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("--db: %w", err)
+			}
+			config.SetDBPathOverride(path)
+		case "--format":
+			f, err := table.ParseFormat(value)
+			if err != nil {
+				return nil, fmt.Errorf("--format: %w", err)
+			}
+			table.SetDefaultFormat(f)
+		case "--owner":
+			owners, err := parseOwners(value)
+			if err != nil {
+				return nil, fmt.Errorf("--owner: %w", err)
+			}
+			dbutil.SetOwners(owners)
+		}
+	}
+
+	logging.Setup(verbosity)
+	return rest, nil
+}
+
+// parseOwners splits a comma separated list of account owners, like
+// "me,joint", normalizing each name
+func parseOwners(value string) ([]string, error) {
+	var owners []string
+	for _, name := range strings.Split(value, ",") {
+		owner, err := database.NormalizeOwner(name)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	return owners, nil
+}
+
+// expandHome resolves a leading ~ and makes the path absolute so it does not
+// depend on the directory the command was run from
+func expandHome(path string) (string, error) {
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+func TestApplyGlobalFlags(t *testing.T) {
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Cleanup(func() { config.SetProfile(config.DefaultProfile) })
+
+	rest, err := applyGlobalFlags([]string{"--profile", "business", "balance", "--type", "checking"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"balance", "--type", "checking"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if profile := config.New().Profile; profile != "business" {
+		t.Errorf("Profile = %q, want business", profile)
+	}
+
+	if _, err := applyGlobalFlags([]string{"balance", "--profile=../escape"}); err == nil {
+		t.Error("expected error for invalid profile name")
+	}
+	if _, err := applyGlobalFlags([]string{"balance", "--profile"}); err == nil {
+		t.Error("expected error for missing profile name")
+	}
+}
+
+func TestApplyGlobalMoneyDirFlags(t *testing.T) {
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Cleanup(func() {
+		config.SetMoneyDirOverride("")
+		config.SetDBPathOverride("")
+	})
+
+	restored := t.TempDir()
+	rest, err := applyGlobalFlags([]string{"balance", "--money-dir=" + restored})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"balance"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if dir := config.New().MoneyDir; dir != restored {
+		t.Errorf("MoneyDir = %q, want %q", dir, restored)
+	}
+
+	if _, err := applyGlobalFlags([]string{"--db", filepath.Join(restored, "missing.db"), "balance"}); err == nil {
+		t.Error("expected error for a database file that does not exist")
+	}
+
+	backup := filepath.Join(restored, "backup.db")
+	if err := os.WriteFile(backup, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyGlobalFlags([]string{"--db", backup, "balance"}); err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if path := config.New().DBPath(); path != backup {
+		t.Errorf("DBPath = %q, want %q", path, backup)
+	}
+}
+
+func TestApplyGlobalFormatFlag(t *testing.T) {
+	t.Cleanup(func() { table.SetDefaultFormat(table.FormatText) })
+
+	rest, err := applyGlobalFlags([]string{"budget", "--format", "csv"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"budget"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if got := table.DefaultConfig().Format; got != table.FormatCSV {
+		t.Errorf("default format = %v, want csv", got)
+	}
+
+	if _, err := applyGlobalFlags([]string{"budget", "--format=json"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestApplyGlobalNoPagerFlag(t *testing.T) {
+	t.Cleanup(func() { noPager = false })
+
+	rest, err := applyGlobalFlags([]string{"transactions", "--no-pager", "list"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"transactions", "list"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if got := resolvePager("less"); got != "" {
+		t.Errorf("resolvePager after --no-pager = %q, want none", got)
+	}
+}
+
+func TestApplyGlobalOutputFlags(t *testing.T) {
+	t.Cleanup(func() { noColor, plainOutput = false, false })
+
+	rest, err := applyGlobalFlags([]string{"--plain", "balance", "--no-color"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"balance"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if !noColor || !plainOutput {
+		t.Errorf("noColor = %v, plainOutput = %v, want both set", noColor, plainOutput)
+	}
+}
+
+func TestApplyGlobalOwnerFlag(t *testing.T) {
+	t.Cleanup(func() { dbutil.SetOwners(nil) })
+	t.Setenv("MONEY_DIR", t.TempDir())
+	defer dbutil.Close()
+
+	rest, err := applyGlobalFlags([]string{"balance", "--owner", "Me, joint"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"balance"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	db, err := dbutil.Open()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if want := []string{"me", "joint"}; !reflect.DeepEqual(db.Owners(), want) {
+		t.Errorf("owners = %v, want %v", db.Owners(), want)
+	}
+
+	if _, err := applyGlobalFlags([]string{"balance", "--owner=me,"}); err == nil {
+		t.Error("expected error for an empty owner name")
+	}
+}
+
+func TestApplyGlobalFlagsStopAtTerminator(t *testing.T) {
+	t.Cleanup(func() { dbutil.SetOwners(nil) })
+
+	rest, err := applyGlobalFlags([]string{"db", "query", "--", "--owner", "--plain"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"db", "query", "--", "--owner", "--plain"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if plainOutput {
+		t.Error("expected --plain after -- to be left to the command")
+	}
+}
+
+func TestOwnerFlagScopesCommands(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() { dbutil.SetOwners(nil) })
+	defer dbutil.Close()
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	accounts := []struct{ id, name, owner, description string }{
+		{"checking", "Everyday Checking", "me", "CORNER COFFEE"},
+		{"card", "Rewards Visa", "partner", "AIRLINE TICKETS"},
+	}
+	for _, account := range accounts {
+		if err := db.SaveAccount(account.id, "org-1", account.name, "USD", 10000, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+		if err := db.SetAccountOwner(account.id, account.owner); err != nil {
+			t.Fatalf("Failed to set account owner: %v", err)
+		}
+		if err := db.SaveTransaction(account.id+"-1", account.id, "2025-03-01T12:00:00Z", -1500, account.description, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	if _, err := applyGlobalFlags([]string{"--owner", "me", "transactions", "list"}); err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	out := runCommand(t, TransactionsList)
+	if !strings.Contains(out, "CORNER COFFEE") || strings.Contains(out, "AIRLINE TICKETS") {
+		t.Errorf("Expected transactions list --owner me to show only my account's transactions:\n%s", out)
+	}
+	out = runCommand(t, AccountsList)
+	if !strings.Contains(out, "Everyday Checking") || strings.Contains(out, "Rewards Visa") {
+		t.Errorf("Expected accounts list --owner me to show only my accounts:\n%s", out)
+	}
+}
+
+func TestApplyGlobalReadOnlyFlag(t *testing.T) {
+	t.Setenv("MONEY_READONLY", "")
+	t.Cleanup(func() { config.SetReadOnlyOverride(false) })
+
+	rest, err := applyGlobalFlags([]string{"report", "--read-only", "income"})
+	if err != nil {
+		t.Fatalf("applyGlobalFlags: %v", err)
+	}
+	if want := []string{"report", "income"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if !config.New().ReadOnly {
+		t.Error("expected --read-only to make the config read-only")
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		args    []string
+		allowed bool
+	}{
+		{[]string{"balance", "--type", "checking"}, true},
+		{[]string{"report", "income"}, true},
+		{[]string{"accounts", "list"}, true},
+		{[]string{"fetch"}, false},
+		{[]string{"fetch", "help"}, true},
+		{[]string{"acc", "owner", "set", "demo-checking", "me"}, false},
+		{[]string{"close", "2025-01"}, false},
+		{[]string{"close", "list"}, true},
+		{[]string{"categories", "style", "Groceries", "--color", "green"}, false},
+		{[]string{"categories", "internal", "set", "Transfers"}, false},
+		{[]string{"categories", "internal", "list"}, true},
+		{[]string{"db", "query", "SELECT 1"}, true},
+		{[]string{"db", "query", "--write", "DELETE FROM bills"}, false},
+		{[]string{"db", "sql", "DELETE FROM bills", "-w"}, false},
+	}
+	for _, tt := range tests {
+		err := checkReadOnly(Cmd, tt.args)
+		if (err == nil) != tt.allowed {
+			t.Errorf("checkReadOnly(%v) = %v, want allowed %v", tt.args, err, tt.allowed)
+		}
+	}
+}
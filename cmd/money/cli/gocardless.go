@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/internal/prompt"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/gocardless"
+	"github.com/arjungandhi/money/pkg/provider"
+	"github.com/arjungandhi/money/pkg/simplefin"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// goCardlessLinkTimeout is how long 'money init gocardless' waits for the
+// bank to grant access
+const goCardlessLinkTimeout = 15 * time.Minute
+
+// goCardlessExpiryWarningDays is how many days before a bank's access runs
+// out that fetch starts warning about it
+const goCardlessExpiryWarningDays = 7
+
+// maxInstitutionChoices is the most banks a search offers to pick from
+const maxInstitutionChoices = 20
+
+type initGoCardlessOptions struct {
+	secretID    string
+	secretKey   string
+	country     string
+	institution string
+	list        bool
+}
+
+func initGoCardlessFlags(opts *initGoCardlessOptions) *flags.Set {
+	set := flags.New("money init gocardless")
+	set.StringVar(&opts.secretID, "secret-id", "", "ID")
+	set.StringVar(&opts.secretKey, "secret-key", "", "KEY")
+	set.StringVar(&opts.country, "country", "c", "CC")
+	set.StringVar(&opts.institution, "institution", "i", "ID")
+	set.BoolVar(&opts.list, "list", "l")
+	return set
+}
+
+var InitGoCardless = &Z.Cmd{
+	Name:     "gocardless",
+	Summary:  "Link a European bank through GoCardless Bank Account Data",
+	Usage:    "gocardless " + initGoCardlessFlags(&initGoCardlessOptions{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Links a bank in Europe through GoCardless Bank Account Data (formerly
+Nordigen), the free PSD2 open banking API, for banks SimpleFIN doesn't
+reach. 'money fetch' then reads its accounts alongside SimpleFIN's.
+
+This command will:
+1. Use the user secret given with --secret-id and --secret-key, the one
+   stored before, or prompt for one
+2. Exchange it for access tokens, which are stored and renewed as needed
+3. Find your bank by --institution, or by searching the banks of
+   --country (a two-letter code like DE)
+4. Print a link where you log in to your bank and grant access, then
+   wait until you have
+
+Create a user secret at https://bankaccountdata.gocardless.com under
+Developers > User secrets. Run the command once per bank.
+
+Banks grant access for a limited time, usually 90 days, and most allow
+only four reads a day of each account's details, balances and
+transactions. Run the command again with the same bank to renew access
+before it runs out; the accounts keep their history. --list shows the
+linked banks and when their access ends.
+
+Examples:
+  money init gocardless                               # Interactive mode
+  money init gocardless --country DE                  # Search German banks
+  money init gocardless --institution REVOLUT_REVOLT21
+  money init gocardless --list
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts initGoCardlessOptions
+		if err := initGoCardlessFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		if (opts.secretID == "") != (opts.secretKey == "") {
+			return fmt.Errorf("--secret-id and --secret-key must be given together")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if opts.list {
+				return listGoCardlessBanks(db)
+			}
+
+			client, err := goCardlessClientForInit(db, opts)
+			if err != nil {
+				return err
+			}
+			defer saveGoCardlessTokens(db, client)
+
+			institution, err := chooseInstitution(client, opts)
+			if err != nil {
+				return err
+			}
+			return linkGoCardlessBank(db, client, *institution)
+		})
+	},
+}
+
+// newGoCardlessClient returns a client with the stored credentials and
+// tokens. Call saveGoCardlessTokens when done with it.
+func newGoCardlessClient(db *database.DB) (*gocardless.Client, error) {
+	creds, err := db.GetGoCardlessCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return gocardless.NewClient(creds.SecretID, creds.SecretKey, gocardless.Tokens{
+		Access:         creds.AccessToken,
+		AccessExpires:  creds.AccessExpires,
+		Refresh:        creds.RefreshToken,
+		RefreshExpires: creds.RefreshExpires,
+	}), nil
+}
+
+// saveGoCardlessTokens stores the client's tokens when they were renewed,
+// so the next command doesn't have to renew them again
+func saveGoCardlessTokens(db *database.DB, client *gocardless.Client) {
+	tokens, changed := client.Tokens()
+	if !changed {
+		return
+	}
+	err := db.SaveGoCardlessCredentials(database.GoCardlessCredentials{
+		SecretID:       client.SecretID,
+		SecretKey:      client.SecretKey,
+		AccessToken:    tokens.Access,
+		AccessExpires:  tokens.AccessExpires,
+		RefreshToken:   tokens.Refresh,
+		RefreshExpires: tokens.RefreshExpires,
+	})
+	if err != nil {
+		slog.Warn("failed to save GoCardless tokens", "err", err)
+	}
+}
+
+// goCardlessClientForInit returns a client with the secret given on the
+// command line, the stored one, or one prompted for. A new secret is
+// checked by exchanging it for tokens and only then stored.
+func goCardlessClientForInit(db *database.DB, opts initGoCardlessOptions) (*gocardless.Client, error) {
+	hasCredentials, err := db.HasGoCardlessCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if hasCredentials && opts.secretID == "" {
+		return newGoCardlessClient(db)
+	}
+
+	secretID, secretKey := opts.secretID, opts.secretKey
+	if secretID == "" {
+		fmt.Println("Create a user secret at https://bankaccountdata.gocardless.com under Developers > User secrets.")
+		secretID = prompt.InputWithValidator("Enter your GoCardless secret ID", "", APIKeyValidator)
+		if secretID == "" {
+			return nil, fmt.Errorf("secret ID is required")
+		}
+		secretKey = prompt.MaskedInput("Enter your GoCardless secret key", "")
+		if secretKey == "" {
+			return nil, fmt.Errorf("secret key is required")
+		}
+	}
+
+	fmt.Println("Exchanging the user secret for access tokens...")
+	client := gocardless.NewClient(secretID, secretKey, gocardless.Tokens{})
+	if err := client.NewTokens(); err != nil {
+		return nil, err
+	}
+	saveGoCardlessTokens(db, client)
+	return client, nil
+}
+
+// chooseInstitution returns the bank named by --institution, or one the
+// user searches for among the banks of a country
+func chooseInstitution(client *gocardless.Client, opts initGoCardlessOptions) (*gocardless.Institution, error) {
+	if opts.institution != "" {
+		return client.Institution(opts.institution)
+	}
+
+	country := opts.country
+	if country == "" {
+		country = prompt.InputWithValidator("Which country is your bank in? (two-letter code)", "DE", CountryValidator)
+	}
+	if err := CountryValidator(country); err != nil {
+		return nil, err
+	}
+	country = strings.ToUpper(country)
+
+	institutions, err := client.Institutions(country)
+	if err != nil {
+		return nil, err
+	}
+	if len(institutions) == 0 {
+		return nil, fmt.Errorf("GoCardless has no banks in %s", country)
+	}
+	fmt.Printf("GoCardless connects to %d banks in %s.\n", len(institutions), country)
+
+	for {
+		search := strings.ToLower(strings.TrimSpace(prompt.Input("Search for your bank", "e.g. Revolut")))
+		if search == "" {
+			return nil, fmt.Errorf("no bank chosen")
+		}
+
+		var options []prompt.Option
+		for _, institution := range institutions {
+			if strings.Contains(strings.ToLower(institution.Name), search) || strings.Contains(strings.ToLower(institution.ID), search) ||
+				strings.EqualFold(institution.BIC, search) {
+				options = append(options, prompt.Option{Label: institution.Name, Value: institution.ID, Description: institution.ID})
+			}
+		}
+		switch {
+		case len(options) == 0:
+			fmt.Printf("No banks in %s match %q.\n", country, search)
+			continue
+		case len(options) > maxInstitutionChoices:
+			fmt.Printf("%d banks match %q, try a longer search.\n", len(options), search)
+			continue
+		}
+
+		choice := prompt.Select("Choose your bank", options)
+		if choice == nil {
+			return nil, fmt.Errorf("no bank chosen")
+		}
+		for i := range institutions {
+			if institutions[i].ID == choice.Value {
+				return &institutions[i], nil
+			}
+		}
+	}
+}
+
+// linkGoCardlessBank asks for access to the institution's accounts, waits
+// for the user to grant it at their bank, and records the bank, replacing
+// an earlier link to it
+func linkGoCardlessBank(db *database.DB, client *gocardless.Client, institution gocardless.Institution) error {
+	// The bank sends the browser back to a page served here once access is
+	// granted. The requisition is polled too, for when the browser is on
+	// another machine and can't reach it.
+	callback := make(chan struct{}, 1)
+	redirect := "http://localhost/"
+	if listener, err := net.Listen("tcp", "127.0.0.1:0"); err == nil {
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "money: access granted, you can close this tab and return to the terminal.")
+			select {
+			case callback <- struct{}{}:
+			default:
+			}
+		})}
+		go server.Serve(listener)
+		defer server.Close()
+		redirect = fmt.Sprintf("http://localhost:%d/", listener.Addr().(*net.TCPAddr).Port)
+	}
+
+	reference := "money-" + time.Now().UTC().Format("20060102T150405.000")
+	requisition, agreement, err := client.CreateRequisition(institution, redirect, reference)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nOpen this link, log in to %s and grant access to your accounts:\n\n  %s\n\n", institution.Name, requisition.Link)
+	fmt.Println("Waiting for access to be granted...")
+
+	deadline := time.After(goCardlessLinkTimeout)
+	poll := time.NewTicker(5 * time.Second)
+	defer poll.Stop()
+	for requisition.Status != gocardless.StatusLinked {
+		select {
+		case <-deadline:
+			return fmt.Errorf("access wasn't granted within %v; run the command again to get a new link", goCardlessLinkTimeout)
+		case <-callback:
+		case <-poll.C:
+		}
+		if requisition, err = client.Requisition(requisition.ID); err != nil {
+			return err
+		}
+		switch requisition.Status {
+		case gocardless.StatusRejected, gocardless.StatusExpired, gocardless.StatusSuspended:
+			return fmt.Errorf("%s didn't grant access (status %s); run the command again to retry", institution.Name, requisition.Status)
+		}
+	}
+
+	banks, err := db.GetGoCardlessBanks()
+	if err != nil {
+		return err
+	}
+	for _, bank := range banks {
+		if bank.InstitutionID == institution.ID && bank.RequisitionID != requisition.ID {
+			if err := client.DeleteRequisition(bank.RequisitionID); err != nil {
+				slog.Warn("failed to revoke the earlier GoCardless access", "institution", institution.Name, "err", err)
+			}
+		}
+	}
+
+	expires := format.Now().AddDate(0, 0, agreement.AccessValidForDays).Format("2006-01-02")
+	if err := db.SaveGoCardlessBank(database.GoCardlessBank{
+		RequisitionID:   requisition.ID,
+		InstitutionID:   institution.ID,
+		InstitutionName: institution.Name,
+		ExpiresAt:       expires,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("%s%s linked with %d account(s)!\n", icon("✅ "), institution.Name, len(requisition.Accounts))
+	fmt.Printf("Access lasts until %s; renew it before then with 'money init gocardless --institution %s'.\n", expires, institution.ID)
+	fmt.Println("Run 'money fetch' to bring in balances and transactions.")
+	return nil
+}
+
+// listGoCardlessBanks prints the linked banks and when their access ends
+func listGoCardlessBanks(db *database.DB) error {
+	banks, err := db.GetGoCardlessBanks()
+	if err != nil {
+		return err
+	}
+	if len(banks) == 0 {
+		fmt.Println("No banks are linked through GoCardless. Link one with 'money init gocardless'.")
+		return nil
+	}
+
+	today := format.Now().Format("2006-01-02")
+	t := table.New("Bank", "Institution ID", "Access Until")
+	for _, bank := range banks {
+		expires := bank.ExpiresAt
+		if expires < today {
+			expires += " (expired)"
+		}
+		t.AddRow(bank.InstitutionName, bank.InstitutionID, expires)
+	}
+	return t.Render()
+}
+
+// openGoCardless opens a connection for each bank linked through
+// GoCardless. Replays stay offline, so they open none.
+func openGoCardless(db *database.DB, opts provider.Options) ([]provider.Connection, error) {
+	if opts.ReplayDir != "" {
+		return nil, nil
+	}
+	banks, err := db.GetGoCardlessBanks()
+	if err != nil || len(banks) == 0 {
+		return nil, err
+	}
+	client, err := newGoCardlessClient(db)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	known := make(map[string]database.Account, len(accounts))
+	for _, account := range accounts {
+		known[account.ID] = account
+	}
+
+	warnBefore := format.Now().AddDate(0, 0, goCardlessExpiryWarningDays).Format("2006-01-02")
+	connections := make([]provider.Connection, len(banks))
+	for i, bank := range banks {
+		linked := gocardless.Bank{
+			RequisitionID:   bank.RequisitionID,
+			InstitutionID:   bank.InstitutionID,
+			InstitutionName: bank.InstitutionName,
+		}
+		read := func(since time.Time, balancesOnly bool) (*simplefin.AccountsResponse, error) {
+			resp := gocardless.Fetch(client, []gocardless.Bank{linked}, gocardless.FetchOptions{
+				Since:        sinceDay(since),
+				Known:        known,
+				BalancesOnly: balancesOnly,
+			})
+			saveGoCardlessTokens(db, client)
+			if bank.ExpiresAt <= warnBefore {
+				fmt.Printf("Access to %s ends %s; renew it with 'money init gocardless --institution %s'\n",
+					bank.InstitutionName, bank.ExpiresAt, bank.InstitutionID)
+			}
+			return partialResponse(resp)
+		}
+		connections[i] = provider.Connection{
+			Key:      "gocardless:" + bank.InstitutionID,
+			Name:     bank.InstitutionName + " (GoCardless)",
+			Provider: provider.NewAggregator(read),
+		}
+	}
+	return connections, nil
+}
@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/holdings"
+	"github.com/arjungandhi/money/pkg/quote"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Holdings = &Z.Cmd{
+	Name:    "holdings",
+	Aliases: []string{"holding"},
+	Summary: "Show investment holdings and refresh their prices",
+	Description: `
+Holdings are the stocks and funds in investment accounts, saved by every
+'money fetch' from what SimpleFIN reports.
+
+'money holdings refresh' revalues them from live quotes between syncs and
+moves each account's balance by the change. Quotes come from the source set
+by the quote_source config key: stooq (default), yahoo, or alphavantage
+(needs alpha_vantage_api_key). The next fetch replaces refreshed values with
+the ones SimpleFIN reports.
+
+'money holdings lots' records individual purchases and sales by hand, for
+'money report gains' when SimpleFIN's cost basis isn't enough.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		HoldingsList,
+		HoldingsRefresh,
+		HoldingsLots,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return HoldingsList.Call(cmd, args...)
+	},
+}
+
+var HoldingsList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List holdings by account",
+	Usage:    "list [<account-id>]",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: money holdings %s", cmd.Usage)
+		}
+		accountID := ""
+		if len(args) == 1 {
+			accountID = args[0]
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			list, err := db.GetHoldings(accountID)
+			if err != nil {
+				return err
+			}
+
+			if len(list) == 0 {
+				fmt.Println("No holdings. They're saved by 'money fetch' for accounts whose institution reports them.")
+				return nil
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			names := make(map[string]string, len(accounts))
+			currencies := make(map[string]string, len(accounts))
+			for _, account := range accounts {
+				names[account.ID] = account.DisplayName()
+				currencies[account.ID] = account.Currency
+			}
+
+			t := table.New("Account", "Symbol", "Description", "Shares", "Value", "Cost Basis", "Priced")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnNumber, table.ColumnCurrency, table.ColumnCurrency)
+			var total int64
+			for _, h := range list {
+				costBasis := "-"
+				if h.CostBasis != nil {
+					costBasis = format.Currency(*h.CostBasis, currencies[h.AccountID])
+				}
+				priced := "SimpleFIN"
+				if h.PriceUpdatedAt != nil {
+					priced = *h.PriceUpdatedAt
+				}
+
+				t.AddRow(
+					names[h.AccountID],
+					h.Symbol,
+					h.Description,
+					strconv.FormatFloat(h.Shares, 'f', -1, 64),
+					format.Currency(h.MarketValue, currencies[h.AccountID]),
+					costBasis,
+					priced,
+				)
+				total += h.MarketValue
+			}
+			// Cost basis is often unknown, so only value is totaled
+			t.SetFooter("Total", "", "", "", format.Currency(total, format.BaseCurrency()))
+			return t.Render()
+		})
+	},
+}
+
+var HoldingsRefresh = &Z.Cmd{
+	Name:     "refresh",
+	Summary:  "Revalue investment accounts from live quotes",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			fmt.Printf("Fetching quotes from %s...\n", db.GetConfig().QuoteSource)
+			summary, err := holdings.NewService(db).Refresh()
+			if summary != nil {
+				printHoldingsRefreshSummary(summary)
+			}
+			return err
+		})
+	},
+}
+
+var HoldingsLots = &Z.Cmd{
+	Name:    "lots",
+	Aliases: []string{"lot"},
+	Summary: "Record tax lots for gains tracking",
+	Description: `
+A lot is one purchase of shares, with its cost basis and date. When an
+account has lots for a symbol, 'money report gains' uses them instead of the
+cost basis SimpleFIN reports for the holding, and selling a lot records a
+realized gain.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		HoldingsLotsList,
+		HoldingsLotsAdd,
+		HoldingsLotsSell,
+		HoldingsLotsRemove,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return HoldingsLotsList.Call(cmd, args...)
+	},
+}
+
+var HoldingsLotsList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List tax lots",
+	Usage:    "list [<account-id>]",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: money holdings lots %s", cmd.Usage)
+		}
+		accountID := ""
+		if len(args) == 1 {
+			accountID = args[0]
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			lots, err := holdings.NewService(db).ListLots(accountID)
+			if err != nil {
+				return err
+			}
+			currencies, err := accountCurrencies(db)
+			if err != nil {
+				return err
+			}
+
+			if len(lots) == 0 {
+				fmt.Println("No lots. Add one with 'money holdings lots add <account-id> <symbol> <shares> <cost-basis> <acquired>'.")
+				return nil
+			}
+
+			t := table.New("ID", "Account ID", "Symbol", "Shares", "Cost Basis", "Acquired", "Sold", "Proceeds")
+			t.SetColumnTypes(table.ColumnNumber, table.ColumnText, table.ColumnText, table.ColumnNumber, table.ColumnCurrency,
+				table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			for _, lot := range lots {
+				sold, proceeds := "-", "-"
+				if lot.Sold != nil {
+					sold = *lot.Sold
+				}
+				if lot.Proceeds != nil {
+					proceeds = format.Currency(*lot.Proceeds, currencies[lot.AccountID])
+				}
+				t.AddRow(
+					strconv.Itoa(lot.ID),
+					lot.AccountID,
+					lot.Symbol,
+					strconv.FormatFloat(lot.Shares, 'f', -1, 64),
+					format.Currency(lot.CostBasis, currencies[lot.AccountID]),
+					lot.Acquired,
+					sold,
+					proceeds,
+				)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var HoldingsLotsAdd = &Z.Cmd{
+	Name:     "add",
+	Summary:  "Record a purchase of shares",
+	Usage:    "add <account-id> <symbol> <shares> <cost-basis> <acquired>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Records buying <shares> of <symbol> on <acquired> (YYYY-MM-DD) for a total
+of <cost-basis>, fees included.
+
+Example:
+  money holdings lots add brokerage-123 VTI 10 2045.50 2021-03-15
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 5 {
+			return fmt.Errorf("usage: money holdings lots %s", cmd.Usage)
+		}
+
+		shares, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return fmt.Errorf("invalid shares: %s", args[2])
+		}
+		costBasis, err := format.ParseCents(args[3])
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			id, err := holdings.NewService(db).AddLot(database.Lot{
+				AccountID: args[0],
+				Symbol:    args[1],
+				Shares:    shares,
+				CostBasis: costBasis,
+				Acquired:  args[4],
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Added lot %d\n", id)
+			return nil
+		})
+	},
+}
+
+func holdingsLotsSellFlags(shares *float64) *flags.Set {
+	set := flags.New("money holdings lots sell")
+	set.Positional("<lot-id>", "<sold>", "<proceeds>")
+	set.Func("shares", "", "<number>", func(value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("must be a number greater than 0")
+		}
+		*shares = parsed
+		return nil
+	})
+	return set
+}
+
+var HoldingsLotsSell = &Z.Cmd{
+	Name:     "sell",
+	Summary:  "Record selling shares of a lot",
+	Usage:    "sell " + holdingsLotsSellFlags(new(float64)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Records selling a lot on <sold> (YYYY-MM-DD) for <proceeds>, after fees.
+With --shares, only that many of the lot's shares are sold: the lot is
+split, and the sold part gets its share of the cost basis.
+
+Examples:
+  money holdings lots sell 3 2024-06-01 2500
+  money holdings lots sell 3 2024-06-01 1250 --shares 5
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var shares float64
+		set := holdingsLotsSellFlags(&shares)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+		if len(positional) != 3 {
+			return fmt.Errorf("usage: money holdings lots %s", cmd.Usage)
+		}
+
+		id, err := strconv.Atoi(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid lot ID: %s", positional[0])
+		}
+		proceeds, err := format.ParseCents(positional[2])
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			soldID, err := holdings.NewService(db).SellLot(id, shares, positional[1], proceeds)
+			if err != nil {
+				return err
+			}
+
+			if soldID == id {
+				fmt.Printf("Sold lot %d\n", id)
+			} else {
+				fmt.Printf("Sold part of lot %d as lot %d\n", id, soldID)
+			}
+			return nil
+		})
+	},
+}
+
+var HoldingsLotsRemove = &Z.Cmd{
+	Name:     "remove",
+	Aliases:  []string{"rm"},
+	Summary:  "Delete a lot entered by mistake",
+	Usage:    "remove <lot-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money holdings lots %s", cmd.Usage)
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid lot ID: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := holdings.NewService(db).RemoveLot(id); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed lot %d\n", id)
+			return nil
+		})
+	},
+}
+
+func printHoldingsRefreshSummary(summary *holdings.RefreshSummary) {
+	if len(summary.Accounts) == 0 {
+		fmt.Println("No holdings to refresh. Run 'money fetch' to save them from SimpleFIN.")
+		return
+	}
+
+	for _, refresh := range summary.Accounts {
+		change := refresh.NewBalance - refresh.OldBalance
+		sign := "+"
+		if change < 0 {
+			sign = "-"
+			change = -change
+		}
+		fmt.Printf("%s: %s -> %s (%s%s, %d repriced",
+			refresh.Account.DisplayName(),
+			format.Currency(refresh.OldBalance, refresh.Account.Currency),
+			format.Currency(refresh.NewBalance, refresh.Account.Currency),
+			sign, format.Currency(change, refresh.Account.Currency),
+			refresh.Repriced)
+		if refresh.Unpriced > 0 {
+			fmt.Printf(", %d kept at last value", refresh.Unpriced)
+		}
+		fmt.Println(")")
+	}
+
+	if len(summary.Failed) > 0 {
+		symbols := make([]string, 0, len(summary.Failed))
+		for symbol := range summary.Failed {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+
+		fmt.Printf("\nNo price for %d symbol(s):\n", len(symbols))
+		for _, symbol := range symbols {
+			err := summary.Failed[symbol]
+			if errors.Is(err, quote.ErrNoQuote) {
+				fmt.Printf("  %s: not found\n", symbol)
+			} else {
+				fmt.Printf("  %s: %v\n", symbol, err)
+			}
+		}
+	}
+}
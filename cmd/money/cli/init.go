@@ -2,25 +2,33 @@ package cli
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/internal/prompt"
 	"github.com/arjungandhi/money/pkg/config"
 	"github.com/arjungandhi/money/pkg/database"
 	"github.com/arjungandhi/money/pkg/simplefin"
 )
 
 var Init = &Z.Cmd{
-	Name:     "init",
-	Summary:  "Interactive setup tutorial for money CLI",
+	Name:    "init",
+	Summary: "Interactive setup tutorial for money CLI",
+	Usage:   initFlags(&initOptions{}).Usage(),
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		InitSimpleFIN,
 		InitRentCast,
+		InitGoCardless,
+		InitTeller,
 	},
 	Description: `
 Interactive setup tutorial for the money CLI.
@@ -32,14 +40,31 @@ This command will guide you through:
 4. Setting up LLM integration for transaction categorization (optional)
 5. Adding environment variables to your shell configuration
 
+Passing any of the flags below skips every prompt so setup can run
+unattended, e.g. from a dotfiles bootstrap script or a container build.
+Steps without a flag are left as they are, and the environment variables
+to export are printed instead of being written to ~/.bashrc. Use the global
+--money-dir flag to choose the data directory.
+
+Flags:
+  --simplefin-token <token>   SimpleFIN setup token to exchange for credentials
+  --rentcast-key <key>        RentCast API key for property valuations
+  --llm-cmd <command>         Command used for LLM categorization
+  --yes, -y                   Overwrite existing credentials without asking
+
 Subcommands:
   simplefin  - Set up SimpleFIN credentials only
   rentcast   - Set up RentCast API key only
+  gocardless - Link EU and UK banks through GoCardless Bank Account Data
+  teller     - Enroll US banks through Teller
 
 Examples:
   money init               # Interactive full setup
   money init simplefin     # SimpleFIN setup only
   money init rentcast      # RentCast setup only
+  money init gocardless    # Link a bank through GoCardless
+  money init teller        # Enroll a bank through Teller
+  money init --money-dir ~/finance --simplefin-token "$TOKEN" --llm-cmd ollama --yes
 `,
 	Call: initCommand,
 }
@@ -48,7 +73,7 @@ var InitSimpleFIN = &Z.Cmd{
 	Name:     "simplefin",
 	Summary:  "Set up SimpleFIN credentials for bank account access",
 	Commands: []*Z.Cmd{help.Cmd},
-	Usage:    "simplefin [setup-token]",
+	Usage:    "simplefin " + initSimpleFINFlags(new(bool)).Usage(),
 	Description: `
 Set up SimpleFIN credentials for accessing your bank accounts.
 
@@ -57,6 +82,13 @@ This command will:
 2. Exchange the token for permanent access credentials
 3. Store the credentials securely in the local database
 4. Test the connection to verify setup
+5. Check that the bridge speaks a SimpleFIN protocol version money handles
+
+With --rotate, a new setup token replaces working credentials safely: the
+new credentials are tested first, and the stored ones are only replaced,
+in a single transaction, once the test succeeds. If anything fails the
+existing credentials are kept. Revoke the old access in your bridge's
+portal afterwards.
 
 You can get a setup token from your financial institution's
 SimpleFIN portal or bridge service.
@@ -64,6 +96,7 @@ SimpleFIN portal or bridge service.
 Examples:
   money init simplefin                                    # Interactive mode
   money init simplefin aHR0cHM6Ly9icmlkZ2Uuc2ltcGxlZmlu...  # Non-interactive mode
+  money init simplefin --rotate                           # Replace existing credentials
 `,
 	Call: initSimpleFinCommand,
 }
@@ -91,7 +124,15 @@ Examples:
 }
 
 func initCommand(cmd *Z.Cmd, args ...string) error {
-	fmt.Println("💰 Welcome to Money CLI Setup!")
+	opts, err := parseInitFlags(args)
+	if err != nil {
+		return err
+	}
+	if opts.unattended() {
+		return runUnattendedInit(opts)
+	}
+
+	fmt.Println(icon("💰 ") + "Welcome to Money CLI Setup!")
 	fmt.Println("==============================")
 	fmt.Println()
 	fmt.Println("This interactive setup will help you configure the money CLI step by step.")
@@ -100,12 +141,12 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	cfg := config.New()
 
 	// Step 1: Configure data storage location
-	fmt.Println("📁 Step 1: Data Storage Location")
+	fmt.Println(icon("📁 ") + "Step 1: Data Storage Location")
 	fmt.Println("--------------------------------")
 	fmt.Printf("Current data directory: %s\n", cfg.MoneyDir)
 
-	if RunConfirmation("Would you like to change the data storage location?") {
-		newDir := RunInputWithValidator("Enter new data directory path", cfg.MoneyDir, DirectoryValidator)
+	if prompt.Confirm("Would you like to change the data storage location?") {
+		newDir := prompt.InputWithValidator("Enter new data directory path", cfg.MoneyDir, DirectoryValidator)
 		if newDir != "" {
 			cfg.SetMoneyDir(newDir)
 			fmt.Printf("Data directory updated to: %s\n", cfg.MoneyDir)
@@ -114,30 +155,30 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	fmt.Println()
 
 	// Step 2: SimpleFIN Setup
-	fmt.Println("🏦 Step 2: Bank Account Access (SimpleFIN)")
+	fmt.Println(icon("🏦 ") + "Step 2: Bank Account Access (SimpleFIN)")
 	fmt.Println("------------------------------------------")
 	fmt.Println("SimpleFIN allows secure access to your bank accounts for transaction data.")
 
 	// Check for existing SimpleFIN credentials
 	hasCredentials, err := checkExistingSimpleFINCredentials(cfg)
 	if err != nil {
-		fmt.Printf("⚠️  Could not check existing SimpleFIN credentials: %v\n", err)
+		fmt.Printf("%sCould not check existing SimpleFIN credentials: %v\n", icon("⚠️  "), err)
 	}
 
 	if hasCredentials {
-		fmt.Println("✅ SimpleFIN credentials already configured!")
-		if RunConfirmation("Would you like to reconfigure SimpleFIN credentials?") {
+		fmt.Println(icon("✅ ") + "SimpleFIN credentials already configured!")
+		if prompt.Confirm("Would you like to reconfigure SimpleFIN credentials?") {
 			if err := runSimpleFinSetup(cfg); err != nil {
-				fmt.Printf("⚠️  SimpleFIN setup failed: %v\n", err)
+				fmt.Printf("%sSimpleFIN setup failed: %v\n", icon("⚠️  "), err)
 				fmt.Println("You can set it up later with: money init simplefin")
 			}
 		} else {
 			fmt.Println("Keeping existing SimpleFIN configuration.")
 		}
 	} else {
-		if RunConfirmation("Would you like to set up SimpleFIN now?") {
+		if prompt.Confirm("Would you like to set up SimpleFIN now?") {
 			if err := runSimpleFinSetup(cfg); err != nil {
-				fmt.Printf("⚠️  SimpleFIN setup failed: %v\n", err)
+				fmt.Printf("%sSimpleFIN setup failed: %v\n", icon("⚠️  "), err)
 				fmt.Println("You can set it up later with: money init simplefin")
 			}
 		} else {
@@ -147,30 +188,30 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	fmt.Println()
 
 	// Step 3: RentCast Setup (optional)
-	fmt.Println("🏠 Step 3: Property Valuations (RentCast) - Optional")
+	fmt.Println(icon("🏠 ") + "Step 3: Property Valuations (RentCast) - Optional")
 	fmt.Println("---------------------------------------------------")
 	fmt.Println("RentCast provides property value estimates for real estate tracking.")
 
 	// Check for existing RentCast API key
 	hasRentCastKey, err := checkExistingRentCastCredentials(cfg)
 	if err != nil {
-		fmt.Printf("⚠️  Could not check existing RentCast credentials: %v\n", err)
+		fmt.Printf("%sCould not check existing RentCast credentials: %v\n", icon("⚠️  "), err)
 	}
 
 	if hasRentCastKey {
-		fmt.Println("✅ RentCast API key already configured!")
-		if RunConfirmation("Would you like to reconfigure RentCast API key?") {
+		fmt.Println(icon("✅ ") + "RentCast API key already configured!")
+		if prompt.Confirm("Would you like to reconfigure RentCast API key?") {
 			if err := runRentCastSetup(cfg); err != nil {
-				fmt.Printf("⚠️  RentCast setup failed: %v\n", err)
+				fmt.Printf("%sRentCast setup failed: %v\n", icon("⚠️  "), err)
 				fmt.Println("You can set it up later with: money init rentcast")
 			}
 		} else {
 			fmt.Println("Keeping existing RentCast configuration.")
 		}
 	} else {
-		if RunConfirmation("Would you like to set up RentCast for property valuations?") {
+		if prompt.Confirm("Would you like to set up RentCast for property valuations?") {
 			if err := runRentCastSetup(cfg); err != nil {
-				fmt.Printf("⚠️  RentCast setup failed: %v\n", err)
+				fmt.Printf("%sRentCast setup failed: %v\n", icon("⚠️  "), err)
 				fmt.Println("You can set it up later with: money init rentcast")
 			}
 		} else {
@@ -180,14 +221,14 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	fmt.Println()
 
 	// Step 4: LLM Configuration (optional)
-	fmt.Println("🤖 Step 4: AI-Powered Transaction Categorization - Optional")
+	fmt.Println(icon("🤖 ") + "Step 4: AI-Powered Transaction Categorization - Optional")
 	fmt.Println("----------------------------------------------------------")
 	fmt.Println("Configure an LLM (like Claude, ChatGPT, or Ollama) for automatic transaction categorization.")
 	fmt.Printf("Current LLM command: %s\n", cfg.LLMPromptCmd)
 
-	if RunConfirmation("Would you like to configure LLM integration?") {
+	if prompt.Confirm("Would you like to configure LLM integration?") {
 		if err := configureLLMInteractive(cfg); err != nil {
-			fmt.Printf("⚠️  LLM configuration failed: %v\n", err)
+			fmt.Printf("%sLLM configuration failed: %v\n", icon("⚠️  "), err)
 		}
 	} else {
 		fmt.Println("Skipping LLM setup. You can configure it later by setting the LLM_PROMPT_CMD environment variable.")
@@ -195,17 +236,17 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	fmt.Println()
 
 	// Step 5: Shell Configuration
-	fmt.Println("🐚 Step 5: Shell Configuration")
+	fmt.Println(icon("🐚 ") + "Step 5: Shell Configuration")
 	fmt.Println("------------------------------")
 	fmt.Println("Add environment variables to your shell configuration for persistence.")
 
 	if err := configureBashrc(cfg); err != nil {
-		fmt.Printf("⚠️  Shell configuration failed: %v\n", err)
+		fmt.Printf("%sShell configuration failed: %v\n", icon("⚠️  "), err)
 	}
 	fmt.Println()
 
 	// Final summary
-	fmt.Println("🎉 Setup Complete!")
+	fmt.Println(icon("🎉 ") + "Setup Complete!")
 	fmt.Println("==================")
 	fmt.Println("Your money CLI is now configured. Try these commands:")
 	fmt.Println("  money fetch      - Fetch latest account data")
@@ -217,18 +258,38 @@ func initCommand(cmd *Z.Cmd, args ...string) error {
 	return nil
 }
 
+func initSimpleFINFlags(rotate *bool) *flags.Set {
+	set := flags.New("money init simplefin")
+	set.Positional("[setup-token]")
+	set.BoolVar(rotate, "rotate", "")
+	return set
+}
+
 func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
+	rotate := false
+	set := initSimpleFINFlags(&rotate)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	var setupToken string
+	if len(set.Args()) > 0 {
+		setupToken = set.Args()[0]
+	}
+
+	if rotate {
+		return rotateSimpleFINCommand(setupToken)
+	}
+
 	fmt.Println("SimpleFIN Setup")
 	fmt.Println("===============")
 	fmt.Println()
 
 	// Initialize database connection
 	fmt.Println("Initializing database...")
-	db, err := database.New()
+	db, err := dbutil.Open()
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
-	defer db.Close()
 
 	// Check if credentials already exist
 	hasCredentials, err := db.HasCredentials()
@@ -237,10 +298,10 @@ func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
 	}
 
 	if hasCredentials {
-		fmt.Println("⚠️  Existing credentials found!")
+		fmt.Println(icon("⚠️  ") + "Existing credentials found!")
 		fmt.Println()
 
-		if !RunConfirmation("Do you want to overwrite the existing setup?") {
+		if !prompt.Confirm("Do you want to overwrite the existing setup?") {
 			fmt.Println("Setup cancelled.")
 			return nil
 		}
@@ -248,11 +309,8 @@ func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
 	}
 
 	// Get SimpleFIN setup token from args or prompt
-	var setupToken string
-	if len(args) > 0 {
-		setupToken = args[0]
-	} else {
-		setupToken = RunInputWithValidator(
+	if setupToken == "" {
+		setupToken = prompt.InputWithValidator(
 			"Enter your SimpleFIN setup token (base64 encoded)",
 			"aHR0cHM6Ly9icmlkZ2Uuc2ltcGxlZmluLm9yZy9zaW1wbGVmaW4vY2xhaW0vYWJjMTIz",
 			SetupTokenValidator,
@@ -287,6 +345,7 @@ func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
 	if err != nil {
 		return fmt.Errorf("failed to test connection - credentials may be invalid: %w", err)
 	}
+	checkSimpleFINVersions(client)
 
 	// Extract organizations from accounts since they're now embedded
 	orgMap := make(map[string]simplefin.Organization)
@@ -296,7 +355,7 @@ func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
 
 	// Confirm successful setup
 	fmt.Println()
-	fmt.Println("✅ Setup completed successfully!")
+	fmt.Println(icon("✅ ") + "Setup completed successfully!")
 	fmt.Printf("Found %d organizations with %d total accounts\n",
 		len(orgMap), len(accounts.Accounts))
 
@@ -323,12 +382,74 @@ func initSimpleFinCommand(cmd *Z.Cmd, args ...string) error {
 	return nil
 }
 
+// rotateSimpleFINCommand replaces working SimpleFIN credentials with ones
+// claimed from a new setup token, prompting for the token if it is empty
+func rotateSimpleFINCommand(setupToken string) error {
+	db, err := dbutil.Open()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	hasCredentials, err := db.HasCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to check existing credentials: %w", err)
+	}
+	if !hasCredentials {
+		return fmt.Errorf("no SimpleFIN credentials to rotate, run 'money init simplefin' instead")
+	}
+
+	if setupToken == "" {
+		setupToken = prompt.InputWithValidator(
+			"Enter your new SimpleFIN setup token (base64 encoded)",
+			"aHR0cHM6Ly9icmlkZ2Uuc2ltcGxlZmluLm9yZy9zaW1wbGVmaW4vY2xhaW0vYWJjMTIz",
+			SetupTokenValidator,
+		)
+		if setupToken == "" {
+			return fmt.Errorf("setup token is required")
+		}
+	}
+
+	accountCount, err := rotateSimpleFINCredentials(db, setupToken)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%sSimpleFIN credentials rotated! Found %d accounts\n", icon("✅ "), accountCount)
+	fmt.Println("Revoke the old access in your SimpleFIN bridge's portal.")
+	return nil
+}
+
+// rotateSimpleFINCredentials claims new credentials with setupToken and
+// stores them only after a test request with them succeeds, so the existing
+// credentials stay in place if the token or the new connection is bad. It
+// returns the number of accounts visible through the new connection.
+func rotateSimpleFINCredentials(db *database.DB, setupToken string) (int, error) {
+	fmt.Println("Exchanging setup token for new credentials...")
+	client, err := simplefin.NewClientFromToken(setupToken)
+	if err != nil {
+		return 0, fmt.Errorf("failed to exchange setup token, existing credentials were kept: %w", err)
+	}
+
+	fmt.Println("Testing the new credentials...")
+	accounts, err := client.GetAccountsWithOptions(&simplefin.AccountsOptions{BalancesOnly: true})
+	if err != nil {
+		return 0, fmt.Errorf("new credentials failed the connection test, existing credentials were kept: %w", err)
+	}
+	checkSimpleFINVersions(client)
+
+	accessURL, username, password := client.GetCredentials()
+	if err := db.SaveCredentials(accessURL, username, password); err != nil {
+		return 0, fmt.Errorf("failed to replace credentials, existing credentials were kept: %w", err)
+	}
+	return len(accounts.Accounts), nil
+}
+
 func initRentCastCommand(cmd *Z.Cmd, args ...string) error {
 	var apiKey string
 	if len(args) > 0 {
 		apiKey = args[0]
 	} else {
-		apiKey = RunInputWithValidator(
+		apiKey = prompt.InputWithValidator(
 			"Enter your RentCast API key",
 			"Get one from: https://developers.rentcast.io/",
 			APIKeyValidator,
@@ -343,11 +464,10 @@ func initRentCastCommand(cmd *Z.Cmd, args ...string) error {
 		return fmt.Errorf("API key appears to be too short. Please check your RentCast API key")
 	}
 
-	db, err := database.New()
+	db, err := dbutil.Open()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Save the API key
 	err = db.SaveRentCastAPIKey(apiKey)
@@ -362,15 +482,9 @@ func initRentCastCommand(cmd *Z.Cmd, args ...string) error {
 	return nil
 }
 
-
 func runSimpleFinSetup(cfg *config.Config) error {
-	// Set MONEY_DIR temporarily for the database
-	oldMoneyDir := os.Getenv("MONEY_DIR")
-	os.Setenv("MONEY_DIR", cfg.MoneyDir)
-	defer os.Setenv("MONEY_DIR", oldMoneyDir)
-
 	// Get setup token
-	setupToken := RunInputWithValidator(
+	setupToken := prompt.InputWithValidator(
 		"Enter your SimpleFIN setup token (base64 encoded)",
 		"aHR0cHM6Ly9icmlkZ2Uuc2ltcGxlZmluLm9yZy9zaW1wbGVmaW4vY2xhaW0vYWJjMTIz",
 		SetupTokenValidator,
@@ -380,7 +494,7 @@ func runSimpleFinSetup(cfg *config.Config) error {
 	}
 
 	// Initialize database
-	db, err := database.New()
+	db, err := database.Open(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -393,41 +507,82 @@ func runSimpleFinSetup(cfg *config.Config) error {
 	}
 
 	if hasCredentials {
-		if !RunConfirmation("Existing SimpleFIN credentials found. Do you want to overwrite them?") {
+		if !prompt.Confirm("Existing SimpleFIN credentials found. Do you want to overwrite them?") {
 			return fmt.Errorf("setup cancelled by user")
 		}
 	}
 
-	// Exchange token and save credentials
+	accountCount, err := saveSimpleFINToken(db, setupToken)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%sSimpleFIN setup successful! Found %d accounts\n", icon("✅ "), accountCount)
+	return nil
+}
+
+// saveSimpleFINToken exchanges a setup token for permanent credentials, stores
+// them and returns the number of accounts visible through the new connection.
+func saveSimpleFINToken(db *database.DB, setupToken string) (int, error) {
 	fmt.Println("Exchanging setup token for permanent credentials...")
 	client, err := simplefin.NewClientFromToken(setupToken)
 	if err != nil {
-		return fmt.Errorf("failed to exchange setup token: %w", err)
+		return 0, fmt.Errorf("failed to exchange setup token: %w", err)
 	}
 
 	accessURL, username, password := client.GetCredentials()
 	if err := db.SaveCredentials(accessURL, username, password); err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+		return 0, fmt.Errorf("failed to save credentials: %w", err)
 	}
 
 	// Test connection
 	fmt.Println("Testing connection...")
 	accounts, err := client.GetAccounts()
 	if err != nil {
-		return fmt.Errorf("failed to test connection: %w", err)
+		return 0, fmt.Errorf("failed to test connection: %w", err)
 	}
+	checkSimpleFINVersions(client)
 
-	fmt.Printf("✅ SimpleFIN setup successful! Found %d accounts\n", len(accounts.Accounts))
-	return nil
+	return len(accounts.Accounts), nil
 }
 
-func runRentCastSetup(cfg *config.Config) error {
-	// Set MONEY_DIR temporarily for the database
-	oldMoneyDir := os.Getenv("MONEY_DIR")
-	os.Setenv("MONEY_DIR", cfg.MoneyDir)
-	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+// checkSimpleFINVersions prints the protocol versions the bridge supports and
+// warns when it speaks one this version of money doesn't handle
+func checkSimpleFINVersions(client *simplefin.Client) {
+	info, err := client.GetInfo()
+	if err != nil {
+		slog.Warn("could not check the bridge's SimpleFIN versions", "err", err)
+		return
+	}
+
+	if len(info.Versions) > 0 {
+		fmt.Printf("Bridge supports SimpleFIN protocol %s\n", strings.Join(info.Versions, ", "))
+	}
+	if warning := simpleFINVersionWarning(info); warning != "" {
+		slog.Warn(warning)
+	}
+}
+
+// simpleFINVersionWarning describes a protocol mismatch with the bridge, or
+// returns "" when there is none
+func simpleFINVersionWarning(info *simplefin.Info) string {
+	supported := strings.Join(simplefin.SupportedVersions, ", ")
+	if len(info.Versions) == 0 {
+		return "the bridge didn't report which SimpleFIN versions it speaks"
+	}
+	if !info.Compatible() {
+		return fmt.Sprintf("the bridge speaks SimpleFIN %s but money only handles %s; fetching may fail, try 'money update'",
+			strings.Join(info.Versions, ", "), supported)
+	}
+	if unsupported := info.UnsupportedVersions(); len(unsupported) > 0 {
+		return fmt.Sprintf("the bridge also speaks SimpleFIN %s, which money doesn't handle yet (using %s)",
+			strings.Join(unsupported, ", "), supported)
+	}
+	return ""
+}
 
-	apiKey := RunInputWithValidator(
+func runRentCastSetup(cfg *config.Config) error {
+	apiKey := prompt.InputWithValidator(
 		"Enter your RentCast API key",
 		"Get one from: https://developers.rentcast.io/",
 		APIKeyValidator,
@@ -440,7 +595,7 @@ func runRentCastSetup(cfg *config.Config) error {
 		return fmt.Errorf("API key appears to be too short")
 	}
 
-	db, err := database.New()
+	db, err := database.Open(cfg)
 	if err != nil {
 		return err
 	}
@@ -450,13 +605,13 @@ func runRentCastSetup(cfg *config.Config) error {
 		return fmt.Errorf("failed to save RentCast API key: %w", err)
 	}
 
-	fmt.Println("✅ RentCast setup successful!")
+	fmt.Println(icon("✅ ") + "RentCast setup successful!")
 	return nil
 }
 
 func configureLLMInteractive(cfg *config.Config) error {
 	// LLM Command Selection
-	llmOptions := []SelectOption{
+	llmOptions := []prompt.Option{
 		{Label: "claude", Value: "claude", Description: "Anthropic Claude (default)"},
 		{Label: "openai", Value: "openai", Description: "OpenAI ChatGPT"},
 		{Label: "ollama", Value: "ollama", Description: "Local Ollama installation"},
@@ -465,13 +620,13 @@ func configureLLMInteractive(cfg *config.Config) error {
 		{Label: "keep current", Value: "current", Description: fmt.Sprintf("Keep current: %s", cfg.LLMPromptCmd)},
 	}
 
-	selectedLLM := RunSelection("Choose your LLM command:", llmOptions)
+	selectedLLM := prompt.Select("Choose your LLM command:", llmOptions)
 	if selectedLLM == nil {
 		return fmt.Errorf("LLM selection cancelled")
 	}
 
 	if selectedLLM.Value == "custom" {
-		customCmd := RunInput("Enter custom LLM command", "my-llm-tool")
+		customCmd := prompt.Input("Enter custom LLM command", "my-llm-tool")
 		if customCmd != "" {
 			cfg.SetLLMPromptCmd(customCmd)
 			fmt.Printf("LLM command updated to: %s\n", cfg.LLMPromptCmd)
@@ -482,7 +637,7 @@ func configureLLMInteractive(cfg *config.Config) error {
 	}
 
 	// Batch Size Configuration
-	batchSizeInput := RunInputWithValidator(
+	batchSizeInput := prompt.InputWithValidator(
 		fmt.Sprintf("Enter batch size for LLM categorization (current: %d)", cfg.LLMBatchSize),
 		strconv.Itoa(cfg.LLMBatchSize),
 		BatchSizeValidator,
@@ -513,13 +668,13 @@ func configureBashrc(cfg *config.Config) error {
 	fmt.Println()
 
 	// Offer shell configuration options
-	shellOptions := []SelectOption{
+	shellOptions := []prompt.Option{
 		{Label: "Auto-add to ~/.bashrc", Value: "bashrc", Description: "Automatically append to your .bashrc file"},
 		{Label: "Show commands to run manually", Value: "manual", Description: "Display commands for manual configuration"},
 		{Label: "Skip shell configuration", Value: "skip", Description: "Skip for now (you can configure manually later)"},
 	}
 
-	selection := RunSelection("How would you like to configure your shell?", shellOptions)
+	selection := prompt.Select("How would you like to configure your shell?", shellOptions)
 	if selection == nil || selection.Value == "skip" {
 		fmt.Println("Skipping shell configuration. You can set these environment variables manually.")
 		return nil
@@ -549,7 +704,7 @@ func configureBashrc(cfg *config.Config) error {
 	fmt.Println("The changes will be appended to the end of the file with clear comments.")
 	fmt.Println()
 
-	if !RunConfirmation("Proceed with modifying your .bashrc file?") {
+	if !prompt.Confirm("Proceed with modifying your .bashrc file?") {
 		fmt.Println("Shell configuration cancelled. You can add the variables manually.")
 		return nil
 	}
@@ -558,12 +713,12 @@ func configureBashrc(cfg *config.Config) error {
 	if _, err := os.Stat(bashrcPath); err == nil {
 		backupPath := bashrcPath + ".money-backup"
 		if err := copyFile(bashrcPath, backupPath); err != nil {
-			fmt.Printf("⚠️  Warning: Could not create backup of .bashrc: %v\n", err)
-			if !RunConfirmation("Continue without backup?") {
+			fmt.Printf("%sWarning: Could not create backup of .bashrc: %v\n", icon("⚠️  "), err)
+			if !prompt.Confirm("Continue without backup?") {
 				return fmt.Errorf("operation cancelled by user")
 			}
 		} else {
-			fmt.Printf("📁 Backup created: %s\n", backupPath)
+			fmt.Printf("%sBackup created: %s\n", icon("📁 "), backupPath)
 		}
 	}
 
@@ -582,7 +737,7 @@ func configureBashrc(cfg *config.Config) error {
 	}
 	file.WriteString("# End Money CLI configuration\n")
 
-	fmt.Printf("✅ Environment variables added to %s\n", bashrcPath)
+	fmt.Printf("%sEnvironment variables added to %s\n", icon("✅ "), bashrcPath)
 	fmt.Println("Run 'source ~/.bashrc' or restart your terminal to apply changes.")
 	return nil
 }
@@ -604,15 +759,8 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-
-
 func checkExistingSimpleFINCredentials(cfg *config.Config) (bool, error) {
-	// Set MONEY_DIR temporarily for the database
-	oldMoneyDir := os.Getenv("MONEY_DIR")
-	os.Setenv("MONEY_DIR", cfg.MoneyDir)
-	defer os.Setenv("MONEY_DIR", oldMoneyDir)
-
-	db, err := database.New()
+	db, err := database.Open(cfg)
 	if err != nil {
 		return false, err
 	}
@@ -622,12 +770,7 @@ func checkExistingSimpleFINCredentials(cfg *config.Config) (bool, error) {
 }
 
 func checkExistingRentCastCredentials(cfg *config.Config) (bool, error) {
-	// Set MONEY_DIR temporarily for the database
-	oldMoneyDir := os.Getenv("MONEY_DIR")
-	os.Setenv("MONEY_DIR", cfg.MoneyDir)
-	defer os.Setenv("MONEY_DIR", oldMoneyDir)
-
-	db, err := database.New()
+	db, err := database.Open(cfg)
 	if err != nil {
 		return false, err
 	}
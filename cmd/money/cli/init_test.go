@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestRotateSimpleFINCredentials(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+
+	accountsStatus := http.StatusForbidden
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/claim":
+			fmt.Fprintf(w, "http://newuser:newpass@%s/simplefin", r.Host)
+		case "/simplefin/accounts":
+			if accountsStatus != http.StatusOK {
+				http.Error(w, "forbidden", accountsStatus)
+				return
+			}
+			fmt.Fprint(w, `{"errors": [], "accounts": [{"id": "acc-1", "name": "Checking", "org": {"id": "org-1", "name": "Bank"}, "currency": "USD", "balance": "1.00"}]}`)
+		case "/simplefin/info":
+			fmt.Fprint(w, `{"versions": ["1.0"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	token := base64.StdEncoding.EncodeToString([]byte(server.URL + "/claim"))
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	if err := db.SaveCredentials("https://old.example/simplefin", "olduser", "oldpass"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing connection test keeps the old credentials
+	if _, err := rotateSimpleFINCredentials(db, token); err == nil {
+		t.Fatal("expected rotation to fail when the new credentials don't work")
+	}
+	if _, username, _, _ := db.GetCredentials(); username != "olduser" {
+		t.Errorf("credentials were replaced despite the failed test: %s", username)
+	}
+
+	accountsStatus = http.StatusOK
+	count, err := rotateSimpleFINCredentials(db, token)
+	if err != nil {
+		t.Fatalf("rotateSimpleFINCredentials failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 account, got %d", count)
+	}
+	accessURL, username, password, _ := db.GetCredentials()
+	if accessURL != server.URL+"/simplefin" || username != "newuser" || password != "newpass" {
+		t.Errorf("credentials not rotated: %s %s %s", accessURL, username, password)
+	}
+}
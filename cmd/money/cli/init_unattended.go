@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// initOptions holds the flags accepted by 'money init'. Setting any of them
+// switches init into unattended mode, where no prompt is ever shown. The data
+// directory comes from the global --money-dir flag.
+type initOptions struct {
+	simpleFINToken string
+	rentCastKey    string
+	llmCmd         string
+	yes            bool
+}
+
+func initFlags(opts *initOptions) *flags.Set {
+	set := flags.New("money init")
+	set.Func("simplefin-token", "", "<token>", nonEmptyFlag(&opts.simpleFINToken))
+	set.Func("rentcast-key", "", "<key>", nonEmptyFlag(&opts.rentCastKey))
+	set.Func("llm-cmd", "", "<command>", nonEmptyFlag(&opts.llmCmd))
+	set.BoolVar(&opts.yes, "yes", "y")
+	return set
+}
+
+// nonEmptyFlag returns a flag setter that stores a value in p, refusing an
+// empty one, which would leave init prompting for it
+func nonEmptyFlag(p *string) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			return fmt.Errorf("can't be empty")
+		}
+		*p = value
+		return nil
+	}
+}
+
+func parseInitFlags(args []string) (*initOptions, error) {
+	opts := &initOptions{}
+	if err := initFlags(opts).Parse(args); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// unattended reports whether any flag was given, in which case init must not
+// prompt.
+func (o *initOptions) unattended() bool {
+	return o.yes || o.simpleFINToken != "" || o.rentCastKey != "" || o.llmCmd != ""
+}
+
+// runUnattendedInit performs the same steps as the interactive setup using
+// only the values passed on the command line. Unlike the tutorial, any failure
+// is returned so scripts get a non-zero exit status.
+func runUnattendedInit(opts *initOptions) error {
+	cfg := config.New()
+	if err := cfg.EnsureMoneyDir(); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	fmt.Printf("Data directory: %s\n", cfg.MoneyDir)
+
+	if opts.simpleFINToken != "" || opts.rentCastKey != "" {
+		db, err := database.Open(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+		defer db.Close()
+
+		if opts.simpleFINToken != "" {
+			if err := SetupTokenValidator(opts.simpleFINToken); err != nil {
+				return fmt.Errorf("invalid --simplefin-token: %w", err)
+			}
+			hasCredentials, err := db.HasCredentials()
+			if err != nil {
+				return fmt.Errorf("failed to check existing credentials: %w", err)
+			}
+			if hasCredentials && !opts.yes {
+				return fmt.Errorf("SimpleFIN credentials already exist; pass --yes to overwrite them")
+			}
+			accountCount, err := saveSimpleFINToken(db, opts.simpleFINToken)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%sSimpleFIN setup successful! Found %d accounts\n", icon("✅ "), accountCount)
+		}
+
+		if opts.rentCastKey != "" {
+			if err := APIKeyValidator(opts.rentCastKey); err != nil {
+				return fmt.Errorf("invalid --rentcast-key: %w", err)
+			}
+			hasKey, err := db.HasRentCastAPIKey()
+			if err != nil {
+				return fmt.Errorf("failed to check existing RentCast API key: %w", err)
+			}
+			if hasKey && !opts.yes {
+				return fmt.Errorf("a RentCast API key already exists; pass --yes to overwrite it")
+			}
+			if err := db.SaveRentCastAPIKey(opts.rentCastKey); err != nil {
+				return fmt.Errorf("failed to save RentCast API key: %w", err)
+			}
+			fmt.Println(icon("✅ ") + "RentCast setup successful!")
+		}
+	}
+
+	if opts.llmCmd != "" {
+		cfg.SetLLMPromptCmd(opts.llmCmd)
+		fmt.Printf("LLM command: %s\n", cfg.LLMPromptCmd)
+	}
+
+	exports := cfg.GetBashrcExports()
+	if len(exports) > 0 {
+		fmt.Println()
+		fmt.Println("Add these lines to your shell configuration:")
+		for _, export := range exports {
+			fmt.Println(export)
+		}
+	}
+
+	return nil
+}
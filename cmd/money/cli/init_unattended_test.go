@@ -0,0 +1,34 @@
+package cli
+
+import "testing"
+
+func TestParseInitFlags(t *testing.T) {
+	opts, err := parseInitFlags([]string{"--simplefin-token", "abc", "--llm-cmd", "ollama", "-y"})
+	if err != nil {
+		t.Fatalf("parseInitFlags: %v", err)
+	}
+	if opts.simpleFINToken != "abc" || opts.llmCmd != "ollama" || !opts.yes {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if !opts.unattended() {
+		t.Error("expected unattended mode when flags are given")
+	}
+
+	opts, err = parseInitFlags(nil)
+	if err != nil {
+		t.Fatalf("parseInitFlags(nil): %v", err)
+	}
+	if opts.unattended() {
+		t.Error("expected interactive mode without flags")
+	}
+
+	if _, err := parseInitFlags([]string{"--rentcast-key"}); err == nil {
+		t.Error("expected error for flag without a value")
+	}
+	if _, err := parseInitFlags([]string{"--money-dir", "/tmp/money"}); err == nil {
+		t.Error("expected --money-dir to be left to the global flags")
+	}
+	if _, err := parseInitFlags([]string{"--bogus"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Validators
+
+func DirectoryValidator(path string) error {
+	if path == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+
+	// Expand ~ to home directory
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("could not get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+
+	// Check if directory exists or can be created
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Try to create the directory
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return fmt.Errorf("cannot create directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func SetupTokenValidator(token string) error {
+	if token == "" {
+		return fmt.Errorf("setup token cannot be empty")
+	}
+	if len(token) < 10 {
+		return fmt.Errorf("setup token appears too short")
+	}
+	return nil
+}
+
+func APIKeyValidator(key string) error {
+	if key == "" {
+		return fmt.Errorf("API key cannot be empty")
+	}
+	if len(key) < 10 {
+		return fmt.Errorf("API key appears too short")
+	}
+	return nil
+}
+
+func CountryValidator(country string) error {
+	if len(country) != 2 {
+		return fmt.Errorf("use a two-letter country code, like DE or GB")
+	}
+	for _, r := range strings.ToUpper(country) {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("use a two-letter country code, like DE or GB")
+		}
+	}
+	return nil
+}
+
+func BatchSizeValidator(input string) error {
+	if input == "" {
+		return nil // Allow empty for default
+	}
+
+	size, err := strconv.Atoi(input)
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+
+	if size < 1 || size > 100 {
+		return fmt.Errorf("batch size must be between 1 and 100")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// defaultLLMLogLimit is how many calls 'money llm log' lists by default
+const defaultLLMLogLimit = 20
+
+var LLM = &Z.Cmd{
+	Name:    "llm",
+	Summary: "Inspect what was sent to and received from the LLM",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		LLMLog,
+	},
+}
+
+func llmLogFlags(limit *int, failedOnly *bool) *flags.Set {
+	set := flags.New("money llm log")
+	set.IntVar(limit, "limit", "n", "N", 1)
+	set.BoolVar(failedOnly, "failed", "")
+	return set
+}
+
+var LLMLog = &Z.Cmd{
+	Name:    "log",
+	Summary: "List logged LLM prompts and responses, newest first",
+	Usage:   "log " + llmLogFlags(new(int), new(bool)).Usage(),
+	Description: `
+Every prompt piped to the LLM command and the raw response it returned are
+logged in the database with the time, latency, and estimated token counts,
+including calls that failed. Use 'money llm log show <id>' to see the full
+prompt and response, and 'money llm log clear' to delete the log.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		LLMLogShow,
+		LLMLogClear,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		limit := defaultLLMLogLimit
+		failedOnly := false
+		if err := llmLogFlags(&limit, &failedOnly).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			calls, err := db.GetLLMCalls(limit, failedOnly)
+			if err != nil {
+				return err
+			}
+
+			if len(calls) == 0 {
+				fmt.Println("No LLM calls logged")
+				return nil
+			}
+
+			t := table.New("ID", "Time", "Purpose", "Latency", "Tokens In", "Tokens Out", "Status")
+			t.SetColumnTypes(table.ColumnNumber, table.ColumnText, table.ColumnText, table.ColumnNumber, table.ColumnNumber, table.ColumnNumber)
+			for _, call := range calls {
+				status := greenColor.Sprint("ok")
+				if call.Error != "" {
+					status = redColor.Sprint("failed")
+				}
+				t.AddRow(
+					strconv.Itoa(call.ID),
+					call.CreatedAt,
+					call.Purpose,
+					fmt.Sprintf("%.1fs", float64(call.LatencyMs)/1000),
+					"~"+strconv.Itoa(call.PromptTokens),
+					"~"+strconv.Itoa(call.ResponseTokens),
+					status,
+				)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var LLMLogShow = &Z.Cmd{
+	Name:     "show",
+	Summary:  "Show the full prompt and response of a logged call",
+	Usage:    "show <id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money llm log show <id>")
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid call ID: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			call, err := db.GetLLMCall(id)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("LLM call %d\n", call.ID)
+			fmt.Printf("  Time: %s\n", call.CreatedAt)
+			fmt.Printf("  Purpose: %s\n", call.Purpose)
+			fmt.Printf("  Command: %s\n", call.Command)
+			fmt.Printf("  Latency: %dms\n", call.LatencyMs)
+			fmt.Printf("  Tokens: ~%d in, ~%d out (estimated)\n", call.PromptTokens, call.ResponseTokens)
+			if call.Error != "" {
+				redColor.Printf("  Error: %s\n", call.Error)
+			}
+
+			fmt.Println("\n--- Prompt ---")
+			fmt.Println(call.Prompt)
+			fmt.Println("\n--- Response ---")
+			if call.Response == "" {
+				grayColor.Println("(empty)")
+			} else {
+				fmt.Println(call.Response)
+			}
+			return nil
+		})
+	},
+}
+
+var LLMLogClear = &Z.Cmd{
+	Name:     "clear",
+	Summary:  "Delete all logged LLM calls",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			removed, err := db.ClearLLMCalls()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted %d logged LLM calls\n", removed)
+			return nil
+		})
+	},
+}
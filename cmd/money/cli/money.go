@@ -8,17 +8,42 @@ import (
 var Cmd = &Z.Cmd{
 	Name:    "money",
 	Summary: "Personal finance management CLI",
+	Hidden:  []string{completeCmdName},
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		Version,
 		Update,
 		Init,
+		Demo,
+		Config,
 		Fetch,
+		Diff,
 		Balance,
 		Accounts,
 		Categories,
 		Property,
+		Assets,
+		Holdings,
+		Crypto,
 		Budget,
 		Transactions,
+		Rules,
+		Alerts,
+		Bills,
+		Close,
+		Report,
+		Export,
+		YNAB,
+		Actual,
+		Sync,
+		Ask,
+		LLM,
+		Notify,
+		UI,
+		DB,
+		Debug,
+		Doctor,
+		Completion,
+		completeCmd,
 	},
 }
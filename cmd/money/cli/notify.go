@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/pkg/alerts"
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/notify"
+)
+
+var Notify = &Z.Cmd{
+	Name:    "notify",
+	Summary: "Show and test the configured notification sinks",
+	Description: `
+Alerts and fetch failures are sent to every configured notification sink:
+
+  MONEY_NOTIFY_WEBHOOK      URL that receives a JSON POST of {"title", "body"}
+  MONEY_NOTIFY_NTFY         ntfy topic URL, e.g. https://ntfy.sh/my-money
+  MONEY_NOTIFY_NTFY_TOKEN   access token for protected ntfy topics
+  MONEY_SMTP_HOST           SMTP server for email (with MONEY_SMTP_PORT,
+                            MONEY_SMTP_USER, MONEY_SMTP_PASSWORD,
+                            MONEY_SMTP_FROM, and comma-separated MONEY_SMTP_TO)
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		NotifyTest,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		sinks := notify.FromConfig(config.New())
+		if len(sinks) == 0 {
+			fmt.Println("No notification sinks configured. See 'money notify help'.")
+			return nil
+		}
+
+		fmt.Println("Configured notification sinks:")
+		for _, sink := range sinks {
+			fmt.Printf("  %s\n", sink.Name())
+		}
+		return nil
+	},
+}
+
+var NotifyTest = &Z.Cmd{
+	Name:     "test",
+	Summary:  "Send a test message to every configured sink",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		sinks := notify.FromConfig(config.New())
+		if len(sinks) == 0 {
+			return fmt.Errorf("no notification sinks configured. See 'money notify help'")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		msg := notify.Message{Title: "money: test notification", Body: "Notifications from money are working."}
+		if err := notify.Send(ctx, sinks, msg); err != nil {
+			return fmt.Errorf("failed to send test notification: %w", err)
+		}
+
+		fmt.Printf("Sent a test notification to %d sink(s)\n", len(sinks))
+		return nil
+	},
+}
+
+// sendNotification delivers a message to the configured sinks, if any.
+// Failures are printed as warnings so they never fail the calling command.
+func sendNotification(msg notify.Message) {
+	sinks := notify.FromConfig(config.New())
+	if len(sinks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := notify.Send(ctx, sinks, msg); err != nil {
+		slog.Warn("failed to send notification", "err", err)
+	}
+}
+
+// notifyAlerts sends the raised alerts that haven't been sent yet to the
+// configured sinks, as one notification
+func notifyAlerts(db *database.DB, raised []alerts.Alert, now time.Time) {
+	unsent, err := alerts.Unsent(db, raised, now)
+	if err != nil {
+		slog.Warn("failed to check which alerts were already sent", "err", err)
+		return
+	}
+	if len(unsent) > 0 {
+		sendNotification(alertsMessage(unsent))
+	}
+}
+
+// alertsMessage formats raised alerts as a single notification
+func alertsMessage(raised []alerts.Alert) notify.Message {
+	lines := make([]string, len(raised))
+	for i, alert := range raised {
+		lines[i] = "- " + alert.Message
+	}
+
+	title := "money: 1 alert"
+	if len(raised) != 1 {
+		title = fmt.Sprintf("money: %d alerts", len(raised))
+	}
+	return notify.Message{Title: title, Body: strings.Join(lines, "\n")}
+}
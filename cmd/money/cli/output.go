@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/guptarohit/asciigraph"
+	"github.com/muesli/termenv"
+
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// noColor is set by --no-color
+var noColor bool
+
+// plainOutput is set by --plain: emoji icons are left out, for scripts, logs
+// and terminals that can't show them
+var plainOutput bool
+
+// applyColorSetting turns colors off everywhere, tables, graphs and the TUIs
+// included, when --no-color is given or NO_COLOR is set
+func applyColorSetting() {
+	if !noColor && os.Getenv("NO_COLOR") == "" {
+		return
+	}
+	color.NoColor = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// hintf prints a gray note about the output, like how to see more of it.
+// Notes go to stderr so they never end up in output redirected with
+// --format csv, tsv or markdown.
+func hintf(msg string, args ...any) {
+	grayColor.Fprintf(os.Stderr, msg, args...)
+}
+
+// textOutput reports whether tables are rendered as aligned text, rather
+// than in a machine-readable --format
+func textOutput() bool {
+	return table.DefaultFormat() == table.FormatText
+}
+
+// icon returns s, an emoji icon and the spacing after it, or nothing with
+// --plain
+func icon(s string) string {
+	if plainOutput {
+		return ""
+	}
+	return s
+}
+
+// graphColors colors graph series, or leaves them in the terminal's default
+// color when colors are off
+func graphColors(colors ...asciigraph.AnsiColor) asciigraph.Option {
+	if color.NoColor {
+		colors = make([]asciigraph.AnsiColor, len(colors))
+	}
+	return asciigraph.SeriesColors(colors...)
+}
+
+// legendKey returns the colored square a graph legend names a series by,
+// left uncolored when colors are off
+func legendKey(c asciigraph.AnsiColor) string {
+	if color.NoColor {
+		return "■"
+	}
+	return c.String() + "■" + asciigraph.Default.String()
+}
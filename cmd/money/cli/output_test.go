@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/guptarohit/asciigraph"
+)
+
+func TestIcon(t *testing.T) {
+	t.Cleanup(func() { plainOutput = false })
+
+	if got := icon("💰 ") + "Income"; got != "💰 Income" {
+		t.Errorf("icon = %q, want the emoji kept", got)
+	}
+	plainOutput = true
+	if got := icon("💰 ") + "Income"; got != "Income" {
+		t.Errorf("icon with --plain = %q, want Income", got)
+	}
+	if got := checkWarn.icon(); strings.ContainsRune(got, '⚠') {
+		t.Errorf("doctor icon with --plain = %q, want a text label", got)
+	}
+}
+
+func TestApplyColorSetting(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	t.Setenv("NO_COLOR", "1")
+	color.NoColor = false
+	applyColorSetting()
+	if !color.NoColor {
+		t.Error("NO_COLOR didn't turn colors off")
+	}
+
+	graph := asciigraph.Plot([]float64{1, 3, 2}, graphColors(asciigraph.Red))
+	if strings.Contains(graph, "\x1b[") {
+		t.Errorf("graph has colors with colors off:\n%s", graph)
+	}
+}
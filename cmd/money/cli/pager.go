@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// pagerCommand is the shell command long output is paged through, set in
+// Run from the pager setting; "" turns paging off
+var pagerCommand string
+
+// noPager is set by --no-pager
+var noPager bool
+
+// resolvePager returns the pager to use given the pager setting: the
+// setting, then $PAGER, then less. An empty value or cat means no pager.
+func resolvePager(setting string) string {
+	if noPager {
+		return ""
+	}
+	pager := setting
+	if pager == "" {
+		var ok bool
+		if pager, ok = os.LookupEnv("PAGER"); !ok {
+			pager = "less"
+		}
+	}
+	if pager = strings.TrimSpace(pager); pager == "cat" {
+		return ""
+	}
+	return pager
+}
+
+// startPager sends stdout through the pager when it's a terminal, like git
+// does for long output. less is told to quit when the output fits on one
+// screen and to keep colors, unless $LESS says otherwise. The returned
+// function must be called when the output is done: it restores stdout and
+// waits for the pager to quit.
+func startPager() func() {
+	if pagerCommand == "" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		slog.Debug("not paging output", "err", err)
+		return func() {}
+	}
+
+	pager := exec.Command("sh", "-c", pagerCommand)
+	pager.Stdin = r
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	pager.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		pager.Env = append(pager.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		pager.Env = append(pager.Env, "LV=-c")
+	}
+	if err := pager.Start(); err != nil {
+		r.Close()
+		w.Close()
+		slog.Warn("not paging output", "pager", pagerCommand, "err", err)
+		return func() {}
+	}
+	r.Close()
+
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	return func() {
+		os.Stdout, color.Output = stdout, colorOutput
+		w.Close()
+		if err := pager.Wait(); err != nil {
+			slog.Debug("pager exited", "pager", pagerCommand, "err", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResolvePager(t *testing.T) {
+	t.Cleanup(func() { noPager = false })
+
+	tests := []struct {
+		setting string
+		env     string
+		unset   bool
+		noPager bool
+		want    string
+	}{
+		{setting: "most", env: "more", want: "most"},
+		{env: "more -s", want: "more -s"},
+		{unset: true, want: "less"},
+		{env: "", want: ""},
+		{env: "cat", want: ""},
+		{setting: " cat ", env: "more", want: ""},
+		{setting: "most", noPager: true, want: ""},
+	}
+	for _, tt := range tests {
+		if tt.unset {
+			t.Setenv("PAGER", "")
+			os.Unsetenv("PAGER")
+		} else {
+			t.Setenv("PAGER", tt.env)
+		}
+		noPager = tt.noPager
+		if got := resolvePager(tt.setting); got != tt.want {
+			t.Errorf("resolvePager(%q) with PAGER=%q = %q, want %q", tt.setting, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestStartPagerNotTerminal(t *testing.T) {
+	defer func(command string) { pagerCommand = command }(pagerCommand)
+	pagerCommand = "false"
+
+	stdout := os.Stdout
+	stop := startPager()
+	if os.Stdout != stdout {
+		t.Error("stdout was redirected although it isn't a terminal")
+	}
+	stop()
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// noProgress is set by --quiet
+var noProgress bool
+
+// progressEnabled reports whether progress lines can be drawn; swapped out
+// in tests
+var progressEnabled = func() bool {
+	return !noProgress && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+const (
+	progressInterval = 100 * time.Millisecond
+	progressBarWidth = 20
+)
+
+var (
+	spinnerFrames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	plainSpinnerFrames = []string{"|", "/", "-", "\\"}
+)
+
+// progress is a status line on stderr for slow work, like a full-history
+// fetch or a run of LLM batches: a spinner, a label, a bar and count when
+// the total is known, and the time elapsed. It keeps redrawing while the
+// caller waits, and is cleared when done, leaving the regular output as it
+// was. Off a terminal, or with --quiet, nothing is drawn.
+type progress struct {
+	mu      sync.Mutex
+	label   string
+	total   int
+	done    int
+	frame   int
+	started time.Time
+	drawing bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startProgress starts drawing a progress line for total units of work, or
+// just a spinner when total is 0. Call Done when the work is over.
+func startProgress(label string, total int) *progress {
+	p := &progress{label: label, total: total, started: time.Now()}
+	if !progressEnabled() {
+		return p
+	}
+	p.drawing = true
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	go p.run()
+	return p
+}
+
+func (p *progress) run() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	p.mu.Lock()
+	p.draw()
+	p.mu.Unlock()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Add records n more units of work done
+func (p *progress) Add(n int) {
+	p.mu.Lock()
+	p.done += n
+	p.mu.Unlock()
+}
+
+// Printf prints regular output to stdout, moving the progress line out of
+// its way
+func (p *progress) Printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawing {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	fmt.Printf(format, args...)
+	if p.drawing {
+		p.draw()
+	}
+}
+
+// Done stops and clears the progress line. Calling it again does nothing.
+func (p *progress) Done() {
+	p.mu.Lock()
+	if !p.drawing {
+		p.mu.Unlock()
+		return
+	}
+	p.drawing = false
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.stopped
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+// draw redraws the line; p.mu must be held
+func (p *progress) draw() {
+	line := progressLine(p.label, p.done, p.total, p.frame, time.Since(p.started), plainOutput)
+	// A line wider than the terminal wraps, and \r only returns to the
+	// start of the last row
+	if width, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil && width > 1 {
+		if runes := []rune(line); len(runes) >= width {
+			line = string(runes[:width-1])
+		}
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K"+line)
+}
+
+// progressLine renders a progress line like "⠹ Transactions
+// [████████░░░░░░░░░░░░] 412/1030 (12s)". With plain, only ASCII is used.
+func progressLine(label string, done, total, frame int, elapsed time.Duration, plain bool) string {
+	frames, full, empty := spinnerFrames, "█", "░"
+	if plain {
+		frames, full, empty = plainSpinnerFrames, "#", "-"
+	}
+
+	var b strings.Builder
+	b.WriteString(frames[frame%len(frames)])
+	b.WriteString(" ")
+	b.WriteString(label)
+	if total > 0 {
+		if done > total {
+			done = total
+		}
+		filled := done * progressBarWidth / total
+		fmt.Fprintf(&b, " [%s%s] %d/%d", strings.Repeat(full, filled), strings.Repeat(empty, progressBarWidth-filled), done, total)
+	}
+	fmt.Fprintf(&b, " (%s)", elapsed.Round(time.Second))
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	tests := []struct {
+		label       string
+		done, total int
+		frame       int
+		plain       bool
+		want        string
+	}{
+		{"Waiting for SimpleFIN", 0, 0, 1, false, "⠙ Waiting for SimpleFIN (3s)"},
+		{"Transactions", 5, 20, 0, false, "⠋ Transactions [█████░░░░░░░░░░░░░░░] 5/20 (3s)"},
+		{"LLM batches", 3, 3, 2, true, "- LLM batches [####################] 3/3 (3s)"},
+		{"Accounts", 7, 4, 0, true, "| Accounts [####################] 4/4 (3s)"},
+	}
+	for _, tt := range tests {
+		got := progressLine(tt.label, tt.done, tt.total, tt.frame, 3200*time.Millisecond, tt.plain)
+		if got != tt.want {
+			t.Errorf("progressLine(%q, %d, %d) = %q, want %q", tt.label, tt.done, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestProgressDisabled(t *testing.T) {
+	defer func(enabled func() bool) { progressEnabled = enabled }(progressEnabled)
+	progressEnabled = func() bool { return false }
+
+	p := startProgress("Accounts", 2)
+	p.Add(1)
+	p.Done()
+	p.Done()
+	if p.done != 1 || p.stop != nil {
+		t.Errorf("Expected a progress that only counts, got %+v", p)
+	}
+}
@@ -2,12 +2,16 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
 	"github.com/arjungandhi/money/pkg/database"
 	"github.com/arjungandhi/money/pkg/format"
 	"github.com/arjungandhi/money/pkg/property"
@@ -17,7 +21,7 @@ import (
 var Property = &Z.Cmd{
 	Name:    "property",
 	Aliases: []string{"prop", "p"},
-	Summary: "Manage property accounts and valuations using RentCast API",
+	Summary: "Manage property accounts and valuations",
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		PropertyAdd,
@@ -25,12 +29,24 @@ var Property = &Z.Cmd{
 		PropertyUpdate,
 		PropertyUpdateAll,
 		PropertySetValue,
+		PropertyProvider,
+		PropertyMortgage,
+		PropertyEquity,
+		PropertyTag,
+		PropertyUntag,
+		PropertyPnL,
+		PropertyRecords,
 		PropertyDetails,
 	},
 	Description: `
-Manage property accounts and valuations using RentCast API.
+Manage property accounts and valuations.
 
-To configure RentCast API access, use: money init rentcast
+Values come from a valuation provider, chosen per property:
+  rentcast - RentCast value and rent estimates (default), set up with
+             money init rentcast
+  attom    - ATTOM AVM value estimates, set up with
+             money config set attom_api_key <key>
+  manual   - never fetched, set with set-value
 
 Commands:
   add        - Add a new property account
@@ -38,6 +54,13 @@ Commands:
   update     - Update valuation for a specific property
   update-all - Update valuations for all properties
   set-value  - Manually set property value
+  provider   - Choose where a property's valuations come from
+  mortgage   - Link loan accounts to the properties they're secured by
+  equity     - Show property values minus linked mortgages
+  tag        - Tag transactions as a property's income or expenses
+  untag      - Remove transactions' property tags
+  pnl        - Show a property's cash flow, cap rate and ROI
+  records    - Fetch beds, baths, size and year built from RentCast
   details    - Show detailed property information
 `,
 }
@@ -114,11 +137,10 @@ Examples:
 			}
 		}
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		propertyService := property.NewService(db)
 
@@ -152,11 +174,10 @@ var PropertyList = &Z.Cmd{
 	Summary:  "List all property accounts with their details",
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		propertyService := property.NewService(db)
 
@@ -174,7 +195,8 @@ var PropertyList = &Z.Cmd{
 		config.Title = "Property Accounts"
 		config.MaxColumnWidth = 30
 
-		t := table.NewWithConfig(config, "Account ID", "Address", "Value", "Last Updated")
+		t := table.NewWithConfig(config, "Account ID", "Address", "Value", "Provider", "Last Updated")
+		t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency)
 
 		for _, prop := range properties {
 			// Get account details for the current balance
@@ -187,7 +209,7 @@ var PropertyList = &Z.Cmd{
 
 			valueStr := "N/A"
 			if account.Balance > 0 {
-				valueStr = format.Currency(account.Balance, "USD")
+				valueStr = format.Currency(account.Balance, account.Currency)
 			}
 
 			lastUpdated := "Never"
@@ -195,7 +217,7 @@ var PropertyList = &Z.Cmd{
 				lastUpdated = *prop.LastUpdated
 			}
 
-			t.AddRow(prop.AccountID, address, valueStr, lastUpdated)
+			t.AddRow(prop.AccountID, address, valueStr, property.ProviderName(prop), lastUpdated)
 		}
 
 		if err := t.Render(); err != nil {
@@ -208,7 +230,7 @@ var PropertyList = &Z.Cmd{
 
 var PropertyUpdate = &Z.Cmd{
 	Name:     "update",
-	Summary:  "Update valuation for a specific property using RentCast API",
+	Summary:  "Update valuation for a specific property from its provider",
 	Usage:    "<account-id>",
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
@@ -218,11 +240,10 @@ var PropertyUpdate = &Z.Cmd{
 
 		accountID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		propertyService := property.NewService(db)
 
@@ -246,43 +267,104 @@ var PropertyUpdate = &Z.Cmd{
 
 		fmt.Printf("Successfully updated property valuation:\n")
 		fmt.Printf("  Address: %s, %s, %s %s\n", propertyDetails.Address, propertyDetails.City, propertyDetails.State, propertyDetails.ZipCode)
-		fmt.Printf("  Current Value: %s\n", format.Currency(account.Balance, "USD"))
+		fmt.Printf("  Current Value: %s\n", format.Currency(account.Balance, account.Currency))
 
 		if propertyDetails.LastRentEstimate != nil {
-			fmt.Printf("  Estimated Rent: %s/month\n", format.Currency(*propertyDetails.LastRentEstimate, "USD"))
+			fmt.Printf("  Estimated Rent: %s/month\n", format.Currency(*propertyDetails.LastRentEstimate, account.Currency))
 		}
 
 		return nil
 	},
 }
 
+func propertyUpdateAllFlags(maxAgeDays *int) *flags.Set {
+	set := flags.New("money property update-all")
+	set.IntVar(maxAgeDays, "max-age", "", "<days>", 0)
+	return set
+}
+
 var PropertyUpdateAll = &Z.Cmd{
-	Name:     "update-all",
-	Summary:  "Update valuations for all property accounts using RentCast API",
+	Name:    "update-all",
+	Summary: "Update valuations for all property accounts from their providers",
+	Usage:   propertyUpdateAllFlags(new(int)).Usage(),
+	Description: `
+Refreshes every property's valuation from its provider, skipping properties
+valued manually. With --max-age, properties valued within the last <days>
+days are skipped too, which saves API quota when run on a schedule. The
+default comes from the property_max_age_days setting, which also applies
+to the refresh after 'money fetch'; 0 refreshes every property.
+
+Several properties are valued at once, with at most
+rentcast_requests_per_second RentCast requests a second (20 by default;
+set it to your plan's limit). A property that fails doesn't stop the
+others: each one's result is listed at the end, and the command fails if
+any did.
+
+Examples:
+  money property update-all
+  money property update-all --max-age 30
+`,
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
+
+		maxAgeDays := db.GetConfig().PropertyMaxAgeDays
+		if err := propertyUpdateAllFlags(&maxAgeDays).Parse(args); err != nil {
+			return err
+		}
 
 		propertyService := property.NewService(db)
 
 		fmt.Println("Updating valuations for all property accounts...")
 
-		err = propertyService.UpdateAllPropertyValuations()
+		summary, err := propertyService.UpdateAllPropertyValuations(time.Duration(maxAgeDays) * 24 * time.Hour)
 		if err != nil {
 			return fmt.Errorf("failed to update property valuations: %w", err)
 		}
+		printPropertyUpdateSummary(summary, maxAgeDays)
 
-		fmt.Println("Successfully updated all property valuations.")
-		fmt.Println("Run 'money balance' to see updated net worth with current property values.")
+		if len(summary.Refreshed) > 0 {
+			fmt.Println("Run 'money balance' to see updated net worth with current property values.")
+		}
+		if len(summary.Failed) > 0 {
+			// Exit non-zero so scheduled runs notice
+			return fmt.Errorf("%d of %d property valuations failed", len(summary.Failed), len(summary.Failed)+len(summary.Refreshed))
+		}
 
 		return nil
 	},
 }
 
+// printPropertyUpdateSummary lists which properties update-all refreshed,
+// which it skipped and which failed, with why
+func printPropertyUpdateSummary(summary *property.UpdateSummary, maxAgeDays int) {
+	for _, prop := range summary.Refreshed {
+		fmt.Printf("  %s%s: refreshed\n", icon("✅ "), prop.Address)
+	}
+	for _, prop := range summary.Skipped {
+		updated, _ := property.LastUpdated(prop)
+		fmt.Printf("  %s%s: skipped, valued %s\n", icon("⏭️  "), prop.Address, updated.Local().Format("2006-01-02"))
+	}
+	for _, prop := range summary.Manual {
+		fmt.Printf("  %s%s: skipped, valued manually\n", icon("⏭️  "), prop.Address)
+	}
+	for _, failure := range summary.Failed {
+		fmt.Printf("  %s%s: failed: %v\n", icon("❌ "), failure.Property.Address, failure.Err)
+	}
+
+	fmt.Printf("%d refreshed, %d skipped", len(summary.Refreshed), len(summary.Skipped)+len(summary.Manual))
+	if maxAgeDays > 0 && len(summary.Skipped) > 0 {
+		fmt.Printf(" (%d valued within %d days)", len(summary.Skipped), maxAgeDays)
+	}
+	if len(summary.Failed) > 0 {
+		fmt.Printf(", %d failed", len(summary.Failed))
+	}
+	fmt.Println()
+}
+
 var PropertySetValue = &Z.Cmd{
 	Name:     "set-value",
 	Summary:  "Manually set the value for a property account",
@@ -307,13 +389,12 @@ var PropertySetValue = &Z.Cmd{
 		}
 
 		// Convert to cents
-		valueInCents := int(value * 100)
+		valueInCents := int64(value * 100)
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		propertyService := property.NewService(db)
 
@@ -327,13 +408,510 @@ var PropertySetValue = &Z.Cmd{
 		if err != nil {
 			return fmt.Errorf("failed to set property value: %w", err)
 		}
+		account, err := db.GetAccountByID(accountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account details: %w", err)
+		}
+
+		fmt.Printf("Successfully set property value to %s for account: %s\n", format.Currency(valueInCents, account.Currency), accountID)
+
+		return nil
+	},
+}
+
+var PropertyProvider = &Z.Cmd{
+	Name:    "provider",
+	Summary: "Choose where a property's valuations come from",
+	Usage:   "<account-id> rentcast|attom|manual|default",
+	Description: `
+Sets the valuation provider used by update, update-all and fetch for one
+property. Switch providers when one is down or too expensive; properties
+set to manual are skipped and only change with set-value.
+
+Examples:
+  money property provider property_TX_Austin_78701 attom
+  money property provider property_TX_Austin_78701 manual
+  money property provider property_TX_Austin_78701 default
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		accountID := args[0]
+		provider := args[1]
 
-		fmt.Printf("Successfully set property value to %s for account: %s\n", format.Currency(valueInCents, "USD"), accountID)
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		err = propertyService.SetValuationProvider(accountID, provider)
+		if err != nil {
+			return fmt.Errorf("failed to set valuation provider: %w", err)
+		}
+
+		if provider == "default" {
+			provider = property.DefaultProvider
+		}
+		fmt.Printf("Property %s is now valued with %s\n", accountID, provider)
+
+		return nil
+	},
+}
+
+var PropertyMortgage = &Z.Cmd{
+	Name:    "mortgage",
+	Summary: "Link loan accounts to the properties they're secured by",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		PropertyMortgageLink,
+		PropertyMortgageUnlink,
+	},
+}
+
+var PropertyMortgageLink = &Z.Cmd{
+	Name:    "link",
+	Summary: "Link a loan account to the property it's secured by",
+	Usage:   "<property-account-id> <loan-account-id>",
+	Description: `
+Counts a loan against a property's equity. The loan must have the loan
+account type ('money accounts type set <account-id> loan'). A property can
+have several loans, such as a mortgage and a HELOC, but each loan belongs
+to one property; linking a loan again moves it.
+
+Example:
+  money property mortgage link property_TX_Austin_78701 ACT-mortgage-123
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		propertyAccountID := args[0]
+		loanAccountID := args[1]
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		err = propertyService.LinkMortgage(propertyAccountID, loanAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to link mortgage: %w", err)
+		}
+
+		fmt.Printf("Linked loan %s to property %s\n", loanAccountID, propertyAccountID)
+		fmt.Println("Run 'money property equity' to see the property's equity.")
+
+		return nil
+	},
+}
+
+var PropertyMortgageUnlink = &Z.Cmd{
+	Name:     "unlink",
+	Summary:  "Stop counting a loan against its property's equity",
+	Usage:    "<loan-account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		loanAccountID := args[0]
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		err = propertyService.UnlinkMortgage(loanAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to unlink mortgage: %w", err)
+		}
+
+		fmt.Printf("Unlinked loan %s from its property\n", loanAccountID)
+
+		return nil
+	},
+}
+
+var PropertyEquity = &Z.Cmd{
+	Name:    "equity",
+	Summary: "Show each property's value minus its linked mortgages",
+	Description: `
+Shows each property's current value, the outstanding balance of the loans
+linked to it with 'money property mortgage link', the equity left over and
+the loan-to-value ratio, plus totals across all properties.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown argument: %s", args[0])
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		equities, err := propertyService.GetEquity()
+		if err != nil {
+			return fmt.Errorf("failed to get property equity: %w", err)
+		}
+
+		if len(equities) == 0 {
+			fmt.Println("No property accounts found. Use 'money property add' to add a property.")
+			return nil
+		}
+
+		config := table.DefaultConfig()
+		config.Title = "Property Equity"
+		config.MaxColumnWidth = 30
+
+		t := table.NewWithConfig(config, "Property", "Value", "Mortgages", "Owed", "Equity", "LTV")
+		t.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnNumber)
+
+		var total property.Equity
+		for _, equity := range equities {
+			var loans []string
+			for _, loan := range equity.Mortgages {
+				loans = append(loans, loan.DisplayName())
+			}
+			mortgages := strings.Join(loans, ", ")
+			if mortgages == "" {
+				mortgages = "-"
+			}
+
+			t.AddRow(equity.Name, format.Currency(equity.Value, format.BaseCurrency()), mortgages,
+				format.Currency(equity.Owed, format.BaseCurrency()), format.Currency(equity.Equity(), format.BaseCurrency()), percentString(equity.LoanToValue()))
+
+			total.Value += equity.Value
+			total.Owed += equity.Owed
+		}
+
+		if len(equities) > 1 {
+			t.SetFooter("Total", format.Currency(total.Value, format.BaseCurrency()), "",
+				format.Currency(total.Owed, format.BaseCurrency()), format.Currency(total.Equity(), format.BaseCurrency()), percentString(total.LoanToValue()))
+		}
+
+		if err := t.Render(); err != nil {
+			return fmt.Errorf("failed to render equity table: %w", err)
+		}
 
 		return nil
 	},
 }
 
+var PropertyTag = &Z.Cmd{
+	Name:    "tag",
+	Summary: "Tag transactions as a property's income or expenses",
+	Usage:   "<property-account-id> <transaction-id>...",
+	Description: `
+Tags transactions, such as rent received, repairs, property taxes,
+insurance and mortgage payments, to a property so they show up in
+'money property pnl'. Their categories decide how they're reported;
+transactions in internal categories (such as Transfers) count as debt
+service. Tagging a transaction again moves it to the new property.
+
+Find transaction IDs with 'money transactions list'.
+
+Example:
+  money property tag property_TX_Austin_78701 TXN-123 TXN-456
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		accountID := args[0]
+		transactionIDs := args[1:]
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		err = propertyService.TagTransactions(accountID, transactionIDs)
+		if err != nil {
+			return fmt.Errorf("failed to tag transactions: %w", err)
+		}
+
+		fmt.Printf("Tagged %d transaction(s) to property %s\n", len(transactionIDs), accountID)
+
+		return nil
+	},
+}
+
+var PropertyUntag = &Z.Cmd{
+	Name:     "untag",
+	Summary:  "Remove transactions' property tags",
+	Usage:    "<transaction-id>...",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		for _, transactionID := range args {
+			err = propertyService.UntagTransaction(transactionID)
+			if err != nil {
+				return fmt.Errorf("failed to untag transaction: %w", err)
+			}
+		}
+
+		fmt.Printf("Untagged %d transaction(s)\n", len(args))
+
+		return nil
+	},
+}
+
+func propertyPnLFlags(year *int) *flags.Set {
+	set := flags.New("money property pnl")
+	set.Positional("<account-id>")
+	set.IntVar(year, "year", "y", "YYYY", 1900)
+	return set
+}
+
+var PropertyPnL = &Z.Cmd{
+	Name:    "pnl",
+	Summary: "Show a property's rental cash flow, cap rate and ROI",
+	Usage:   propertyPnLFlags(new(int)).Usage(),
+	Description: `
+Adds up the transactions tagged to a property with 'money property tag'
+for a calendar year, the current year by default:
+
+  Income            incoming tagged transactions, by category
+  Operating costs   outgoing tagged transactions, by category
+  NOI               net operating income, income less operating costs
+  Debt service      tagged transactions in internal categories, such as
+                    mortgage payments filed under Transfers
+  Cash flow         NOI less debt service
+
+  Cap rate          NOI as a percentage of the current value
+  ROI               cash flow as a percentage of the current equity (value
+                    less linked mortgages), the cash-on-cash return
+
+For the current year the ratios are annualized from the days so far.
+
+Examples:
+  money property pnl property_TX_Austin_78701
+  money property pnl property_TX_Austin_78701 --year 2024
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		now := time.Now()
+		year := now.Year()
+		set := propertyPnLFlags(&year)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		positional := set.Args()
+
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		pnl, err := propertyService.GetPnL(positional[0], year, now)
+		if err != nil {
+			return fmt.Errorf("failed to get property P&L: %w", err)
+		}
+
+		config := table.DefaultConfig()
+		config.Title = fmt.Sprintf("%s%s P&L (%s to %s)", icon("🏠 "), pnl.Name, pnl.Start.Format("Jan 2, 2006"), pnl.End.Format("Jan 2, 2006"))
+		config.ShowHeaders = false
+
+		t := table.NewWithConfig(config, "", "")
+		addPnLSection(t, "Income", pnl.Income)
+		t.AddRow("Total Income", format.Currency(pnl.TotalIncome(), format.BaseCurrency()))
+		addPnLSection(t, "Operating Costs", pnl.OperatingExpenses)
+		t.AddRow("Total Operating Costs", format.Currency(pnl.TotalOperatingExpenses(), format.BaseCurrency()))
+		t.SetFooter("Net Operating Income", format.Currency(pnl.NOI(), format.BaseCurrency()))
+
+		if err := t.Render(); err != nil {
+			return fmt.Errorf("failed to render P&L table: %w", err)
+		}
+
+		// Below the operating income, what's left after the mortgage and how
+		// the property is doing overall
+		config.Title = ""
+		returns := table.NewWithConfig(config, "", "")
+		returns.AddRow("Debt Service", format.Currency(pnl.DebtService, format.BaseCurrency()))
+		returns.AddRow("Cash Flow", format.Currency(pnl.CashFlow(), format.BaseCurrency()))
+		returns.AddRow("", "")
+		returns.AddRow("Current Value", format.Currency(pnl.Value, format.BaseCurrency()))
+		returns.AddRow("Current Equity", format.Currency(pnl.Equity, format.BaseCurrency()))
+		returns.AddRow("Cap Rate", percentString(pnl.CapRate()))
+		returns.AddRow("ROI (cash-on-cash)", percentString(pnl.ROI()))
+
+		fmt.Println()
+		if err := returns.Render(); err != nil {
+			return fmt.Errorf("failed to render P&L table: %w", err)
+		}
+
+		if len(pnl.Income) == 0 && len(pnl.OperatingExpenses) == 0 && pnl.DebtService == 0 {
+			fmt.Printf("\nNo transactions are tagged to this property in %d. Tag them with 'money property tag'.\n", year)
+		}
+
+		return nil
+	},
+}
+
+// addPnLSection adds a heading and one row per category, largest first
+func addPnLSection(t *table.Table, heading string, amounts map[string]int64) {
+	if len(amounts) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(amounts))
+	for name := range amounts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := amounts[names[i]], amounts[names[j]]
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		if a != b {
+			return a > b
+		}
+		return names[i] < names[j]
+	})
+
+	t.AddRow(heading, "")
+	for _, name := range names {
+		t.AddRow("  "+name, format.Currency(amounts[name], format.BaseCurrency()))
+	}
+}
+
+// percentString formats an optional percentage
+func percentString(percent float64, ok bool) string {
+	if !ok {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+var PropertyRecords = &Z.Cmd{
+	Name:    "records",
+	Aliases: []string{"enrich"},
+	Summary: "Fetch public record details for properties from RentCast",
+	Usage:   "[<account-id>]",
+	Description: `
+Looks up each property's public record with RentCast's property records
+endpoint and stores its bedrooms, bathrooms, square footage, lot size and
+year built, shown by 'money property details'. The property type is filled
+in if it wasn't given when the property was added. Without an account ID,
+every property is looked up, one RentCast request each.
+
+Examples:
+  money property records
+  money property records property_TX_Austin_78701
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: %s", cmd.Usage)
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return err
+		}
+
+		propertyService := property.NewService(db)
+
+		var accountIDs []string
+		if len(args) == 1 {
+			accountIDs = args
+		} else {
+			properties, err := propertyService.ListAllProperties()
+			if err != nil {
+				return fmt.Errorf("failed to list properties: %w", err)
+			}
+			for _, prop := range properties {
+				accountIDs = append(accountIDs, prop.AccountID)
+			}
+		}
+
+		if len(accountIDs) == 0 {
+			fmt.Println("No property accounts found. Use 'money property add' to add a property.")
+			return nil
+		}
+
+		failed := 0
+		for _, accountID := range accountIDs {
+			prop, err := propertyService.UpdatePropertyRecord(accountID)
+			if err != nil {
+				fmt.Printf("%s%s: %v\n", icon("❌ "), accountID, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s%s: %s\n", icon("✅ "), accountID, propertyRecordSummary(*prop))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to fetch %d property record(s)", failed)
+		}
+		return nil
+	},
+}
+
+// propertyRecordSummary describes a property's public record details in one
+// line, e.g. "3 bd, 2.5 ba, 1,878 sq ft, built 1973"
+func propertyRecordSummary(prop database.Property) string {
+	var parts []string
+	if prop.Bedrooms != nil {
+		parts = append(parts, fmt.Sprintf("%d bd", *prop.Bedrooms))
+	}
+	if prop.Bathrooms != nil {
+		parts = append(parts, strconv.FormatFloat(*prop.Bathrooms, 'f', -1, 64)+" ba")
+	}
+	if prop.SquareFootage != nil {
+		parts = append(parts, format.WithCommas(int64(*prop.SquareFootage))+" sq ft")
+	}
+	if prop.LotSize != nil {
+		parts = append(parts, format.WithCommas(int64(*prop.LotSize))+" sq ft lot")
+	}
+	if prop.YearBuilt != nil {
+		parts = append(parts, fmt.Sprintf("built %d", *prop.YearBuilt))
+	}
+	if len(parts) == 0 {
+		return "no details on record"
+	}
+	return strings.Join(parts, ", ")
+}
+
 var PropertyDetails = &Z.Cmd{
 	Name:     "details",
 	Aliases:  []string{"detail", "info"},
@@ -347,11 +925,10 @@ var PropertyDetails = &Z.Cmd{
 
 		accountID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return err
 		}
-		defer db.Close()
 
 		propertyService := property.NewService(db)
 
@@ -382,15 +959,31 @@ var PropertyDetails = &Z.Cmd{
 		if propertyDetails.Latitude != nil && propertyDetails.Longitude != nil {
 			fmt.Printf("Location: %.6f, %.6f\n", *propertyDetails.Latitude, *propertyDetails.Longitude)
 		}
+		if propertyDetails.Bedrooms != nil {
+			fmt.Printf("Bedrooms: %d\n", *propertyDetails.Bedrooms)
+		}
+		if propertyDetails.Bathrooms != nil {
+			fmt.Printf("Bathrooms: %s\n", strconv.FormatFloat(*propertyDetails.Bathrooms, 'f', -1, 64))
+		}
+		if propertyDetails.SquareFootage != nil {
+			fmt.Printf("Square Footage: %s sq ft\n", format.WithCommas(int64(*propertyDetails.SquareFootage)))
+		}
+		if propertyDetails.LotSize != nil {
+			fmt.Printf("Lot Size: %s sq ft\n", format.WithCommas(int64(*propertyDetails.LotSize)))
+		}
+		if propertyDetails.YearBuilt != nil {
+			fmt.Printf("Year Built: %d\n", *propertyDetails.YearBuilt)
+		}
 
-		fmt.Printf("Current Value: %s\n", format.Currency(account.Balance, "USD"))
+		fmt.Printf("Current Value: %s\n", format.Currency(account.Balance, account.Currency))
+		fmt.Printf("Valuation Provider: %s\n", property.ProviderName(*propertyDetails))
 
 		if propertyDetails.LastValueEstimate != nil {
-			fmt.Printf("Last Value Estimate: %s\n", format.Currency(*propertyDetails.LastValueEstimate, "USD"))
+			fmt.Printf("Last Value Estimate: %s\n", format.Currency(*propertyDetails.LastValueEstimate, account.Currency))
 		}
 
 		if propertyDetails.LastRentEstimate != nil {
-			fmt.Printf("Last Rent Estimate: %s/month\n", format.Currency(*propertyDetails.LastRentEstimate, "USD"))
+			fmt.Printf("Last Rent Estimate: %s/month\n", format.Currency(*propertyDetails.LastRentEstimate, account.Currency))
 		}
 
 		if propertyDetails.LastUpdated != nil {
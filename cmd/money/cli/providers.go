@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/provider"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// The sources money fetch reads, in the order it reads them. A new source
+// registers an opener here.
+func init() {
+	provider.Register("simplefin", openSimpleFIN)
+	provider.Register("gocardless", openGoCardless)
+	provider.Register("teller", openTeller)
+}
+
+// openSimpleFIN opens the SimpleFIN bridge set up with 'money init', or the
+// recorded responses being replayed
+func openSimpleFIN(db *database.DB, opts provider.Options) ([]provider.Connection, error) {
+	var client *simplefin.Client
+	if opts.ReplayDir != "" {
+		fmt.Printf("Replaying recorded SimpleFIN responses from %s...\n", opts.ReplayDir)
+		replay, err := simplefin.NewReplayClient(opts.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+		client = replay
+	} else {
+		hasCredentials, err := db.HasCredentials()
+		if err != nil || !hasCredentials {
+			return nil, err
+		}
+		accessURL, username, password, err := db.GetCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credentials: %w", err)
+		}
+
+		client = simplefin.NewClient(accessURL, username, password)
+		if opts.RecordDir != "" {
+			fmt.Printf("Recording SimpleFIN responses to %s\n", opts.RecordDir)
+			client.RecordTo(opts.RecordDir)
+		}
+	}
+
+	cfg := db.GetConfig()
+	retry := simplefin.DefaultRetryPolicy
+	retry.MaxAttempts = cfg.SimpleFINMaxAttempts
+	retry.MaxRequests = cfg.SimpleFINRequestBudget
+	client.SetRetryPolicy(retry)
+
+	return []provider.Connection{{Key: "simplefin", Name: "SimpleFIN", Provider: provider.SimpleFIN(client)}}, nil
+}
+
+// sinceDay formats since as the YYYY-MM-DD GoCardless and Teller take, ""
+// when it's zero
+func sinceDay(since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+	return since.Format("2006-01-02")
+}
+
+// partialResponse returns a bank's response, warning about the accounts
+// that failed, or an error when the whole bank did
+func partialResponse(resp *simplefin.AccountsResponse) (*simplefin.AccountsResponse, error) {
+	sort.Strings(resp.Errors)
+	if len(resp.Accounts) == 0 && len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(resp.Errors, "; "))
+	}
+	for _, message := range resp.Errors {
+		slog.Warn("account failed", "err", message)
+	}
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+)
+
+// mutatingCommands are the commands that change the database, a config file
+// or the money directory, which read-only mode refuses to start. Everything
+// else runs against the read-only connection, where incidental writes like
+// the LLM audit log fail with a warning, and edits in the TUIs fail with an
+// error.
+var mutatingCommands = []*Z.Cmd{
+	Init, InitSimpleFIN, InitRentCast, InitGoCardless, InitTeller,
+	DemoInit,
+	ConfigSet, ConfigUnset, ConfigImportRules,
+	Fetch,
+	AccountsTypeSet, AccountsTypeClear,
+	AccountsNicknameSet, AccountsNicknameClear,
+	AccountsDefaultCategorySet, AccountsDefaultCategoryClear, AccountsDefaultCategoryApply,
+	AccountsCreditLimitSet, AccountsCreditLimitClear,
+	AccountsStatementDueSet, AccountsStatementDueClear,
+	AccountsOwnerSet, AccountsOwnerClear,
+	AccountsDelete,
+	CategoriesAdd, CategoriesRemove, CategoriesSeed,
+	CategoriesInternalSet, CategoriesInternalUnset, CategoriesSetInternal, CategoriesClearInternal,
+	CategoriesStyle, CategoriesSetTax, CategoriesClearTax,
+	PropertyAdd, PropertyUpdate, PropertyUpdateAll, PropertySetValue, PropertyProvider,
+	PropertyMortgageLink, PropertyMortgageUnlink, PropertyTag, PropertyUntag, PropertyRecords,
+	AssetsAdd, AssetsDepreciate, AssetsSetValue, AssetsUpdate,
+	HoldingsRefresh, HoldingsLotsAdd, HoldingsLotsSell, HoldingsLotsRemove,
+	CryptoAdd, CryptoRefresh,
+	BudgetSet, BudgetClear,
+	TransactionsEdit, CategorizeModify, CategorizeClear, CategorizeAuto, CategorizeManual,
+	RulesRenameAdd, RulesRenameRemove, RulesRenameApply,
+	AlertsLowBalanceSet, AlertsLowBalanceClear,
+	BillsAdd, BillsRemove,
+	Close, CloseReopen,
+	LLMLogClear,
+	DBMaintain, DBRollback,
+	SyncPull,
+	YNABMapAccount, YNABMapCategory, YNABUnmapAccount, YNABUnmapCategory, YNABPush, YNABPull,
+	ActualMap, ActualUnmap,
+}
+
+// checkReadOnly returns an error when args, the command line after the
+// global flags, would run a command that makes changes
+func checkReadOnly(root *Z.Cmd, args []string) error {
+	cmd := root
+	var path []string
+	for _, arg := range args {
+		sub := findSubcommand(cmd, arg)
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		path = append(path, sub.Name)
+	}
+
+	mutating := slices.Contains(mutatingCommands, cmd)
+	if cmd == DBQuery {
+		// Only --write changes anything; a query that doesn't parse is left
+		// for the command to report
+		var write bool
+		err := dbQueryFlags(&write, new(int)).Parse(args[len(path):])
+		mutating = err == nil && write
+		if mutating {
+			path = append(path, "--write")
+		}
+	}
+	if mutating {
+		return fmt.Errorf("'money %s' makes changes, which read-only mode doesn't allow (drop --read-only or unset MONEY_READONLY)", strings.Join(path, " "))
+	}
+	return nil
+}
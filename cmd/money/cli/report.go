@@ -0,0 +1,1580 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guptarohit/asciigraph"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/fees"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/holdings"
+	"github.com/arjungandhi/money/pkg/llm"
+	"github.com/arjungandhi/money/pkg/notify"
+	"github.com/arjungandhi/money/pkg/report"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Report = &Z.Cmd{
+	Name:    "report",
+	Aliases: []string{"reports"},
+	Summary: "Reports that look across months of spending",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		ReportAnomalies,
+		ReportClosed,
+		ReportDigest,
+		ReportFees,
+		ReportFIRE,
+		ReportGains,
+		ReportHeatmap,
+		ReportIncome,
+		ReportSimulate,
+		ReportTax,
+		ReportTrends,
+		ReportUtilization,
+	},
+}
+
+func reportClosedFlags(all *bool) *flags.Set {
+	set := flags.New("money report closed")
+	set.Positional("<YYYY-MM>")
+	set.BoolVar(all, "all", "a")
+	return set
+}
+
+var ReportClosed = &Z.Cmd{
+	Name:    "closed",
+	Summary: "Compare a closed month's snapshot with what it adds up to now",
+	Usage:   "closed " + reportClosedFlags(new(bool)).Usage(),
+	Description: `
+Recomputes the category totals and ending balances of a month closed with
+'money close' and shows them next to the snapshot taken when it closed,
+so late-posting transactions, forced category edits, and backfilled
+balances stand out. Only rows that changed are shown unless --all is given.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		all := false
+		set := reportClosedFlags(&all)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) != 1 {
+			return fmt.Errorf("usage: money report %s", cmd.Usage)
+		}
+		month := set.Args()[0]
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			comparison, err := report.CompareClosedMonth(db, month)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s, closed %s\n\n", month, comparison.ClosedAt)
+			if !comparison.Changed() && !all {
+				fmt.Println("Nothing has changed since the month was closed.")
+				return nil
+			}
+
+			categories := table.New("Category", "Closed Income", "Income", "Closed Expenses", "Expenses", "Change")
+			categories.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency)
+			categoryRows := 0
+			for _, c := range comparison.Categories {
+				if !c.Changed() && !all {
+					continue
+				}
+				categoryRows++
+				change := (c.Income - c.Expenses) - (c.ClosedIncome - c.ClosedExpenses)
+				categories.AddRow(
+					colorizeCategory(c.Category),
+					format.Currency(c.ClosedIncome, format.BaseCurrency()),
+					format.Currency(c.Income, format.BaseCurrency()),
+					format.Currency(c.ClosedExpenses, format.BaseCurrency()),
+					format.Currency(c.Expenses, format.BaseCurrency()),
+					colorizeAmount(change, format.Currency(change, format.BaseCurrency())),
+				)
+			}
+			if categoryRows > 0 {
+				if err := categories.Render(); err != nil {
+					return err
+				}
+			}
+
+			balances := table.New("Account", "Closed Balance", "Balance", "Change")
+			balances.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency)
+			balanceRows := 0
+			for _, b := range comparison.Balances {
+				if !b.Changed() && !all {
+					continue
+				}
+				balanceRows++
+				change := b.Current - b.Closed
+				balances.AddRow(
+					b.Account,
+					format.Currency(b.Closed, format.BaseCurrency()),
+					format.Currency(b.Current, format.BaseCurrency()),
+					colorizeAmount(change, format.Currency(change, format.BaseCurrency())),
+				)
+			}
+			if balanceRows > 0 {
+				if categoryRows > 0 {
+					fmt.Println()
+				}
+				return balances.Render()
+			}
+			return nil
+		})
+	},
+}
+
+func reportHeatmapFlags(category *string, weeks *int) *flags.Set {
+	set := flags.New("money report heatmap")
+	set.StringVar(category, "category", "c", "<name>")
+	set.IntVar(weeks, "weeks", "w", "N", 1)
+	return set
+}
+
+var ReportHeatmap = &Z.Cmd{
+	Name:    "heatmap",
+	Summary: "Calendar heatmap of daily spending",
+	Usage:   "heatmap " + reportHeatmapFlags(new(string), new(int)).Usage(),
+	Description: `
+Draws a calendar of the last --weeks weeks (default 13), a row per weekday
+and a column per week, shading each day by how much was spent. Shades
+split the days with spending into quarters, so one big purchase doesn't
+wash out the rest. Below it are the average spent on each weekday and the
+busiest days. Income, refunds, and internal categories are left out;
+--category limits it to one category.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		weeks := 13
+		category := ""
+		if err := reportHeatmapFlags(&category, &weeks).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if category != "" && category != "Uncategorized" {
+				c, err := db.GetCategoryByName(category)
+				if err != nil {
+					return err
+				}
+				category = c.Name
+			}
+
+			heatmap, err := report.BuildHeatmap(db, format.Now(), weeks, category)
+			if err != nil {
+				return err
+			}
+
+			title := icon("🔥 ") + "Daily Spending"
+			if heatmap.Category != "" {
+				title += " on " + heatmap.Category
+			}
+			fmt.Printf("%s, %s to %s\n\n", title,
+				heatmap.Start.Format("Jan 2, 2006"), heatmap.End.Format("Jan 2, 2006"))
+
+			if heatmap.Total() == 0 {
+				fmt.Println("No spending in this period.")
+				return nil
+			}
+
+			fmt.Println(renderHeatmap(heatmap))
+			fmt.Printf("\n%sTotal: %s\n\n", icon("💸 "), format.Currency(heatmap.Total(), format.BaseCurrency()))
+
+			averages := heatmap.WeekdayAverages()
+			var largest int64
+			for _, average := range averages {
+				largest = max(largest, average)
+			}
+			weekdays := table.New("Weekday", "Average", "")
+			weekdays.SetColumnTypes(table.ColumnText, table.ColumnCurrency)
+			for i, average := range averages {
+				bar := ""
+				if largest > 0 {
+					bar = strings.Repeat("█", int(average*20/largest))
+				}
+				weekdays.AddRow(heatmapWeekdays[i], format.Currency(average, format.BaseCurrency()), bar)
+			}
+			if err := weekdays.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			busiest := table.New("Busiest Day", "Spent")
+			busiest.SetColumnTypes(table.ColumnText, table.ColumnCurrency)
+			for _, day := range heatmap.BusiestDays(5) {
+				busiest.AddRow(day.Date.Format("Mon Jan 2"), format.Currency(day.Amount, format.BaseCurrency()))
+			}
+			return busiest.Render()
+		})
+	},
+}
+
+func reportTrendsFlags(categories *[]string, months *int) *flags.Set {
+	set := flags.New("money report trends")
+	set.Func("category", "c", "<name,...>", func(value string) error {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				*categories = append(*categories, name)
+			}
+		}
+		return nil
+	})
+	set.IntVar(months, "months", "m", "N", 2)
+	return set
+}
+
+var ReportTrends = &Z.Cmd{
+	Name:    "trends",
+	Aliases: []string{"trend"},
+	Summary: "Monthly totals for categories over the past year, with their trend",
+	Usage:   "trends " + reportTrendsFlags(new([]string), new(int)).Usage(),
+	Description: `
+Plots each category's monthly total over the last --months complete
+months (default 12), leaving out the month in progress. Spending is net
+of refunds; a category that earned more than it spent is totaled as
+income instead. Below the graph are each category's monthly average and
+the direction of the line fitted through its months: rising or falling
+when it moves at least 5% of the average over the period, flat otherwise.
+
+--category can be given more than once, and takes comma-separated
+categories too, e.g. -c Groceries,Dining.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		months := report.DefaultTrendMonths
+		var categories []string
+		if err := reportTrendsFlags(&categories, &months).Parse(args); err != nil {
+			return err
+		}
+		if len(categories) == 0 {
+			return fmt.Errorf("usage: money report %s", cmd.Usage)
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			for i, name := range categories {
+				if name == "Uncategorized" {
+					continue
+				}
+				category, err := db.GetCategoryByName(name)
+				if err != nil {
+					return err
+				}
+				categories[i] = category.Name
+			}
+
+			trends, err := report.CategoryTrends(db, categories, format.Now(), months)
+			if err != nil {
+				return err
+			}
+
+			empty := true
+			for _, trend := range trends {
+				for _, amount := range trend.Totals {
+					empty = empty && amount == 0
+				}
+			}
+			if empty {
+				fmt.Printf("No transactions in %s over the last %d months.\n", strings.Join(categories, ", "), months)
+				return nil
+			}
+
+			monthKeys := trends[0].Months
+			first, _ := time.Parse("2006-01", monthKeys[0])
+			last, _ := time.Parse("2006-01", monthKeys[len(monthKeys)-1])
+			fmt.Printf("%sMonthly Totals, %s to %s\n", icon("📈 "), first.Format("Jan 2006"), last.Format("Jan 2006"))
+			fmt.Println(plotCategoryTrends(trends))
+			fmt.Println()
+
+			summary := table.New("Category", "Totals", "Monthly Average", "Last Month", "Trend")
+			summary.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnCurrency)
+			for _, trend := range trends {
+				kind := "Spending"
+				if trend.Income {
+					kind = "Income"
+				}
+				summary.AddRow(
+					colorizeCategory(trend.Category),
+					kind,
+					format.Currency(trend.Average(), format.BaseCurrency()),
+					format.Currency(trend.Totals[len(trend.Totals)-1], format.BaseCurrency()),
+					trendDirection(trend),
+				)
+			}
+			if err := summary.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			headers := []string{"Month"}
+			types := []table.ColumnType{table.ColumnText}
+			for _, trend := range trends {
+				headers = append(headers, trend.Category)
+				types = append(types, table.ColumnCurrency)
+			}
+			monthly := table.New(headers...)
+			monthly.SetColumnTypes(types...)
+			for i, month := range monthKeys {
+				row := []string{month}
+				for _, trend := range trends {
+					row = append(row, format.Currency(trend.Totals[i], format.BaseCurrency()))
+				}
+				monthly.AddRow(row...)
+			}
+			return monthly.Render()
+		})
+	},
+}
+
+// categoryTrendColors color each category's line on a trends graph, in order
+var categoryTrendColors = []asciigraph.AnsiColor{
+	asciigraph.Green, asciigraph.Blue, asciigraph.Magenta, asciigraph.Gold, asciigraph.Cyan, asciigraph.Red,
+}
+
+// plotCategoryTrends draws each category's monthly totals in dollars on one
+// graph as wide as the terminal allows, with months along the x-axis and a
+// legend naming each line
+func plotCategoryTrends(trends []report.CategoryTrend) string {
+	series := make([][]float64, len(trends))
+	colors := make([]asciigraph.AnsiColor, len(trends))
+	for i, trend := range trends {
+		series[i] = make([]float64, len(trend.Totals))
+		for j, amount := range trend.Totals {
+			series[i][j] = float64(amount) / 100.0
+		}
+		colors[i] = categoryTrendColors[i%len(categoryTrendColors)]
+	}
+
+	minVal, maxVal := seriesRange(series[0])
+	for _, s := range series[1:] {
+		low, high := seriesRange(s)
+		minVal = min(minVal, low)
+		maxVal = max(maxVal, high)
+	}
+	padding := (maxVal - minVal) * 0.05
+	lowerBound := minVal - padding
+	upperBound := maxVal + padding
+
+	leftPad := graphOffset + axisLabelWidth(lowerBound, upperBound)
+	width := max(minGraphWidth, terminalWidth()-leftPad-1)
+
+	graph := asciigraph.PlotMany(series,
+		asciigraph.Height(graphHeight),
+		asciigraph.Width(width),
+		asciigraph.Offset(graphOffset),
+		asciigraph.LowerBound(lowerBound),
+		asciigraph.UpperBound(upperBound),
+		graphColors(colors...))
+
+	months := trends[0].Months
+	labels := make([]string, len(months))
+	for i, month := range months {
+		labels[i] = month
+		if parsed, err := time.Parse("2006-01", month); err == nil {
+			labels[i] = parsed.Format("Jan 06")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(graph)
+	b.WriteString("\n")
+	b.WriteString(labelAxis(labels, leftPad, width))
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat(" ", leftPad))
+	for i, trend := range trends {
+		if i > 0 {
+			b.WriteString("  ")
+		}
+		b.WriteString(legendKey(colors[i]) + " " + trend.Category)
+	}
+	return b.String()
+}
+
+// trendDirection describes which way a category's monthly totals are
+// heading and by how much over the period
+func trendDirection(trend report.CategoryTrend) string {
+	change := format.Currency(trend.Change(), format.BaseCurrency())
+	percent := fmt.Sprintf("%+.1f%%", trend.ChangePercent())
+	switch trend.Direction() {
+	case 1:
+		return fmt.Sprintf("↑ rising (%s, %s)", change, percent)
+	case -1:
+		return fmt.Sprintf("↓ falling (%s, %s)", change, percent)
+	default:
+		return fmt.Sprintf("→ flat (%s, %s)", change, percent)
+	}
+}
+
+// heatmapWeekdays label a heatmap's rows, Monday first
+var heatmapWeekdays = [7]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// heatmapShades draw a heatmap day at each level of spending, none first
+var heatmapShades = [report.HeatmapLevels + 1]string{"··", "░░", "▒▒", "▓▓", "██"}
+
+// renderHeatmap draws a heatmap as a calendar with a row per weekday and a
+// column per week, month names above the weeks they start in, and a legend
+func renderHeatmap(h *report.Heatmap) string {
+	const labelWidth = 4 // "Mon "
+	const cellWidth = 3  // two shade characters and a space
+
+	var b strings.Builder
+
+	// Name each month over the week its 1st falls in, and the first week's
+	// month unless the next name follows right after it
+	type monthLabel struct {
+		week int
+		name string
+	}
+	var labels []monthLabel
+	for day := 0; day < len(h.Days); day++ {
+		if date := h.Date(day); day == 0 || date.Day() == 1 {
+			labels = append(labels, monthLabel{week: day / 7, name: date.Format("Jan")})
+		}
+	}
+	if len(labels) > 1 && labels[1].week <= 1 {
+		labels = labels[1:]
+	}
+
+	months := []rune(strings.Repeat(" ", labelWidth+cellWidth*h.Weeks()))
+	lastEnd := 0
+	for _, label := range labels {
+		column := labelWidth + label.week*cellWidth
+		if column >= lastEnd && column+len(label.name) <= len(months) {
+			copy(months[column:], []rune(label.name))
+			lastEnd = column + len(label.name) + 1
+		}
+	}
+	b.WriteString(strings.TrimRight(string(months), " "))
+	b.WriteString("\n")
+
+	for weekday := 0; weekday < 7; weekday++ {
+		b.WriteString(heatmapWeekdays[weekday] + " ")
+		for week := 0; week < h.Weeks(); week++ {
+			day := week*7 + weekday
+			if !h.InRange(day) {
+				break
+			}
+			if week > 0 {
+				b.WriteString(" ")
+			}
+			level := h.Level(day)
+			if level == 0 {
+				b.WriteString(grayColor.Sprint(heatmapShades[0]))
+			} else {
+				b.WriteString(redColor.Sprint(heatmapShades[level]))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n" + strings.Repeat(" ", labelWidth) + "Less ")
+	for level, shade := range heatmapShades {
+		if level == 0 {
+			b.WriteString(grayColor.Sprint(shade))
+		} else {
+			b.WriteString(redColor.Sprint(shade))
+		}
+		b.WriteString(" ")
+	}
+	b.WriteString("More")
+	return b.String()
+}
+
+// reportDigestOptions holds the flags of money report digest
+type reportDigestOptions struct {
+	since       string
+	markdown    bool
+	sendToSinks bool
+}
+
+func reportDigestFlags(opts *reportDigestOptions) *flags.Set {
+	set := flags.New("money report digest")
+	set.StringVar(&opts.since, "since", "", "7d|2w|YYYY-MM-DD")
+	set.BoolVar(&opts.markdown, "markdown", "")
+	set.BoolVar(&opts.sendToSinks, "notify", "")
+	return set
+}
+
+var ReportDigest = &Z.Cmd{
+	Name:    "digest",
+	Summary: "Summarize recent spending, notable transactions, and balance changes",
+	Usage:   "digest " + reportDigestFlags(&reportDigestOptions{}).Usage(),
+	Description: `
+Prints a compact summary of the period since --since (the last 7 days by
+default): income and spending by category, the largest transactions,
+account balance changes, how many transactions are uncategorized, and
+how many months of expenses cash covers, flagged when it's below the
+emergency_fund_months setting.
+
+The output is plain text, or Markdown with --markdown, so it can be piped
+into email or other tools. --notify also sends the plain text digest to
+the configured notification sinks (see 'money notify').
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts reportDigestOptions
+		if err := reportDigestFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		now := format.Now()
+		start := now.AddDate(0, 0, -7)
+		if opts.since != "" {
+			parsed, err := parseSince(opts.since, now)
+			if err != nil {
+				return err
+			}
+			start = parsed
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			digest, err := report.BuildDigest(db, start, now)
+			if err != nil {
+				return err
+			}
+
+			if opts.markdown {
+				fmt.Print(digest.Markdown())
+			} else {
+				fmt.Print(digest.Text())
+			}
+
+			if opts.sendToSinks {
+				sendNotification(notify.Message{Title: digest.Title(), Body: digest.Text()})
+			}
+			return nil
+		})
+	},
+}
+
+func reportAnomaliesFlags(month *time.Time, opts *report.AnomalyOptions, explain *bool) *flags.Set {
+	set := flags.New("money report anomalies")
+	set.Func("month", "m", "YYYY-MM", func(value string) error {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			return fmt.Errorf("use YYYY-MM")
+		}
+		*month = parsed
+		return nil
+	})
+	set.IntVar(&opts.LookbackMonths, "months", "", "N", 1)
+	set.Func("factor", "", "X", func(value string) error {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil || factor <= 1 {
+			return fmt.Errorf("must be a number greater than 1")
+		}
+		opts.SpikeFactor = factor
+		return nil
+	})
+	set.BoolVar(explain, "explain", "")
+	return set
+}
+
+var ReportAnomalies = &Z.Cmd{
+	Name:    "anomalies",
+	Aliases: []string{"anomaly"},
+	Summary: "Flag unusual transactions and category spending spikes",
+	Usage:   "anomalies " + reportAnomaliesFlags(new(time.Time), &report.AnomalyOptions{}, new(bool)).Usage(),
+	Description: `
+Compares a month's spending (the current month by default) against the
+preceding months. A category spikes when its spending reaches --factor
+times (default 3) its median monthly spending over the last --months
+months (default 6). A transaction is unusual when it is at least --factor
+times the median expense in its category and far outside the category's
+usual spread. Internal categories are ignored.
+
+--explain asks the configured LLM to summarize why the month looks different.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		now := format.Now()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		var opts report.AnomalyOptions
+		explain := false
+
+		if err := reportAnomaliesFlags(&month, &opts, &explain).Parse(args); err != nil {
+			return err
+		}
+
+		lookback := opts.LookbackMonths
+		if lookback == 0 {
+			lookback = report.DefaultLookbackMonths
+		}
+		startDate := month.AddDate(0, -lookback, 0).Format("2006-01-02")
+		endDate := month.AddDate(0, 1, -1).Format("2006-01-02")
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			currencies, err := accountCurrencies(db)
+			if err != nil {
+				return err
+			}
+			byCategory, err := db.GetTransactionsByCategory(startDate, endDate, true)
+			if err != nil {
+				return fmt.Errorf("failed to get transactions: %w", err)
+			}
+
+			anomalies := report.DetectAnomalies(byCategory, month, opts)
+			monthLabel := month.Format("January 2006")
+
+			if len(anomalies.Spikes) == 0 && len(anomalies.Transactions) == 0 {
+				fmt.Printf("No anomalies found in %s\n", monthLabel)
+				return nil
+			}
+
+			if len(anomalies.Spikes) > 0 {
+				fmt.Printf("Category spikes in %s:\n", monthLabel)
+				t := table.New("Category", "Spent", "Monthly Median", "Ratio")
+				t.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnNumber)
+				for _, spike := range anomalies.Spikes {
+					t.AddRow(
+						colorizeCategory(spike.Category),
+						colorizeAmount(-spike.Spent, format.Currency(spike.Spent, format.BaseCurrency())),
+						format.Currency(spike.Median, format.BaseCurrency()),
+						fmt.Sprintf("%.1fx", spike.Ratio),
+					)
+				}
+				if err := t.Render(); err != nil {
+					return err
+				}
+			}
+
+			if len(anomalies.Transactions) > 0 {
+				if len(anomalies.Spikes) > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("Unusual transactions in %s:\n", monthLabel)
+				t := table.New("Date", "Description", "Category", "Amount", "Typical")
+				t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnCurrency)
+				for _, unusual := range anomalies.Transactions {
+					txn := unusual.Transaction
+					t.AddRow(
+						postedDay(txn.Posted),
+						txn.DisplayDescription(),
+						colorizeCategory(unusual.Category),
+						colorizeAmount(txn.Amount, format.Currency(txn.Amount, currencies[txn.AccountID])),
+						format.Currency(unusual.Median, format.BaseCurrency()),
+					)
+				}
+				if err := t.Render(); err != nil {
+					return err
+				}
+			}
+
+			if !explain {
+				return nil
+			}
+
+			fmt.Println("\nAsking the LLM to summarize...")
+			llmClient := llm.NewClient()
+			llmClient.SetAuditLog(db)
+			summary, err := llmClient.SummarizeSpendingAnomalies(context.Background(), monthLabel, spendingAnomalies(anomalies))
+			if err != nil {
+				return fmt.Errorf("failed to summarize anomalies: %w", err)
+			}
+			fmt.Printf("\n%s\n", summary)
+			return nil
+		})
+	},
+}
+
+func reportFeesFlags(years *int) *flags.Set {
+	set := flags.New("money report fees")
+	set.IntVar(years, "years", "y", "N", 1)
+	return set
+}
+
+var ReportFees = &Z.Cmd{
+	Name:    "fees",
+	Aliases: []string{"interest"},
+	Summary: "Total the interest and fees each account charged per year",
+	Usage:   "fees " + reportFeesFlags(new(int)).Usage(),
+	Description: `
+Totals the transactions in the Interest and Fees categories by account
+over the last --years calendar years (default 3, the current year
+included), with refunded fees netted out.
+
+'money transactions categorize auto' files interest charges and bank
+fees under those categories by their descriptions, after rename rules,
+before asking the LLM about the rest. Add a rename rule to catch a
+charge whose description doesn't say what it is.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		years := report.DefaultFeesYears
+		if err := reportFeesFlags(&years).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			feesReport, err := report.BuildFeesReport(db, format.Now(), years)
+			if err != nil {
+				return err
+			}
+
+			first, last := feesReport.Years[0], feesReport.Years[len(feesReport.Years)-1]
+			if len(feesReport.Rows) == 0 {
+				fmt.Printf("No interest or fees from %d to %d. 'money transactions categorize auto' files them as it finds them.\n", first, last)
+				return nil
+			}
+
+			fmt.Printf("%sInterest and Fees, %d to %d\n\n", icon("🏦 "), first, last)
+
+			headers := []string{"Account", "Type"}
+			types := []table.ColumnType{table.ColumnText, table.ColumnText}
+			for _, year := range feesReport.Years {
+				headers = append(headers, strconv.Itoa(year))
+				types = append(types, table.ColumnCurrency)
+			}
+			headers = append(headers, "Total")
+			types = append(types, table.ColumnCurrency)
+
+			t := table.New(headers...)
+			t.SetColumnTypes(types...)
+			for _, row := range feesReport.Rows {
+				cells := []string{row.Account, row.Category}
+				for _, amount := range row.Totals {
+					cells = append(cells, format.Currency(amount, format.BaseCurrency()))
+				}
+				cells = append(cells, format.Currency(row.Total(), format.BaseCurrency()))
+				t.AddRow(cells...)
+			}
+			t.AddTotals("Total")
+			if err := t.Render(); err != nil {
+				return err
+			}
+
+			var interest, charged int64
+			for _, amount := range feesReport.CategoryTotals(fees.InterestCategory) {
+				interest += amount
+			}
+			for _, amount := range feesReport.CategoryTotals(fees.FeesCategory) {
+				charged += amount
+			}
+			fmt.Printf("\nInterest paid: %s   Fees charged: %s\n",
+				redColor.Sprint(format.Currency(interest, format.BaseCurrency())), redColor.Sprint(format.Currency(charged, format.BaseCurrency())))
+			return nil
+		})
+	},
+}
+
+// percentFlag returns a flag setter that stores a percent above min in p
+func percentFlag(p *float64, min float64) func(string) error {
+	return func(value string) error {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent <= min || percent > 100 {
+			return fmt.Errorf("use a percent above %g, up to 100", min)
+		}
+		*p = percent
+		return nil
+	}
+}
+
+func reportFIREFlags(opts *report.FIREOptions) *flags.Set {
+	set := flags.New("money report fire")
+	set.Func("return", "r", "PCT", percentFlag(&opts.ReturnRate, -100))
+	set.Func("withdrawal-rate", "w", "PCT", percentFlag(&opts.WithdrawalRate, 0))
+	set.IntVar(&opts.HistoryMonths, "months", "m", "N", 1)
+	set.IntVar(&opts.Years, "years", "y", "N", 1)
+	return set
+}
+
+var ReportFIRE = &Z.Cmd{
+	Name:    "fire",
+	Aliases: []string{"retirement", "fi"},
+	Summary: "Project net worth at the current savings rate to financial independence",
+	Usage:   "fire " + reportFIREFlags(&report.FIREOptions{}).Usage(),
+	Description: `
+Projects net worth, the total of every account's balance, forward from
+today and estimates when it will be large enough to live off: when the
+--withdrawal-rate (default 4%) of it covers a year of spending.
+
+Income and spending are averaged over the last --months complete months
+(default 12), leaving out internal categories like budget does, and the
+difference is saved every month. Net worth and savings grow at --return
+percent a year (default 5%, after inflation, so the projection is in
+today's dollars), compounded monthly. The chart runs until independence,
+or for --years years.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		opts := report.FIREOptions{
+			ReturnRate:     report.DefaultFIREReturn,
+			WithdrawalRate: report.DefaultWithdrawalRate,
+			HistoryMonths:  report.DefaultFIREHistoryMonths,
+		}
+		if err := reportFIREFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			projection, err := report.ProjectFIRE(db, format.Now(), opts)
+			if err != nil {
+				return err
+			}
+
+			config := table.DefaultConfig()
+			config.Title = icon("🔥 ") + "Financial Independence Projection"
+			config.ShowHeaders = false
+			config.MaxColumnWidth = 50
+			summary := table.NewWithConfig(config, "", "")
+			summary.AddRow("Net worth today", format.Currency(projection.NetWorth, format.BaseCurrency()))
+			summary.AddRow(fmt.Sprintf("Monthly income (%d-month average)", opts.HistoryMonths), format.Currency(projection.MonthlyIncome, format.BaseCurrency()))
+			summary.AddRow("Monthly spending", format.Currency(projection.MonthlyExpenses, format.BaseCurrency()))
+			summary.AddRow("Monthly savings",
+				colorizeAmount(projection.MonthlySavings(), format.Currency(projection.MonthlySavings(), format.BaseCurrency()))+
+					fmt.Sprintf(" (%.1f%% of income)", projection.SavingsRate()))
+			summary.AddRow("Return", fmt.Sprintf("%.1f%% a year after inflation", opts.ReturnRate))
+			summary.AddRow("Withdrawal rate", fmt.Sprintf("%.1f%% a year", opts.WithdrawalRate))
+			summary.AddRow("Target", fmt.Sprintf("%s (%.1fx yearly spending)", format.Currency(projection.Target, format.BaseCurrency()), 100/opts.WithdrawalRate))
+
+			independence := "Not within 100 years at this savings rate"
+			if date, ok := projection.IndependenceDate(); ok {
+				independence = fmt.Sprintf("%s, in %s", date.Format("January 2006"), yearsAndMonths(projection.Months))
+				if projection.Months == 0 {
+					independence = "Now: net worth already covers your spending"
+				}
+			}
+			summary.AddRow("Independence", independence)
+			if err := summary.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			fmt.Println(plotFIREProjection(projection))
+			return nil
+		})
+	},
+}
+
+// yearsAndMonths describes a number of months as years and months
+func yearsAndMonths(months int) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch years, rest := months/12, months%12; {
+	case years == 0:
+		return plural(rest, "month")
+	case rest == 0:
+		return plural(years, "year")
+	default:
+		return plural(years, "year") + ", " + plural(rest, "month")
+	}
+}
+
+// plotFIREProjection draws projected net worth in dollars at the end of
+// each year, with the target as a second line, labeled by year
+func plotFIREProjection(projection *report.FIREProjection) string {
+	netWorth := make([]float64, len(projection.Yearly))
+	target := make([]float64, len(projection.Yearly))
+	labels := make([]string, len(projection.Yearly))
+	for i, cents := range projection.Yearly {
+		netWorth[i] = float64(cents) / 100.0
+		target[i] = float64(projection.Target) / 100.0
+		labels[i] = strconv.Itoa(projection.Start.Year() + i)
+	}
+
+	minVal, maxVal := seriesRange(netWorth)
+	minVal = min(minVal, target[0])
+	maxVal = max(maxVal, target[0])
+	padding := (maxVal - minVal) * 0.05
+	lowerBound := minVal - padding
+	upperBound := maxVal + padding
+
+	leftPad := graphOffset + axisLabelWidth(lowerBound, upperBound)
+	width := max(minGraphWidth, terminalWidth()-leftPad-1)
+
+	graph := asciigraph.PlotMany([][]float64{netWorth, target},
+		asciigraph.Height(graphHeight),
+		asciigraph.Width(width),
+		asciigraph.Offset(graphOffset),
+		asciigraph.LowerBound(lowerBound),
+		asciigraph.UpperBound(upperBound),
+		graphColors(asciigraph.Green, asciigraph.Gold))
+
+	var b strings.Builder
+	b.WriteString(graph)
+	b.WriteString("\n")
+	b.WriteString(labelAxis(labels, leftPad, width))
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat(" ", leftPad))
+	b.WriteString(legendKey(asciigraph.Green) + " Projected net worth  " + legendKey(asciigraph.Gold) + " Target")
+	return b.String()
+}
+
+func reportGainsFlags(year *int) *flags.Set {
+	set := flags.New("money report gains")
+	set.IntVar(year, "year", "y", "YYYY", 1900)
+	return set
+}
+
+var ReportGains = &Z.Cmd{
+	Name:    "gains",
+	Aliases: []string{"gain"},
+	Summary: "Show realized and unrealized capital gains",
+	Usage:   "gains " + reportGainsFlags(new(int)).Usage(),
+	Description: `
+Lists the gains realized on lots sold in --year (the current year by
+default) and the unrealized gains on everything still held, split into
+short and long term (held more than a year).
+
+Held shares are valued at their holding's latest price. Positions with
+lots recorded by 'money holdings lots add' are broken down lot by lot;
+other holdings use the cost basis and purchase date SimpleFIN reports,
+when the institution provides them.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		now := time.Now()
+		year := now.Year()
+		if err := reportGainsFlags(&year).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			gains, err := holdings.NewService(db).GetGains(year, now)
+			if err != nil {
+				return err
+			}
+
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+			names := make(map[string]string, len(accounts))
+			currencies := make(map[string]string, len(accounts))
+			for _, account := range accounts {
+				names[account.ID] = account.DisplayName()
+				currencies[account.ID] = account.Currency
+			}
+
+			fmt.Printf("Realized gains in %d\n\n", year)
+			if len(gains.Realized) == 0 {
+				fmt.Println("No lots sold. Record sales with 'money holdings lots sell'.")
+			} else if err := printGains(gains, gains.Realized, names, currencies); err != nil {
+				return err
+			}
+
+			fmt.Printf("\nUnrealized gains as of %s\n\n", now.Format("2006-01-02"))
+			if len(gains.Unrealized) == 0 {
+				fmt.Println("No holdings. They're saved by 'money fetch' or added with 'money holdings lots add'.")
+				return nil
+			}
+			return printGains(gains, gains.Unrealized, names, currencies)
+		})
+	},
+}
+
+func reportIncomeFlags(months *int) *flags.Set {
+	set := flags.New("money report income")
+	set.IntVar(months, "months", "m", "N", 1)
+	return set
+}
+
+var ReportIncome = &Z.Cmd{
+	Name:    "income",
+	Summary: "Monthly income by source, with paychecks separated from refunds and transfers",
+	Usage:   "income " + reportIncomeFlags(new(int)).Usage(),
+	Description: `
+Totals the money coming in over the last --months months (default 6, the
+current month included) by where it came from, so salary isn't mixed up
+with refunds and transfers the way budget's income totals are.
+
+Paychecks are deposits from a payer that arrive on a schedule: at least 3
+within 25% of their usual amount, at least $200, typically 6 to 35 days
+apart. A payer's other deposits, like bonuses, count as paychecks too.
+Deposits in internal categories are transfers, deposits in categories
+that spent more than they earned are refunds, and the rest are other
+income, by category. Only paychecks and other income add up to income.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		months := report.DefaultIncomeMonths
+		if err := reportIncomeFlags(&months).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := loadCategoryStyles(db); err != nil {
+				return err
+			}
+			now := format.Now()
+			incomeReport, err := report.BuildIncomeReport(db, now, months)
+			if err != nil {
+				return err
+			}
+
+			if len(incomeReport.Sources) == 0 {
+				fmt.Printf("No deposits over the last %d months.\n", months)
+				return nil
+			}
+
+			first, _ := time.Parse("2006-01", incomeReport.Months[0])
+			last, _ := time.Parse("2006-01", incomeReport.Months[len(incomeReport.Months)-1])
+			fmt.Printf("%sIncome by Source, %s to %s\n\n", icon("💵 "), first.Format("Jan 2006"), last.Format("Jan 2006"))
+
+			paychecks := incomeReport.KindTotals(report.IncomePaycheck)
+			other := incomeReport.KindTotals(report.IncomeOther)
+			refunds := incomeReport.KindTotals(report.IncomeRefund)
+			transfers := incomeReport.KindTotals(report.IncomeTransfer)
+
+			monthly := table.New("Month", "Paychecks", "Other Income", "Total Income", "Refunds", "Transfers")
+			monthly.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency)
+			for i, month := range incomeReport.Months {
+				if month == now.Format("2006-01") {
+					month += " (so far)"
+				}
+				monthly.AddRow(month,
+					format.Currency(paychecks[i], format.BaseCurrency()),
+					format.Currency(other[i], format.BaseCurrency()),
+					format.Currency(paychecks[i]+other[i], format.BaseCurrency()),
+					format.Currency(refunds[i], format.BaseCurrency()),
+					format.Currency(transfers[i], format.BaseCurrency()))
+			}
+			monthly.AddTotals("Total")
+			if err := monthly.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			sources := table.New("Source", "Type", "Deposits", "Monthly Average", "Total")
+			sources.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnNumber, table.ColumnCurrency, table.ColumnCurrency)
+			for _, source := range incomeReport.Sources {
+				name := source.Name
+				if source.Kind != report.IncomePaycheck {
+					name = colorizeCategory(name)
+				}
+				total := source.Total()
+				sources.AddRow(name, source.Kind.String(), strconv.Itoa(source.Count),
+					format.Currency(total/int64(len(incomeReport.Months)), format.BaseCurrency()),
+					format.Currency(total, format.BaseCurrency()))
+			}
+			return sources.Render()
+		})
+	},
+}
+
+func reportSimulateFlags(opts *report.SimulationOptions) *flags.Set {
+	set := flags.New("money report simulate")
+	set.Func("return", "r", "PCT", percentFlag(&opts.ReturnRate, -100))
+	set.Func("volatility", "v", "PCT", func(value string) error {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("use a percent from 0 to 100")
+		}
+		opts.Volatility = percent
+		return nil
+	})
+	set.Func("contribution", "c", "AMOUNT", func(value string) error {
+		cents, err := format.ParseCents(value)
+		if err != nil {
+			return err
+		}
+		opts.Contribution = cents
+		return nil
+	})
+	set.Func("contribution-growth", "g", "PCT", percentFlag(&opts.ContributionGrowth, -100))
+	set.IntVar(&opts.Runs, "runs", "n", "N", 1)
+	set.IntVar(&opts.Years, "years", "y", "N", 1)
+	set.Func("seed", "", "N", func(value string) error {
+		seed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("use a whole number")
+		}
+		opts.Seed = seed
+		return nil
+	})
+	return set
+}
+
+var ReportSimulate = &Z.Cmd{
+	Name:    "simulate",
+	Aliases: []string{"montecarlo", "sim"},
+	Summary: "Simulate the range of net worth outcomes with random market returns",
+	Usage:   "simulate " + reportSimulateFlags(&report.SimulationOptions{}).Usage(),
+	Description: `
+Runs --runs (default 1000) Monte Carlo simulations of net worth, the total
+of every account's balance, over the next --years years (default 30), and
+shows the range of outcomes at 10, 20 and 30 years: the 10th, 25th, 50th,
+75th and 90th percentiles.
+
+Each month, net worth earns a random return with a mean of --return
+percent a year (default 5%, after inflation, so outcomes are in today's
+dollars) and a standard deviation of --volatility percent a year (default
+15%, roughly a stock-heavy portfolio), then the --contribution is added.
+The contribution defaults to the average monthly savings over the last 12
+complete months, like fire's, and grows by --contribution-growth percent
+each year. A negative contribution is a monthly withdrawal.
+
+Pass --seed to draw the same simulations every time.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		opts := report.SimulationOptions{
+			ReturnRate: report.DefaultFIREReturn,
+			Volatility: report.DefaultVolatility,
+			Runs:       report.DefaultSimulationRuns,
+			Years:      report.DefaultSimulationYears,
+		}
+		set := reportSimulateFlags(&opts)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if !set.Changed("seed") {
+			opts.Seed = uint64(time.Now().UnixNano())
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			now := format.Now()
+			contributionSource := "from --contribution"
+			if !set.Changed("contribution") {
+				income, expenses, err := report.MonthlyCashFlow(db, now, report.DefaultFIREHistoryMonths)
+				if err != nil {
+					return err
+				}
+				opts.Contribution = income - expenses
+				contributionSource = fmt.Sprintf("%d-month average savings", report.DefaultFIREHistoryMonths)
+			}
+
+			netWorth, err := report.NetWorth(db)
+			if err != nil {
+				return err
+			}
+			simulation := report.Simulate(netWorth, now, opts)
+
+			config := table.DefaultConfig()
+			config.Title = icon("🎲 ") + "Net Worth Simulation"
+			config.ShowHeaders = false
+			config.MaxColumnWidth = 50
+			summary := table.NewWithConfig(config, "", "")
+			summary.AddRow("Net worth today", format.Currency(netWorth, format.BaseCurrency()))
+			contribution := colorizeAmount(opts.Contribution, format.Currency(opts.Contribution, format.BaseCurrency())) + " a month (" + contributionSource + ")"
+			summary.AddRow("Contribution", contribution)
+			if opts.ContributionGrowth != 0 {
+				summary.AddRow("Contribution growth", fmt.Sprintf("%.1f%% a year", opts.ContributionGrowth))
+			}
+			summary.AddRow("Return", fmt.Sprintf("%.1f%% a year after inflation, ±%.1f%%", opts.ReturnRate, opts.Volatility))
+			summary.AddRow("Simulations", strconv.Itoa(opts.Runs))
+			if err := summary.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			headers := []string{"After", "Year"}
+			types := []table.ColumnType{table.ColumnText, table.ColumnText}
+			for _, p := range report.SimulationPercentiles {
+				headers = append(headers, percentileName(p))
+				types = append(types, table.ColumnCurrency)
+			}
+			outcomes := table.New(headers...)
+			outcomes.SetColumnTypes(types...)
+			for _, years := range simulationHorizons(opts.Years) {
+				row := []string{yearsAndMonths(years * 12), strconv.Itoa(simulation.Start.Year() + years)}
+				for _, cents := range simulation.At(years) {
+					row = append(row, format.Currency(cents, format.BaseCurrency()))
+				}
+				outcomes.AddRow(row...)
+			}
+			if err := outcomes.Render(); err != nil {
+				return err
+			}
+
+			fmt.Println()
+			fmt.Println(plotSimulation(simulation))
+			return nil
+		})
+	},
+}
+
+// simulationHorizons returns the years a simulation's outcomes are shown
+// at: every decade up to 30 years, and the last year simulated
+func simulationHorizons(years int) []int {
+	var horizons []int
+	for horizon := 10; horizon <= min(years, 30); horizon += 10 {
+		horizons = append(horizons, horizon)
+	}
+	if len(horizons) == 0 || horizons[len(horizons)-1] != years {
+		horizons = append(horizons, years)
+	}
+	return horizons
+}
+
+// percentileName labels a percentile column, like "10th" or "Median"
+func percentileName(p float64) string {
+	if p == 50 {
+		return "Median"
+	}
+	return fmt.Sprintf("%gth", p)
+}
+
+// plotSimulation draws the 10th percentile, median and 90th percentile
+// net worth in dollars at the end of each year, labeled by year
+func plotSimulation(simulation *report.Simulation) string {
+	low, median, high := 0, len(report.SimulationPercentiles)/2, len(report.SimulationPercentiles)-1
+	series := make([][]float64, 3)
+	labels := make([]string, len(simulation.Yearly))
+	for year, values := range simulation.Yearly {
+		for i, p := range []int{low, median, high} {
+			series[i] = append(series[i], float64(values[p])/100.0)
+		}
+		labels[year] = strconv.Itoa(simulation.Start.Year() + year)
+	}
+
+	minVal, _ := seriesRange(series[0])
+	_, maxVal := seriesRange(series[2])
+	padding := (maxVal - minVal) * 0.05
+	lowerBound := minVal - padding
+	upperBound := maxVal + padding
+
+	leftPad := graphOffset + axisLabelWidth(lowerBound, upperBound)
+	width := max(minGraphWidth, terminalWidth()-leftPad-1)
+
+	graph := asciigraph.PlotMany(series,
+		asciigraph.Height(graphHeight),
+		asciigraph.Width(width),
+		asciigraph.Offset(graphOffset),
+		asciigraph.LowerBound(lowerBound),
+		asciigraph.UpperBound(upperBound),
+		graphColors(asciigraph.Red, asciigraph.Blue, asciigraph.Green))
+
+	var b strings.Builder
+	b.WriteString(graph)
+	b.WriteString("\n")
+	b.WriteString(labelAxis(labels, leftPad, width))
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat(" ", leftPad))
+	b.WriteString(fmt.Sprintf("%s %s  %s Median  %s %s",
+		legendKey(asciigraph.Red), percentileName(report.SimulationPercentiles[low]),
+		legendKey(asciigraph.Blue),
+		legendKey(asciigraph.Green), percentileName(report.SimulationPercentiles[high])))
+	return b.String()
+}
+
+func reportTaxFlags(year *int, csvPath *string) *flags.Set {
+	set := flags.New("money report tax")
+	set.IntVar(year, "year", "y", "YYYY", 1900)
+	set.StringVar(csvPath, "csv", "", "<path>")
+	return set
+}
+
+var ReportTax = &Z.Cmd{
+	Name:    "tax",
+	Aliases: []string{"taxes"},
+	Summary: "Total deductible and business categories by tax form line",
+	Usage:   "tax " + reportTaxFlags(new(int), new(string)).Usage(),
+	Description: `
+Totals a year's transactions (last year by default) in the categories
+mapped to Schedule A or Schedule C lines with 'money categories set-tax'.
+Expense lines add up what was spent, less refunds; income lines add up
+deposits. Pending transactions are left out.
+
+--csv writes every counted transaction to <path> (or stdout for -) with its
+form, line, date, description, category, account, and amount, for an
+accountant or tax software.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		year := format.Now().Year() - 1
+		csvPath := ""
+		if err := reportTaxFlags(&year, &csvPath).Parse(args); err != nil {
+			return err
+		}
+
+		defer startPager()()
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			tax, err := report.BuildTaxReport(db, year)
+			if err != nil {
+				return err
+			}
+
+			if csvPath == "-" {
+				return tax.WriteCSV(os.Stdout)
+			}
+
+			if len(tax.Lines) == 0 {
+				fmt.Printf("No transactions in %d in categories mapped to tax lines. Map them with 'money categories set-tax <category> <tax-line>'.\n", year)
+				return nil
+			}
+
+			fmt.Printf("Tax lines for %d\n\n", year)
+			t := table.New("Form", "Line", "Tax Line", "Category", "Amount")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency)
+			for _, line := range tax.Lines {
+				for _, category := range line.Categories {
+					t.AddRow(line.Line.Form, line.Line.Line, line.Line.Name, category.Category, format.Currency(category.Amount, format.BaseCurrency()))
+				}
+				if len(line.Categories) > 1 {
+					t.AddRow("", "", "", "Total", format.Currency(line.Total, format.BaseCurrency()))
+				}
+			}
+			if err := t.Render(); err != nil {
+				return err
+			}
+
+			if csvPath != "" {
+				file, err := os.Create(csvPath)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", csvPath, err)
+				}
+				if err := tax.WriteCSV(file); err != nil {
+					file.Close()
+					return err
+				}
+				if err := file.Close(); err != nil {
+					return fmt.Errorf("failed to write %s: %w", csvPath, err)
+				}
+				fmt.Printf("\nWrote %d transaction(s) to %s\n", len(tax.Transactions), csvPath)
+			}
+			return nil
+		})
+	},
+}
+
+func printGains(report *holdings.GainsReport, gains []holdings.Gain, names, currencies map[string]string) error {
+	t := table.New("Account", "Symbol", "Shares", "Acquired", "Sold", "Cost Basis", "Value", "Gain", "Term")
+	t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnNumber, table.ColumnText, table.ColumnText,
+		table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency)
+	t.SetRowStyle(negativeAmounts(7))
+	unknown := 0
+	for _, gain := range gains {
+		acquired, sold := "-", "-"
+		if gain.Acquired != "" {
+			acquired = gain.Acquired
+		}
+		if gain.Sold != "" {
+			sold = gain.Sold
+		}
+		costBasis := "-"
+		if gain.CostBasis != nil {
+			costBasis = format.Currency(*gain.CostBasis, currencies[gain.AccountID])
+		}
+		value := "-"
+		if gain.Priced {
+			value = format.Currency(gain.Value, currencies[gain.AccountID])
+		}
+		amount := "-"
+		if a, ok := gain.Amount(); ok {
+			amount = format.Currency(a, currencies[gain.AccountID])
+		} else {
+			unknown++
+		}
+		term := "short"
+		if gain.LongTerm(report.AsOf) {
+			term = "long"
+		}
+
+		t.AddRow(
+			names[gain.AccountID],
+			gain.Symbol,
+			strconv.FormatFloat(gain.Shares, 'f', -1, 64),
+			acquired,
+			sold,
+			costBasis,
+			value,
+			amount,
+			term,
+		)
+	}
+	if err := t.Render(); err != nil {
+		return err
+	}
+
+	shortTerm, longTerm := report.Totals(gains)
+	fmt.Printf("\nShort term: %s\n", format.Currency(shortTerm, format.BaseCurrency()))
+	fmt.Printf("Long term:  %s\n", format.Currency(longTerm, format.BaseCurrency()))
+	fmt.Printf("Total:      %s\n", format.Currency(shortTerm+longTerm, format.BaseCurrency()))
+	if unknown > 0 {
+		fmt.Printf("%d position(s) left out: no cost basis or price\n", unknown)
+	}
+	return nil
+}
+
+// spendingAnomalies converts detected anomalies for the LLM
+func spendingAnomalies(anomalies report.Anomalies) []llm.SpendingAnomaly {
+	var result []llm.SpendingAnomaly
+	for _, spike := range anomalies.Spikes {
+		result = append(result, llm.SpendingAnomaly{
+			Kind:     "category_spike",
+			Category: spike.Category,
+			Amount:   spike.Spent,
+			Typical:  spike.Median,
+		})
+	}
+	for _, unusual := range anomalies.Transactions {
+		result = append(result, llm.SpendingAnomaly{
+			Kind:        "unusual_transaction",
+			Category:    unusual.Category,
+			Description: unusual.Transaction.DisplayDescription(),
+			Date:        postedDay(unusual.Transaction.Posted),
+			Amount:      -unusual.Transaction.Amount,
+			Typical:     unusual.Median,
+		})
+	}
+	return result
+}
+
+// parseSince parses a relative period such as 7d, 2w, or 36h, or a
+// YYYY-MM-DD date, into the start time it describes
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	if len(value) > 1 {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n > 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 7d, 2w, 36h, or YYYY-MM-DD)", value)
+}
+
+// postedDay formats a stored RFC3339 posted time as a date in the configured
+// time zone
+func postedDay(posted string) string {
+	return format.PostedDate(posted)
+}
+
+func reportUtilizationFlags(threshold *int) *flags.Set {
+	set := flags.New("money report utilization")
+	set.IntVar(threshold, "warn", "w", "PCT", 1)
+	return set
+}
+
+var ReportUtilization = &Z.Cmd{
+	Name:    "utilization",
+	Aliases: []string{"credit", "util"},
+	Summary: "Show how much of each card's credit limit is in use",
+	Usage:   "utilization " + reportUtilizationFlags(new(int)).Usage(),
+	Description: `
+Shows the balance owed on each credit account against its credit limit,
+set with 'money accounts credit-limit set', and overall utilization across
+every card with a limit. Cards above --warn percent (the
+utilization_warn_percent setting, 30% by default) are flagged; lenders
+tend to see utilization above 30% as a risk.
+
+Accounts of any other type show up too once they have a credit limit, so
+a line of credit can be tracked alongside the cards.
+`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		threshold := 0
+		if err := reportUtilizationFlags(&threshold).Parse(args); err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if threshold == 0 {
+				threshold = db.GetConfig().UtilizationWarnPercent
+			}
+
+			utilization, err := report.BuildUtilization(db, threshold)
+			if err != nil {
+				return err
+			}
+
+			if len(utilization.Cards) == 0 {
+				fmt.Println("No credit accounts. Mark one with 'money accounts type set <account-id> credit'.")
+				return nil
+			}
+
+			config := table.DefaultConfig()
+			config.Title = icon("💳 ") + "Credit Utilization"
+			config.MaxColumnWidth = 30
+			t := table.NewWithConfig(config, "Card", "Owed", "Limit", "Available", "Utilization")
+			t.SetColumnTypes(table.ColumnText, table.ColumnCurrency, table.ColumnCurrency, table.ColumnCurrency, table.ColumnNumber)
+
+			flagged := 0
+			for _, card := range utilization.Cards {
+				currency := card.Account.Currency
+				if !card.HasLimit() {
+					t.AddRow(card.Account.DisplayName(), format.Currency(card.Owed, currency), "", "", grayColor.Sprint("no limit"))
+					continue
+				}
+
+				percent := fmt.Sprintf("%.1f%%", card.Percent())
+				if utilization.Flagged(card) {
+					flagged++
+					percent = redColor.Sprint(icon("⚠ ") + percent)
+				}
+				t.AddRow(card.Account.DisplayName(),
+					format.Currency(card.Owed, currency),
+					format.Currency(card.Limit, currency),
+					format.Currency(card.Available(), currency),
+					percent)
+			}
+
+			owed, limit := utilization.Totals()
+			if limit > 0 {
+				overall := fmt.Sprintf("%.1f%%", utilization.Percent())
+				if utilization.Percent() > float64(threshold) {
+					overall = redColor.Sprint(overall)
+				}
+				t.SetFooter("Overall", format.Currency(owed, format.BaseCurrency()), format.Currency(limit, format.BaseCurrency()), format.Currency(limit-owed, format.BaseCurrency()), overall)
+			}
+			if err := t.Render(); err != nil {
+				return err
+			}
+
+			if flagged > 0 {
+				fmt.Printf("\n%d card(s) above %d%% of their limit.\n", flagged, threshold)
+			}
+			if limit == 0 {
+				fmt.Println("\nSet limits with 'money accounts credit-limit set <account-id> <amount>' to measure utilization.")
+			}
+			return nil
+		})
+	},
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/arjungandhi/money/pkg/report"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"7d", time.Date(2024, 7, 8, 12, 0, 0, 0, time.UTC)},
+		{"2w", time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{"36h", time.Date(2024, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{"2024-07-01", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if err != nil {
+			t.Errorf("parseSince(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "d", "-3d", "soon"} {
+		if _, err := parseSince(bad, now); err == nil {
+			t.Errorf("parseSince(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReportFlagsRejectMissingValues(t *testing.T) {
+	var digest reportDigestOptions
+	if err := reportDigestFlags(&digest).Parse([]string{"--since"}); err == nil {
+		t.Error("Expected an error for --since without a value")
+	}
+
+	var month time.Time
+	var opts report.AnomalyOptions
+	var explain bool
+	if err := reportAnomaliesFlags(&month, &opts, &explain).Parse([]string{"--month"}); err == nil {
+		t.Error("Expected an error for --month without a value")
+	}
+	if err := reportAnomaliesFlags(&month, &opts, &explain).Parse([]string{"--factor", "1"}); err == nil {
+		t.Error("Expected an error for a --factor of 1")
+	}
+	if err := reportAnomaliesFlags(&month, &opts, &explain).Parse([]string{"--month", "2024-03", "--explain"}); err != nil {
+		t.Fatalf("Failed to parse anomalies flags: %v", err)
+	}
+	if month.Format("2006-01") != "2024-03" || !explain {
+		t.Errorf("Expected March 2024 explained, got %s, %v", month.Format("2006-01"), explain)
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	// Two weeks starting Monday Jan 29, up to Friday Feb 9
+	start := time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC)
+	heatmap := &report.Heatmap{
+		Start: start,
+		End:   start.AddDate(0, 0, 11),
+		Days:  make([]int64, 14),
+	}
+	heatmap.Days[5] = 1000
+
+	lines := strings.Split(renderHeatmap(heatmap), "\n")
+
+	// February starts in the first week, so January isn't named
+	if lines[0] != "    Feb" {
+		t.Errorf("Expected the month row to name Feb, got %q", lines[0])
+	}
+	if lines[1] != "Mon ·· ··" {
+		t.Errorf("Expected Monday's row to have both weeks, got %q", lines[1])
+	}
+	if lines[6] != "Sat ██" {
+		t.Errorf("Expected Saturday's row to stop at the end of the range, got %q", lines[6])
+	}
+	if !strings.Contains(lines[9], "Less ·· ░░ ▒▒ ▓▓ ██ More") {
+		t.Errorf("Expected a legend, got %q", lines[9])
+	}
+}
+
+func TestYearsAndMonths(t *testing.T) {
+	tests := []struct {
+		months int
+		want   string
+	}{
+		{1, "1 month"},
+		{11, "11 months"},
+		{12, "1 year"},
+		{25, "2 years, 1 month"},
+		{253, "21 years, 1 month"},
+	}
+	for _, tt := range tests {
+		if got := yearsAndMonths(tt.months); got != tt.want {
+			t.Errorf("yearsAndMonths(%d) = %q; want %q", tt.months, got, tt.want)
+		}
+	}
+}
+
+func TestSimulationHorizons(t *testing.T) {
+	tests := []struct {
+		years int
+		want  []int
+	}{
+		{5, []int{5}},
+		{10, []int{10}},
+		{25, []int{10, 20, 25}},
+		{30, []int{10, 20, 30}},
+		{45, []int{10, 20, 30, 45}},
+	}
+	for _, tt := range tests {
+		if got := simulationHorizons(tt.years); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("simulationHorizons(%d) = %v; want %v", tt.years, got, tt.want)
+		}
+	}
+}
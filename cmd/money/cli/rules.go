@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+var Rules = &Z.Cmd{
+	Name:    "rules",
+	Aliases: []string{"rule"},
+	Summary: "Manage rules applied to transactions",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		RulesRename,
+	},
+}
+
+var RulesRename = &Z.Cmd{
+	Name:    "rename",
+	Summary: "Rewrite messy bank descriptions into clean display names",
+	Description: `
+Rename rules match a regular expression against a transaction's raw bank
+description and store a clean display description alongside it. The raw
+description is kept for matching and categorization. Rules are applied to
+new transactions during fetch; run 'money rules rename apply' to rewrite
+existing history after changing rules.
+
+The replacement may reference capture groups, e.g. "SQ \*(.*)" -> "$1".
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		RulesRenameAdd,
+		RulesRenameList,
+		RulesRenameRemove,
+		RulesRenameApply,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return RulesRenameList.Call(cmd, args...)
+	},
+}
+
+var RulesRenameAdd = &Z.Cmd{
+	Name:     "add",
+	Summary:  "Add or replace a rename rule",
+	Usage:    "add <pattern> <replacement>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money rules rename add <pattern> <replacement>")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			id, err := db.AddRenameRule(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Saved rename rule %d: %q → %q\n", id, args[0], args[1])
+			fmt.Println("Run 'money rules rename apply' to rewrite existing transactions.")
+			return nil
+		})
+	},
+}
+
+var RulesRenameList = &Z.Cmd{
+	Name:     "list",
+	Aliases:  []string{"ls"},
+	Summary:  "List rename rules in the order they are applied",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			rules, err := db.GetRenameRules()
+			if err != nil {
+				return err
+			}
+
+			if len(rules) == 0 {
+				fmt.Println("No rename rules. Add one with 'money rules rename add <pattern> <replacement>'.")
+				return nil
+			}
+
+			t := table.New("ID", "Pattern", "Replacement")
+			for _, rule := range rules {
+				t.AddRow(strconv.Itoa(rule.ID), rule.Pattern, rule.Replacement)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var RulesRenameRemove = &Z.Cmd{
+	Name:     "remove",
+	Aliases:  []string{"rm"},
+	Summary:  "Remove a rename rule",
+	Usage:    "remove <rule-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money rules rename remove <rule-id>")
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid rule ID: %s", args[0])
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.DeleteRenameRule(id); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed rename rule %d\n", id)
+			fmt.Println("Run 'money rules rename apply' to restore descriptions it rewrote.")
+			return nil
+		})
+	},
+}
+
+var RulesRenameApply = &Z.Cmd{
+	Name:     "apply",
+	Summary:  "Apply rename rules to all existing transactions",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			changed, err := db.ApplyRenameRules(nil)
+			if err != nil {
+				return fmt.Errorf("failed to apply rename rules: %w", err)
+			}
+
+			fmt.Printf("Updated the display description of %d transactions\n", changed)
+			return nil
+		})
+	},
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommandsUseSharedDatabase keeps commands on the dbutil handle, which
+// is scoped to --owner and shared between commands, instead of opening the
+// configured database themselves
+func TestCommandsUseSharedDatabase(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Failed to list source files: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+		ast.Inspect(parsed, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "database" && sel.Sel.Name == "New" {
+				t.Errorf("%s: database.New() opens its own handle; use dbutil.Open or dbutil.WithDatabase", fset.Position(sel.Pos()))
+			}
+			return true
+		})
+	}
+}
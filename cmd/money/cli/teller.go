@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/provider"
+	"github.com/arjungandhi/money/pkg/simplefin"
+	"github.com/arjungandhi/money/pkg/table"
+	"github.com/arjungandhi/money/pkg/teller"
+)
+
+// tellerEnrollTimeout is how long 'money init teller' waits for the bank to
+// be enrolled in the browser
+const tellerEnrollTimeout = 15 * time.Minute
+
+type initTellerOptions struct {
+	token       string
+	environment string
+	list        bool
+	remove      string
+}
+
+func initTellerFlags(opts *initTellerOptions) *flags.Set {
+	set := flags.New("money init teller")
+	set.StringVar(&opts.token, "token", "t", "TOKEN")
+	set.StringVar(&opts.environment, "environment", "e", "ENV")
+	set.BoolVar(&opts.list, "list", "l")
+	set.StringVar(&opts.remove, "remove", "", "ENROLLMENT")
+	return set
+}
+
+var InitTeller = &Z.Cmd{
+	Name:     "teller",
+	Summary:  "Enroll a bank through Teller",
+	Usage:    "teller " + initTellerFlags(&initTellerOptions{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Enrolls a US bank through Teller, which connects to banks' own APIs, for
+banks it covers better than SimpleFIN. 'money fetch' then reads its
+accounts alongside SimpleFIN's and any other linked banks.
+
+Teller identifies your application by a client certificate. Sign up at
+https://teller.io, download the certificate and private key from the
+dashboard, and point money at them:
+
+  money config set teller_application_id <app id>
+  money config set teller_certificate ~/teller/certificate.pem
+  money config set teller_private_key ~/teller/private_key.pem
+
+This command then serves Teller Connect on localhost: open the link it
+prints, log in to your bank, and the enrollment's access token is stored.
+--environment picks the Teller environment Connect enrolls in (sandbox,
+development or production; development by default). --token stores an
+access token you already have instead, such as a sandbox one.
+
+Run the command once per bank. --list shows the enrolled banks and
+--remove forgets one; its accounts and their history are kept.
+
+Examples:
+  money init teller
+  money init teller --environment sandbox
+  money init teller --token test_token_abc123
+  money init teller --list
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var opts initTellerOptions
+		if err := initTellerFlags(&opts).Parse(args); err != nil {
+			return err
+		}
+		switch opts.environment {
+		case "":
+			opts.environment = "development"
+		case "sandbox", "development", "production":
+		default:
+			return fmt.Errorf("invalid --environment %q: use sandbox, development or production", opts.environment)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if opts.list {
+				return listTellerEnrollments(db)
+			}
+			if opts.remove != "" {
+				if err := db.DeleteTellerEnrollment(opts.remove); err != nil {
+					return err
+				}
+				fmt.Printf("Teller enrollment %s removed; its accounts were kept\n", opts.remove)
+				return nil
+			}
+
+			client, err := newTellerClient(db)
+			if err != nil {
+				return err
+			}
+
+			token, institutionName := opts.token, ""
+			if token == "" {
+				if token, institutionName, err = connectTellerBank(db, opts.environment); err != nil {
+					return err
+				}
+			}
+			return saveTellerEnrollment(db, client, token, institutionName)
+		})
+	},
+}
+
+// newTellerClient returns a client with the configured certificate
+func newTellerClient(db *database.DB) (*teller.Client, error) {
+	cfg := db.GetConfig()
+	if cfg.TellerCertificate == "" || cfg.TellerPrivateKey == "" {
+		return nil, fmt.Errorf("Teller isn't set up: run 'money config set teller_certificate <certificate.pem>' and 'money config set teller_private_key <private_key.pem>'")
+	}
+	certificate, err := expandHome(cfg.TellerCertificate)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := expandHome(cfg.TellerPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return teller.NewClient(certificate, privateKey)
+}
+
+// tellerConnectPage opens Teller Connect and posts the enrollment it
+// returns back to the command
+var tellerConnectPage = template.Must(template.New("connect").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>money: enroll a bank with Teller</title></head>
+<body>
+<p id="message">Opening Teller Connect...</p>
+<script src="https://cdn.teller.io/connect/connect.js"></script>
+<script>
+var message = document.getElementById("message");
+TellerConnect.setup({
+  applicationId: {{.ApplicationID}},
+  environment: {{.Environment}},
+  products: ["balance", "transactions"],
+  onSuccess: function(enrollment) {
+    fetch("/enrollment", {method: "POST", body: JSON.stringify(enrollment)}).then(function() {
+      message.textContent = "Bank enrolled, you can close this tab and return to the terminal.";
+    });
+  },
+  onExit: function() {
+    message.textContent = "Teller Connect was closed. Reload the page to try again.";
+  }
+}).open();
+</script>
+</body>
+</html>
+`))
+
+// tellerConnectEnrollment is what Teller Connect returns once a bank is
+// enrolled
+type tellerConnectEnrollment struct {
+	AccessToken string `json:"accessToken"`
+	Enrollment  struct {
+		ID          string `json:"id"`
+		Institution struct {
+			Name string `json:"name"`
+		} `json:"institution"`
+	} `json:"enrollment"`
+}
+
+// connectTellerBank serves Teller Connect on localhost and waits for the
+// user to enroll a bank with it, returning the access token and the bank's
+// name
+func connectTellerBank(db *database.DB, environment string) (string, string, error) {
+	applicationID := db.GetConfig().TellerApplicationID
+	if applicationID == "" {
+		return "", "", fmt.Errorf("Teller isn't set up: run 'money config set teller_application_id <app id>', or pass --token")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to start the Teller Connect page: %w", err)
+	}
+	enrolled := make(chan tellerConnectEnrollment, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		tellerConnectPage.Execute(w, map[string]string{"ApplicationID": applicationID, "Environment": environment})
+	})
+	mux.HandleFunc("/enrollment", func(w http.ResponseWriter, r *http.Request) {
+		var enrollment tellerConnectEnrollment
+		if r.Method != "POST" || json.NewDecoder(r.Body).Decode(&enrollment) != nil || enrollment.AccessToken == "" {
+			http.Error(w, "expected a Teller Connect enrollment", http.StatusBadRequest)
+			return
+		}
+		select {
+		case enrolled <- enrollment:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Printf("\nOpen this link in your browser and enroll your bank with Teller Connect:\n\n  http://localhost:%d/\n\n", listener.Addr().(*net.TCPAddr).Port)
+	fmt.Println("Waiting for the bank to be enrolled...")
+
+	select {
+	case enrollment := <-enrolled:
+		return enrollment.AccessToken, enrollment.Enrollment.Institution.Name, nil
+	case <-time.After(tellerEnrollTimeout):
+		return "", "", fmt.Errorf("no bank was enrolled within %v; run the command again to retry", tellerEnrollTimeout)
+	}
+}
+
+// saveTellerEnrollment checks an access token by listing its accounts and
+// stores the enrollment
+func saveTellerEnrollment(db *database.DB, client *teller.Client, token, institutionName string) error {
+	accounts, err := client.Accounts(token)
+	if err != nil {
+		return fmt.Errorf("failed to list the enrollment's accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("the enrollment has no accounts")
+	}
+	if institutionName == "" {
+		institutionName = accounts[0].Institution.Name
+	}
+
+	if err := db.SaveTellerEnrollment(database.TellerEnrollment{
+		ID:              accounts[0].EnrollmentID,
+		AccessToken:     token,
+		InstitutionName: institutionName,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("%s%s enrolled with %d account(s)!\n", icon("✅ "), institutionName, len(accounts))
+	fmt.Println("Run 'money fetch' to bring in balances and transactions.")
+	return nil
+}
+
+// listTellerEnrollments prints the enrolled banks
+func listTellerEnrollments(db *database.DB) error {
+	enrollments, err := db.GetTellerEnrollments()
+	if err != nil {
+		return err
+	}
+	if len(enrollments) == 0 {
+		fmt.Println("No banks are enrolled through Teller. Enroll one with 'money init teller'.")
+		return nil
+	}
+
+	t := table.New("Bank", "Enrollment ID")
+	for _, enrollment := range enrollments {
+		t.AddRow(enrollment.InstitutionName, enrollment.ID)
+	}
+	return t.Render()
+}
+
+// openTeller opens a connection for each bank enrolled through Teller.
+// Replays stay offline, so they open none.
+func openTeller(db *database.DB, opts provider.Options) ([]provider.Connection, error) {
+	if opts.ReplayDir != "" {
+		return nil, nil
+	}
+	enrollments, err := db.GetTellerEnrollments()
+	if err != nil || len(enrollments) == 0 {
+		return nil, err
+	}
+	client, err := newTellerClient(db)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := make([]provider.Connection, len(enrollments))
+	for i, enrollment := range enrollments {
+		enrolled := teller.Enrollment{
+			ID:              enrollment.ID,
+			AccessToken:     enrollment.AccessToken,
+			InstitutionName: enrollment.InstitutionName,
+		}
+		read := func(since time.Time, balancesOnly bool) (*simplefin.AccountsResponse, error) {
+			resp := teller.Fetch(client, []teller.Enrollment{enrolled}, teller.FetchOptions{
+				Since:        sinceDay(since),
+				BalancesOnly: balancesOnly,
+			})
+			return partialResponse(resp)
+		}
+		connections[i] = provider.Connection{
+			Key:      "teller:" + enrollment.ID,
+			Name:     enrollment.InstitutionName + " (Teller)",
+			Provider: provider.NewAggregator(read),
+		}
+	}
+	return connections, nil
+}
@@ -3,59 +3,75 @@ package cli
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/fatih/color"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
 	"github.com/arjungandhi/money/internal/convert"
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/fees"
+	"github.com/arjungandhi/money/pkg/format"
 	"github.com/arjungandhi/money/pkg/llm"
+	"github.com/arjungandhi/money/pkg/report"
 	"github.com/arjungandhi/money/pkg/table"
+	"github.com/arjungandhi/money/pkg/transfers"
 )
 
+// defaultTransactionsListLimit caps how many rows `transactions list` prints
+// unless --limit says otherwise
+const defaultTransactionsListLimit = 100
+
 var (
 	grayColor  = color.New(color.FgHiBlack)
 	redColor   = color.New(color.FgRed)   // For expenses (negative amounts)
 	greenColor = color.New(color.FgGreen) // For income (positive amounts)
 )
 
-// colorizeCategory returns a colorized version of the category name
-func colorizeCategory(category string) string {
-	if category == "Uncategorized" {
-		return redColor.Sprint(category)
-	}
-	if strings.Contains(category, "(internal)") {
-		return grayColor.Sprint(category)
-	}
-
-	// All other categories are uncolored
-	return category
-}
-
-// colorizeAmount returns a colorized version of the amount based on sign
-// and calculates the proper padding to account for ANSI color codes
-func colorizeAmount(amount int, amountStr string, width int) string {
-	coloredStr := amountStr
+// colorizeAmount colors amountStr by the sign of amount. It adds no
+// padding; tables align amount columns themselves.
+func colorizeAmount(amount int64, amountStr string) string {
 	if amount < 0 {
-		coloredStr = redColor.Sprint(amountStr) // Expenses in red
+		return redColor.Sprint(amountStr) // Expenses in red
 	} else if amount > 0 {
-		coloredStr = greenColor.Sprint(amountStr) // Income in green
+		return greenColor.Sprint(amountStr) // Income in green
 	}
+	return amountStr
+}
 
-	// Calculate padding to account for invisible ANSI codes
-	// The visible length is the original string length
-	visibleLen := len(amountStr)
-	totalLen := len(coloredStr)
-	invisibleLen := totalLen - visibleLen
-
-	// Adjust width to account for invisible characters
-	adjustedWidth := width + invisibleLen
+// accountCurrencies maps account IDs to their currency, for showing
+// transactions in their account's currency
+func accountCurrencies(db *database.DB) (map[string]string, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	currencies := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		currencies[account.ID] = account.Currency
+	}
+	return currencies, nil
+}
 
-	// Right-align the colored string with adjusted width
-	return fmt.Sprintf("%*s", adjustedWidth, coloredStr)
+// negativeAmounts is a table row style that colors amounts below zero red
+// in the given columns
+func negativeAmounts(columns ...int) table.RowStyleFunc {
+	return func(row []string, column int) *color.Color {
+		if !slices.Contains(columns, column) {
+			return nil
+		}
+		if cents, err := format.ParseCurrency(row[column]); err == nil && cents < 0 {
+			return redColor
+		}
+		return nil
+	}
 }
 
 var Transactions = &Z.Cmd{
@@ -65,6 +81,9 @@ var Transactions = &Z.Cmd{
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		TransactionsList,
+		TransactionsUncategorized,
+		TransactionsShow,
+		TransactionsEdit,
 		Categorize,
 	},
 	Call: func(cmd *Z.Cmd, args ...string) error {
@@ -77,61 +96,89 @@ var Transactions = &Z.Cmd{
 	},
 }
 
+func transactionsListFlags(filter *database.TransactionFilter, categoryName *string, limit, offset *int) *flags.Set {
+	set := flags.New("money transactions list")
+	set.Func("start", "", "YYYY-MM-DD", dateFlag(&filter.StartDate))
+	set.Func("end", "", "YYYY-MM-DD", dateFlag(&filter.EndDate))
+	set.StringVar(&filter.AccountID, "account", "", "<account-id>")
+	set.StringVar(categoryName, "category", "", "<category>")
+	set.BoolVar(&filter.UncategorizedOnly, "uncategorized", "")
+	set.Func("min-amount", "", "N", centsFlag(&filter.MinAmount))
+	set.Func("max-amount", "", "N", centsFlag(&filter.MaxAmount))
+	set.StringVar(&filter.Description, "description", "", "<text>")
+	set.BoolVar(&filter.PendingOnly, "pending", "")
+	set.BoolVar(&filter.PostedOnly, "posted", "")
+	set.IntVar(limit, "limit", "", "N", 0)
+	set.IntVar(offset, "offset", "", "N", 0)
+	return set
+}
+
+// centsFlag returns a flag setter that stores a non-negative dollar amount
+// in p as cents
+func centsFlag(p *int64) func(string) error {
+	return func(value string) error {
+		amount, err := format.ParseCents(value)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("must be a dollar amount of at least 0")
+		}
+		*p = amount
+		return nil
+	}
+}
+
 var TransactionsList = &Z.Cmd{
 	Name:     "list",
 	Aliases:  []string{"ls", "l"},
 	Summary:  "List transactions with optional filtering",
-	Usage:    "list [--start YYYY-MM-DD] [--end YYYY-MM-DD] [--account <account-id>]",
+	Usage:    "list " + transactionsListFlags(&database.TransactionFilter{}, new(string), new(int), new(int)).Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		db, err := database.New()
-		if err != nil {
-			return fmt.Errorf("failed to initialize database: %w", err)
+		var filter database.TransactionFilter
+		var categoryName string
+		limit, offset := defaultTransactionsListLimit, 0
+		if err := transactionsListFlags(&filter, &categoryName, &limit, &offset).Parse(args); err != nil {
+			return err
 		}
-		defer db.Close()
 
-		// Parse command line arguments
-		var startDate, endDate, accountID string
-		for i := 0; i < len(args); i++ {
-			switch args[i] {
-			case "--start":
-				if i+1 < len(args) {
-					startDate = args[i+1]
-					i++
-				}
-			case "--end":
-				if i+1 < len(args) {
-					endDate = args[i+1]
-					i++
-				}
-			case "--account":
-				if i+1 < len(args) {
-					accountID = args[i+1]
-					i++
-				}
-			}
+		if filter.PendingOnly && filter.PostedOnly {
+			return fmt.Errorf("--pending and --posted cannot be used together")
+		}
+		if categoryName != "" && filter.UncategorizedOnly {
+			return fmt.Errorf("--category and --uncategorized cannot be used together")
 		}
 
-		// Validate date format if provided
-		if startDate != "" {
-			if _, err := time.Parse("2006-01-02", startDate); err != nil {
-				return fmt.Errorf("invalid start date format. Use YYYY-MM-DD: %w", err)
-			}
+		db, err := dbutil.Open()
+		if err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
 		}
-		if endDate != "" {
-			if _, err := time.Parse("2006-01-02", endDate); err != nil {
-				return fmt.Errorf("invalid end date format. Use YYYY-MM-DD: %w", err)
+
+		if categoryName != "" {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return fmt.Errorf("failed to find category: %w", err)
 			}
+			filter.CategoryID = category.ID
 		}
 
+		defer startPager()()
+
 		// Get transactions from database
-		transactions, err := db.GetTransactions(accountID, startDate, endDate)
+		total, err := db.CountTransactions(filter)
+		if err != nil {
+			return fmt.Errorf("failed to count transactions: %w", err)
+		}
+
+		transactions, err := db.GetTransactions(filter, limit, offset)
 		if err != nil {
 			return fmt.Errorf("failed to get transactions: %w", err)
 		}
 
 		if len(transactions) == 0 {
-			fmt.Println("No transactions found.")
+			if total > 0 {
+				fmt.Printf("No transactions found at offset %d (%d match).\n", offset, total)
+			} else {
+				fmt.Println("No transactions found.")
+			}
 			return nil
 		}
 
@@ -141,37 +188,54 @@ var TransactionsList = &Z.Cmd{
 			return fmt.Errorf("failed to get accounts: %w", err)
 		}
 
-		// Create account ID to display name mapping
+		// Create account ID to display name and currency mappings
 		accountMap := make(map[string]string)
+		currencies := make(map[string]string)
 		for _, account := range accounts {
 			accountMap[account.ID] = account.DisplayName()
+			currencies[account.ID] = account.Currency
+		}
+
+		// Read categories once rather than looking each row's up
+		categories, err := db.GetCategories()
+		if err != nil {
+			return fmt.Errorf("failed to get categories: %w", err)
+		}
+		categoryNames := make(map[int]string, len(categories))
+		for _, category := range categories {
+			categoryNames[category.ID] = category.Name
+			if category.IsInternal {
+				categoryNames[category.ID] += " (internal)"
+			}
 		}
+		setCategoryStyles(categories)
 
 		// Create and populate transactions table
 		config := table.DefaultConfig()
-		config.Title = fmt.Sprintf("Found %d transactions", len(transactions))
+		if len(transactions) < total {
+			config.Title = fmt.Sprintf("Showing %d-%d of %d transactions", offset+1, offset+len(transactions), total)
+		} else {
+			config.Title = fmt.Sprintf("Found %d transactions", len(transactions))
+		}
 		config.MaxColumnWidth = 50
 
 		t := table.NewWithConfig(config, "ID", "Date", "Account", "Amount", "Description", "Category")
+		t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnText, table.ColumnCurrency)
 
 		for _, txn := range transactions {
 			// Parse date for display
-			postedTime, _ := time.Parse(time.RFC3339, txn.Posted)
+			postedTime, _ := format.PostedTime(txn.Posted)
 			dateStr := postedTime.Format("2006-01-02 15:04")
 
-			// Format amount (convert cents to dollars)
-			amountStr := fmt.Sprintf("$%.2f", float64(txn.Amount)/100.0)
-			coloredAmount := colorizeAmount(txn.Amount, amountStr, 12)
+			// Format amount
+			amountStr := format.Currency(txn.Amount, currencies[txn.AccountID])
+			coloredAmount := colorizeAmount(txn.Amount, amountStr)
 
 			// Get category name if categorized
 			categoryStr := "Uncategorized"
 			if txn.CategoryID != nil {
-				category, err := db.GetCategoryByID(*txn.CategoryID)
-				if err == nil {
-					categoryStr = category.Name
-					if category.IsInternal {
-						categoryStr += " (internal)"
-					}
+				if name, exists := categoryNames[*txn.CategoryID]; exists {
+					categoryStr = name
 				}
 			}
 
@@ -184,17 +248,106 @@ var TransactionsList = &Z.Cmd{
 			// Apply color to category
 			coloredCategory := colorizeCategory(categoryStr)
 
-			t.AddRow(txn.ID, dateStr, accountDisplay, coloredAmount, txn.Description, coloredCategory)
+			t.AddRow(txn.ID, dateStr, accountDisplay, coloredAmount, txn.DisplayDescription(), coloredCategory)
 		}
 
 		if err := t.Render(); err != nil {
 			return fmt.Errorf("failed to render transactions table: %w", err)
 		}
 
+		if remaining := total - offset - len(transactions); remaining > 0 {
+			hintf("%d more transactions. Use --offset %d to see the next page, or --limit 0 to show all.\n", remaining, offset+len(transactions))
+		}
+
 		return nil
 	},
 }
 
+func transactionsUncategorizedFlags(summary *bool, sortBy *string, top *int) *flags.Set {
+	set := flags.New("money transactions uncategorized")
+	set.BoolVar(summary, "summary", "s")
+	set.Func("sort", "", "total|count", func(value string) error {
+		if value != "total" && value != "count" {
+			return fmt.Errorf("use total or count")
+		}
+		*sortBy = value
+		return nil
+	})
+	set.IntVar(top, "top", "n", "N", 1)
+	return set
+}
+
+var TransactionsUncategorized = &Z.Cmd{
+	Name:     "uncategorized",
+	Aliases:  []string{"uncat", "u"},
+	Summary:  "List uncategorized transactions, or group them by merchant",
+	Usage:    "uncategorized " + transactionsUncategorizedFlags(new(bool), new(string), new(int)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Lists every uncategorized transaction, newest first. With --summary they
+are grouped by merchant instead, with how many there are and what they add
+up to, so the biggest offenders can be cleared in one go. Merchants are
+grouped by description with processor prefixes and store and reference
+numbers removed; the Example column shows one raw bank description, for
+writing a rename rule or a transactions list --description filter.
+
+The summary is ordered by total (by size) unless --sort count is given,
+and --top shows only the first N merchants.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var summary bool
+		sortBy, top := "total", 0
+		if err := transactionsUncategorizedFlags(&summary, &sortBy, &top).Parse(args); err != nil {
+			return err
+		}
+		if !summary {
+			return TransactionsList.Call(TransactionsList, "--uncategorized", "--limit", "0")
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			detail, err := report.CategoryDrillDown(db, "Uncategorized", "", "")
+			if err != nil {
+				return err
+			}
+			if len(detail.Merchants) == 0 {
+				fmt.Println("No uncategorized transactions.")
+				return nil
+			}
+
+			config := table.DefaultConfig()
+			config.Title = fmt.Sprintf("%d Uncategorized Transactions by Merchant", len(detail.Transactions))
+			config.MaxColumnWidth = 40
+
+			merchants := detail.Merchants
+			if sortBy == "count" {
+				sort.SliceStable(merchants, func(i, j int) bool {
+					return merchants[i].Count > merchants[j].Count
+				})
+			}
+			if top > 0 && top < len(merchants) {
+				merchants = merchants[:top]
+			}
+
+			t := table.NewWithConfig(config, "Merchant", "Transactions", "Total", "Example")
+			t.SetColumnTypes(table.ColumnText, table.ColumnNumber, table.ColumnCurrency, table.ColumnText)
+			t.SetRowStyle(negativeAmounts(2))
+			for _, m := range merchants {
+				t.AddRow(m.Merchant, strconv.Itoa(m.Count), format.Currency(m.Total, format.BaseCurrency()), m.Example)
+			}
+			t.SetFooter("Total", strconv.Itoa(len(detail.Transactions)), format.Currency(detail.Total, format.BaseCurrency()), "")
+
+			defer startPager()()
+			if err := t.Render(); err != nil {
+				return fmt.Errorf("failed to render uncategorized summary: %w", err)
+			}
+			if remaining := len(detail.Merchants) - len(merchants); remaining > 0 {
+				hintf("%d more merchants. Raise --top to see them.\n", remaining)
+			}
+			return nil
+		})
+	},
+}
+
 var Categorize = &Z.Cmd{
 	Name:    "categorize",
 	Aliases: []string{"cat", "c"},
@@ -211,38 +364,30 @@ var Categorize = &Z.Cmd{
 var CategorizeModify = &Z.Cmd{
 	Name:     "modify",
 	Summary:  "Set or change the category of a specific transaction",
-	Usage:    "modify <transaction-id> <category-name>",
+	Usage:    "modify " + forceFlags("money transactions categorize modify", new(bool), "<transaction-id>", "<category-name>...").Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
+		var force bool
+		set := forceFlags("money transactions categorize modify", &force, "<transaction-id>", "<category-name>...")
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		args = set.Args()
 		if len(args) < 2 {
-			return fmt.Errorf("usage: money transactions categorize modify <transaction-id> <category-name>")
+			return fmt.Errorf("usage: money transactions categorize %s", cmd.Usage)
 		}
 
 		transactionID := args[0]
 		categoryName := strings.Join(args[1:], " ")
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return fmt.Errorf("failed to initialize database: %w", err)
 		}
-		defer db.Close()
-
-		// Determine category type based on transaction amount
-		transactions, err := db.GetTransactions("", "", "")
-		if err != nil {
-			return fmt.Errorf("failed to get transactions: %w", err)
-		}
+		db.AllowClosedMonthEdits(force)
 
-		var transaction *database.Transaction
-		for _, t := range transactions {
-			if t.ID == transactionID {
-				transaction = &t
-				break
-			}
-		}
-
-		if transaction == nil {
-			return fmt.Errorf("transaction not found: %s", transactionID)
+		if _, err := db.GetTransactionByID(transactionID); err != nil {
+			return err
 		}
 
 		// Save or get category (no type needed now)
@@ -254,7 +399,7 @@ var CategorizeModify = &Z.Cmd{
 		// Update transaction
 		err = db.UpdateTransactionCategory(transactionID, categoryID)
 		if err != nil {
-			return fmt.Errorf("failed to update transaction category: %w", err)
+			return fmt.Errorf("failed to update transaction category: %w", closedMonthError(err))
 		}
 
 		fmt.Printf("Transaction %s categorized as '%s'\n", transactionID, categoryName)
@@ -265,24 +410,30 @@ var CategorizeModify = &Z.Cmd{
 var CategorizeClear = &Z.Cmd{
 	Name:     "clear",
 	Summary:  "Clear the category of a specific transaction",
-	Usage:    "clear <transaction-id>",
+	Usage:    "clear " + forceFlags("money transactions categorize clear", new(bool), "<transaction-id>").Usage(),
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
+		var force bool
+		set := forceFlags("money transactions categorize clear", &force, "<transaction-id>")
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		args = set.Args()
 		if len(args) != 1 {
-			return fmt.Errorf("usage: money transactions categorize clear <transaction-id>")
+			return fmt.Errorf("usage: money transactions categorize %s", cmd.Usage)
 		}
 
 		transactionID := args[0]
 
-		db, err := database.New()
+		db, err := dbutil.Open()
 		if err != nil {
 			return fmt.Errorf("failed to initialize database: %w", err)
 		}
-		defer db.Close()
+		db.AllowClosedMonthEdits(force)
 
 		err = db.ClearTransactionCategory(transactionID)
 		if err != nil {
-			return fmt.Errorf("failed to clear transaction category: %w", err)
+			return fmt.Errorf("failed to clear transaction category: %w", closedMonthError(err))
 		}
 
 		fmt.Printf("Category cleared for transaction %s\n", transactionID)
@@ -291,23 +442,37 @@ var CategorizeClear = &Z.Cmd{
 }
 
 var CategorizeAuto = &Z.Cmd{
-	Name:     "auto",
-	Summary:  "Automatically categorize transactions using LLM",
-	Usage:    "auto [--all]",
+	Name:    "auto",
+	Summary: "Automatically categorize transactions using LLM",
+	Usage:   "auto [--all] [--force]",
+	Description: `
+First pairs up transfers between your own accounts (an amount leaving one
+account and the same amount arriving in another within 3 days) and files
+both sides under the internal Transfers category. Interest charges and
+bank fees are recognized by their descriptions (after rename rules) and
+filed under Interest and Fees. The LLM then categorizes the remaining
+uncategorized transactions.
+
+With --all, every transaction is matched and recategorized, replacing
+existing categories wherever a transfer or suggestion is found.
+
+Transactions in months closed with 'money close' are skipped unless
+--force is given.
+`,
 	Commands: []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		processAll := false
-		for _, arg := range args {
-			if arg == "--all" {
-				processAll = true
-				break
-			}
+		var processAll, force bool
+		set := flags.New("money categorize auto")
+		set.BoolVar(&processAll, "all", "")
+		set.BoolVar(&force, "force", "f")
+		if err := set.Parse(args); err != nil {
+			return err
 		}
 
 		if processAll {
-			return recategorizeAllTransactions()
+			return recategorizeAllTransactions(force)
 		} else {
-			return autoCategorizeTransactions()
+			return autoCategorizeTransactions(force)
 		}
 	},
 }
@@ -321,19 +486,22 @@ var CategorizeManual = &Z.Cmd{
 	},
 }
 
-// autoCategorizeTransactions implements the LLM-based auto-categorization logic
-func autoCategorizeTransactions() error {
-	db, err := database.New()
+// autoCategorizeTransactions files matched transfers under the internal
+// Transfers category and interest and fees under their own, then categorizes the remaining uncategorized
+// transactions with the LLM. Closed months are skipped unless force is set.
+func autoCategorizeTransactions(force bool) error {
+	db, err := dbutil.Open()
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
-	defer db.Close()
 
-	// Get uncategorized transactions (not marked as transfers)
 	transactions, err := db.GetUncategorizedTransactions()
 	if err != nil {
 		return fmt.Errorf("failed to get uncategorized transactions: %w", err)
 	}
+	if transactions, err = openMonthTransactions(db, transactions, force); err != nil {
+		return err
+	}
 
 	if len(transactions) == 0 {
 		fmt.Println("No uncategorized transactions found.")
@@ -342,6 +510,157 @@ func autoCategorizeTransactions() error {
 
 	fmt.Printf("Found %d uncategorized transactions.\n\n", len(transactions))
 
+	remaining, err := categorizeTransfers(db, transactions)
+	if err != nil {
+		return err
+	}
+	if remaining, err = categorizeFees(db, remaining); err != nil {
+		return err
+	}
+
+	return categorizeWithLLM(db, remaining)
+}
+
+// recategorizeAllTransactions re-runs transfer matching and LLM
+// categorization over every transaction, replacing existing categories
+// wherever a transfer or suggestion is found. Closed months are skipped
+// unless force is set.
+func recategorizeAllTransactions(force bool) error {
+	db, err := dbutil.Open()
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	transactions, err := db.GetTransactions(database.TransactionFilter{}, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get transactions: %w", err)
+	}
+	if transactions, err = openMonthTransactions(db, transactions, force); err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		fmt.Println("No transactions found.")
+		return nil
+	}
+
+	fmt.Printf("Recategorizing all %d transactions; existing categories will be replaced.\n\n", len(transactions))
+
+	remaining, err := categorizeTransfers(db, transactions)
+	if err != nil {
+		return err
+	}
+	if remaining, err = categorizeFees(db, remaining); err != nil {
+		return err
+	}
+
+	return categorizeWithLLM(db, remaining)
+}
+
+// categorizeTransfers files transactions that pair up as transfers between
+// the user's accounts under the internal Transfers category, returning the
+// transactions that weren't matched
+func categorizeTransfers(db *database.DB, transactions []database.Transaction) ([]database.Transaction, error) {
+	pairs := transfers.Match(transactions, transfers.DefaultWindowDays)
+	if len(pairs) == 0 {
+		return transactions, nil
+	}
+
+	categoryID, err := db.SaveCategoryWithInternal(transfers.CategoryName, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s category: %w", transfers.CategoryName, err)
+	}
+
+	currencies, err := accountCurrencies(db)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]bool)
+	var ids []string
+	for _, pair := range pairs {
+		matched[pair.Out.ID] = true
+		matched[pair.In.ID] = true
+		ids = append(ids, pair.Out.ID, pair.In.ID)
+		fmt.Printf("%s%s ↔ %s (%s)\n", icon("🔁 "), pair.Out.DisplayDescription(), pair.In.DisplayDescription(),
+			format.Currency(pair.In.Amount, currencies[pair.In.AccountID]))
+	}
+
+	if _, err := db.SetTransactionCategories(ids, &categoryID); err != nil {
+		return nil, fmt.Errorf("failed to categorize transfers: %w", err)
+	}
+	fmt.Printf("Matched %d transfers between your accounts as '%s'.\n\n", len(pairs), transfers.CategoryName)
+
+	var remaining []database.Transaction
+	for _, txn := range transactions {
+		if !matched[txn.ID] {
+			remaining = append(remaining, txn)
+		}
+	}
+	return remaining, nil
+}
+
+// categorizeFees files transactions that read like interest charges or bank
+// fees under the Interest and Fees categories, returning the rest
+func categorizeFees(db *database.DB, transactions []database.Transaction) ([]database.Transaction, error) {
+	matches := fees.Match(transactions)
+	if len(matches) == 0 {
+		return transactions, nil
+	}
+
+	currencies, err := accountCurrencies(db)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]bool)
+	for _, category := range []string{fees.InterestCategory, fees.FeesCategory} {
+		if len(matches[category]) == 0 {
+			continue
+		}
+
+		categoryID, err := db.SaveCategory(category)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s category: %w", category, err)
+		}
+
+		var ids []string
+		for _, txn := range matches[category] {
+			matched[txn.ID] = true
+			ids = append(ids, txn.ID)
+			fmt.Printf("%s%s (%s)\n", icon("🏦 "), txn.DisplayDescription(), format.Currency(txn.Amount, currencies[txn.AccountID]))
+		}
+
+		if _, err := db.SetTransactionCategories(ids, &categoryID); err != nil {
+			return nil, fmt.Errorf("failed to categorize %s: %w", strings.ToLower(category), err)
+		}
+		fmt.Printf("Filed %d transactions under '%s'.\n\n", len(ids), category)
+	}
+
+	var remaining []database.Transaction
+	for _, txn := range transactions {
+		if !matched[txn.ID] {
+			remaining = append(remaining, txn)
+		}
+	}
+	return remaining, nil
+}
+
+// llmBatchResult is the LLM's answer for one batch of transactions
+type llmBatchResult struct {
+	result *llm.CategoryAnalysisResult
+	err    error
+}
+
+// categorizeWithLLM asks the LLM to categorize transactions in batches of
+// the configured size, running up to LLMParallelism batches at once, and
+// applies its suggestions
+func categorizeWithLLM(db *database.DB, transactions []database.Transaction) error {
+	if len(transactions) == 0 {
+		fmt.Println("No transactions left for the LLM to categorize.")
+		return nil
+	}
+
 	// Get all accounts for context (helps LLM identify transfers and account-specific patterns)
 	accounts, err := db.GetAccounts()
 	if err != nil {
@@ -355,17 +674,14 @@ func autoCategorizeTransactions() error {
 	}
 
 	if len(categories) == 0 {
-		fmt.Println("No categories found. Please run 'money transactions category seed' first to create default categories, or add categories manually using 'money transactions category add <name>'.")
+		fmt.Println("No categories found. Please run 'money categories seed' first to create default categories, or add categories manually using 'money categories add <name>'.")
 		return nil
 	}
 
 	// Separate regular and internal categories for the LLM prompt
 	var regularCategories []string
 	var internalCategories []string
-	allCategoryNames := make([]string, len(categories))
-
-	for i, cat := range categories {
-		allCategoryNames[i] = cat.Name
+	for _, cat := range categories {
 		if cat.IsInternal {
 			internalCategories = append(internalCategories, cat.Name)
 		} else {
@@ -382,10 +698,8 @@ func autoCategorizeTransactions() error {
 
 	// Initialize LLM client
 	llmClient := llm.NewClient()
-	ctx := context.Background()
+	llmClient.SetAuditLog(db)
 
-	// Convert database types to LLM types
-	llmTransactions := convert.ToLLMTransactionData(transactions)
 	llmAccounts := convert.ToLLMAccountData(accounts)
 
 	// Get examples from previously categorized transactions
@@ -400,57 +714,110 @@ func autoCategorizeTransactions() error {
 	}
 
 	if len(examples) > 0 {
-		fmt.Printf("📚 Using %d examples from previously categorized transactions\n", len(examples))
+		fmt.Printf("%sUsing %d examples from previously categorized transactions\n", icon("📚 "), len(examples))
 	}
 
-	// Categorize transactions using user's existing categories
-	fmt.Printf("📝 Categorizing %d transactions using your existing categories...\n", len(llmTransactions))
-	categoryResult, err := llmClient.CategorizeTransactionsWithExamples(ctx, llmTransactions, categories, llmAccounts, examples)
-	if err != nil {
-		return fmt.Errorf("failed to categorize transactions: %w", err)
+	byID := make(map[string]database.Transaction)
+	for _, tx := range transactions {
+		byID[tx.ID] = tx
 	}
 
-	// Apply category suggestions with user approval
-	categoryCount := 0
-	for _, suggestion := range categoryResult.Suggestions {
-		// Find the transaction to show details
-		var transaction *database.Transaction
-		for _, tx := range transactions {
-			if tx.ID == suggestion.TransactionID {
-				transaction = &tx
-				break
+	batchSize := db.GetConfig().LLMBatchSize
+	if batchSize <= 0 {
+		batchSize = len(transactions)
+	}
+
+	var batches [][]database.Transaction
+	for start := 0; start < len(transactions); start += batchSize {
+		batches = append(batches, transactions[start:min(start+batchSize, len(transactions))])
+	}
+
+	parallelism := min(db.GetConfig().LLMParallelism, len(batches))
+	if parallelism > 1 {
+		fmt.Printf("Running up to %d LLM batches at once\n", parallelism)
+	}
+
+	batchProgress := startProgress("LLM batches", len(batches))
+	defer batchProgress.Done()
+
+	// Workers run the LLM on batches as they come up, while the results are
+	// applied here in batch order, so the output reads the same however the
+	// calls finish. The first failure cancels the batches still running.
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make([]chan llmBatchResult, len(batches))
+	for i := range results {
+		results[i] = make(chan llmBatchResult, 1)
+	}
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range batches {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
 			}
 		}
+	}()
+	for w := 0; w < parallelism; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range jobs {
+				result, err := llmClient.CategorizeTransactionsWithExamples(ctx, convert.ToLLMTransactionData(batches[i]), categories, llmAccounts, examples)
+				results[i] <- llmBatchResult{result, err}
+			}
+		}()
+	}
 
-		if transaction == nil {
-			continue
+	categoryCount := 0
+	for i, batch := range batches {
+		first := i*batchSize + 1
+		batchProgress.Printf("%sCategorizing transactions %d-%d of %d using your existing categories...\n", icon("📝 "), first, first+len(batch)-1, len(transactions))
+		batchResult := <-results[i]
+		if batchResult.err != nil {
+			return fmt.Errorf("failed to categorize transactions: %w", batchResult.err)
 		}
 
-		// Get category ID (this will find the existing category since we're using user's categories)
-		categoryID, err := db.SaveCategory(suggestion.Category)
-		if err != nil {
-			return fmt.Errorf("failed to get category ID: %w", err)
+		// Apply category suggestions, one update per category
+		var categoryIDs []int
+		idsByCategory := make(map[int][]string)
+		for _, suggestion := range batchResult.result.Suggestions {
+			transaction, exists := byID[suggestion.TransactionID]
+			if !exists {
+				continue
+			}
+
+			// Get category ID (this will find the existing category since we're using user's categories)
+			categoryID, err := db.SaveCategory(suggestion.Category)
+			if err != nil {
+				return fmt.Errorf("failed to get category ID: %w", err)
+			}
+
+			if _, seen := idsByCategory[categoryID]; !seen {
+				categoryIDs = append(categoryIDs, categoryID)
+			}
+			idsByCategory[categoryID] = append(idsByCategory[categoryID], suggestion.TransactionID)
+			batchProgress.Printf("%s%s → %s\n", icon("💸 "), transaction.Description, suggestion.Category)
 		}
 
-		// Update transaction category
-		err = db.UpdateTransactionCategory(suggestion.TransactionID, categoryID)
-		if err != nil {
-			return fmt.Errorf("failed to update transaction category: %w", err)
+		for _, categoryID := range categoryIDs {
+			updated, err := db.UpdateTransactionCategories(idsByCategory[categoryID], categoryID)
+			if err != nil {
+				return fmt.Errorf("failed to update transaction categories: %w", err)
+			}
+			categoryCount += updated
 		}
-		fmt.Printf("💸 %s → %s\n", transaction.Description, suggestion.Category)
-		categoryCount++
+		batchProgress.Add(1)
 	}
+	batchProgress.Done()
 
-	fmt.Printf("\n🎉 Auto-categorization complete!\n")
+	fmt.Printf("\n%sAuto-categorization complete!\n", icon("🎉 "))
 	fmt.Printf("   Transactions categorized: %d\n", categoryCount)
 
 	return nil
 }
-
-// recategorizeAllTransactions recategorizes ALL transactions using LLM
-func recategorizeAllTransactions() error {
-	// TODO: This function needs to be updated to work with internal categories instead of transfer flags
-	fmt.Println("⚠️  Recategorize all functionality temporarily disabled during refactor")
-	fmt.Println("Please use 'money transactions categorize auto' for new categorization")
-	return nil
-}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+var TransactionsShow = &Z.Cmd{
+	Name:     "show",
+	Aliases:  []string{"info"},
+	Summary:  "Show full details of a transaction",
+	Usage:    "show <transaction-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money transactions show <transaction-id>")
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+
+		txn, err := db.GetTransactionByID(args[0])
+		if err != nil {
+			return err
+		}
+
+		accountDisplay := txn.AccountID
+		source := "SimpleFIN"
+		currency := ""
+		account, err := db.GetAccountByID(txn.AccountID)
+		if err == nil {
+			accountDisplay = account.DisplayName()
+			currency = account.Currency
+
+			orgs, err := db.GetOrganizations()
+			if err != nil {
+				return fmt.Errorf("failed to get organizations: %w", err)
+			}
+			for _, org := range orgs {
+				if org.ID == account.OrgID {
+					source = fmt.Sprintf("SimpleFIN (%s)", org.Name)
+					break
+				}
+			}
+		}
+
+		categoryStr := "Uncategorized"
+		if txn.CategoryID != nil {
+			if category, err := db.GetCategoryByID(*txn.CategoryID); err == nil {
+				categoryStr = category.Name
+				if category.IsInternal {
+					categoryStr += " (internal)"
+				}
+			}
+		}
+
+		status := "Posted"
+		if txn.Pending {
+			status = "Pending"
+		}
+
+		createdAt, updatedAt, err := db.GetTransactionTimestamps(txn.ID)
+		if err != nil {
+			return err
+		}
+
+		edits, err := db.GetTransactionEdits(txn.ID)
+		if err != nil {
+			return err
+		}
+		if err := loadCategoryStyles(db); err != nil {
+			return err
+		}
+
+		fmt.Printf("Transaction %s\n", txn.ID)
+		fmt.Printf("  Date: %s\n", formatPosted(txn.Posted))
+		fmt.Printf("  Description: %s\n", txn.DisplayDescription())
+		if txn.DisplayDescription() != txn.Description {
+			fmt.Printf("  Bank Description: %s\n", txn.Description)
+		}
+		fmt.Printf("  Amount: %s\n", colorizeAmount(txn.Amount, format.Currency(txn.Amount, currency)))
+		fmt.Printf("  Account: %s (%s)\n", accountDisplay, txn.AccountID)
+		fmt.Printf("  Category: %s\n", colorizeCategory(categoryStr))
+		fmt.Printf("  Status: %s\n", status)
+		fmt.Printf("  Source: %s\n", source)
+		fmt.Printf("  Imported: %s\n", createdAt)
+		fmt.Printf("  Last Updated: %s\n", updatedAt)
+
+		if len(edits) == 0 {
+			grayColor.Println("\nNo manual edits")
+			return nil
+		}
+
+		fmt.Println("\nEdit history:")
+		for _, edit := range edits {
+			fmt.Printf("  %s  %s: %s → %s\n", edit.EditedAt, edit.Field,
+				formatEditValue(edit.Field, edit.OldValue, currency), formatEditValue(edit.Field, edit.NewValue, currency))
+		}
+
+		return nil
+	},
+}
+
+func transactionsEditFlags(changes *database.TransactionChanges, newDate *string) *flags.Set {
+	set := flags.New("money transactions edit")
+	set.Positional("<transaction-id>")
+	set.Func("description", "", "<text>", func(value string) error {
+		changes.Description = &value
+		return nil
+	})
+	set.StringVar(newDate, "date", "", "YYYY-MM-DD")
+	set.Func("amount", "", "N", func(value string) error {
+		amount, err := format.ParseCents(value)
+		if err != nil {
+			return err
+		}
+		changes.Amount = &amount
+		return nil
+	})
+	return set
+}
+
+var TransactionsEdit = &Z.Cmd{
+	Name:     "edit",
+	Summary:  "Edit the description, date, or amount of a transaction",
+	Usage:    "edit " + transactionsEditFlags(&database.TransactionChanges{}, new(string)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		var changes database.TransactionChanges
+		var newDate string
+		set := transactionsEditFlags(&changes, &newDate)
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if len(set.Args()) != 1 {
+			return fmt.Errorf("usage: money transactions %s", cmd.Usage)
+		}
+		transactionID := set.Args()[0]
+
+		if changes.Description == nil && changes.Amount == nil && newDate == "" {
+			return fmt.Errorf("nothing to edit: pass --description, --date, or --amount")
+		}
+
+		db, err := dbutil.Open()
+		if err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+
+		if newDate != "" {
+			txn, err := db.GetTransactionByID(transactionID)
+			if err != nil {
+				return err
+			}
+			posted, err := replacePostedDate(txn.Posted, newDate)
+			if err != nil {
+				return err
+			}
+			changes.Posted = &posted
+		}
+
+		changed, err := db.EditTransaction(transactionID, changes)
+		if err != nil {
+			return fmt.Errorf("failed to edit transaction: %w", err)
+		}
+
+		if changed == 0 {
+			fmt.Printf("Transaction %s already has those values; nothing changed\n", transactionID)
+			return nil
+		}
+
+		// Rename rules match the raw description, so re-run them after it changes
+		if changes.Description != nil {
+			if _, err := db.ApplyRenameRules([]string{transactionID}); err != nil {
+				return fmt.Errorf("failed to apply rename rules: %w", err)
+			}
+		}
+
+		fmt.Printf("Updated %d field(s) of transaction %s\n", changed, transactionID)
+		return nil
+	},
+}
+
+// replacePostedDate returns posted moved to date (YYYY-MM-DD) in the
+// configured time zone, keeping its local time of day so edits don't reorder
+// transactions within the day. The result is stored in UTC like fetched
+// transactions.
+func replacePostedDate(posted, date string) (string, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, format.Location())
+	if err != nil {
+		return "", fmt.Errorf("invalid date format. Use YYYY-MM-DD: %w", err)
+	}
+
+	original, err := format.PostedTime(posted)
+	if err != nil {
+		return day.UTC().Format(time.RFC3339), nil
+	}
+
+	moved := time.Date(day.Year(), day.Month(), day.Day(),
+		original.Hour(), original.Minute(), original.Second(), 0, original.Location())
+	return moved.UTC().Format(time.RFC3339), nil
+}
+
+// formatPosted formats a stored RFC3339 posted time for display in the
+// configured time zone
+func formatPosted(posted string) string {
+	postedTime, err := format.PostedTime(posted)
+	if err != nil {
+		return posted
+	}
+	return postedTime.Format("2006-01-02 15:04")
+}
+
+// formatEditValue formats an audit trail value for display, amounts in
+// the transaction's currency
+func formatEditValue(field, value, currency string) string {
+	switch field {
+	case "amount":
+		if cents, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return format.Currency(cents, currency)
+		}
+	case "posted":
+		return formatPosted(value)
+	case "description":
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestReplacePostedDate(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tests := []struct {
+		posted  string
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{"2024-01-01T08:30:00Z", "2024-02-15", "2024-02-15T08:30:00Z", false},
+		{"not a time", "2024-02-15", "2024-02-15T00:00:00Z", false},
+		{"2024-01-01T08:30:00Z", "02/15/2024", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := replacePostedDate(tt.posted, tt.date)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("replacePostedDate(%q, %q) expected error", tt.posted, tt.date)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("replacePostedDate(%q, %q) error: %v", tt.posted, tt.date, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("replacePostedDate(%q, %q) = %q, want %q", tt.posted, tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestReplacePostedDateInLocation(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.FixedZone("EST", -5*60*60))
+
+	// 04:30 UTC on Feb 1st is 23:30 on Jan 31st in UTC-5; moving it keeps
+	// the local time of day
+	got, err := replacePostedDate("2024-02-01T04:30:00Z", "2024-03-10")
+	if err != nil {
+		t.Fatalf("replacePostedDate error: %v", err)
+	}
+	if want := "2024-03-11T04:30:00Z"; got != want {
+		t.Errorf("replacePostedDate = %q, want %q", got, want)
+	}
+}
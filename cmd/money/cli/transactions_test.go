@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/table"
+)
+
+// fakeLLM answers every transaction in the prompt with Groceries, taking
+// longest over the first batch so later batches finish before it
+const fakeLLM = `#!/bin/sh
+ids=$(sed -n 's/^ID: \([^,]*\),.*/\1/p')
+case "$ids" in *tx-7*) sleep 0.5 ;; esac
+printf '{"suggestions":['
+sep=
+for id in $ids; do
+	printf '%s{"transaction_id":"%s","category":"Groceries"}' "$sep" "$id"
+	sep=,
+done
+printf ']}'
+`
+
+func TestCategorizeWithLLMParallel(t *testing.T) {
+	moneyDir := t.TempDir()
+	script := filepath.Join(moneyDir, "llm.sh")
+	if err := os.WriteFile(script, []byte(fakeLLM), 0755); err != nil {
+		t.Fatalf("Failed to write fake LLM: %v", err)
+	}
+	t.Setenv("MONEY_DIR", moneyDir)
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("LLM_PROMPT_CMD", script)
+	t.Setenv("LLM_BATCH_SIZE", "2")
+	t.Setenv("LLM_PARALLELISM", "3")
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if _, err := db.SaveCategory("Groceries"); err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	for i := 1; i <= 7; i++ {
+		id := fmt.Sprintf("tx-%d", i)
+		if err := db.SaveTransaction(id, "acc-1", fmt.Sprintf("2024-05-0%dT00:00:00Z", i), -500, "Store "+id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	transactions, err := db.GetUncategorizedTransactions()
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err = categorizeWithLLM(db, transactions)
+	w.Close()
+	os.Stdout = stdout
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("categorizeWithLLM failed: %v", err)
+	}
+
+	remaining, err := db.GetUncategorizedTransactions()
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected every transaction categorized, %d left", len(remaining))
+	}
+
+	// The first batch finishes last, but its results come first
+	txnID := regexp.MustCompile(`tx-\d+`)
+	var applied []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.Contains(line, " → ") {
+			applied = append(applied, txnID.FindString(line))
+		}
+	}
+	var want []string
+	for _, txn := range transactions {
+		want = append(want, txn.ID)
+	}
+	if strings.Join(applied, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected results applied in batch order %v, got %v", want, applied)
+	}
+
+	calls, err := db.GetLLMCalls(10, false)
+	if err != nil {
+		t.Fatalf("Failed to get LLM calls: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Errorf("Expected 4 logged LLM calls, got %d", len(calls))
+	}
+}
+
+func TestTransactionsListCSV(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	table.SetDefaultFormat(table.FormatCSV)
+	t.Cleanup(func() { table.SetDefaultFormat(table.FormatText) })
+	defer dbutil.Close()
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveAccount("acc-2", "org-1", "Girokonto", "EUR", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-eur", "acc-2", "2024-04-01T00:00:00Z", -1999, "Bäckerei", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("tx-%d", i)
+		if err := db.SaveTransaction(id, "acc-1", fmt.Sprintf("2024-05-0%dT00:00:00Z", i), -6120, "Store "+id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	out := runCommand(t, TransactionsList, "--limit", "2")
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v:\n%s", err, out)
+	}
+	if len(records) != 3 {
+		t.Errorf("Expected a header and 2 rows with the paging hint left out, got %d records:\n%s", len(records), out)
+	}
+	for _, record := range records[1:] {
+		if record[3] != "-$61.20" {
+			t.Errorf("Expected the amount without padding, got %q", record[3])
+		}
+	}
+
+	// Transactions are shown in their own account's currency
+	out = runCommand(t, TransactionsList, "--account", "acc-2")
+	if !strings.Contains(out, "-€19.99") {
+		t.Errorf("Expected the EUR account's transaction in euros:\n%s", out)
+	}
+}
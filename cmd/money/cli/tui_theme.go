@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/money/pkg/config"
+)
+
+// tuiTheme holds the colors used by the interactive views, by role
+type tuiTheme struct {
+	Accent       lipgloss.Color // headers, borders, and the active tab
+	AccentText   lipgloss.Color // text drawn on an accent background
+	Muted        lipgloss.Color // instructions and hints
+	Status       lipgloss.Color // status messages and active filters
+	Highlight    lipgloss.Color // background of the row under the cursor
+	VisualCursor lipgloss.Color // cursor row background in visual mode
+	Selection    lipgloss.Color // text of rows selected in visual mode
+	InputBg      lipgloss.Color // background of inline text inputs
+	Expense      lipgloss.Color
+	Income       lipgloss.Color
+	BarTrack     lipgloss.Color // unfilled part of progress bars
+}
+
+var darkTheme = tuiTheme{
+	Accent:       "#00d7ff",
+	AccentText:   "#000",
+	Muted:        "#888",
+	Status:       "#ff0",
+	Highlight:    "#555",
+	VisualCursor: "#666",
+	Selection:    "#ffffff",
+	InputBg:      "#333",
+	Expense:      "#f64",
+	Income:       "#8c8",
+	BarTrack:     "#444",
+}
+
+// lightTheme keeps the same roles readable on light terminal backgrounds
+var lightTheme = tuiTheme{
+	Accent:       "#005f87",
+	AccentText:   "#fff",
+	Muted:        "#666",
+	Status:       "#875f00",
+	Highlight:    "#d0d0d0",
+	VisualCursor: "#bcbcbc",
+	Selection:    "#000000",
+	InputBg:      "#e4e4e4",
+	Expense:      "#af0000",
+	Income:       "#005f00",
+	BarTrack:     "#d0d0d0",
+}
+
+// theme is the active TUI theme, set by loadTUISettings
+var theme = darkTheme
+
+// themeRoles maps the role names used in MONEY_THEME_COLORS to theme fields
+func themeRoles(t *tuiTheme) map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"accent":        &t.Accent,
+		"accent_text":   &t.AccentText,
+		"muted":         &t.Muted,
+		"status":        &t.Status,
+		"highlight":     &t.Highlight,
+		"visual_cursor": &t.VisualCursor,
+		"selection":     &t.Selection,
+		"input_bg":      &t.InputBg,
+		"expense":       &t.Expense,
+		"income":        &t.Income,
+		"bar_track":     &t.BarTrack,
+	}
+}
+
+// buildTheme starts from the named base theme and applies color overrides
+func buildTheme(name string, colors map[string]string) (tuiTheme, error) {
+	var t tuiTheme
+	switch name {
+	case "", "dark":
+		t = darkTheme
+	case "light":
+		t = lightTheme
+	default:
+		return t, fmt.Errorf("unknown theme %q (expected dark or light)", name)
+	}
+
+	roles := themeRoles(&t)
+	for role, color := range colors {
+		field, exists := roles[role]
+		if !exists {
+			return t, fmt.Errorf("unknown theme color %q (expected one of %s)", role, strings.Join(sortedKeys(roles), ", "))
+		}
+		*field = lipgloss.Color(color)
+	}
+	return t, nil
+}
+
+// defaultKeyBindings maps each remappable action to the key the views
+// handle it with
+var defaultKeyBindings = map[string]string{
+	"up":                   "k",
+	"down":                 "j",
+	"left":                 "h",
+	"right":                "l",
+	"page_down":            "ctrl+f",
+	"page_up":              "ctrl+b",
+	"top":                  "g",
+	"bottom":               "G",
+	"open":                 "enter",
+	"back":                 "esc",
+	"categorize":           "e",
+	"uncategorize":         "u",
+	"undo":                 "ctrl+z",
+	"visual":               "v",
+	"search":               "/",
+	"next_match":           "n",
+	"prev_match":           "N",
+	"filter_uncategorized": "c",
+	"filter_account":       "a",
+	"filter_sign":          "s",
+	"filter_dates":         "d",
+	"clear_filters":        "x",
+	"sort":                 "o",
+	"reverse_sort":         "O",
+	"next_month":           "n",
+	"prev_month":           "p",
+	"reload":               "r",
+	"next_tab":             "tab",
+	"prev_tab":             "shift+tab",
+	"quit":                 "q",
+}
+
+// keyRemap translates custom keys to the default key of their action.
+// Default keys keep working unless a custom binding takes them over.
+type keyRemap map[string]string
+
+// keys is the active key remapping, set by loadTUISettings
+var keys = keyRemap{}
+
+// buildKeyRemap validates custom bindings of action to key
+func buildKeyRemap(bindings map[string]string) (keyRemap, error) {
+	remap := keyRemap{}
+	for action, key := range bindings {
+		defaultKey, exists := defaultKeyBindings[action]
+		if !exists {
+			return nil, fmt.Errorf("unknown key binding action %q (expected one of %s)", action, strings.Join(sortedKeys(defaultKeyBindings), ", "))
+		}
+		remap[key] = defaultKey
+	}
+	return remap, nil
+}
+
+// resolve returns the default key for key if it has been remapped
+func (r keyRemap) resolve(key string) string {
+	if defaultKey, exists := r[key]; exists {
+		return defaultKey
+	}
+	return key
+}
+
+// loadTUISettings applies the configured theme and keybindings
+func loadTUISettings() error {
+	cfg := config.New()
+
+	t, err := buildTheme(cfg.Theme, cfg.ThemeColors)
+	if err != nil {
+		return fmt.Errorf("invalid MONEY_THEME settings: %w", err)
+	}
+
+	remap, err := buildKeyRemap(cfg.KeyBindings)
+	if err != nil {
+		return fmt.Errorf("invalid MONEY_KEYS settings: %w", err)
+	}
+
+	theme = t
+	keys = remap
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
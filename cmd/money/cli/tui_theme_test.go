@@ -0,0 +1,46 @@
+package cli
+
+import "testing"
+
+func TestBuildTheme(t *testing.T) {
+	light, err := buildTheme("light", map[string]string{"accent": "#123456"})
+	if err != nil {
+		t.Fatalf("buildTheme() error: %v", err)
+	}
+	if light.Accent != "#123456" {
+		t.Errorf("Accent = %q, want override #123456", light.Accent)
+	}
+	if light.Highlight != lightTheme.Highlight {
+		t.Errorf("Highlight = %q, want light theme default %q", light.Highlight, lightTheme.Highlight)
+	}
+
+	if _, err := buildTheme("solarized", nil); err == nil {
+		t.Error("Expected error for unknown theme")
+	}
+	if _, err := buildTheme("dark", map[string]string{"sparkle": "#fff"}); err == nil {
+		t.Error("Expected error for unknown color role")
+	}
+}
+
+func TestBuildKeyRemap(t *testing.T) {
+	remap, err := buildKeyRemap(map[string]string{"down": "s", "categorize": "enter"})
+	if err != nil {
+		t.Fatalf("buildKeyRemap() error: %v", err)
+	}
+
+	tests := map[string]string{
+		"s":     "j", // custom key acts as the default
+		"enter": "e",
+		"j":     "j", // default keys keep working
+		"z":     "z", // unbound keys pass through
+	}
+	for key, want := range tests {
+		if got := remap.resolve(key); got != want {
+			t.Errorf("resolve(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	if _, err := buildKeyRemap(map[string]string{"teleport": "t"}); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
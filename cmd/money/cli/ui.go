@@ -0,0 +1,506 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/guptarohit/asciigraph"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func uiFlags(days *int) *flags.Set {
+	set := flags.New("money ui")
+	set.IntVar(days, "days", "d", "<number>", 1)
+	return set
+}
+
+var UI = &Z.Cmd{
+	Name:     "ui",
+	Aliases:  []string{"dashboard", "dash"},
+	Summary:  "Open a full-screen dashboard with balances, trends, budget, and transactions",
+	Usage:    uiFlags(new(int)).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Opens a full-screen terminal dashboard that brings the main views together
+in one place. Switch between tabs with tab/shift+tab, h/l, or the number
+keys 1-5:
+
+  1 Balances      account balances grouped by type, with net worth
+  2 Trends        net worth trend over the last --days days (default 30)
+  3 Budget        spent vs budgeted by category, with drill-down and
+                  inline recategorization (see 'money budget tui')
+  4 Transactions  the most recent transactions
+  5 Categorize    the manual categorization view
+
+Press r to reload data and q or ctrl+c to quit.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		days := 30
+		if err := uiFlags(&days).Parse(args); err != nil {
+			return err
+		}
+
+		return runDashboard(days)
+	},
+}
+
+const (
+	dashboardTabBalances = iota
+	dashboardTabTrends
+	dashboardTabBudget
+	dashboardTabTransactions
+	dashboardTabCategorize
+)
+
+var dashboardTabNames = []string{"Balances", "Trends", "Budget", "Transactions", "Categorize"}
+
+// dashboardRecentLimit caps how many transactions the Transactions tab keeps in memory.
+const dashboardRecentLimit = 200
+
+// dashboardData is a snapshot of everything the read-only dashboard tabs render.
+type dashboardData struct {
+	accounts      []database.Account
+	orgNames      map[string]string
+	accountNames  map[string]string
+	currencies    map[string]string // account ID to currency
+	categoryNames map[int]string
+	dates         []string
+	netWorth      []float64
+	recent        []database.Transaction
+}
+
+// DashboardModel is the bubbletea model behind `money ui`.
+type DashboardModel struct {
+	db         *database.DB
+	days       int
+	activeTab  int
+	data       *dashboardData
+	budget     tea.Model
+	categorize tea.Model
+	message    string
+	width      int
+	height     int
+}
+
+// loadDashboardData reads the balances, trend, and recent transaction data
+// for the dashboard.
+func loadDashboardData(db *database.DB, days int) (*dashboardData, error) {
+	data := &dashboardData{
+		orgNames:      make(map[string]string),
+		accountNames:  make(map[string]string),
+		currencies:    make(map[string]string),
+		categoryNames: make(map[int]string),
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	data.accounts = accounts
+	for _, account := range accounts {
+		data.accountNames[account.ID] = account.DisplayName()
+		data.currencies[account.ID] = account.Currency
+	}
+
+	orgs, err := db.GetOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organizations: %w", err)
+	}
+	for _, org := range orgs {
+		data.orgNames[org.ID] = org.Name
+	}
+
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, category := range categories {
+		data.categoryNames[category.ID] = category.Name
+	}
+	setCategoryStyles(categories)
+
+	history, err := db.GetAllBalanceHistory(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	dates, typeHistoryMap := dailyBalancesByType(history, accounts)
+	data.dates = dates
+	data.netWorth = netWorthSeries(dates, typeHistoryMap)
+
+	recent, err := db.GetTransactions(database.TransactionFilter{}, dashboardRecentLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	data.recent = recent
+
+	return data, nil
+}
+
+// NewDashboardModel loads the dashboard data and the embedded budget and
+// categorization panes.
+func NewDashboardModel(db *database.DB, days int) (*DashboardModel, error) {
+	data, err := loadDashboardData(db, days)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := NewBudgetModel(db)
+	if err != nil {
+		return nil, err
+	}
+
+	categorize, err := NewCategorizationModel(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DashboardModel{
+		db:         db,
+		days:       days,
+		data:       data,
+		budget:     budget,
+		categorize: categorize,
+	}, nil
+}
+
+func (m DashboardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		// Interactive panes sit below the tab bar, so give them less room
+		paneSize := tea.WindowSizeMsg{Width: msg.Width, Height: msg.Height - 2}
+		var budgetCmd, categorizeCmd tea.Cmd
+		m.budget, budgetCmd = m.budget.Update(paneSize)
+		m.categorize, categorizeCmd = m.categorize.Update(paneSize)
+		return m, tea.Batch(budgetCmd, categorizeCmd)
+
+	case tea.KeyMsg:
+		key := keys.resolve(msg.String())
+
+		// While an interactive pane is collecting text every key belongs to it
+		if paneCapturingInput(m.activePane()) {
+			return m.updateActivePane(msg)
+		}
+
+		switch key {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "tab":
+			m.activeTab = (m.activeTab + 1) % len(dashboardTabNames)
+			return m, nil
+		case "shift+tab":
+			m.activeTab = (m.activeTab + len(dashboardTabNames) - 1) % len(dashboardTabNames)
+			return m, nil
+		case "1", "2", "3", "4", "5":
+			m.activeTab = int(key[0] - '1')
+			return m, nil
+		}
+
+		if m.activePane() != nil {
+			return m.updateActivePane(msg)
+		}
+
+		switch key {
+		case "l", "right":
+			m.activeTab = (m.activeTab + 1) % len(dashboardTabNames)
+		case "h", "left":
+			m.activeTab = (m.activeTab + len(dashboardTabNames) - 1) % len(dashboardTabNames)
+		case "r":
+			data, err := loadDashboardData(m.db, m.days)
+			if err != nil {
+				m.message = fmt.Sprintf("Error reloading data: %v", err)
+			} else {
+				m.data = data
+				m.message = "Reloaded"
+			}
+		}
+		return m, nil
+	}
+
+	return m.updateActivePane(msg)
+}
+
+// activePane returns the interactive model behind the active tab, or nil
+// for the read-only tabs the dashboard renders itself.
+func (m DashboardModel) activePane() tea.Model {
+	switch m.activeTab {
+	case dashboardTabBudget:
+		return m.budget
+	case dashboardTabCategorize:
+		return m.categorize
+	}
+	return nil
+}
+
+// updateActivePane forwards msg to the active tab's interactive model.
+func (m DashboardModel) updateActivePane(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.activeTab {
+	case dashboardTabBudget:
+		m.budget, cmd = m.budget.Update(msg)
+	case dashboardTabCategorize:
+		m.categorize, cmd = m.categorize.Update(msg)
+	}
+	return m, cmd
+}
+
+// paneCapturingInput reports whether an interactive pane is in a text entry
+// mode and should receive every key press.
+func paneCapturingInput(model tea.Model) bool {
+	switch pane := model.(type) {
+	case CategorizationModel:
+		return pane.inputMode || pane.searchMode || pane.filterInputMode
+	case *CategorizationModel:
+		return pane.inputMode || pane.searchMode || pane.filterInputMode
+	case BudgetModel:
+		return pane.inputMode
+	case *BudgetModel:
+		return pane.inputMode
+	}
+	return false
+}
+
+func (m DashboardModel) View() string {
+	tabBar := renderDashboardTabs(m.activeTab)
+
+	if pane := m.activePane(); pane != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, tabBar, pane.View())
+	}
+
+	var content string
+	switch m.activeTab {
+	case dashboardTabBalances:
+		content = renderDashboardBalances(m.data)
+	case dashboardTabTrends:
+		content = renderDashboardTrends(m.data, m.days, m.width)
+	case dashboardTabTransactions:
+		content = renderDashboardTransactions(m.data, m.width, m.height-6)
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Render("tab/h/l or 1-5: switch view  |  r: reload  |  q: quit")
+
+	status := lipgloss.NewStyle().
+		Foreground(theme.Status).
+		Render(m.message)
+
+	return lipgloss.NewStyle().Margin(0, 1).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabBar,
+			"",
+			content,
+			"",
+			help,
+			status,
+		),
+	)
+}
+
+// renderDashboardTabs draws the tab bar with the active tab highlighted.
+func renderDashboardTabs(activeTab int) string {
+	activeStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.AccentText).
+		Background(theme.Accent).
+		Padding(0, 1)
+	inactiveStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Padding(0, 1)
+
+	var tabs []string
+	for i, name := range dashboardTabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i == activeTab {
+			tabs = append(tabs, activeStyle.Render(label))
+		} else {
+			tabs = append(tabs, inactiveStyle.Render(label))
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+func renderDashboardBalances(data *dashboardData) string {
+	if len(data.accounts) == 0 {
+		return "No accounts found. Run 'money fetch' to sync your financial data."
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+
+	accountsByType := make(map[string][]database.Account)
+	var netWorth int64
+	for _, account := range data.accounts {
+		accountType := "unset"
+		if account.AccountType != nil {
+			accountType = *account.AccountType
+		}
+		accountsByType[accountType] = append(accountsByType[accountType], account)
+		netWorth += account.Balance
+	}
+
+	var b strings.Builder
+	typeOrder := []string{"checking", "savings", "credit", "investment", "crypto", "loan", "property", "other", "unset"}
+	for _, accountType := range typeOrder {
+		accounts, exists := accountsByType[accountType]
+		if !exists {
+			continue
+		}
+
+		var typeTotal int64
+		for _, account := range accounts {
+			typeTotal += account.Balance
+		}
+
+		b.WriteString(titleStyle.Render(icon(getTypeIcon(accountType)+" ") + getTypeDisplayName(accountType)))
+		b.WriteString(fmt.Sprintf("  %s\n", format.Currency(typeTotal, format.BaseCurrency())))
+		for _, account := range accounts {
+			institution := data.orgNames[account.OrgID]
+			if institution == "" {
+				institution = account.OrgID
+			}
+			b.WriteString(fmt.Sprintf("  %-32s %-24s %16s\n",
+				truncateString(account.DisplayName(), 32),
+				truncateString(institution, 24),
+				format.Currency(account.Balance, account.Currency)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(titleStyle.Render("Net Worth"))
+	b.WriteString(fmt.Sprintf("  %s", colorizeAmount(netWorth, format.Currency(netWorth, format.BaseCurrency()))))
+	return b.String()
+}
+
+func renderDashboardTrends(data *dashboardData, days, width int) string {
+	if len(data.dates) < 2 {
+		return "Not enough historical data points to generate a trend graph. Run 'money fetch' over a few days to collect history."
+	}
+
+	graphWidth := width - 16
+	if graphWidth < 20 {
+		graphWidth = 70
+	}
+
+	graph := asciigraph.Plot(data.netWorth,
+		asciigraph.Height(12),
+		asciigraph.Width(graphWidth),
+		graphColors(asciigraph.Cyan),
+		asciigraph.Caption(fmt.Sprintf("Net Worth (Last %d Days)", days)),
+	)
+
+	first := data.netWorth[0]
+	last := data.netWorth[len(data.netWorth)-1]
+	change := int64((last - first) * 100)
+	summary := fmt.Sprintf("%s → %s  (%s)",
+		format.DateForDisplay(data.dates[0]),
+		format.DateForDisplay(data.dates[len(data.dates)-1]),
+		colorizeAmount(change, format.Currency(change, format.BaseCurrency())))
+
+	return lipgloss.JoinVertical(lipgloss.Left, graph, "", summary)
+}
+
+func renderDashboardTransactions(data *dashboardData, width, height int) string {
+	if len(data.recent) == 0 {
+		return "No transactions found. Run 'money fetch' to sync your financial data."
+	}
+
+	if height < 5 {
+		height = 20
+	}
+	rows := data.recent
+	if len(rows) > height {
+		rows = rows[:height]
+	}
+
+	descriptionWidth := width - 80
+	if descriptionWidth < 20 {
+		descriptionWidth = 30
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("%-12s %-20s %14s  %-*s  %s",
+		"Date", "Account", "Amount", descriptionWidth, "Description", "Category")))
+	b.WriteString("\n")
+	for _, tx := range rows {
+		date := format.PostedDate(tx.Posted)
+
+		category := "Uncategorized"
+		if tx.CategoryID != nil {
+			if name, exists := data.categoryNames[*tx.CategoryID]; exists {
+				category = name
+			}
+		}
+
+		b.WriteString(fmt.Sprintf("%-12s %-20s %s  %-*s  %s\n",
+			date,
+			truncateString(data.accountNames[tx.AccountID], 20),
+			colorizeAmount(tx.Amount, fmt.Sprintf("%14s", format.Currency(tx.Amount, data.currencies[tx.AccountID]))),
+			descriptionWidth,
+			truncateString(tx.DisplayDescription(), descriptionWidth),
+			colorizeCategory(category)))
+	}
+
+	return b.String()
+}
+
+// renderBar draws a horizontal bar in barColor scaled so that maxValue fills width cells.
+func renderBar(value, maxValue int64, width int, barColor lipgloss.Color) string {
+	if maxValue <= 0 || value <= 0 || width <= 0 {
+		return ""
+	}
+	filled := int(value * int64(width) / maxValue)
+	if filled == 0 {
+		filled = 1
+	}
+	return lipgloss.NewStyle().Foreground(barColor).Render(strings.Repeat("█", filled))
+}
+
+// truncateString shortens s to at most width runes, adding an ellipsis when cut.
+func truncateString(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func runDashboard(days int) error {
+	if err := loadTUISettings(); err != nil {
+		return err
+	}
+
+	db, err := dbutil.Open()
+	if err != nil {
+		return err
+	}
+
+	model, err := NewDashboardModel(db, days)
+	if err != nil {
+		return err
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// keyMsg builds the key press bubbletea would deliver for key.
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"Groceries", 20, "Groceries"},
+		{"Groceries", 9, "Groceries"},
+		{"Groceries", 5, "Groc…"},
+		{"Café Olé", 5, "Café…"},
+		{"abc", 1, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := truncateString(tt.input, tt.width)
+			if result != tt.expected {
+				t.Errorf("truncateString(%q, %d) = %q; want %q", tt.input, tt.width, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDashboardTabSwitching(t *testing.T) {
+	m := DashboardModel{data: &dashboardData{}, budget: BudgetModel{}, categorize: CategorizationModel{}}
+
+	model, _ := m.Update(keyMsg("tab"))
+	if got := model.(DashboardModel).activeTab; got != dashboardTabTrends {
+		t.Errorf("tab moved to %d; want %d", got, dashboardTabTrends)
+	}
+
+	model, _ = model.Update(keyMsg("shift+tab"))
+	model, _ = model.Update(keyMsg("shift+tab"))
+	if got := model.(DashboardModel).activeTab; got != dashboardTabCategorize {
+		t.Errorf("shift+tab wrapped to %d; want %d", got, dashboardTabCategorize)
+	}
+
+	model, _ = model.Update(keyMsg("3"))
+	if got := model.(DashboardModel).activeTab; got != dashboardTabBudget {
+		t.Errorf("3 selected %d; want %d", got, dashboardTabBudget)
+	}
+}
+
+func TestDashboardBalancesPlain(t *testing.T) {
+	t.Cleanup(func() { plainOutput = false })
+
+	checking := "checking"
+	data := &dashboardData{
+		accounts: []database.Account{{ID: "acct-1", Name: "Everyday", Balance: 12345, AccountType: &checking}},
+		orgNames: map[string]string{},
+	}
+	if got := renderDashboardBalances(data); !strings.Contains(got, "💰") {
+		t.Errorf("Expected the account type icon, got:\n%s", got)
+	}
+	plainOutput = true
+	if got := renderDashboardBalances(data); strings.Contains(got, "💰") {
+		t.Errorf("Expected no icon with --plain, got:\n%s", got)
+	}
+}
@@ -2,14 +2,16 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
@@ -28,7 +30,8 @@ Automatically updates the money CLI to the latest version from GitHub releases.
 This command:
 - Checks GitHub releases for newer versions
 - Downloads the appropriate binary for your platform
-- Replaces the current binary with the latest version
+- Verifies it against the SHA-256 checksum published with the release
+- Replaces the running binary with the latest version
 - Requires write permissions to the directory containing the money binary
 
 Examples:
@@ -93,17 +96,23 @@ func getLatestRelease() (*github.RepositoryRelease, error) {
 	return release, nil
 }
 
-// updateBinary downloads and replaces the current binary with the latest version
+// updateBinary downloads the latest release binary for this platform,
+// verifies it against the release's SHA-256 checksum, and swaps it in place
+// of the running executable
 func updateBinary() error {
 	release, err := getLatestRelease()
 	if err != nil {
 		return err
 	}
 
-	// Find the current binary path
-	path, err := exec.LookPath("money")
+	// Find the binary that is running, resolving symlinks so the real file is replaced
+	path, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("could not find money binary in PATH: %w", err)
+		return fmt.Errorf("could not find the money binary: %w", err)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("could not resolve the money binary: %w", err)
 	}
 
 	// Determine the asset name based on platform
@@ -111,42 +120,57 @@ func updateBinary() error {
 	if runtime.GOOS == "windows" {
 		assetName += ".exe"
 	}
+	checksumName := assetName + ".sha256"
 
-	// Find the correct asset
-	var downloadURL string
+	// Find the binary and its checksum
+	var downloadURL, checksumURL string
 	for _, asset := range release.Assets {
-		if asset.Name != nil && *asset.Name == assetName {
+		if asset.Name == nil || asset.BrowserDownloadURL == nil {
+			continue
+		}
+		switch *asset.Name {
+		case assetName:
 			downloadURL = *asset.BrowserDownloadURL
-			break
+		case checksumName:
+			checksumURL = *asset.BrowserDownloadURL
 		}
 	}
 
 	if downloadURL == "" {
 		return fmt.Errorf("no binary found for platform %s-%s", runtime.GOOS, runtime.GOARCH)
 	}
+	if checksumURL == "" {
+		return fmt.Errorf("release has no %s checksum; refusing to install an unverified binary", checksumName)
+	}
+
+	// Download the checksum first so a bad release fails before the binary is fetched
+	checksumFile, err := download(checksumURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksum: %w", err)
+	}
+	expected, err := parseChecksum(checksumFile)
+	if err != nil {
+		return err
+	}
 
 	// Download the binary
-	resp, err := http.Get(downloadURL)
+	binary, err := download(downloadURL)
 	if err != nil {
 		return fmt.Errorf("failed to download binary: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download binary: HTTP %d", resp.StatusCode)
+	if err := verifyChecksum(binary, expected); err != nil {
+		return err
 	}
 
-	// Create a temporary file
-	tmpDir := filepath.Dir(path)
-	tmpFile, err := os.CreateTemp(tmpDir, "money-update-*")
+	// Write to a temporary file next to the binary so the rename is atomic
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "money-update-*")
 	if err != nil {
 		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 
-	// Copy the downloaded binary to the temporary file
-	_, err = io.Copy(tmpFile, resp.Body)
-	if err != nil {
+	if _, err := tmpFile.Write(binary); err != nil {
+		tmpFile.Close()
 		return fmt.Errorf("failed to write binary: %w", err)
 	}
 
@@ -169,4 +193,42 @@ func updateBinary() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// download fetches url and returns the response body
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseChecksum reads the hex digest from a sha256sum-style file,
+// "<digest>  <file name>"
+func parseChecksum(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", errors.New("checksum file is empty")
+	}
+
+	digest := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid SHA-256 checksum %q", fields[0])
+	}
+	return digest, nil
+}
+
+// verifyChecksum checks that data hashes to the expected hex SHA-256 digest
+func verifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
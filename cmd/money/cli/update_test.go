@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	binary := []byte("money binary")
+	sum := sha256.Sum256(binary)
+	digest := hex.EncodeToString(sum[:])
+
+	expected, err := parseChecksum([]byte(digest + "  money-linux-amd64\n"))
+	if err != nil {
+		t.Fatalf("parseChecksum: %v", err)
+	}
+	if err := verifyChecksum(binary, expected); err != nil {
+		t.Errorf("verifyChecksum: %v", err)
+	}
+	if err := verifyChecksum([]byte("tampered"), expected); err == nil {
+		t.Error("expected checksum mismatch for tampered binary")
+	}
+
+	if _, err := parseChecksum([]byte("")); err == nil {
+		t.Error("expected error for empty checksum file")
+	}
+	if _, err := parseChecksum([]byte("not-a-digest  money-linux-amd64")); err == nil {
+		t.Error("expected error for malformed digest")
+	}
+}
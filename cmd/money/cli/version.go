@@ -6,17 +6,38 @@ import (
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 
+	"github.com/arjungandhi/money/internal/flags"
 	"github.com/arjungandhi/money/pkg/version"
 )
 
+func versionFlags(short *bool) *flags.Set {
+	set := flags.New("money version")
+	set.BoolVar(short, "short", "")
+	return set
+}
+
 var Version = &Z.Cmd{
 	Name:        "version",
 	Aliases:     []string{"v", "ver"},
 	Summary:     "Display the current version of the money CLI",
-	Description: `Shows the build version (defaults to "dev" for development builds).`,
+	Usage:       versionFlags(new(bool)).Usage(),
+	Description: `
+Shows the build version (defaults to "dev" for development builds) with the
+commit and build date it was made from. Use --short to print only the
+version, e.g. for scripts.
+`,
 	Commands:    []*Z.Cmd{help.Cmd},
 	Call: func(cmd *Z.Cmd, args ...string) error {
-		fmt.Println(version.Version)
+		short := false
+		if err := versionFlags(&short).Parse(args); err != nil {
+			return err
+		}
+
+		if short {
+			fmt.Println(version.Version)
+			return nil
+		}
+		fmt.Println(version.String())
 		return nil
 	},
 }
\ No newline at end of file
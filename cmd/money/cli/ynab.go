@@ -0,0 +1,538 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/money/internal/dbutil"
+	"github.com/arjungandhi/money/internal/flags"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/table"
+	"github.com/arjungandhi/money/pkg/ynab"
+)
+
+var YNAB = &Z.Cmd{
+	Name:    "ynab",
+	Summary: "Sync cleared transactions and categories with a YNAB budget",
+	Description: `
+Keeps a YNAB budget in step with money, for moving between the two or
+sharing the books with someone who budgets in YNAB. Transactions are
+synced for the accounts you map to YNAB accounts; categories are synced
+when they're mapped, or when both sides have one with the same name.
+
+Set up a personal access token in YNAB under Account Settings > Developer
+Settings, then:
+
+  money config set ynab_token <token>
+  money ynab accounts
+  money ynab map account <account-id> "<ynab-account>"
+  money ynab push
+  money ynab pull
+
+The budget last opened in YNAB is used unless ynab_budget names another.
+A transaction pushed or pulled is remembered, so syncing again doesn't
+copy it twice, and one already on the other side with the same amount
+within 3 days is matched instead of copied. Categories only fill in where
+the other side has none, unless --overwrite is given.
+`,
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		YNABAccounts,
+		YNABCategories,
+		YNABMap,
+		YNABUnmap,
+		YNABPush,
+		YNABPull,
+	},
+}
+
+// newYNABClient returns a client for the configured YNAB budget
+func newYNABClient(db *database.DB) (*ynab.Client, error) {
+	cfg := db.GetConfig()
+	if cfg.YNABToken == "" {
+		return nil, fmt.Errorf("YNAB isn't set up: create a personal access token in YNAB under Account Settings > Developer Settings, then run 'money config set ynab_token <token>'")
+	}
+	return ynab.NewClient(cfg.YNABToken, cfg.YNABBudget), nil
+}
+
+var YNABAccounts = &Z.Cmd{
+	Name:     "accounts",
+	Summary:  "List the YNAB budget's accounts and the accounts mapped to them",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			ynabAccounts, err := client.Accounts()
+			if err != nil {
+				return err
+			}
+			links, err := db.GetYNABLinks()
+			if err != nil {
+				return err
+			}
+			accounts, err := db.GetAccounts()
+			if err != nil {
+				return fmt.Errorf("failed to get accounts: %w", err)
+			}
+
+			mapped := make(map[string]string, len(links.Accounts))
+			for _, account := range accounts {
+				if ynabID, ok := links.Accounts[account.ID]; ok {
+					mapped[ynabID] = fmt.Sprintf("%s (%s)", account.DisplayName(), account.ID)
+				}
+			}
+
+			if len(ynabAccounts) == 0 {
+				fmt.Println("The YNAB budget has no accounts.")
+				return nil
+			}
+
+			t := table.New("YNAB Account", "Type", "Balance", "Mapped To", "YNAB ID")
+			t.SetColumnTypes(table.ColumnText, table.ColumnText, table.ColumnCurrency, table.ColumnText, table.ColumnText)
+			for _, account := range ynabAccounts {
+				name := account.Name
+				if account.Closed {
+					name += " (closed)"
+				}
+				t.AddRow(name, account.Type, format.Currency(account.Balance/10, format.BaseCurrency()), mapped[account.ID], account.ID)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var YNABCategories = &Z.Cmd{
+	Name:     "categories",
+	Summary:  "List the YNAB budget's categories and the categories synced with them",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			ynabCategories, err := client.Categories()
+			if err != nil {
+				return err
+			}
+			matched, err := ynab.MatchCategories(db, ynabCategories)
+			if err != nil {
+				return err
+			}
+			links, err := db.GetYNABLinks()
+			if err != nil {
+				return err
+			}
+			categories, err := db.GetCategories()
+			if err != nil {
+				return err
+			}
+			names := make(map[int]string, len(categories))
+			for _, c := range categories {
+				names[c.ID] = c.Name
+			}
+
+			t := table.New("Group", "YNAB Category", "Synced With", "YNAB ID")
+			for _, c := range ynabCategories {
+				synced := ""
+				if categoryID, ok := matched[c.ID]; ok {
+					synced = names[categoryID]
+					if links.Categories[categoryID] != c.ID {
+						synced += " (same name)"
+					}
+				}
+				name := c.Name
+				if c.Hidden {
+					name += " (hidden)"
+				}
+				t.AddRow(c.Group, name, synced, c.ID)
+			}
+			return t.Render()
+		})
+	},
+}
+
+var YNABMap = &Z.Cmd{
+	Name:    "map",
+	Summary: "Map accounts and categories to YNAB's, or list the mappings",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		YNABMapAccount,
+		YNABMapCategory,
+	},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) > 0 {
+			return help.Cmd.Call(cmd, args...)
+		}
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			links, err := db.GetYNABLinks()
+			if err != nil {
+				return err
+			}
+			if len(links.Accounts) == 0 && len(links.Categories) == 0 {
+				fmt.Println("Nothing is mapped to YNAB. Map an account with 'money ynab map account <account-id> <ynab-account>'.")
+				return nil
+			}
+
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			ynabAccounts, err := client.Accounts()
+			if err != nil {
+				return err
+			}
+			ynabCategories, err := client.Categories()
+			if err != nil {
+				return err
+			}
+			ynabNames := make(map[string]string)
+			for _, a := range ynabAccounts {
+				ynabNames[a.ID] = a.Name
+			}
+			for _, c := range ynabCategories {
+				ynabNames[c.ID] = c.Group + ": " + c.Name
+			}
+			// Mappings to accounts or categories deleted in YNAB show their ID
+			ynabName := func(id string) string {
+				if name, ok := ynabNames[id]; ok {
+					return name
+				}
+				return id + " (not in YNAB)"
+			}
+
+			if len(links.Accounts) > 0 {
+				accounts, err := db.GetAccounts()
+				if err != nil {
+					return fmt.Errorf("failed to get accounts: %w", err)
+				}
+				sort.Slice(accounts, func(i, j int) bool {
+					return accounts[i].DisplayName() < accounts[j].DisplayName()
+				})
+				t := table.New("Account", "ID", "YNAB Account")
+				for _, account := range accounts {
+					if ynabID, ok := links.Accounts[account.ID]; ok {
+						t.AddRow(account.DisplayName(), account.ID, ynabName(ynabID))
+					}
+				}
+				if err := t.Render(); err != nil {
+					return err
+				}
+			}
+
+			if len(links.Categories) > 0 {
+				if len(links.Accounts) > 0 {
+					fmt.Println()
+				}
+				categories, err := db.GetCategories()
+				if err != nil {
+					return err
+				}
+				setCategoryStyles(categories)
+				t := table.New("Category", "YNAB Category")
+				for _, c := range categories {
+					if ynabID, ok := links.Categories[c.ID]; ok {
+						t.AddRow(colorizeCategory(c.Name), ynabName(ynabID))
+					}
+				}
+				if err := t.Render(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+var YNABMapAccount = &Z.Cmd{
+	Name:     "account",
+	Summary:  "Sync an account's transactions with a YNAB account",
+	Usage:    "account <account-id> <ynab-account>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Maps an account to the YNAB account, by name or ID as listed by
+'money ynab accounts', that its transactions are pushed to and pulled
+from. Each YNAB account can be mapped to one account.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("usage: money ynab map %s", cmd.Usage)
+		}
+		// Join remaining args as the YNAB account to support multi-word names
+		ynabAccountName := strings.Join(args[1:], " ")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			account, err := db.GetAccountByID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			ynabAccounts, err := client.Accounts()
+			if err != nil {
+				return err
+			}
+			ynabAccount, err := ynab.FindAccount(ynabAccounts, ynabAccountName)
+			if err != nil {
+				return err
+			}
+
+			links, err := db.GetYNABLinks()
+			if err != nil {
+				return err
+			}
+			for accountID, ynabID := range links.Accounts {
+				if ynabID == ynabAccount.ID && accountID != account.ID {
+					return fmt.Errorf("YNAB account %s is already mapped to account %s; unmap it first with 'money ynab unmap account %s'", ynabAccount.Name, accountID, accountID)
+				}
+			}
+
+			if err := db.SetYNABAccount(account.ID, ynabAccount.ID); err != nil {
+				return err
+			}
+			fmt.Printf("%s will sync with YNAB account %s\n", account.DisplayName(), ynabAccount.Name)
+			return nil
+		})
+	},
+}
+
+var YNABMapCategory = &Z.Cmd{
+	Name:     "category",
+	Summary:  "Sync a category with a YNAB category",
+	Usage:    "category <category> <ynab-category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Maps a category to a YNAB category, by name, "Group: Name" or ID as listed
+by 'money ynab categories', for categories whose names differ. Quote names
+with spaces. Categories with the same name on both sides are synced
+without a mapping. Each YNAB category can be mapped to one category.
+
+Example:
+  money ynab map category "Dining Out" "Eating Out"
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: money ynab map %s", cmd.Usage)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			ynabCategories, err := client.Categories()
+			if err != nil {
+				return err
+			}
+			ynabCategory, err := ynab.FindCategory(ynabCategories, args[1])
+			if err != nil {
+				return err
+			}
+
+			links, err := db.GetYNABLinks()
+			if err != nil {
+				return err
+			}
+			for categoryID, ynabID := range links.Categories {
+				if ynabID == ynabCategory.ID && categoryID != category.ID {
+					return fmt.Errorf("YNAB category %s is already mapped to another category; unmap it first", ynabCategory.Name)
+				}
+			}
+
+			if err := db.SetYNABCategory(category.ID, ynabCategory.ID); err != nil {
+				return err
+			}
+			fmt.Printf("%s will sync with YNAB category %s: %s\n", category.Name, ynabCategory.Group, ynabCategory.Name)
+			return nil
+		})
+	},
+}
+
+var YNABUnmap = &Z.Cmd{
+	Name:    "unmap",
+	Summary: "Stop syncing an account or category with YNAB",
+	Commands: []*Z.Cmd{
+		help.Cmd,
+		YNABUnmapAccount,
+		YNABUnmapCategory,
+	},
+}
+
+var YNABUnmapAccount = &Z.Cmd{
+	Name:     "account",
+	Summary:  "Stop syncing an account's transactions with YNAB",
+	Usage:    "account <account-id>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: money ynab unmap %s", cmd.Usage)
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			if err := db.ClearYNABAccount(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Account %s will no longer sync with YNAB\n", args[0])
+			return nil
+		})
+	},
+}
+
+var YNABUnmapCategory = &Z.Cmd{
+	Name:     "category",
+	Summary:  "Remove a category's YNAB mapping",
+	Usage:    "category <category>",
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: money ynab unmap %s", cmd.Usage)
+		}
+		// Join args to support multi-word names
+		categoryName := strings.Join(args, " ")
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			category, err := db.GetCategoryByName(categoryName)
+			if err != nil {
+				return err
+			}
+			if err := db.ClearYNABCategory(category.ID); err != nil {
+				return err
+			}
+			fmt.Printf("%s is no longer mapped to a YNAB category\n", category.Name)
+			return nil
+		})
+	},
+}
+
+func ynabSyncFlags(command string, opts *ynab.Options) *flags.Set {
+	set := flags.New("money ynab " + command)
+	set.Func("since", "s", "YYYY-MM-DD", dateFlag(&opts.Since))
+	set.BoolVar(&opts.Overwrite, "overwrite", "")
+	if command == "pull" {
+		set.BoolVar(&opts.Import, "import", "")
+	}
+	return set
+}
+
+// parseYNABSyncFlags parses push or pull's flags, defaulting --since to
+// ynab.DefaultSinceDays ago
+func parseYNABSyncFlags(command string, args []string) (ynab.Options, error) {
+	var opts ynab.Options
+	if err := ynabSyncFlags(command, &opts).Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Since == "" {
+		opts.Since = format.Now().AddDate(0, 0, -ynab.DefaultSinceDays).Format("2006-01-02")
+	}
+	return opts, nil
+}
+
+// printYNABResult prints what a push or pull left for the user to look at
+func printYNABResult(result *ynab.Result, side string) {
+	if result.Conflicts > 0 {
+		fmt.Printf("%d transaction(s) have a different category in %s and were left alone; --overwrite replaces them\n", result.Conflicts, side)
+	}
+	if len(result.Unmapped) > 0 {
+		fmt.Printf("Categories without a counterpart in %s: %s\n", side, strings.Join(result.Unmapped, ", "))
+		fmt.Println("Map them with 'money ynab map category <category> <ynab-category>'.")
+	}
+}
+
+var YNABPush = &Z.Cmd{
+	Name:     "push",
+	Summary:  "Copy posted transactions and their categories to YNAB",
+	Usage:    "push " + ynabSyncFlags("push", &ynab.Options{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Copies posted transactions in the mapped accounts, from the last 30 days
+or since --since, to YNAB as cleared transactions waiting for approval,
+with their category where it's synced. Transactions YNAB already has,
+pushed before or brought in by its own bank import, are matched rather
+than copied, and get money's category where they have none in YNAB.
+--overwrite also replaces YNAB categories that differ.
+
+Internal categories like Transfers are pushed without a category, since
+YNAB records transfers with a transfer payee instead.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		opts, err := parseYNABSyncFlags("push", args)
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			result, err := ynab.Push(db, client, opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Pushed transactions since %s: %d created, %d matched, %d categories set in YNAB\n",
+				opts.Since, result.Created, result.Linked, result.Categorized)
+			printYNABResult(result, "YNAB")
+			return nil
+		})
+	},
+}
+
+var YNABPull = &Z.Cmd{
+	Name:     "pull",
+	Summary:  "Bring categories and cleared transactions back from YNAB",
+	Usage:    "pull " + ynabSyncFlags("pull", &ynab.Options{}).Usage(),
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+Reads cleared transactions in the mapped YNAB accounts, from the last 30
+days or since --since, and matches each with its transaction in money.
+Categories set in YNAB are copied to transactions that have none in
+money; --overwrite also replaces categories that differ. Months closed
+with 'money close' are left alone.
+
+YNAB transactions that match nothing in money, such as cash spending
+entered by hand, are counted; --import adds them to the mapped account.
+`,
+	Call: func(cmd *Z.Cmd, args ...string) error {
+		opts, err := parseYNABSyncFlags("pull", args)
+		if err != nil {
+			return err
+		}
+
+		return dbutil.WithDatabase(func(db *database.DB) error {
+			client, err := newYNABClient(db)
+			if err != nil {
+				return err
+			}
+			result, err := ynab.Pull(db, client, opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Pulled transactions since %s: %d matched, %d imported, %d categories set\n",
+				opts.Since, result.Linked, result.Created, result.Categorized)
+			if result.Unmatched > 0 {
+				fmt.Printf("%d cleared YNAB transaction(s) aren't in money; --import adds them\n", result.Unmatched)
+			}
+			if result.Closed > 0 {
+				fmt.Printf("%d category change(s) were skipped because their month is closed\n", result.Closed)
+			}
+			printYNABResult(result, "money")
+			return nil
+		})
+	},
+}
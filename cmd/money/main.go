@@ -5,5 +5,5 @@ import (
 )
 
 func main() {
-	cli.Cmd.Run()
+	cli.Run()
 }
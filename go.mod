@@ -3,6 +3,7 @@ module github.com/arjungandhi/money
 go 1.25
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/charmbracelet/bubbles v0.11.0
 	github.com/charmbracelet/bubbletea v0.21.0
 	github.com/charmbracelet/lipgloss v0.5.0
@@ -10,8 +11,13 @@ require (
 	github.com/fatih/color v1.18.0
 	github.com/google/go-github/v52 v52.0.0
 	github.com/guptarohit/asciigraph v0.7.3
+	github.com/mattn/go-runewidth v0.0.13
+	github.com/muesli/termenv v0.11.1-0.20220212125758-44cd13922739
 	github.com/rwxrob/bonzai v0.20.10
 	github.com/rwxrob/help v0.7.2
+	golang.org/x/image v0.25.0
+	golang.org/x/term v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.38.2
 )
 
@@ -27,11 +33,9 @@ require (
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/muesli/ansi v0.0.0-20211018074035-2e021307bc4b // indirect
 	github.com/muesli/cancelreader v0.2.0 // indirect
 	github.com/muesli/reflow v0.3.0 // indirect
-	github.com/muesli/termenv v0.11.1-0.20220212125758-44cd13922739 // indirect
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
@@ -47,7 +51,6 @@ require (
 	golang.org/x/net v0.9.0 // indirect
 	golang.org/x/oauth2 v0.7.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
-	golang.org/x/term v0.7.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
 	modernc.org/libc v1.66.3 // indirect
@@ -1,12 +1,78 @@
+// Package dbutil keeps one database handle open for the life of the process,
+// so commands and TUIs don't reopen the database and rerun the migration
+// check for every action.
 package dbutil
 
-import "github.com/arjungandhi/money/pkg/database"
+import (
+	"sync"
 
+	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+var (
+	mu         sync.Mutex
+	shared     *database.DB
+	sharedPath string
+
+	// owners limits every handle Open returns to these owners' accounts
+	owners []string
+)
+
+// SetOwners limits the handles Open returns to the accounts of owners, for
+// the --owner global flag. No owners reads every account.
+func SetOwners(names []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	owners = names
+}
+
+// Open returns the shared handle to the configured database, opening it and
+// running migrations on first use. The handle is reopened when the database
+// path changes, like after switching profiles, and is scoped to the owners
+// set with SetOwners. Callers must not close it; use Close.
+func Open() (*database.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cfg := config.New()
+	path := cfg.DBPath()
+	if shared != nil && sharedPath == path {
+		return shared.ForOwners(owners), nil
+	}
+	if shared != nil {
+		shared.Close()
+		shared = nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	shared = db
+	sharedPath = path
+	return shared.ForOwners(owners), nil
+}
+
+// Close closes the shared handle, if one is open. The next Open reopens it.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if shared == nil {
+		return nil
+	}
+	err := shared.Close()
+	shared = nil
+	sharedPath = ""
+	return err
+}
+
+// WithDatabase calls fn with the shared database handle
 func WithDatabase(fn func(*database.DB) error) error {
-	db, err := database.New()
+	db, err := Open()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 	return fn(db)
 }
@@ -0,0 +1,85 @@
+package dbutil
+
+import (
+	"os"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestOpenSharesHandle(t *testing.T) {
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+	defer Close()
+
+	first, err := Open()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	var second *database.DB
+	err = WithDatabase(func(db *database.DB) error {
+		second = db
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithDatabase failed: %v", err)
+	}
+	if first != second {
+		t.Error("Expected WithDatabase to use the shared handle")
+	}
+
+	// The handle must still be usable after WithDatabase returns
+	if _, err := first.GetAccounts(); err != nil {
+		t.Errorf("Expected the shared handle to stay open: %v", err)
+	}
+
+	// Pointing at another money directory reopens
+	os.Setenv("MONEY_DIR", t.TempDir())
+	third, err := Open()
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if third == first {
+		t.Error("Expected a new handle after the database path changed")
+	}
+
+	if err := Close(); err != nil {
+		t.Fatalf("Failed to close database: %v", err)
+	}
+	fourth, err := Open()
+	if err != nil {
+		t.Fatalf("Failed to open database after Close: %v", err)
+	}
+	if fourth == third {
+		t.Error("Expected a new handle after Close")
+	}
+}
+
+func TestOpenScopesToOwners(t *testing.T) {
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+	defer Close()
+
+	SetOwners([]string{"me"})
+	defer SetOwners(nil)
+
+	db, err := Open()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if owners := db.Owners(); len(owners) != 1 || owners[0] != "me" {
+		t.Errorf("Expected a handle scoped to me, got %v", owners)
+	}
+
+	SetOwners(nil)
+	db, err = Open()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if owners := db.Owners(); owners != nil {
+		t.Errorf("Expected an unscoped handle, got %v", owners)
+	}
+}
@@ -0,0 +1,240 @@
+// Package flags parses the flags of leaf commands. Flags are declared once,
+// with a long name, an optional short name and a value placeholder, and the
+// same declarations reject typos, validate values and generate the usage
+// line shown in errors and completions.
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Flag is one declared flag
+type Flag struct {
+	Name        string // long name without dashes, e.g. days
+	Short       string // one-letter short name without the dash, or ""
+	Placeholder string // value placeholder for usage, e.g. <number>; "" for switches
+
+	set  func(value string) error
+	seen bool
+}
+
+// names returns how the flag is written in usage, e.g. --days|-d
+func (f *Flag) names() string {
+	if f.Short == "" {
+		return "--" + f.Name
+	}
+	return "--" + f.Name + "|-" + f.Short
+}
+
+// Set is the flags and positional arguments a command accepts
+type Set struct {
+	command    string
+	flags      []*Flag
+	positional []string
+	args       []string
+}
+
+// New returns an empty set for command, the full command name shown in
+// usage errors, e.g. "money balance"
+func New(command string) *Set {
+	return &Set{command: command}
+}
+
+func (s *Set) add(f *Flag) {
+	s.flags = append(s.flags, f)
+}
+
+// StringVar declares a flag whose value is stored in p. The value p holds
+// beforehand is the default.
+func (s *Set) StringVar(p *string, name, short, placeholder string) {
+	s.add(&Flag{Name: name, Short: short, Placeholder: placeholder, set: func(value string) error {
+		*p = value
+		return nil
+	}})
+}
+
+// IntVar declares a flag whose value must be a whole number of at least min,
+// stored in p. The value p holds beforehand is the default.
+func (s *Set) IntVar(p *int, name, short, placeholder string, min int) {
+	s.add(&Flag{Name: name, Short: short, Placeholder: placeholder, set: func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min {
+			return fmt.Errorf("must be a whole number of at least %d", min)
+		}
+		*p = n
+		return nil
+	}})
+}
+
+// BoolVar declares a switch that sets p to true when given
+func (s *Set) BoolVar(p *bool, name, short string) {
+	s.add(&Flag{Name: name, Short: short, set: func(string) error {
+		*p = true
+		return nil
+	}})
+}
+
+// Func declares a flag whose value is checked and stored by fn. A switch,
+// with no placeholder, calls fn with an empty value.
+func (s *Set) Func(name, short, placeholder string, fn func(value string) error) {
+	s.add(&Flag{Name: name, Short: short, Placeholder: placeholder, set: fn})
+}
+
+// Positional names the positional arguments the command takes, for usage. A
+// name ending in "..." takes any number of arguments; otherwise passing more
+// than are named is an error.
+func (s *Set) Positional(names ...string) {
+	s.positional = append(s.positional, names...)
+}
+
+// Usage returns the generated usage line, without the command name
+func (s *Set) Usage() string {
+	parts := make([]string, 0, len(s.positional)+len(s.flags))
+	parts = append(parts, s.positional...)
+	for _, f := range s.flags {
+		if f.Placeholder == "" {
+			parts = append(parts, "["+f.names()+"]")
+		} else {
+			parts = append(parts, "["+f.names()+" "+f.Placeholder+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Args returns the positional arguments left after Parse
+func (s *Set) Args() []string {
+	return s.args
+}
+
+// Changed reports whether the flag with the given long name was given
+func (s *Set) Changed(name string) bool {
+	for _, f := range s.flags {
+		if f.Name == name {
+			return f.seen
+		}
+	}
+	return false
+}
+
+// Parse sets the declared flags from args, which may come in any order and
+// as --name value or --name=value. Arguments after "--" are positional.
+// Errors name the problem and end with the usage line.
+func (s *Set) Parse(args []string) error {
+	if err := s.parse(args); err != nil {
+		return fmt.Errorf("%w\nusage: %s %s", err, s.command, s.Usage())
+	}
+	return nil
+}
+
+func (s *Set) parse(args []string) error {
+	s.args = nil
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			s.args = append(s.args, args[i+1:]...)
+			break
+		}
+		if !isFlag(arg) {
+			s.args = append(s.args, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		f := s.lookup(name)
+		if f == nil {
+			return s.unknown(name)
+		}
+
+		if f.Placeholder == "" {
+			if hasValue {
+				return fmt.Errorf("%s doesn't take a value", name)
+			}
+		} else if !hasValue {
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value %s", name, f.Placeholder)
+			}
+			value = args[i+1]
+			i++
+		}
+
+		f.seen = true
+		if err := f.set(value); err != nil {
+			if f.Placeholder == "" {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return fmt.Errorf("invalid %s value %q: %w", name, value, err)
+		}
+	}
+
+	if len(s.args) > len(s.positional) && !s.variadic() {
+		return errors.New("unexpected argument " + strconv.Quote(s.args[len(s.positional)]))
+	}
+	return nil
+}
+
+// isFlag reports whether arg looks like a flag rather than a value such as
+// "-" or a negative number
+func isFlag(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+func (s *Set) variadic() bool {
+	return len(s.positional) > 0 && strings.HasSuffix(s.positional[len(s.positional)-1], "...")
+}
+
+func (s *Set) lookup(name string) *Flag {
+	for _, f := range s.flags {
+		if name == "--"+f.Name || (f.Short != "" && name == "-"+f.Short) {
+			return f
+		}
+	}
+	return nil
+}
+
+// unknown returns the error for an unknown flag, suggesting the declared
+// flag it's most likely a typo of
+func (s *Set) unknown(name string) error {
+	if !strings.HasPrefix(name, "--") {
+		return fmt.Errorf("unknown flag %s", name)
+	}
+
+	typed := name[2:]
+	best, bestDistance := "", 3
+	for _, f := range s.flags {
+		if d := distance(typed, f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown flag %s, did you mean --%s?", name, best)
+	}
+	return fmt.Errorf("unknown flag %s", name)
+}
+
+// distance is the Levenshtein edit distance between a and b
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
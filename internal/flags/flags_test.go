@@ -0,0 +1,104 @@
+package flags
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testOptions struct {
+	days    int
+	account string
+	all     bool
+}
+
+func testSet(opts *testOptions) *Set {
+	set := New("money test")
+	set.Positional("<name>")
+	set.IntVar(&opts.days, "days", "d", "<number>", 1)
+	set.StringVar(&opts.account, "account", "a", "<account-id>")
+	set.BoolVar(&opts.all, "all", "")
+	return set
+}
+
+func TestParse(t *testing.T) {
+	opts := testOptions{days: 30}
+	set := testSet(&opts)
+	if err := set.Parse([]string{"-d", "7", "groceries", "--account=acct-1", "--all"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if opts.days != 7 || opts.account != "acct-1" || !opts.all {
+		t.Errorf("opts = %+v", opts)
+	}
+	if want := []string{"groceries"}; !reflect.DeepEqual(set.Args(), want) {
+		t.Errorf("Args() = %v, want %v", set.Args(), want)
+	}
+	if !set.Changed("days") || !set.Changed("all") {
+		t.Error("Changed() = false for given flags")
+	}
+
+	opts = testOptions{days: 30}
+	set = testSet(&opts)
+	if err := set.Parse([]string{"--", "--days"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if opts.days != 30 || set.Changed("days") {
+		t.Errorf("days = %d after --, want the default 30", opts.days)
+	}
+	if want := []string{"--days"}; !reflect.DeepEqual(set.Args(), want) {
+		t.Errorf("Args() = %v, want %v", set.Args(), want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--day", "7"}, "unknown flag --day, did you mean --days?"},
+		{[]string{"--verbose"}, "unknown flag --verbose\n"},
+		{[]string{"-x"}, "unknown flag -x\n"},
+		{[]string{"--days"}, "--days requires a value <number>"},
+		{[]string{"--days", "0"}, `invalid --days value "0": must be a whole number of at least 1`},
+		{[]string{"--days", "week"}, `invalid --days value "week"`},
+		{[]string{"--all=yes"}, "--all doesn't take a value"},
+		{[]string{"one", "two"}, `unexpected argument "two"`},
+	}
+	for _, tt := range tests {
+		opts := testOptions{}
+		err := testSet(&opts).Parse(tt.args)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", tt.args)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %q, want it to contain %q", tt.args, err, tt.want)
+		}
+		if !strings.HasSuffix(err.Error(), "usage: money test "+testSet(&opts).Usage()) {
+			t.Errorf("Parse(%q) = %q, want it to end with the usage", tt.args, err)
+		}
+	}
+}
+
+func TestParseNegativeValue(t *testing.T) {
+	var amount string
+	set := New("money test")
+	set.StringVar(&amount, "amount", "", "<dollars>")
+	set.Positional("<values>...")
+	if err := set.Parse([]string{"--amount", "-12.50", "-", "-3"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if amount != "-12.50" {
+		t.Errorf("amount = %q, want -12.50", amount)
+	}
+	if want := []string{"-", "-3"}; !reflect.DeepEqual(set.Args(), want) {
+		t.Errorf("Args() = %v, want %v", set.Args(), want)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	want := "<name> [--days|-d <number>] [--account|-a <account-id>] [--all]"
+	if got := testSet(&testOptions{}).Usage(); got != want {
+		t.Errorf("Usage() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+// Package logging configures the shared slog logger used for warnings and
+// diagnostics across the money CLI.
+//
+// Packages log through the slog default logger (slog.Warn, slog.Debug, ...).
+// Setup installs a handler that prints to stderr at the level chosen by the
+// --verbose and --quiet flags and, optionally, also writes every record to a
+// log file under $MONEY_DIR/logs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verbosity selects how much is printed to stderr
+type Verbosity int
+
+const (
+	Quiet   Verbosity = -1 // errors only
+	Normal  Verbosity = 0  // warnings and errors
+	Verbose Verbosity = 1  // everything, including debug output
+)
+
+// level returns the lowest level printed to stderr
+func (v Verbosity) level() slog.Level {
+	switch {
+	case v < Normal:
+		return slog.LevelError
+	case v > Normal:
+		return slog.LevelDebug
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// Setup makes the default slog logger print to stderr at the given verbosity
+func Setup(verbosity Verbosity) {
+	slog.SetDefault(slog.New(NewCLIHandler(os.Stderr, verbosity.level())))
+}
+
+// AddLogFile additionally writes every record, including debug output, to
+// a dated file in dir. The returned function closes the file.
+func AddLogFile(dir string, now time.Time) (func() error, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "money-"+now.Format("2006-01-02")+".log")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	fileHandler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
+	slog.SetDefault(slog.New(fanoutHandler{slog.Default().Handler(), fileHandler}))
+	return file.Close, nil
+}
+
+// cliHandler prints records as short human-readable lines, e.g.
+// "warning: failed to update last_used timestamp err=database is locked"
+type cliHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+}
+
+// NewCLIHandler returns a handler printing records at level or above to w
+func NewCLIHandler(w io.Writer, level slog.Level) slog.Handler {
+	return &cliHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *cliHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *cliHandler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(record.Level.String()))
+	b.WriteString(": ")
+	b.WriteString(record.Message)
+
+	writeAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *cliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not needed for terminal output; grouped attributes are
+// printed with their own keys
+func (h *cliHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// fanoutHandler sends each record to every handler that accepts its level
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
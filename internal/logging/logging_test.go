@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLIHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewCLIHandler(&buf, Normal.level()))
+
+	logger.Debug("hidden at normal verbosity")
+	logger.With("account", "acct1").Warn("failed to update", "err", "locked")
+
+	if got, want := buf.String(), "warn: failed to update account=acct1 err=locked\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestVerbosityLevels(t *testing.T) {
+	if Quiet.level() != slog.LevelError || Normal.level() != slog.LevelWarn || Verbose.level() != slog.LevelDebug {
+		t.Errorf("unexpected levels: quiet=%v normal=%v verbose=%v", Quiet.level(), Normal.level(), Verbose.level())
+	}
+}
+
+func TestAddLogFile(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var stderr bytes.Buffer
+	slog.SetDefault(slog.New(NewCLIHandler(&stderr, slog.LevelWarn)))
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	closeLog, err := AddLogFile(dir, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("AddLogFile: %v", err)
+	}
+	slog.Debug("debug detail")
+	slog.Warn("something odd")
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(stderr.String(), "debug detail") || !strings.Contains(stderr.String(), "something odd") {
+		t.Errorf("stderr = %q, want only the warning", stderr.String())
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, "money-2024-07-01.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "debug detail") || !strings.Contains(string(contents), "something odd") {
+		t.Errorf("log file = %q, want both records", contents)
+	}
+}
@@ -0,0 +1,90 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// stdin and stdout back the line-based prompts; swapped out in tests
+var (
+	stdin            = bufio.NewReader(os.Stdin)
+	stdout io.Writer = os.Stdout
+)
+
+// readLine reads one line of input without its line ending. ok is false
+// once input is exhausted.
+func readLine() (line string, ok bool) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+func lineConfirm(question string) bool {
+	fmt.Fprintf(stdout, "%s [y/N]: ", question)
+	answer, ok := readLine()
+	if !ok {
+		fmt.Fprintln(stdout)
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func lineInput(question string, validator func(string) error) string {
+	for {
+		fmt.Fprintf(stdout, "%s: ", question)
+		answer, ok := readLine()
+		if !ok {
+			fmt.Fprintln(stdout)
+			return ""
+		}
+
+		answer = strings.TrimSpace(answer)
+		if validator != nil {
+			if err := validator(answer); err != nil {
+				fmt.Fprintf(stdout, "Error: %v\n", err)
+				continue
+			}
+		}
+		return answer
+	}
+}
+
+func lineSelect(title string, options []Option) *Option {
+	fmt.Fprintln(stdout, title)
+	for i, opt := range options {
+		if opt.Description != "" {
+			fmt.Fprintf(stdout, "  %d) %s - %s\n", i+1, opt.Label, opt.Description)
+		} else {
+			fmt.Fprintf(stdout, "  %d) %s\n", i+1, opt.Label)
+		}
+	}
+
+	for {
+		fmt.Fprintf(stdout, "Enter a number (1-%d): ", len(options))
+		answer, ok := readLine()
+		if !ok {
+			fmt.Fprintln(stdout)
+			return nil
+		}
+
+		choice, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err != nil || choice < 1 || choice > len(options) {
+			fmt.Fprintf(stdout, "Please enter a number between 1 and %d\n", len(options))
+			continue
+		}
+
+		option := options[choice-1]
+		return &option
+	}
+}
@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// withInput runs the prompts against piped input instead of a terminal
+func withInput(t *testing.T, input string) *bytes.Buffer {
+	t.Helper()
+
+	oldInteractive, oldStdin, oldStdout := interactive, stdin, stdout
+	t.Cleanup(func() {
+		interactive, stdin, stdout = oldInteractive, oldStdin, oldStdout
+	})
+
+	var output bytes.Buffer
+	interactive = func() bool { return false }
+	stdin = bufio.NewReader(strings.NewReader(input))
+	stdout = &output
+	return &output
+}
+
+func TestConfirm(t *testing.T) {
+	withInput(t, "yes\nn\n\n")
+
+	if !Confirm("Continue?") {
+		t.Error("Expected yes to confirm")
+	}
+	if Confirm("Continue?") {
+		t.Error("Expected n to decline")
+	}
+	if Confirm("Continue?") {
+		t.Error("Expected an empty answer to default to no")
+	}
+	if Confirm("Continue?") {
+		t.Error("Expected end of input to decline")
+	}
+}
+
+func TestInputWithValidator(t *testing.T) {
+	output := withInput(t, "abc\n42\n")
+
+	validator := func(s string) error {
+		if s != "42" {
+			return fmt.Errorf("must be 42")
+		}
+		return nil
+	}
+
+	if got := InputWithValidator("Number", "", validator); got != "42" {
+		t.Errorf("Expected 42 after a retry, got %q", got)
+	}
+	if !strings.Contains(output.String(), "Error: must be 42") {
+		t.Errorf("Expected the validation error to be shown, got %q", output.String())
+	}
+	if got := Input("Anything", ""); got != "" {
+		t.Errorf("Expected empty input at end of input, got %q", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	withInput(t, "9\n2\n")
+
+	options := []Option{
+		{Label: "Claude", Value: "claude"},
+		{Label: "Custom", Value: "custom", Description: "Enter your own command"},
+	}
+
+	selected := Select("Choose:", options)
+	if selected == nil || selected.Value != "custom" {
+		t.Errorf("Expected custom after an out-of-range answer, got %+v", selected)
+	}
+	if Select("Choose:", options) != nil {
+		t.Error("Expected nil at end of input")
+	}
+}
@@ -1,19 +1,128 @@
-package cli
+// Package prompt asks the user questions on the terminal. Prompts are
+// bubbletea components when stdin and stdout are terminals, and fall back
+// to plain line-based stdin prompts otherwise, so commands keep working
+// when input is piped or scripted.
+package prompt
 
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Simple confirmation model
+// Option is a choice offered by Select
+type Option struct {
+	Label       string
+	Value       string
+	Description string
+}
+
+// interactive reports whether the bubbletea prompts can run; swapped out in tests
+var interactive = func() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Confirm asks a yes/no question, defaulting to no
+func Confirm(question string) bool {
+	if !interactive() {
+		return lineConfirm(question)
+	}
+
+	model := confirmModel{
+		question: question,
+		selected: 1, // Default to No
+	}
+
+	p := tea.NewProgram(model)
+	finalModel, err := p.StartReturningModel()
+	if err != nil {
+		return false
+	}
+
+	return finalModel.(confirmModel).result
+}
+
+// Input asks for a line of text. The placeholder is only a hint; an empty
+// answer returns "".
+func Input(question, placeholder string) string {
+	return InputWithValidator(question, placeholder, nil)
+}
+
+// InputWithValidator asks for a line of text until validator accepts it
+func InputWithValidator(question, placeholder string, validator func(string) error) string {
+	if !interactive() {
+		return lineInput(question, validator)
+	}
+
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.Focus()
+
+	return runInput(inputModel{textInput: ti, question: question, validator: validator})
+}
+
+// MaskedInput asks for a secret without echoing it on the terminal
+func MaskedInput(question, placeholder string) string {
+	if !interactive() {
+		return lineInput(question, nil)
+	}
+
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.EchoMode = textinput.EchoPassword
+	ti.Focus()
+
+	return runInput(inputModel{textInput: ti, question: question})
+}
+
+func runInput(model inputModel) string {
+	p := tea.NewProgram(model)
+	finalModel, err := p.StartReturningModel()
+	if err != nil {
+		return ""
+	}
+
+	return finalModel.(inputModel).textInput.Value()
+}
+
+// Select asks the user to pick one of options, returning nil if they cancel
+func Select(title string, options []Option) *Option {
+	if !interactive() {
+		return lineSelect(title, options)
+	}
+
+	items := make([]list.Item, len(options))
+	for i, opt := range options {
+		items[i] = listItem{option: opt}
+	}
+
+	l := list.New(items, list.NewDefaultDelegate(), 80, 14)
+	l.Title = title
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+
+	p := tea.NewProgram(listModel{list: l})
+	finalModel, err := p.StartReturningModel()
+	if err != nil {
+		return nil
+	}
+
+	return finalModel.(listModel).result
+}
+
+// confirmModel is a yes/no toggle
 type confirmModel struct {
 	question string
 	selected int
@@ -59,7 +168,7 @@ func (m confirmModel) View() string {
 	return s
 }
 
-// Simple input model using textinput
+// inputModel is a single-line text input with optional validation
 type inputModel struct {
 	textInput textinput.Model
 	question  string
@@ -112,21 +221,19 @@ func (m inputModel) View() string {
 	return s
 }
 
-// List item for selections
+// listItem wraps an Option for the list component
 type listItem struct {
-	title, desc string
-	value       string
+	option Option
 }
 
-func (i listItem) FilterValue() string { return i.title }
-func (i listItem) Title() string       { return i.title }
-func (i listItem) Description() string { return i.desc }
+func (i listItem) FilterValue() string { return i.option.Label }
+func (i listItem) Title() string       { return i.option.Label }
+func (i listItem) Description() string { return i.option.Description }
 
-// Simple list model
+// listModel picks one item from a list
 type listModel struct {
 	list   list.Model
-	done   bool
-	result *listItem
+	result *Option
 }
 
 func (m listModel) Init() tea.Cmd {
@@ -140,10 +247,9 @@ func (m listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "q", "esc":
 			return m, tea.Quit
 		case "enter":
-			if selected := m.list.SelectedItem(); selected != nil {
-				item := selected.(listItem)
-				m.result = &item
-				m.done = true
+			if selected, ok := m.list.SelectedItem().(listItem); ok {
+				option := selected.option
+				m.result = &option
 				return m, tea.Quit
 			}
 		}
@@ -155,194 +261,8 @@ func (m listModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m listModel) View() string {
-	if m.done {
+	if m.result != nil {
 		return ""
 	}
 	return m.list.View()
 }
-
-
-// Helper functions using standard components
-
-func RunConfirmation(question string) bool {
-	model := confirmModel{
-		question: question,
-		selected: 1, // Default to No
-	}
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.StartReturningModel()
-	if err != nil {
-		return false
-	}
-
-	result := finalModel.(confirmModel)
-	return result.result
-}
-
-func RunInput(prompt, placeholder string) string {
-	ti := textinput.New()
-	ti.Placeholder = placeholder
-	ti.Focus()
-
-	model := inputModel{
-		textInput: ti,
-		question:  prompt,
-	}
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.StartReturningModel()
-	if err != nil {
-		return ""
-	}
-
-	result := finalModel.(inputModel)
-	return result.textInput.Value()
-}
-
-func RunInputWithValidator(prompt, placeholder string, validator func(string) error) string {
-	ti := textinput.New()
-	ti.Placeholder = placeholder
-	ti.Focus()
-
-	model := inputModel{
-		textInput: ti,
-		question:  prompt,
-		validator: validator,
-	}
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.StartReturningModel()
-	if err != nil {
-		return ""
-	}
-
-	result := finalModel.(inputModel)
-	return result.textInput.Value()
-}
-
-func RunMaskedInput(prompt, placeholder string) string {
-	ti := textinput.New()
-	ti.Placeholder = placeholder
-	ti.EchoMode = textinput.EchoPassword
-	ti.Focus()
-
-	model := inputModel{
-		textInput: ti,
-		question:  prompt,
-	}
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.StartReturningModel()
-	if err != nil {
-		return ""
-	}
-
-	result := finalModel.(inputModel)
-	return result.textInput.Value()
-}
-
-type SelectOption struct {
-	Label       string
-	Value       string
-	Description string
-}
-
-func RunSelection(title string, options []SelectOption) *SelectOption {
-	items := make([]list.Item, len(options))
-	for i, opt := range options {
-		items[i] = listItem{
-			title: opt.Label,
-			desc:  opt.Description,
-			value: opt.Value,
-		}
-	}
-
-	l := list.New(items, list.NewDefaultDelegate(), 80, 14)
-	l.Title = title
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
-
-	model := listModel{list: l}
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.StartReturningModel()
-	if err != nil {
-		return nil
-	}
-
-	result := finalModel.(listModel)
-	if result.result == nil {
-		return nil
-	}
-
-	return &SelectOption{
-		Label:       result.result.title,
-		Value:       result.result.value,
-		Description: result.result.desc,
-	}
-}
-
-// Validators
-
-func DirectoryValidator(path string) error {
-	if path == "" {
-		return fmt.Errorf("path cannot be empty")
-	}
-
-	// Expand ~ to home directory
-	if strings.HasPrefix(path, "~") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("could not get home directory: %w", err)
-		}
-		path = filepath.Join(home, path[1:])
-	}
-
-	// Check if directory exists or can be created
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Try to create the directory
-		if err := os.MkdirAll(path, 0755); err != nil {
-			return fmt.Errorf("cannot create directory: %w", err)
-		}
-	}
-
-	return nil
-}
-
-func SetupTokenValidator(token string) error {
-	if token == "" {
-		return fmt.Errorf("setup token cannot be empty")
-	}
-	if len(token) < 10 {
-		return fmt.Errorf("setup token appears too short")
-	}
-	return nil
-}
-
-func APIKeyValidator(key string) error {
-	if key == "" {
-		return fmt.Errorf("API key cannot be empty")
-	}
-	if len(key) < 10 {
-		return fmt.Errorf("API key appears too short")
-	}
-	return nil
-}
-
-func BatchSizeValidator(input string) error {
-	if input == "" {
-		return nil // Allow empty for default
-	}
-
-	size, err := strconv.Atoi(input)
-	if err != nil {
-		return fmt.Errorf("must be a number")
-	}
-
-	if size < 1 || size > 100 {
-		return fmt.Errorf("batch size must be between 1 and 100")
-	}
-
-	return nil
-}
\ No newline at end of file
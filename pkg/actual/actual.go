@@ -0,0 +1,188 @@
+// Package actual pushes transactions and balances to a self-hosted Actual
+// Budget server. Actual's own sync protocol is private to its apps, so it
+// talks to actual-http-api, the REST server that wraps Actual's API and
+// runs alongside the sync server.
+package actual
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client represents an actual-http-api client for one budget
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	Budget     string // the budget's sync ID
+	Password   string // for end-to-end encrypted budgets
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the budget with the sync ID given on the
+// actual-http-api server at baseURL
+func NewClient(baseURL, apiKey, budget, password string) *Client {
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		APIKey:   apiKey,
+		Budget:   budget,
+		Password: password,
+		HTTPClient: &http.Client{
+			// Actual downloads and opens the budget on the first request
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+// Account is an Actual account
+type Account struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	OffBudget bool   `json:"offbudget"`
+	Closed    bool   `json:"closed"`
+}
+
+// Category is an Actual category
+type Category struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	GroupID  string `json:"group_id"`
+	IsIncome bool   `json:"is_income"`
+	Hidden   bool   `json:"hidden"`
+}
+
+// Transaction is a transaction to import into Actual. Amounts are in
+// cents, as in money.
+type Transaction struct {
+	Account    string `json:"account"`
+	Date       string `json:"date"` // YYYY-MM-DD
+	Amount     int64  `json:"amount"`
+	PayeeName  string `json:"payee_name,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+	ImportedID string `json:"imported_id,omitempty"`
+	Cleared    bool   `json:"cleared"`
+}
+
+// ImportResult lists the IDs of the transactions an import added to Actual
+// and the ones it matched to transactions already there
+type ImportResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// do sends a request to path under the budget, with body encoded as JSON
+// when it isn't nil, and decodes the response's data into out
+func (c *Client) do(method, path string, body, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/v1/budgets/%s%s", c.BaseURL, url.PathEscape(c.Budget), path)
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if c.Password != "" {
+		req.Header.Set("budget-encryption-password", c.Password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make Actual request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("Actual request failed with status %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("Actual request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	if out == nil {
+		return nil
+	}
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse Actual response: %w", err)
+	}
+	return nil
+}
+
+// Accounts returns the budget's accounts
+func (c *Client) Accounts() ([]Account, error) {
+	var accounts []Account
+	if err := c.do("GET", "/accounts", nil, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Categories returns the budget's categories
+func (c *Client) Categories() ([]Category, error) {
+	var categories []Category
+	if err := c.do("GET", "/categories", nil, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Balance returns an account's balance in cents
+func (c *Client) Balance(accountID string) (int64, error) {
+	var balance int64
+	if err := c.do("GET", "/accounts/"+url.PathEscape(accountID)+"/balance", nil, &balance); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// ImportTransactions imports transactions into an account the way Actual's
+// bank sync does: one with an imported ID Actual already has, or that looks
+// like one already in the account, updates it instead of being added again
+func (c *Client) ImportTransactions(accountID string, transactions []Transaction) (*ImportResult, error) {
+	result := &ImportResult{}
+	if len(transactions) == 0 {
+		return result, nil
+	}
+
+	body := struct {
+		Transactions []Transaction `json:"transactions"`
+	}{transactions}
+	if err := c.do("POST", "/accounts/"+url.PathEscape(accountID)+"/transactions/import", body, result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("Actual refused the import: %s", result.Errors[0].Message)
+	}
+	return result, nil
+}
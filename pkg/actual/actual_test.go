@@ -0,0 +1,220 @@
+package actual
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// fakeActual is an in-memory budget served the way actual-http-api serves
+// one, matching imports by imported ID only
+type fakeActual struct {
+	t            *testing.T
+	accounts     []Account
+	categories   []Category
+	transactions []Transaction
+}
+
+func (f *fakeActual) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("x-api-key") != "test-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Invalid API key"}`))
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/v1/budgets/budget-1")
+	if !ok {
+		f.t.Errorf("Unexpected path %s", r.URL.Path)
+	}
+
+	var data interface{}
+	switch {
+	case r.Method == "GET" && path == "/accounts":
+		data = f.accounts
+	case r.Method == "GET" && path == "/categories":
+		data = f.categories
+	case r.Method == "GET" && strings.HasSuffix(path, "/balance"):
+		account := strings.TrimSuffix(strings.TrimPrefix(path, "/accounts/"), "/balance")
+		var balance int64
+		for _, txn := range f.transactions {
+			if txn.Account == account {
+				balance += txn.Amount
+			}
+		}
+		data = balance
+	case r.Method == "POST" && strings.HasSuffix(path, "/transactions/import"):
+		var body struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Fatalf("Failed to decode import: %v", err)
+		}
+		result := ImportResult{Added: []string{}, Updated: []string{}}
+		for _, txn := range body.Transactions {
+			if existing := f.transaction(txn.ImportedID); existing != nil {
+				existing.Amount = txn.Amount
+				result.Updated = append(result.Updated, txn.ImportedID)
+				continue
+			}
+			f.transactions = append(f.transactions, txn)
+			result.Added = append(result.Added, txn.ImportedID)
+		}
+		data = result
+	default:
+		f.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func (f *fakeActual) transaction(importedID string) *Transaction {
+	for i := range f.transactions {
+		if f.transactions[i].ImportedID == importedID {
+			return &f.transactions[i]
+		}
+	}
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	for _, account := range []struct {
+		id      string
+		balance int64
+	}{{"checking", 100000}, {"house", 45000000}, {"savings", 500000}} {
+		if err := db.SaveAccount(account.id, "org-1", account.id, "USD", account.balance, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+	groceries, _ := db.SaveCategory("Groceries")
+	rent, _ := db.SaveCategory("Rent")
+	transfers, _ := db.SaveCategoryWithInternal("Transfers", true)
+
+	for _, txn := range []struct {
+		id, account, posted string
+		amount              int64
+		category            int
+		pending             bool
+	}{
+		{"t-groceries", "checking", "2025-03-02T17:00:00Z", -4500, groceries, false},
+		{"t-rent", "checking", "2025-03-01T17:00:00Z", -150000, rent, false},
+		{"t-transfer", "checking", "2025-03-03T17:00:00Z", -20000, transfers, false},
+		{"t-pending", "checking", "2025-03-05T17:00:00Z", -999, 0, true},
+		{"t-old", "checking", "2025-01-05T17:00:00Z", -1234, 0, false},
+		{"t-savings", "savings", "2025-03-03T17:00:00Z", 20000, transfers, false},
+	} {
+		if err := db.SaveTransaction(txn.id, txn.account, txn.posted, txn.amount, strings.ToUpper(txn.id), txn.pending); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.category != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.category); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	fake := &fakeActual{
+		t: t,
+		accounts: []Account{
+			{ID: "a-checking", Name: "Checking"},
+			{ID: "a-house", Name: "House", OffBudget: true},
+		},
+		categories: []Category{
+			{ID: "c-groceries", Name: "groceries"},
+			{ID: "c-housing", Name: "Housing"},
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client := NewClient(server.URL+"/", "test-key", "budget-1", "")
+
+	if _, err := Push(db, client, Options{Since: "2025-03-01"}); err == nil || !strings.Contains(err.Error(), "map one") {
+		t.Fatalf("Expected an error before any account is mapped, got %v", err)
+	}
+	if err := db.SetActualAccount("checking", "a-checking"); err != nil {
+		t.Fatalf("Failed to map account: %v", err)
+	}
+	if err := db.SetActualAccount("house", "a-house"); err != nil {
+		t.Fatalf("Failed to map account: %v", err)
+	}
+
+	result, err := Push(db, client, Options{Since: "2025-03-01"})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if result.Added != 3 || result.Updated != 0 || result.Adjusted != 2 {
+		t.Errorf("Unexpected push result %+v", result)
+	}
+	if len(result.Unmapped) != 1 || result.Unmapped[0] != "Rent" {
+		t.Errorf("Expected Rent to be unmapped, got %v", result.Unmapped)
+	}
+
+	groceriesTxn := fake.transaction("t-groceries")
+	if groceriesTxn == nil || groceriesTxn.Account != "a-checking" || groceriesTxn.Date != "2025-03-02" || groceriesTxn.Amount != -4500 ||
+		groceriesTxn.Category != "c-groceries" || groceriesTxn.PayeeName != "T-GROCERIES" || !groceriesTxn.Cleared {
+		t.Errorf("Unexpected pushed groceries %+v", groceriesTxn)
+	}
+	if transfer := fake.transaction("t-transfer"); transfer == nil || transfer.Category != "" {
+		t.Errorf("Expected the transfer to be pushed without a category, got %+v", transfer)
+	}
+	if fake.transaction("t-pending") != nil || fake.transaction("t-old") != nil || fake.transaction("t-savings") != nil {
+		t.Error("Expected pending, old and unmapped transactions not to be pushed")
+	}
+
+	today := format.Now().Format("2006-01-02")
+	checkingAdjustment := fake.transaction(AdjustmentID("checking", today, 100000))
+	if checkingAdjustment == nil || checkingAdjustment.Amount != 100000+4500+150000+20000 || checkingAdjustment.PayeeName != adjustmentPayee {
+		t.Errorf("Unexpected checking adjustment %+v", checkingAdjustment)
+	}
+	if houseAdjustment := fake.transaction(AdjustmentID("house", today, 45000000)); houseAdjustment == nil || houseAdjustment.Amount != 45000000 {
+		t.Errorf("Unexpected house adjustment %+v", houseAdjustment)
+	}
+
+	// Pushing again updates the same transactions and needs no adjustment
+	count := len(fake.transactions)
+	if result, err = Push(db, client, Options{Since: "2025-03-01"}); err != nil {
+		t.Fatalf("Second push failed: %v", err)
+	}
+	if result.Added != 0 || result.Updated != 3 || result.Adjusted != 0 || len(fake.transactions) != count {
+		t.Errorf("Expected a second push to add nothing, got %+v", result)
+	}
+
+	// A new balance is adjusted for the difference
+	if err := db.UpdateAccountBalance("house", 46000000); err != nil {
+		t.Fatalf("Failed to update balance: %v", err)
+	}
+	if result, err = Push(db, client, Options{Since: "2025-03-01", NoBalances: true}); err != nil || result.Adjusted != 0 {
+		t.Fatalf("Expected no adjustments with NoBalances, got %+v, %v", result, err)
+	}
+	if result, err = Push(db, client, Options{Since: "2025-03-01"}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if houseAdjustment := fake.transaction(AdjustmentID("house", today, 46000000)); result.Adjusted != 1 || houseAdjustment == nil || houseAdjustment.Amount != 1000000 {
+		t.Errorf("Expected the house to be adjusted by $10,000, got %+v", houseAdjustment)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(&fakeActual{t: t})
+	defer server.Close()
+	client := NewClient(server.URL, "wrong-key", "budget-1", "")
+
+	_, err := client.Accounts()
+	if err == nil || !strings.Contains(err.Error(), "401: Invalid API key") {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+}
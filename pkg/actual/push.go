@@ -0,0 +1,232 @@
+package actual
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// DefaultSinceDays is how many days back push looks by default
+const DefaultSinceDays = 30
+
+// adjustmentPayee is the payee of the transactions that bring an Actual
+// account's balance in line with money's
+const adjustmentPayee = "Balance adjustment"
+
+// Options controls a push
+type Options struct {
+	Since      string // YYYY-MM-DD; transactions dated before it aren't pushed
+	NoBalances bool   // leave Actual's balances alone, pushing only transactions
+}
+
+// Result counts what a push changed in Actual
+type Result struct {
+	Added    int // transactions added
+	Updated  int // transactions Actual already had, pushed before or from its own bank sync
+	Adjusted int // accounts given a balance adjustment
+	// Unmapped are money's categories with no Actual category of the same
+	// name, which were pushed without one, sorted
+	Unmapped []string
+}
+
+// FindAccount returns the Actual account with the ID or, ignoring case, the
+// name given
+func FindAccount(accounts []Account, nameOrID string) (*Account, error) {
+	var found *Account
+	for i, account := range accounts {
+		if account.ID == nameOrID {
+			return &accounts[i], nil
+		}
+		if strings.EqualFold(account.Name, nameOrID) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one Actual account is named %q, use its ID", nameOrID)
+			}
+			found = &accounts[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("Actual account not found: %s", nameOrID)
+	}
+	return found, nil
+}
+
+// AdjustmentID returns the imported ID of the adjustment that brings an
+// account to balance on date, so pushing twice in a day doesn't adjust twice
+func AdjustmentID(accountID, date string, balance int64) string {
+	return fmt.Sprintf("money-balance:%s:%s:%d", accountID, date, balance)
+}
+
+// categoryIDs returns the Actual category each money category is pushed
+// with: the one with the same name, ignoring case, when only one has it
+func categoryIDs(categories []database.Category, actualCategories []Category) map[int]string {
+	byName := make(map[string][]string)
+	for _, c := range actualCategories {
+		name := strings.ToLower(c.Name)
+		byName[name] = append(byName[name], c.ID)
+	}
+
+	ids := make(map[int]string)
+	for _, c := range categories {
+		if matches := byName[strings.ToLower(c.Name)]; len(matches) == 1 {
+			ids[c.ID] = matches[0]
+		}
+	}
+	return ids
+}
+
+// Push imports posted transactions in the mapped accounts since opts.Since
+// into their Actual accounts, as cleared transactions with the Actual
+// category of the same name. Each is imported with its transaction ID, so
+// Actual updates the one it has rather than adding it twice, whether it was
+// pushed before or came from Actual's own SimpleFIN sync. Internal
+// categories like Transfers are left for Actual's transfer payees.
+//
+// Unless opts.NoBalances is set, each Actual account whose balance then
+// differs from money's gets an adjustment for the difference dated today,
+// which covers history from before opts.Since and accounts, like
+// properties, that have no transactions.
+func Push(db *database.DB, client *Client, opts Options) (*Result, error) {
+	mapped, err := db.GetActualAccounts()
+	if err != nil {
+		return nil, err
+	}
+	if len(mapped) == 0 {
+		return nil, fmt.Errorf("no accounts are mapped to Actual; map one with 'money actual map <account-id> <actual-account>'")
+	}
+
+	actualAccounts, err := client.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(actualAccounts))
+	for _, account := range actualAccounts {
+		exists[account.ID] = true
+	}
+	accountIDs := make([]string, 0, len(mapped))
+	for accountID, actualID := range mapped {
+		if !exists[actualID] {
+			return nil, fmt.Errorf("account %s is mapped to an Actual account that no longer exists; map it again", accountID)
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	actualCategories, err := client.Categories()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	toActual := categoryIDs(categories, actualCategories)
+	byID := make(map[int]database.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	transactions, err := db.GetTransactions(database.TransactionFilter{
+		StartDate:  opts.Since,
+		PostedOnly: true,
+		Ascending:  true,
+	}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	result := &Result{}
+	unmapped := make(map[string]bool)
+	pushes := make(map[string][]Transaction, len(mapped))
+	for _, txn := range transactions {
+		actualID, ok := mapped[txn.AccountID]
+		if !ok {
+			continue
+		}
+
+		var category string
+		if txn.CategoryID != nil {
+			if c := byID[*txn.CategoryID]; !c.IsInternal {
+				if id, ok := toActual[c.ID]; ok {
+					category = id
+				} else {
+					unmapped[c.Name] = true
+				}
+			}
+		}
+		pushes[txn.AccountID] = append(pushes[txn.AccountID], Transaction{
+			Account:    actualID,
+			Date:       format.PostedDate(txn.Posted),
+			Amount:     txn.Amount,
+			PayeeName:  txn.DisplayDescription(),
+			Category:   category,
+			ImportedID: txn.ID,
+			Cleared:    true,
+		})
+	}
+
+	for _, accountID := range accountIDs {
+		imported, err := client.ImportTransactions(mapped[accountID], pushes[accountID])
+		if err != nil {
+			return nil, fmt.Errorf("failed to push account %s: %w", accountID, err)
+		}
+		result.Added += len(imported.Added)
+		result.Updated += len(imported.Updated)
+	}
+
+	if !opts.NoBalances {
+		if result.Adjusted, err = adjustBalances(db, client, mapped, accountIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range unmapped {
+		result.Unmapped = append(result.Unmapped, name)
+	}
+	sort.Strings(result.Unmapped)
+	return result, nil
+}
+
+// adjustBalances adds an adjustment to each mapped Actual account whose
+// balance differs from money's, returning how many it adjusted
+func adjustBalances(db *database.DB, client *Client, mapped map[string]string, accountIDs []string) (int, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	balances := make(map[string]int64, len(accounts))
+	for _, account := range accounts {
+		balances[account.ID] = account.Balance
+	}
+
+	today := format.Now().Format("2006-01-02")
+	adjusted := 0
+	for _, accountID := range accountIDs {
+		actualID := mapped[accountID]
+		actualBalance, err := client.Balance(actualID)
+		if err != nil {
+			return adjusted, fmt.Errorf("failed to get the balance of account %s in Actual: %w", accountID, err)
+		}
+		difference := balances[accountID] - actualBalance
+		if difference == 0 {
+			continue
+		}
+
+		_, err = client.ImportTransactions(actualID, []Transaction{{
+			Account:    actualID,
+			Date:       today,
+			Amount:     difference,
+			PayeeName:  adjustmentPayee,
+			Notes:      "Brings the balance in line with money",
+			ImportedID: AdjustmentID(accountID, today, balances[accountID]),
+			Cleared:    true,
+		}})
+		if err != nil {
+			return adjusted, fmt.Errorf("failed to adjust the balance of account %s in Actual: %w", accountID, err)
+		}
+		adjusted++
+	}
+	return adjusted, nil
+}
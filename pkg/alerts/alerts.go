@@ -0,0 +1,312 @@
+// Package alerts evaluates warning conditions, such as low balances,
+// upcoming bills and budgets running out, against the stored data.
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+const (
+	KindLowBalance = "low_balance"
+	KindBillsDue   = "bills_due"
+	KindBudget     = "budget"
+)
+
+// BillWindowDays is how far ahead bills are counted against available balances
+const BillWindowDays = 14
+
+// BudgetWarnPercent is how much of a category's monthly budget can be spent
+// before it raises an alert; spending past the whole budget raises another
+const BudgetWarnPercent = 80
+
+// Alert is a warning raised by a check
+type Alert struct {
+	Kind      string
+	AccountID string // empty when the alert isn't about a single account
+	Message   string
+
+	// Budget alerts name their category and the threshold crossed, 80 or
+	// 100 percent
+	CategoryID int
+	Percent    int
+}
+
+// Check evaluates one kind of alert at time now
+type Check func(db *database.DB, now time.Time) ([]Alert, error)
+
+// Checks are the checks run by Evaluate, in order
+var Checks = []Check{
+	checkLowBalances,
+	checkBillsDue,
+	checkBudgets,
+}
+
+// Evaluate runs every check and returns the alerts raised
+func Evaluate(db *database.DB, now time.Time) ([]Alert, error) {
+	var alerts []Alert
+	for _, check := range Checks {
+		raised, err := check(db, now)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, raised...)
+	}
+	return alerts, nil
+}
+
+func checkLowBalances(db *database.DB, now time.Time) ([]Alert, error) {
+	thresholds, err := db.GetLowBalanceThresholds()
+	if err != nil {
+		return nil, err
+	}
+	if len(thresholds) == 0 {
+		return nil, nil
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	return lowBalanceAlerts(accounts, thresholds), nil
+}
+
+// lowBalanceAlerts flags accounts whose available balance is below their threshold
+func lowBalanceAlerts(accounts []database.Account, thresholds map[string]int64) []Alert {
+	var alerts []Alert
+	for _, account := range accounts {
+		threshold, exists := thresholds[account.ID]
+		if !exists {
+			continue
+		}
+
+		balance := availableBalance(account)
+		if balance < threshold {
+			alerts = append(alerts, Alert{
+				Kind:      KindLowBalance,
+				AccountID: account.ID,
+				Message: fmt.Sprintf("%s balance %s is below %s",
+					account.DisplayName(),
+					format.Currency(balance, account.Currency),
+					format.Currency(threshold, account.Currency)),
+			})
+		}
+	}
+	return alerts
+}
+
+func checkBillsDue(db *database.DB, now time.Time) ([]Alert, error) {
+	bills, err := db.GetBills()
+	if err != nil {
+		return nil, err
+	}
+	if len(bills) == 0 {
+		return nil, nil
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	return billsDueAlerts(bills, accounts, now, BillWindowDays), nil
+}
+
+// billsDueAlerts flags accounts that can't cover the bills due from them in
+// the next windowDays days. Bills without an account are counted against the
+// combined balance of all checking accounts.
+func billsDueAlerts(bills []database.Bill, accounts []database.Account, now time.Time, windowDays int) []Alert {
+	accountsByID := make(map[string]database.Account)
+	for _, account := range accounts {
+		accountsByID[account.ID] = account
+	}
+
+	// Group the bills due within the window by paying account
+	due := make(map[string][]database.Bill)
+	for _, bill := range UpcomingBills(bills, now, windowDays) {
+		due[bill.AccountID] = append(due[bill.AccountID], bill)
+	}
+
+	accountIDs := make([]string, 0, len(due))
+	for accountID := range due {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	var alerts []Alert
+	for _, accountID := range accountIDs {
+		accountBills := due[accountID]
+		var total int64
+		names := make([]string, len(accountBills))
+		for i, bill := range accountBills {
+			total += bill.Amount
+			names[i] = bill.Name
+		}
+
+		var available int64
+		var label, currency string
+		if account, exists := accountsByID[accountID]; exists {
+			available = availableBalance(account)
+			label = account.DisplayName()
+			currency = account.Currency
+		} else {
+			checking := 0
+			for _, account := range accounts {
+				if account.AccountType != nil && *account.AccountType == "checking" {
+					available += availableBalance(account)
+					currency = account.Currency
+					checking++
+				}
+			}
+			if checking == 0 {
+				// Nothing to compare against until an account is marked as checking
+				continue
+			}
+			label = "Checking accounts"
+		}
+
+		if total > available {
+			alerts = append(alerts, Alert{
+				Kind:      KindBillsDue,
+				AccountID: accountID,
+				Message: fmt.Sprintf("%s: %s in bills due in the next %d days (%s) exceeds the available %s",
+					label,
+					format.Currency(total, currency),
+					windowDays,
+					strings.Join(names, ", "),
+					format.Currency(available, currency)),
+			})
+		}
+	}
+	return alerts
+}
+
+func checkBudgets(db *database.DB, now time.Time) ([]Alert, error) {
+	budgets, err := db.GetBudgets()
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	now = now.In(format.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	categoryTransactions, err := db.GetTransactionsByCategory(monthStart.Format("2006-01-02"),
+		monthStart.AddDate(0, 1, -1).Format("2006-01-02"), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categorized transactions: %w", err)
+	}
+
+	return budgetAlerts(categoryTransactions, budgets, now), nil
+}
+
+// budgetAlerts flags categories that have spent BudgetWarnPercent of their
+// monthly budget, or all of it, in now's month. Spending counts withdrawals
+// only, like money budget tui.
+func budgetAlerts(categoryTransactions map[string][]database.Transaction, budgets []database.Budget, now time.Time) []Alert {
+	lastDay := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	daysLeft := lastDay - now.Day()
+
+	var alerts []Alert
+	for _, budget := range budgets {
+		if budget.Amount <= 0 {
+			continue
+		}
+		var spent int64
+		for _, txn := range categoryTransactions[budget.CategoryName] {
+			if txn.Amount < 0 {
+				spent += -txn.Amount
+			}
+		}
+
+		alert := Alert{Kind: KindBudget, CategoryID: budget.CategoryID}
+		switch {
+		case spent > budget.Amount:
+			alert.Percent = 100
+			alert.Message = fmt.Sprintf("%s is over budget: %s spent of %s this month",
+				budget.CategoryName, format.Currency(spent, format.BaseCurrency()), format.Currency(budget.Amount, format.BaseCurrency()))
+		case spent*100 >= budget.Amount*BudgetWarnPercent:
+			alert.Percent = BudgetWarnPercent
+			alert.Message = fmt.Sprintf("%s has used %d%% of its budget: %s spent of %s, with %d days left in the month",
+				budget.CategoryName, spent*100/budget.Amount, format.Currency(spent, format.BaseCurrency()),
+				format.Currency(budget.Amount, format.BaseCurrency()), daysLeft)
+		default:
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// Unsent returns the raised alerts to notify about. Budget alerts are only
+// sent the first time their category crosses each threshold in now's month,
+// and are recorded as sent; other alerts are sent every time they're raised.
+func Unsent(db *database.DB, raised []Alert, now time.Time) ([]Alert, error) {
+	month := now.In(format.Location()).Format("2006-01")
+
+	var unsent []Alert
+	for _, alert := range raised {
+		if alert.Kind == KindBudget {
+			isNew, err := db.RecordBudgetNotification(alert.CategoryID, month, alert.Percent)
+			if err != nil {
+				return nil, err
+			}
+			if !isNew {
+				continue
+			}
+		}
+		unsent = append(unsent, alert)
+	}
+	return unsent, nil
+}
+
+// UpcomingBills returns the bills whose next due date falls within
+// windowDays days of now, soonest first
+func UpcomingBills(bills []database.Bill, now time.Time, windowDays int) []database.Bill {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := today.AddDate(0, 0, windowDays)
+
+	var upcoming []database.Bill
+	for _, bill := range bills {
+		if NextDueDate(bill.DueDay, today).Before(end) {
+			upcoming = append(upcoming, bill)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return NextDueDate(upcoming[i].DueDay, today).Before(NextDueDate(upcoming[j].DueDay, today))
+	})
+	return upcoming
+}
+
+// NextDueDate returns the first date on or after from that falls on dueDay
+// of its month. Days past the end of a month fall on the month's last day.
+func NextDueDate(dueDay int, from time.Time) time.Time {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for months := 0; ; months++ {
+		monthStart := time.Date(from.Year(), from.Month()+time.Month(months), 1, 0, 0, 0, 0, from.Location())
+		lastDay := monthStart.AddDate(0, 1, -1).Day()
+		day := dueDay
+		if day > lastDay {
+			day = lastDay
+		}
+		due := time.Date(monthStart.Year(), monthStart.Month(), day, 0, 0, 0, 0, from.Location())
+		if !due.Before(from) {
+			return due
+		}
+	}
+}
+
+// availableBalance prefers the bank's available balance over the ledger balance
+func availableBalance(account database.Account) int64 {
+	if account.AvailableBalance != nil {
+		return *account.AvailableBalance
+	}
+	return account.Balance
+}
@@ -0,0 +1,150 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestNextDueDate(t *testing.T) {
+	tests := []struct {
+		dueDay int
+		from   string
+		want   string
+	}{
+		{15, "2024-01-10", "2024-01-15"},
+		{15, "2024-01-15", "2024-01-15"},
+		{15, "2024-01-16", "2024-02-15"},
+		{31, "2024-02-10", "2024-02-29"}, // clamped to the end of February
+		{1, "2024-12-20", "2025-01-01"},
+	}
+
+	for _, tt := range tests {
+		from, _ := time.Parse("2006-01-02", tt.from)
+		got := NextDueDate(tt.dueDay, from).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("NextDueDate(%d, %s) = %s, want %s", tt.dueDay, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestLowBalanceAlerts(t *testing.T) {
+	available := int64(4000)
+	accounts := []database.Account{
+		{ID: "checking", Name: "Checking", Currency: "USD", Balance: 90000, AvailableBalance: &available},
+		{ID: "savings", Name: "Savings", Currency: "USD", Balance: 500000},
+		{ID: "other", Name: "Other", Currency: "USD", Balance: 0},
+	}
+	thresholds := map[string]int64{"checking": 10000, "savings": 100000}
+
+	alerts := lowBalanceAlerts(accounts, thresholds)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d: %v", len(alerts), alerts)
+	}
+	if alerts[0].AccountID != "checking" || alerts[0].Kind != KindLowBalance {
+		t.Errorf("Expected low balance alert for checking (available balance), got %+v", alerts[0])
+	}
+}
+
+func TestBillsDueAlerts(t *testing.T) {
+	checkingType := "checking"
+	accounts := []database.Account{
+		{ID: "checking", Name: "Checking", Currency: "USD", Balance: 150000, AccountType: &checkingType},
+		{ID: "card", Name: "Card", Currency: "USD", Balance: 20000},
+	}
+	bills := []database.Bill{
+		{Name: "Rent", Amount: 120000, DueDay: 1},
+		{Name: "Internet", Amount: 8000, DueDay: 5},
+		{Name: "Gym", Amount: 5000, DueDay: 25}, // outside the window
+		{Name: "Insurance", Amount: 30000, DueDay: 3, AccountID: "card"},
+	}
+	now, _ := time.Parse("2006-01-02", "2024-03-28")
+
+	alerts := billsDueAlerts(bills, accounts, now, 14)
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %d: %v", len(alerts), alerts)
+	}
+	if alerts[0].AccountID != "card" {
+		t.Errorf("Expected alert for the card account, got %+v", alerts[0])
+	}
+
+	// Unassigned bills are covered by checking until they aren't
+	bills = append(bills, database.Bill{Name: "Car", Amount: 30000, DueDay: 2})
+	alerts = billsDueAlerts(bills, accounts, now, 14)
+	if len(alerts) != 2 || alerts[0].AccountID != "" {
+		t.Errorf("Expected alerts for checking and card, got %v", alerts)
+	}
+}
+
+func TestBudgetAlerts(t *testing.T) {
+	budgets := []database.Budget{
+		{CategoryID: 1, CategoryName: "Dining Out", Amount: 40000},
+		{CategoryID: 2, CategoryName: "Groceries", Amount: 60000},
+		{CategoryID: 3, CategoryName: "Shopping", Amount: 20000},
+	}
+	categoryTransactions := map[string][]database.Transaction{
+		"Dining Out": {{Amount: -30000}, {Amount: -5000}, {Amount: 2000}}, // 87%, the refund doesn't count
+		"Groceries":  {{Amount: -30000}},                                  // 50%
+		"Shopping":   {{Amount: -25000}},                                  // over
+		"Travel":     {{Amount: -90000}},                                  // no budget
+	}
+	now, _ := time.Parse("2006-01-02", "2024-03-20")
+
+	alerts := budgetAlerts(categoryTransactions, budgets, now)
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d: %v", len(alerts), alerts)
+	}
+	if alerts[0].CategoryID != 1 || alerts[0].Percent != BudgetWarnPercent || alerts[0].Kind != KindBudget {
+		t.Errorf("Expected an 80%% alert for Dining Out, got %+v", alerts[0])
+	}
+	if want := "Dining Out has used 87% of its budget: $350.00 spent of $400.00, with 11 days left in the month"; alerts[0].Message != want {
+		t.Errorf("Expected message %q, got %q", want, alerts[0].Message)
+	}
+	if alerts[1].CategoryID != 3 || alerts[1].Percent != 100 {
+		t.Errorf("Expected an over budget alert for Shopping, got %+v", alerts[1])
+	}
+}
+
+func TestUnsent(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	categoryID, err := db.SaveCategory("Dining Out")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	lowBalance := Alert{Kind: KindLowBalance, AccountID: "checking"}
+	warn := Alert{Kind: KindBudget, CategoryID: categoryID, Percent: BudgetWarnPercent}
+	over := Alert{Kind: KindBudget, CategoryID: categoryID, Percent: 100}
+	march := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+
+	send := func(now time.Time, raised ...Alert) []Alert {
+		t.Helper()
+		unsent, err := Unsent(db, raised, now)
+		if err != nil {
+			t.Fatalf("Unsent failed: %v", err)
+		}
+		return unsent
+	}
+
+	if got := send(march, lowBalance, warn); len(got) != 2 {
+		t.Errorf("Expected both alerts sent the first time, got %v", got)
+	}
+	if got := send(march, lowBalance, warn); len(got) != 1 || got[0].Kind != KindLowBalance {
+		t.Errorf("Expected only the low balance alert sent again, got %v", got)
+	}
+	if got := send(march, over); len(got) != 1 {
+		t.Errorf("Expected going over budget to be sent, got %v", got)
+	}
+	if got := send(march, warn); len(got) != 0 {
+		t.Errorf("Expected no 80%% alert after going over, got %v", got)
+	}
+	if got := send(march.AddDate(0, 1, 0), warn); len(got) != 1 {
+		t.Errorf("Expected the alert sent again the next month, got %v", got)
+	}
+}
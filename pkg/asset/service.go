@@ -0,0 +1,240 @@
+// Package asset tracks vehicles and other depreciating assets as accounts of
+// type other, valued by hand or by straight-line depreciation, so they count
+// towards net worth without editing balances.
+package asset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// OrgID is the organization asset accounts belong to
+const OrgID = "Assets"
+
+// Kinds lists the kinds of asset that can be tracked
+var Kinds = []string{"vehicle", "equipment", "other"}
+
+// IsValidKind reports whether kind is one of Kinds
+func IsValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns an asset's straight-line depreciated value at now: the
+// purchase price falling evenly to the salvage value over its useful life,
+// and never below it. It returns false for assets valued by hand.
+func Value(asset database.Asset, now time.Time) (int64, bool) {
+	if asset.UsefulLifeMonths == nil {
+		return 0, false
+	}
+
+	purchased, err := time.Parse("2006-01-02", asset.PurchaseDate)
+	if err != nil {
+		return 0, false
+	}
+	end := purchased.AddDate(0, *asset.UsefulLifeMonths, 0)
+
+	switch {
+	case !now.After(purchased):
+		return asset.PurchasePrice, true
+	case !now.Before(end):
+		return asset.SalvageValue, true
+	}
+
+	elapsed := float64(now.Sub(purchased)) / float64(end.Sub(purchased))
+	depreciation := float64(asset.PurchasePrice-asset.SalvageValue) * elapsed
+	return asset.PurchasePrice - int64(depreciation), true
+}
+
+type Service struct {
+	db *database.DB
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// accountID returns the account ID for an asset named name, e.g.
+// "asset_2019-honda-civic" for "2019 Honda Civic"
+func accountID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return "asset_" + strings.TrimSuffix(b.String(), "-")
+}
+
+// CreateAsset adds an asset account valued at its depreciated value, or its
+// purchase price when it's valued by hand
+func (s *Service) CreateAsset(name string, asset database.Asset, now time.Time) (string, error) {
+	if !IsValidKind(asset.Kind) {
+		return "", fmt.Errorf("invalid asset kind: %s. Valid kinds are: %v", asset.Kind, Kinds)
+	}
+	if _, err := time.Parse("2006-01-02", asset.PurchaseDate); err != nil {
+		return "", fmt.Errorf("invalid purchase date %q, expected YYYY-MM-DD", asset.PurchaseDate)
+	}
+
+	asset.AccountID = accountID(name)
+	if asset.AccountID == "asset_" {
+		return "", fmt.Errorf("asset name must contain a letter or digit")
+	}
+	if _, err := s.db.GetAccountByID(asset.AccountID); err == nil {
+		return "", fmt.Errorf("an account with ID %s already exists", asset.AccountID)
+	}
+
+	value, ok := Value(asset, now)
+	if !ok {
+		value = asset.PurchasePrice
+	}
+
+	if err := s.db.SaveOrganization(OrgID, OrgID, ""); err != nil {
+		return "", fmt.Errorf("failed to save organization: %w", err)
+	}
+
+	// Prices are entered by hand, in the currency totals are shown in
+	err := s.db.SaveAccount(asset.AccountID, OrgID, name, format.BaseCurrency(), value, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create asset account: %w", err)
+	}
+
+	err = s.db.SetAccountType(asset.AccountID, "other")
+	if err != nil {
+		return "", fmt.Errorf("failed to set account type to other: %w", err)
+	}
+
+	err = s.db.SaveAsset(asset)
+	if err != nil {
+		return "", fmt.Errorf("failed to save asset details: %w", err)
+	}
+
+	err = s.db.SaveBalanceHistory(asset.AccountID, value, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to save balance history: %w", err)
+	}
+
+	return asset.AccountID, nil
+}
+
+// Update is an asset whose value was changed by UpdateAllValues
+type Update struct {
+	Asset    database.Asset
+	OldValue int64
+	NewValue int64
+}
+
+// UpdateAllValues applies depreciation to every scheduled asset whose value
+// has changed since it was last updated
+func (s *Service) UpdateAllValues(now time.Time) ([]Update, error) {
+	assets, err := s.db.GetAllAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []Update
+	for _, asset := range assets {
+		value, ok := Value(asset, now)
+		if !ok {
+			continue
+		}
+
+		account, err := s.db.GetAccountByID(asset.AccountID)
+		if err != nil {
+			return updates, err
+		}
+		if account.Balance == value {
+			continue
+		}
+
+		if err := s.setBalance(asset.AccountID, value); err != nil {
+			return updates, err
+		}
+		updates = append(updates, Update{Asset: asset, OldValue: account.Balance, NewValue: value})
+	}
+
+	return updates, nil
+}
+
+// SetValue sets an asset's value by hand. Scheduled depreciation would
+// overwrite the value, so the asset switches to being valued by hand.
+func (s *Service) SetValue(accountID string, value int64) error {
+	asset, err := s.db.GetAsset(accountID)
+	if err != nil {
+		return err
+	}
+
+	if asset.UsefulLifeMonths != nil {
+		asset.UsefulLifeMonths = nil
+		if err := s.db.SaveAsset(*asset); err != nil {
+			return err
+		}
+	}
+
+	return s.setBalance(accountID, value)
+}
+
+// SetDepreciation schedules straight-line depreciation for an asset over
+// lifeMonths from its purchase date down to salvage, and applies it
+func (s *Service) SetDepreciation(accountID string, lifeMonths int, salvage int64, now time.Time) (int64, error) {
+	if lifeMonths <= 0 {
+		return 0, fmt.Errorf("useful life must be at least one month")
+	}
+
+	asset, err := s.db.GetAsset(accountID)
+	if err != nil {
+		return 0, err
+	}
+	if salvage < 0 || salvage > asset.PurchasePrice {
+		return 0, fmt.Errorf("salvage value must be between 0 and the purchase price")
+	}
+
+	asset.UsefulLifeMonths = &lifeMonths
+	asset.SalvageValue = salvage
+	if err := s.db.SaveAsset(*asset); err != nil {
+		return 0, err
+	}
+
+	value, _ := Value(*asset, now)
+	if err := s.setBalance(accountID, value); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// GetAsset returns an asset's details
+func (s *Service) GetAsset(accountID string) (*database.Asset, error) {
+	return s.db.GetAsset(accountID)
+}
+
+// ListAssets returns every asset
+func (s *Service) ListAssets() ([]database.Asset, error) {
+	return s.db.GetAllAssets()
+}
+
+func (s *Service) setBalance(accountID string, value int64) error {
+	err := s.db.UpdateAccountBalance(accountID, value)
+	if err != nil {
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+
+	err = s.db.SaveBalanceHistory(accountID, value, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save balance history: %w", err)
+	}
+
+	return nil
+}
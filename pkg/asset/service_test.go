@@ -0,0 +1,143 @@
+package asset
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestValue(t *testing.T) {
+	life := 120
+	car := database.Asset{
+		PurchasePrice:    2400000,
+		PurchaseDate:     "2020-01-01",
+		SalvageValue:     300000,
+		UsefulLifeMonths: &life,
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int64
+	}{
+		{"before purchase", time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), 2400000},
+		{"on purchase", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 2400000},
+		{"after useful life", time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC), 300000},
+	}
+	for _, tt := range tests {
+		got, ok := Value(car, tt.now)
+		if !ok || got != tt.want {
+			t.Errorf("%s: expected %d, got %d (%v)", tt.name, tt.want, got, ok)
+		}
+	}
+
+	// Halfway through its life the car has lost half of price minus salvage
+	halfway, _ := Value(car, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if halfway < 1345000 || halfway > 1355000 {
+		t.Errorf("Expected about 1350000 halfway, got %d", halfway)
+	}
+
+	car.UsefulLifeMonths = nil
+	if _, ok := Value(car, time.Now()); ok {
+		t.Error("Expected manual assets to have no depreciated value")
+	}
+}
+
+func TestAccountID(t *testing.T) {
+	tests := map[string]string{
+		"2019 Honda Civic": "asset_2019-honda-civic",
+		"  Bob's Boat!! ":  "asset_bob-s-boat",
+		"Piano":            "asset_piano",
+	}
+	for name, want := range tests {
+		if got := accountID(name); got != want {
+			t.Errorf("accountID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAssetLifecycle(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewService(db)
+	purchased := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	life := 12
+
+	accountID, err := service.CreateAsset("Work Laptop", database.Asset{
+		Kind:             "equipment",
+		PurchasePrice:    240000,
+		PurchaseDate:     "2024-01-01",
+		UsefulLifeMonths: &life,
+	}, purchased)
+	if err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	account, err := db.GetAccountByID(accountID)
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.AccountType == nil || *account.AccountType != "other" {
+		t.Errorf("Expected account type other, got %v", account.AccountType)
+	}
+	if account.Balance != 240000 {
+		t.Errorf("Expected the purchase price as the opening value, got %d", account.Balance)
+	}
+
+	if _, err := service.CreateAsset("Work Laptop", database.Asset{Kind: "equipment", PurchasePrice: 1, PurchaseDate: "2024-01-01"}, purchased); err == nil {
+		t.Error("Expected an error for a duplicate asset")
+	}
+	if _, err := service.CreateAsset("Boat", database.Asset{Kind: "yacht", PurchasePrice: 1, PurchaseDate: "2024-01-01"}, purchased); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+
+	updates, err := service.UpdateAllValues(purchased.AddDate(2, 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to update values: %v", err)
+	}
+	if len(updates) != 1 || updates[0].NewValue != 0 {
+		t.Fatalf("Expected the laptop to be fully depreciated, got %+v", updates)
+	}
+	updates, err = service.UpdateAllValues(purchased.AddDate(2, 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to update values: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("Expected no updates when values haven't changed, got %d", len(updates))
+	}
+
+	// Setting a value by hand stops depreciation
+	if err := service.SetValue(accountID, 50000); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	asset, err := service.GetAsset(accountID)
+	if err != nil {
+		t.Fatalf("Failed to get asset: %v", err)
+	}
+	if asset.UsefulLifeMonths != nil {
+		t.Error("Expected depreciation to be turned off after setting a value")
+	}
+	if updates, _ := service.UpdateAllValues(purchased.AddDate(3, 0, 0)); len(updates) != 0 {
+		t.Errorf("Expected manual assets to be left alone, got %d updates", len(updates))
+	}
+
+	value, err := service.SetDepreciation(accountID, 24, 40000, purchased.AddDate(3, 0, 0))
+	if err != nil {
+		t.Fatalf("Failed to set depreciation: %v", err)
+	}
+	if value != 40000 {
+		t.Errorf("Expected the salvage value after the useful life, got %d", value)
+	}
+}
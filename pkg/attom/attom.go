@@ -0,0 +1,107 @@
+package attom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	BaseURL = "https://api.gateway.attomdata.com/propertyapi/v1.0.0"
+)
+
+// Client represents an ATTOM Property API client
+type Client struct {
+	APIKey     string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new ATTOM API client
+func NewClient(apiKey string) *Client {
+	return &Client{
+		APIKey:  apiKey,
+		BaseURL: BaseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// AVMRequest identifies the property to value. ATTOM takes the street
+// address and the "city, state zip" line separately.
+type AVMRequest struct {
+	Address string
+	City    string
+	State   string
+	ZipCode string
+}
+
+// AVMResponse represents the response from the AVM detail API
+type AVMResponse struct {
+	Status struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"status"`
+	Property []struct {
+		AVM struct {
+			Amount struct {
+				Value *int `json:"value"`
+				Low   *int `json:"low"`
+				High  *int `json:"high"`
+			} `json:"amount"`
+		} `json:"avm"`
+	} `json:"property"`
+}
+
+// Value returns the estimated value in dollars, or nil when ATTOM has no
+// estimate for the property
+func (r *AVMResponse) Value() *int {
+	if len(r.Property) == 0 {
+		return nil
+	}
+	return r.Property[0].AVM.Amount.Value
+}
+
+// GetValueEstimate gets a property value estimate using the ATTOM AVM API
+func (c *Client) GetValueEstimate(req AVMRequest) (*AVMResponse, error) {
+	params := url.Values{}
+	params.Set("address1", req.Address)
+	params.Set("address2", strings.TrimSpace(fmt.Sprintf("%s, %s %s", req.City, req.State, req.ZipCode)))
+
+	url := fmt.Sprintf("%s/attomavm/detail?%s", c.BaseURL, params.Encode())
+
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("apikey", c.APIKey)
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "money-cli/1.0")
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make value estimate request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var avmResp AVMResponse
+	if err := json.Unmarshal(body, &avmResp); err != nil {
+		return nil, fmt.Errorf("failed to parse value estimate response: %w", err)
+	}
+
+	return &avmResp, nil
+}
@@ -0,0 +1,69 @@
+package attom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetValueEstimate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/attomavm/detail" {
+			t.Errorf("Expected path /attomavm/detail, got %s", r.URL.Path)
+		}
+		if got := r.Header.Get("apikey"); got != "test-key" {
+			t.Errorf("Expected apikey header 'test-key', got '%s'", got)
+		}
+		if got := r.URL.Query().Get("address1"); got != "123 Main St" {
+			t.Errorf("Expected address1 '123 Main St', got '%s'", got)
+		}
+		if got := r.URL.Query().Get("address2"); got != "Denver, CO 80202" {
+			t.Errorf("Expected address2 'Denver, CO 80202', got '%s'", got)
+		}
+		w.Write([]byte(`{"status":{"code":0,"msg":"SuccessWithResult"},"property":[{"avm":{"amount":{"value":512000,"low":480000,"high":545000}}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key")
+	client.BaseURL = server.URL
+
+	resp, err := client.GetValueEstimate(AVMRequest{Address: "123 Main St", City: "Denver", State: "CO", ZipCode: "80202"})
+	if err != nil {
+		t.Fatalf("GetValueEstimate failed: %v", err)
+	}
+	if resp.Value() == nil || *resp.Value() != 512000 {
+		t.Errorf("Expected value 512000, got %v", resp.Value())
+	}
+}
+
+func TestGetValueEstimateNoResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":{"code":1,"msg":"SuccessWithoutResult"},"property":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key")
+	client.BaseURL = server.URL
+
+	resp, err := client.GetValueEstimate(AVMRequest{Address: "1 Nowhere Rd"})
+	if err != nil {
+		t.Fatalf("GetValueEstimate failed: %v", err)
+	}
+	if resp.Value() != nil {
+		t.Errorf("Expected no value, got %d", *resp.Value())
+	}
+}
+
+func TestGetValueEstimateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("bad-key")
+	client.BaseURL = server.URL
+
+	if _, err := client.GetValueEstimate(AVMRequest{Address: "123 Main St"}); err == nil {
+		t.Error("Expected an error for a 401 response")
+	}
+}
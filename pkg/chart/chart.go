@@ -0,0 +1,343 @@
+// Package chart renders line and bar charts to PNG and SVG images so they
+// can be shared outside the terminal.
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// Colors for series and bars
+var (
+	Green   = color.RGBA{0x2e, 0x9e, 0x44, 0xff}
+	Blue    = color.RGBA{0x2f, 0x6f, 0xd6, 0xff}
+	Red     = color.RGBA{0xd6, 0x3b, 0x3b, 0xff}
+	Magenta = color.RGBA{0xb0, 0x3f, 0xb8, 0xff}
+	Gold    = color.RGBA{0xd4, 0xa0, 0x17, 0xff}
+	Yellow  = color.RGBA{0xc9, 0xb8, 0x1a, 0xff}
+	Cyan    = color.RGBA{0x1a, 0xa6, 0xb7, 0xff}
+	Gray    = color.RGBA{0x80, 0x80, 0x80, 0xff}
+
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	gridColor  = color.RGBA{0xe4, 0xe4, 0xe4, 0xff}
+)
+
+// palette colors series and bars that don't set their own
+var palette = []color.Color{Blue, Green, Red, Magenta, Gold, Cyan, Yellow, Gray}
+
+const (
+	width = 960
+	// charWidth and lineHeight are the size of the fixed-width font both
+	// formats lay text out with
+	charWidth  = 7
+	lineHeight = 13
+	padding    = 16
+)
+
+// Chart is a chart that can be saved as an image
+type Chart interface {
+	// size returns the chart's width and height in pixels
+	size() (int, int)
+	// draw lays the chart out on a canvas
+	draw(c canvas)
+}
+
+// anchor is which part of a string lines up with the x coordinate it's
+// drawn at
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type point struct {
+	x, y float64
+}
+
+// canvas is the drawing surface each image format provides. Text is drawn
+// with its baseline at y.
+type canvas interface {
+	rect(x, y, w, h float64, c color.Color)
+	polyline(points []point, strokeWidth float64, c color.Color)
+	text(x, y float64, s string, a anchor, c color.Color)
+}
+
+// Save writes a chart to path, as a PNG or SVG image depending on its
+// extension
+func Save(path string, c Chart) error {
+	var write func(*os.File, Chart) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		write = func(f *os.File, c Chart) error { return writePNG(f, c) }
+	case ".svg":
+		write = func(f *os.File, c Chart) error { return writeSVG(f, c) }
+	default:
+		return fmt.Errorf("unsupported chart format %q, use .png or .svg", filepath.Ext(path))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create chart file: %w", err)
+	}
+	if err := write(f, c); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write chart: %w", err)
+	}
+	return nil
+}
+
+// Series is one line on a line chart, with a value per date. Values are in
+// dollars.
+type Series struct {
+	Name   string
+	Values []float64
+	Color  color.Color // defaults to the next palette color
+}
+
+// Line is a line chart of one or more series over the same dates, which
+// are YYYY-MM-DD strings
+type Line struct {
+	Title  string
+	Dates  []string
+	Series []Series
+}
+
+const lineChartHeight = 480
+
+func (l *Line) size() (int, int) {
+	return width, lineChartHeight
+}
+
+func (l *Line) draw(c canvas) {
+	c.rect(0, 0, width, lineChartHeight, background)
+	c.text(width/2, padding+lineHeight, l.Title, anchorMiddle, foreground)
+
+	lower, upper := l.bounds()
+	ticks := niceTicks(lower, upper, 5)
+	lower = math.Min(lower, ticks[0])
+	upper = math.Max(upper, ticks[len(ticks)-1])
+
+	labelWidth := 0
+	for _, tick := range ticks {
+		labelWidth = max(labelWidth, textWidth(dollars(tick)))
+	}
+
+	// Plot area, leaving room for the title above, y labels to the left
+	// and date labels and the legend below
+	left := float64(padding + labelWidth + 8)
+	right := float64(width - padding*2)
+	top := float64(padding*2 + lineHeight*2)
+	bottom := float64(lineChartHeight - padding*2 - lineHeight*3)
+
+	y := func(v float64) float64 {
+		if upper == lower {
+			return (top + bottom) / 2
+		}
+		return bottom - (v-lower)/(upper-lower)*(bottom-top)
+	}
+	x := func(i int) float64 {
+		if len(l.Dates) < 2 {
+			return left
+		}
+		return left + float64(i)/float64(len(l.Dates)-1)*(right-left)
+	}
+
+	for _, tick := range ticks {
+		c.polyline([]point{{left, y(tick)}, {right, y(tick)}}, 1, gridColor)
+		c.text(left-8, y(tick)+lineHeight/2-2, dollars(tick), anchorEnd, foreground)
+	}
+	c.polyline([]point{{left, top}, {left, bottom}, {right, bottom}}, 1, foreground)
+
+	for _, i := range dateTicks(len(l.Dates), int(right-left), textWidth("2006-01-02")) {
+		c.polyline([]point{{x(i), bottom}, {x(i), bottom + 4}}, 1, foreground)
+		a := anchorMiddle
+		if i == 0 {
+			a = anchorStart
+		} else if i == len(l.Dates)-1 {
+			a = anchorEnd
+		}
+		c.text(x(i), bottom+6+lineHeight, dateLabel(l.Dates, i), a, foreground)
+	}
+
+	legendX := left
+	legendY := bottom + padding + lineHeight*2
+	for i, series := range l.Series {
+		seriesColor := series.Color
+		if seriesColor == nil {
+			seriesColor = palette[i%len(palette)]
+		}
+
+		points := make([]point, 0, len(series.Values))
+		for j, value := range series.Values {
+			points = append(points, point{x(j), y(value)})
+		}
+		c.polyline(points, 2, seriesColor)
+
+		c.rect(legendX, legendY-lineHeight+3, 10, 10, seriesColor)
+		c.text(legendX+14, legendY, series.Name, anchorStart, foreground)
+		legendX += float64(14 + textWidth(series.Name) + padding)
+	}
+}
+
+// bounds returns the smallest and largest values across all series, padded
+// by 5% so lines don't touch the edges
+func (l *Line) bounds() (float64, float64) {
+	lower, upper := math.Inf(1), math.Inf(-1)
+	for _, series := range l.Series {
+		for _, value := range series.Values {
+			lower = math.Min(lower, value)
+			upper = math.Max(upper, value)
+		}
+	}
+	if math.IsInf(lower, 1) {
+		return 0, 1
+	}
+	if lower == upper {
+		return lower - 1, upper + 1
+	}
+	pad := (upper - lower) * 0.05
+	return lower - pad, upper + pad
+}
+
+// Bar is one labeled bar on a bar chart, in dollars
+type Bar struct {
+	Label string
+	Value float64
+	Color color.Color // defaults to the first palette color
+}
+
+// Bars is a horizontal bar chart, drawn top to bottom in order
+type Bars struct {
+	Title string
+	Bars  []Bar
+}
+
+const barRowHeight = 24
+
+func (b *Bars) size() (int, int) {
+	return width, padding*4 + lineHeight + len(b.Bars)*barRowHeight
+}
+
+func (b *Bars) draw(c canvas) {
+	_, height := b.size()
+	c.rect(0, 0, width, float64(height), background)
+	c.text(width/2, padding+lineHeight, b.Title, anchorMiddle, foreground)
+
+	labelWidth, valueWidth := 0, 0
+	largest := 0.0
+	for _, bar := range b.Bars {
+		labelWidth = max(labelWidth, textWidth(bar.Label))
+		valueWidth = max(valueWidth, textWidth(dollars(bar.Value)))
+		largest = math.Max(largest, math.Abs(bar.Value))
+	}
+
+	left := float64(padding + labelWidth + 8)
+	right := float64(width - padding - valueWidth - 8)
+	top := float64(padding*3 + lineHeight)
+
+	for i, bar := range b.Bars {
+		barColor := bar.Color
+		if barColor == nil {
+			barColor = palette[0]
+		}
+
+		rowTop := top + float64(i*barRowHeight)
+		baseline := rowTop + barRowHeight/2 + lineHeight/2 - 2
+		length := 0.0
+		if largest > 0 {
+			length = math.Abs(bar.Value) / largest * (right - left)
+		}
+
+		c.text(left-8, baseline, bar.Label, anchorEnd, foreground)
+		c.rect(left, rowTop+4, length, barRowHeight-8, barColor)
+		c.text(left+length+8, baseline, dollars(bar.Value), anchorStart, foreground)
+	}
+	c.polyline([]point{{left, top}, {left, top + float64(len(b.Bars)*barRowHeight)}}, 1, foreground)
+}
+
+// textWidth returns how many pixels wide s is in the chart font
+func textWidth(s string) int {
+	return len([]rune(s)) * charWidth
+}
+
+// dollars formats an axis or bar value as whole dollars
+func dollars(value float64) string {
+	whole := int64(math.Round(value))
+	if whole < 0 {
+		return "-$" + format.WithCommas(-whole)
+	}
+	return "$" + format.WithCommas(whole)
+}
+
+// niceTicks returns about count evenly spaced round values covering lower
+// to upper, stepping by 1, 2 or 5 times a power of ten
+func niceTicks(lower, upper float64, count int) []float64 {
+	if upper <= lower {
+		return []float64{lower}
+	}
+
+	rough := (upper - lower) / float64(count-1)
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+	step := magnitude * 10
+	for _, multiple := range []float64{1, 2, 5} {
+		if magnitude*multiple >= rough {
+			step = magnitude * multiple
+			break
+		}
+	}
+
+	start := math.Floor(lower/step) * step
+	ticks := []float64{start}
+	for i := 1; ticks[len(ticks)-1] < upper; i++ {
+		ticks = append(ticks, start+float64(i)*step)
+	}
+	return ticks
+}
+
+// dateTicks picks which of n dates to label along an axis span pixels
+// wide, always including the first and last
+func dateTicks(n, span, labelWidth int) []int {
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return []int{0}
+	}
+
+	count := min(n, max(2, span/(labelWidth*2)+1))
+	indexes := make([]int, 0, count)
+	for k := 0; k < count; k++ {
+		index := int(math.Round(float64(k*(n-1)) / float64(count-1)))
+		if len(indexes) == 0 || indexes[len(indexes)-1] != index {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// dateLabel formats dates[i] as month and day when all dates fall in the
+// same year, or leaves it as YYYY-MM-DD otherwise
+func dateLabel(dates []string, i int) string {
+	first, last := dates[0], dates[len(dates)-1]
+	if len(first) < 4 || len(last) < 4 || first[:4] != last[:4] {
+		return dates[i]
+	}
+	parsed, err := time.Parse("2006-01-02", dates[i])
+	if err != nil {
+		return dates[i]
+	}
+	return parsed.Format("Jan 2")
+}
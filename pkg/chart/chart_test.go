@@ -0,0 +1,153 @@
+package chart
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		lower, upper float64
+		expected     []float64
+	}{
+		{0, 100, []float64{0, 50, 100}},
+		{12, 97, []float64{0, 50, 100}},
+		{-150, 40, []float64{-150, -100, -50, 0, 50}},
+		{1234, 1290, []float64{1220, 1240, 1260, 1280, 1300}},
+		{5, 5, []float64{5}},
+	}
+
+	for _, tt := range tests {
+		ticks := niceTicks(tt.lower, tt.upper, 5)
+		if len(ticks) != len(tt.expected) {
+			t.Errorf("niceTicks(%v, %v) = %v; want %v", tt.lower, tt.upper, ticks, tt.expected)
+			continue
+		}
+		for i := range ticks {
+			if ticks[i] != tt.expected[i] {
+				t.Errorf("niceTicks(%v, %v) = %v; want %v", tt.lower, tt.upper, ticks, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestDateTicks(t *testing.T) {
+	if ticks := dateTicks(0, 800, 70); len(ticks) != 0 {
+		t.Errorf("Expected no ticks without dates, got %v", ticks)
+	}
+	if ticks := dateTicks(1, 800, 70); len(ticks) != 1 || ticks[0] != 0 {
+		t.Errorf("Expected a single tick, got %v", ticks)
+	}
+
+	ticks := dateTicks(30, 800, 70)
+	if ticks[0] != 0 || ticks[len(ticks)-1] != 29 {
+		t.Errorf("Expected the first and last dates to be labeled, got %v", ticks)
+	}
+	if len(ticks) != 6 {
+		t.Errorf("Expected 6 ticks for 800 pixels, got %v", ticks)
+	}
+
+	// Never more ticks than dates
+	if ticks := dateTicks(3, 800, 70); len(ticks) != 3 {
+		t.Errorf("Expected a tick per date, got %v", ticks)
+	}
+}
+
+func TestDateLabel(t *testing.T) {
+	sameYear := []string{"2024-01-05", "2024-03-09"}
+	if label := dateLabel(sameYear, 1); label != "Mar 9" {
+		t.Errorf("Expected Mar 9, got %q", label)
+	}
+	acrossYears := []string{"2023-12-30", "2024-01-02"}
+	if label := dateLabel(acrossYears, 0); label != "2023-12-30" {
+		t.Errorf("Expected the full date across years, got %q", label)
+	}
+}
+
+func TestDollars(t *testing.T) {
+	tests := map[float64]string{
+		0:          "$0",
+		1234.56:    "$1,235",
+		-123456.4:  "-$123,456",
+		1000000.01: "$1,000,000",
+	}
+	for value, expected := range tests {
+		if got := dollars(value); got != expected {
+			t.Errorf("dollars(%v) = %q; want %q", value, got, expected)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	line := &Line{
+		Title: "Balances & Trends",
+		Dates: []string{"2024-01-01", "2024-01-02", "2024-01-03"},
+		Series: []Series{
+			{Name: "Cash", Values: []float64{100, 150, 125}, Color: Green},
+			{Name: "Net Worth", Values: []float64{1000, 900, 1100}},
+		},
+	}
+	bars := &Bars{
+		Title: "Expenses",
+		Bars: []Bar{
+			{Label: "Groceries", Value: 420.5, Color: Red},
+			{Label: "Dining", Value: 80},
+		},
+	}
+
+	dir := t.TempDir()
+
+	pngPath := filepath.Join(dir, "chart.PNG")
+	if err := Save(pngPath, line); err != nil {
+		t.Fatalf("Failed to save PNG: %v", err)
+	}
+	f, err := os.Open(pngPath)
+	if err != nil {
+		t.Fatalf("Failed to open PNG: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != width || bounds.Dy() != lineChartHeight {
+		t.Errorf("Expected a %dx%d image, got %v", width, lineChartHeight, bounds)
+	}
+
+	svgPath := filepath.Join(dir, "bars.svg")
+	if err := Save(svgPath, bars); err != nil {
+		t.Fatalf("Failed to save SVG: %v", err)
+	}
+	data, err := os.ReadFile(svgPath)
+	if err != nil {
+		t.Fatalf("Failed to read SVG: %v", err)
+	}
+	svg := string(data)
+	for _, want := range []string{"<svg", "Expenses", "Groceries", "$421", "#d63b3b", "</svg>"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("Expected SVG to contain %q", want)
+		}
+	}
+
+	if err := Save(filepath.Join(dir, "line.svg"), line); err != nil {
+		t.Fatalf("Failed to save SVG: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "line.svg"))
+	if err != nil {
+		t.Fatalf("Failed to read SVG: %v", err)
+	}
+	if !strings.Contains(string(data), "Balances &amp; Trends") {
+		t.Error("Expected the title to be escaped in the SVG")
+	}
+
+	if err := Save(filepath.Join(dir, "chart.jpg"), line); err == nil {
+		t.Error("Expected error saving an unsupported format")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "chart.jpg")); !os.IsNotExist(err) {
+		t.Error("Expected no file for an unsupported format")
+	}
+}
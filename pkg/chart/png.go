@@ -0,0 +1,73 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// pngCanvas draws onto an in-memory image with antialiased lines and the
+// basic 7x13 font
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func writePNG(w io.Writer, c Chart) error {
+	width, height := c.size()
+	canvas := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.draw(canvas)
+	return png.Encode(w, canvas.img)
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, c color.Color) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(p.img, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// polyline strokes each segment as a quad; they all wind the same way, so
+// the overlaps at the joints don't cancel out
+func (p *pngCanvas) polyline(points []point, strokeWidth float64, c color.Color) {
+	bounds := p.img.Bounds()
+	r := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	half := float32(strokeWidth / 2)
+	for i := 1; i < len(points); i++ {
+		x0, y0 := float32(points[i-1].x), float32(points[i-1].y)
+		x1, y1 := float32(points[i].x), float32(points[i].y)
+		dx, dy := x1-x0, y1-y0
+		length := float32(math.Hypot(float64(dx), float64(dy)))
+		if length == 0 {
+			continue
+		}
+		nx, ny := -dy/length*half, dx/length*half
+		r.MoveTo(x0+nx, y0+ny)
+		r.LineTo(x1+nx, y1+ny)
+		r.LineTo(x1-nx, y1-ny)
+		r.LineTo(x0-nx, y0-ny)
+		r.ClosePath()
+	}
+	r.Draw(p.img, bounds, image.NewUniform(c), image.Point{})
+}
+
+func (p *pngCanvas) text(x, y float64, s string, a anchor, c color.Color) {
+	switch a {
+	case anchorMiddle:
+		x -= float64(textWidth(s)) / 2
+	case anchorEnd:
+		x -= float64(textWidth(s))
+	}
+	d := font.Drawer{
+		Dst:  p.img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(int(math.Round(x)), int(math.Round(y))),
+	}
+	d.DrawString(s)
+}
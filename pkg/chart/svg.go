@@ -0,0 +1,56 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// svgCanvas writes each shape as an SVG element
+type svgCanvas struct {
+	w *bufio.Writer
+}
+
+func writeSVG(w io.Writer, c Chart) error {
+	width, height := c.size()
+	canvas := &svgCanvas{w: bufio.NewWriter(w)}
+	fmt.Fprintf(canvas.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="12">`+"\n",
+		width, height, width, height)
+	c.draw(canvas)
+	fmt.Fprintln(canvas.w, "</svg>")
+	return canvas.w.Flush()
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, c color.Color) {
+	fmt.Fprintf(s.w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, hexColor(c))
+}
+
+func (s *svgCanvas) polyline(points []point, strokeWidth float64, c color.Color) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+	}
+	fmt.Fprintf(s.w, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f" stroke-linejoin="round"/>`+"\n",
+		strings.Join(coords, " "), hexColor(c), strokeWidth)
+}
+
+func (s *svgCanvas) text(x, y float64, text string, a anchor, c color.Color) {
+	textAnchor := "start"
+	switch a {
+	case anchorMiddle:
+		textAnchor = "middle"
+	case anchorEnd:
+		textAnchor = "end"
+	}
+	fmt.Fprintf(s.w, `<text x="%.1f" y="%.1f" text-anchor="%s" fill="%s">%s</text>`+"\n",
+		x, y, textAnchor, hexColor(c), html.EscapeString(text))
+}
+
+// hexColor formats a color as #rrggbb
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -1,51 +1,286 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration options for the money CLI
 type Config struct {
-	// MoneyDir is the directory where money data is stored
+	// MoneyDir is the directory where money data is stored, the profile's
+	// directory when a profile other than the default is selected
 	MoneyDir string
+	Profile  string
 
 	// LLM configuration
 	LLMPromptCmd  string
 	LLMBatchSize  int
 
+	// LLMParallelism is how many LLM categorization batches run at once,
+	// and LLMRequestsPerMinute caps how often the LLM command is started
+	// (0 for no limit)
+	LLMParallelism       int
+	LLMRequestsPerMinute int
+
+	// TUI appearance and keybindings
+	Theme       string            // "dark" or "light"
+	ThemeColors map[string]string // color overrides by role, e.g. accent -> #005f87
+	KeyBindings map[string]string // key overrides by action, e.g. down -> s
+
+	// LogToFile also writes log output, including debug messages, to LogDir
+	LogToFile bool
+
+	// ReadOnly opens the database read-only and refuses commands that
+	// change it
+	ReadOnly bool
+
+	// Timezone is the IANA time zone dates are shown, filtered and grouped
+	// in, e.g. America/New_York; empty for the system's time zone
+	Timezone string
+
+	// Locale sets the separators amounts are written with, e.g. de-DE;
+	// empty for en-US. AccountingNegatives writes negative amounts in
+	// parentheses.
+	Locale              string
+	AccountingNegatives bool
+
+	// Currency is the currency code of amounts that aren't one account's,
+	// such as totals across accounts and budgets; empty for USD
+	Currency string
+
+	// Pager is the command long output is paged through when stdout is a
+	// terminal; empty for $PAGER, then less
+	Pager string
+
+	// BudgetIncomeMode is the income money budget plans a month against:
+	// "actual" for the month's deposits, or "3-month" or "6-month" for the
+	// average of the months before it
+	BudgetIncomeMode string
+
+	// EmergencyFundMonths is how many months of expenses cash should cover
+	// before balance and the digest warn (0 to not warn)
+	EmergencyFundMonths int
+
+	// UtilizationWarnPercent is the percent of a card's credit limit above
+	// which money report utilization flags it
+	UtilizationWarnPercent int
+
+	// SimpleFIN retries: attempts per request and the total requests one
+	// fetch may make (0 for no limit)
+	SimpleFINMaxAttempts   int
+	SimpleFINRequestBudget int
+
+	// StaleAccountDays is how long a linked account can go without updating
+	// before balance and doctor warn about it (0 to not warn)
+	StaleAccountDays int
+
+	// ATTOMAPIKey enables ATTOM as a property valuation provider
+	ATTOMAPIKey string
+
+	// PropertyMaxAgeDays is how old a property valuation can get before
+	// update-all and fetch refresh it (0 to refresh every time)
+	PropertyMaxAgeDays int
+
+	// RentCastRequestsPerSecond caps how fast property valuations call
+	// RentCast, to stay within the API key's rate limit
+	RentCastRequestsPerSecond int
+
+	// Investment quotes: the source holdings are revalued from (stooq,
+	// yahoo or alphavantage) and the Alpha Vantage API key
+	QuoteSource        string
+	AlphaVantageAPIKey string
+
+	// MigrationBackups is how many copies of the database taken before
+	// schema migrations are kept in BackupDir (0 to not take them)
+	MigrationBackups int
+
+	// Sync between machines: where money sync pushes the encrypted
+	// database, and the passphrase it is encrypted with
+	SyncRemote     string
+	SyncPassphrase string
+
+	// YNAB sync: the personal access token and the budget money ynab
+	// pushes to and pulls from (the last one used when empty)
+	YNABToken  string
+	YNABBudget string
+
+	// Actual Budget: the actual-http-api server money actual pushes to, its
+	// API key, the budget's sync ID and, for encrypted budgets, its password
+	ActualURL      string
+	ActualAPIKey   string
+	ActualBudget   string
+	ActualPassword string
+
+	// Teller: the application ID Teller Connect enrolls banks under, and
+	// the client certificate and private key files requests are made with
+	TellerApplicationID string
+	TellerCertificate   string
+	TellerPrivateKey    string
+
+	// Notification sinks; each one is enabled when its address is set
+	NotifyWebhookURL string // generic webhook that receives a JSON POST
+	NotifyNtfyURL    string // ntfy topic URL, e.g. https://ntfy.sh/my-money
+	NotifyNtfyToken  string // optional ntfy access token
+	SMTPHost         string // email via SMTP
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	SMTPTo           []string
+
 	// Default values
-	DefaultLLMPromptCmd  string
-	DefaultLLMBatchSize  int
-	DefaultMoneyDirName  string
+	DefaultLLMPromptCmd              string
+	DefaultLLMBatchSize              int
+	DefaultLLMParallelism            int
+	DefaultMoneyDirName              string
+	DefaultTheme                     string
+	DefaultSMTPPort                  int
+	DefaultSimpleFINMaxAttempts      int
+	DefaultSimpleFINRequestBudget    int
+	DefaultStaleAccountDays          int
+	DefaultQuoteSource               string
+	DefaultBudgetIncomeMode          string
+	DefaultEmergencyFundMonths       int
+	DefaultUtilizationWarnPercent    int
+	DefaultMigrationBackups          int
+	DefaultRentCastRequestsPerSecond int
+
+	// fileValues holds settings read from config.toml, keyed by the
+	// environment variable that overrides them
+	fileValues map[string]string
+
+	// baseDir is the configured MONEY_DIR that profile directories live in
+	baseDir string
 }
 
-// New creates a new configuration instance with values from environment variables
+// New creates a new configuration instance with values from the config file,
+// overridden by environment variables
 func New() *Config {
 	cfg := &Config{
-		DefaultLLMPromptCmd:  "claude",
-		DefaultLLMBatchSize:  10,
-		DefaultMoneyDirName:  ".money",
+		DefaultLLMPromptCmd:              "claude",
+		DefaultLLMBatchSize:              10,
+		DefaultLLMParallelism:            4,
+		DefaultMoneyDirName:              ".money",
+		DefaultTheme:                     "dark",
+		DefaultSMTPPort:                  587,
+		DefaultSimpleFINMaxAttempts:      4,
+		DefaultSimpleFINRequestBudget:    10,
+		DefaultStaleAccountDays:          7,
+		DefaultQuoteSource:               "stooq",
+		DefaultBudgetIncomeMode:          "actual",
+		DefaultEmergencyFundMonths:       6,
+		DefaultUtilizationWarnPercent:    30,
+		DefaultMigrationBackups:          5,
+		DefaultRentCastRequestsPerSecond: 20,
 	}
 
+	cfg.loadFromFiles()
 	cfg.loadFromEnvironment()
 	return cfg
 }
 
-// loadFromEnvironment loads configuration from environment variables
+// loadFromEnvironment loads configuration from environment variables, falling
+// back to values read from the config file
 func (c *Config) loadFromEnvironment() {
 	// Money directory
-	c.MoneyDir = c.getMoneyDir()
+	c.Profile = c.getProfile()
+	c.baseDir = c.getBaseDir()
+	c.MoneyDir = profileDir(c.baseDir, c.Profile)
 
 	// LLM configuration
 	c.LLMPromptCmd = c.getLLMPromptCmd()
 	c.LLMBatchSize = c.getLLMBatchSize()
+	c.LLMParallelism = c.getInt("LLM_PARALLELISM", 1, c.DefaultLLMParallelism)
+	c.LLMRequestsPerMinute = c.getInt("LLM_REQUESTS_PER_MINUTE", 0, 0)
+
+	// TUI configuration
+	c.Theme = c.getTheme()
+	c.ThemeColors = parseKeyValueList(c.getenv("MONEY_THEME_COLORS"))
+	c.KeyBindings = parseKeyValueList(c.getenv("MONEY_KEYS"))
+
+	// Logging configuration
+	c.LogToFile = parseBool(c.getenv("MONEY_LOG_FILE"))
+
+	// Database access configuration
+	c.ReadOnly = readOnlyOverride || parseBool(c.getenv("MONEY_READONLY"))
+
+	// Date configuration
+	c.Timezone = c.getenv("MONEY_TIMEZONE")
+
+	// Amount formatting configuration
+	c.Locale = c.getenv("MONEY_LOCALE")
+	c.AccountingNegatives = parseBool(c.getenv("MONEY_ACCOUNTING_NEGATIVES"))
+	c.Currency = c.getenv("MONEY_CURRENCY")
+
+	// Output configuration
+	c.Pager = c.getenv("MONEY_PAGER")
+
+	// Budget configuration
+	c.BudgetIncomeMode = c.getBudgetIncomeMode()
+	c.EmergencyFundMonths = c.getInt("MONEY_EMERGENCY_FUND_MONTHS", 0, c.DefaultEmergencyFundMonths)
+
+	// Credit configuration
+	c.UtilizationWarnPercent = c.getInt("MONEY_UTILIZATION_WARN_PERCENT", 1, c.DefaultUtilizationWarnPercent)
+
+	// SimpleFIN configuration
+	c.SimpleFINMaxAttempts = c.getInt("MONEY_SIMPLEFIN_MAX_ATTEMPTS", 1, c.DefaultSimpleFINMaxAttempts)
+	c.SimpleFINRequestBudget = c.getInt("MONEY_SIMPLEFIN_REQUEST_BUDGET", 0, c.DefaultSimpleFINRequestBudget)
+	c.StaleAccountDays = c.getInt("MONEY_STALE_ACCOUNT_DAYS", 0, c.DefaultStaleAccountDays)
+
+	// Property valuation configuration
+	c.ATTOMAPIKey = c.getenv("ATTOM_API_KEY")
+	c.PropertyMaxAgeDays = c.getInt("MONEY_PROPERTY_MAX_AGE_DAYS", 0, 0)
+	c.RentCastRequestsPerSecond = c.getInt("MONEY_RENTCAST_REQUESTS_PER_SECOND", 1, c.DefaultRentCastRequestsPerSecond)
+
+	// Investment quote configuration
+	c.QuoteSource = c.getQuoteSource()
+	c.AlphaVantageAPIKey = c.getenv("ALPHA_VANTAGE_API_KEY")
+
+	// Database configuration
+	c.MigrationBackups = c.getInt("MONEY_MIGRATION_BACKUPS", 0, c.DefaultMigrationBackups)
+
+	// Sync configuration
+	c.SyncRemote = c.getenv("MONEY_SYNC_REMOTE")
+	c.SyncPassphrase = c.getenv("MONEY_SYNC_PASSPHRASE")
+
+	// YNAB configuration
+	c.YNABToken = c.getenv("YNAB_ACCESS_TOKEN")
+	c.YNABBudget = c.getenv("MONEY_YNAB_BUDGET")
+
+	// Actual Budget configuration
+	c.ActualURL = c.getenv("MONEY_ACTUAL_URL")
+	c.ActualAPIKey = c.getenv("MONEY_ACTUAL_API_KEY")
+	c.ActualBudget = c.getenv("MONEY_ACTUAL_BUDGET")
+	c.ActualPassword = c.getenv("MONEY_ACTUAL_PASSWORD")
+
+	// Teller configuration
+	c.TellerApplicationID = c.getenv("MONEY_TELLER_APPLICATION_ID")
+	c.TellerCertificate = c.getenv("MONEY_TELLER_CERTIFICATE")
+	c.TellerPrivateKey = c.getenv("MONEY_TELLER_PRIVATE_KEY")
+
+	// Notification configuration
+	c.NotifyWebhookURL = c.getenv("MONEY_NOTIFY_WEBHOOK")
+	c.NotifyNtfyURL = c.getenv("MONEY_NOTIFY_NTFY")
+	c.NotifyNtfyToken = c.getenv("MONEY_NOTIFY_NTFY_TOKEN")
+	c.SMTPHost = c.getenv("MONEY_SMTP_HOST")
+	c.SMTPPort = c.getSMTPPort()
+	c.SMTPUsername = c.getenv("MONEY_SMTP_USER")
+	c.SMTPPassword = c.getenv("MONEY_SMTP_PASSWORD")
+	c.SMTPFrom = c.getenv("MONEY_SMTP_FROM")
+	c.SMTPTo = parseList(c.getenv("MONEY_SMTP_TO"))
 }
 
-// getMoneyDir returns the money directory path
-func (c *Config) getMoneyDir() string {
-	if dir := os.Getenv("MONEY_DIR"); dir != "" {
+// getBaseDir returns the money directory path, before any profile is applied
+func (c *Config) getBaseDir() string {
+	if moneyDirOverride != "" {
+		return moneyDirOverride
+	}
+	if dir := c.getenv("MONEY_DIR"); dir != "" {
 		return dir
 	}
 	home, _ := os.UserHomeDir()
@@ -54,7 +289,7 @@ func (c *Config) getMoneyDir() string {
 
 // getLLMPromptCmd returns the LLM prompt command
 func (c *Config) getLLMPromptCmd() string {
-	if cmd := os.Getenv("LLM_PROMPT_CMD"); cmd != "" {
+	if cmd := c.getenv("LLM_PROMPT_CMD"); cmd != "" {
 		return cmd
 	}
 	return c.DefaultLLMPromptCmd
@@ -62,7 +297,7 @@ func (c *Config) getLLMPromptCmd() string {
 
 // getLLMBatchSize returns the LLM batch size
 func (c *Config) getLLMBatchSize() int {
-	if batchSizeStr := os.Getenv("LLM_BATCH_SIZE"); batchSizeStr != "" {
+	if batchSizeStr := c.getenv("LLM_BATCH_SIZE"); batchSizeStr != "" {
 		if batchSize, err := strconv.Atoi(batchSizeStr); err == nil && batchSize > 0 {
 			return batchSize
 		}
@@ -70,9 +305,107 @@ func (c *Config) getLLMBatchSize() int {
 	return c.DefaultLLMBatchSize
 }
 
-// SetMoneyDir updates the money directory path
+// getTheme returns the TUI color theme name
+func (c *Config) getTheme() string {
+	if theme := c.getenv("MONEY_THEME"); theme != "" {
+		return strings.ToLower(theme)
+	}
+	return c.DefaultTheme
+}
+
+// getQuoteSource returns the source investment quotes are fetched from
+func (c *Config) getQuoteSource() string {
+	if source := c.getenv("MONEY_QUOTE_SOURCE"); source != "" {
+		return strings.ToLower(source)
+	}
+	return c.DefaultQuoteSource
+}
+
+// getBudgetIncomeMode returns the income money budget plans against
+func (c *Config) getBudgetIncomeMode() string {
+	if mode := c.getenv("MONEY_BUDGET_INCOME_MODE"); mode != "" {
+		return strings.ToLower(mode)
+	}
+	return c.DefaultBudgetIncomeMode
+}
+
+// getSMTPPort returns the SMTP server port
+func (c *Config) getSMTPPort() int {
+	if portStr := c.getenv("MONEY_SMTP_PORT"); portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil && port > 0 {
+			return port
+		}
+	}
+	return c.DefaultSMTPPort
+}
+
+// getInt returns the integer setting name, or def when it is unset, not a
+// number, or below min
+func (c *Config) getInt(name string, min, def int) int {
+	if valueStr := c.getenv(name); valueStr != "" {
+		if value, err := strconv.Atoi(valueStr); err == nil && value >= min {
+			return value
+		}
+	}
+	return def
+}
+
+// parseBool reports whether value is one of 1, true, yes or on
+func parseBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// parseList parses a comma-separated list, skipping empty entries
+func parseList(list string) []string {
+	var values []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// parseKeyValueList parses a comma-separated list of name=value pairs, such
+// as "accent=#005f87,muted=#666". Malformed entries are skipped.
+func parseKeyValueList(list string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		name, value, found := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !found || name == "" || value == "" {
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// formatKeyValueList is the inverse of parseKeyValueList, with names sorted
+func formatKeyValueList(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = name + "=" + values[name]
+	}
+	return strings.Join(entries, ",")
+}
+
+// SetMoneyDir updates the money directory path; the selected profile, if
+// any, lives inside it
 func (c *Config) SetMoneyDir(dir string) {
-	c.MoneyDir = dir
+	c.baseDir = dir
+	c.MoneyDir = profileDir(dir, c.Profile)
 }
 
 // SetLLMPromptCmd updates the LLM prompt command
@@ -89,8 +422,8 @@ func (c *Config) SetLLMBatchSize(size int) {
 func (c *Config) ToEnvironmentVars() map[string]string {
 	vars := make(map[string]string)
 
-	if c.MoneyDir != "" {
-		vars["MONEY_DIR"] = c.MoneyDir
+	if c.baseDir != "" {
+		vars["MONEY_DIR"] = c.baseDir
 	}
 
 	if c.LLMPromptCmd != c.DefaultLLMPromptCmd {
@@ -101,6 +434,119 @@ func (c *Config) ToEnvironmentVars() map[string]string {
 		vars["LLM_BATCH_SIZE"] = strconv.Itoa(c.LLMBatchSize)
 	}
 
+	if c.LLMParallelism != c.DefaultLLMParallelism {
+		vars["LLM_PARALLELISM"] = strconv.Itoa(c.LLMParallelism)
+	}
+
+	if c.LLMRequestsPerMinute != 0 {
+		vars["LLM_REQUESTS_PER_MINUTE"] = strconv.Itoa(c.LLMRequestsPerMinute)
+	}
+
+	if c.Theme != c.DefaultTheme {
+		vars["MONEY_THEME"] = c.Theme
+	}
+
+	if len(c.ThemeColors) > 0 {
+		vars["MONEY_THEME_COLORS"] = formatKeyValueList(c.ThemeColors)
+	}
+
+	if len(c.KeyBindings) > 0 {
+		vars["MONEY_KEYS"] = formatKeyValueList(c.KeyBindings)
+	}
+
+	if c.LogToFile {
+		vars["MONEY_LOG_FILE"] = "true"
+	}
+
+	if c.BudgetIncomeMode != c.DefaultBudgetIncomeMode {
+		vars["MONEY_BUDGET_INCOME_MODE"] = c.BudgetIncomeMode
+	}
+
+	if c.EmergencyFundMonths != c.DefaultEmergencyFundMonths {
+		vars["MONEY_EMERGENCY_FUND_MONTHS"] = strconv.Itoa(c.EmergencyFundMonths)
+	}
+
+	if c.UtilizationWarnPercent != c.DefaultUtilizationWarnPercent {
+		vars["MONEY_UTILIZATION_WARN_PERCENT"] = strconv.Itoa(c.UtilizationWarnPercent)
+	}
+
+	if c.SimpleFINMaxAttempts != c.DefaultSimpleFINMaxAttempts {
+		vars["MONEY_SIMPLEFIN_MAX_ATTEMPTS"] = strconv.Itoa(c.SimpleFINMaxAttempts)
+	}
+
+	if c.SimpleFINRequestBudget != c.DefaultSimpleFINRequestBudget {
+		vars["MONEY_SIMPLEFIN_REQUEST_BUDGET"] = strconv.Itoa(c.SimpleFINRequestBudget)
+	}
+
+	if c.StaleAccountDays != c.DefaultStaleAccountDays {
+		vars["MONEY_STALE_ACCOUNT_DAYS"] = strconv.Itoa(c.StaleAccountDays)
+	}
+
+	if c.ATTOMAPIKey != "" {
+		vars["ATTOM_API_KEY"] = c.ATTOMAPIKey
+	}
+
+	if c.PropertyMaxAgeDays != 0 {
+		vars["MONEY_PROPERTY_MAX_AGE_DAYS"] = strconv.Itoa(c.PropertyMaxAgeDays)
+	}
+
+	if c.RentCastRequestsPerSecond != c.DefaultRentCastRequestsPerSecond {
+		vars["MONEY_RENTCAST_REQUESTS_PER_SECOND"] = strconv.Itoa(c.RentCastRequestsPerSecond)
+	}
+
+	if c.QuoteSource != c.DefaultQuoteSource {
+		vars["MONEY_QUOTE_SOURCE"] = c.QuoteSource
+	}
+
+	if c.AlphaVantageAPIKey != "" {
+		vars["ALPHA_VANTAGE_API_KEY"] = c.AlphaVantageAPIKey
+	}
+
+	if c.MigrationBackups != c.DefaultMigrationBackups {
+		vars["MONEY_MIGRATION_BACKUPS"] = strconv.Itoa(c.MigrationBackups)
+	}
+
+	if c.SyncRemote != "" {
+		vars["MONEY_SYNC_REMOTE"] = c.SyncRemote
+	}
+	if c.SyncPassphrase != "" {
+		vars["MONEY_SYNC_PASSPHRASE"] = c.SyncPassphrase
+	}
+
+	if c.YNABToken != "" {
+		vars["YNAB_ACCESS_TOKEN"] = c.YNABToken
+	}
+	if c.YNABBudget != "" {
+		vars["MONEY_YNAB_BUDGET"] = c.YNABBudget
+	}
+
+	if c.ActualURL != "" {
+		vars["MONEY_ACTUAL_URL"] = c.ActualURL
+	}
+	if c.ActualAPIKey != "" {
+		vars["MONEY_ACTUAL_API_KEY"] = c.ActualAPIKey
+	}
+	if c.ActualBudget != "" {
+		vars["MONEY_ACTUAL_BUDGET"] = c.ActualBudget
+	}
+	if c.ActualPassword != "" {
+		vars["MONEY_ACTUAL_PASSWORD"] = c.ActualPassword
+	}
+
+	if c.TellerApplicationID != "" {
+		vars["MONEY_TELLER_APPLICATION_ID"] = c.TellerApplicationID
+	}
+	if c.TellerCertificate != "" {
+		vars["MONEY_TELLER_CERTIFICATE"] = c.TellerCertificate
+	}
+	if c.TellerPrivateKey != "" {
+		vars["MONEY_TELLER_PRIVATE_KEY"] = c.TellerPrivateKey
+	}
+
+	for name, value := range c.notifyVars() {
+		vars[name] = value
+	}
+
 	return vars
 }
 
@@ -109,11 +555,11 @@ func (c *Config) GetBashrcExports() []string {
 	var exports []string
 
 	// Only export non-default values
-	if c.MoneyDir != "" {
+	if c.baseDir != "" {
 		home, _ := os.UserHomeDir()
 		defaultDir := filepath.Join(home, c.DefaultMoneyDirName)
-		if c.MoneyDir != defaultDir {
-			exports = append(exports, "export MONEY_DIR=\""+c.MoneyDir+"\"")
+		if c.baseDir != defaultDir {
+			exports = append(exports, "export MONEY_DIR=\""+c.baseDir+"\"")
 		}
 	}
 
@@ -125,14 +571,180 @@ func (c *Config) GetBashrcExports() []string {
 		exports = append(exports, "export LLM_BATCH_SIZE=\""+strconv.Itoa(c.LLMBatchSize)+"\"")
 	}
 
+	if c.LLMParallelism != c.DefaultLLMParallelism {
+		exports = append(exports, "export LLM_PARALLELISM=\""+strconv.Itoa(c.LLMParallelism)+"\"")
+	}
+
+	if c.LLMRequestsPerMinute != 0 {
+		exports = append(exports, "export LLM_REQUESTS_PER_MINUTE=\""+strconv.Itoa(c.LLMRequestsPerMinute)+"\"")
+	}
+
+	if c.Theme != c.DefaultTheme {
+		exports = append(exports, "export MONEY_THEME=\""+c.Theme+"\"")
+	}
+
+	if len(c.ThemeColors) > 0 {
+		exports = append(exports, "export MONEY_THEME_COLORS=\""+formatKeyValueList(c.ThemeColors)+"\"")
+	}
+
+	if len(c.KeyBindings) > 0 {
+		exports = append(exports, "export MONEY_KEYS=\""+formatKeyValueList(c.KeyBindings)+"\"")
+	}
+
+	if c.LogToFile {
+		exports = append(exports, "export MONEY_LOG_FILE=\"true\"")
+	}
+
+	if c.BudgetIncomeMode != c.DefaultBudgetIncomeMode {
+		exports = append(exports, "export MONEY_BUDGET_INCOME_MODE=\""+c.BudgetIncomeMode+"\"")
+	}
+
+	if c.EmergencyFundMonths != c.DefaultEmergencyFundMonths {
+		exports = append(exports, "export MONEY_EMERGENCY_FUND_MONTHS=\""+strconv.Itoa(c.EmergencyFundMonths)+"\"")
+	}
+
+	if c.UtilizationWarnPercent != c.DefaultUtilizationWarnPercent {
+		exports = append(exports, "export MONEY_UTILIZATION_WARN_PERCENT=\""+strconv.Itoa(c.UtilizationWarnPercent)+"\"")
+	}
+
+	if c.SimpleFINMaxAttempts != c.DefaultSimpleFINMaxAttempts {
+		exports = append(exports, "export MONEY_SIMPLEFIN_MAX_ATTEMPTS=\""+strconv.Itoa(c.SimpleFINMaxAttempts)+"\"")
+	}
+
+	if c.SimpleFINRequestBudget != c.DefaultSimpleFINRequestBudget {
+		exports = append(exports, "export MONEY_SIMPLEFIN_REQUEST_BUDGET=\""+strconv.Itoa(c.SimpleFINRequestBudget)+"\"")
+	}
+
+	if c.StaleAccountDays != c.DefaultStaleAccountDays {
+		exports = append(exports, "export MONEY_STALE_ACCOUNT_DAYS=\""+strconv.Itoa(c.StaleAccountDays)+"\"")
+	}
+
+	if c.ATTOMAPIKey != "" {
+		exports = append(exports, "export ATTOM_API_KEY=\""+c.ATTOMAPIKey+"\"")
+	}
+
+	if c.PropertyMaxAgeDays != 0 {
+		exports = append(exports, "export MONEY_PROPERTY_MAX_AGE_DAYS=\""+strconv.Itoa(c.PropertyMaxAgeDays)+"\"")
+	}
+
+	if c.RentCastRequestsPerSecond != c.DefaultRentCastRequestsPerSecond {
+		exports = append(exports, "export MONEY_RENTCAST_REQUESTS_PER_SECOND=\""+strconv.Itoa(c.RentCastRequestsPerSecond)+"\"")
+	}
+
+	if c.QuoteSource != c.DefaultQuoteSource {
+		exports = append(exports, "export MONEY_QUOTE_SOURCE=\""+c.QuoteSource+"\"")
+	}
+
+	if c.AlphaVantageAPIKey != "" {
+		exports = append(exports, "export ALPHA_VANTAGE_API_KEY=\""+c.AlphaVantageAPIKey+"\"")
+	}
+
+	if c.MigrationBackups != c.DefaultMigrationBackups {
+		exports = append(exports, "export MONEY_MIGRATION_BACKUPS=\""+strconv.Itoa(c.MigrationBackups)+"\"")
+	}
+
+	if c.SyncRemote != "" {
+		exports = append(exports, "export MONEY_SYNC_REMOTE=\""+c.SyncRemote+"\"")
+	}
+	if c.SyncPassphrase != "" {
+		exports = append(exports, "export MONEY_SYNC_PASSPHRASE=\""+c.SyncPassphrase+"\"")
+	}
+
+	if c.YNABToken != "" {
+		exports = append(exports, "export YNAB_ACCESS_TOKEN=\""+c.YNABToken+"\"")
+	}
+	if c.YNABBudget != "" {
+		exports = append(exports, "export MONEY_YNAB_BUDGET=\""+c.YNABBudget+"\"")
+	}
+
+	if c.ActualURL != "" {
+		exports = append(exports, "export MONEY_ACTUAL_URL=\""+c.ActualURL+"\"")
+	}
+	if c.ActualAPIKey != "" {
+		exports = append(exports, "export MONEY_ACTUAL_API_KEY=\""+c.ActualAPIKey+"\"")
+	}
+	if c.ActualBudget != "" {
+		exports = append(exports, "export MONEY_ACTUAL_BUDGET=\""+c.ActualBudget+"\"")
+	}
+	if c.ActualPassword != "" {
+		exports = append(exports, "export MONEY_ACTUAL_PASSWORD=\""+c.ActualPassword+"\"")
+	}
+
+	if c.TellerApplicationID != "" {
+		exports = append(exports, "export MONEY_TELLER_APPLICATION_ID=\""+c.TellerApplicationID+"\"")
+	}
+	if c.TellerCertificate != "" {
+		exports = append(exports, "export MONEY_TELLER_CERTIFICATE=\""+c.TellerCertificate+"\"")
+	}
+	if c.TellerPrivateKey != "" {
+		exports = append(exports, "export MONEY_TELLER_PRIVATE_KEY=\""+c.TellerPrivateKey+"\"")
+	}
+
+	notifyVars := c.notifyVars()
+	for _, name := range []string{"MONEY_NOTIFY_WEBHOOK", "MONEY_NOTIFY_NTFY", "MONEY_NOTIFY_NTFY_TOKEN",
+		"MONEY_SMTP_HOST", "MONEY_SMTP_PORT", "MONEY_SMTP_USER", "MONEY_SMTP_PASSWORD", "MONEY_SMTP_FROM", "MONEY_SMTP_TO"} {
+		if value, exists := notifyVars[name]; exists {
+			exports = append(exports, "export "+name+"=\""+value+"\"")
+		}
+	}
+
 	return exports
 }
 
+// notifyVars returns the environment variables for configured notification sinks
+func (c *Config) notifyVars() map[string]string {
+	vars := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			vars[name] = value
+		}
+	}
+
+	set("MONEY_NOTIFY_WEBHOOK", c.NotifyWebhookURL)
+	set("MONEY_NOTIFY_NTFY", c.NotifyNtfyURL)
+	set("MONEY_NOTIFY_NTFY_TOKEN", c.NotifyNtfyToken)
+	set("MONEY_SMTP_HOST", c.SMTPHost)
+	if c.SMTPHost != "" && c.SMTPPort != c.DefaultSMTPPort {
+		vars["MONEY_SMTP_PORT"] = strconv.Itoa(c.SMTPPort)
+	}
+	set("MONEY_SMTP_USER", c.SMTPUsername)
+	set("MONEY_SMTP_PASSWORD", c.SMTPPassword)
+	set("MONEY_SMTP_FROM", c.SMTPFrom)
+	set("MONEY_SMTP_TO", strings.Join(c.SMTPTo, ","))
+
+	return vars
+}
+
+// Location returns the configured time zone, the system's when none is set
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// LogDir returns the directory log files are written to
+func (c *Config) LogDir() string {
+	return filepath.Join(c.MoneyDir, "logs")
+}
+
 // DBPath returns the full path to the database file
 func (c *Config) DBPath() string {
+	if dbPathOverride != "" {
+		return dbPathOverride
+	}
 	return filepath.Join(c.MoneyDir, "money.db")
 }
 
+// BackupDir returns the directory database backups are written to
+func (c *Config) BackupDir() string {
+	return filepath.Join(c.MoneyDir, "backups")
+}
+
 // EnsureMoneyDir creates the money directory if it doesn't exist
 func (c *Config) EnsureMoneyDir() error {
 	return os.MkdirAll(c.MoneyDir, 0755)
@@ -0,0 +1,393 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileName is the name of the config file, looked up in both the user config
+// directory and the money data directory
+const FileName = "config.toml"
+
+// Key is a setting that can be stored in the config file. The matching
+// environment variable always takes precedence over the file.
+type Key struct {
+	Name        string // key in config.toml
+	Env         string // overriding environment variable
+	Numeric     bool   // stored as a TOML integer
+	Secret      bool   // masked when listed
+	Description string
+}
+
+// Keys lists every setting supported by the config file
+var Keys = []Key{
+	{Name: "money_dir", Env: "MONEY_DIR", Description: "Directory where money data is stored"},
+	{Name: "profile", Env: "MONEY_PROFILE", Description: "Profile whose books are used by default"},
+	{Name: "llm_prompt_cmd", Env: "LLM_PROMPT_CMD", Description: "Command used to prompt the LLM"},
+	{Name: "llm_batch_size", Env: "LLM_BATCH_SIZE", Numeric: true, Description: "Transactions per LLM categorization request"},
+	{Name: "llm_parallelism", Env: "LLM_PARALLELISM", Numeric: true, Description: "LLM categorization requests run at once"},
+	{Name: "llm_requests_per_minute", Env: "LLM_REQUESTS_PER_MINUTE", Numeric: true, Description: "Most LLM requests started per minute (0 for no limit)"},
+	{Name: "theme", Env: "MONEY_THEME", Description: "TUI color theme (dark or light)"},
+	{Name: "theme_colors", Env: "MONEY_THEME_COLORS", Description: "TUI color overrides, e.g. accent=#005f87"},
+	{Name: "keys", Env: "MONEY_KEYS", Description: "TUI key binding overrides, e.g. down=s"},
+	{Name: "log_file", Env: "MONEY_LOG_FILE", Description: "Also write logs to $MONEY_DIR/logs (true or false)"},
+	{Name: "readonly", Env: "MONEY_READONLY", Description: "Open the database read-only and refuse commands that change it (true or false)"},
+	{Name: "timezone", Env: "MONEY_TIMEZONE", Description: "Time zone dates are shown and grouped in, e.g. America/New_York (defaults to the system's)"},
+	{Name: "locale", Env: "MONEY_LOCALE", Description: "Locale whose separators amounts are written with, e.g. de-DE (defaults to en-US)"},
+	{Name: "currency", Env: "MONEY_CURRENCY", Description: "Currency totals and budgets are shown in, e.g. EUR (defaults to USD); an account's own amounts use its currency"},
+	{Name: "accounting_negatives", Env: "MONEY_ACCOUNTING_NEGATIVES", Description: "Write negative amounts in parentheses (true or false)"},
+	{Name: "pager", Env: "MONEY_PAGER", Description: "Command long output is paged through (defaults to $PAGER, then less; cat turns paging off)"},
+	{Name: "budget_income_mode", Env: "MONEY_BUDGET_INCOME_MODE", Description: "Income 'money budget' plans a month against: actual, 3-month or 6-month (average of the months before)"},
+	{Name: "emergency_fund_months", Env: "MONEY_EMERGENCY_FUND_MONTHS", Numeric: true, Description: "Months of expenses cash should cover before 'money balance' and the digest warn (0 to not warn)"},
+	{Name: "utilization_warn_percent", Env: "MONEY_UTILIZATION_WARN_PERCENT", Numeric: true, Description: "Percent of a card's credit limit above which 'money report utilization' flags it"},
+	{Name: "simplefin_max_attempts", Env: "MONEY_SIMPLEFIN_MAX_ATTEMPTS", Numeric: true, Description: "Attempts per SimpleFIN request before giving up"},
+	{Name: "simplefin_request_budget", Env: "MONEY_SIMPLEFIN_REQUEST_BUDGET", Numeric: true, Description: "Most SimpleFIN requests one fetch may make, retries included (0 for no limit)"},
+	{Name: "stale_account_days", Env: "MONEY_STALE_ACCOUNT_DAYS", Numeric: true, Description: "Days a linked account can go without updating before 'money balance' and 'money doctor' warn (0 to not warn)"},
+	{Name: "attom_api_key", Env: "ATTOM_API_KEY", Secret: true, Description: "ATTOM API key for property valuations"},
+	{Name: "property_max_age_days", Env: "MONEY_PROPERTY_MAX_AGE_DAYS", Numeric: true, Description: "Days before a property valuation is refreshed again (0 to refresh every time)"},
+	{Name: "rentcast_requests_per_second", Env: "MONEY_RENTCAST_REQUESTS_PER_SECOND", Numeric: true, Description: "Most RentCast requests per second property updates make; set it to your plan's rate limit"},
+	{Name: "quote_source", Env: "MONEY_QUOTE_SOURCE", Description: "Source of stock and fund prices for 'money holdings refresh' (stooq, yahoo or alphavantage)"},
+	{Name: "alpha_vantage_api_key", Env: "ALPHA_VANTAGE_API_KEY", Secret: true, Description: "Alpha Vantage API key for the alphavantage quote source"},
+	{Name: "migration_backups", Env: "MONEY_MIGRATION_BACKUPS", Numeric: true, Description: "Copies of the database taken before schema migrations to keep in $MONEY_DIR/backups (0 to not take them)"},
+	{Name: "sync_remote", Env: "MONEY_SYNC_REMOTE", Description: "Where 'money sync' keeps the encrypted database: s3://bucket/key, davs://host/path, git+<repo url> or a file path"},
+	{Name: "sync_passphrase", Env: "MONEY_SYNC_PASSPHRASE", Secret: true, Description: "Passphrase 'money sync' encrypts the database with; use the same one on every machine"},
+	{Name: "ynab_token", Env: "YNAB_ACCESS_TOKEN", Secret: true, Description: "YNAB personal access token for 'money ynab'"},
+	{Name: "ynab_budget", Env: "MONEY_YNAB_BUDGET", Description: "ID of the YNAB budget 'money ynab' syncs with (defaults to the last one used)"},
+	{Name: "actual_url", Env: "MONEY_ACTUAL_URL", Description: "URL of the actual-http-api server 'money actual' pushes to"},
+	{Name: "actual_api_key", Env: "MONEY_ACTUAL_API_KEY", Secret: true, Description: "API key of the actual-http-api server"},
+	{Name: "actual_budget", Env: "MONEY_ACTUAL_BUDGET", Description: "Sync ID of the Actual budget, from Settings > Advanced settings"},
+	{Name: "actual_password", Env: "MONEY_ACTUAL_PASSWORD", Secret: true, Description: "Password of the Actual budget, when it's end-to-end encrypted"},
+	{Name: "teller_application_id", Env: "MONEY_TELLER_APPLICATION_ID", Description: "Teller application ID 'money init teller' enrolls banks under"},
+	{Name: "teller_certificate", Env: "MONEY_TELLER_CERTIFICATE", Description: "Path of the client certificate (PEM) Teller issued the application"},
+	{Name: "teller_private_key", Env: "MONEY_TELLER_PRIVATE_KEY", Description: "Path of the certificate's private key (PEM)"},
+	{Name: "notify_webhook", Env: "MONEY_NOTIFY_WEBHOOK", Description: "Webhook URL for notifications"},
+	{Name: "notify_ntfy", Env: "MONEY_NOTIFY_NTFY", Description: "ntfy topic URL for notifications"},
+	{Name: "notify_ntfy_token", Env: "MONEY_NOTIFY_NTFY_TOKEN", Secret: true, Description: "ntfy access token"},
+	{Name: "smtp_host", Env: "MONEY_SMTP_HOST", Description: "SMTP server for email notifications"},
+	{Name: "smtp_port", Env: "MONEY_SMTP_PORT", Numeric: true, Description: "SMTP server port"},
+	{Name: "smtp_user", Env: "MONEY_SMTP_USER", Description: "SMTP username"},
+	{Name: "smtp_password", Env: "MONEY_SMTP_PASSWORD", Secret: true, Description: "SMTP password"},
+	{Name: "smtp_from", Env: "MONEY_SMTP_FROM", Description: "Sender address for email notifications"},
+	{Name: "smtp_to", Env: "MONEY_SMTP_TO", Description: "Comma-separated email recipients"},
+}
+
+// LookupKey returns the config file key with the given name
+func LookupKey(name string) (Key, bool) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// UserFilePath returns the per-user config file path,
+// $XDG_CONFIG_HOME/money/config.toml or ~/.config/money/config.toml
+func UserFilePath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "money", FileName)
+}
+
+// DataFilePath returns the config file kept alongside the database
+func (c *Config) DataFilePath() string {
+	return filepath.Join(c.MoneyDir, FileName)
+}
+
+// FilePath returns the config file that settings are written to: the one in
+// the data directory if it exists, otherwise the per-user file. money_dir and
+// profile are always written to the per-user file since they decide where the
+// data directory is.
+func (c *Config) FilePath(key Key) string {
+	if !locatesDataDir(key.Name) {
+		if _, err := os.Stat(c.DataFilePath()); err == nil {
+			return c.DataFilePath()
+		}
+	}
+	return UserFilePath()
+}
+
+// ReadFile reads a config file into a map of key name to value. A missing
+// file is not an error.
+func ReadFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	var raw map[string]interface{}
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	for name, value := range raw {
+		if _, ok := LookupKey(name); !ok {
+			return nil, fmt.Errorf("unknown key %q in %s", name, path)
+		}
+		str, err := tomlValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s in %s: %w", name, path, err)
+		}
+		values[name] = str
+	}
+	return values, nil
+}
+
+// tomlValueString flattens a decoded TOML value into the same string format
+// used by the matching environment variable
+func tomlValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case []interface{}:
+		entries := make([]string, len(v))
+		for i, entry := range v {
+			str, ok := entry.(string)
+			if !ok {
+				return "", fmt.Errorf("expected a list of strings")
+			}
+			entries[i] = str
+		}
+		return strings.Join(entries, ","), nil
+	case map[string]interface{}:
+		pairs := make(map[string]string, len(v))
+		for name, entry := range v {
+			str, ok := entry.(string)
+			if !ok {
+				return "", fmt.Errorf("expected a table of strings")
+			}
+			pairs[name] = str
+		}
+		return formatKeyValueList(pairs), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// WriteFile writes values to the config file at path, creating its directory
+// if needed. The file is only readable by the owner since it may hold
+// passwords and tokens.
+func WriteFile(path string, values map[string]string) error {
+	doc := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		key, ok := LookupKey(name)
+		if !ok {
+			return fmt.Errorf("unknown key %q", name)
+		}
+		if key.Numeric {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number", name)
+			}
+			doc[name] = n
+			continue
+		}
+		doc[name] = value
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString("# money CLI configuration, see 'money config list'\n"); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := toml.NewEncoder(file).Encode(doc); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// warnedFiles records config files that failed to load so each one is only
+// reported once per run, however many times New is called
+var warnedFiles sync.Map
+
+// loadFromFiles reads the per-user config file, then the one in the data
+// directory, which wins when both set a key. Values are stored by environment
+// variable name so loadFromEnvironment can fall back to them.
+func (c *Config) loadFromFiles() {
+	c.fileValues = make(map[string]string)
+	c.loadFile(UserFilePath(), true)
+
+	moneyDir := profileDir(c.getBaseDir(), c.getProfile())
+	c.loadFile(filepath.Join(moneyDir, FileName), false)
+}
+
+// locatesDataDir reports whether a key decides where the data directory is,
+// so it is only honored in the per-user file
+func locatesDataDir(name string) bool {
+	return name == "money_dir" || name == "profile"
+}
+
+func (c *Config) loadFile(path string, allowDataDirKeys bool) {
+	values, err := ReadFile(path)
+	if err != nil {
+		if _, warned := warnedFiles.LoadOrStore(path, true); !warned {
+			slog.Warn("ignoring config file", "err", err)
+		}
+		return
+	}
+
+	for name, value := range values {
+		if locatesDataDir(name) && !allowDataDirKeys {
+			continue
+		}
+		key, _ := LookupKey(name)
+		c.fileValues[key.Env] = value
+	}
+}
+
+// getenv returns the environment variable if set, otherwise the value from
+// the config file
+func (c *Config) getenv(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return c.fileValues[name]
+}
+
+// Source reports where the effective value of key comes from: "flag", "env",
+// "file" or "default"
+func (c *Config) Source(key Key) string {
+	if (key.Name == "money_dir" && moneyDirOverride != "") || (key.Name == "profile" && profileOverride != "") || (key.Name == "readonly" && readOnlyOverride) {
+		return "flag"
+	}
+	if os.Getenv(key.Env) != "" {
+		return "env"
+	}
+	if _, ok := c.fileValues[key.Env]; ok {
+		return "file"
+	}
+	return "default"
+}
+
+// Value returns the effective value of key, formatted like its environment
+// variable
+func (c *Config) Value(key Key) string {
+	switch key.Name {
+	case "money_dir":
+		return c.baseDir
+	case "profile":
+		return c.Profile
+	case "llm_prompt_cmd":
+		return c.LLMPromptCmd
+	case "llm_batch_size":
+		return strconv.Itoa(c.LLMBatchSize)
+	case "llm_parallelism":
+		return strconv.Itoa(c.LLMParallelism)
+	case "llm_requests_per_minute":
+		return strconv.Itoa(c.LLMRequestsPerMinute)
+	case "theme":
+		return c.Theme
+	case "theme_colors":
+		return formatKeyValueList(c.ThemeColors)
+	case "keys":
+		return formatKeyValueList(c.KeyBindings)
+	case "log_file":
+		return strconv.FormatBool(c.LogToFile)
+	case "readonly":
+		return strconv.FormatBool(c.ReadOnly)
+	case "timezone":
+		return c.Timezone
+	case "locale":
+		return c.Locale
+	case "accounting_negatives":
+		return strconv.FormatBool(c.AccountingNegatives)
+	case "pager":
+		return c.Pager
+	case "budget_income_mode":
+		return c.BudgetIncomeMode
+	case "emergency_fund_months":
+		return strconv.Itoa(c.EmergencyFundMonths)
+	case "utilization_warn_percent":
+		return strconv.Itoa(c.UtilizationWarnPercent)
+	case "simplefin_max_attempts":
+		return strconv.Itoa(c.SimpleFINMaxAttempts)
+	case "simplefin_request_budget":
+		return strconv.Itoa(c.SimpleFINRequestBudget)
+	case "stale_account_days":
+		return strconv.Itoa(c.StaleAccountDays)
+	case "attom_api_key":
+		return c.ATTOMAPIKey
+	case "property_max_age_days":
+		return strconv.Itoa(c.PropertyMaxAgeDays)
+	case "rentcast_requests_per_second":
+		return strconv.Itoa(c.RentCastRequestsPerSecond)
+	case "quote_source":
+		return c.QuoteSource
+	case "alpha_vantage_api_key":
+		return c.AlphaVantageAPIKey
+	case "migration_backups":
+		return strconv.Itoa(c.MigrationBackups)
+	case "sync_remote":
+		return c.SyncRemote
+	case "sync_passphrase":
+		return c.SyncPassphrase
+	case "ynab_token":
+		return c.YNABToken
+	case "ynab_budget":
+		return c.YNABBudget
+	case "actual_url":
+		return c.ActualURL
+	case "actual_api_key":
+		return c.ActualAPIKey
+	case "actual_budget":
+		return c.ActualBudget
+	case "actual_password":
+		return c.ActualPassword
+	case "teller_application_id":
+		return c.TellerApplicationID
+	case "teller_certificate":
+		return c.TellerCertificate
+	case "teller_private_key":
+		return c.TellerPrivateKey
+	case "notify_webhook":
+		return c.NotifyWebhookURL
+	case "notify_ntfy":
+		return c.NotifyNtfyURL
+	case "notify_ntfy_token":
+		return c.NotifyNtfyToken
+	case "smtp_host":
+		return c.SMTPHost
+	case "smtp_port":
+		return strconv.Itoa(c.SMTPPort)
+	case "smtp_user":
+		return c.SMTPUsername
+	case "smtp_password":
+		return c.SMTPPassword
+	case "smtp_from":
+		return c.SMTPFrom
+	case "smtp_to":
+		return strings.Join(c.SMTPTo, ",")
+	}
+	return ""
+}
+
+// KeyNames returns the names of all config file keys, sorted
+func KeyNames() []string {
+	names := make([]string, len(Keys))
+	for i, key := range Keys {
+		names[i] = key.Name
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigFilePrecedence(t *testing.T) {
+	home := t.TempDir()
+	moneyDir := filepath.Join(home, "data")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	for _, key := range Keys {
+		t.Setenv(key.Env, "")
+	}
+
+	if err := WriteFile(UserFilePath(), map[string]string{
+		"money_dir":      moneyDir,
+		"llm_prompt_cmd": "ollama",
+		"llm_batch_size": "25",
+		"smtp_to":        "a@example.com,b@example.com",
+	}); err != nil {
+		t.Fatalf("WriteFile user: %v", err)
+	}
+	if err := WriteFile(filepath.Join(moneyDir, FileName), map[string]string{
+		"llm_prompt_cmd": "llm",
+	}); err != nil {
+		t.Fatalf("WriteFile data: %v", err)
+	}
+
+	cfg := New()
+	if cfg.MoneyDir != moneyDir {
+		t.Errorf("MoneyDir = %q, want %q", cfg.MoneyDir, moneyDir)
+	}
+	if cfg.LLMPromptCmd != "llm" {
+		t.Errorf("LLMPromptCmd = %q, want data directory file to win", cfg.LLMPromptCmd)
+	}
+	if cfg.LLMBatchSize != 25 {
+		t.Errorf("LLMBatchSize = %d, want 25", cfg.LLMBatchSize)
+	}
+	if len(cfg.SMTPTo) != 2 {
+		t.Errorf("SMTPTo = %v, want two recipients", cfg.SMTPTo)
+	}
+
+	batchKey, _ := LookupKey("llm_batch_size")
+	if source := cfg.Source(batchKey); source != "file" {
+		t.Errorf("Source(llm_batch_size) = %q, want file", source)
+	}
+
+	t.Setenv("LLM_BATCH_SIZE", "5")
+	cfg = New()
+	if cfg.LLMBatchSize != 5 {
+		t.Errorf("LLMBatchSize = %d, want environment to override file", cfg.LLMBatchSize)
+	}
+	if source := cfg.Source(batchKey); source != "env" {
+		t.Errorf("Source(llm_batch_size) = %q, want env", source)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	values, err := ReadFile(filepath.Join(dir, "missing.toml"))
+	if err != nil || len(values) != 0 {
+		t.Fatalf("ReadFile(missing) = %v, %v; want empty, nil", values, err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	content := `
+llm_batch_size = 20
+smtp_to = ["a@example.com", "b@example.com"]
+
+[theme_colors]
+accent = "#005f87"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	values, err = ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want := map[string]string{
+		"llm_batch_size": "20",
+		"smtp_to":        "a@example.com,b@example.com",
+		"theme_colors":   "accent=#005f87",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("llm_cmd = \"claude\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(path); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestProfileDirectory(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("MONEY_DIR", base)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("MONEY_PROFILE", "business")
+
+	cfg := New()
+	if want := filepath.Join(base, "profiles", "business"); cfg.MoneyDir != want {
+		t.Errorf("MoneyDir = %q, want %q", cfg.MoneyDir, want)
+	}
+	if exports := cfg.GetBashrcExports(); len(exports) == 0 || exports[0] != `export MONEY_DIR="`+base+`"` {
+		t.Errorf("exports = %v, want MONEY_DIR to stay the base directory", exports)
+	}
+
+	t.Setenv("MONEY_PROFILE", DefaultProfile)
+	if cfg := New(); cfg.MoneyDir != base {
+		t.Errorf("MoneyDir = %q, want %q for the default profile", cfg.MoneyDir, base)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("MONEY_DIR", filepath.Join(home, "data"))
+	t.Setenv("MONEY_TIMEZONE", "")
+
+	loc, err := New().Location()
+	if err != nil || loc != time.Local {
+		t.Errorf("Location() = %v, %v; want the system time zone", loc, err)
+	}
+
+	t.Setenv("MONEY_TIMEZONE", "America/New_York")
+	loc, err = New().Location()
+	if err != nil || loc.String() != "America/New_York" {
+		t.Errorf("Location() = %v, %v; want America/New_York", loc, err)
+	}
+
+	t.Setenv("MONEY_TIMEZONE", "Mars/Olympus_Mons")
+	if _, err := New().Location(); err == nil {
+		t.Error("Location() should fail for an unknown time zone")
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+// Overrides set from the global --money-dir, --db and --read-only flags.
+// They apply to every Config created afterwards and win over environment
+// variables and the config file, so a single invocation can target another
+// database without touching the environment.
+var (
+	moneyDirOverride string
+	dbPathOverride   string
+	readOnlyOverride bool
+)
+
+// SetMoneyDirOverride makes dir the money directory for this run, as if
+// MONEY_DIR were set to it
+func SetMoneyDirOverride(dir string) {
+	moneyDirOverride = dir
+}
+
+// SetDBPathOverride makes path the database file for this run, leaving the
+// rest of the data directory unchanged
+func SetDBPathOverride(path string) {
+	dbPathOverride = path
+}
+
+// SetReadOnlyOverride opens the database read-only for this run, as if
+// MONEY_READONLY were set
+func SetReadOnlyOverride(readOnly bool) {
+	readOnlyOverride = readOnly
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the profile whose data lives directly in MONEY_DIR
+const DefaultProfile = "default"
+
+// profilesDirName is the directory inside MONEY_DIR holding one data
+// directory per named profile
+const profilesDirName = "profiles"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// profileOverride is set by the global --profile flag and wins over
+// MONEY_PROFILE and the config file
+var profileOverride string
+
+// SetProfile selects the profile used by every Config created afterwards
+func SetProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	profileOverride = name
+	return nil
+}
+
+// ValidateProfileName checks that a profile name is safe to use as a
+// directory name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// getProfile returns the selected profile name. An invalid MONEY_PROFILE
+// falls back to the default profile; the CLI rejects it before getting here.
+func (c *Config) getProfile() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if profile := c.getenv("MONEY_PROFILE"); profile != "" && ValidateProfileName(profile) == nil {
+		return profile
+	}
+	return DefaultProfile
+}
+
+// profileDir returns the data directory for profile inside base
+func profileDir(base, profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return base
+	}
+	return filepath.Join(base, profilesDirName, profile)
+}
+
+// Profiles returns the names of all profiles with a data directory, always
+// including the default profile
+func (c *Config) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(c.baseDir, profilesDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	profiles := []string{DefaultProfile}
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfileName(entry.Name()) == nil && entry.Name() != DefaultProfile {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles[1:])
+	return profiles, nil
+}
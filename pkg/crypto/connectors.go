@@ -0,0 +1,300 @@
+// Package crypto reads crypto balances from public blockchain addresses and
+// exchange accounts into accounts of type crypto, valued in the account's
+// currency from CoinGecko prices.
+package crypto
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source names for crypto wallets
+const (
+	SourceBitcoin  = "bitcoin"
+	SourceEthereum = "ethereum"
+	SourceKraken   = "kraken"
+)
+
+// SourceNames lists every wallet source
+var SourceNames = []string{SourceBitcoin, SourceEthereum, SourceKraken}
+
+const (
+	// BitcoinBaseURL is an Esplora API, which needs no API key
+	BitcoinBaseURL = "https://mempool.space/api"
+	// EthereumRPCURL is a public Ethereum JSON-RPC endpoint
+	EthereumRPCURL = "https://ethereum-rpc.publicnode.com"
+	KrakenBaseURL  = "https://api.kraken.com"
+)
+
+// Balance is the amount of one asset held, in whole units (e.g. BTC, not
+// satoshis)
+type Balance struct {
+	Asset  string // ticker, e.g. BTC
+	Amount float64
+}
+
+// Connector reads the balances of a wallet or exchange account
+type Connector interface {
+	Name() string
+	Balances() ([]Balance, error)
+}
+
+// IsAddressSource reports whether source reads a public address rather than
+// exchange credentials
+func IsAddressSource(source string) bool {
+	return source == SourceBitcoin || source == SourceEthereum
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
+
+// do sends req and returns the response body, failing on non-200 statuses
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	req.Header.Set("User-Agent", "money-cli/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+// Bitcoin reads a Bitcoin address's balance from an Esplora API
+type Bitcoin struct {
+	Address    string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewBitcoin creates a connector for a Bitcoin address
+func NewBitcoin(address string) *Bitcoin {
+	return &Bitcoin{Address: address, BaseURL: BitcoinBaseURL, HTTPClient: newHTTPClient()}
+}
+
+func (b *Bitcoin) Name() string { return SourceBitcoin }
+
+// esploraStats are an address's confirmed or unconfirmed totals, in satoshis
+type esploraStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+}
+
+// Balances returns the address's BTC balance, including unconfirmed
+// transactions
+func (b *Bitcoin) Balances() ([]Balance, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/address/%s", b.BaseURL, url.PathEscape(b.Address)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := do(b.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var address struct {
+		ChainStats   esploraStats `json:"chain_stats"`
+		MempoolStats esploraStats `json:"mempool_stats"`
+	}
+	if err := json.Unmarshal(body, &address); err != nil {
+		return nil, fmt.Errorf("failed to parse address response: %w", err)
+	}
+
+	sats := address.ChainStats.FundedTxoSum - address.ChainStats.SpentTxoSum +
+		address.MempoolStats.FundedTxoSum - address.MempoolStats.SpentTxoSum
+	return []Balance{{Asset: "BTC", Amount: float64(sats) / 1e8}}, nil
+}
+
+// Ethereum reads an Ethereum address's ETH balance over JSON-RPC. Token
+// balances aren't read.
+type Ethereum struct {
+	Address    string
+	RPCURL     string
+	HTTPClient *http.Client
+}
+
+// NewEthereum creates a connector for an Ethereum address
+func NewEthereum(address string) *Ethereum {
+	return &Ethereum{Address: address, RPCURL: EthereumRPCURL, HTTPClient: newHTTPClient()}
+}
+
+func (e *Ethereum) Name() string { return SourceEthereum }
+
+// Balances returns the address's ETH balance
+func (e *Ethereum) Balances() ([]Balance, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_getBalance",
+		"params":  []string{e.Address, "latest"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.RPCURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := do(e.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse balance response: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("ethereum RPC error: %s", resp.Error.Message)
+	}
+
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(resp.Result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance in response: %q", resp.Result)
+	}
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return []Balance{{Asset: "ETH", Amount: eth}}, nil
+}
+
+// Kraken reads an exchange account's balances with a query-funds API key
+type Kraken struct {
+	APIKey     string
+	APISecret  string // base64, as shown by Kraken
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewKraken creates a connector for a Kraken account
+func NewKraken(apiKey, apiSecret string) *Kraken {
+	return &Kraken{APIKey: apiKey, APISecret: apiSecret, BaseURL: KrakenBaseURL, HTTPClient: newHTTPClient()}
+}
+
+func (k *Kraken) Name() string { return SourceKraken }
+
+// Balances returns every non-zero asset balance on the account
+func (k *Kraken) Balances() ([]Balance, error) {
+	const path = "/0/private/Balance"
+
+	form := url.Values{}
+	form.Set("nonce", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	signature, err := krakenSignature(path, form, k.APISecret)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", k.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("API-Key", k.APIKey)
+	req.Header.Set("API-Sign", signature)
+
+	body, err := do(k.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Error  []string          `json:"error"`
+		Result map[string]string `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse balance response: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("kraken: %s", strings.Join(resp.Error, ", "))
+	}
+
+	// Staked and spot balances of the same asset are reported separately
+	amounts := make(map[string]float64)
+	var assets []string
+	for name, value := range resp.Result {
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s balance: %w", name, err)
+		}
+		if amount == 0 {
+			continue
+		}
+		asset := krakenAsset(name)
+		if _, seen := amounts[asset]; !seen {
+			assets = append(assets, asset)
+		}
+		amounts[asset] += amount
+	}
+
+	balances := make([]Balance, 0, len(assets))
+	for _, asset := range assets {
+		balances = append(balances, Balance{Asset: asset, Amount: amounts[asset]})
+	}
+	return balances, nil
+}
+
+// krakenSignature signs a private API request: HMAC-SHA512 of the path and
+// the SHA-256 of nonce plus form data, keyed by the decoded secret
+func krakenSignature(path string, form url.Values, secret string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid Kraken API secret: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(form.Get("nonce") + form.Encode()))
+	mac := hmac.New(sha512.New, key)
+	mac.Write([]byte(path))
+	mac.Write(digest[:])
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// krakenAsset converts a Kraken asset code to its usual ticker: legacy
+// four-letter codes drop their X (crypto) or Z (fiat) prefix, staking and
+// earn suffixes are dropped, and XBT is BTC
+func krakenAsset(name string) string {
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	if len(name) == 4 && (name[0] == 'X' || name[0] == 'Z') {
+		name = name[1:]
+	}
+	switch name {
+	case "XBT":
+		return "BTC"
+	case "XDG":
+		return "DOGE"
+	}
+	return name
+}
@@ -0,0 +1,174 @@
+package crypto
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBitcoinBalances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/address/bc1qtest" {
+			t.Errorf("Expected path /address/bc1qtest, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"address":"bc1qtest","chain_stats":{"funded_txo_sum":150000000,"spent_txo_sum":50000000},"mempool_stats":{"funded_txo_sum":2500000,"spent_txo_sum":0}}`))
+	}))
+	defer server.Close()
+
+	bitcoin := NewBitcoin("bc1qtest")
+	bitcoin.BaseURL = server.URL
+
+	balances, err := bitcoin.Balances()
+	if err != nil {
+		t.Fatalf("Balances failed: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Asset != "BTC" || balances[0].Amount != 1.025 {
+		t.Errorf("Expected 1.025 BTC, got %+v", balances)
+	}
+}
+
+func TestEthereumBalances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Method != "eth_getBalance" || len(req.Params) != 2 || req.Params[0] != "0xabc" {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		// 2.5 ETH in wei
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x22b1c8c1227a0000"}`))
+	}))
+	defer server.Close()
+
+	ethereum := NewEthereum("0xabc")
+	ethereum.RPCURL = server.URL
+
+	balances, err := ethereum.Balances()
+	if err != nil {
+		t.Fatalf("Balances failed: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Asset != "ETH" || balances[0].Amount != 2.5 {
+		t.Errorf("Expected 2.5 ETH, got %+v", balances)
+	}
+}
+
+func TestKrakenBalances(t *testing.T) {
+	const secret = "c2VjcmV0LWtleQ=="
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0/private/Balance" {
+			t.Errorf("Expected path /0/private/Balance, got %s", r.URL.Path)
+		}
+		if got := r.Header.Get("API-Key"); got != "test-key" {
+			t.Errorf("Expected API-Key 'test-key', got '%s'", got)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		form, err := url.ParseQuery(string(body))
+		if err != nil || form.Get("nonce") == "" {
+			t.Fatalf("Expected a nonce in the form, got %q", body)
+		}
+		want, err := krakenSignature(r.URL.Path, form, secret)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		if got := r.Header.Get("API-Sign"); got != want {
+			t.Errorf("Expected API-Sign %s, got %s", want, got)
+		}
+
+		w.Write([]byte(`{"error":[],"result":{"XXBT":"0.5","ETH.S":"1.0","XETH":"2.0","ZUSD":"150.25","ADA":"0.0000"}}`))
+	}))
+	defer server.Close()
+
+	kraken := NewKraken("test-key", secret)
+	kraken.BaseURL = server.URL
+
+	balances, err := kraken.Balances()
+	if err != nil {
+		t.Fatalf("Balances failed: %v", err)
+	}
+
+	amounts := make(map[string]float64)
+	for _, balance := range balances {
+		amounts[balance.Asset] = balance.Amount
+	}
+	want := map[string]float64{"BTC": 0.5, "ETH": 3, "USD": 150.25}
+	if len(amounts) != len(want) {
+		t.Errorf("Expected %v, got %v", want, amounts)
+	}
+	for asset, amount := range want {
+		if amounts[asset] != amount {
+			t.Errorf("Expected %v %s, got %v", amount, asset, amounts[asset])
+		}
+	}
+}
+
+func TestKrakenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":["EAPI:Invalid key"]}`))
+	}))
+	defer server.Close()
+
+	kraken := NewKraken("bad-key", "c2VjcmV0")
+	kraken.BaseURL = server.URL
+
+	if _, err := kraken.Balances(); err == nil {
+		t.Error("Expected an error for an invalid key")
+	}
+}
+
+func TestKrakenAsset(t *testing.T) {
+	tests := map[string]string{
+		"XXBT":  "BTC",
+		"XBT.M": "BTC",
+		"XETH":  "ETH",
+		"ZUSD":  "USD",
+		"ZEUR":  "EUR",
+		"XXDG":  "DOGE",
+		"SOL.S": "SOL",
+		"USDC":  "USDC",
+		"DOT":   "DOT",
+	}
+	for name, want := range tests {
+		if got := krakenAsset(name); got != want {
+			t.Errorf("krakenAsset(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCoinGeckoPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ids"); got != "bitcoin,ethereum" {
+			t.Errorf("Expected ids 'bitcoin,ethereum', got '%s'", got)
+		}
+		if got := r.URL.Query().Get("vs_currencies"); got != "usd" {
+			t.Errorf("Expected vs_currencies 'usd', got '%s'", got)
+		}
+		w.Write([]byte(`{"bitcoin":{"usd":60000},"ethereum":{"usd":2500.5}}`))
+	}))
+	defer server.Close()
+
+	coinGecko := NewCoinGecko()
+	coinGecko.BaseURL = server.URL
+
+	prices, err := coinGecko.Prices([]string{"BTC", "ETH", "USD", "NOPE"}, "usd")
+	if err != nil {
+		t.Fatalf("Prices failed: %v", err)
+	}
+	want := map[string]float64{"BTC": 60000, "ETH": 2500.5, "USD": 1}
+	if len(prices) != len(want) {
+		t.Errorf("Expected %v, got %v", want, prices)
+	}
+	for asset, price := range want {
+		if prices[asset] != price {
+			t.Errorf("Expected %s at %v, got %v", asset, price, prices[asset])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const CoinGeckoBaseURL = "https://api.coingecko.com/api/v3"
+
+// PriceSource returns prices for crypto assets in a currency
+type PriceSource interface {
+	// Prices returns the price of each asset in currency, leaving out
+	// assets it has no price for
+	Prices(assets []string, currency string) (map[string]float64, error)
+}
+
+// coinGeckoIDs maps tickers to CoinGecko coin IDs for common assets
+var coinGeckoIDs = map[string]string{
+	"BTC":   "bitcoin",
+	"ETH":   "ethereum",
+	"SOL":   "solana",
+	"ADA":   "cardano",
+	"DOT":   "polkadot",
+	"XRP":   "ripple",
+	"LTC":   "litecoin",
+	"DOGE":  "dogecoin",
+	"AVAX":  "avalanche-2",
+	"MATIC": "matic-network",
+	"POL":   "polygon-ecosystem-token",
+	"LINK":  "chainlink",
+	"ATOM":  "cosmos",
+	"XLM":   "stellar",
+	"XMR":   "monero",
+	"BCH":   "bitcoin-cash",
+	"ETC":   "ethereum-classic",
+	"USDC":  "usd-coin",
+	"USDT":  "tether",
+	"DAI":   "dai",
+}
+
+// CoinGecko prices assets with CoinGecko's free simple price API
+type CoinGecko struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewCoinGecko creates a CoinGecko price source
+func NewCoinGecko() *CoinGecko {
+	return &CoinGecko{BaseURL: CoinGeckoBaseURL, HTTPClient: newHTTPClient()}
+}
+
+// Prices returns the price of each asset in currency. An asset that is the
+// currency itself, like a USD balance on an exchange, is priced at 1.
+func (c *CoinGecko) Prices(assets []string, currency string) (map[string]float64, error) {
+	currency = strings.ToUpper(currency)
+	prices := make(map[string]float64)
+
+	var ids []string
+	idAssets := make(map[string]string)
+	for _, asset := range assets {
+		if asset == currency {
+			prices[asset] = 1
+			continue
+		}
+		if id, ok := coinGeckoIDs[asset]; ok {
+			if _, seen := idAssets[id]; !seen {
+				ids = append(ids, id)
+			}
+			idAssets[id] = asset
+		}
+	}
+	if len(ids) == 0 {
+		return prices, nil
+	}
+
+	params := url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("vs_currencies", strings.ToLower(currency))
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/simple/price?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, err := do(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// {"bitcoin": {"usd": 67000.12}}
+	var resp map[string]map[string]float64
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse price response: %w", err)
+	}
+
+	for id, quotes := range resp {
+		asset, ok := idAssets[id]
+		if !ok {
+			continue
+		}
+		if price, ok := quotes[strings.ToLower(currency)]; ok {
+			prices[asset] = price
+		}
+	}
+	return prices, nil
+}
@@ -0,0 +1,190 @@
+package crypto
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// OrgID is the organization crypto accounts belong to
+const OrgID = "Crypto"
+
+// NewConnector returns the connector that reads a wallet's balances
+func NewConnector(wallet database.CryptoWallet) (Connector, error) {
+	switch wallet.Source {
+	case SourceBitcoin:
+		return NewBitcoin(wallet.Address), nil
+	case SourceEthereum:
+		return NewEthereum(wallet.Address), nil
+	case SourceKraken:
+		return NewKraken(wallet.APIKey, wallet.APISecret), nil
+	}
+	return nil, fmt.Errorf("unknown crypto source: %s. Valid sources are: %v", wallet.Source, SourceNames)
+}
+
+type Service struct {
+	db           *database.DB
+	prices       PriceSource
+	newConnector func(database.CryptoWallet) (Connector, error)
+}
+
+func NewService(db *database.DB) *Service {
+	return &Service{db: db, prices: NewCoinGecko(), newConnector: NewConnector}
+}
+
+// accountID returns the account ID for a wallet named name, e.g.
+// "crypto_cold-storage" for "Cold Storage"
+func accountID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return "crypto_" + strings.TrimSuffix(b.String(), "-")
+}
+
+// AddWallet creates a crypto account valued in currency that reads its
+// balances from wallet. Its balance stays zero until the first refresh.
+func (s *Service) AddWallet(name, currency string, wallet database.CryptoWallet) (string, error) {
+	if IsAddressSource(wallet.Source) {
+		if wallet.Address == "" {
+			return "", fmt.Errorf("a %s wallet needs a public address", wallet.Source)
+		}
+	} else if wallet.Source == SourceKraken {
+		if wallet.APIKey == "" || wallet.APISecret == "" {
+			return "", fmt.Errorf("a kraken account needs an API key and secret")
+		}
+	} else {
+		return "", fmt.Errorf("unknown crypto source: %s. Valid sources are: %v", wallet.Source, SourceNames)
+	}
+
+	wallet.AccountID = accountID(name)
+	if wallet.AccountID == "crypto_" {
+		return "", fmt.Errorf("wallet name must contain a letter or digit")
+	}
+	if _, err := s.db.GetAccountByID(wallet.AccountID); err == nil {
+		return "", fmt.Errorf("an account with ID %s already exists", wallet.AccountID)
+	}
+
+	if err := s.db.SaveOrganization(OrgID, OrgID, ""); err != nil {
+		return "", fmt.Errorf("failed to save organization: %w", err)
+	}
+
+	err := s.db.SaveAccount(wallet.AccountID, OrgID, name, strings.ToUpper(currency), 0, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create crypto account: %w", err)
+	}
+
+	if err := s.db.SetAccountType(wallet.AccountID, "crypto"); err != nil {
+		return "", fmt.Errorf("failed to set account type to crypto: %w", err)
+	}
+
+	if err := s.db.SaveCryptoWallet(wallet); err != nil {
+		return "", err
+	}
+
+	return wallet.AccountID, nil
+}
+
+// WalletRefresh is the result of reading one wallet's balances
+type WalletRefresh struct {
+	Wallet     database.CryptoWallet
+	Account    database.Account
+	OldBalance int64
+	NewBalance int64
+	Balances   []Balance
+	Unpriced   []string // assets without a price, left out of the balance
+	Err        error    // set when the wallet couldn't be read
+}
+
+// Refresh reads every wallet's balances, prices them in the account's
+// currency, and saves them as the account's holdings and balance. A wallet
+// that fails doesn't stop the others; its error is in its WalletRefresh.
+func (s *Service) Refresh() ([]WalletRefresh, error) {
+	wallets, err := s.db.GetCryptoWallets()
+	if err != nil {
+		return nil, err
+	}
+
+	var refreshes []WalletRefresh
+	for _, wallet := range wallets {
+		account, err := s.db.GetAccountByID(wallet.AccountID)
+		if err != nil {
+			return refreshes, err
+		}
+
+		refresh := WalletRefresh{
+			Wallet:     wallet,
+			Account:    *account,
+			OldBalance: account.Balance,
+			NewBalance: account.Balance,
+		}
+		refresh.Err = s.refreshWallet(&refresh)
+		refreshes = append(refreshes, refresh)
+	}
+
+	return refreshes, nil
+}
+
+func (s *Service) refreshWallet(refresh *WalletRefresh) error {
+	connector, err := s.newConnector(refresh.Wallet)
+	if err != nil {
+		return err
+	}
+
+	balances, err := connector.Balances()
+	if err != nil {
+		return fmt.Errorf("failed to read %s balances: %w", connector.Name(), err)
+	}
+	refresh.Balances = balances
+
+	assets := make([]string, len(balances))
+	for i, balance := range balances {
+		assets[i] = balance.Asset
+	}
+	prices, err := s.prices.Prices(assets, refresh.Account.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to get prices: %w", err)
+	}
+
+	var total int64
+	holdings := make([]database.Holding, 0, len(balances))
+	for _, balance := range balances {
+		holding := database.Holding{
+			ID:          refresh.Wallet.AccountID + ":" + balance.Asset,
+			Symbol:      balance.Asset,
+			Description: refresh.Wallet.Source,
+			Shares:      balance.Amount,
+			Currency:    refresh.Account.Currency,
+		}
+		if price, ok := prices[balance.Asset]; ok {
+			holding.MarketValue = int64(math.Round(balance.Amount * price * 100))
+			total += holding.MarketValue
+		} else {
+			refresh.Unpriced = append(refresh.Unpriced, balance.Asset)
+		}
+		holdings = append(holdings, holding)
+	}
+
+	if err := s.db.SaveHoldings(refresh.Wallet.AccountID, holdings); err != nil {
+		return err
+	}
+
+	if err := s.db.UpdateAccountBalance(refresh.Wallet.AccountID, total); err != nil {
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+	if err := s.db.SaveBalanceHistory(refresh.Wallet.AccountID, total, nil); err != nil {
+		return fmt.Errorf("failed to save balance history: %w", err)
+	}
+	refresh.NewBalance = total
+
+	return s.db.MarkCryptoWalletUpdated(refresh.Wallet.AccountID)
+}
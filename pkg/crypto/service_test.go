@@ -0,0 +1,129 @@
+package crypto
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+type fakeConnector struct {
+	balances []Balance
+	err      error
+}
+
+func (f fakeConnector) Name() string { return "fake" }
+
+func (f fakeConnector) Balances() ([]Balance, error) { return f.balances, f.err }
+
+type fakePrices map[string]float64
+
+func (f fakePrices) Prices(assets []string, currency string) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	for _, asset := range assets {
+		if price, ok := f[asset]; ok {
+			prices[asset] = price
+		}
+	}
+	return prices, nil
+}
+
+func TestRefresh(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	connectors := map[string]Connector{
+		"crypto_cold-storage": fakeConnector{balances: []Balance{{Asset: "BTC", Amount: 0.5}}},
+		"crypto_exchange": fakeConnector{balances: []Balance{
+			{Asset: "ETH", Amount: 2},
+			{Asset: "USD", Amount: 100},
+			{Asset: "OBSCURE", Amount: 1000},
+		}},
+		"crypto_broken": fakeConnector{err: errors.New("connection refused")},
+	}
+	service := &Service{
+		db:     db,
+		prices: fakePrices{"BTC": 60000, "ETH": 2500, "USD": 1},
+		newConnector: func(wallet database.CryptoWallet) (Connector, error) {
+			return connectors[wallet.AccountID], nil
+		},
+	}
+
+	for name, wallet := range map[string]database.CryptoWallet{
+		"Cold Storage": {Source: SourceBitcoin, Address: "bc1qtest"},
+		"Exchange":     {Source: SourceKraken, APIKey: "key", APISecret: "secret"},
+		"Broken":       {Source: SourceEthereum, Address: "0xabc"},
+	} {
+		if _, err := service.AddWallet(name, "usd", wallet); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+
+	if _, err := service.AddWallet("Nothing", "USD", database.CryptoWallet{Source: SourceBitcoin}); err == nil {
+		t.Error("Expected an error for a bitcoin wallet without an address")
+	}
+	if _, err := service.AddWallet("Exchange", "USD", database.CryptoWallet{Source: SourceBitcoin, Address: "bc1q"}); err == nil {
+		t.Error("Expected an error for a duplicate account")
+	}
+
+	account, err := db.GetAccountByID("crypto_cold-storage")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.AccountType == nil || *account.AccountType != "crypto" {
+		t.Errorf("Expected account type crypto, got %v", account.AccountType)
+	}
+
+	refreshes, err := service.Refresh()
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(refreshes) != 3 {
+		t.Fatalf("Expected 3 refreshes, got %d", len(refreshes))
+	}
+
+	byID := make(map[string]WalletRefresh)
+	for _, refresh := range refreshes {
+		byID[refresh.Wallet.AccountID] = refresh
+	}
+
+	if refresh := byID["crypto_broken"]; refresh.Err == nil {
+		t.Error("Expected the broken wallet to fail")
+	}
+	if refresh := byID["crypto_cold-storage"]; refresh.Err != nil || refresh.NewBalance != 3000000 {
+		t.Errorf("Expected cold storage to be worth 3000000, got %+v", refresh)
+	}
+	exchange := byID["crypto_exchange"]
+	if exchange.Err != nil || exchange.NewBalance != 510000 {
+		t.Errorf("Expected the exchange to be worth 510000, got %+v", exchange)
+	}
+	if len(exchange.Unpriced) != 1 || exchange.Unpriced[0] != "OBSCURE" {
+		t.Errorf("Expected OBSCURE to be unpriced, got %v", exchange.Unpriced)
+	}
+
+	account, err = db.GetAccountByID("crypto_exchange")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.Balance != 510000 {
+		t.Errorf("Expected the account balance to be saved, got %d", account.Balance)
+	}
+
+	holdings, err := db.GetHoldings("crypto_exchange")
+	if err != nil {
+		t.Fatalf("Failed to get holdings: %v", err)
+	}
+	if len(holdings) != 3 || holdings[0].Symbol != "ETH" || holdings[0].MarketValue != 500000 {
+		t.Errorf("Expected the balances saved as holdings, got %+v", holdings)
+	}
+}
@@ -1,30 +1,79 @@
 package database
 
 import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/format"
 	_ "modernc.org/sqlite"
 )
 
 //go:embed schema.sql
 var schemaSQL string
 
+// schemaVersion identifies the schema this version of money migrates
+// databases to. It is stored in the database's user_version once migrations
+// finish, so a database with a different one is about to be migrated and is
+// backed up first. Migrations come with a change to schema.sql, which
+// changes it.
+var schemaVersion = func() int32 {
+	sum := sha256.Sum256([]byte(schemaSQL))
+	return int32(binary.BigEndian.Uint32(sum[:4]) >> 1)
+}()
+
 type DB struct {
 	conn   *sql.DB
 	config *config.Config
+
+	// allowClosedEdits lets category changes through to closed months
+	allowClosedEdits bool
+
+	// owners limits reads to the accounts of these owners, see ForOwners
+	owners []string
 }
 
 func New() (*DB, error) {
-	cfg := config.New()
+	return Open(config.New())
+}
+
+// Open opens the database in cfg's money directory, creating it if needed.
+// With cfg.ReadOnly the database must already exist, and is opened read-only
+// without running migrations.
+func Open(cfg *config.Config) (*DB, error) {
+	if cfg.ReadOnly {
+		return openReadOnly(cfg)
+	}
 
 	if err := cfg.EnsureMoneyDir(); err != nil {
 		return nil, fmt.Errorf("failed to create money directory: %w", err)
 	}
 	dbPath := cfg.DBPath()
-	conn, err := sql.Open("sqlite", dbPath)
+	slog.Debug("opening database", "path", dbPath)
+	// Foreign keys are enforced per connection, so every connection the pool
+	// opens turns them on. The busy timeout makes a write wait for another
+	// connection's to finish, e.g. LLM calls logged while categories are saved.
+	conn, err := sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -39,14 +88,49 @@ func New() (*DB, error) {
 		config: cfg,
 	}
 
+	backup, err := db.backupBeforeMigrations(time.Now())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
 	if err := db.runMigrations(); err != nil {
 		conn.Close()
+		if backup != "" {
+			return nil, fmt.Errorf("failed to run migrations (the database was copied to %s first; restore it with 'money db rollback'): %w", backup, err)
+		}
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
 	return db, nil
 }
 
+// openReadOnly opens the existing database read-only: SQLite refuses every
+// write, so nothing, not even a migration, can change the file. Commands
+// reading a database last opened by an older version may fail on columns
+// the skipped migrations would have added.
+func openReadOnly(cfg *config.Config) (*DB, error) {
+	dbPath := cfg.DBPath()
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database read-only: %w", err)
+	}
+	slog.Debug("opening database read-only", "path", dbPath)
+	dsn := &url.URL{Scheme: "file", Path: dbPath, RawQuery: "mode=ro"}
+	conn, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{
+		conn:   conn,
+		config: cfg,
+	}, nil
+}
+
 func (db *DB) Close() error {
 	if db.conn != nil {
 		return db.conn.Close()
@@ -54,7 +138,33 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// runMigrations brings the schema up to date. Migrations that rebuild a table
+// drop it while other tables still reference it, which enforced foreign keys
+// would turn into cascading deletes, so they run on a single connection with
+// enforcement off.
 func (db *DB) runMigrations() error {
+	db.conn.SetMaxOpenConns(1)
+	defer db.conn.SetMaxOpenConns(0)
+
+	if _, err := db.conn.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+
+	if err := db.migrateSchema(); err != nil {
+		return err
+	}
+
+	if _, err := db.conn.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	if _, err := db.conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) migrateSchema() error {
 	var tableCount int
 	err := db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'").Scan(&tableCount)
 	if err != nil {
@@ -365,20 +475,21 @@ func (db *DB) runIncrementalMigrations() error {
 		}
 	}
 
-	// Check if we need to update the account_type constraint to include 'property'
+	// Check if we need to update the account_type constraint to include the
+	// newest type, 'crypto' (earlier versions lacked 'property' too)
 	// We need to recreate the accounts table with the updated constraint
-	var hasPropertyType int
+	var hasLatestTypes int
 	err = db.conn.QueryRow(`
 		SELECT COUNT(*)
 		FROM sqlite_master
-		WHERE type='table' AND name='accounts' AND sql LIKE '%property%'
-	`).Scan(&hasPropertyType)
+		WHERE type='table' AND name='accounts' AND sql LIKE '%''crypto''%'
+	`).Scan(&hasLatestTypes)
 	if err != nil {
 		return fmt.Errorf("failed to check account_type constraint: %w", err)
 	}
 
-	// If the constraint doesn't include 'property', we need to recreate the table
-	if hasPropertyType == 0 {
+	// If the constraint doesn't include every type, we need to recreate the table
+	if hasLatestTypes == 0 {
 		// Start a transaction for this complex migration
 		tx, err := db.conn.Begin()
 		if err != nil {
@@ -397,7 +508,7 @@ func (db *DB) runIncrementalMigrations() error {
 				balance INTEGER NOT NULL,
 				available_balance INTEGER,
 				balance_date DATETIME,
-				account_type TEXT CHECK (account_type IN ('checking', 'savings', 'credit', 'investment', 'loan', 'property', 'other', 'unset')) DEFAULT 'unset',
+				account_type TEXT CHECK (account_type IN ('checking', 'savings', 'credit', 'investment', 'crypto', 'loan', 'property', 'other', 'unset')) DEFAULT 'unset',
 				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 				FOREIGN KEY (org_id) REFERENCES organizations(id)
@@ -488,1138 +599,6254 @@ func (db *DB) runIncrementalMigrations() error {
 		}
 	}
 
-	return nil
-}
-
-// GetConfig returns the database configuration
-func (db *DB) GetConfig() *config.Config {
-	return db.config
-}
-
-func (db *DB) SaveCredentials(accessURL, username, password string) error {
-	_, err := db.conn.Exec("DELETE FROM credentials")
+	// Check if budgets table exists
+	var budgetsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='budgets'
+	`).Scan(&budgetsTableExists)
 	if err != nil {
-		return fmt.Errorf("failed to clear existing credentials: %w", err)
+		return fmt.Errorf("failed to check budgets table: %w", err)
 	}
 
-	_, err = db.conn.Exec(`
-		INSERT INTO credentials (access_url, username, password, last_used) 
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
-		accessURL, username, password)
-	if err != nil {
-		return fmt.Errorf("failed to save credentials: %w", err)
+	// Create budgets table if it doesn't exist
+	if budgetsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE budgets (
+				category_id INTEGER PRIMARY KEY,
+				amount INTEGER NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (category_id) REFERENCES categories(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create budgets table: %w", err)
+		}
 	}
 
-	return nil
-}
-
-func (db *DB) GetCredentials() (accessURL, username, password string, err error) {
+	// Check if category_operations table exists
+	var categoryOperationsTableExists int
 	err = db.conn.QueryRow(`
-		SELECT access_url, username, password 
-		FROM credentials 
-		ORDER BY created_at DESC 
-		LIMIT 1`).Scan(&accessURL, &username, &password)
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='category_operations'
+	`).Scan(&categoryOperationsTableExists)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", "", "", fmt.Errorf("no credentials found - run 'money init' first")
+		return fmt.Errorf("failed to check category_operations table: %w", err)
+	}
+
+	// Create category_operations table if it doesn't exist
+	if categoryOperationsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE category_operations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				batch_id INTEGER NOT NULL,
+				transaction_id TEXT NOT NULL,
+				old_category_id INTEGER,
+				new_category_id INTEGER,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create category_operations table: %w", err)
+		}
+
+		_, err = db.conn.Exec(`CREATE INDEX idx_category_operations_batch_id ON category_operations(batch_id)`)
+		if err != nil {
+			return fmt.Errorf("failed to create category_operations index: %w", err)
 		}
-		return "", "", "", fmt.Errorf("failed to retrieve credentials: %w", err)
 	}
 
-	// Update last_used timestamp
-	_, updateErr := db.conn.Exec("UPDATE credentials SET last_used = CURRENT_TIMESTAMP WHERE access_url = ?", accessURL)
-	if updateErr != nil {
-		fmt.Printf("Warning: failed to update last_used timestamp: %v\n", updateErr)
+	// Check if transaction_edits table exists
+	var transactionEditsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='transaction_edits'
+	`).Scan(&transactionEditsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check transaction_edits table: %w", err)
 	}
 
-	return accessURL, username, password, nil
-}
+	// Create transaction_edits table if it doesn't exist
+	if transactionEditsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE transaction_edits (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				transaction_id TEXT NOT NULL,
+				field TEXT NOT NULL,
+				old_value TEXT NOT NULL,
+				new_value TEXT NOT NULL,
+				edited_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction_edits table: %w", err)
+		}
 
-func (db *DB) SaveRentCastAPIKey(apiKey string) error {
-	_, err := db.conn.Exec("DELETE FROM rentcast_credentials")
-	if err != nil {
-		return fmt.Errorf("failed to clear existing RentCast API key: %w", err)
+		_, err = db.conn.Exec(`CREATE INDEX idx_transaction_edits_transaction_id ON transaction_edits(transaction_id)`)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction_edits index: %w", err)
+		}
 	}
 
-	_, err = db.conn.Exec(`
-		INSERT INTO rentcast_credentials (api_key, last_used)
-		VALUES (?, CURRENT_TIMESTAMP)`,
-		apiKey)
+	// Check if display_description column exists in transactions table
+	var displayDescriptionColumnExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info('transactions')
+		WHERE name = 'display_description'
+	`).Scan(&displayDescriptionColumnExists)
 	if err != nil {
-		return fmt.Errorf("failed to save RentCast API key: %w", err)
+		return fmt.Errorf("failed to check display_description column: %w", err)
 	}
 
-	return nil
-}
-
-func (db *DB) GetRentCastAPIKey() (string, error) {
-	var apiKey string
-	err := db.conn.QueryRow(`
-		SELECT api_key
-		FROM rentcast_credentials
-		ORDER BY created_at DESC
-		LIMIT 1`).Scan(&apiKey)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("no RentCast API key found - run 'money property config' to set one")
+	// Add display_description column if it doesn't exist
+	if displayDescriptionColumnExists == 0 {
+		_, err = db.conn.Exec(`ALTER TABLE transactions ADD COLUMN display_description TEXT`)
+		if err != nil {
+			return fmt.Errorf("failed to add display_description column: %w", err)
 		}
-		return "", fmt.Errorf("failed to retrieve RentCast API key: %w", err)
 	}
 
-	// Update last_used timestamp
-	_, updateErr := db.conn.Exec("UPDATE rentcast_credentials SET last_used = CURRENT_TIMESTAMP WHERE api_key = ?", apiKey)
-	if updateErr != nil {
-		fmt.Printf("Warning: failed to update last_used timestamp: %v\n", updateErr)
+	// Check if rename_rules table exists
+	var renameRulesTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='rename_rules'
+	`).Scan(&renameRulesTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check rename_rules table: %w", err)
 	}
 
-	return apiKey, nil
-}
+	// Create rename_rules table if it doesn't exist
+	if renameRulesTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE rename_rules (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				pattern TEXT NOT NULL UNIQUE,
+				replacement TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create rename_rules table: %w", err)
+		}
+	}
 
-func (db *DB) HasRentCastAPIKey() (bool, error) {
-	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM rentcast_credentials").Scan(&count)
+	// Check if low_balance_alerts table exists
+	var lowBalanceAlertsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='low_balance_alerts'
+	`).Scan(&lowBalanceAlertsTableExists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check RentCast API key: %w", err)
+		return fmt.Errorf("failed to check low_balance_alerts table: %w", err)
 	}
-	return count > 0, nil
-}
 
-func (db *DB) HasCredentials() (bool, error) {
-	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM credentials").Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("failed to check credentials: %w", err)
+	// Create low_balance_alerts table if it doesn't exist
+	if lowBalanceAlertsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE low_balance_alerts (
+				account_id TEXT PRIMARY KEY,
+				threshold INTEGER NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create low_balance_alerts table: %w", err)
+		}
 	}
-	return count > 0, nil
-}
 
-func (db *DB) SaveOrganization(id, name, url string) error {
-	// Use INSERT OR REPLACE to handle both new and existing organizations
-	_, err := db.conn.Exec(`
-		INSERT OR REPLACE INTO organizations (id, name, url)
-		VALUES (?, ?, ?)`,
-		id, name, sql.NullString{String: url, Valid: url != ""})
+	// Check if bills table exists
+	var billsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='bills'
+	`).Scan(&billsTableExists)
 	if err != nil {
-		return fmt.Errorf("failed to save organization: %w", err)
+		return fmt.Errorf("failed to check bills table: %w", err)
 	}
-	return nil
-}
 
-func (db *DB) GetOrganizations() ([]Organization, error) {
-	query := `
-		SELECT id, name, url
-		FROM organizations
-		ORDER BY name`
+	// Create bills table if it doesn't exist
+	if billsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE bills (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				amount INTEGER NOT NULL,
+				due_day INTEGER NOT NULL CHECK (due_day BETWEEN 1 AND 31),
+				account_id TEXT,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create bills table: %w", err)
+		}
+	}
 
-	rows, err := db.conn.Query(query)
+	// Check if llm_calls table exists
+	var llmCallsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='llm_calls'
+	`).Scan(&llmCallsTableExists)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query organizations: %w", err)
+		return fmt.Errorf("failed to check llm_calls table: %w", err)
 	}
-	defer rows.Close()
-
-	var orgs []Organization
-	for rows.Next() {
-		var org Organization
-		var url sql.NullString
 
-		err := rows.Scan(&org.ID, &org.Name, &url)
+	// Create llm_calls table if it doesn't exist
+	if llmCallsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE llm_calls (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				purpose TEXT NOT NULL,
+				command TEXT NOT NULL,
+				prompt TEXT NOT NULL,
+				response TEXT NOT NULL DEFAULT '',
+				error TEXT NOT NULL DEFAULT '',
+				latency_ms INTEGER NOT NULL,
+				prompt_tokens INTEGER NOT NULL,
+				response_tokens INTEGER NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)
+		`)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan organization: %w", err)
+			return fmt.Errorf("failed to create llm_calls table: %w", err)
 		}
+	}
 
-		if url.Valid {
-			org.URL = &url.String
+	// Check if property_mortgages table exists
+	var propertyMortgagesTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='property_mortgages'
+	`).Scan(&propertyMortgagesTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check property_mortgages table: %w", err)
+	}
+
+	// Create property_mortgages table if it doesn't exist
+	if propertyMortgagesTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE property_mortgages (
+				loan_account_id TEXT PRIMARY KEY,
+				property_account_id TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (loan_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (property_account_id) REFERENCES properties(account_id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create property_mortgages table: %w", err)
 		}
+	}
 
-		orgs = append(orgs, org)
+	// Check if property_transactions table exists
+	var propertyTransactionsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='property_transactions'
+	`).Scan(&propertyTransactionsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check property_transactions table: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	// Create property_transactions table if it doesn't exist
+	if propertyTransactionsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE property_transactions (
+				transaction_id TEXT PRIMARY KEY,
+				property_account_id TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (transaction_id) REFERENCES transactions(id),
+				FOREIGN KEY (property_account_id) REFERENCES properties(account_id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create property_transactions table: %w", err)
+		}
 	}
 
-	return orgs, nil
-}
+	// Add the public record columns to the properties table
+	for _, column := range []struct{ name, definition string }{
+		{"bedrooms", "INTEGER"},
+		{"bathrooms", "REAL"},
+		{"square_footage", "INTEGER"},
+		{"lot_size", "INTEGER"},
+		{"year_built", "INTEGER"},
+	} {
+		var columnExists int
+		err = db.conn.QueryRow(`
+			SELECT COUNT(*)
+			FROM pragma_table_info('properties')
+			WHERE name = ?
+		`, column.name).Scan(&columnExists)
+		if err != nil {
+			return fmt.Errorf("failed to check %s column: %w", column.name, err)
+		}
 
-func (db *DB) SaveAccount(id, orgID, name, currency string, balance int, availableBalance *int, balanceDate string) error {
-	// Use INSERT OR REPLACE to handle both new and existing accounts
-	// Update the updated_at timestamp for existing accounts
-	var availableBalanceVal sql.NullInt64
-	if availableBalance != nil {
-		availableBalanceVal = sql.NullInt64{Int64: int64(*availableBalance), Valid: true}
+		if columnExists == 0 {
+			_, err = db.conn.Exec(`ALTER TABLE properties ADD COLUMN ` + column.name + ` ` + column.definition)
+			if err != nil {
+				return fmt.Errorf("failed to add %s column: %w", column.name, err)
+			}
+		}
 	}
 
-	// Use INSERT OR IGNORE first, then UPDATE to preserve account_type
-	_, err := db.conn.Exec(`
+	// Check if assets table exists
+	var assetsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='assets'
+	`).Scan(&assetsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check assets table: %w", err)
+	}
+
+	// Create assets table if it doesn't exist
+	if assetsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE assets (
+				account_id TEXT PRIMARY KEY,
+				kind TEXT NOT NULL,
+				purchase_price INTEGER NOT NULL,
+				purchase_date TEXT NOT NULL,
+				salvage_value INTEGER NOT NULL DEFAULT 0,
+				useful_life_months INTEGER,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create assets table: %w", err)
+		}
+	}
+
+	// Check if valuation_provider column exists in properties table
+	var valuationProviderColumnExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info('properties')
+		WHERE name = 'valuation_provider'
+	`).Scan(&valuationProviderColumnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check valuation_provider column: %w", err)
+	}
+
+	// Add valuation_provider column if it doesn't exist
+	if valuationProviderColumnExists == 0 {
+		_, err = db.conn.Exec(`ALTER TABLE properties ADD COLUMN valuation_provider TEXT`)
+		if err != nil {
+			return fmt.Errorf("failed to add valuation_provider column: %w", err)
+		}
+	}
+
+	// Check if holdings table exists
+	var holdingsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='holdings'
+	`).Scan(&holdingsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check holdings table: %w", err)
+	}
+
+	// Create holdings table if it doesn't exist
+	if holdingsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE holdings (
+				id TEXT PRIMARY KEY,
+				account_id TEXT NOT NULL,
+				symbol TEXT NOT NULL DEFAULT '',
+				description TEXT NOT NULL DEFAULT '',
+				shares REAL NOT NULL DEFAULT 0,
+				currency TEXT NOT NULL DEFAULT '',
+				market_value INTEGER NOT NULL DEFAULT 0,
+				cost_basis INTEGER,
+				acquired TEXT NOT NULL DEFAULT '',
+				price_updated_at DATETIME,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create holdings table: %w", err)
+		}
+
+		_, err = db.conn.Exec(`CREATE INDEX idx_holdings_account_id ON holdings(account_id)`)
+		if err != nil {
+			return fmt.Errorf("failed to create holdings index: %w", err)
+		}
+	}
+
+	// Check if crypto_wallets table exists
+	var cryptoWalletsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='crypto_wallets'
+	`).Scan(&cryptoWalletsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check crypto_wallets table: %w", err)
+	}
+
+	// Create crypto_wallets table if it doesn't exist
+	if cryptoWalletsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE crypto_wallets (
+				account_id TEXT PRIMARY KEY,
+				source TEXT NOT NULL,
+				address TEXT NOT NULL DEFAULT '',
+				api_key TEXT NOT NULL DEFAULT '',
+				api_secret TEXT NOT NULL DEFAULT '',
+				last_updated DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create crypto_wallets table: %w", err)
+		}
+	}
+
+	// Check if acquired column exists in holdings table
+	var acquiredColumnExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info('holdings')
+		WHERE name = 'acquired'
+	`).Scan(&acquiredColumnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check acquired column: %w", err)
+	}
+
+	// Add acquired column if it doesn't exist
+	if acquiredColumnExists == 0 {
+		_, err = db.conn.Exec(`ALTER TABLE holdings ADD COLUMN acquired TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			return fmt.Errorf("failed to add acquired column: %w", err)
+		}
+	}
+
+	// Check if lots table exists
+	var lotsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='lots'
+	`).Scan(&lotsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check lots table: %w", err)
+	}
+
+	// Create lots table if it doesn't exist
+	if lotsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE lots (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				account_id TEXT NOT NULL,
+				symbol TEXT NOT NULL,
+				shares REAL NOT NULL,
+				cost_basis INTEGER NOT NULL,
+				acquired TEXT NOT NULL,
+				sold TEXT,
+				proceeds INTEGER,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id) REFERENCES accounts(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create lots table: %w", err)
+		}
+
+		_, err = db.conn.Exec(`CREATE INDEX idx_lots_account_id ON lots(account_id)`)
+		if err != nil {
+			return fmt.Errorf("failed to create lots index: %w", err)
+		}
+	}
+
+	// Check if tax_mappings table exists
+	var taxMappingsTableExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type='table' AND name='tax_mappings'
+	`).Scan(&taxMappingsTableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check tax_mappings table: %w", err)
+	}
+
+	// Create tax_mappings table if it doesn't exist
+	if taxMappingsTableExists == 0 {
+		_, err = db.conn.Exec(`
+			CREATE TABLE tax_mappings (
+				category_id INTEGER PRIMARY KEY,
+				tax_line TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (category_id) REFERENCES categories(id)
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create tax_mappings table: %w", err)
+		}
+	}
+
+	// Closed months and the snapshots they were closed with
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS closed_months (
+			month TEXT PRIMARY KEY,
+			closed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS closed_month_categories (
+			month TEXT NOT NULL,
+			category TEXT NOT NULL,
+			income INTEGER NOT NULL,
+			expenses INTEGER NOT NULL,
+			PRIMARY KEY (month, category),
+			FOREIGN KEY (month) REFERENCES closed_months(month) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS closed_month_balances (
+			month TEXT NOT NULL,
+			account_id TEXT NOT NULL,
+			balance INTEGER NOT NULL,
+			PRIMARY KEY (month, account_id),
+			FOREIGN KEY (month) REFERENCES closed_months(month) ON DELETE CASCADE,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create closed month tables: %w", err)
+		}
+	}
+
+	// Default categories for new transactions, by account
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS account_default_categories (
+			account_id TEXT PRIMARY KEY,
+			category_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create account_default_categories table: %w", err)
+	}
+
+	// Credit limits, by account
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS credit_limits (
+			account_id TEXT PRIMARY KEY,
+			credit_limit INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create credit_limits table: %w", err)
+	}
+
+	// Check if owner column exists in accounts table
+	var ownerColumnExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info('accounts')
+		WHERE name = 'owner'
+	`).Scan(&ownerColumnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check owner column: %w", err)
+	}
+
+	// Add owner column if it doesn't exist
+	if ownerColumnExists == 0 {
+		_, err = db.conn.Exec(`
+			ALTER TABLE accounts
+			ADD COLUMN owner TEXT
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to add owner column: %w", err)
+		}
+	}
+
+	// Add synced_at, for spotting accounts whose bank connection broke.
+	// Accounts with a balance date came from SimpleFIN, and were last
+	// synced when they were last updated.
+	var syncedAtColumnExists int
+	err = db.conn.QueryRow(`
+		SELECT COUNT(*)
+		FROM pragma_table_info('accounts')
+		WHERE name = 'synced_at'
+	`).Scan(&syncedAtColumnExists)
+	if err != nil {
+		return fmt.Errorf("failed to check synced_at column: %w", err)
+	}
+	if syncedAtColumnExists == 0 {
+		_, err = db.conn.Exec(`ALTER TABLE accounts ADD COLUMN synced_at DATETIME`)
+		if err != nil {
+			return fmt.Errorf("failed to add synced_at column: %w", err)
+		}
+		_, err = db.conn.Exec(`UPDATE accounts SET synced_at = updated_at WHERE balance_date IS NOT NULL`)
+		if err != nil {
+			return fmt.Errorf("failed to backfill synced_at: %w", err)
+		}
+	}
+
+	// Fetch runs, for money diff
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS fetch_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NOT NULL,
+			replay BOOLEAN NOT NULL DEFAULT FALSE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create fetch_runs table: %w", err)
+	}
+
+	// Budget thresholds already notified, so each is only sent once a month
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS budget_notifications (
+			category_id INTEGER NOT NULL,
+			month TEXT NOT NULL,
+			percent INTEGER NOT NULL,
+			sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (category_id, month, percent),
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create budget_notifications table: %w", err)
+	}
+
+	// YNAB accounts, categories and transactions synced with money's
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS ynab_accounts (
+			account_id TEXT PRIMARY KEY,
+			ynab_account_id TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS ynab_categories (
+			category_id INTEGER PRIMARY KEY,
+			ynab_category_id TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS ynab_transactions (
+			transaction_id TEXT PRIMARY KEY,
+			ynab_transaction_id TEXT NOT NULL UNIQUE,
+			synced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+		)`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create YNAB tables: %w", err)
+		}
+	}
+
+	// Actual Budget accounts money's are pushed to
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS actual_accounts (
+			account_id TEXT PRIMARY KEY,
+			actual_account_id TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create actual_accounts table: %w", err)
+	}
+
+	// GoCardless credentials and the banks linked through it
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS gocardless_credentials (
+			id INTEGER PRIMARY KEY,
+			secret_id TEXT NOT NULL,
+			secret_key TEXT NOT NULL,
+			access_token TEXT,
+			access_expires TEXT,
+			refresh_token TEXT,
+			refresh_expires TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS gocardless_banks (
+			requisition_id TEXT PRIMARY KEY,
+			institution_id TEXT NOT NULL UNIQUE,
+			institution_name TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create GoCardless tables: %w", err)
+		}
+	}
+
+	// Banks enrolled through Teller Connect, with the access token each gave
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS teller_enrollments (
+			enrollment_id TEXT PRIMARY KEY,
+			access_token TEXT NOT NULL,
+			institution_name TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create teller_enrollments table: %w", err)
+	}
+
+	// Day of the month each credit account's statement payment is due
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS statement_due_days (
+			account_id TEXT PRIMARY KEY,
+			due_day INTEGER NOT NULL CHECK (due_day BETWEEN 1 AND 31),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create statement_due_days table: %w", err)
+	}
+
+	// Add color and emoji, how a category is drawn
+	for _, column := range []string{"color", "emoji"} {
+		var columnExists int
+		err = db.conn.QueryRow(`
+			SELECT COUNT(*)
+			FROM pragma_table_info('categories')
+			WHERE name = ?
+		`, column).Scan(&columnExists)
+		if err != nil {
+			return fmt.Errorf("failed to check %s column: %w", column, err)
+		}
+		if columnExists == 0 {
+			_, err = db.conn.Exec(`ALTER TABLE categories ADD COLUMN ` + column + ` TEXT`)
+			if err != nil {
+				return fmt.Errorf("failed to add %s column: %w", column, err)
+			}
+		}
+	}
+
+	// Tables created before foreign keys were enforced have no ON DELETE
+	// actions; rebuild them from schema.sql to pick them up
+	rows, err := db.conn.Query(`
+		SELECT name
+		FROM sqlite_master
+		WHERE type='table' AND sql LIKE '%REFERENCES%' AND sql NOT LIKE '%ON DELETE%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to check foreign key actions: %w", err)
+	}
+	var outdated []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table name: %w", err)
+		}
+		outdated = append(outdated, name)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating tables: %w", err)
+	}
+
+	if len(outdated) > 0 {
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for _, table := range outdated {
+			createSQL, ok := schemaTableSQL(table)
+			if !ok || !strings.Contains(createSQL, "ON DELETE") {
+				continue
+			}
+			if err := rebuildTable(tx, table, createSQL); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit foreign key migration: %w", err)
+		}
+	}
+
+	// Deleting a category used to drop the undo log's changes involving it;
+	// now it marks them, so undo can report what it can't restore
+	var categoryOperationsSQL string
+	err = db.conn.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='category_operations'`).Scan(&categoryOperationsSQL)
+	if err != nil {
+		return fmt.Errorf("failed to check category_operations table: %w", err)
+	}
+	if !strings.Contains(categoryOperationsSQL, "old_category_deleted") {
+		createSQL, ok := schemaTableSQL("category_operations")
+		if !ok {
+			return fmt.Errorf("category_operations is missing from the schema")
+		}
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := rebuildTable(tx, "category_operations", createSQL); err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit category_operations migration: %w", err)
+		}
+	}
+
+	// Transactions are mostly read for one account or category over a date
+	// range, newest first. The composite indexes serve those without a scan
+	// or a sort, and replace the single column indexes they start with.
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_transactions_account_posted ON transactions(account_id, posted)`,
+		`CREATE INDEX IF NOT EXISTS idx_transactions_category_posted ON transactions(category_id, posted)`,
+		`CREATE INDEX IF NOT EXISTS idx_transactions_description ON transactions(description COLLATE NOCASE)`,
+		`DROP INDEX IF EXISTS idx_transactions_account_id`,
+		`DROP INDEX IF EXISTS idx_transactions_category_id`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to migrate transaction indexes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// schemaTableSQL returns table's CREATE TABLE statement from schema.sql
+func schemaTableSQL(table string) (string, bool) {
+	start := strings.Index(schemaSQL, "CREATE TABLE "+table+" (")
+	if start < 0 {
+		return "", false
+	}
+	end := strings.Index(schemaSQL[start:], "\n);")
+	if end < 0 {
+		return "", false
+	}
+	return schemaSQL[start : start+end+len("\n);")], true
+}
+
+// rebuildTable recreates table with createSQL, copying the rows of the
+// columns both versions have and restoring its indexes. Foreign keys must be
+// off, or dropping the old table would cascade.
+func rebuildTable(tx *sql.Tx, table, createSQL string) error {
+	var indexes []string
+	rows, err := tx.Query(`SELECT sql FROM sqlite_master WHERE type='index' AND tbl_name = ? AND sql IS NOT NULL`, table)
+	if err != nil {
+		return fmt.Errorf("failed to query %s indexes: %w", table, err)
+	}
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s index: %w", table, err)
+		}
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+
+	newTable := table + "_new"
+	_, err = tx.Exec(strings.Replace(createSQL, "CREATE TABLE "+table+" (", "CREATE TABLE "+newTable+" (", 1))
+	if err != nil {
+		return fmt.Errorf("failed to create new %s table: %w", table, err)
+	}
+
+	rows, err = tx.Query(`
+		SELECT name FROM pragma_table_info(?)
+		WHERE name IN (SELECT name FROM pragma_table_info(?))`,
+		table, newTable)
+	if err != nil {
+		return fmt.Errorf("failed to query %s columns: %w", table, err)
+	}
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		columns = append(columns, `"`+column+`"`)
+	}
+	rows.Close()
+
+	columnList := strings.Join(columns, ", ")
+	_, err = tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, newTable, columnList, columnList, table))
+	if err != nil {
+		return fmt.Errorf("failed to copy %s data: %w", table, err)
+	}
+
+	if _, err = tx.Exec(`DROP TABLE ` + table); err != nil {
+		return fmt.Errorf("failed to drop old %s table: %w", table, err)
+	}
+	if _, err = tx.Exec(`ALTER TABLE ` + newTable + ` RENAME TO ` + table); err != nil {
+		return fmt.Errorf("failed to rename new %s table: %w", table, err)
+	}
+
+	for _, index := range indexes {
+		if _, err = tx.Exec(index); err != nil {
+			return fmt.Errorf("failed to recreate %s index: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// GetConfig returns the database configuration
+func (db *DB) GetConfig() *config.Config {
+	return db.config
+}
+
+// SaveCredentials replaces the stored SimpleFIN credentials. The old ones are
+// removed in the same transaction, so a failure never leaves none stored.
+func (db *DB) SaveCredentials(accessURL, username, password string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM credentials")
+	if err != nil {
+		return fmt.Errorf("failed to clear existing credentials: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO credentials (access_url, username, password, last_used) 
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		accessURL, username, password)
+	if err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit credentials: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetCredentials() (accessURL, username, password string, err error) {
+	err = db.conn.QueryRow(`
+		SELECT access_url, username, password 
+		FROM credentials 
+		ORDER BY created_at DESC 
+		LIMIT 1`).Scan(&accessURL, &username, &password)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", "", fmt.Errorf("no credentials found - run 'money init' first")
+		}
+		return "", "", "", fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	// Update last_used timestamp
+	_, updateErr := db.conn.Exec("UPDATE credentials SET last_used = CURRENT_TIMESTAMP WHERE access_url = ?", accessURL)
+	if updateErr != nil {
+		slog.Warn("failed to update last_used timestamp", "err", updateErr)
+	}
+
+	return accessURL, username, password, nil
+}
+
+func (db *DB) SaveRentCastAPIKey(apiKey string) error {
+	_, err := db.conn.Exec("DELETE FROM rentcast_credentials")
+	if err != nil {
+		return fmt.Errorf("failed to clear existing RentCast API key: %w", err)
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO rentcast_credentials (api_key, last_used)
+		VALUES (?, CURRENT_TIMESTAMP)`,
+		apiKey)
+	if err != nil {
+		return fmt.Errorf("failed to save RentCast API key: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetRentCastAPIKey() (string, error) {
+	var apiKey string
+	err := db.conn.QueryRow(`
+		SELECT api_key
+		FROM rentcast_credentials
+		ORDER BY created_at DESC
+		LIMIT 1`).Scan(&apiKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("no RentCast API key found - run 'money property config' to set one")
+		}
+		return "", fmt.Errorf("failed to retrieve RentCast API key: %w", err)
+	}
+
+	// Update last_used timestamp
+	_, updateErr := db.conn.Exec("UPDATE rentcast_credentials SET last_used = CURRENT_TIMESTAMP WHERE api_key = ?", apiKey)
+	if updateErr != nil {
+		slog.Warn("failed to update last_used timestamp", "err", updateErr)
+	}
+
+	return apiKey, nil
+}
+
+func (db *DB) HasRentCastAPIKey() (bool, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM rentcast_credentials").Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check RentCast API key: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GoCardlessCredentials are the GoCardless user secret and the tokens it
+// was last exchanged for, which are empty until then
+type GoCardlessCredentials struct {
+	SecretID       string
+	SecretKey      string
+	AccessToken    string
+	AccessExpires  time.Time
+	RefreshToken   string
+	RefreshExpires time.Time
+}
+
+// SaveGoCardlessCredentials replaces the stored GoCardless credentials
+func (db *DB) SaveGoCardlessCredentials(c GoCardlessCredentials) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM gocardless_credentials"); err != nil {
+		return fmt.Errorf("failed to clear existing GoCardless credentials: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO gocardless_credentials (secret_id, secret_key, access_token, access_expires, refresh_token, refresh_expires)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		c.SecretID, c.SecretKey,
+		c.AccessToken, c.AccessExpires.UTC().Format(time.RFC3339),
+		c.RefreshToken, c.RefreshExpires.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save GoCardless credentials: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit GoCardless credentials: %w", err)
+	}
+	return nil
+}
+
+// GetGoCardlessCredentials returns the stored GoCardless credentials
+func (db *DB) GetGoCardlessCredentials() (*GoCardlessCredentials, error) {
+	var c GoCardlessCredentials
+	var accessToken, accessExpires, refreshToken, refreshExpires sql.NullString
+	err := db.conn.QueryRow(`
+		SELECT secret_id, secret_key, access_token, access_expires, refresh_token, refresh_expires
+		FROM gocardless_credentials
+		ORDER BY created_at DESC
+		LIMIT 1`).Scan(&c.SecretID, &c.SecretKey, &accessToken, &accessExpires, &refreshToken, &refreshExpires)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no GoCardless credentials found - run 'money init gocardless' first")
+		}
+		return nil, fmt.Errorf("failed to retrieve GoCardless credentials: %w", err)
+	}
+
+	c.AccessToken = accessToken.String
+	c.RefreshToken = refreshToken.String
+	// Unparseable expiries are left zero, so the tokens are renewed
+	c.AccessExpires, _ = time.Parse(time.RFC3339, accessExpires.String)
+	c.RefreshExpires, _ = time.Parse(time.RFC3339, refreshExpires.String)
+	return &c, nil
+}
+
+func (db *DB) HasGoCardlessCredentials() (bool, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM gocardless_credentials").Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check GoCardless credentials: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GoCardlessBank is a bank linked through GoCardless
+type GoCardlessBank struct {
+	RequisitionID   string
+	InstitutionID   string
+	InstitutionName string
+	ExpiresAt       string // YYYY-MM-DD, when the granted access runs out
+}
+
+// SaveGoCardlessBank records a linked bank, replacing an earlier link to
+// the same institution
+func (db *DB) SaveGoCardlessBank(bank GoCardlessBank) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO gocardless_banks (requisition_id, institution_id, institution_name, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(institution_id) DO UPDATE SET
+			requisition_id = excluded.requisition_id,
+			institution_name = excluded.institution_name,
+			expires_at = excluded.expires_at,
+			created_at = CURRENT_TIMESTAMP`,
+		bank.RequisitionID, bank.InstitutionID, bank.InstitutionName, bank.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save GoCardless bank: %w", err)
+	}
+	return nil
+}
+
+// GetGoCardlessBanks returns the banks linked through GoCardless by name
+func (db *DB) GetGoCardlessBanks() ([]GoCardlessBank, error) {
+	rows, err := db.conn.Query(`
+		SELECT requisition_id, institution_id, institution_name, expires_at
+		FROM gocardless_banks
+		ORDER BY institution_name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GoCardless banks: %w", err)
+	}
+	defer rows.Close()
+
+	var banks []GoCardlessBank
+	for rows.Next() {
+		var bank GoCardlessBank
+		if err := rows.Scan(&bank.RequisitionID, &bank.InstitutionID, &bank.InstitutionName, &bank.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan GoCardless bank: %w", err)
+		}
+		banks = append(banks, bank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating GoCardless banks: %w", err)
+	}
+
+	return banks, nil
+}
+
+// TellerEnrollment is a bank enrolled through Teller Connect
+type TellerEnrollment struct {
+	ID              string
+	AccessToken     string
+	InstitutionName string
+}
+
+// SaveTellerEnrollment records an enrollment, replacing the access token
+// of one enrolled again
+func (db *DB) SaveTellerEnrollment(e TellerEnrollment) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO teller_enrollments (enrollment_id, access_token, institution_name)
+		VALUES (?, ?, ?)
+		ON CONFLICT(enrollment_id) DO UPDATE SET
+			access_token = excluded.access_token,
+			institution_name = excluded.institution_name,
+			created_at = CURRENT_TIMESTAMP`,
+		e.ID, e.AccessToken, e.InstitutionName)
+	if err != nil {
+		return fmt.Errorf("failed to save Teller enrollment: %w", err)
+	}
+	return nil
+}
+
+// GetTellerEnrollments returns the banks enrolled through Teller by name
+func (db *DB) GetTellerEnrollments() ([]TellerEnrollment, error) {
+	rows, err := db.conn.Query(`
+		SELECT enrollment_id, access_token, institution_name
+		FROM teller_enrollments
+		ORDER BY institution_name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Teller enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	var enrollments []TellerEnrollment
+	for rows.Next() {
+		var e TellerEnrollment
+		if err := rows.Scan(&e.ID, &e.AccessToken, &e.InstitutionName); err != nil {
+			return nil, fmt.Errorf("failed to scan Teller enrollment: %w", err)
+		}
+		enrollments = append(enrollments, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Teller enrollments: %w", err)
+	}
+
+	return enrollments, nil
+}
+
+// DeleteTellerEnrollment forgets an enrollment; its accounts and their
+// history are kept
+func (db *DB) DeleteTellerEnrollment(id string) error {
+	result, err := db.conn.Exec("DELETE FROM teller_enrollments WHERE enrollment_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete Teller enrollment: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("Teller enrollment not found: %s", id)
+	}
+	return nil
+}
+
+func (db *DB) HasCredentials() (bool, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM credentials").Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check credentials: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (db *DB) SaveOrganization(id, name, url string) error {
+	// Upsert rather than replace, which would delete the organization first
+	// and trip the foreign keys of its accounts
+	_, err := db.conn.Exec(`
+		INSERT INTO organizations (id, name, url)
+		VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			url = excluded.url`,
+		id, name, sql.NullString{String: url, Valid: url != ""})
+	if err != nil {
+		return fmt.Errorf("failed to save organization: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetOrganizations() ([]Organization, error) {
+	query := `
+		SELECT id, name, url
+		FROM organizations
+		ORDER BY name`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		var org Organization
+		var url sql.NullString
+
+		err := rows.Scan(&org.ID, &org.Name, &url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+
+		if url.Valid {
+			org.URL = &url.String
+		}
+
+		orgs = append(orgs, org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+func (db *DB) SaveAccount(id, orgID, name, currency string, balance int64, availableBalance *int64, balanceDate string) error {
+	// Use INSERT OR REPLACE to handle both new and existing accounts
+	// Update the updated_at timestamp for existing accounts
+	var availableBalanceVal sql.NullInt64
+	if availableBalance != nil {
+		availableBalanceVal = sql.NullInt64{Int64: *availableBalance, Valid: true}
+	}
+
+	// Use INSERT OR IGNORE first, then UPDATE to preserve account_type
+	_, err := db.conn.Exec(`
 		INSERT OR IGNORE INTO accounts (id, org_id, name, currency, balance, available_balance, balance_date, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
 		id, orgID, name, currency, balance, availableBalanceVal,
 		sql.NullString{String: balanceDate, Valid: balanceDate != ""})
 	if err != nil {
-		return fmt.Errorf("failed to insert account: %w", err)
+		return fmt.Errorf("failed to insert account: %w", err)
+	}
+
+	// Now update existing records (preserves account_type if already set)
+	_, err = db.conn.Exec(`
+		UPDATE accounts 
+		SET org_id = ?, name = ?, currency = ?, balance = ?, available_balance = ?, balance_date = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		orgID, name, currency, balance, availableBalanceVal,
+		sql.NullString{String: balanceDate, Valid: balanceDate != ""}, id)
+	if err != nil {
+		return fmt.Errorf("failed to save account: %w", err)
+	}
+	return nil
+}
+
+// MarkAccountSynced records that a fetch just returned the account
+func (db *DB) MarkAccountSynced(id string) error {
+	_, err := db.conn.Exec(`UPDATE accounts SET synced_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark account synced: %w", err)
+	}
+	return nil
+}
+
+// GetStaleAccounts returns the linked accounts, of the handle's owners,
+// last updated before cutoff, oldest first. An account is last updated as
+// of the balance date its bank reported, or when a fetch last returned it
+// if the bank doesn't report one. Accounts added by hand, like assets and
+// properties, are never stale.
+func (db *DB) GetStaleAccounts(cutoff time.Time) ([]StaleAccount, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, strftime('%Y-%m-%d %H:%M:%S', COALESCE(balance_date, synced_at)) AS last_update
+		FROM accounts
+		WHERE COALESCE(balance_date, synced_at) IS NOT NULL
+		  AND last_update < ?
+		ORDER BY last_update, id`,
+		cutoff.UTC().Format(fetchRunTimeLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []StaleAccount
+	for rows.Next() {
+		var id, lastUpdate string
+		if err := rows.Scan(&id, &lastUpdate); err != nil {
+			return nil, fmt.Errorf("failed to scan stale account: %w", err)
+		}
+		account, exists := byID[id]
+		if !exists {
+			continue
+		}
+		updated, err := time.Parse(fetchRunTimeLayout, lastUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update time %q for account %s: %w", lastUpdate, id, err)
+		}
+		stale = append(stale, StaleAccount{Account: account, LastUpdate: updated})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stale accounts: %w", err)
+	}
+
+	return stale, nil
+}
+
+// GetAccounts returns every account, or only those of the handle's owners
+// when it was scoped with ForOwners
+func (db *DB) GetAccounts() ([]Account, error) {
+	ownerCondition, ownerArgs := db.ownerCondition("a.id")
+	query := `
+		SELECT a.id, a.org_id, a.name, a.nickname, a.currency, a.balance, a.available_balance, a.balance_date, a.account_type, a.owner
+		FROM accounts a
+		WHERE ` + ownerCondition + `
+		ORDER BY a.org_id, a.name`
+
+	rows, err := db.conn.Query(query, ownerArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var account Account
+		var nickname sql.NullString
+		var availableBalance sql.NullInt64
+		var balanceDate sql.NullString
+		var accountType sql.NullString
+		var owner sql.NullString
+
+		err := rows.Scan(
+			&account.ID,
+			&account.OrgID,
+			&account.Name,
+			&nickname,
+			&account.Currency,
+			&account.Balance,
+			&availableBalance,
+			&balanceDate,
+			&accountType,
+			&owner,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+
+		// Handle nullable fields
+		if nickname.Valid {
+			account.Nickname = &nickname.String
+		}
+		if availableBalance.Valid {
+			balance := availableBalance.Int64
+			account.AvailableBalance = &balance
+		}
+		if balanceDate.Valid {
+			account.BalanceDate = &balanceDate.String
+		}
+		if accountType.Valid {
+			account.AccountType = &accountType.String
+		}
+		if owner.Valid {
+			account.Owner = &owner.String
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+func (db *DB) UpdateAccountBalance(accountID string, balance int64) error {
+	_, err := db.conn.Exec(`
+		UPDATE accounts
+		SET balance = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		balance, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) SetAccountType(accountID, accountType string) error {
+	// Validate account type
+	validTypes := []string{"checking", "savings", "credit", "investment", "crypto", "loan", "property", "other"}
+	isValid := false
+	for _, validType := range validTypes {
+		if accountType == validType {
+			isValid = true
+			break
+		}
+	}
+	if !isValid {
+		return fmt.Errorf("invalid account type: %s. Valid types are: %v", accountType, validTypes)
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE accounts 
+		SET account_type = ?, updated_at = CURRENT_TIMESTAMP 
+		WHERE id = ?`,
+		accountType, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to set account type: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ClearAccountType(accountID string) error {
+	_, err := db.conn.Exec(`
+		UPDATE accounts
+		SET account_type = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear account type: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) SetAccountNickname(accountID, nickname string) error {
+	_, err := db.conn.Exec(`
+		UPDATE accounts
+		SET nickname = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		nickname, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to set account nickname: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ClearAccountNickname(accountID string) error {
+	_, err := db.conn.Exec(`
+		UPDATE accounts
+		SET nickname = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear account nickname: %w", err)
+	}
+	return nil
+}
+
+// NormalizeOwner trims and lowercases an owner name, so "Joint" and "joint"
+// are the same owner. Owners are listed comma separated in --owner, so a name
+// can't contain a comma.
+func NormalizeOwner(owner string) (string, error) {
+	owner = strings.ToLower(strings.TrimSpace(owner))
+	if owner == "" {
+		return "", fmt.Errorf("owner name cannot be empty")
+	}
+	if strings.Contains(owner, ",") {
+		return "", fmt.Errorf("owner name cannot contain a comma: %s", owner)
+	}
+	return owner, nil
+}
+
+// SetAccountOwner records who an account belongs to, like "me", "spouse" or
+// "joint"
+func (db *DB) SetAccountOwner(accountID, owner string) error {
+	owner, err := NormalizeOwner(owner)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.conn.Exec(`
+		UPDATE accounts
+		SET owner = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		owner, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to set account owner: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("account not found: %s", accountID)
+	}
+	return nil
+}
+
+func (db *DB) ClearAccountOwner(accountID string) error {
+	_, err := db.conn.Exec(`
+		UPDATE accounts
+		SET owner = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear account owner: %w", err)
+	}
+	return nil
+}
+
+// GetAccountOwners returns the owners set on any account, sorted by name
+func (db *DB) GetAccountOwners() ([]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT DISTINCT owner
+		FROM accounts
+		WHERE owner IS NOT NULL
+		ORDER BY owner`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account owners: %w", err)
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, fmt.Errorf("failed to scan account owner: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating account owners: %w", err)
+	}
+	return owners, nil
+}
+
+// ForOwners returns a handle on the same database that only reads the
+// accounts belonging to owners, along with their transactions and balance
+// history, so balances, budgets and reports answer for those people alone.
+// Accounts without an owner are left out. Lookups by ID and writes are not
+// limited. With no owners it returns db itself.
+func (db *DB) ForOwners(owners []string) *DB {
+	if len(owners) == 0 {
+		return db
+	}
+	scoped := *db
+	scoped.owners = owners
+	return &scoped
+}
+
+// Owners returns the owners the handle is limited to, or nil when it reads
+// every account
+func (db *DB) Owners() []string {
+	return db.owners
+}
+
+// ownerCondition returns the SQL condition limiting column, an account ID,
+// to the accounts of the handle's owners, and its arguments. An unscoped
+// handle matches every account.
+func (db *DB) ownerCondition(column string) (string, []interface{}) {
+	if len(db.owners) == 0 {
+		return "1 = 1", nil
+	}
+	return ownerCondition(column, db.owners)
+}
+
+func ownerCondition(column string, owners []string) (string, []interface{}) {
+	placeholders := make([]string, len(owners))
+	args := make([]interface{}, len(owners))
+	for i, owner := range owners {
+		placeholders[i] = "?"
+		args[i] = owner
+	}
+	return column + " IN (SELECT id FROM accounts WHERE owner IN (" + strings.Join(placeholders, ", ") + "))", args
+}
+
+func (db *DB) GetAccountByID(accountID string) (*Account, error) {
+	query := `
+		SELECT a.id, a.org_id, a.name, a.nickname, a.currency, a.balance, a.available_balance, a.balance_date, a.account_type, a.owner
+		FROM accounts a
+		WHERE a.id = ?`
+
+	var account Account
+	var nickname sql.NullString
+	var availableBalance sql.NullInt64
+	var balanceDate sql.NullString
+	var accountType sql.NullString
+	var owner sql.NullString
+
+	err := db.conn.QueryRow(query, accountID).Scan(
+		&account.ID,
+		&account.OrgID,
+		&account.Name,
+		&nickname,
+		&account.Currency,
+		&account.Balance,
+		&availableBalance,
+		&balanceDate,
+		&accountType,
+		&owner,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account not found: %s", accountID)
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	// Handle nullable fields
+	if nickname.Valid {
+		account.Nickname = &nickname.String
+	}
+	if availableBalance.Valid {
+		balance := availableBalance.Int64
+		account.AvailableBalance = &balance
+	}
+	if balanceDate.Valid {
+		account.BalanceDate = &balanceDate.String
+	}
+	if accountType.Valid {
+		account.AccountType = &accountType.String
+	}
+	if owner.Valid {
+		account.Owner = &owner.String
+	}
+
+	return &account, nil
+}
+
+// DeleteAccount deletes an account and all associated data
+func (db *DB) DeleteAccount(accountID string) error {
+	// Start a transaction to ensure data consistency
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Delete balance history
+	_, err = tx.Exec("DELETE FROM balance_history WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete balance history: %w", err)
+	}
+
+	// Delete the low balance alert, default category, credit limit and
+	// statement due day, and detach bills paid from the account
+	_, err = tx.Exec("DELETE FROM low_balance_alerts WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete low balance alert: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM account_default_categories WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete default category: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM credit_limits WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete credit limit: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM statement_due_days WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete statement due day: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM ynab_accounts WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete YNAB account mapping: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM actual_accounts WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete Actual account mapping: %w", err)
+	}
+
+	_, err = tx.Exec("UPDATE bills SET account_id = NULL WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to detach bills: %w", err)
+	}
+
+	// Delete the edit history for the account's transactions
+	_, err = tx.Exec("DELETE FROM transaction_edits WHERE transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction edits: %w", err)
+	}
+
+	// Forget which YNAB transactions the account's transactions were synced with
+	_, err = tx.Exec("DELETE FROM ynab_transactions WHERE transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete YNAB transaction links: %w", err)
+	}
+
+	// Delete the category change log for the account's transactions
+	_, err = tx.Exec("DELETE FROM category_operations WHERE transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category operations: %w", err)
+	}
+
+	// Delete transactions
+	_, err = tx.Exec("DELETE FROM transactions WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete transactions: %w", err)
+	}
+
+	// Unlink mortgages from the account, whether it's the loan or the property
+	_, err = tx.Exec("DELETE FROM property_mortgages WHERE loan_account_id = ? OR property_account_id = ?", accountID, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink mortgages: %w", err)
+	}
+
+	// Untag the account's transactions, and transactions tagged to it if it's a property
+	_, err = tx.Exec("DELETE FROM property_transactions WHERE property_account_id = ? OR transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", accountID, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to untag property transactions: %w", err)
+	}
+
+	// Delete the wallet if it's a crypto account
+	_, err = tx.Exec("DELETE FROM crypto_wallets WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete crypto wallet: %w", err)
+	}
+
+	// Delete tax lots if it's an investment account
+	_, err = tx.Exec("DELETE FROM lots WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete lots: %w", err)
+	}
+
+	// Delete holdings if it's an investment or crypto account
+	_, err = tx.Exec("DELETE FROM holdings WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete holdings: %w", err)
+	}
+
+	// Delete asset details if it's an asset account
+	_, err = tx.Exec("DELETE FROM assets WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete asset details: %w", err)
+	}
+
+	// Delete property details if it's a property account
+	_, err = tx.Exec("DELETE FROM properties WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete property details: %w", err)
+	}
+
+	// Delete the account itself
+	result, err := tx.Exec("DELETE FROM accounts WHERE id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found: %s", accountID)
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) SaveTransaction(id, accountID, posted string, amount int64, description string, pending bool) error {
+	// Use INSERT OR IGNORE to avoid duplicate transactions
+	// If the transaction already exists, we don't update it to preserve any manual categorization
+	_, err := db.conn.Exec(`
+		INSERT OR IGNORE INTO transactions (id, account_id, posted, amount, description, pending)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		id, accountID, posted, amount, description, pending)
+	if err != nil {
+		return fmt.Errorf("failed to save transaction: %w", err)
+	}
+	return nil
+}
+
+// TransactionSort is a column the paged transaction queries can order by
+type TransactionSort string
+
+const (
+	SortByDate     TransactionSort = "date"
+	SortByAmount   TransactionSort = "amount"
+	SortByAccount  TransactionSort = "account"
+	SortByCategory TransactionSort = "category"
+)
+
+// TransactionFilter narrows and orders the transactions returned by the
+// paged transaction queries. Zero values mean no restriction, newest first.
+type TransactionFilter struct {
+	UncategorizedOnly bool
+	AccountID         string
+	StartDate         string // YYYY-MM-DD in the configured time zone, inclusive
+	EndDate           string // YYYY-MM-DD in the configured time zone, inclusive
+	AmountSign        int    // -1 for expenses only, 1 for income only
+	PendingOnly       bool
+	PostedOnly        bool
+	CategoryID        int      // 0 for any category
+	PropertyID        string   // property account the transactions are tagged to
+	MinAmount         int64    // cents, compared to the amount's magnitude
+	MaxAmount         int64    // cents, compared to the amount's magnitude
+	Description       string   // case-insensitive substring
+	Owners            []string // accounts belonging to any of these owners
+
+	SortBy    TransactionSort // defaults to SortByDate
+	Ascending bool            // sort order, descending by default
+}
+
+// whereClause builds the SQL condition and arguments for the filter against
+// the transactions table aliased as t.
+func (f TransactionFilter) whereClause() (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if f.UncategorizedOnly {
+		conditions = append(conditions, "t.category_id IS NULL")
+	}
+	if f.AccountID != "" {
+		conditions = append(conditions, "t.account_id = ?")
+		args = append(args, f.AccountID)
+	}
+	if f.StartDate != "" {
+		conditions = append(conditions, "t.posted >= ?")
+		args = append(args, rangeStart(f.StartDate))
+	}
+	if f.EndDate != "" {
+		conditions = append(conditions, "t.posted < ?")
+		args = append(args, rangeEnd(f.EndDate))
+	}
+	if f.CategoryID != 0 {
+		conditions = append(conditions, "t.category_id = ?")
+		args = append(args, f.CategoryID)
+	}
+	if f.PropertyID != "" {
+		conditions = append(conditions, "t.id IN (SELECT transaction_id FROM property_transactions WHERE property_account_id = ?)")
+		args = append(args, f.PropertyID)
+	}
+	if f.MinAmount > 0 {
+		conditions = append(conditions, "abs(t.amount) >= ?")
+		args = append(args, f.MinAmount)
+	}
+	if f.MaxAmount > 0 {
+		conditions = append(conditions, "abs(t.amount) <= ?")
+		args = append(args, f.MaxAmount)
+	}
+	if f.Description != "" {
+		conditions = append(conditions, "(instr(lower(t.description), ?) > 0 OR instr(lower(COALESCE(t.display_description, '')), ?) > 0)")
+		args = append(args, strings.ToLower(f.Description), strings.ToLower(f.Description))
+	}
+	if len(f.Owners) > 0 {
+		condition, ownerArgs := ownerCondition("t.account_id", f.Owners)
+		conditions = append(conditions, condition)
+		args = append(args, ownerArgs...)
+	}
+	if f.PendingOnly {
+		conditions = append(conditions, "t.pending = 1")
+	}
+	if f.PostedOnly {
+		conditions = append(conditions, "t.pending = 0")
+	}
+	if f.AmountSign < 0 {
+		conditions = append(conditions, "t.amount < 0")
+	} else if f.AmountSign > 0 {
+		conditions = append(conditions, "t.amount > 0")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// orderClause builds the SQL ordering for the filter against the
+// transactions table aliased as t, accounts as a, and categories as c. Ties
+// are broken by date and ID so positions are stable.
+func (f TransactionFilter) orderClause() string {
+	direction := "DESC"
+	if f.Ascending {
+		direction = "ASC"
+	}
+
+	switch f.SortBy {
+	case SortByAmount:
+		return "t.amount " + direction + ", t.posted DESC, t.id"
+	case SortByAccount:
+		return "lower(COALESCE(NULLIF(a.nickname, ''), a.name, t.account_id)) " + direction + ", t.posted DESC, t.id"
+	case SortByCategory:
+		// Uncategorized transactions sort as an empty name
+		return "lower(COALESCE(c.name, '')) " + direction + ", t.posted DESC, t.id"
+	default:
+		return "t.posted " + direction + ", t.id"
+	}
+}
+
+// nextDay returns the YYYY-MM-DD date after date, or date unchanged if it
+// can't be parsed.
+func nextDay(date string) string {
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return parsed.AddDate(0, 0, 1).Format("2006-01-02")
+}
+
+// rangeStart returns the first posted time on or after a YYYY-MM-DD date:
+// the instant the day begins in the configured time zone, in UTC like the
+// stored posted times. Anything else, like an RFC3339 time, is used as is.
+func rangeStart(date string) string {
+	start, err := format.DayStart(date)
+	if err != nil {
+		return date
+	}
+	return start
+}
+
+// rangeEnd returns the posted time that ends a range through a YYYY-MM-DD
+// date, exclusive: the instant the next day begins in the configured time
+// zone. Anything else, like an RFC3339 time, is used as is.
+func rangeEnd(date string) string {
+	end, err := format.DayStart(nextDay(date))
+	if err != nil {
+		return date
+	}
+	return end
+}
+
+// CountTransactions returns the number of stored transactions matching filter
+func (db *DB) CountTransactions(filter TransactionFilter) (int, error) {
+	where, args := db.scope(filter).whereClause()
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM transactions t WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+	return count, nil
+}
+
+// scope limits filter to the handle's owners, unless it names owners itself
+func (db *DB) scope(filter TransactionFilter) TransactionFilter {
+	if len(filter.Owners) == 0 {
+		filter.Owners = db.owners
+	}
+	return filter
+}
+
+// selectQuery builds the query for the transactions matching the filter in
+// its sort order, with the columns scanTransaction expects
+func (f TransactionFilter) selectQuery() (string, []interface{}) {
+	where, args := f.whereClause()
+	return `
+		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.display_description, t.pending, t.category_id
+		FROM transactions t
+		LEFT JOIN accounts a ON t.account_id = a.id
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE ` + where + `
+		ORDER BY ` + f.orderClause(), args
+}
+
+// GetTransactions returns transactions matching filter in the filter's sort
+// order, skipping the first offset and returning at most limit of them. A
+// limit of 0 or less returns all remaining transactions. The ordering is
+// stable so positions can be used to page through large histories without
+// loading them all into memory.
+func (db *DB) GetTransactions(filter TransactionFilter, limit, offset int) ([]Transaction, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite treats a negative LIMIT as no limit
+	}
+
+	query, args := db.scope(filter).selectQuery()
+	args = append(args, limit, offset)
+	rows, err := db.conn.Query(query+`
+		LIMIT ? OFFSET ?`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// ForEachTransaction calls fn with each transaction matching filter, in the
+// filter's sort order, reading them from the database one at a time so any
+// number can be processed in constant memory. It stops at the first error
+// fn returns and returns that error as is.
+//
+// The query stays open while fn runs, so fn must not write to the database:
+// the write would wait on the read and fail as locked. Collect the changes
+// and make them after.
+func (db *DB) ForEachTransaction(filter TransactionFilter, fn func(Transaction) error) error {
+	query, args := db.scope(filter).selectQuery()
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transactions: %w", err)
+	}
+	return nil
+}
+
+// FindTransactionPositions returns the positions, in GetTransactions
+// order for the same filter, of transactions whose description, account
+// name, category name, or formatted amount contains term (case insensitive).
+func (db *DB) FindTransactionPositions(filter TransactionFilter, term string) ([]int, error) {
+	term = strings.ToLower(term)
+	filter = db.scope(filter)
+	where, args := filter.whereClause()
+	args = append(args, term, term, term, term)
+	rows, err := db.conn.Query(`
+		SELECT position FROM (
+			SELECT ROW_NUMBER() OVER (ORDER BY `+filter.orderClause()+`) - 1 AS position,
+			       lower(t.description || ' ' || COALESCE(t.display_description, '')) AS description,
+			       lower(COALESCE(NULLIF(a.nickname, ''), a.name, t.account_id)) AS account_name,
+			       lower(COALESCE(c.name, '')) AS category_name,
+			       printf('%.2f', t.amount / 100.0) AS amount
+			FROM transactions t
+			LEFT JOIN accounts a ON t.account_id = a.id
+			LEFT JOIN categories c ON t.category_id = c.id
+			WHERE `+where+`
+		)
+		WHERE instr(description, ?) > 0
+		   OR instr(account_name, ?) > 0
+		   OR instr(category_name, ?) > 0
+		   OR instr(amount, ?) > 0
+		ORDER BY position`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []int
+	for rows.Next() {
+		var position int
+		if err := rows.Scan(&position); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction position: %w", err)
+		}
+		positions = append(positions, position)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction positions: %w", err)
+	}
+
+	return positions, nil
+}
+
+// scanTransactions reads transaction rows selected as id, account_id,
+// posted, amount, description, display_description, pending, category_id
+func scanTransactions(rows *sql.Rows) ([]Transaction, error) {
+	var transactions []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// scanTransaction scans the current row, with the columns selectQuery selects
+func scanTransaction(rows *sql.Rows) (Transaction, error) {
+	var t Transaction
+	var categoryID sql.NullInt64
+	var displayDescription sql.NullString
+
+	err := rows.Scan(
+		&t.ID,
+		&t.AccountID,
+		&t.Posted,
+		&t.Amount,
+		&t.Description,
+		&displayDescription,
+		&t.Pending,
+		&categoryID,
+	)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to scan transaction: %w", err)
+	}
+
+	if displayDescription.Valid {
+		t.CleanDescription = &displayDescription.String
+	}
+
+	if categoryID.Valid {
+		catID := int(categoryID.Int64)
+		t.CategoryID = &catID
+	}
+
+	return t, nil
+}
+
+func (db *DB) GetUncategorizedTransactions() ([]Transaction, error) {
+	query := `
+		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
+		FROM transactions t
+		WHERE t.category_id IS NULL
+		ORDER BY t.posted DESC`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uncategorized transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		var categoryID sql.NullInt64
+
+		err := rows.Scan(
+			&t.ID,
+			&t.AccountID,
+			&t.Posted,
+			&t.Amount,
+			&t.Description,
+			&t.Pending,
+			&categoryID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan uncategorized transaction: %w", err)
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating uncategorized transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+func (db *DB) UpdateTransactionCategory(transactionID string, categoryID int) error {
+	if err := db.checkMonthsOpen(db.conn, []string{transactionID}); err != nil {
+		return err
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE transactions
+		SET category_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		categoryID, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction category: %w", err)
+	}
+	return nil
+}
+
+// UpdateTransactionCategories sets the category of every transaction in
+// transactionIDs with a single UPDATE, and returns how many were updated.
+// Unlike SetTransactionCategories the change isn't logged for undo.
+func (db *DB) UpdateTransactionCategories(transactionIDs []string, categoryID int) (int, error) {
+	if len(transactionIDs) == 0 {
+		return 0, nil
+	}
+	if err := db.checkMonthsOpen(db.conn, transactionIDs); err != nil {
+		return 0, err
+	}
+
+	result, err := db.conn.Exec(`
+		UPDATE transactions
+		SET category_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT value FROM json_each(?))`,
+		categoryID, idList(transactionIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update transaction categories: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(updated), nil
+}
+
+// idList encodes ids as a JSON array, so any number of them can be matched
+// with one parameter: id IN (SELECT value FROM json_each(?)). Bound
+// variables are limited, and a statement per ID is slow.
+func idList(ids []string) string {
+	encoded, _ := json.Marshal(ids) // a []string always encodes
+	return string(encoded)
+}
+
+// ErrMonthClosed is returned when a category change would touch a
+// transaction posted in a closed month
+var ErrMonthClosed = errors.New("month is closed")
+
+// ErrCategoryDeleted is returned when undoing a batch of category changes
+// would restore a category that has since been deleted
+var ErrCategoryDeleted = errors.New("a category the changes replaced has been deleted")
+
+// AllowClosedMonthEdits lets category changes through to transactions in
+// closed months, for commands run with --force
+func (db *DB) AllowClosedMonthEdits(allow bool) {
+	db.allowClosedEdits = allow
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// checkMonthsOpen returns an ErrMonthClosed error if any of transactionIDs
+// was posted in a closed month, unless closed month edits are allowed.
+// Months are matched in the configured time zone, like money close.
+func (db *DB) checkMonthsOpen(q queryer, transactionIDs []string) error {
+	if db.allowClosedEdits || len(transactionIDs) == 0 {
+		return nil
+	}
+
+	closed, err := closedMonthSet(q)
+	if err != nil {
+		return err
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+
+	rows, err := q.Query("SELECT posted FROM transactions WHERE id IN (SELECT value FROM json_each(?))", idList(transactionIDs))
+	if err != nil {
+		return fmt.Errorf("failed to query transaction dates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var posted string
+		if err := rows.Scan(&posted); err != nil {
+			return fmt.Errorf("failed to scan transaction date: %w", err)
+		}
+		if month := format.PostedMonth(posted); closed[month] {
+			return fmt.Errorf("%w: %s", ErrMonthClosed, month)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transaction dates: %w", err)
+	}
+	return nil
+}
+
+// closedMonthSet returns the closed months, YYYY-MM
+func closedMonthSet(q queryer) (map[string]bool, error) {
+	rows, err := q.Query("SELECT month FROM closed_months")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed months: %w", err)
+	}
+	defer rows.Close()
+
+	closed := make(map[string]bool)
+	for rows.Next() {
+		var month string
+		if err := rows.Scan(&month); err != nil {
+			return nil, fmt.Errorf("failed to scan closed month: %w", err)
+		}
+		closed[month] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating closed months: %w", err)
+	}
+	return closed, nil
+}
+
+func (db *DB) ClearTransactionCategory(transactionID string) error {
+	if err := db.checkMonthsOpen(db.conn, []string{transactionID}); err != nil {
+		return err
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE transactions
+		SET category_id = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to clear transaction category: %w", err)
+	}
+	return nil
+}
+
+// GetTransactionByID returns a single transaction
+func (db *DB) GetTransactionByID(transactionID string) (*Transaction, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.display_description, t.pending, t.category_id
+		FROM transactions t
+		WHERE t.id = ?`,
+		transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("transaction not found: %s", transactionID)
+	}
+	return &transactions[0], nil
+}
+
+// GetTransactionTimestamps returns when a transaction was first stored and
+// when it was last changed
+func (db *DB) GetTransactionTimestamps(transactionID string) (createdAt, updatedAt string, err error) {
+	err = db.conn.QueryRow(`
+		SELECT created_at, updated_at
+		FROM transactions
+		WHERE id = ?`,
+		transactionID).Scan(&createdAt, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", fmt.Errorf("transaction not found: %s", transactionID)
+		}
+		return "", "", fmt.Errorf("failed to get transaction timestamps: %w", err)
+	}
+	return createdAt, updatedAt, nil
+}
+
+// TransactionChanges holds new values for EditTransaction; nil fields are
+// left unchanged
+type TransactionChanges struct {
+	Description *string
+	Posted      *string // RFC3339
+	Amount      *int64  // cents
+}
+
+// EditTransaction applies changes to a transaction and records each changed
+// field in the transaction's audit trail. It returns the number of fields
+// that actually changed.
+func (db *DB) EditTransaction(transactionID string, changes TransactionChanges) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var description, posted string
+	var amount int64
+	err = tx.QueryRow("SELECT description, posted, amount FROM transactions WHERE id = ?", transactionID).
+		Scan(&description, &posted, &amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("transaction not found: %s", transactionID)
+		}
+		return 0, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	type fieldChange struct {
+		column   string
+		oldValue string
+		newValue string
+		value    interface{}
+	}
+	var fieldChanges []fieldChange
+	if changes.Description != nil && *changes.Description != description {
+		fieldChanges = append(fieldChanges, fieldChange{"description", description, *changes.Description, *changes.Description})
+	}
+	if changes.Posted != nil && *changes.Posted != posted {
+		fieldChanges = append(fieldChanges, fieldChange{"posted", posted, *changes.Posted, *changes.Posted})
+	}
+	if changes.Amount != nil && *changes.Amount != amount {
+		fieldChanges = append(fieldChanges, fieldChange{"amount", strconv.FormatInt(amount, 10), strconv.FormatInt(*changes.Amount, 10), *changes.Amount})
+	}
+
+	for _, change := range fieldChanges {
+		// column comes from the fixed set above, never from user input
+		_, err := tx.Exec(`UPDATE transactions SET `+change.column+` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			change.value, transactionID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update transaction %s: %w", change.column, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO transaction_edits (transaction_id, field, old_value, new_value)
+			VALUES (?, ?, ?, ?)`,
+			transactionID, change.column, change.oldValue, change.newValue)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record transaction edit: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction edit: %w", err)
+	}
+
+	return len(fieldChanges), nil
+}
+
+// GetTransactionEdits returns the audit trail of a transaction, oldest first
+func (db *DB) GetTransactionEdits(transactionID string) ([]TransactionEdit, error) {
+	rows, err := db.conn.Query(`
+		SELECT field, old_value, new_value, edited_at
+		FROM transaction_edits
+		WHERE transaction_id = ?
+		ORDER BY id`,
+		transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction edits: %w", err)
+	}
+	defer rows.Close()
+
+	var edits []TransactionEdit
+	for rows.Next() {
+		var edit TransactionEdit
+		if err := rows.Scan(&edit.Field, &edit.OldValue, &edit.NewValue, &edit.EditedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction edit: %w", err)
+		}
+		edits = append(edits, edit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction edits: %w", err)
+	}
+
+	return edits, nil
+}
+
+// AddRenameRule stores a rule that rewrites matching descriptions
+func (db *DB) AddRenameRule(pattern, replacement string) (int, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO rename_rules (pattern, replacement)
+		VALUES (?, ?)
+		ON CONFLICT(pattern) DO UPDATE SET replacement = excluded.replacement`,
+		pattern, replacement)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save rename rule: %w", err)
+	}
+
+	var id int
+	err = db.conn.QueryRow("SELECT id FROM rename_rules WHERE pattern = ?", pattern).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rename rule ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetRenameRules returns rename rules in the order they are applied
+func (db *DB) GetRenameRules() ([]RenameRule, error) {
+	rows, err := db.conn.Query("SELECT id, pattern, replacement FROM rename_rules ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rename rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []RenameRule
+	for rows.Next() {
+		var rule RenameRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.Replacement); err != nil {
+			return nil, fmt.Errorf("failed to scan rename rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rename rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// DeleteRenameRule removes a rename rule. Descriptions it already rewrote
+// are kept until rules are applied again.
+func (db *DB) DeleteRenameRule(id int) error {
+	result, err := db.conn.Exec("DELETE FROM rename_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rename rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("rename rule not found: %d", id)
+	}
+	return nil
+}
+
+// ApplyRenameRules recomputes the display description of the given
+// transactions, or of every transaction when transactionIDs is nil. The first
+// matching rule wins; transactions no rule matches get their raw description
+// back. It returns the number of transactions whose display description
+// changed.
+func (db *DB) ApplyRenameRules(transactionIDs []string) (int, error) {
+	rules, err := db.GetRenameRules()
+	if err != nil {
+		return 0, err
+	}
+
+	compiled := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		compiled[i], err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pattern in rename rule %d: %w", rule.ID, err)
+		}
+	}
+
+	query := "SELECT id, description, display_description FROM transactions"
+	var args []interface{}
+	if transactionIDs != nil {
+		if len(transactionIDs) == 0 {
+			return 0, nil
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(transactionIDs)), ",")
+		query += " WHERE id IN (" + placeholders + ")"
+		for _, id := range transactionIDs {
+			args = append(args, id)
+		}
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query transactions: %w", err)
+	}
+
+	type rename struct {
+		id          string
+		description sql.NullString
+	}
+	var renames []rename
+	for rows.Next() {
+		var id, description string
+		var current sql.NullString
+		if err := rows.Scan(&id, &description, &current); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+
+		var next sql.NullString
+		for i, re := range compiled {
+			match := re.FindStringSubmatchIndex(description)
+			if match == nil {
+				continue
+			}
+			expanded := string(re.ExpandString(nil, rules[i].Replacement, description, match))
+			next = sql.NullString{String: expanded, Valid: true}
+			break
+		}
+
+		if next != current {
+			renames = append(renames, rename{id, next})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("error iterating transactions: %w", err)
+	}
+	rows.Close()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range renames {
+		_, err := tx.Exec("UPDATE transactions SET display_description = ? WHERE id = ?", r.description, r.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update display description: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit display descriptions: %w", err)
+	}
+
+	return len(renames), nil
+}
+
+// SetTransactionCategories sets the category of each transaction, or clears
+// it when categoryID is nil, and records the changes in the operations log
+// as a single batch. It returns the batch ID to pass to UndoCategoryBatch.
+// Each step is a single statement, however many transactions there are.
+func (db *DB) SetTransactionCategories(transactionIDs []string, categoryID *int) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.checkMonthsOpen(tx, transactionIDs); err != nil {
+		return 0, err
+	}
+
+	var batchID int64
+	err = tx.QueryRow("SELECT COALESCE(MAX(batch_id), 0) + 1 FROM category_operations").Scan(&batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate operation batch: %w", err)
+	}
+
+	ids := idList(transactionIDs)
+	rows, err := tx.Query("SELECT id FROM transactions WHERE id IN (SELECT value FROM json_each(?))", ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	found := make(map[string]bool, len(transactionIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan transaction ID: %w", err)
+		}
+		found[id] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating transactions: %w", err)
+	}
+	for _, transactionID := range transactionIDs {
+		if !found[transactionID] {
+			return 0, fmt.Errorf("transaction not found: %s", transactionID)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO category_operations (batch_id, transaction_id, old_category_id, new_category_id)
+		SELECT ?, id, category_id, ?
+		FROM transactions
+		WHERE id IN (SELECT value FROM json_each(?))`,
+		batchID, categoryID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to log category operations: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET category_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT value FROM json_each(?))`,
+		categoryID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update transaction categories: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit category changes: %w", err)
+	}
+
+	return batchID, nil
+}
+
+// UndoCategoryBatch restores the categories a batch of changes replaced and
+// removes the batch from the operations log. It returns the number of
+// transactions restored. A batch that replaced a category deleted since
+// can't be undone, and fails with ErrCategoryDeleted without restoring any.
+func (db *DB) UndoCategoryBatch(batchID int64) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Undo in reverse order in case a transaction appears more than once
+	rows, err := tx.Query(`
+		SELECT transaction_id, old_category_id, old_category_deleted
+		FROM category_operations
+		WHERE batch_id = ?
+		ORDER BY id DESC`,
+		batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query category operations: %w", err)
+	}
+
+	type operation struct {
+		transactionID string
+		oldCategoryID sql.NullInt64
+		deleted       bool
+	}
+	var operations []operation
+	for rows.Next() {
+		var op operation
+		if err := rows.Scan(&op.transactionID, &op.oldCategoryID, &op.deleted); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan category operation: %w", err)
+		}
+		operations = append(operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("error iterating category operations: %w", err)
+	}
+	rows.Close()
+
+	if len(operations) == 0 {
+		return 0, fmt.Errorf("category operation batch not found: %d", batchID)
+	}
+
+	var deleted int
+	for _, op := range operations {
+		if op.deleted {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		return 0, fmt.Errorf("%w: %d of the %d transactions were in it", ErrCategoryDeleted, deleted, len(operations))
+	}
+
+	transactionIDs := make([]string, len(operations))
+	for i, op := range operations {
+		transactionIDs[i] = op.transactionID
+	}
+	if err := db.checkMonthsOpen(tx, transactionIDs); err != nil {
+		return 0, err
+	}
+
+	for _, op := range operations {
+		_, err := tx.Exec(`
+			UPDATE transactions
+			SET category_id = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`,
+			op.oldCategoryID, op.transactionID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore transaction category: %w", err)
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM category_operations WHERE batch_id = ?", batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove category operations: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit undo: %w", err)
+	}
+
+	return len(operations), nil
+}
+
+func (db *DB) TransactionExists(id string) (bool, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM transactions WHERE id = ?", id).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check transaction existence: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (db *DB) SaveCategory(name string) (int, error) {
+	return db.SaveCategoryWithInternal(name, false)
+}
+
+func (db *DB) SaveCategoryWithInternal(name string, isInternal bool) (int, error) {
+	// Use INSERT OR IGNORE to avoid duplicate categories, then get the ID
+	_, err := db.conn.Exec(`
+		INSERT OR IGNORE INTO categories (name, is_internal)
+		VALUES (?, ?)`,
+		name, isInternal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert category: %w", err)
+	}
+
+	// Get the category ID
+	var id int
+	err = db.conn.QueryRow(`
+		SELECT id FROM categories
+		WHERE name = ?`,
+		name).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get category ID: %w", err)
+	}
+
+	return id, nil
+}
+
+func (db *DB) GetCategories() ([]Category, error) {
+	query := `
+		SELECT id, name, COALESCE(is_internal, FALSE), COALESCE(color, ''), COALESCE(emoji, '')
+		FROM categories
+		ORDER BY name`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var c Category
+		err := rows.Scan(&c.ID, &c.Name, &c.IsInternal, &c.Color, &c.Emoji)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (db *DB) GetCategoryByID(categoryID int) (*Category, error) {
+	var c Category
+	err := db.conn.QueryRow(`
+		SELECT id, name, COALESCE(is_internal, FALSE), COALESCE(color, ''), COALESCE(emoji, '')
+		FROM categories
+		WHERE id = ?`,
+		categoryID).Scan(&c.ID, &c.Name, &c.IsInternal, &c.Color, &c.Emoji)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category not found: %d", categoryID)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &c, nil
+}
+
+func (db *DB) GetCategoryByName(name string) (*Category, error) {
+	var c Category
+	err := db.conn.QueryRow(`
+		SELECT id, name, COALESCE(is_internal, FALSE), COALESCE(color, ''), COALESCE(emoji, '')
+		FROM categories
+		WHERE name = ?`,
+		name).Scan(&c.ID, &c.Name, &c.IsInternal, &c.Color, &c.Emoji)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("category not found: %s", name)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &c, nil
+}
+
+func (db *DB) DeleteCategory(name string) error {
+	// Check if category is used by any transactions
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) FROM transactions
+		WHERE category_id = (SELECT id FROM categories WHERE name = ?)`,
+		name).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check category usage: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("cannot delete category '%s': it is used by %d transactions", name, count)
+	}
+
+	// Mark logged changes away from the category, so undoing them reports
+	// that it's gone; deleting it then clears it from the log
+	_, err = db.conn.Exec(`
+		UPDATE category_operations
+		SET old_category_deleted = 1
+		WHERE old_category_id = (SELECT id FROM categories WHERE name = ?)`,
+		name)
+	if err != nil {
+		return fmt.Errorf("failed to mark category operations: %w", err)
+	}
+
+	// Remove any budget target for the category
+	_, err = db.conn.Exec(`DELETE FROM budgets WHERE category_id = (SELECT id FROM categories WHERE name = ?)`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete category budget: %w", err)
+	}
+
+	// Remove any tax line mapping for the category
+	_, err = db.conn.Exec(`DELETE FROM tax_mappings WHERE category_id = (SELECT id FROM categories WHERE name = ?)`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete category tax mapping: %w", err)
+	}
+
+	// Stop filing accounts' new transactions under the category
+	_, err = db.conn.Exec(`DELETE FROM account_default_categories WHERE category_id = (SELECT id FROM categories WHERE name = ?)`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete account default categories: %w", err)
+	}
+
+	// Stop syncing the category with YNAB
+	_, err = db.conn.Exec(`DELETE FROM ynab_categories WHERE category_id = (SELECT id FROM categories WHERE name = ?)`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete YNAB category mapping: %w", err)
+	}
+
+	// Delete the category
+	result, err := db.conn.Exec(`DELETE FROM categories WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %s", name)
+	}
+
+	return nil
+}
+
+func (db *DB) SeedDefaultCategories() error {
+	// Regular categories
+	defaultCategories := []string{
+		"Housing",
+		"Transportation",
+		"Groceries",
+		"Dining Out",
+		"Healthcare",
+		"Shopping",
+		"Entertainment",
+		"Bills & Services",
+		"Personal Care",
+		"Travel",
+		"Fees",
+		"Interest",
+		"Projects",
+		"Subscriptions",
+		"Income",
+		"Other",
+	}
+
+	// Internal categories (excluded from budget calculations)
+	internalCategories := []string{
+		"Transfers",
+	}
+
+	// Seed regular categories
+	for _, categoryName := range defaultCategories {
+		_, err := db.SaveCategory(categoryName)
+		if err != nil {
+			return fmt.Errorf("failed to seed category '%s': %w", categoryName, err)
+		}
+	}
+
+	// Seed internal categories
+	for _, categoryName := range internalCategories {
+		_, err := db.SaveCategoryWithInternal(categoryName, true)
+		if err != nil {
+			return fmt.Errorf("failed to seed internal category '%s': %w", categoryName, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) SetCategoryInternal(categoryID int, isInternal bool) error {
+	result, err := db.conn.Exec(`
+		UPDATE categories
+		SET is_internal = ?
+		WHERE id = ?`,
+		isInternal, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to set category internal flag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %d", categoryID)
+	}
+
+	return nil
+}
+
+func (db *DB) SetCategoryInternalByName(categoryName string, isInternal bool) error {
+	result, err := db.conn.Exec(`
+		UPDATE categories
+		SET is_internal = ?
+		WHERE name = ?`,
+		isInternal, categoryName)
+	if err != nil {
+		return fmt.Errorf("failed to set category internal flag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %s", categoryName)
+	}
+
+	return nil
+}
+
+// SetCategoryStyle sets the color and emoji a category is drawn with; empty
+// strings clear them
+func (db *DB) SetCategoryStyle(categoryID int, color, emoji string) error {
+	result, err := db.conn.Exec(`
+		UPDATE categories
+		SET color = NULLIF(?, ''), emoji = NULLIF(?, '')
+		WHERE id = ?`,
+		color, emoji, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to set category style: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category not found: %d", categoryID)
+	}
+
+	return nil
+}
+
+// SetLowBalanceThreshold sets the balance, in cents, below which an account
+// raises a low balance alert
+func (db *DB) SetLowBalanceThreshold(accountID string, threshold int64) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO low_balance_alerts (account_id, threshold)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			threshold = excluded.threshold,
+			updated_at = CURRENT_TIMESTAMP`,
+		accountID, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to set low balance threshold: %w", err)
+	}
+	return nil
+}
+
+// ClearLowBalanceThreshold removes an account's low balance alert
+func (db *DB) ClearLowBalanceThreshold(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM low_balance_alerts WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear low balance threshold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no low balance threshold set for account: %s", accountID)
+	}
+
+	return nil
+}
+
+// GetLowBalanceThresholds returns low balance thresholds in cents by account ID
+func (db *DB) GetLowBalanceThresholds() (map[string]int64, error) {
+	rows, err := db.conn.Query(`SELECT account_id, threshold FROM low_balance_alerts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query low balance thresholds: %w", err)
+	}
+	defer rows.Close()
+
+	thresholds := make(map[string]int64)
+	for rows.Next() {
+		var accountID string
+		var threshold int64
+		if err := rows.Scan(&accountID, &threshold); err != nil {
+			return nil, fmt.Errorf("failed to scan low balance threshold: %w", err)
+		}
+		thresholds[accountID] = threshold
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating low balance thresholds: %w", err)
+	}
+
+	return thresholds, nil
+}
+
+// SetCreditLimit sets an account's credit limit in cents
+func (db *DB) SetCreditLimit(accountID string, limit int64) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO credit_limits (account_id, credit_limit)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			credit_limit = excluded.credit_limit,
+			updated_at = CURRENT_TIMESTAMP`,
+		accountID, limit)
+	if err != nil {
+		return fmt.Errorf("failed to set credit limit: %w", err)
+	}
+	return nil
+}
+
+// ClearCreditLimit removes an account's credit limit
+func (db *DB) ClearCreditLimit(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM credit_limits WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear credit limit: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no credit limit set for account: %s", accountID)
+	}
+
+	return nil
+}
+
+// GetCreditLimits returns credit limits in cents by account ID
+func (db *DB) GetCreditLimits() (map[string]int64, error) {
+	rows, err := db.conn.Query(`SELECT account_id, credit_limit FROM credit_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credit limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int64)
+	for rows.Next() {
+		var accountID string
+		var limit int64
+		if err := rows.Scan(&accountID, &limit); err != nil {
+			return nil, fmt.Errorf("failed to scan credit limit: %w", err)
+		}
+		limits[accountID] = limit
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating credit limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// YNABLinks are the YNAB IDs money's accounts, categories and transactions
+// are synced with
+type YNABLinks struct {
+	Accounts     map[string]string // YNAB account ID by account ID
+	Categories   map[int]string    // YNAB category ID by category ID
+	Transactions map[string]string // YNAB transaction ID by transaction ID
+}
+
+// SetYNABAccount maps an account to the YNAB account its transactions are
+// synced with. Each YNAB account can be mapped to one account.
+func (db *DB) SetYNABAccount(accountID, ynabAccountID string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ynab_accounts (account_id, ynab_account_id)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			ynab_account_id = excluded.ynab_account_id`,
+		accountID, ynabAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to map YNAB account: %w", err)
+	}
+	return nil
+}
+
+// ClearYNABAccount stops syncing an account with YNAB
+func (db *DB) ClearYNABAccount(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM ynab_accounts WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to unmap YNAB account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account is not mapped to YNAB: %s", accountID)
+	}
+
+	return nil
+}
+
+// SetYNABCategory maps a category to the YNAB category it's synced with.
+// Each YNAB category can be mapped to one category.
+func (db *DB) SetYNABCategory(categoryID int, ynabCategoryID string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ynab_categories (category_id, ynab_category_id)
+		VALUES (?, ?)
+		ON CONFLICT(category_id) DO UPDATE SET
+			ynab_category_id = excluded.ynab_category_id`,
+		categoryID, ynabCategoryID)
+	if err != nil {
+		return fmt.Errorf("failed to map YNAB category: %w", err)
+	}
+	return nil
+}
+
+// ClearYNABCategory removes a category's YNAB mapping
+func (db *DB) ClearYNABCategory(categoryID int) error {
+	result, err := db.conn.Exec(`DELETE FROM ynab_categories WHERE category_id = ?`, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to unmap YNAB category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category is not mapped to YNAB")
+	}
+
+	return nil
+}
+
+// LinkYNABTransaction records that a transaction and a YNAB transaction are
+// the same one, so later syncs neither copy nor match it again
+func (db *DB) LinkYNABTransaction(transactionID, ynabTransactionID string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ynab_transactions (transaction_id, ynab_transaction_id)
+		VALUES (?, ?)
+		ON CONFLICT(transaction_id) DO UPDATE SET
+			ynab_transaction_id = excluded.ynab_transaction_id,
+			synced_at = CURRENT_TIMESTAMP`,
+		transactionID, ynabTransactionID)
+	if err != nil {
+		return fmt.Errorf("failed to link YNAB transaction: %w", err)
+	}
+	return nil
+}
+
+// GetYNABLinks returns every account, category and transaction mapped to
+// YNAB
+func (db *DB) GetYNABLinks() (*YNABLinks, error) {
+	links := &YNABLinks{
+		Accounts:     make(map[string]string),
+		Categories:   make(map[int]string),
+		Transactions: make(map[string]string),
+	}
+
+	rows, err := db.conn.Query(`SELECT account_id, ynab_account_id FROM ynab_accounts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query YNAB accounts: %w", err)
+	}
+	for rows.Next() {
+		var accountID, ynabID string
+		if err := rows.Scan(&accountID, &ynabID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan YNAB account: %w", err)
+		}
+		links.Accounts[accountID] = ynabID
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating YNAB accounts: %w", err)
+	}
+
+	rows, err = db.conn.Query(`SELECT category_id, ynab_category_id FROM ynab_categories`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query YNAB categories: %w", err)
+	}
+	for rows.Next() {
+		var categoryID int
+		var ynabID string
+		if err := rows.Scan(&categoryID, &ynabID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan YNAB category: %w", err)
+		}
+		links.Categories[categoryID] = ynabID
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating YNAB categories: %w", err)
+	}
+
+	rows, err = db.conn.Query(`SELECT transaction_id, ynab_transaction_id FROM ynab_transactions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query YNAB transactions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var transactionID, ynabID string
+		if err := rows.Scan(&transactionID, &ynabID); err != nil {
+			return nil, fmt.Errorf("failed to scan YNAB transaction: %w", err)
+		}
+		links.Transactions[transactionID] = ynabID
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating YNAB transactions: %w", err)
+	}
+
+	return links, nil
+}
+
+// SetActualAccount maps an account to the Actual Budget account it's pushed
+// to. Each Actual account can be mapped to one account.
+func (db *DB) SetActualAccount(accountID, actualAccountID string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO actual_accounts (account_id, actual_account_id)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			actual_account_id = excluded.actual_account_id`,
+		accountID, actualAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to map Actual account: %w", err)
+	}
+	return nil
+}
+
+// ClearActualAccount stops pushing an account to Actual Budget
+func (db *DB) ClearActualAccount(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM actual_accounts WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to unmap Actual account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account is not mapped to Actual: %s", accountID)
+	}
+
+	return nil
+}
+
+// GetActualAccounts returns the Actual Budget account ID each mapped account
+// is pushed to, by account ID
+func (db *DB) GetActualAccounts() (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT account_id, actual_account_id FROM actual_accounts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Actual accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]string)
+	for rows.Next() {
+		var accountID, actualID string
+		if err := rows.Scan(&accountID, &actualID); err != nil {
+			return nil, fmt.Errorf("failed to scan Actual account: %w", err)
+		}
+		accounts[accountID] = actualID
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating Actual accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// AccountDefaultCategory is the category new transactions in an account are
+// filed under when they're fetched
+type AccountDefaultCategory struct {
+	AccountID    string
+	CategoryID   int
+	CategoryName string
+}
+
+// SetAccountDefaultCategory sets the category uncategorized transactions in
+// an account get during fetch
+func (db *DB) SetAccountDefaultCategory(accountID string, categoryID int) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO account_default_categories (account_id, category_id)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			category_id = excluded.category_id,
+			updated_at = CURRENT_TIMESTAMP`,
+		accountID, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to set default category: %w", err)
+	}
+	return nil
+}
+
+// ClearAccountDefaultCategory removes an account's default category
+func (db *DB) ClearAccountDefaultCategory(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM account_default_categories WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear default category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no default category set for account: %s", accountID)
+	}
+
+	return nil
+}
+
+// GetAccountDefaultCategories returns every account's default category,
+// ordered by account ID
+func (db *DB) GetAccountDefaultCategories() ([]AccountDefaultCategory, error) {
+	rows, err := db.conn.Query(`
+		SELECT d.account_id, d.category_id, c.name
+		FROM account_default_categories d
+		JOIN categories c ON d.category_id = c.id
+		ORDER BY d.account_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query default categories: %w", err)
+	}
+	defer rows.Close()
+
+	var defaults []AccountDefaultCategory
+	for rows.Next() {
+		var d AccountDefaultCategory
+		if err := rows.Scan(&d.AccountID, &d.CategoryID, &d.CategoryName); err != nil {
+			return nil, fmt.Errorf("failed to scan default category: %w", err)
+		}
+		defaults = append(defaults, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating default categories: %w", err)
+	}
+
+	return defaults, nil
+}
+
+// ApplyAccountDefaultCategories files the uncategorized transactions among
+// transactionIDs, or all uncategorized transactions when transactionIDs is
+// nil, under their account's default category. Transactions in closed months
+// are left alone unless closed month edits are allowed. It returns the
+// number of transactions categorized.
+func (db *DB) ApplyAccountDefaultCategories(transactionIDs []string) (int, error) {
+	if transactionIDs != nil && len(transactionIDs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	closed := map[string]bool{}
+	if !db.allowClosedEdits {
+		if closed, err = closedMonthSet(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	query := `
+		SELECT t.id, t.posted, d.category_id
+		FROM transactions t
+		JOIN account_default_categories d ON t.account_id = d.account_id
+		WHERE t.category_id IS NULL`
+	var args []interface{}
+	if transactionIDs != nil {
+		query += " AND t.id IN (SELECT value FROM json_each(?))"
+		args = append(args, idList(transactionIDs))
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query uncategorized transactions: %w", err)
+	}
+	byCategory := make(map[int][]string)
+	for rows.Next() {
+		var id, posted string
+		var categoryID int
+		if err := rows.Scan(&id, &posted, &categoryID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if closed[format.PostedMonth(posted)] {
+			continue
+		}
+		byCategory[categoryID] = append(byCategory[categoryID], id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	categorized := 0
+	for categoryID, ids := range byCategory {
+		result, err := tx.Exec(`
+			UPDATE transactions
+			SET category_id = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id IN (SELECT value FROM json_each(?))`,
+			categoryID, idList(ids))
+		if err != nil {
+			return 0, fmt.Errorf("failed to update transaction categories: %w", err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		categorized += int(updated)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit default categories: %w", err)
+	}
+
+	return categorized, nil
+}
+
+// SetStatementDueDay sets the day of the month an account's statement
+// payment is due
+func (db *DB) SetStatementDueDay(accountID string, dueDay int) error {
+	if dueDay < 1 || dueDay > 31 {
+		return fmt.Errorf("due day must be between 1 and 31, got %d", dueDay)
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO statement_due_days (account_id, due_day)
+		VALUES (?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			due_day = excluded.due_day,
+			updated_at = CURRENT_TIMESTAMP`,
+		accountID, dueDay)
+	if err != nil {
+		return fmt.Errorf("failed to set statement due day: %w", err)
+	}
+	return nil
+}
+
+// ClearStatementDueDay removes an account's statement due day
+func (db *DB) ClearStatementDueDay(accountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM statement_due_days WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to clear statement due day: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no statement due day set for account: %s", accountID)
+	}
+
+	return nil
+}
+
+// GetStatementDueDays returns statement due days by account ID
+func (db *DB) GetStatementDueDays() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT account_id, due_day FROM statement_due_days`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statement due days: %w", err)
+	}
+	defer rows.Close()
+
+	dueDays := make(map[string]int)
+	for rows.Next() {
+		var accountID string
+		var dueDay int
+		if err := rows.Scan(&accountID, &dueDay); err != nil {
+			return nil, fmt.Errorf("failed to scan statement due day: %w", err)
+		}
+		dueDays[accountID] = dueDay
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating statement due days: %w", err)
+	}
+
+	return dueDays, nil
+}
+
+// SaveBill stores a recurring monthly bill. accountID is the account it's
+// paid from, or empty if unknown.
+func (db *DB) SaveBill(name string, amount int64, dueDay int, accountID string) (int, error) {
+	if dueDay < 1 || dueDay > 31 {
+		return 0, fmt.Errorf("due day must be between 1 and 31, got %d", dueDay)
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO bills (name, amount, due_day, account_id)
+		VALUES (?, ?, ?, ?)`,
+		name, amount, dueDay, sql.NullString{String: accountID, Valid: accountID != ""})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save bill: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get bill ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// GetBills returns all recurring bills ordered by due day
+func (db *DB) GetBills() ([]Bill, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, amount, due_day, account_id
+		FROM bills
+		ORDER BY due_day, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bills: %w", err)
+	}
+	defer rows.Close()
+
+	var bills []Bill
+	for rows.Next() {
+		var b Bill
+		var accountID sql.NullString
+		if err := rows.Scan(&b.ID, &b.Name, &b.Amount, &b.DueDay, &accountID); err != nil {
+			return nil, fmt.Errorf("failed to scan bill: %w", err)
+		}
+		b.AccountID = accountID.String
+		bills = append(bills, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bills: %w", err)
+	}
+
+	return bills, nil
+}
+
+// DeleteBill removes a recurring bill
+func (db *DB) DeleteBill(id int) error {
+	result, err := db.conn.Exec(`DELETE FROM bills WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete bill: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bill not found: %d", id)
+	}
+
+	return nil
+}
+
+// SaveLLMCall records a prompt sent to the LLM and its raw response
+func (db *DB) SaveLLMCall(call LLMCall) (int, error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO llm_calls (purpose, command, prompt, response, error, latency_ms, prompt_tokens, response_tokens)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		call.Purpose, call.Command, call.Prompt, call.Response, call.Error,
+		call.LatencyMs, call.PromptTokens, call.ResponseTokens)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save LLM call: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get LLM call ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// GetLLMCalls returns the most recent LLM calls first, optionally only the
+// ones that failed
+func (db *DB) GetLLMCalls(limit int, failedOnly bool) ([]LLMCall, error) {
+	query := `
+		SELECT id, purpose, command, prompt, response, error, latency_ms, prompt_tokens, response_tokens, created_at
+		FROM llm_calls`
+	if failedOnly {
+		query += ` WHERE error != ''`
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query LLM calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []LLMCall
+	for rows.Next() {
+		var call LLMCall
+		if err := rows.Scan(&call.ID, &call.Purpose, &call.Command, &call.Prompt, &call.Response, &call.Error,
+			&call.LatencyMs, &call.PromptTokens, &call.ResponseTokens, &call.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan LLM call: %w", err)
+		}
+		calls = append(calls, call)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating LLM calls: %w", err)
+	}
+
+	return calls, nil
+}
+
+// GetLLMCall returns a single logged LLM call
+func (db *DB) GetLLMCall(id int) (*LLMCall, error) {
+	var call LLMCall
+	err := db.conn.QueryRow(`
+		SELECT id, purpose, command, prompt, response, error, latency_ms, prompt_tokens, response_tokens, created_at
+		FROM llm_calls
+		WHERE id = ?`, id).Scan(&call.ID, &call.Purpose, &call.Command, &call.Prompt, &call.Response, &call.Error,
+		&call.LatencyMs, &call.PromptTokens, &call.ResponseTokens, &call.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("LLM call not found: %d", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM call: %w", err)
+	}
+	return &call, nil
+}
+
+// ClearLLMCalls deletes the LLM call log, returning how many calls were removed
+func (db *DB) ClearLLMCalls() (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM llm_calls`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear LLM calls: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// secretTables hold credentials and are never exposed to read-only queries
+var secretTables = []string{"credentials", "rentcast_credentials", "gocardless_credentials", "teller_enrollments", "crypto_wallets"}
+
+// ReadOnlySchema returns the CREATE statements of the tables that
+// QueryReadOnly may read, for describing the database to the LLM
+func (db *DB) ReadOnlySchema() (string, error) {
+	rows, err := db.conn.Query(`
+		SELECT name, sql FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND sql IS NOT NULL
+		ORDER BY name`)
+	if err != nil {
+		return "", fmt.Errorf("failed to query schema: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var name, statement string
+		if err := rows.Scan(&name, &statement); err != nil {
+			return "", fmt.Errorf("failed to scan schema: %w", err)
+		}
+		if isSecretTable(name) {
+			continue
+		}
+		statements = append(statements, statement+";")
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating schema: %w", err)
+	}
+
+	return strings.Join(statements, "\n\n"), nil
+}
+
+// QueryReadOnly runs a single SELECT statement with its parameters on a
+// connection that refuses writes, returning at most maxRows rows as text.
+// Queries that touch credential tables are rejected.
+func (db *DB) QueryReadOnly(query string, args []interface{}, maxRows int) (*QueryResult, error) {
+	query, err := validateReadOnlyQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, fmt.Errorf("failed to enable read-only mode: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA query_only = OFF")
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query columns: %w", err)
+	}
+
+	result := &QueryResult{Columns: columns}
+	for rows.Next() {
+		if maxRows > 0 && len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan query row: %w", err)
+		}
+
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = formatQueryValue(value)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating query rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// Exec runs a single statement that may change the database, for
+// 'money db query --write', returning how many rows it changed
+func (db *DB) Exec(statement string) (int64, error) {
+	statement = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(statement), ";"))
+	if statement == "" {
+		return 0, fmt.Errorf("empty query")
+	}
+	if strings.Contains(statement, ";") {
+		return 0, fmt.Errorf("only a single statement is allowed")
+	}
+
+	result, err := db.conn.Exec(statement)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run statement: %w", err)
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return changed, nil
+}
+
+// Dump writes the whole database to w as SQL statements that rebuild it,
+// like the sqlite3 shell's .dump: the tables with their rows, then the
+// indexes, triggers and views, in one transaction. With skipSecrets the
+// credential tables are created empty. The dump is read in one transaction,
+// so it is consistent even while other processes write.
+func (db *DB) Dump(w io.Writer, skipSecrets bool) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int32
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	type schemaEntry struct{ kind, name, sql string }
+	rows, err := tx.Query(`
+		SELECT type, name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_autoindex_%'
+		ORDER BY type != 'table', rowid`)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	var entries []schemaEntry
+	for rows.Next() {
+		var entry schemaEntry
+		if err := rows.Scan(&entry.kind, &entry.name, &entry.sql); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schema: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating schema: %w", err)
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "PRAGMA foreign_keys=OFF;")
+	fmt.Fprintln(out, "BEGIN TRANSACTION;")
+	for _, entry := range entries {
+		if entry.name == "sqlite_sequence" {
+			// Created by SQLite itself along with the first AUTOINCREMENT table
+			continue
+		}
+		fmt.Fprintf(out, "%s;\n", entry.sql)
+		if entry.kind != "table" || (skipSecrets && isSecretTable(entry.name)) {
+			continue
+		}
+		if err := dumpRows(tx, out, entry.name); err != nil {
+			return err
+		}
+	}
+	if err := dumpSequences(tx, out); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "PRAGMA user_version=%d;\n", version)
+	fmt.Fprintln(out, "COMMIT;")
+	return out.Flush()
+}
+
+// dumpRows writes an INSERT statement for every row of table. Values are
+// written by SQLite's quote(), so they read back exactly as stored.
+func dumpRows(tx *sql.Tx, w io.Writer, table string) error {
+	columnRows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	var quoted []string
+	for columnRows.Next() {
+		var column string
+		if err := columnRows.Scan(&column); err != nil {
+			columnRows.Close()
+			return fmt.Errorf("failed to scan columns of %s: %w", table, err)
+		}
+		quoted = append(quoted, "quote("+quoteIdentifier(column)+")")
+	}
+	columnRows.Close()
+
+	rows, err := tx.Query("SELECT " + strings.Join(quoted, " || ',' || ") + " FROM " + quoteIdentifier(table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var values string
+		if err := rows.Scan(&values); err != nil {
+			return fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		fmt.Fprintf(w, "INSERT INTO %s VALUES(%s);\n", quoteIdentifier(table), values)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s: %w", table, err)
+	}
+	return nil
+}
+
+// dumpSequences writes the AUTOINCREMENT counters, so restored tables don't
+// reuse the IDs of deleted rows
+func dumpSequences(tx *sql.Tx, w io.Writer) error {
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'sqlite_sequence'").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check sqlite_sequence: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "DELETE FROM sqlite_sequence;")
+	return dumpRows(tx, w, "sqlite_sequence")
+}
+
+// quoteIdentifier quotes a table or column name for SQL
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// validateReadOnlyQuery checks that query is a single SELECT statement that
+// doesn't read credentials, returning it without a trailing semicolon
+func validateReadOnlyQuery(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	if query == "" {
+		return "", fmt.Errorf("empty query")
+	}
+	if strings.Contains(query, ";") {
+		return "", fmt.Errorf("only a single statement is allowed")
+	}
+
+	lower := strings.ToLower(query)
+	if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+		return "", fmt.Errorf("only SELECT queries are allowed")
+	}
+	for _, table := range secretTables {
+		if strings.Contains(lower, table) {
+			return "", fmt.Errorf("queries may not read the %s table", table)
+		}
+	}
+
+	return query, nil
+}
+
+func isSecretTable(name string) bool {
+	for _, table := range secretTables {
+		if name == table {
+			return true
+		}
+	}
+	return false
+}
+
+// formatQueryValue renders a scanned column value as text
+func formatQueryValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// anonymizedDropTables are emptied in anonymized exports: credentials, and
+// tables whose contents can't be scrubbed without losing their meaning
+// (LLM prompts, edit history, and rename rules all quote raw descriptions)
+var anonymizedDropTables = []string{
+	"credentials", "rentcast_credentials", "llm_calls", "transaction_edits", "rename_rules",
+	"crypto_wallets", "gocardless_credentials", "teller_enrollments",
+}
+
+// anonymizedColumns lists text columns replaced by salted hashes and amount
+// columns rounded to one significant digit in anonymized exports
+var (
+	anonymizedTextColumns = map[string][]string{
+		"organizations": {"name", "url"},
+		"accounts":      {"name", "nickname", "owner"},
+		"properties":    {"address", "city", "zip_code"},
+		"transactions":  {"description", "display_description"},
+		"bills":         {"name"},
+		"holdings":      {"description"},
+	}
+	anonymizedAmountColumns = map[string][]string{
+		"accounts":                {"balance", "available_balance"},
+		"balance_history":         {"balance", "available_balance"},
+		"properties":              {"last_value_estimate", "last_rent_estimate"},
+		"transactions":            {"amount"},
+		"budgets":                 {"amount"},
+		"bills":                   {"amount"},
+		"low_balance_alerts":      {"threshold"},
+		"credit_limits":           {"credit_limit"},
+		"assets":                  {"purchase_price", "salvage_value"},
+		"holdings":                {"market_value", "cost_basis"},
+		"lots":                    {"cost_basis", "proceeds"},
+		"closed_month_categories": {"income", "expenses"},
+		"closed_month_balances":   {"balance"},
+	}
+)
+
+// Backup writes a consistent copy of the whole database to path, which must
+// not exist yet. It works on read-only handles too.
+func (db *DB) Backup(path string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	return nil
+}
+
+// MigrationBackup is a copy of the database taken before migrations changed
+// its schema
+type MigrationBackup struct {
+	Path    string
+	TakenAt time.Time
+	Size    int64
+}
+
+// migrationBackupTimeLayout is the time in backup file names, which sorts
+// them oldest first
+const migrationBackupTimeLayout = "20060102-150405"
+
+// migrationBackupPrefix starts the names of the backups of cfg's database,
+// e.g. money-pre-migration-, so databases opened with --db keep their own
+func migrationBackupPrefix(cfg *config.Config) string {
+	return strings.TrimSuffix(filepath.Base(cfg.DBPath()), ".db") + "-pre-migration-"
+}
+
+// backupBeforeMigrations copies an existing database into the backup
+// directory when migrations are about to change its schema, then deletes
+// all but the newest cfg.MigrationBackups copies. It returns the copy's
+// path, or "" when none was taken.
+func (db *DB) backupBeforeMigrations(now time.Time) (string, error) {
+	if db.config.MigrationBackups == 0 {
+		return "", nil
+	}
+
+	var version int32
+	if err := db.conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version == schemaVersion {
+		return "", nil
+	}
+	var tableCount int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'").Scan(&tableCount)
+	if err != nil {
+		return "", fmt.Errorf("failed to check existing tables: %w", err)
+	}
+	if tableCount == 0 {
+		// A new database has nothing to lose
+		return "", nil
+	}
+
+	dir := db.config.BackupDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(dir, migrationBackupPrefix(db.config)+now.Format(migrationBackupTimeLayout)+".db")
+	if _, err := os.Stat(path); err == nil {
+		// Taken by an attempt earlier this second whose migrations failed
+		return path, nil
+	}
+
+	slog.Debug("backing up database before migrations", "path", path)
+	if err := db.Backup(path); err != nil {
+		return "", fmt.Errorf("failed to back up database before migrations: %w", err)
+	}
+
+	backups, err := ListMigrationBackups(db.config)
+	if err != nil {
+		return "", err
+	}
+	for _, old := range backups[min(db.config.MigrationBackups, len(backups)):] {
+		if err := os.Remove(old.Path); err != nil {
+			slog.Warn("failed to remove old backup", "path", old.Path, "error", err)
+		}
+	}
+	return path, nil
+}
+
+// ListMigrationBackups returns the copies of cfg's database taken before
+// migrations, newest first
+func ListMigrationBackups(cfg *config.Config) ([]MigrationBackup, error) {
+	entries, err := os.ReadDir(cfg.BackupDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	prefix := migrationBackupPrefix(cfg)
+	var backups []MigrationBackup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		takenAt, err := time.ParseInLocation(migrationBackupTimeLayout, strings.TrimSuffix(stamp, ".db"), time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, MigrationBackup{
+			Path:    filepath.Join(cfg.BackupDir(), entry.Name()),
+			TakenAt: takenAt,
+			Size:    info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].TakenAt.After(backups[j].TakenAt)
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces cfg's database with the copy at backupPath, keeping
+// the current database as money.db.before-rollback. Every handle to the
+// database must be closed first. The restored copy is migrated again the
+// next time this version of money opens it.
+func RestoreBackup(cfg *config.Config, backupPath string) error {
+	dbPath := cfg.DBPath()
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	tmp := dbPath + ".rollback"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write restored database: %w", err)
+	}
+	if _, err := os.Stat(dbPath); err == nil {
+		if err := os.Rename(dbPath, dbPath+".before-rollback"); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to keep the current database: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	return nil
+}
+
+// ExportAnonymized writes a copy of the database to path that is safe to
+// attach to a bug report: credentials and free-text tables are emptied,
+// names and descriptions are replaced with hashes salted per export (equal
+// values still hash equally within one export), amounts are rounded to one
+// significant digit, and property coordinates are removed. Categories, dates,
+// account types, and IDs are kept so problems stay reproducible.
+func (db *DB) ExportAnonymized(path string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer conn.Close()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range anonymizedDropTables {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	for table, columns := range anonymizedTextColumns {
+		for _, column := range columns {
+			err := rewriteColumn(tx, table, column, func(value interface{}) interface{} {
+				text := formatQueryValue(value)
+				if value == nil || text == "" {
+					return value
+				}
+				return anonymizeText(salt, text)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for table, columns := range anonymizedAmountColumns {
+		for _, column := range columns {
+			err := rewriteColumn(tx, table, column, func(value interface{}) interface{} {
+				cents, ok := value.(int64)
+				if !ok {
+					return value
+				}
+				return bucketAmount(cents)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE properties SET latitude = NULL, longitude = NULL"); err != nil {
+		return fmt.Errorf("failed to clear property coordinates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit anonymized export: %w", err)
+	}
+
+	// Rewrite the file so freed pages holding the original values are dropped
+	if _, err := conn.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to compact export: %w", err)
+	}
+	return nil
+}
+
+// rewriteColumn replaces every value of table.column with rewrite(value)
+func rewriteColumn(tx *sql.Tx, table, column string, rewrite func(interface{}) interface{}) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT rowid, %s FROM %s", column, table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+	}
+
+	type update struct {
+		rowid int64
+		value interface{}
+	}
+	var updates []update
+	for rows.Next() {
+		var u update
+		var value interface{}
+		if err := rows.Scan(&u.rowid, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s.%s: %w", table, column, err)
+		}
+		u.value = rewrite(value)
+		updates = append(updates, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s.%s: %w", table, column, err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", table, column))
+	if err != nil {
+		return fmt.Errorf("failed to prepare %s.%s update: %w", table, column, err)
+	}
+	defer stmt.Close()
+
+	for _, u := range updates {
+		if _, err := stmt.Exec(u.value, u.rowid); err != nil {
+			return fmt.Errorf("failed to update %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+// anonymizeText replaces text with a short salted hash
+func anonymizeText(salt []byte, text string) string {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), text...))
+	return "anon-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// bucketAmount rounds cents to one significant digit, keeping the sign, so
+// 4,321.00 becomes 4,000.00 and -87.50 becomes -90.00
+func bucketAmount(cents int64) int64 {
+	sign := int64(1)
+	if cents < 0 {
+		sign, cents = -1, -cents
+	}
+	if cents < 10 {
+		return sign * cents
+	}
+
+	magnitude := int64(1)
+	for cents/magnitude >= 10 {
+		magnitude *= 10
+	}
+	return sign * ((cents + magnitude/2) / magnitude) * magnitude
+}
+
+// orphanExamples is how many missing values CheckForeignKeys lists per
+// reference
+const orphanExamples = 5
+
+// QuickCheck runs SQLite's structural check of the database file, returning
+// the problems it finds
+func (db *DB) QuickCheck() ([]string, error) {
+	rows, err := db.conn.Query(`PRAGMA quick_check`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, fmt.Errorf("failed to scan check result: %w", err)
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// CheckForeignKeys finds rows that reference a row that doesn't exist, such
+// as transactions of deleted accounts, left behind before foreign keys were
+// enforced. Each reference with orphans is reported once.
+func (db *DB) CheckForeignKeys() ([]OrphanedReference, error) {
+	rows, err := db.conn.Query(`
+		SELECT m.name, f."from", f."table", COALESCE(f."to", '')
+		FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table'
+		ORDER BY m.name, f.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+	var references []OrphanedReference
+	for rows.Next() {
+		var ref OrphanedReference
+		if err := rows.Scan(&ref.Table, &ref.Column, &ref.Parent, &ref.ParentColumn); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+		if ref.ParentColumn == "" {
+			ref.ParentColumn = "rowid"
+		}
+		references = append(references, ref)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating foreign keys: %w", err)
+	}
+
+	var orphaned []OrphanedReference
+	for _, ref := range references {
+		found, err := db.findOrphans(ref)
+		if err != nil {
+			return nil, err
+		}
+		if found.Rows > 0 {
+			orphaned = append(orphaned, found)
+		}
+	}
+	return orphaned, nil
+}
+
+// findOrphans counts ref's rows whose value is missing from the parent table
+func (db *DB) findOrphans(ref OrphanedReference) (OrphanedReference, error) {
+	rows, err := db.conn.Query(fmt.Sprintf(`
+		SELECT CAST(c."%[2]s" AS TEXT), COUNT(*)
+		FROM "%[1]s" c
+		WHERE c."%[2]s" IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM "%[3]s" p WHERE p."%[4]s" = c."%[2]s")
+		GROUP BY c."%[2]s"
+		ORDER BY COUNT(*) DESC, 1`,
+		ref.Table, ref.Column, ref.Parent, ref.ParentColumn))
+	if err != nil {
+		return ref, fmt.Errorf("failed to check %s.%s: %w", ref.Table, ref.Column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return ref, fmt.Errorf("failed to scan orphans: %w", err)
+		}
+		ref.Rows += count
+		ref.MissingValues++
+		if len(ref.Missing) < orphanExamples {
+			ref.Missing = append(ref.Missing, value)
+		}
+	}
+	return ref, rows.Err()
+}
+
+// FileSize returns the size of the database in bytes
+func (db *DB) FileSize() (int64, error) {
+	var pageCount, pageSize int64
+	if err := db.conn.QueryRow(`PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := db.conn.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to get page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
+
+// TableSizes returns every table's row count and the space it and its
+// indexes take, largest first. Sizes come from SQLite's dbstat table; when
+// SQLite was built without it, Bytes is -1 and tables are ordered by rows.
+func (db *DB) TableSizes() ([]TableSize, error) {
+	rows, err := db.conn.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type='table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var sizes []TableSize
+	for rows.Next() {
+		size := TableSize{Bytes: -1}
+		if err := rows.Scan(&size.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		sizes = append(sizes, size)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %w", err)
+	}
+
+	for i := range sizes {
+		err := db.conn.QueryRow(`SELECT COUNT(*) FROM "` + sizes[i].Name + `"`).Scan(&sizes[i].Rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s rows: %w", sizes[i].Name, err)
+		}
+	}
+
+	bytes := make(map[string]int64)
+	rows, err = db.conn.Query(`
+		SELECT m.tbl_name, SUM(s.pgsize)
+		FROM dbstat s
+		JOIN sqlite_master m ON m.name = s.name
+		GROUP BY m.tbl_name`)
+	if err == nil {
+		for rows.Next() {
+			var name string
+			var size int64
+			if err := rows.Scan(&name, &size); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan table size: %w", err)
+			}
+			bytes[name] = size
+		}
+		rows.Close()
+		for i := range sizes {
+			sizes[i].Bytes = bytes[sizes[i].Name]
+		}
+	} else {
+		slog.Debug("dbstat unavailable, table sizes unknown", "error", err)
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		if sizes[i].Bytes != sizes[j].Bytes {
+			return sizes[i].Bytes > sizes[j].Bytes
+		}
+		return sizes[i].Rows > sizes[j].Rows
+	})
+	return sizes, nil
+}
+
+// Maintain rebuilds every index, refreshes the query planner's statistics,
+// and vacuums the database to reclaim free pages and defragment it,
+// returning how long each step took
+func (db *DB) Maintain() ([]MaintenanceStep, error) {
+	var steps []MaintenanceStep
+	for _, step := range []struct{ name, stmt string }{
+		{"Rebuild indexes", "REINDEX"},
+		{"Update statistics", "ANALYZE"},
+		{"Vacuum", "VACUUM"},
+	} {
+		start := time.Now()
+		if _, err := db.conn.Exec(step.stmt); err != nil {
+			return steps, fmt.Errorf("failed to %s: %w", strings.ToLower(step.name), err)
+		}
+		steps = append(steps, MaintenanceStep{Name: step.name, Duration: time.Since(start)})
+	}
+	return steps, nil
+}
+
+// SetBudget sets the monthly budget target for a category, replacing any existing target
+func (db *DB) SetBudget(categoryID int, amount int64) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO budgets (category_id, amount)
+		VALUES (?, ?)
+		ON CONFLICT(category_id) DO UPDATE SET
+			amount = excluded.amount,
+			updated_at = CURRENT_TIMESTAMP`,
+		categoryID, amount)
+	if err != nil {
+		return fmt.Errorf("failed to set budget: %w", err)
+	}
+	return nil
+}
+
+// ClearBudget removes the monthly budget target for a category
+func (db *DB) ClearBudget(categoryID int) error {
+	result, err := db.conn.Exec(`DELETE FROM budgets WHERE category_id = ?`, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to clear budget: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no budget set for category: %d", categoryID)
+	}
+
+	return nil
+}
+
+// GetBudgets returns all monthly budget targets ordered by category name
+func (db *DB) GetBudgets() ([]Budget, error) {
+	rows, err := db.conn.Query(`
+		SELECT b.category_id, c.name, b.amount
+		FROM budgets b
+		JOIN categories c ON b.category_id = c.id
+		ORDER BY c.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []Budget
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.CategoryID, &b.CategoryName, &b.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating budgets: %w", err)
+	}
+
+	return budgets, nil
+}
+
+// RecordBudgetNotification records that category crossing percent of its
+// budget in month (YYYY-MM) was notified. It reports false, recording
+// nothing, when that or a higher threshold was already notified that month.
+func (db *DB) RecordBudgetNotification(categoryID int, month string, percent int) (bool, error) {
+	var sent int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) FROM budget_notifications
+		WHERE category_id = ? AND month = ? AND percent >= ?`,
+		categoryID, month, percent).Scan(&sent)
+	if err != nil {
+		return false, fmt.Errorf("failed to check budget notifications: %w", err)
+	}
+	if sent > 0 {
+		return false, nil
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT INTO budget_notifications (category_id, month, percent)
+		VALUES (?, ?, ?)`,
+		categoryID, month, percent)
+	if err != nil {
+		return false, fmt.Errorf("failed to record budget notification: %w", err)
+	}
+	return true, nil
+}
+
+// SetTaxLine maps a category to the tax form line it's reported on,
+// replacing any existing mapping
+func (db *DB) SetTaxLine(categoryID int, taxLine string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO tax_mappings (category_id, tax_line)
+		VALUES (?, ?)
+		ON CONFLICT(category_id) DO UPDATE SET
+			tax_line = excluded.tax_line,
+			updated_at = CURRENT_TIMESTAMP`,
+		categoryID, taxLine)
+	if err != nil {
+		return fmt.Errorf("failed to set tax line: %w", err)
+	}
+	return nil
+}
+
+// ClearTaxLine removes a category's tax line mapping
+func (db *DB) ClearTaxLine(categoryID int) error {
+	result, err := db.conn.Exec(`DELETE FROM tax_mappings WHERE category_id = ?`, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to clear tax line: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no tax line set for category: %d", categoryID)
+	}
+
+	return nil
+}
+
+// GetTaxMappings returns every category's tax line mapping ordered by
+// category name
+func (db *DB) GetTaxMappings() ([]TaxMapping, error) {
+	rows, err := db.conn.Query(`
+		SELECT m.category_id, c.name, m.tax_line
+		FROM tax_mappings m
+		JOIN categories c ON m.category_id = c.id
+		ORDER BY c.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tax mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []TaxMapping
+	for rows.Next() {
+		var m TaxMapping
+		if err := rows.Scan(&m.CategoryID, &m.CategoryName, &m.TaxLine); err != nil {
+			return nil, fmt.Errorf("failed to scan tax mapping: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tax mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// CloseMonth locks month (YYYY-MM) against category changes and records
+// the totals and ending balances it closed with, replacing the snapshot of
+// a month that was already closed
+func (db *DB) CloseMonth(month string, categories []ClosedCategoryTotal, balances []ClosedBalance) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM closed_months WHERE month = ?", month); err != nil {
+		return fmt.Errorf("failed to replace closed month: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO closed_months (month) VALUES (?)", month); err != nil {
+		return fmt.Errorf("failed to close month: %w", err)
+	}
+
+	for _, c := range categories {
+		_, err := tx.Exec(`
+			INSERT INTO closed_month_categories (month, category, income, expenses)
+			VALUES (?, ?, ?, ?)`,
+			month, c.Category, c.Income, c.Expenses)
+		if err != nil {
+			return fmt.Errorf("failed to save category total: %w", err)
+		}
+	}
+
+	for _, b := range balances {
+		_, err := tx.Exec(`
+			INSERT INTO closed_month_balances (month, account_id, balance)
+			VALUES (?, ?, ?)`,
+			month, b.AccountID, b.Balance)
+		if err != nil {
+			return fmt.Errorf("failed to save ending balance: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit closed month: %w", err)
+	}
+	return nil
+}
+
+// ReopenMonth unlocks a closed month and discards its snapshot
+func (db *DB) ReopenMonth(month string) error {
+	result, err := db.conn.Exec("DELETE FROM closed_months WHERE month = ?", month)
+	if err != nil {
+		return fmt.Errorf("failed to reopen month: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("month is not closed: %s", month)
+	}
+
+	return nil
+}
+
+// GetClosedMonths returns every closed month, newest first, without their
+// snapshots
+func (db *DB) GetClosedMonths() ([]ClosedMonth, error) {
+	rows, err := db.conn.Query("SELECT month, closed_at FROM closed_months ORDER BY month DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed months: %w", err)
+	}
+	defer rows.Close()
+
+	var months []ClosedMonth
+	for rows.Next() {
+		var m ClosedMonth
+		if err := rows.Scan(&m.Month, &m.ClosedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan closed month: %w", err)
+		}
+		months = append(months, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating closed months: %w", err)
+	}
+
+	return months, nil
+}
+
+// GetClosedMonth returns month's snapshot, with categories ordered by name,
+// or nil when the month isn't closed
+func (db *DB) GetClosedMonth(month string) (*ClosedMonth, error) {
+	closed := ClosedMonth{Month: month}
+	err := db.conn.QueryRow("SELECT closed_at FROM closed_months WHERE month = ?", month).Scan(&closed.ClosedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closed month: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT category, income, expenses
+		FROM closed_month_categories
+		WHERE month = ?
+		ORDER BY category`,
+		month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category totals: %w", err)
+	}
+	for rows.Next() {
+		var c ClosedCategoryTotal
+		if err := rows.Scan(&c.Category, &c.Income, &c.Expenses); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan category total: %w", err)
+		}
+		closed.Categories = append(closed.Categories, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating category totals: %w", err)
+	}
+
+	rows, err = db.conn.Query(`
+		SELECT account_id, balance
+		FROM closed_month_balances
+		WHERE month = ?
+		ORDER BY account_id`,
+		month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ending balances: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b ClosedBalance
+		if err := rows.Scan(&b.AccountID, &b.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan ending balance: %w", err)
+		}
+		closed.Balances = append(closed.Balances, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ending balances: %w", err)
+	}
+
+	return &closed, nil
+}
+
+// FetchRun is one money fetch
+type FetchRun struct {
+	ID         int64
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Replay     bool // fed recorded responses instead of contacting SimpleFIN
+}
+
+// BalanceChange is an account's balance before and after a fetch
+type BalanceChange struct {
+	AccountID string
+	Before    *int64 // nil when the fetch added the account
+	After     int64
+}
+
+// FetchChanges is what one fetch added and changed. Rows are attributed to
+// the fetch by when they were created, so it also picks up anything else
+// written while it ran.
+type FetchChanges struct {
+	Organizations []Organization
+	AccountIDs    []string      // accounts the fetch added
+	Transactions  []Transaction // new transactions, by account and oldest first
+	Balances      []BalanceChange
+}
+
+// fetchRunTimeLayout is how fetch run times are stored, matching
+// CURRENT_TIMESTAMP so they compare with created_at columns
+const fetchRunTimeLayout = "2006-01-02 15:04:05"
+
+// RecordFetchRun records a finished fetch that started at startedAt
+func (db *DB) RecordFetchRun(startedAt, finishedAt time.Time, replay bool) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO fetch_runs (started_at, finished_at, replay)
+		VALUES (?, ?, ?)`,
+		startedAt.UTC().Format(fetchRunTimeLayout), finishedAt.UTC().Format(fetchRunTimeLayout), replay)
+	if err != nil {
+		return fmt.Errorf("failed to record fetch: %w", err)
 	}
+	return nil
+}
 
-	// Now update existing records (preserves account_type if already set)
-	_, err = db.conn.Exec(`
-		UPDATE accounts 
-		SET org_id = ?, name = ?, currency = ?, balance = ?, available_balance = ?, balance_date = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		orgID, name, currency, balance, availableBalanceVal,
-		sql.NullString{String: balanceDate, Valid: balanceDate != ""}, id)
+// GetLastFetchRun returns the most recent fetch, or nil when there has been
+// none since fetches were recorded
+func (db *DB) GetLastFetchRun() (*FetchRun, error) {
+	var run FetchRun
+	var startedAt, finishedAt string
+	err := db.conn.QueryRow(`
+		SELECT id, strftime('%Y-%m-%d %H:%M:%S', started_at), strftime('%Y-%m-%d %H:%M:%S', finished_at), replay
+		FROM fetch_runs
+		ORDER BY id DESC
+		LIMIT 1`).Scan(&run.ID, &startedAt, &finishedAt, &run.Replay)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to save account: %w", err)
+		return nil, fmt.Errorf("failed to get last fetch: %w", err)
+	}
+
+	if run.StartedAt, err = time.Parse(fetchRunTimeLayout, startedAt); err != nil {
+		return nil, fmt.Errorf("invalid fetch start time %q: %w", startedAt, err)
+	}
+	if run.FinishedAt, err = time.Parse(fetchRunTimeLayout, finishedAt); err != nil {
+		return nil, fmt.Errorf("invalid fetch finish time %q: %w", finishedAt, err)
+	}
+	return &run, nil
+}
+
+// GetFetchChanges returns the organizations, accounts and transactions run
+// added and the balances it changed, limited to the handle's owners
+func (db *DB) GetFetchChanges(run FetchRun) (*FetchChanges, error) {
+	start := run.StartedAt.UTC().Format(fetchRunTimeLayout)
+	end := run.FinishedAt.UTC().Format(fetchRunTimeLayout)
+	changes := &FetchChanges{}
+
+	rows, err := db.conn.Query(`
+		SELECT id, name, url
+		FROM organizations
+		WHERE created_at BETWEEN ? AND ?
+		ORDER BY name`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new organizations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var org Organization
+		var url sql.NullString
+		if err := rows.Scan(&org.ID, &org.Name, &url); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		if url.Valid {
+			org.URL = &url.String
+		}
+		changes.Organizations = append(changes.Organizations, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organizations: %w", err)
+	}
+	rows.Close()
+
+	ownerCondition, ownerArgs := db.ownerCondition("id")
+	rows, err = db.conn.Query(`
+		SELECT id
+		FROM accounts
+		WHERE created_at BETWEEN ? AND ? AND `+ownerCondition+`
+		ORDER BY id`, append([]interface{}{start, end}, ownerArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new accounts: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		changes.AccountIDs = append(changes.AccountIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounts: %w", err)
+	}
+
+	ownerCondition, ownerArgs = db.ownerCondition("t.account_id")
+	rows, err = db.conn.Query(`
+		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.display_description, t.pending, t.category_id
+		FROM transactions t
+		WHERE t.created_at BETWEEN ? AND ? AND `+ownerCondition+`
+		ORDER BY t.account_id, t.posted, t.id`, append([]interface{}{start, end}, ownerArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new transactions: %w", err)
+	}
+	changes.Transactions, err = scanTransactions(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each account's last balance recorded during the run, against its last
+	// one from before
+	ownerCondition, ownerArgs = db.ownerCondition("h.account_id")
+	rows, err = db.conn.Query(`
+		SELECT h.account_id, h.balance, (
+			SELECT balance
+			FROM balance_history
+			WHERE account_id = h.account_id AND recorded_at < ?
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		)
+		FROM balance_history h
+		WHERE h.id = (
+			SELECT id
+			FROM balance_history
+			WHERE account_id = h.account_id AND recorded_at BETWEEN ? AND ?
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) AND `+ownerCondition+`
+		ORDER BY h.account_id`, append([]interface{}{start, start, end}, ownerArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance changes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var change BalanceChange
+		var before sql.NullInt64
+		if err := rows.Scan(&change.AccountID, &change.After, &before); err != nil {
+			return nil, fmt.Errorf("failed to scan balance change: %w", err)
+		}
+		if before.Valid {
+			change.Before = &before.Int64
+		}
+		changes.Balances = append(changes.Balances, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balance changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (db *DB) SaveBalanceHistory(accountID string, balance int64, availableBalance *int64) error {
+	var availableBalanceVal sql.NullInt64
+	if availableBalance != nil {
+		availableBalanceVal = sql.NullInt64{Int64: *availableBalance, Valid: true}
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO balance_history (account_id, balance, available_balance, recorded_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		accountID, balance, availableBalanceVal)
+	if err != nil {
+		return fmt.Errorf("failed to save balance history: %w", err)
 	}
 	return nil
 }
 
-func (db *DB) GetAccounts() ([]Account, error) {
+// SaveBalanceHistoryAt records a balance snapshot taken at recordedAt rather
+// than now, for backfilling history
+func (db *DB) SaveBalanceHistoryAt(accountID string, balance int64, availableBalance *int64, recordedAt time.Time) error {
+	var availableBalanceVal sql.NullInt64
+	if availableBalance != nil {
+		availableBalanceVal = sql.NullInt64{Int64: *availableBalance, Valid: true}
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO balance_history (account_id, balance, available_balance, recorded_at)
+		VALUES (?, ?, ?, ?)`,
+		accountID, balance, availableBalanceVal, recordedAt.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return fmt.Errorf("failed to save balance history: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetAllBalanceHistory(days int) ([]BalanceHistory, error) {
+	ownerCondition, ownerArgs := db.ownerCondition("account_id")
 	query := `
-		SELECT a.id, a.org_id, a.name, a.nickname, a.currency, a.balance, a.available_balance, a.balance_date, a.account_type
-		FROM accounts a
-		ORDER BY a.org_id, a.name`
+		SELECT id, account_id, balance, available_balance, recorded_at
+		FROM balance_history
+		WHERE recorded_at >= datetime('now', '-' || ? || ' days') AND ` + ownerCondition + `
+		ORDER BY recorded_at ASC`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.Query(query, append([]interface{}{days}, ownerArgs...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query accounts: %w", err)
+		return nil, fmt.Errorf("failed to query all balance history: %w", err)
 	}
 	defer rows.Close()
 
-	var accounts []Account
+	var history []BalanceHistory
 	for rows.Next() {
-		var account Account
-		var nickname sql.NullString
+		var bh BalanceHistory
 		var availableBalance sql.NullInt64
-		var balanceDate sql.NullString
-		var accountType sql.NullString
+
+		err := rows.Scan(&bh.ID, &bh.AccountID, &bh.Balance, &availableBalance, &bh.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance history: %w", err)
+		}
+
+		if availableBalance.Valid {
+			balance := availableBalance.Int64
+			bh.AvailableBalance = &balance
+		}
+
+		history = append(history, bh)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating all balance history: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetBalanceHistory returns one account's balance history for the last
+// days days, oldest first
+func (db *DB) GetBalanceHistory(accountID string, days int) ([]BalanceHistory, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, account_id, balance, available_balance, recorded_at
+		FROM balance_history
+		WHERE account_id = ? AND recorded_at >= datetime('now', '-' || ? || ' days')
+		ORDER BY recorded_at ASC, id ASC`,
+		accountID, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []BalanceHistory
+	for rows.Next() {
+		var bh BalanceHistory
+		var availableBalance sql.NullInt64
+
+		err := rows.Scan(&bh.ID, &bh.AccountID, &bh.Balance, &availableBalance, &bh.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance history: %w", err)
+		}
+
+		if availableBalance.Valid {
+			balance := availableBalance.Int64
+			bh.AvailableBalance = &balance
+		}
+
+		history = append(history, bh)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balance history: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetBalancesBefore returns each account's last recorded balance before
+// the given time, keyed by account ID. Accounts with no history that early
+// are left out.
+func (db *DB) GetBalancesBefore(before time.Time) (map[string]int64, error) {
+	ownerCondition, ownerArgs := db.ownerCondition("h.account_id")
+	rows, err := db.conn.Query(`
+		SELECT h.account_id, h.balance
+		FROM balance_history h
+		WHERE h.id = (
+			SELECT id
+			FROM balance_history
+			WHERE account_id = h.account_id AND recorded_at < ?
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) AND `+ownerCondition,
+		append([]interface{}{before.UTC().Format("2006-01-02 15:04:05")}, ownerArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance history: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]int64)
+	for rows.Next() {
+		var accountID string
+		var balance int64
+		if err := rows.Scan(&accountID, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance history: %w", err)
+		}
+		balances[accountID] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balance history: %w", err)
+	}
+
+	return balances, nil
+}
+
+// GetTransactionsByCategory returns transactions grouped by category name,
+// newest first. startDate and endDate are inclusive YYYY-MM-DD dates in the
+// configured time zone, or RFC3339 times with endDate exclusive; the range is
+// only applied when both are set.
+func (db *DB) GetTransactionsByCategory(startDate, endDate string, excludeInternal bool) (map[string][]Transaction, error) {
+	conditions, args := db.ownerCondition("t.account_id")
+	if startDate != "" && endDate != "" {
+		conditions += " AND t.posted >= ? AND t.posted < ?"
+		args = append(args, rangeStart(startDate), rangeEnd(endDate))
+	}
+	if excludeInternal {
+		conditions += " AND COALESCE(c.is_internal, FALSE) = FALSE"
+	}
+
+	query := `
+		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.display_description, t.pending,
+		       t.category_id, c.name as category_name
+		FROM transactions t
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE ` + conditions + `
+		ORDER BY t.posted DESC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions by category: %w", err)
+	}
+	defer rows.Close()
+
+	categoryTransactions := make(map[string][]Transaction)
+
+	for rows.Next() {
+		var t Transaction
+		var categoryID sql.NullInt64
+		var categoryName sql.NullString
+		var displayDescription sql.NullString
 
 		err := rows.Scan(
-			&account.ID,
-			&account.OrgID,
-			&account.Name,
-			&nickname,
-			&account.Currency,
-			&account.Balance,
-			&availableBalance,
-			&balanceDate,
-			&accountType,
+			&t.ID,
+			&t.AccountID,
+			&t.Posted,
+			&t.Amount,
+			&t.Description,
+			&displayDescription,
+			&t.Pending,
+			&categoryID,
+			&categoryName,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
 
-		// Handle nullable fields
-		if nickname.Valid {
-			account.Nickname = &nickname.String
-		}
-		if availableBalance.Valid {
-			balance := int(availableBalance.Int64)
-			account.AvailableBalance = &balance
-		}
-		if balanceDate.Valid {
-			account.BalanceDate = &balanceDate.String
-		}
-		if accountType.Valid {
-			account.AccountType = &accountType.String
-		}
+		if displayDescription.Valid {
+			t.CleanDescription = &displayDescription.String
+		}
+
+		if categoryID.Valid {
+			catID := int(categoryID.Int64)
+			t.CategoryID = &catID
+		}
+
+		// Determine category name
+		var catName string
+		if categoryName.Valid {
+			catName = categoryName.String
+		} else {
+			catName = "Uncategorized"
+		}
+
+		categoryTransactions[catName] = append(categoryTransactions[catName], t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return categoryTransactions, nil
+}
+
+func (db *DB) SaveProperty(accountID, address, city, state, zipCode string, propertyType *string, latitude, longitude *float64) error {
+	var latVal, lonVal sql.NullFloat64
+	var propTypeVal sql.NullString
+	if latitude != nil {
+		latVal = sql.NullFloat64{Float64: *latitude, Valid: true}
+	}
+	if longitude != nil {
+		lonVal = sql.NullFloat64{Float64: *longitude, Valid: true}
+	}
+	if propertyType != nil {
+		propTypeVal = sql.NullString{String: *propertyType, Valid: true}
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO properties (account_id, address, city, state, zip_code, property_type, latitude, longitude)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			address = excluded.address,
+			city = excluded.city,
+			state = excluded.state,
+			zip_code = excluded.zip_code,
+			property_type = excluded.property_type,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude`,
+		accountID, address, city, state, zipCode, propTypeVal, latVal, lonVal)
+	if err != nil {
+		return fmt.Errorf("failed to save property: %w", err)
+	}
+	return nil
+}
+
+// propertyColumns are the properties columns read by scanProperty, in order
+const propertyColumns = `account_id, address, city, state, zip_code, property_type, latitude, longitude,
+		       last_value_estimate, last_rent_estimate, last_updated, valuation_provider,
+		       bedrooms, bathrooms, square_footage, lot_size, year_built`
+
+// scanProperty reads a property selected with propertyColumns from row,
+// which is a *sql.Row or *sql.Rows
+func scanProperty(row interface{ Scan(...interface{}) error }) (*Property, error) {
+	var p Property
+	var lat, lon, bathrooms sql.NullFloat64
+	var propertyType sql.NullString
+	var lastValueEstimate, lastRentEstimate sql.NullInt64
+	var lastUpdated, valuationProvider sql.NullString
+	var bedrooms, squareFootage, lotSize, yearBuilt sql.NullInt64
+
+	err := row.Scan(
+		&p.AccountID, &p.Address, &p.City, &p.State, &p.ZipCode, &propertyType,
+		&lat, &lon, &lastValueEstimate, &lastRentEstimate, &lastUpdated, &valuationProvider,
+		&bedrooms, &bathrooms, &squareFootage, &lotSize, &yearBuilt)
+	if err != nil {
+		return nil, err
+	}
 
-		accounts = append(accounts, account)
+	if propertyType.Valid {
+		p.PropertyType = &propertyType.String
+	}
+	if lat.Valid {
+		p.Latitude = &lat.Float64
+	}
+	if lon.Valid {
+		p.Longitude = &lon.Float64
+	}
+	p.LastValueEstimate = nullInt64Ptr(lastValueEstimate)
+	p.LastRentEstimate = nullInt64Ptr(lastRentEstimate)
+	if lastUpdated.Valid {
+		p.LastUpdated = &lastUpdated.String
+	}
+	p.ValuationProvider = valuationProvider.String
+	p.Bedrooms = nullIntPtr(bedrooms)
+	if bathrooms.Valid {
+		p.Bathrooms = &bathrooms.Float64
 	}
+	p.SquareFootage = nullIntPtr(squareFootage)
+	p.LotSize = nullIntPtr(lotSize)
+	p.YearBuilt = nullIntPtr(yearBuilt)
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating accounts: %w", err)
+	return &p, nil
+}
+
+// nullIntPtr returns a pointer to the value, or nil if it's NULL
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
 	}
+	n := int(value.Int64)
+	return &n
+}
 
-	return accounts, nil
+// nullInt64Ptr returns a pointer to the value, or nil if it's NULL
+func nullInt64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
 }
 
-func (db *DB) UpdateAccountBalance(accountID string, balance int) error {
-	_, err := db.conn.Exec(`
-		UPDATE accounts
-		SET balance = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		balance, accountID)
+func (db *DB) GetProperty(accountID string) (*Property, error) {
+	p, err := scanProperty(db.conn.QueryRow(`SELECT `+propertyColumns+` FROM properties WHERE account_id = ?`, accountID))
 	if err != nil {
-		return fmt.Errorf("failed to update account balance: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("property not found for account: %s", accountID)
+		}
+		return nil, fmt.Errorf("failed to get property: %w", err)
 	}
-	return nil
+
+	return p, nil
 }
 
-func (db *DB) SetAccountType(accountID, accountType string) error {
-	// Validate account type
-	validTypes := []string{"checking", "savings", "credit", "investment", "loan", "property", "other"}
-	isValid := false
-	for _, validType := range validTypes {
-		if accountType == validType {
-			isValid = true
-			break
-		}
+func (db *DB) UpdatePropertyValuation(accountID string, valueEstimate, rentEstimate *int64) error {
+	var valueVal, rentVal sql.NullInt64
+	if valueEstimate != nil {
+		valueVal = sql.NullInt64{Int64: int64(*valueEstimate), Valid: true}
 	}
-	if !isValid {
-		return fmt.Errorf("invalid account type: %s. Valid types are: %v", accountType, validTypes)
+	if rentEstimate != nil {
+		rentVal = sql.NullInt64{Int64: int64(*rentEstimate), Valid: true}
 	}
 
 	_, err := db.conn.Exec(`
-		UPDATE accounts 
-		SET account_type = ?, updated_at = CURRENT_TIMESTAMP 
-		WHERE id = ?`,
-		accountType, accountID)
+		UPDATE properties
+		SET last_value_estimate = ?, last_rent_estimate = ?, last_updated = CURRENT_TIMESTAMP
+		WHERE account_id = ?`,
+		valueVal, rentVal, accountID)
 	if err != nil {
-		return fmt.Errorf("failed to set account type: %w", err)
+		return fmt.Errorf("failed to update property valuation: %w", err)
 	}
 	return nil
 }
 
-func (db *DB) ClearAccountType(accountID string) error {
+// LinkMortgage records that a loan is secured by a property. A loan can only
+// be linked to one property, so linking it again moves it.
+func (db *DB) LinkMortgage(propertyAccountID, loanAccountID string) error {
 	_, err := db.conn.Exec(`
-		UPDATE accounts
-		SET account_type = NULL, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		accountID)
+		INSERT INTO property_mortgages (loan_account_id, property_account_id)
+		VALUES (?, ?)
+		ON CONFLICT(loan_account_id) DO UPDATE SET
+			property_account_id = excluded.property_account_id`,
+		loanAccountID, propertyAccountID)
 	if err != nil {
-		return fmt.Errorf("failed to clear account type: %w", err)
+		return fmt.Errorf("failed to link mortgage: %w", err)
 	}
 	return nil
 }
 
-func (db *DB) SetAccountNickname(accountID, nickname string) error {
-	_, err := db.conn.Exec(`
-		UPDATE accounts
-		SET nickname = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		nickname, accountID)
+// UnlinkMortgage removes a loan's link to its property
+func (db *DB) UnlinkMortgage(loanAccountID string) error {
+	result, err := db.conn.Exec(`DELETE FROM property_mortgages WHERE loan_account_id = ?`, loanAccountID)
 	if err != nil {
-		return fmt.Errorf("failed to set account nickname: %w", err)
+		return fmt.Errorf("failed to unlink mortgage: %w", err)
 	}
-	return nil
-}
 
-func (db *DB) ClearAccountNickname(accountID string) error {
-	_, err := db.conn.Exec(`
-		UPDATE accounts
-		SET nickname = NULL, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		accountID)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to clear account nickname: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	return nil
-}
 
-func (db *DB) GetAccountByID(accountID string) (*Account, error) {
-	query := `
-		SELECT a.id, a.org_id, a.name, a.nickname, a.currency, a.balance, a.available_balance, a.balance_date, a.account_type
-		FROM accounts a
-		WHERE a.id = ?`
+	if rowsAffected == 0 {
+		return fmt.Errorf("loan is not linked to a property: %s", loanAccountID)
+	}
 
-	var account Account
-	var nickname sql.NullString
-	var availableBalance sql.NullInt64
-	var balanceDate sql.NullString
-	var accountType sql.NullString
+	return nil
+}
 
-	err := db.conn.QueryRow(query, accountID).Scan(
-		&account.ID,
-		&account.OrgID,
-		&account.Name,
-		&nickname,
-		&account.Currency,
-		&account.Balance,
-		&availableBalance,
-		&balanceDate,
-		&accountType,
-	)
+// GetMortgageLinks returns the loan account IDs linked to each property,
+// keyed by property account ID
+func (db *DB) GetMortgageLinks() (map[string][]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT property_account_id, loan_account_id
+		FROM property_mortgages
+		ORDER BY property_account_id, loan_account_id`)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("account not found: %s", accountID)
-		}
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to query mortgage links: %w", err)
 	}
+	defer rows.Close()
 
-	// Handle nullable fields
-	if nickname.Valid {
-		account.Nickname = &nickname.String
-	}
-	if availableBalance.Valid {
-		balance := int(availableBalance.Int64)
-		account.AvailableBalance = &balance
-	}
-	if balanceDate.Valid {
-		account.BalanceDate = &balanceDate.String
+	links := make(map[string][]string)
+	for rows.Next() {
+		var propertyAccountID, loanAccountID string
+		if err := rows.Scan(&propertyAccountID, &loanAccountID); err != nil {
+			return nil, fmt.Errorf("failed to scan mortgage link: %w", err)
+		}
+		links[propertyAccountID] = append(links[propertyAccountID], loanAccountID)
 	}
-	if accountType.Valid {
-		account.AccountType = &accountType.String
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mortgage links: %w", err)
 	}
 
-	return &account, nil
+	return links, nil
 }
 
-// DeleteAccount deletes an account and all associated data
-func (db *DB) DeleteAccount(accountID string) error {
-	// Start a transaction to ensure data consistency
+// TagPropertyTransactions tags transactions to a property, as its rental
+// income or expenses. Tagging a transaction again moves it to the new property.
+func (db *DB) TagPropertyTransactions(propertyAccountID string, transactionIDs []string) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Delete balance history
-	_, err = tx.Exec("DELETE FROM balance_history WHERE account_id = ?", accountID)
-	if err != nil {
-		return fmt.Errorf("failed to delete balance history: %w", err)
+	for _, transactionID := range transactionIDs {
+		result, err := tx.Exec(`
+			INSERT INTO property_transactions (transaction_id, property_account_id)
+			SELECT id, ? FROM transactions WHERE id = ?
+			ON CONFLICT(transaction_id) DO UPDATE SET
+				property_account_id = excluded.property_account_id`,
+			propertyAccountID, transactionID)
+		if err != nil {
+			return fmt.Errorf("failed to tag transaction: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("transaction not found: %s", transactionID)
+		}
 	}
 
-	// Delete transactions
-	_, err = tx.Exec("DELETE FROM transactions WHERE account_id = ?", accountID)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction tags: %w", err)
+	}
+	return nil
+}
+
+// UntagPropertyTransaction removes a transaction's property tag
+func (db *DB) UntagPropertyTransaction(transactionID string) error {
+	result, err := db.conn.Exec(`DELETE FROM property_transactions WHERE transaction_id = ?`, transactionID)
 	if err != nil {
-		return fmt.Errorf("failed to delete transactions: %w", err)
+		return fmt.Errorf("failed to untag transaction: %w", err)
 	}
 
-	// Delete property details if it's a property account
-	_, err = tx.Exec("DELETE FROM properties WHERE account_id = ?", accountID)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to delete property details: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	// Delete the account itself
-	result, err := tx.Exec("DELETE FROM accounts WHERE id = ?", accountID)
+	if rowsAffected == 0 {
+		return fmt.Errorf("transaction is not tagged to a property: %s", transactionID)
+	}
+
+	return nil
+}
+
+// PropertyRecord is a property's public record details
+type PropertyRecord struct {
+	PropertyType  *string
+	Bedrooms      *int
+	Bathrooms     *float64
+	SquareFootage *int
+	LotSize       *int
+	YearBuilt     *int
+}
+
+// UpdatePropertyRecord stores a property's public record details. The
+// property type is only filled in when it wasn't set when the property was
+// added.
+func (db *DB) UpdatePropertyRecord(accountID string, record PropertyRecord) error {
+	result, err := db.conn.Exec(`
+		UPDATE properties
+		SET property_type = COALESCE(property_type, ?),
+		    bedrooms = ?, bathrooms = ?, square_footage = ?, lot_size = ?, year_built = ?
+		WHERE account_id = ?`,
+		record.PropertyType, record.Bedrooms, record.Bathrooms, record.SquareFootage, record.LotSize, record.YearBuilt, accountID)
 	if err != nil {
-		return fmt.Errorf("failed to delete account: %w", err)
+		return fmt.Errorf("failed to update property record: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
 	if rowsAffected == 0 {
-		return fmt.Errorf("account not found: %s", accountID)
+		return fmt.Errorf("property not found for account: %s", accountID)
 	}
+	return nil
+}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit account deletion: %w", err)
+// SetPropertyValuationProvider chooses where a property's valuations come
+// from. An empty provider goes back to the default.
+func (db *DB) SetPropertyValuationProvider(accountID, provider string) error {
+	var providerVal sql.NullString
+	if provider != "" {
+		providerVal = sql.NullString{String: provider, Valid: true}
 	}
 
+	result, err := db.conn.Exec(`UPDATE properties SET valuation_provider = ? WHERE account_id = ?`, providerVal, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to set valuation provider: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("property not found: %s", accountID)
+	}
 	return nil
 }
 
-func (db *DB) SaveTransaction(id, accountID, posted string, amount int, description string, pending bool) error {
-	// Use INSERT OR IGNORE to avoid duplicate transactions
-	// If the transaction already exists, we don't update it to preserve any manual categorization
-	_, err := db.conn.Exec(`
-		INSERT OR IGNORE INTO transactions (id, account_id, posted, amount, description, pending)
-		VALUES (?, ?, ?, ?, ?, ?)`,
-		id, accountID, posted, amount, description, pending)
-	if err != nil {
-		return fmt.Errorf("failed to save transaction: %w", err)
+func (db *DB) GetAllProperties() ([]Property, error) {
+	rows, err := db.conn.Query(`SELECT ` + propertyColumns + ` FROM properties ORDER BY address`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query properties: %w", err)
+	}
+	defer rows.Close()
+
+	var properties []Property
+	for rows.Next() {
+		p, err := scanProperty(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan property: %w", err)
+		}
+		properties = append(properties, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating properties: %w", err)
+	}
+
+	return properties, nil
+}
+
+// Data types
+type Account struct {
+	ID               string
+	OrgID            string
+	Name             string
+	Nickname         *string
+	Currency         string
+	Balance          int64
+	AvailableBalance *int64
+	BalanceDate      *string
+	AccountType      *string
+	Owner            *string // who the account belongs to, like "me" or "joint"
+}
+
+// DisplayName returns the nickname if set, otherwise returns the original name
+func (a *Account) DisplayName() string {
+	if a.Nickname != nil && *a.Nickname != "" {
+		return *a.Nickname
+	}
+	return a.Name
+}
+
+// StaleAccount is a linked account that stopped updating, likely because
+// its bank connection broke
+type StaleAccount struct {
+	Account
+	LastUpdate time.Time // UTC
+}
+
+type BalanceHistory struct {
+	ID               int
+	AccountID        string
+	Balance          int64
+	AvailableBalance *int64
+	RecordedAt       string
+}
+
+type Transaction struct {
+	ID               string
+	AccountID        string
+	Posted           string
+	Amount           int64
+	Description      string  // raw description from the bank, used for matching
+	CleanDescription *string // set by rename rules
+	Pending          bool
+	CategoryID       *int
+}
+
+// DisplayDescription returns the rename-rule description if set, otherwise
+// the raw bank description
+func (t *Transaction) DisplayDescription() string {
+	if t.CleanDescription != nil && *t.CleanDescription != "" {
+		return *t.CleanDescription
 	}
-	return nil
+	return t.Description
 }
 
-func (db *DB) GetTransactions(accountID string, startDate, endDate string) ([]Transaction, error) {
-	var query string
-	var args []interface{}
+// RenameRule rewrites descriptions matching Pattern (a regular expression)
+// to Replacement, which may reference capture groups as $1
+type RenameRule struct {
+	ID          int
+	Pattern     string
+	Replacement string
+}
 
-	if accountID != "" {
-		if startDate != "" && endDate != "" {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
-				FROM transactions t
-				WHERE t.account_id = ? AND t.posted >= ? AND t.posted <= ?
-				ORDER BY t.posted DESC`
-			args = []interface{}{accountID, startDate, endDate}
-		} else {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
-				FROM transactions t
-				WHERE t.account_id = ?
-				ORDER BY t.posted DESC`
-			args = []interface{}{accountID}
-		}
-	} else {
-		if startDate != "" && endDate != "" {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
-				FROM transactions t
-				WHERE t.posted >= ? AND t.posted <= ?
-				ORDER BY t.posted DESC`
-			args = []interface{}{startDate, endDate}
-		} else {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
-				FROM transactions t
-				ORDER BY t.posted DESC`
-			args = []interface{}{}
-		}
-	}
+// TransactionEdit is one change in a transaction's audit trail. Amounts are
+// recorded in cents.
+type TransactionEdit struct {
+	Field    string
+	OldValue string
+	NewValue string
+	EditedAt string
+}
 
-	rows, err := db.conn.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %w", err)
-	}
-	defer rows.Close()
+type Organization struct {
+	ID   string
+	Name string
+	URL  *string
+}
 
-	var transactions []Transaction
-	for rows.Next() {
-		var t Transaction
-		var categoryID sql.NullInt64
+type Category struct {
+	ID         int
+	Name       string
+	IsInternal bool
+	// Color and Emoji say how the category is drawn; empty means the
+	// default. Color is a color name or #rrggbb.
+	Color string
+	Emoji string
+}
 
-		err := rows.Scan(
-			&t.ID,
-			&t.AccountID,
-			&t.Posted,
-			&t.Amount,
-			&t.Description,
-			&t.Pending,
-			&categoryID,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
+// Budget is a monthly spending target for a category, in cents
+type Budget struct {
+	CategoryID   int
+	CategoryName string
+	Amount       int64
+}
 
-		if categoryID.Valid {
-			catID := int(categoryID.Int64)
-			t.CategoryID = &catID
-		}
+// TaxMapping maps a category to the tax form line it's reported on
+type TaxMapping struct {
+	CategoryID   int
+	CategoryName string
+	TaxLine      string
+}
 
-		transactions = append(transactions, t)
-	}
+// ClosedMonth is a month locked by money close, with the snapshot it was
+// closed with
+type ClosedMonth struct {
+	Month      string // YYYY-MM
+	ClosedAt   string
+	Categories []ClosedCategoryTotal
+	Balances   []ClosedBalance
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transactions: %w", err)
-	}
+// ClosedCategoryTotal is a category's income and spending in a closed
+// month, in cents. Expenses are positive.
+type ClosedCategoryTotal struct {
+	Category string
+	Income   int64
+	Expenses int64
+}
 
-	return transactions, nil
+// ClosedBalance is an account's balance at the end of a closed month, in
+// cents
+type ClosedBalance struct {
+	AccountID string
+	Balance   int64
 }
 
-func (db *DB) GetUncategorizedTransactions() ([]Transaction, error) {
-	query := `
-		SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending, t.category_id
-		FROM transactions t
-		WHERE t.category_id IS NULL
-		ORDER BY t.posted DESC`
+// Bill is a recurring monthly payment
+type Bill struct {
+	ID        int
+	Name      string
+	Amount    int64 // cents
+	DueDay    int   // day of the month, clamped to the month's last day
+	AccountID string
+}
 
-	rows, err := db.conn.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query uncategorized transactions: %w", err)
-	}
-	defer rows.Close()
+// LLMCall is a logged prompt and response from the LLM command
+type LLMCall struct {
+	ID             int
+	Purpose        string
+	Command        string
+	Prompt         string
+	Response       string
+	Error          string // empty if the call succeeded
+	LatencyMs      int
+	PromptTokens   int // estimated from the prompt length
+	ResponseTokens int
+	CreatedAt      string
+}
 
-	var transactions []Transaction
-	for rows.Next() {
-		var t Transaction
-		var categoryID sql.NullInt64
+// OrphanedReference is a foreign key column whose rows point at rows
+// missing from the table it references
+type OrphanedReference struct {
+	Table         string
+	Column        string
+	Parent        string
+	ParentColumn  string
+	Rows          int      // rows with a missing reference
+	MissingValues int      // distinct values missing from the parent
+	Missing       []string // the first few missing values, most referenced first
+}
 
-		err := rows.Scan(
-			&t.ID,
-			&t.AccountID,
-			&t.Posted,
-			&t.Amount,
-			&t.Description,
-			&t.Pending,
-			&categoryID,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan uncategorized transaction: %w", err)
-		}
+// TableSize is how much of the database a table takes. Bytes include the
+// table's indexes, and are -1 when unknown.
+type TableSize struct {
+	Name  string
+	Rows  int
+	Bytes int64
+}
 
-		transactions = append(transactions, t)
-	}
+// MaintenanceStep is one step of Maintain and how long it took
+type MaintenanceStep struct {
+	Name     string
+	Duration time.Duration
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating uncategorized transactions: %w", err)
-	}
+// QueryResult holds the columns and rows of a read-only query, as text
+type QueryResult struct {
+	Columns   []string
+	Rows      [][]string
+	Truncated bool // more rows were available than requested
+}
 
-	return transactions, nil
+// Asset is a vehicle or other depreciating asset, tracked as an account of
+// type other. Amounts are in cents.
+type Asset struct {
+	AccountID        string
+	Kind             string // vehicle, equipment, or other
+	PurchasePrice    int64
+	PurchaseDate     string // YYYY-MM-DD
+	SalvageValue     int64
+	UsefulLifeMonths *int // nil when the value is only set by hand
 }
 
-func (db *DB) UpdateTransactionCategory(transactionID string, categoryID int) error {
-	_, err := db.conn.Exec(`
-		UPDATE transactions
-		SET category_id = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		categoryID, transactionID)
-	if err != nil {
-		return fmt.Errorf("failed to update transaction category: %w", err)
-	}
-	return nil
+// CryptoWallet is where a crypto account reads its balances from: a public
+// address for blockchain sources, or API credentials for exchanges
+type CryptoWallet struct {
+	AccountID   string
+	Source      string // bitcoin, ethereum, or kraken
+	Address     string
+	APIKey      string
+	APISecret   string
+	LastUpdated *string
 }
 
-func (db *DB) ClearTransactionCategory(transactionID string) error {
-	_, err := db.conn.Exec(`
-		UPDATE transactions
-		SET category_id = NULL, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		transactionID)
-	if err != nil {
-		return fmt.Errorf("failed to clear transaction category: %w", err)
-	}
-	return nil
+// Holding is a security held in an investment account. Amounts are in cents.
+type Holding struct {
+	ID             string
+	AccountID      string
+	Symbol         string // empty when the institution doesn't report a ticker
+	Description    string
+	Shares         float64
+	Currency       string
+	MarketValue    int64
+	CostBasis      *int64
+	Acquired       string  // YYYY-MM-DD, empty when not reported
+	PriceUpdatedAt *string // nil while MarketValue is the one SimpleFIN reported
 }
 
-func (db *DB) TransactionExists(id string) (bool, error) {
-	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM transactions WHERE id = ?", id).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("failed to check transaction existence: %w", err)
-	}
-	return count > 0, nil
+// Lot is a purchase of shares entered by hand, for gains tracking. Amounts
+// are in cents and cover all of the lot's shares.
+type Lot struct {
+	ID        int
+	AccountID string
+	Symbol    string
+	Shares    float64
+	CostBasis int64
+	Acquired  string  // YYYY-MM-DD
+	Sold      *string // YYYY-MM-DD, nil while held
+	Proceeds  *int64  // nil while held
 }
 
-func (db *DB) SaveCategory(name string) (int, error) {
-	return db.SaveCategoryWithInternal(name, false)
+type Property struct {
+	ID                int
+	AccountID         string
+	Address           string
+	City              string
+	State             string
+	ZipCode           string
+	PropertyType      *string
+	Latitude          *float64
+	Longitude         *float64
+	LastValueEstimate *int64
+	LastRentEstimate  *int64
+	LastUpdated       *string
+	ValuationProvider string // empty for the default provider
+
+	// Public record details, filled in by RentCast's property records
+	Bedrooms      *int
+	Bathrooms     *float64
+	SquareFootage *int
+	LotSize       *int // square feet
+	YearBuilt     *int
 }
 
-func (db *DB) SaveCategoryWithInternal(name string, isInternal bool) (int, error) {
-	// Use INSERT OR IGNORE to avoid duplicate categories, then get the ID
+// SaveAsset stores an asset's purchase and depreciation details, replacing
+// any existing details for the account
+func (db *DB) SaveAsset(asset Asset) error {
 	_, err := db.conn.Exec(`
-		INSERT OR IGNORE INTO categories (name, is_internal)
-		VALUES (?, ?)`,
-		name, isInternal)
+		INSERT INTO assets (account_id, kind, purchase_price, purchase_date, salvage_value, useful_life_months)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			kind = excluded.kind,
+			purchase_price = excluded.purchase_price,
+			purchase_date = excluded.purchase_date,
+			salvage_value = excluded.salvage_value,
+			useful_life_months = excluded.useful_life_months`,
+		asset.AccountID, asset.Kind, asset.PurchasePrice, asset.PurchaseDate, asset.SalvageValue, asset.UsefulLifeMonths)
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert category: %w", err)
+		return fmt.Errorf("failed to save asset: %w", err)
 	}
+	return nil
+}
 
-	// Get the category ID
-	var id int
-	err = db.conn.QueryRow(`
-		SELECT id FROM categories
-		WHERE name = ?`,
-		name).Scan(&id)
+// GetAsset returns an asset's details
+func (db *DB) GetAsset(accountID string) (*Asset, error) {
+	asset, err := scanAsset(db.conn.QueryRow(`SELECT `+assetColumns+` FROM assets WHERE account_id = ?`, accountID))
 	if err != nil {
-		return 0, fmt.Errorf("failed to get category ID: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("asset not found for account: %s", accountID)
+		}
+		return nil, fmt.Errorf("failed to get asset: %w", err)
 	}
-
-	return id, nil
+	return asset, nil
 }
 
-func (db *DB) GetCategories() ([]Category, error) {
-	query := `
-		SELECT id, name, COALESCE(is_internal, FALSE)
-		FROM categories
-		ORDER BY name`
-
-	rows, err := db.conn.Query(query)
+// GetAllAssets returns every asset, ordered by account ID
+func (db *DB) GetAllAssets() ([]Asset, error) {
+	rows, err := db.conn.Query(`SELECT ` + assetColumns + ` FROM assets ORDER BY account_id`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query categories: %w", err)
+		return nil, fmt.Errorf("failed to query assets: %w", err)
 	}
 	defer rows.Close()
 
-	var categories []Category
+	var assets []Asset
 	for rows.Next() {
-		var c Category
-		err := rows.Scan(&c.ID, &c.Name, &c.IsInternal)
+		asset, err := scanAsset(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan category: %w", err)
+			return nil, fmt.Errorf("failed to scan asset: %w", err)
 		}
-		categories = append(categories, c)
+		assets = append(assets, *asset)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating categories: %w", err)
+		return nil, fmt.Errorf("error iterating assets: %w", err)
 	}
 
-	return categories, nil
+	return assets, nil
 }
 
-func (db *DB) GetCategoryByID(categoryID int) (*Category, error) {
-	var c Category
-	err := db.conn.QueryRow(`
-		SELECT id, name, COALESCE(is_internal, FALSE)
-		FROM categories
-		WHERE id = ?`,
-		categoryID).Scan(&c.ID, &c.Name, &c.IsInternal)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("category not found: %d", categoryID)
-		}
-		return nil, fmt.Errorf("failed to get category: %w", err)
-	}
-	return &c, nil
-}
+// assetColumns are the assets columns read by scanAsset, in order
+const assetColumns = `account_id, kind, purchase_price, purchase_date, salvage_value, useful_life_months`
 
-func (db *DB) DeleteCategory(name string) error {
-	// Check if category is used by any transactions
-	var count int
-	err := db.conn.QueryRow(`
-		SELECT COUNT(*) FROM transactions
-		WHERE category_id = (SELECT id FROM categories WHERE name = ?)`,
-		name).Scan(&count)
+func scanAsset(row interface{ Scan(...interface{}) error }) (*Asset, error) {
+	var asset Asset
+	var usefulLife sql.NullInt64
+	err := row.Scan(&asset.AccountID, &asset.Kind, &asset.PurchasePrice, &asset.PurchaseDate, &asset.SalvageValue, &usefulLife)
 	if err != nil {
-		return fmt.Errorf("failed to check category usage: %w", err)
-	}
-
-	if count > 0 {
-		return fmt.Errorf("cannot delete category '%s': it is used by %d transactions", name, count)
+		return nil, err
 	}
+	asset.UsefulLifeMonths = nullIntPtr(usefulLife)
+	return &asset, nil
+}
 
-	// Delete the category
-	result, err := db.conn.Exec(`DELETE FROM categories WHERE name = ?`, name)
+// SaveHoldings replaces an account's holdings with the ones reported by
+// SimpleFIN. Holdings no longer reported are removed, and reported values
+// replace any refreshed from quotes.
+func (db *DB) SaveHoldings(accountID string, holdings []Holding) error {
+	tx, err := db.conn.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete category: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	_, err = tx.Exec("DELETE FROM holdings WHERE account_id = ?", accountID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("category not found: %s", name)
-	}
-
-	return nil
-}
-
-func (db *DB) SeedDefaultCategories() error {
-	// Regular categories
-	defaultCategories := []string{
-		"Housing",
-		"Transportation",
-		"Groceries",
-		"Dining Out",
-		"Healthcare",
-		"Shopping",
-		"Entertainment",
-		"Bills & Services",
-		"Personal Care",
-		"Travel",
-		"Fees",
-		"Projects",
-		"Subscriptions",
-		"Income",
-		"Other",
-	}
-
-	// Internal categories (excluded from budget calculations)
-	internalCategories := []string{
-		"Transfers",
+		return fmt.Errorf("failed to clear holdings: %w", err)
 	}
 
-	// Seed regular categories
-	for _, categoryName := range defaultCategories {
-		_, err := db.SaveCategory(categoryName)
+	for _, holding := range holdings {
+		_, err = tx.Exec(`
+			INSERT INTO holdings (id, account_id, symbol, description, shares, currency, market_value, cost_basis, acquired)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				account_id = excluded.account_id,
+				symbol = excluded.symbol,
+				description = excluded.description,
+				shares = excluded.shares,
+				currency = excluded.currency,
+				market_value = excluded.market_value,
+				cost_basis = excluded.cost_basis,
+				acquired = excluded.acquired,
+				price_updated_at = NULL,
+				updated_at = CURRENT_TIMESTAMP`,
+			holding.ID, accountID, holding.Symbol, holding.Description, holding.Shares,
+			holding.Currency, holding.MarketValue, holding.CostBasis, holding.Acquired)
 		if err != nil {
-			return fmt.Errorf("failed to seed category '%s': %w", categoryName, err)
+			return fmt.Errorf("failed to save holding %s: %w", holding.ID, err)
 		}
 	}
 
-	// Seed internal categories
-	for _, categoryName := range internalCategories {
-		_, err := db.SaveCategoryWithInternal(categoryName, true)
-		if err != nil {
-			return fmt.Errorf("failed to seed internal category '%s': %w", categoryName, err)
-		}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit holdings: %w", err)
 	}
-
 	return nil
 }
 
-func (db *DB) SetCategoryInternal(categoryID int, isInternal bool) error {
-	result, err := db.conn.Exec(`
-		UPDATE categories
-		SET is_internal = ?
-		WHERE id = ?`,
-		isInternal, categoryID)
+// GetHoldings returns the holdings of one account, or of every account when
+// accountID is empty, ordered by account and then by value, largest first
+func (db *DB) GetHoldings(accountID string) ([]Holding, error) {
+	query := `SELECT ` + holdingColumns + ` FROM holdings`
+	var args []interface{}
+	if accountID != "" {
+		query += ` WHERE account_id = ?`
+		args = append(args, accountID)
+	}
+	query += ` ORDER BY account_id, market_value DESC, id`
+
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to set category internal flag: %w", err)
+		return nil, fmt.Errorf("failed to query holdings: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	var holdings []Holding
+	for rows.Next() {
+		var holding Holding
+		var costBasis sql.NullInt64
+		var priceUpdatedAt sql.NullString
+		err := rows.Scan(&holding.ID, &holding.AccountID, &holding.Symbol, &holding.Description, &holding.Shares,
+			&holding.Currency, &holding.MarketValue, &costBasis, &holding.Acquired, &priceUpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan holding: %w", err)
+		}
+		holding.CostBasis = nullInt64Ptr(costBasis)
+		if priceUpdatedAt.Valid {
+			holding.PriceUpdatedAt = &priceUpdatedAt.String
+		}
+		holdings = append(holdings, holding)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("category not found: %d", categoryID)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating holdings: %w", err)
 	}
 
-	return nil
+	return holdings, nil
 }
 
-func (db *DB) SetCategoryInternalByName(categoryName string, isInternal bool) error {
+// holdingColumns are the holdings columns read by GetHoldings, in order
+const holdingColumns = `id, account_id, symbol, description, shares, currency, market_value, cost_basis, acquired, price_updated_at`
+
+// UpdateHoldingValue records a holding's market value refreshed from a quote
+func (db *DB) UpdateHoldingValue(holdingID string, marketValue int64) error {
 	result, err := db.conn.Exec(`
-		UPDATE categories
-		SET is_internal = ?
-		WHERE name = ?`,
-		isInternal, categoryName)
+		UPDATE holdings
+		SET market_value = ?, price_updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, marketValue, holdingID)
 	if err != nil {
-		return fmt.Errorf("failed to set category internal flag: %w", err)
+		return fmt.Errorf("failed to update holding value: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
 	if rowsAffected == 0 {
-		return fmt.Errorf("category not found: %s", categoryName)
+		return fmt.Errorf("holding not found: %s", holdingID)
 	}
-
 	return nil
 }
 
-func (db *DB) SaveBalanceHistory(accountID string, balance int, availableBalance *int) error {
-	var availableBalanceVal sql.NullInt64
-	if availableBalance != nil {
-		availableBalanceVal = sql.NullInt64{Int64: int64(*availableBalance), Valid: true}
+// AddLot stores a tax lot and returns its ID
+func (db *DB) AddLot(lot Lot) (int, error) {
+	result, err := db.conn.Exec(`
+		INSERT INTO lots (account_id, symbol, shares, cost_basis, acquired, sold, proceeds)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		lot.AccountID, lot.Symbol, lot.Shares, lot.CostBasis, lot.Acquired, lot.Sold, lot.Proceeds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add lot: %w", err)
 	}
 
-	_, err := db.conn.Exec(`
-		INSERT INTO balance_history (account_id, balance, available_balance, recorded_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
-		accountID, balance, availableBalanceVal)
+	id, err := result.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("failed to save balance history: %w", err)
+		return 0, fmt.Errorf("failed to get lot ID: %w", err)
 	}
-	return nil
+	return int(id), nil
 }
 
-func (db *DB) GetAllBalanceHistory(days int) ([]BalanceHistory, error) {
-	query := `
-		SELECT id, account_id, balance, available_balance, recorded_at
-		FROM balance_history
-		WHERE recorded_at >= datetime('now', '-' || ? || ' days')
-		ORDER BY recorded_at ASC`
+// GetLots returns the tax lots of one account, or of every account when
+// accountID is empty, ordered by account, symbol, and purchase date
+func (db *DB) GetLots(accountID string) ([]Lot, error) {
+	query := `SELECT ` + lotColumns + ` FROM lots`
+	var args []interface{}
+	if accountID != "" {
+		query += ` WHERE account_id = ?`
+		args = append(args, accountID)
+	}
+	query += ` ORDER BY account_id, symbol, acquired, id`
 
-	rows, err := db.conn.Query(query, days)
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query all balance history: %w", err)
+		return nil, fmt.Errorf("failed to query lots: %w", err)
 	}
 	defer rows.Close()
 
-	var history []BalanceHistory
+	var lots []Lot
 	for rows.Next() {
-		var bh BalanceHistory
-		var availableBalance sql.NullInt64
-
-		err := rows.Scan(&bh.ID, &bh.AccountID, &bh.Balance, &availableBalance, &bh.RecordedAt)
+		lot, err := scanLot(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan balance history: %w", err)
-		}
-
-		if availableBalance.Valid {
-			balance := int(availableBalance.Int64)
-			bh.AvailableBalance = &balance
+			return nil, fmt.Errorf("failed to scan lot: %w", err)
 		}
-
-		history = append(history, bh)
+		lots = append(lots, *lot)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating all balance history: %w", err)
+		return nil, fmt.Errorf("error iterating lots: %w", err)
 	}
 
-	return history, nil
+	return lots, nil
 }
 
-func (db *DB) GetTransactionsByCategory(startDate, endDate string, excludeInternal bool) (map[string][]Transaction, error) {
-	var query string
-	var args []interface{}
-
-	if excludeInternal {
-		if startDate != "" && endDate != "" {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending,
-				       t.category_id, c.name as category_name
-				FROM transactions t
-				LEFT JOIN categories c ON t.category_id = c.id
-				WHERE t.posted >= ? AND t.posted <= ? AND COALESCE(c.is_internal, FALSE) = FALSE
-				ORDER BY t.posted DESC`
-			args = []interface{}{startDate, endDate}
-		} else {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending,
-				       t.category_id, c.name as category_name
-				FROM transactions t
-				LEFT JOIN categories c ON t.category_id = c.id
-				WHERE COALESCE(c.is_internal, FALSE) = FALSE
-				ORDER BY t.posted DESC`
-			args = []interface{}{}
-		}
-	} else {
-		if startDate != "" && endDate != "" {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending,
-				       t.category_id, c.name as category_name
-				FROM transactions t
-				LEFT JOIN categories c ON t.category_id = c.id
-				WHERE t.posted >= ? AND t.posted <= ?
-				ORDER BY t.posted DESC`
-			args = []interface{}{startDate, endDate}
-		} else {
-			query = `
-				SELECT t.id, t.account_id, t.posted, t.amount, t.description, t.pending,
-				       t.category_id, c.name as category_name
-				FROM transactions t
-				LEFT JOIN categories c ON t.category_id = c.id
-				ORDER BY t.posted DESC`
-			args = []interface{}{}
+// GetLot returns a tax lot by ID
+func (db *DB) GetLot(id int) (*Lot, error) {
+	lot, err := scanLot(db.conn.QueryRow(`SELECT `+lotColumns+` FROM lots WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("lot not found: %d", id)
 		}
+		return nil, fmt.Errorf("failed to get lot: %w", err)
 	}
+	return lot, nil
+}
 
-	rows, err := db.conn.Query(query, args...)
+// SellLot records the sale of shares from a held lot for proceeds. Selling
+// part of a lot splits it: the sold shares become a new sold lot with their
+// share of the cost basis, and the rest stay held. It returns the sold lot's
+// ID.
+func (db *DB) SellLot(id int, shares float64, sold string, proceeds int64) (int, error) {
+	lot, err := db.GetLot(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions by category: %w", err)
+		return 0, err
+	}
+	if lot.Sold != nil {
+		return 0, fmt.Errorf("lot %d was already sold on %s", id, *lot.Sold)
+	}
+	if shares <= 0 || shares > lot.Shares {
+		return 0, fmt.Errorf("can only sell between 0 and %g shares of lot %d", lot.Shares, id)
 	}
-	defer rows.Close()
-
-	categoryTransactions := make(map[string][]Transaction)
 
-	for rows.Next() {
-		var t Transaction
-		var categoryID sql.NullInt64
-		var categoryName sql.NullString
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-		err := rows.Scan(
-			&t.ID,
-			&t.AccountID,
-			&t.Posted,
-			&t.Amount,
-			&t.Description,
-			&t.Pending,
-			&categoryID,
-			&categoryName,
-		)
+	soldID := id
+	if shares == lot.Shares {
+		_, err = tx.Exec(`UPDATE lots SET sold = ?, proceeds = ? WHERE id = ?`, sold, proceeds, id)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+			return 0, fmt.Errorf("failed to sell lot: %w", err)
 		}
-
-		if categoryID.Valid {
-			catID := int(categoryID.Int64)
-			t.CategoryID = &catID
+	} else {
+		soldBasis := int64(math.Round(float64(lot.CostBasis) * shares / lot.Shares))
+		_, err = tx.Exec(`UPDATE lots SET shares = ?, cost_basis = ? WHERE id = ?`,
+			lot.Shares-shares, lot.CostBasis-soldBasis, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to split lot: %w", err)
 		}
 
-		// Determine category name
-		var catName string
-		if categoryName.Valid {
-			catName = categoryName.String
-		} else {
-			catName = "Uncategorized"
+		result, err := tx.Exec(`
+			INSERT INTO lots (account_id, symbol, shares, cost_basis, acquired, sold, proceeds)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			lot.AccountID, lot.Symbol, shares, soldBasis, lot.Acquired, sold, proceeds)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add sold lot: %w", err)
 		}
-
-		categoryTransactions[catName] = append(categoryTransactions[catName], t)
+		newID, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get lot ID: %w", err)
+		}
+		soldID = int(newID)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit lot sale: %w", err)
 	}
-
-	return categoryTransactions, nil
+	return soldID, nil
 }
 
-func (db *DB) SaveProperty(accountID, address, city, state, zipCode string, propertyType *string, latitude, longitude *float64) error {
-	var latVal, lonVal sql.NullFloat64
-	var propTypeVal sql.NullString
-	if latitude != nil {
-		latVal = sql.NullFloat64{Float64: *latitude, Valid: true}
-	}
-	if longitude != nil {
-		lonVal = sql.NullFloat64{Float64: *longitude, Valid: true}
-	}
-	if propertyType != nil {
-		propTypeVal = sql.NullString{String: *propertyType, Valid: true}
+// DeleteLot removes a tax lot
+func (db *DB) DeleteLot(id int) error {
+	result, err := db.conn.Exec(`DELETE FROM lots WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete lot: %w", err)
 	}
 
-	_, err := db.conn.Exec(`
-		INSERT OR REPLACE INTO properties (account_id, address, city, state, zip_code, property_type, latitude, longitude)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		accountID, address, city, state, zipCode, propTypeVal, latVal, lonVal)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to save property: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("lot not found: %d", id)
 	}
 	return nil
 }
 
-func (db *DB) GetProperty(accountID string) (*Property, error) {
-	var p Property
-	var lat, lon sql.NullFloat64
-	var propertyType sql.NullString
-	var lastValueEstimate, lastRentEstimate sql.NullInt64
-	var lastUpdated sql.NullString
+// lotColumns are the lots columns read by scanLot, in order
+const lotColumns = `id, account_id, symbol, shares, cost_basis, acquired, sold, proceeds`
 
-	err := db.conn.QueryRow(`
-		SELECT account_id, address, city, state, zip_code, property_type, latitude, longitude,
-		       last_value_estimate, last_rent_estimate, last_updated
-		FROM properties
-		WHERE account_id = ?`,
-		accountID).Scan(
-		&p.AccountID, &p.Address, &p.City, &p.State, &p.ZipCode, &propertyType,
-		&lat, &lon, &lastValueEstimate, &lastRentEstimate, &lastUpdated)
+func scanLot(row interface{ Scan(...interface{}) error }) (*Lot, error) {
+	var lot Lot
+	var sold sql.NullString
+	var proceeds sql.NullInt64
+	err := row.Scan(&lot.ID, &lot.AccountID, &lot.Symbol, &lot.Shares, &lot.CostBasis, &lot.Acquired, &sold, &proceeds)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("property not found for account: %s", accountID)
-		}
-		return nil, fmt.Errorf("failed to get property: %w", err)
-	}
-
-	if propertyType.Valid {
-		p.PropertyType = &propertyType.String
-	}
-	if lat.Valid {
-		p.Latitude = &lat.Float64
-	}
-	if lon.Valid {
-		p.Longitude = &lon.Float64
-	}
-	if lastValueEstimate.Valid {
-		estimate := int(lastValueEstimate.Int64)
-		p.LastValueEstimate = &estimate
+		return nil, err
 	}
-	if lastRentEstimate.Valid {
-		estimate := int(lastRentEstimate.Int64)
-		p.LastRentEstimate = &estimate
-	}
-	if lastUpdated.Valid {
-		p.LastUpdated = &lastUpdated.String
+	if sold.Valid {
+		lot.Sold = &sold.String
 	}
-
-	return &p, nil
+	lot.Proceeds = nullInt64Ptr(proceeds)
+	return &lot, nil
 }
 
-func (db *DB) UpdatePropertyValuation(accountID string, valueEstimate, rentEstimate *int) error {
-	var valueVal, rentVal sql.NullInt64
-	if valueEstimate != nil {
-		valueVal = sql.NullInt64{Int64: int64(*valueEstimate), Valid: true}
-	}
-	if rentEstimate != nil {
-		rentVal = sql.NullInt64{Int64: int64(*rentEstimate), Valid: true}
-	}
-
+// SaveCryptoWallet stores where a crypto account reads its balances from,
+// replacing any existing wallet for the account
+func (db *DB) SaveCryptoWallet(wallet CryptoWallet) error {
 	_, err := db.conn.Exec(`
-		UPDATE properties
-		SET last_value_estimate = ?, last_rent_estimate = ?, last_updated = CURRENT_TIMESTAMP
-		WHERE account_id = ?`,
-		valueVal, rentVal, accountID)
+		INSERT INTO crypto_wallets (account_id, source, address, api_key, api_secret)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET
+			source = excluded.source,
+			address = excluded.address,
+			api_key = excluded.api_key,
+			api_secret = excluded.api_secret`,
+		wallet.AccountID, wallet.Source, wallet.Address, wallet.APIKey, wallet.APISecret)
 	if err != nil {
-		return fmt.Errorf("failed to update property valuation: %w", err)
+		return fmt.Errorf("failed to save crypto wallet: %w", err)
 	}
 	return nil
 }
 
-func (db *DB) GetAllProperties() ([]Property, error) {
-	query := `
-		SELECT account_id, address, city, state, zip_code, property_type, latitude, longitude,
-		       last_value_estimate, last_rent_estimate, last_updated
-		FROM properties
-		ORDER BY address`
-
-	rows, err := db.conn.Query(query)
+// GetCryptoWallets returns every crypto wallet, ordered by account ID
+func (db *DB) GetCryptoWallets() ([]CryptoWallet, error) {
+	rows, err := db.conn.Query(`
+		SELECT account_id, source, address, api_key, api_secret, last_updated
+		FROM crypto_wallets
+		ORDER BY account_id`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query properties: %w", err)
+		return nil, fmt.Errorf("failed to query crypto wallets: %w", err)
 	}
 	defer rows.Close()
 
-	var properties []Property
+	var wallets []CryptoWallet
 	for rows.Next() {
-		var p Property
-		var lat, lon sql.NullFloat64
-		var propertyType sql.NullString
-		var lastValueEstimate, lastRentEstimate sql.NullInt64
+		var wallet CryptoWallet
 		var lastUpdated sql.NullString
-
-		err := rows.Scan(
-			&p.AccountID, &p.Address, &p.City, &p.State, &p.ZipCode, &propertyType,
-			&lat, &lon, &lastValueEstimate, &lastRentEstimate, &lastUpdated)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan property: %w", err)
-		}
-
-		if propertyType.Valid {
-			p.PropertyType = &propertyType.String
-		}
-		if lat.Valid {
-			p.Latitude = &lat.Float64
-		}
-		if lon.Valid {
-			p.Longitude = &lon.Float64
-		}
-		if lastValueEstimate.Valid {
-			estimate := int(lastValueEstimate.Int64)
-			p.LastValueEstimate = &estimate
-		}
-		if lastRentEstimate.Valid {
-			estimate := int(lastRentEstimate.Int64)
-			p.LastRentEstimate = &estimate
+		if err := rows.Scan(&wallet.AccountID, &wallet.Source, &wallet.Address, &wallet.APIKey, &wallet.APISecret, &lastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan crypto wallet: %w", err)
 		}
 		if lastUpdated.Valid {
-			p.LastUpdated = &lastUpdated.String
+			wallet.LastUpdated = &lastUpdated.String
 		}
-
-		properties = append(properties, p)
+		wallets = append(wallets, wallet)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating properties: %w", err)
+		return nil, fmt.Errorf("error iterating crypto wallets: %w", err)
 	}
 
-	return properties, nil
-}
-
-// Data types
-type Account struct {
-	ID               string
-	OrgID            string
-	Name             string
-	Nickname         *string
-	Currency         string
-	Balance          int
-	AvailableBalance *int
-	BalanceDate      *string
-	AccountType      *string
+	return wallets, nil
 }
 
-// DisplayName returns the nickname if set, otherwise returns the original name
-func (a *Account) DisplayName() string {
-	if a.Nickname != nil && *a.Nickname != "" {
-		return *a.Nickname
+// MarkCryptoWalletUpdated records that a wallet's balances were just read
+func (db *DB) MarkCryptoWalletUpdated(accountID string) error {
+	_, err := db.conn.Exec(`UPDATE crypto_wallets SET last_updated = CURRENT_TIMESTAMP WHERE account_id = ?`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update crypto wallet: %w", err)
 	}
-	return a.Name
-}
-
-type BalanceHistory struct {
-	ID               int
-	AccountID        string
-	Balance          int
-	AvailableBalance *int
-	RecordedAt       string
-}
-
-type Transaction struct {
-	ID          string
-	AccountID   string
-	Posted      string
-	Amount      int
-	Description string
-	Pending     bool
-	CategoryID  *int
-}
-
-type Organization struct {
-	ID   string
-	Name string
-	URL  *string
-}
-
-type Category struct {
-	ID         int
-	Name       string
-	IsInternal bool
-}
-
-type Property struct {
-	ID                int
-	AccountID         string
-	Address           string
-	City              string
-	State             string
-	ZipCode           string
-	PropertyType      *string
-	Latitude          *float64
-	Longitude         *float64
-	LastValueEstimate *int
-	LastRentEstimate  *int
-	LastUpdated       *string
+	return nil
 }
 
 func (db *DB) GetCategorizedExamples(limit int) ([]Transaction, error) {
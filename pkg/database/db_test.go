@@ -1,11 +1,18 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/arjungandhi/money/pkg/config"
+	"github.com/arjungandhi/money/pkg/format"
 )
 
 func TestNew(t *testing.T) {
@@ -70,6 +77,121 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestOpenReadOnly(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_READONLY", "")
+
+	cfg := config.New()
+	cfg.ReadOnly = true
+	if _, err := Open(cfg); err == nil {
+		t.Fatal("Expected error opening a database that doesn't exist read-only")
+	}
+	if _, err := os.Stat(cfg.DBPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected read-only mode not to create the database, got %v", err)
+	}
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	db.Close()
+
+	db, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database read-only: %v", err)
+	}
+	defer db.Close()
+
+	orgs, err := db.GetOrganizations()
+	if err != nil || len(orgs) != 1 {
+		t.Errorf("Expected to read the organization, got %v (%v)", orgs, err)
+	}
+	if err := db.SaveOrganization("org-2", "Other Bank", ""); err == nil {
+		t.Error("Expected writes to a read-only database to fail")
+	}
+}
+
+func TestMigrationBackups(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	t.Setenv("MONEY_READONLY", "")
+	t.Setenv("MONEY_MIGRATION_BACKUPS", "2")
+	cfg := config.New()
+
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	db.Close()
+	if backups, _ := ListMigrationBackups(cfg); len(backups) != 0 {
+		t.Fatalf("Expected no backup of a new database, got %v", backups)
+	}
+
+	// Reopening a migrated database takes no backup
+	db, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if backups, _ := ListMigrationBackups(cfg); len(backups) != 0 {
+		t.Fatalf("Expected no backup of an up to date database, got %v", backups)
+	}
+
+	// An older schema version is backed up before migrating, and only the
+	// newest copies are kept
+	for _, stamp := range []string{"20200101-000000", "20210101-000000"} {
+		old := filepath.Join(cfg.BackupDir(), "money-pre-migration-"+stamp+".db")
+		os.MkdirAll(cfg.BackupDir(), 0700)
+		if err := os.WriteFile(old, []byte("old"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.conn.Exec("PRAGMA user_version = 1"); err != nil {
+		t.Fatalf("Failed to set schema version: %v", err)
+	}
+	if err := db.SaveOrganization("org-2", "Other Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	db.Close()
+
+	db, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	db.Close()
+	backups, err := ListMigrationBackups(cfg)
+	if err != nil {
+		t.Fatalf("ListMigrationBackups failed: %v", err)
+	}
+	if len(backups) != 2 || !strings.Contains(backups[1].Path, "20210101") {
+		t.Fatalf("Expected the new backup and the newest old one, got %v", backups)
+	}
+
+	// Restoring brings back the data as it was before the migration
+	db, _ = Open(cfg)
+	db.conn.Exec("DELETE FROM organizations WHERE id = ?", "org-2")
+	db.Close()
+	if err := RestoreBackup(cfg, backups[0].Path); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if _, err := os.Stat(cfg.DBPath() + ".before-rollback"); err != nil {
+		t.Errorf("Expected the replaced database to be kept: %v", err)
+	}
+	db, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer db.Close()
+	orgs, err := db.GetOrganizations()
+	if err != nil || len(orgs) != 2 {
+		t.Errorf("Expected both organizations after the rollback, got %v (%v)", orgs, err)
+	}
+}
+
 func TestGetMoneyDir(t *testing.T) {
 	testDir := "/test/money/dir"
 	oldMoneyDir := os.Getenv("MONEY_DIR")
@@ -288,3 +410,1941 @@ func TestAccountsAndOrganizations(t *testing.T) {
 		}
 	}
 }
+
+func TestLargeAmounts(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Past what a 32-bit int holds: $30 million in cents
+	const balance = int64(3000000000)
+	available := balance + 1
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Brokerage", "USD", balance, &available, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-1", "acc-1", "2024-01-01T00:00:00Z", -balance, "Wire", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	if err := db.SaveBalanceHistory("acc-1", balance, &available); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+
+	account, err := db.GetAccountByID("acc-1")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.Balance != balance || account.AvailableBalance == nil || *account.AvailableBalance != available {
+		t.Errorf("Expected balance %d and available %d, got %d and %v", balance, available, account.Balance, account.AvailableBalance)
+	}
+
+	transactions, err := db.GetTransactions(TransactionFilter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Amount != -balance {
+		t.Errorf("Expected one transaction of %d, got %+v", -balance, transactions)
+	}
+
+	history, err := db.GetAllBalanceHistory(30)
+	if err != nil {
+		t.Fatalf("Failed to get balance history: %v", err)
+	}
+	if len(history) != 1 || history[0].Balance != balance {
+		t.Errorf("Expected one snapshot of %d, got %+v", balance, history)
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	if err := db.SetBudget(groceriesID, 50000); err != nil {
+		t.Fatalf("Failed to set budget: %v", err)
+	}
+
+	// Setting again replaces the existing target
+	if err := db.SetBudget(groceriesID, 60000); err != nil {
+		t.Fatalf("Failed to update budget: %v", err)
+	}
+
+	budgets, err := db.GetBudgets()
+	if err != nil {
+		t.Fatalf("Failed to get budgets: %v", err)
+	}
+	if len(budgets) != 1 {
+		t.Fatalf("Expected 1 budget, got %d", len(budgets))
+	}
+	if budgets[0].CategoryName != "Groceries" || budgets[0].Amount != 60000 {
+		t.Errorf("Expected Groceries budget of 60000, got %s %d", budgets[0].CategoryName, budgets[0].Amount)
+	}
+
+	if err := db.ClearBudget(groceriesID); err != nil {
+		t.Fatalf("Failed to clear budget: %v", err)
+	}
+	if err := db.ClearBudget(groceriesID); err == nil {
+		t.Error("Expected error clearing a budget that is not set")
+	}
+
+	// Deleting a category removes its budget target
+	if err := db.SetBudget(groceriesID, 10000); err != nil {
+		t.Fatalf("Failed to set budget: %v", err)
+	}
+	if err := db.DeleteCategory("Groceries"); err != nil {
+		t.Fatalf("Failed to delete category: %v", err)
+	}
+	budgets, err = db.GetBudgets()
+	if err != nil {
+		t.Fatalf("Failed to get budgets: %v", err)
+	}
+	if len(budgets) != 0 {
+		t.Errorf("Expected no budgets after deleting category, got %d", len(budgets))
+	}
+}
+
+func TestTransactionPaging(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	transactions := []struct {
+		id          string
+		posted      string
+		amount      int64
+		description string
+	}{
+		{"tx-1", "2024-01-01T00:00:00Z", -1250, "COFFEE SHOP"},
+		{"tx-2", "2024-01-02T00:00:00Z", -8999, "GROCERY STORE"},
+		{"tx-3", "2024-01-03T00:00:00Z", 250000, "PAYROLL"},
+		{"tx-4", "2024-01-03T00:00:00Z", -4500, "Coffee Roasters"},
+		{"tx-5", "2024-01-05T00:00:00Z", -2000, "GAS STATION"},
+	}
+	for _, tx := range transactions {
+		if err := db.SaveTransaction(tx.id, "acc-1", tx.posted, tx.amount, tx.description, false); err != nil {
+			t.Fatalf("Failed to save transaction %s: %v", tx.id, err)
+		}
+	}
+
+	count, err := db.CountTransactions(TransactionFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != len(transactions) {
+		t.Errorf("Expected %d transactions, got %d", len(transactions), count)
+	}
+
+	// Newest first, ties broken by ID
+	expectedOrder := []string{"tx-5", "tx-3", "tx-4", "tx-2", "tx-1"}
+
+	var paged []string
+	for offset := 0; offset < count; offset += 2 {
+		page, err := db.GetTransactions(TransactionFilter{}, 2, offset)
+		if err != nil {
+			t.Fatalf("Failed to get page at offset %d: %v", offset, err)
+		}
+		for _, tx := range page {
+			paged = append(paged, tx.ID)
+		}
+	}
+	if len(paged) != len(expectedOrder) {
+		t.Fatalf("Expected %d paged transactions, got %d", len(expectedOrder), len(paged))
+	}
+	for i := range expectedOrder {
+		if paged[i] != expectedOrder[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expectedOrder[i], paged[i])
+		}
+	}
+
+	// Positions match the paging order
+	positions, err := db.FindTransactionPositions(TransactionFilter{}, "coffee")
+	if err != nil {
+		t.Fatalf("Failed to search transactions: %v", err)
+	}
+	if len(positions) != 2 || positions[0] != 2 || positions[1] != 4 {
+		t.Errorf("Expected coffee matches at positions [2 4], got %v", positions)
+	}
+
+	// Account names and amounts are searchable too
+	positions, err = db.FindTransactionPositions(TransactionFilter{}, "everyday")
+	if err != nil {
+		t.Fatalf("Failed to search transactions: %v", err)
+	}
+	if len(positions) != len(transactions) {
+		t.Errorf("Expected all transactions to match account name, got %v", positions)
+	}
+
+	positions, err = db.FindTransactionPositions(TransactionFilter{}, "89.99")
+	if err != nil {
+		t.Fatalf("Failed to search transactions: %v", err)
+	}
+	if len(positions) != 1 || positions[0] != 3 {
+		t.Errorf("Expected amount match at position [3], got %v", positions)
+	}
+
+	// Streaming visits the same transactions in the same order
+	var streamed []string
+	err = db.ForEachTransaction(TransactionFilter{}, func(tx Transaction) error {
+		streamed = append(streamed, tx.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream transactions: %v", err)
+	}
+	if strings.Join(streamed, ",") != strings.Join(expectedOrder, ",") {
+		t.Errorf("Expected streamed order %v, got %v", expectedOrder, streamed)
+	}
+
+	var total int64
+	err = db.ForEachTransaction(TransactionFilter{AmountSign: -1}, func(tx Transaction) error {
+		total += tx.Amount
+		return nil
+	})
+	if err != nil || total != -1250-8999-4500-2000 {
+		t.Errorf("Expected expenses to total %d, got %d, %v", -1250-8999-4500-2000, total, err)
+	}
+
+	// An error from the callback stops the iteration and is returned as is
+	errStop := errors.New("stop")
+	visited := 0
+	err = db.ForEachTransaction(TransactionFilter{}, func(tx Transaction) error {
+		visited++
+		if visited == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || visited != 2 {
+		t.Errorf("Expected to stop after 2 transactions with the callback's error, got %d, %v", visited, err)
+	}
+}
+
+func TestTransactionFilter(t *testing.T) {
+	// Dates are in UTC here; TestTransactionFilterTimezone covers other zones
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if err := db.SaveAccount(id, "org-1", id, "USD", 0, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+
+	transactions := []struct {
+		id        string
+		accountID string
+		posted    string
+		amount    int64
+		pending   bool
+	}{
+		{"tx-1", "acc-1", "2024-01-01T08:00:00Z", -1000, false},
+		{"tx-2", "acc-1", "2024-01-31T18:30:00Z", 5000, false},
+		{"tx-3", "acc-2", "2024-02-01T00:00:00Z", -2000, true},
+		{"tx-4", "acc-2", "2024-02-10T00:00:00Z", -3000, false},
+	}
+	for _, tx := range transactions {
+		if err := db.SaveTransaction(tx.id, tx.accountID, tx.posted, tx.amount, tx.id, tx.pending); err != nil {
+			t.Fatalf("Failed to save transaction %s: %v", tx.id, err)
+		}
+	}
+
+	categoryID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	if err := db.UpdateTransactionCategory("tx-4", categoryID); err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   TransactionFilter
+		expected []string
+	}{
+		{"no filter", TransactionFilter{}, []string{"tx-4", "tx-3", "tx-2", "tx-1"}},
+		{"uncategorized", TransactionFilter{UncategorizedOnly: true}, []string{"tx-3", "tx-2", "tx-1"}},
+		{"account", TransactionFilter{AccountID: "acc-1"}, []string{"tx-2", "tx-1"}},
+		{"expenses", TransactionFilter{AmountSign: -1}, []string{"tx-4", "tx-3", "tx-1"}},
+		{"income", TransactionFilter{AmountSign: 1}, []string{"tx-2"}},
+		{"end date includes whole day", TransactionFilter{StartDate: "2024-01-01", EndDate: "2024-01-31"}, []string{"tx-2", "tx-1"}},
+		{"combined", TransactionFilter{AccountID: "acc-2", UncategorizedOnly: true, StartDate: "2024-02-01"}, []string{"tx-3"}},
+		{"pending", TransactionFilter{PendingOnly: true}, []string{"tx-3"}},
+		{"category", TransactionFilter{CategoryID: categoryID}, []string{"tx-4"}},
+		{"amount range", TransactionFilter{MinAmount: 2000, MaxAmount: 5000}, []string{"tx-4", "tx-3", "tx-2"}},
+		{"description", TransactionFilter{Description: "TX-1"}, []string{"tx-1"}},
+		{"posted", TransactionFilter{PostedOnly: true}, []string{"tx-4", "tx-2", "tx-1"}},
+		{"oldest first", TransactionFilter{Ascending: true}, []string{"tx-1", "tx-2", "tx-3", "tx-4"}},
+		{"amount descending", TransactionFilter{SortBy: SortByAmount}, []string{"tx-2", "tx-1", "tx-3", "tx-4"}},
+		{"amount ascending", TransactionFilter{SortBy: SortByAmount, Ascending: true}, []string{"tx-4", "tx-3", "tx-1", "tx-2"}},
+		{"account descending", TransactionFilter{SortBy: SortByAccount}, []string{"tx-4", "tx-3", "tx-2", "tx-1"}},
+		{"category ascending", TransactionFilter{SortBy: SortByCategory, Ascending: true}, []string{"tx-3", "tx-2", "tx-1", "tx-4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := db.CountTransactions(tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to count transactions: %v", err)
+			}
+			if count != len(tt.expected) {
+				t.Errorf("Expected count %d, got %d", len(tt.expected), count)
+			}
+
+			page, err := db.GetTransactions(tt.filter, 0, 0)
+			if err != nil {
+				t.Fatalf("Failed to get transactions: %v", err)
+			}
+			var ids []string
+			for _, tx := range page {
+				ids = append(ids, tx.ID)
+			}
+			if len(ids) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, ids)
+					break
+				}
+			}
+		})
+	}
+
+	// Search positions are relative to the filtered ordering
+	positions, err := db.FindTransactionPositions(TransactionFilter{AccountID: "acc-2"}, "tx-3")
+	if err != nil {
+		t.Fatalf("Failed to search transactions: %v", err)
+	}
+	if len(positions) != 1 || positions[0] != 1 {
+		t.Errorf("Expected match at filtered position [1], got %v", positions)
+	}
+}
+
+func TestTransactionFilterTimezone(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.FixedZone("EST", -5*60*60))
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	// Posted times are stored in UTC; in UTC-5 the first is the evening of
+	// January 31st and the second the start of February 1st
+	transactions := []struct {
+		id     string
+		posted string
+	}{
+		{"evening", "2024-02-01T04:30:00Z"},
+		{"morning", "2024-02-01T05:00:00Z"},
+	}
+	for _, tx := range transactions {
+		if err := db.SaveTransaction(tx.id, "acc-1", tx.posted, -1000, tx.id, false); err != nil {
+			t.Fatalf("Failed to save transaction %s: %v", tx.id, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   TransactionFilter
+		expected []string
+	}{
+		{"january", TransactionFilter{StartDate: "2024-01-01", EndDate: "2024-01-31"}, []string{"evening"}},
+		{"february", TransactionFilter{StartDate: "2024-02-01", EndDate: "2024-02-29"}, []string{"morning"}},
+	}
+	for _, tt := range tests {
+		page, err := db.GetTransactions(tt.filter, 0, 0)
+		if err != nil {
+			t.Fatalf("Failed to get transactions: %v", err)
+		}
+		var ids []string
+		for _, tx := range page {
+			ids = append(ids, tx.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, ids)
+		}
+	}
+
+	// Category ranges use the same day boundaries, with the end date inclusive
+	categoryID, err := db.SaveCategory("Dining")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	for _, tx := range transactions {
+		if err := db.UpdateTransactionCategory(tx.id, categoryID); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+	}
+	byCategory, err := db.GetTransactionsByCategory("2024-01-01", "2024-01-31", true)
+	if err != nil {
+		t.Fatalf("Failed to get transactions by category: %v", err)
+	}
+	if got := byCategory["Dining"]; len(got) != 1 || got[0].ID != "evening" {
+		t.Errorf("Expected only the evening transaction in January, got %v", got)
+	}
+}
+
+func TestTransactionIndexes(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// explain returns the details of query's plan
+	explain := func(query string, args ...interface{}) string {
+		rows, err := db.conn.Query("EXPLAIN QUERY PLAN "+query, args...)
+		if err != nil {
+			t.Fatalf("Failed to explain query: %v", err)
+		}
+		defer rows.Close()
+
+		var details []string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatalf("Failed to scan query plan: %v", err)
+			}
+			details = append(details, detail)
+		}
+		return strings.Join(details, "; ")
+	}
+
+	tests := []struct {
+		name   string
+		filter TransactionFilter
+		index  string
+	}{
+		{"account over a date range", TransactionFilter{AccountID: "acc-1", StartDate: "2024-01-01", EndDate: "2024-01-31"}, "idx_transactions_account_posted"},
+		{"category over a date range", TransactionFilter{CategoryID: 1, StartDate: "2024-01-01", EndDate: "2024-01-31"}, "idx_transactions_category_posted"},
+		{"uncategorized", TransactionFilter{UncategorizedOnly: true}, "idx_transactions_category_posted"},
+	}
+	for _, tt := range tests {
+		where, args := tt.filter.whereClause()
+		plan := explain("SELECT t.id FROM transactions t WHERE "+where+" ORDER BY "+tt.filter.orderClause(), args...)
+		if !strings.Contains(plan, tt.index) {
+			t.Errorf("%s: expected %s to be used, got %s", tt.name, tt.index, plan)
+		}
+		// Only ties on the date are sorted by ID ("RIGHT PART OF ORDER BY")
+		if strings.Contains(plan, "USE TEMP B-TREE FOR ORDER BY") {
+			t.Errorf("%s: expected rows in date order without sorting them all, got %s", tt.name, plan)
+		}
+	}
+
+	plan := explain("SELECT id FROM transactions WHERE description = ? COLLATE NOCASE", "coffee shop")
+	if !strings.Contains(plan, "idx_transactions_description") {
+		t.Errorf("Expected description lookups to use idx_transactions_description, got %s", plan)
+	}
+
+	// Databases from before the composite indexes have single column ones
+	// they make redundant
+	for _, stmt := range []string{
+		`CREATE INDEX idx_transactions_account_id ON transactions(account_id)`,
+		`CREATE INDEX idx_transactions_category_id ON transactions(category_id)`,
+	} {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+	if err := db.runIncrementalMigrations(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	for _, index := range []string{"idx_transactions_account_id", "idx_transactions_category_id"} {
+		var count int
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, index).Scan(&count); err != nil || count != 0 {
+			t.Errorf("Expected %s to be replaced by a composite index, got %d, %v", index, count, err)
+		}
+	}
+}
+
+func TestUndoCategoryBatch(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if err := db.SaveTransaction(id, "acc-1", "2024-01-01T00:00:00Z", -1000, "STORE", false); err != nil {
+			t.Fatalf("Failed to save transaction %s: %v", id, err)
+		}
+	}
+
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	diningID, err := db.SaveCategory("Dining")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	if err := db.UpdateTransactionCategory("tx-1", diningID); err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+
+	categoryOf := func(id string) *int {
+		var categoryID *int
+		if err := db.conn.QueryRow("SELECT category_id FROM transactions WHERE id = ?", id).Scan(&categoryID); err != nil {
+			t.Fatalf("Failed to read category of %s: %v", id, err)
+		}
+		return categoryID
+	}
+
+	categorizeBatch, err := db.SetTransactionCategories([]string{"tx-1", "tx-2"}, &groceriesID)
+	if err != nil {
+		t.Fatalf("Failed to categorize transactions: %v", err)
+	}
+	clearBatch, err := db.SetTransactionCategories([]string{"tx-2"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to clear transaction category: %v", err)
+	}
+	if categoryOf("tx-2") != nil {
+		t.Fatal("Expected tx-2 to be uncategorized")
+	}
+
+	// Undo the uncategorize, then the bulk categorize
+	if restored, err := db.UndoCategoryBatch(clearBatch); err != nil || restored != 1 {
+		t.Fatalf("Expected 1 transaction restored, got %d (%v)", restored, err)
+	}
+	if got := categoryOf("tx-2"); got == nil || *got != groceriesID {
+		t.Errorf("Expected tx-2 back in Groceries, got %v", got)
+	}
+
+	if restored, err := db.UndoCategoryBatch(categorizeBatch); err != nil || restored != 2 {
+		t.Fatalf("Expected 2 transactions restored, got %d (%v)", restored, err)
+	}
+	if got := categoryOf("tx-1"); got == nil || *got != diningID {
+		t.Errorf("Expected tx-1 back in Dining, got %v", got)
+	}
+	if categoryOf("tx-2") != nil {
+		t.Error("Expected tx-2 to be uncategorized again")
+	}
+
+	// A batch can only be undone once
+	if _, err := db.UndoCategoryBatch(categorizeBatch); err == nil {
+		t.Error("Expected error undoing a batch twice")
+	}
+
+	// Missing transactions fail the whole batch
+	if _, err := db.SetTransactionCategories([]string{"tx-1", "tx-missing"}, &groceriesID); err == nil {
+		t.Error("Expected error categorizing a missing transaction")
+	}
+	if got := categoryOf("tx-1"); got == nil || *got != diningID {
+		t.Errorf("Expected tx-1 to stay in Dining, got %v", got)
+	}
+
+	// Bulk updates without undo skip missing transactions
+	updated, err := db.UpdateTransactionCategories([]string{"tx-1", "tx-2", "tx-missing"}, groceriesID)
+	if err != nil || updated != 2 {
+		t.Fatalf("Expected 2 transactions updated, got %d (%v)", updated, err)
+	}
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if got := categoryOf(id); got == nil || *got != groceriesID {
+			t.Errorf("Expected %s in Groceries, got %v", id, got)
+		}
+	}
+
+	// Deleting the category a batch replaced keeps the batch, but undoing
+	// it reports the category is gone instead of restoring part of it
+	diningBatch, err := db.SetTransactionCategories([]string{"tx-1"}, &diningID)
+	if err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+	movedBatch, err := db.SetTransactionCategories([]string{"tx-1", "tx-2"}, &groceriesID)
+	if err != nil {
+		t.Fatalf("Failed to categorize transactions: %v", err)
+	}
+	if err := db.DeleteCategory("Dining"); err != nil {
+		t.Fatalf("Failed to delete category: %v", err)
+	}
+	if _, err := db.UndoCategoryBatch(movedBatch); !errors.Is(err, ErrCategoryDeleted) {
+		t.Errorf("Expected ErrCategoryDeleted, got %v", err)
+	}
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if got := categoryOf(id); got == nil || *got != groceriesID {
+			t.Errorf("Expected %s to stay in Groceries, got %v", id, got)
+		}
+	}
+	// The change into the deleted category still undoes
+	if restored, err := db.UndoCategoryBatch(diningBatch); err != nil || restored != 1 {
+		t.Errorf("Expected 1 transaction restored, got %d (%v)", restored, err)
+	}
+}
+
+func TestClosedMonths(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-jan", "acc-1", "2024-01-31T23:00:00Z", -1000, "STORE", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	if err := db.SaveTransaction("tx-feb", "acc-1", "2024-02-01T01:00:00Z", -2000, "STORE", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	err = db.CloseMonth("2024-01",
+		[]ClosedCategoryTotal{{Category: "Uncategorized", Expenses: 1000}},
+		[]ClosedBalance{{AccountID: "acc-1", Balance: 5000}})
+	if err != nil {
+		t.Fatalf("Failed to close month: %v", err)
+	}
+
+	closed, err := db.GetClosedMonth("2024-01")
+	if err != nil || closed == nil {
+		t.Fatalf("Expected 2024-01 to be closed, got %v (%v)", closed, err)
+	}
+	if len(closed.Categories) != 1 || closed.Categories[0].Expenses != 1000 {
+		t.Errorf("Unexpected category totals: %+v", closed.Categories)
+	}
+	if len(closed.Balances) != 1 || closed.Balances[0].Balance != 5000 {
+		t.Errorf("Unexpected ending balances: %+v", closed.Balances)
+	}
+	if open, err := db.GetClosedMonth("2024-02"); err != nil || open != nil {
+		t.Errorf("Expected 2024-02 to be open, got %v (%v)", open, err)
+	}
+
+	// Every way of changing a category is refused in the closed month
+	if err := db.UpdateTransactionCategory("tx-jan", groceriesID); !errors.Is(err, ErrMonthClosed) {
+		t.Errorf("Expected ErrMonthClosed updating a closed month, got %v", err)
+	}
+	if err := db.ClearTransactionCategory("tx-jan"); !errors.Is(err, ErrMonthClosed) {
+		t.Errorf("Expected ErrMonthClosed clearing a closed month, got %v", err)
+	}
+	if _, err := db.UpdateTransactionCategories([]string{"tx-feb", "tx-jan"}, groceriesID); !errors.Is(err, ErrMonthClosed) {
+		t.Errorf("Expected ErrMonthClosed bulk updating a closed month, got %v", err)
+	}
+	if _, err := db.SetTransactionCategories([]string{"tx-jan"}, &groceriesID); !errors.Is(err, ErrMonthClosed) {
+		t.Errorf("Expected ErrMonthClosed categorizing a closed month, got %v", err)
+	}
+
+	// The open month can still change
+	if err := db.UpdateTransactionCategory("tx-feb", groceriesID); err != nil {
+		t.Errorf("Failed to categorize an open month: %v", err)
+	}
+
+	// A batch made before the close can't be undone into it
+	if err := db.ReopenMonth("2024-01"); err != nil {
+		t.Fatalf("Failed to reopen month: %v", err)
+	}
+	batchID, err := db.SetTransactionCategories([]string{"tx-jan"}, &groceriesID)
+	if err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+	if err := db.CloseMonth("2024-01", nil, nil); err != nil {
+		t.Fatalf("Failed to close month again: %v", err)
+	}
+	if _, err := db.UndoCategoryBatch(batchID); !errors.Is(err, ErrMonthClosed) {
+		t.Errorf("Expected ErrMonthClosed undoing into a closed month, got %v", err)
+	}
+
+	// Closing again replaced the snapshot
+	closed, err = db.GetClosedMonth("2024-01")
+	if err != nil || closed == nil || len(closed.Categories) != 0 || len(closed.Balances) != 0 {
+		t.Errorf("Expected an empty snapshot after closing again, got %+v (%v)", closed, err)
+	}
+
+	// --force lets edits through
+	db.AllowClosedMonthEdits(true)
+	if err := db.ClearTransactionCategory("tx-jan"); err != nil {
+		t.Errorf("Failed to clear a closed month with edits allowed: %v", err)
+	}
+	db.AllowClosedMonthEdits(false)
+
+	months, err := db.GetClosedMonths()
+	if err != nil || len(months) != 1 || months[0].Month != "2024-01" {
+		t.Errorf("Expected only 2024-01 closed, got %+v (%v)", months, err)
+	}
+	if err := db.ReopenMonth("2024-02"); err == nil {
+		t.Error("Expected error reopening a month that isn't closed")
+	}
+}
+
+func TestGetBalancesBefore(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if err := db.SaveAccount(id, "org-1", id, "USD", 0, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+
+	for _, h := range []struct {
+		account string
+		balance int64
+		at      time.Time
+	}{
+		{"acc-1", 100, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)},
+		{"acc-1", 200, time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)},
+		{"acc-1", 300, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"acc-2", 900, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+	} {
+		if err := db.SaveBalanceHistoryAt(h.account, h.balance, nil, h.at); err != nil {
+			t.Fatalf("Failed to save balance history: %v", err)
+		}
+	}
+
+	balances, err := db.GetBalancesBefore(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get balances: %v", err)
+	}
+	if len(balances) != 1 || balances["acc-1"] != 200 {
+		t.Errorf("Expected only acc-1 at 200, got %v", balances)
+	}
+}
+
+func TestEditTransaction(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-1", "acc-1", "2024-01-01T08:00:00Z", -1000, "SQ *COFFEE", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	description := "Coffee"
+	amount := int64(-1250)
+	sameDate := "2024-01-01T08:00:00Z"
+	changed, err := db.EditTransaction("tx-1", TransactionChanges{Description: &description, Amount: &amount, Posted: &sameDate})
+	if err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("Expected 2 changed fields, got %d", changed)
+	}
+
+	txn, err := db.GetTransactionByID("tx-1")
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if txn.Description != "Coffee" || txn.Amount != -1250 {
+		t.Errorf("Expected edited transaction, got %q %d", txn.Description, txn.Amount)
+	}
+
+	// Re-importing the transaction must not undo the edit
+	if err := db.SaveTransaction("tx-1", "acc-1", "2024-01-01T08:00:00Z", -1000, "SQ *COFFEE", false); err != nil {
+		t.Fatalf("Failed to re-save transaction: %v", err)
+	}
+	if txn, _ := db.GetTransactionByID("tx-1"); txn.Description != "Coffee" {
+		t.Errorf("Expected edit to survive re-import, got %q", txn.Description)
+	}
+
+	edits, err := db.GetTransactionEdits("tx-1")
+	if err != nil {
+		t.Fatalf("Failed to get transaction edits: %v", err)
+	}
+	if len(edits) != 2 {
+		t.Fatalf("Expected 2 edits, got %d", len(edits))
+	}
+	if edits[0].Field != "description" || edits[0].OldValue != "SQ *COFFEE" || edits[0].NewValue != "Coffee" {
+		t.Errorf("Unexpected description edit: %+v", edits[0])
+	}
+	if edits[1].Field != "amount" || edits[1].OldValue != "-1000" || edits[1].NewValue != "-1250" {
+		t.Errorf("Unexpected amount edit: %+v", edits[1])
+	}
+
+	if _, err := db.EditTransaction("missing", TransactionChanges{Description: &description}); err == nil {
+		t.Error("Expected error editing a missing transaction")
+	}
+}
+
+func TestRenameRules(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	descriptions := map[string]string{
+		"tx-1": "AMZN Mktp US*2K4L",
+		"tx-2": "SQ *BLUE BOTTLE",
+		"tx-3": "PAYROLL",
+	}
+	for id, description := range descriptions {
+		if err := db.SaveTransaction(id, "acc-1", "2024-01-01T00:00:00Z", -1000, description, false); err != nil {
+			t.Fatalf("Failed to save transaction %s: %v", id, err)
+		}
+	}
+
+	if _, err := db.AddRenameRule("AMZN Mktp.*", "Amazon"); err != nil {
+		t.Fatalf("Failed to add rename rule: %v", err)
+	}
+	squareID, err := db.AddRenameRule(`SQ \*(.*)`, "$1")
+	if err != nil {
+		t.Fatalf("Failed to add rename rule: %v", err)
+	}
+	if _, err := db.AddRenameRule("([", "broken"); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+
+	changed, err := db.ApplyRenameRules(nil)
+	if err != nil {
+		t.Fatalf("Failed to apply rename rules: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("Expected 2 renamed transactions, got %d", changed)
+	}
+
+	expected := map[string]string{"tx-1": "Amazon", "tx-2": "BLUE BOTTLE", "tx-3": "PAYROLL"}
+	for id, want := range expected {
+		txn, err := db.GetTransactionByID(id)
+		if err != nil {
+			t.Fatalf("Failed to get transaction %s: %v", id, err)
+		}
+		if txn.DisplayDescription() != want {
+			t.Errorf("%s: expected display description %q, got %q", id, want, txn.DisplayDescription())
+		}
+		if txn.Description != descriptions[id] {
+			t.Errorf("%s: raw description changed to %q", id, txn.Description)
+		}
+	}
+
+	// Filters match the display description as well as the raw one
+	count, err := db.CountTransactions(TransactionFilter{Description: "amazon"})
+	if err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 transaction matching the display description, got %d", count)
+	}
+
+	// Removing a rule restores the raw description on the next apply
+	if err := db.DeleteRenameRule(squareID); err != nil {
+		t.Fatalf("Failed to delete rename rule: %v", err)
+	}
+	if changed, err := db.ApplyRenameRules([]string{"tx-2"}); err != nil || changed != 1 {
+		t.Fatalf("Expected 1 restored transaction, got %d (%v)", changed, err)
+	}
+	if txn, _ := db.GetTransactionByID("tx-2"); txn.DisplayDescription() != "SQ *BLUE BOTTLE" {
+		t.Errorf("Expected raw description back, got %q", txn.DisplayDescription())
+	}
+}
+
+func TestAlertSettings(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Everyday Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	if err := db.SetLowBalanceThreshold("acc-1", 10000); err != nil {
+		t.Fatalf("Failed to set threshold: %v", err)
+	}
+	if err := db.SetLowBalanceThreshold("acc-1", 25000); err != nil {
+		t.Fatalf("Failed to update threshold: %v", err)
+	}
+	thresholds, err := db.GetLowBalanceThresholds()
+	if err != nil {
+		t.Fatalf("Failed to get thresholds: %v", err)
+	}
+	if thresholds["acc-1"] != 25000 {
+		t.Errorf("Expected threshold 25000, got %v", thresholds)
+	}
+
+	rentID, err := db.SaveBill("Rent", 150000, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to save bill: %v", err)
+	}
+	if _, err := db.SaveBill("Phone", 6000, 20, "acc-1"); err != nil {
+		t.Fatalf("Failed to save bill: %v", err)
+	}
+	if _, err := db.SaveBill("Bad", 100, 32, ""); err == nil {
+		t.Error("Expected error for due day 32")
+	}
+
+	// Deleting the account drops its threshold and unassigns its bills
+	if err := db.DeleteAccount("acc-1"); err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if err := db.ClearLowBalanceThreshold("acc-1"); err == nil {
+		t.Error("Expected error clearing a threshold that no longer exists")
+	}
+
+	bills, err := db.GetBills()
+	if err != nil {
+		t.Fatalf("Failed to get bills: %v", err)
+	}
+	if len(bills) != 2 || bills[0].Name != "Rent" || bills[1].AccountID != "" {
+		t.Errorf("Unexpected bills after account delete: %+v", bills)
+	}
+
+	if err := db.DeleteBill(rentID); err != nil {
+		t.Fatalf("Failed to delete bill: %v", err)
+	}
+	if err := db.DeleteBill(rentID); err == nil {
+		t.Error("Expected error deleting a missing bill")
+	}
+}
+
+func TestCreditLimits(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("card", "org-1", "Rewards Card", "USD", -150000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	if err := db.SetCreditLimit("card", 500000); err != nil {
+		t.Fatalf("Failed to set credit limit: %v", err)
+	}
+	if err := db.SetCreditLimit("card", 800000); err != nil {
+		t.Fatalf("Failed to update credit limit: %v", err)
+	}
+	limits, err := db.GetCreditLimits()
+	if err != nil {
+		t.Fatalf("Failed to get credit limits: %v", err)
+	}
+	if len(limits) != 1 || limits["card"] != 800000 {
+		t.Errorf("Expected a limit of 800000, got %v", limits)
+	}
+
+	if err := db.ClearCreditLimit("card"); err != nil {
+		t.Fatalf("Failed to clear credit limit: %v", err)
+	}
+	if err := db.ClearCreditLimit("card"); err == nil {
+		t.Error("Expected error clearing a limit that isn't set")
+	}
+
+	// Deleting the account drops its limit
+	if err := db.SetCreditLimit("card", 800000); err != nil {
+		t.Fatalf("Failed to set credit limit: %v", err)
+	}
+	if err := db.DeleteAccount("card"); err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if limits, err := db.GetCreditLimits(); err != nil || len(limits) != 0 {
+		t.Errorf("Expected no limits after deleting the account, got %v (%v)", limits, err)
+	}
+}
+
+func TestStatementDueDays(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("card", "org-1", "Rewards Card", "USD", -150000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	if err := db.SetStatementDueDay("card", 32); err == nil {
+		t.Error("Expected error for a due day past 31")
+	}
+	if err := db.SetStatementDueDay("card", 15); err != nil {
+		t.Fatalf("Failed to set statement due day: %v", err)
+	}
+	if err := db.SetStatementDueDay("card", 22); err != nil {
+		t.Fatalf("Failed to update statement due day: %v", err)
+	}
+	dueDays, err := db.GetStatementDueDays()
+	if err != nil {
+		t.Fatalf("Failed to get statement due days: %v", err)
+	}
+	if len(dueDays) != 1 || dueDays["card"] != 22 {
+		t.Errorf("Expected a due day of 22, got %v", dueDays)
+	}
+
+	if err := db.ClearStatementDueDay("card"); err != nil {
+		t.Fatalf("Failed to clear statement due day: %v", err)
+	}
+	if err := db.ClearStatementDueDay("card"); err == nil {
+		t.Error("Expected error clearing a due day that isn't set")
+	}
+
+	// Deleting the account drops its due day
+	if err := db.SetStatementDueDay("card", 22); err != nil {
+		t.Fatalf("Failed to set statement due day: %v", err)
+	}
+	if err := db.DeleteAccount("card"); err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if dueDays, err := db.GetStatementDueDays(); err != nil || len(dueDays) != 0 {
+		t.Errorf("Expected no due days after deleting the account, got %v (%v)", dueDays, err)
+	}
+}
+
+func TestAccountDefaultCategories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("mortgage", "org-1", "Mortgage", "USD", -30000000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveAccount("checking", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	housingID, err := db.SaveCategory("Housing")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	feesID, err := db.SaveCategory("Fees")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	if err := db.SetAccountDefaultCategory("mortgage", feesID); err != nil {
+		t.Fatalf("Failed to set default category: %v", err)
+	}
+	if err := db.SetAccountDefaultCategory("mortgage", housingID); err != nil {
+		t.Fatalf("Failed to update default category: %v", err)
+	}
+	defaults, err := db.GetAccountDefaultCategories()
+	if err != nil {
+		t.Fatalf("Failed to get default categories: %v", err)
+	}
+	if len(defaults) != 1 || defaults[0].AccountID != "mortgage" || defaults[0].CategoryName != "Housing" {
+		t.Errorf("Expected mortgage to default to Housing, got %+v", defaults)
+	}
+
+	transactions := []struct {
+		id, account, posted string
+	}{
+		{"payment", "mortgage", "2024-03-01T12:00:00Z"},
+		{"escrow", "mortgage", "2024-03-15T12:00:00Z"},
+		{"closed", "mortgage", "2024-01-15T12:00:00Z"},
+		{"coffee", "checking", "2024-03-02T12:00:00Z"},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, txn.account, txn.posted, -1000, "PAYMENT", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	if err := db.UpdateTransactionCategory("escrow", feesID); err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+	if err := db.CloseMonth("2024-01", nil, nil); err != nil {
+		t.Fatalf("Failed to close month: %v", err)
+	}
+
+	// Only the uncategorized transaction among those given is filed
+	categorized, err := db.ApplyAccountDefaultCategories([]string{"payment", "escrow", "coffee"})
+	if err != nil {
+		t.Fatalf("Failed to apply default categories: %v", err)
+	}
+	if categorized != 1 {
+		t.Errorf("Expected 1 transaction categorized, got %d", categorized)
+	}
+
+	// Applying to everything leaves the closed month alone
+	categorized, err = db.ApplyAccountDefaultCategories(nil)
+	if err != nil {
+		t.Fatalf("Failed to apply default categories: %v", err)
+	}
+	if categorized != 0 {
+		t.Errorf("Expected nothing left to categorize, got %d", categorized)
+	}
+
+	want := map[string]int{"payment": housingID, "escrow": feesID, "closed": 0, "coffee": 0}
+	for id, categoryID := range want {
+		txn, err := db.GetTransactionByID(id)
+		if err != nil {
+			t.Fatalf("Failed to get transaction %s: %v", id, err)
+		}
+		got := 0
+		if txn.CategoryID != nil {
+			got = *txn.CategoryID
+		}
+		if got != categoryID {
+			t.Errorf("Transaction %s: expected category %d, got %d", id, categoryID, got)
+		}
+	}
+
+	if err := db.ClearAccountDefaultCategory("mortgage"); err != nil {
+		t.Fatalf("Failed to clear default category: %v", err)
+	}
+	if err := db.ClearAccountDefaultCategory("mortgage"); err == nil {
+		t.Error("Expected error clearing a default category that isn't set")
+	}
+}
+
+func TestQueryReadOnly(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for i, amount := range []int64{-1000, -2500, 4000} {
+		id := fmt.Sprintf("tx-%d", i)
+		if err := db.SaveTransaction(id, "acc-1", "2023-05-01T00:00:00Z", amount, "Store", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	result, err := db.QueryReadOnly("SELECT ROUND(SUM(-amount) / 100.0, 2) AS spent, COUNT(*) AS n FROM transactions WHERE amount < ? AND strftime('%Y', posted) = ?;", []interface{}{0, "2023"}, 10)
+	if err != nil {
+		t.Fatalf("Failed to run query: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != "35" || result.Rows[0][1] != "2" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	result, err = db.QueryReadOnly("SELECT id FROM transactions ORDER BY id", nil, 2)
+	if err != nil {
+		t.Fatalf("Failed to run query: %v", err)
+	}
+	if len(result.Rows) != 2 || !result.Truncated {
+		t.Errorf("Expected 2 rows and truncation, got %+v", result)
+	}
+
+	rejected := []string{
+		"DELETE FROM transactions",
+		"SELECT 1; DROP TABLE transactions",
+		"SELECT * FROM credentials",
+		"WITH x AS (SELECT 1) DELETE FROM transactions",
+	}
+	for _, query := range rejected {
+		if _, err := db.QueryReadOnly(query, nil, 10); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+
+	count, err := db.CountTransactions(TransactionFilter{})
+	if err != nil || count != 3 {
+		t.Errorf("Expected all 3 transactions to survive, got %d (%v)", count, err)
+	}
+
+	// Writes still work on the pooled connection afterwards
+	if err := db.SaveTransaction("tx-new", "acc-1", "2023-06-01T00:00:00Z", -100, "Store", false); err != nil {
+		t.Errorf("Failed to write after a read-only query: %v", err)
+	}
+
+	schema, err := db.ReadOnlySchema()
+	if err != nil {
+		t.Fatalf("Failed to get schema: %v", err)
+	}
+	if !strings.Contains(schema, "CREATE TABLE transactions") || strings.Contains(schema, "credentials") {
+		t.Errorf("Schema should describe transactions but not credentials:\n%s", schema)
+	}
+}
+
+func TestExportAnonymized(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCredentials("https://bridge.example/access", "user", "secret"); err != nil {
+		t.Fatalf("Failed to save credentials: %v", err)
+	}
+	if err := db.SaveOrganization("org-1", "Hometown Credit Union", "https://hometown.example"); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Jane's Checking", "USD", 432150, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for i, amount := range []int64{-8750, -8750, 3} {
+		id := fmt.Sprintf("tx-%d", i)
+		if err := db.SaveTransaction(id, "acc-1", "2023-05-01T00:00:00Z", amount, "CORNER BAKERY 1234", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	exportPath := filepath.Join(tempDir, "export.db")
+	if err := db.ExportAnonymized(exportPath); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	os.Setenv("MONEY_DIR", tempDir)
+	config.SetDBPathOverride(exportPath)
+	defer config.SetDBPathOverride("")
+	export, err := New()
+	if err != nil {
+		t.Fatalf("Failed to open export: %v", err)
+	}
+	defer export.Close()
+
+	if has, err := export.HasCredentials(); err != nil || has {
+		t.Errorf("Expected credentials to be stripped, got %v, %v", has, err)
+	}
+
+	accounts, err := export.GetAccounts()
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("Failed to read accounts: %v", err)
+	}
+	if accounts[0].Name == "Jane's Checking" || accounts[0].Balance != 400000 {
+		t.Errorf("Account not anonymized: %+v", accounts[0])
+	}
+
+	result, err := export.QueryReadOnly("SELECT description, amount FROM transactions ORDER BY id", nil, 10)
+	if err != nil {
+		t.Fatalf("Failed to query transactions: %v", err)
+	}
+	if result.Rows[0][0] == "CORNER BAKERY 1234" || result.Rows[0][0] != result.Rows[1][0] {
+		t.Errorf("Expected equal hashed descriptions, got %v", result.Rows)
+	}
+	if result.Rows[0][1] != "-9000" || result.Rows[2][1] != "3" {
+		t.Errorf("Unexpected bucketed amounts: %v", result.Rows)
+	}
+}
+
+func TestBucketAmount(t *testing.T) {
+	tests := map[int64]int64{0: 0, 7: 7, -7: -7, 12: 10, 15: 20, 432150: 400000, -8750: -9000, 95: 100}
+	for cents, want := range tests {
+		if got := bucketAmount(cents); got != want {
+			t.Errorf("bucketAmount(%d) = %d, want %d", cents, got, want)
+		}
+	}
+}
+
+func TestForeignKeys(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-1", "acc-1", "2024-01-01T00:00:00Z", -500, "Coffee", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	db.Close()
+
+	// Recreate balance_history the way it was before foreign key actions,
+	// and leave orphans behind the way older versions could
+	raw, err := sql.Open("sqlite", filepath.Join(tempDir, "money.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, stmt := range []string{
+		`DROP TABLE balance_history`,
+		`CREATE TABLE balance_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id TEXT NOT NULL,
+			balance INTEGER NOT NULL,
+			available_balance INTEGER,
+			recorded_at DATETIME NOT NULL,
+			FOREIGN KEY (account_id) REFERENCES accounts(id)
+		)`,
+		`CREATE INDEX idx_balance_history_account_id ON balance_history(account_id)`,
+		`INSERT INTO balance_history (account_id, balance, recorded_at) VALUES ('acc-1', 100000, '2024-01-01'), ('gone', 5, '2024-01-01')`,
+		`INSERT INTO transactions (id, account_id, posted, amount, description, category_id) VALUES ('tx-2', 'gone', '2024-01-02', -1, 'Orphan', 99)`,
+	} {
+		if _, err := raw.Exec(stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+	raw.Close()
+
+	db, err = New()
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	var createSQL string
+	if err := db.conn.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'balance_history'`).Scan(&createSQL); err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if !strings.Contains(createSQL, "ON DELETE CASCADE") {
+		t.Errorf("Expected balance_history to be rebuilt with ON DELETE CASCADE, got %s", createSQL)
+	}
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'idx_balance_history_account_id'`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected the balance_history index to be restored, got %d, %v", count, err)
+	}
+
+	orphaned, err := db.CheckForeignKeys()
+	if err != nil {
+		t.Fatalf("CheckForeignKeys failed: %v", err)
+	}
+	found := make(map[string]OrphanedReference)
+	for _, ref := range orphaned {
+		found[ref.Table+"."+ref.Column] = ref
+	}
+	if len(found) != 3 {
+		t.Errorf("Expected 3 orphaned references, got %+v", orphaned)
+	}
+	for key, missing := range map[string]string{
+		"balance_history.account_id": "gone",
+		"transactions.account_id":    "gone",
+		"transactions.category_id":   "99",
+	} {
+		if ref := found[key]; ref.Rows != 1 || len(ref.Missing) != 1 || ref.Missing[0] != missing {
+			t.Errorf("Expected %s to miss %s, got %+v", key, missing, ref)
+		}
+	}
+
+	problems, err := db.QuickCheck()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected a clean quick check, got %v, %v", problems, err)
+	}
+
+	if err := db.SaveTransaction("tx-3", "nope", "2024-01-03T00:00:00Z", -1, "Nowhere", false); err == nil {
+		t.Error("Expected saving a transaction for a missing account to fail")
+	}
+
+	if _, err := db.conn.Exec(`DELETE FROM accounts WHERE id = 'acc-1'`); err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM transactions WHERE account_id = 'acc-1'`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected the account's transactions to be deleted with it, got %d, %v", count, err)
+	}
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM balance_history WHERE account_id = 'acc-1'`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected the account's balance history to be deleted with it, got %d, %v", count, err)
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("tx-%d", i)
+		if err := db.SaveTransaction(id, "acc-1", "2024-01-01T00:00:00Z", -int64(i), strings.Repeat("x", 200), false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	if _, err := db.conn.Exec(`DELETE FROM transactions WHERE amount < -10`); err != nil {
+		t.Fatalf("Failed to delete transactions: %v", err)
+	}
+
+	before, err := db.FileSize()
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+	steps, err := db.Maintain()
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if len(steps) != 3 {
+		t.Errorf("Expected 3 steps, got %+v", steps)
+	}
+	after, err := db.FileSize()
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+	if after >= before {
+		t.Errorf("Expected vacuuming to shrink the database from %d bytes, got %d", before, after)
+	}
+
+	sizes, err := db.TableSizes()
+	if err != nil {
+		t.Fatalf("TableSizes failed: %v", err)
+	}
+	rows := make(map[string]int)
+	for _, size := range sizes {
+		if strings.HasPrefix(size.Name, "sqlite_") {
+			t.Errorf("Expected internal tables to be left out, got %s", size.Name)
+		}
+		rows[size.Name] = size.Rows
+	}
+	if rows["transactions"] != 11 || rows["accounts"] != 1 {
+		t.Errorf("Unexpected row counts: %v", rows)
+	}
+	if sizes[0].Name != "transactions" {
+		t.Errorf("Expected transactions to be the largest table, got %s", sizes[0].Name)
+	}
+}
+
+func TestAccountOwners(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	owners := map[string]string{"mine": "Me", "theirs": "spouse", "shared": " Joint ", "unowned": ""}
+	for id, owner := range owners {
+		if err := db.SaveAccount(id, "org-1", id, "USD", 10000, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+		if err := db.SaveTransaction("txn-"+id, id, "2025-03-10T12:00:00Z", -2500, "GROCERIES", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if owner == "" {
+			continue
+		}
+		if err := db.SetAccountOwner(id, owner); err != nil {
+			t.Fatalf("Failed to set account owner: %v", err)
+		}
+	}
+
+	if err := db.SetAccountOwner("missing", "me"); err == nil {
+		t.Error("Expected error setting the owner of a missing account")
+	}
+	if err := db.SetAccountOwner("mine", "me,joint"); err == nil {
+		t.Error("Expected error for an owner with a comma")
+	}
+
+	account, err := db.GetAccountByID("shared")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.Owner == nil || *account.Owner != "joint" {
+		t.Errorf("Expected the owner to be normalized to joint, got %v", account.Owner)
+	}
+
+	names, err := db.GetAccountOwners()
+	if err != nil {
+		t.Fatalf("Failed to get account owners: %v", err)
+	}
+	if strings.Join(names, ",") != "joint,me,spouse" {
+		t.Errorf("Expected owners joint, me and spouse, got %v", names)
+	}
+
+	if db.ForOwners(nil) != db {
+		t.Error("Expected no owners to return the unscoped handle")
+	}
+	scoped := db.ForOwners([]string{"me", "joint"})
+
+	accounts, err := scoped.GetAccounts()
+	if err != nil {
+		t.Fatalf("Failed to get accounts: %v", err)
+	}
+	var ids []string
+	for _, account := range accounts {
+		ids = append(ids, account.ID)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "mine,shared" {
+		t.Errorf("Expected the accounts of me and joint, got %v", ids)
+	}
+
+	count, err := scoped.CountTransactions(TransactionFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 transactions for me and joint, got %d", count)
+	}
+
+	byCategory, err := scoped.GetTransactionsByCategory("2025-03-01", "2025-03-31", true)
+	if err != nil {
+		t.Fatalf("Failed to get transactions by category: %v", err)
+	}
+	if len(byCategory["Uncategorized"]) != 2 {
+		t.Errorf("Expected 2 uncategorized transactions for me and joint, got %v", byCategory)
+	}
+
+	// The unscoped handle still reads everything
+	if count, err := db.CountTransactions(TransactionFilter{}); err != nil || count != 4 {
+		t.Errorf("Expected 4 transactions unscoped, got %d (%v)", count, err)
+	}
+
+	if err := db.ClearAccountOwner("mine"); err != nil {
+		t.Fatalf("Failed to clear account owner: %v", err)
+	}
+	if accounts, err := db.ForOwners([]string{"me"}).GetAccounts(); err != nil || len(accounts) != 0 {
+		t.Errorf("Expected no accounts for me after clearing, got %v (%v)", accounts, err)
+	}
+}
+
+func TestDump(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCredentials("https://bridge.example.com", "user", "hunter2"); err != nil {
+		t.Fatalf("Failed to save credentials: %v", err)
+	}
+	if err := db.SaveOrganization("org-1", "O'Brien Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 123456, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-1", "acc-1", "2023-05-01T00:00:00Z", -1050, "Coffee; and a\nnewline", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	var dump strings.Builder
+	if err := db.Dump(&dump, false); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+
+	// Loading the dump into an empty database rebuilds the same data
+	restored, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "restored.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer restored.Close()
+	if _, err := restored.Exec(dump.String()); err != nil {
+		t.Fatalf("Failed to load dump: %v\n%s", err, dump.String())
+	}
+
+	var name, description string
+	var balance, amount int64
+	if err := restored.QueryRow("SELECT name FROM organizations WHERE id = 'org-1'").Scan(&name); err != nil || name != "O'Brien Bank" {
+		t.Errorf("Expected the organization to be restored, got %q (%v)", name, err)
+	}
+	if err := restored.QueryRow("SELECT balance FROM accounts WHERE id = 'acc-1'").Scan(&balance); err != nil || balance != 123456 {
+		t.Errorf("Expected the balance to be restored, got %d (%v)", balance, err)
+	}
+	if err := restored.QueryRow("SELECT amount, description FROM transactions WHERE id = 'tx-1'").Scan(&amount, &description); err != nil ||
+		amount != -1050 || description != "Coffee; and a\nnewline" {
+		t.Errorf("Expected the transaction to be restored, got %d %q (%v)", amount, description, err)
+	}
+	var version int32
+	restored.QueryRow("PRAGMA user_version").Scan(&version)
+	if version != schemaVersion {
+		t.Errorf("Expected the schema version %d to be restored, got %d", schemaVersion, version)
+	}
+
+	dump.Reset()
+	if err := db.Dump(&dump, true); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if strings.Contains(dump.String(), "hunter2") || !strings.Contains(dump.String(), "CREATE TABLE credentials") {
+		t.Error("Expected a dump without secrets to create the credentials table empty")
+	}
+}
+
+func TestExec(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	for _, id := range []string{"org-1", "org-2"} {
+		if err := db.SaveOrganization(id, "Bank", ""); err != nil {
+			t.Fatalf("Failed to save organization: %v", err)
+		}
+	}
+
+	changed, err := db.Exec("UPDATE organizations SET name = 'Renamed';")
+	if err != nil || changed != 2 {
+		t.Errorf("Expected 2 rows changed, got %d (%v)", changed, err)
+	}
+	if _, err := db.Exec("DELETE FROM organizations; DROP TABLE accounts"); err == nil {
+		t.Error("Expected more than one statement to be rejected")
+	}
+}
+
+func TestFetchChanges(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if run, err := db.GetLastFetchRun(); err != nil || run != nil {
+		t.Fatalf("Expected no fetch before one is recorded, got %v (%v)", run, err)
+	}
+
+	// An account from an earlier fetch
+	if err := db.SaveOrganization("org-old", "Old Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-old", "org-old", "Checking", "USD", 10000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-old", "acc-old", "2023-05-01T00:00:00Z", -500, "Old", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	if err := db.SaveBalanceHistoryAt("acc-old", 10000, nil, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+	for _, table := range []string{"organizations", "accounts", "transactions"} {
+		if _, err := db.conn.Exec("UPDATE " + table + " SET created_at = '2020-01-01 00:00:00'"); err != nil {
+			t.Fatalf("Failed to backdate %s: %v", table, err)
+		}
+	}
+
+	startedAt := time.Now().Add(-time.Second)
+	if err := db.SaveOrganization("org-new", "New Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-new", "org-new", "Savings", "USD", 50000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveTransaction("tx-new", "acc-old", "2023-05-02T00:00:00Z", -2500, "New", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	if err := db.SaveBalanceHistory("acc-old", 7500, nil); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+	if err := db.SaveBalanceHistory("acc-new", 50000, nil); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+	if err := db.RecordFetchRun(startedAt, time.Now().Add(time.Second), true); err != nil {
+		t.Fatalf("Failed to record fetch: %v", err)
+	}
+
+	run, err := db.GetLastFetchRun()
+	if err != nil || run == nil {
+		t.Fatalf("Expected the recorded fetch, got %v (%v)", run, err)
+	}
+	if !run.Replay || run.StartedAt.Unix() != startedAt.Unix() {
+		t.Errorf("Expected a replayed fetch started at %v, got %+v", startedAt.UTC(), run)
+	}
+
+	changes, err := db.GetFetchChanges(*run)
+	if err != nil {
+		t.Fatalf("GetFetchChanges failed: %v", err)
+	}
+	if len(changes.Organizations) != 1 || changes.Organizations[0].ID != "org-new" {
+		t.Errorf("Expected only org-new to be new, got %+v", changes.Organizations)
+	}
+	if len(changes.AccountIDs) != 1 || changes.AccountIDs[0] != "acc-new" {
+		t.Errorf("Expected only acc-new to be new, got %v", changes.AccountIDs)
+	}
+	if len(changes.Transactions) != 1 || changes.Transactions[0].ID != "tx-new" {
+		t.Errorf("Expected only tx-new to be new, got %+v", changes.Transactions)
+	}
+	if len(changes.Balances) != 2 {
+		t.Fatalf("Expected 2 balance changes, got %+v", changes.Balances)
+	}
+	if old := changes.Balances[1]; old.AccountID != "acc-old" || old.Before == nil || *old.Before != 10000 || old.After != 7500 {
+		t.Errorf("Expected acc-old to go from 10000 to 7500, got %+v", old)
+	}
+	if added := changes.Balances[0]; added.AccountID != "acc-new" || added.Before != nil || added.After != 50000 {
+		t.Errorf("Expected acc-new to have no previous balance, got %+v", added)
+	}
+}
+
+func TestGetStaleAccounts(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	accounts := []struct {
+		id          string
+		balanceDate string
+		synced      bool
+	}{
+		{"fresh", now.Add(-24 * time.Hour).Format(time.RFC3339), true},
+		{"broken", now.AddDate(0, 0, -12).Format(time.RFC3339), true}, // still returned, never updated
+		{"gone", now.AddDate(0, 0, -9).Format(time.RFC3339), false},   // no longer returned
+		{"no-date", "", true},
+		{"manual", "", false},
+	}
+	for _, account := range accounts {
+		if err := db.SaveAccount(account.id, "org-1", account.id, "USD", 0, nil, account.balanceDate); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+		if account.synced {
+			if err := db.MarkAccountSynced(account.id); err != nil {
+				t.Fatalf("MarkAccountSynced failed: %v", err)
+			}
+		}
+	}
+
+	stale, err := db.GetStaleAccounts(now.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("GetStaleAccounts failed: %v", err)
+	}
+	if len(stale) != 2 || stale[0].ID != "broken" || stale[1].ID != "gone" {
+		t.Fatalf("Expected broken and gone, oldest first, got %+v", stale)
+	}
+	if want := now.AddDate(0, 0, -12).Truncate(time.Second); !stale[0].LastUpdate.Equal(want) {
+		t.Errorf("Expected broken last updated %s, got %s", want, stale[0].LastUpdate)
+	}
+
+	// Without a balance date, the last sync is the last update
+	if _, err := db.conn.Exec(`UPDATE accounts SET synced_at = '2020-01-01 00:00:00' WHERE id = 'no-date'`); err != nil {
+		t.Fatalf("Failed to backdate sync: %v", err)
+	}
+	stale, err = db.GetStaleAccounts(now.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("GetStaleAccounts failed: %v", err)
+	}
+	if len(stale) != 3 || stale[0].ID != "no-date" {
+		t.Errorf("Expected no-date to be the oldest stale account, got %+v", stale)
+	}
+}
+
+func TestSetCategoryStyle(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	id, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	if err := db.SetCategoryStyle(id, "#00aa55", "🛒"); err != nil {
+		t.Fatalf("SetCategoryStyle failed: %v", err)
+	}
+	category, err := db.GetCategoryByName("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to get category: %v", err)
+	}
+	if category.Color != "#00aa55" || category.Emoji != "🛒" {
+		t.Errorf("Expected color #00aa55 and emoji 🛒, got %q and %q", category.Color, category.Emoji)
+	}
+
+	if err := db.SetCategoryStyle(id, "", ""); err != nil {
+		t.Fatalf("SetCategoryStyle failed: %v", err)
+	}
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatalf("Failed to get categories: %v", err)
+	}
+	if len(categories) != 1 || categories[0].Color != "" || categories[0].Emoji != "" {
+		t.Errorf("Expected the style cleared, got %+v", categories)
+	}
+
+	if err := db.SetCategoryStyle(id+1, "red", ""); err == nil {
+		t.Error("Expected an error styling a category that doesn't exist")
+	}
+}
@@ -16,6 +16,35 @@ CREATE TABLE rentcast_credentials (
     last_used DATETIME
 );
 
+-- GoCardless Bank Account Data user secret and the tokens it was exchanged for
+CREATE TABLE gocardless_credentials (
+    id INTEGER PRIMARY KEY,
+    secret_id TEXT NOT NULL,
+    secret_key TEXT NOT NULL,
+    access_token TEXT,
+    access_expires TEXT,  -- RFC3339
+    refresh_token TEXT,
+    refresh_expires TEXT,  -- RFC3339
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Banks linked through GoCardless, each by the requisition that granted access
+CREATE TABLE gocardless_banks (
+    requisition_id TEXT PRIMARY KEY,
+    institution_id TEXT NOT NULL UNIQUE,
+    institution_name TEXT NOT NULL,
+    expires_at TEXT NOT NULL,  -- YYYY-MM-DD, when the granted access runs out
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Banks enrolled through Teller Connect, with the access token each gave
+CREATE TABLE teller_enrollments (
+    enrollment_id TEXT PRIMARY KEY,
+    access_token TEXT NOT NULL,
+    institution_name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Financial institutions/organizations
 CREATE TABLE organizations (
     id TEXT PRIMARY KEY,  -- SimpleFIN org ID
@@ -33,11 +62,13 @@ CREATE TABLE accounts (
     currency TEXT NOT NULL DEFAULT 'USD',
     balance INTEGER NOT NULL,  -- Store as cents to avoid floating point issues
     available_balance INTEGER,
-    balance_date DATETIME,
-    account_type TEXT CHECK (account_type IN ('checking', 'savings', 'credit', 'investment', 'loan', 'property', 'other', 'unset')) DEFAULT 'unset',
+    balance_date DATETIME,  -- As of when the bank reported the balance
+    synced_at DATETIME,  -- Last fetch that returned the account; NULL for accounts added by hand
+    account_type TEXT CHECK (account_type IN ('checking', 'savings', 'credit', 'investment', 'crypto', 'loan', 'property', 'other', 'unset')) DEFAULT 'unset',
+    owner TEXT,  -- Who the account belongs to in a shared database, like 'me' or 'joint'
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (org_id) REFERENCES organizations(id)
+    FOREIGN KEY (org_id) REFERENCES organizations(id) ON DELETE RESTRICT
 );
 
 -- Property details for property accounts
@@ -54,8 +85,89 @@ CREATE TABLE properties (
     last_value_estimate INTEGER,  -- Store as cents
     last_rent_estimate INTEGER,   -- Store as cents
     last_updated DATETIME,
+    valuation_provider TEXT,  -- rentcast, attom, or manual; NULL for the default provider
+    bedrooms INTEGER,  -- Public record details from RentCast
+    bathrooms REAL,
+    square_footage INTEGER,
+    lot_size INTEGER,  -- Square feet
+    year_built INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Loans secured by a property, counted against its equity
+CREATE TABLE property_mortgages (
+    loan_account_id TEXT PRIMARY KEY,  -- A loan is secured by at most one property
+    property_account_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (loan_account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+    FOREIGN KEY (property_account_id) REFERENCES properties(account_id) ON DELETE CASCADE
+);
+
+-- Transactions tagged to a property for rental income and expense tracking
+CREATE TABLE property_transactions (
+    transaction_id TEXT PRIMARY KEY,  -- A transaction belongs to at most one property
+    property_account_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (property_account_id) REFERENCES properties(account_id) ON DELETE CASCADE
+);
+
+-- Vehicles and other depreciating assets, tracked as accounts of type other
+CREATE TABLE assets (
+    account_id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,  -- vehicle, equipment, or other
+    purchase_price INTEGER NOT NULL,  -- Store as cents
+    purchase_date TEXT NOT NULL,  -- YYYY-MM-DD
+    salvage_value INTEGER NOT NULL DEFAULT 0,  -- Value at the end of its useful life, in cents
+    useful_life_months INTEGER,  -- Straight-line depreciation period, NULL if valued manually
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Securities held in investment accounts, as reported by SimpleFIN and
+-- revalued from market quotes between syncs
+CREATE TABLE holdings (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    symbol TEXT NOT NULL DEFAULT '',  -- Ticker, empty if the institution doesn't report one
+    description TEXT NOT NULL DEFAULT '',
+    shares REAL NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL DEFAULT '',
+    market_value INTEGER NOT NULL DEFAULT 0,  -- Store as cents
+    cost_basis INTEGER,  -- Store as cents, NULL if not reported
+    acquired TEXT NOT NULL DEFAULT '',  -- YYYY-MM-DD purchase date, empty if not reported
+    price_updated_at DATETIME,  -- Last quote refresh, NULL while the value is the one SimpleFIN reported
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Tax lots entered by hand, for gains on holdings bought at different
+-- times or prices; a sold lot keeps its cost basis and records the proceeds
+CREATE TABLE lots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    shares REAL NOT NULL,
+    cost_basis INTEGER NOT NULL,  -- Store as cents, for all the lot's shares
+    acquired TEXT NOT NULL,  -- YYYY-MM-DD
+    sold TEXT,  -- YYYY-MM-DD, NULL while held
+    proceeds INTEGER,  -- Store as cents, NULL while held
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Where crypto accounts read their balances from: a public address on a
+-- blockchain, or an exchange account's API key
+CREATE TABLE crypto_wallets (
+    account_id TEXT PRIMARY KEY,
+    source TEXT NOT NULL,  -- bitcoin, ethereum, or kraken
+    address TEXT NOT NULL DEFAULT '',  -- Public address for blockchain sources
+    api_key TEXT NOT NULL DEFAULT '',  -- Exchange API key and secret
+    api_secret TEXT NOT NULL DEFAULT '',
+    last_updated DATETIME,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (account_id) REFERENCES accounts(id)
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
 );
 
 -- Categories for transaction classification
@@ -63,9 +175,29 @@ CREATE TABLE categories (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
     is_internal BOOLEAN DEFAULT FALSE,  -- Internal categories excluded from budget calculations
+    color TEXT,                         -- Display color: a color name or #rrggbb
+    emoji TEXT,                         -- Display emoji, shown before the name
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Monthly budget targets per category
+CREATE TABLE budgets (
+    category_id INTEGER PRIMARY KEY,
+    amount INTEGER NOT NULL,  -- Monthly target in cents
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+-- Tax form line each deductible or taxable category is reported on
+CREATE TABLE tax_mappings (
+    category_id INTEGER PRIMARY KEY,
+    tax_line TEXT NOT NULL,  -- Key from report.TaxLines, e.g. c:supplies
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
 -- Balance history for trending
 CREATE TABLE balance_history (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -73,7 +205,7 @@ CREATE TABLE balance_history (
     balance INTEGER NOT NULL,  -- Store as cents
     available_balance INTEGER,
     recorded_at DATETIME NOT NULL,
-    FOREIGN KEY (account_id) REFERENCES accounts(id)
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
 );
 
 -- Transactions
@@ -82,21 +214,195 @@ CREATE TABLE transactions (
     account_id TEXT NOT NULL,
     posted DATETIME NOT NULL,
     amount INTEGER NOT NULL,  -- Store as cents
-    description TEXT NOT NULL,
+    description TEXT NOT NULL,  -- Raw description from the bank
+    display_description TEXT,  -- Clean description set by rename rules, NULL to show the raw one
     pending BOOLEAN DEFAULT FALSE,
     category_id INTEGER,  -- NULL for uncategorized transactions
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (account_id) REFERENCES accounts(id),
-    FOREIGN KEY (category_id) REFERENCES categories(id)
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+);
+
+-- Log of category changes, grouped into batches so an action can be undone
+CREATE TABLE category_operations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_id INTEGER NOT NULL,  -- All changes made by one action share a batch
+    transaction_id TEXT NOT NULL,
+    old_category_id INTEGER,  -- NULL when the transaction was uncategorized
+    new_category_id INTEGER,  -- NULL when the category was cleared
+    old_category_deleted INTEGER NOT NULL DEFAULT 0,  -- 1 once the old category was deleted, so undo can't restore it
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (old_category_id) REFERENCES categories(id) ON DELETE SET NULL,
+    FOREIGN KEY (new_category_id) REFERENCES categories(id) ON DELETE SET NULL
+);
+
+-- Audit trail of manual edits to transaction fields
+CREATE TABLE transaction_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id TEXT NOT NULL,
+    field TEXT NOT NULL,  -- description, posted, or amount
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    edited_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+-- Rules that rewrite raw bank descriptions into clean display descriptions
+CREATE TABLE rename_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pattern TEXT NOT NULL UNIQUE,  -- Regular expression matched against the raw description
+    replacement TEXT NOT NULL,  -- Display description, may reference capture groups as $1
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-account balance thresholds that raise low balance alerts
+CREATE TABLE low_balance_alerts (
+    account_id TEXT PRIMARY KEY,
+    threshold INTEGER NOT NULL,  -- Alert when the balance drops below this, in cents
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Category new transactions in an account get during fetch
+CREATE TABLE account_default_categories (
+    account_id TEXT PRIMARY KEY,
+    category_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+-- Credit limits of credit card accounts, for utilization
+CREATE TABLE credit_limits (
+    account_id TEXT PRIMARY KEY,
+    credit_limit INTEGER NOT NULL,  -- Store as cents
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Day of the month each credit account's statement payment is due
+CREATE TABLE statement_due_days (
+    account_id TEXT PRIMARY KEY,
+    due_day INTEGER NOT NULL CHECK (due_day BETWEEN 1 AND 31),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- Recurring monthly bills
+CREATE TABLE bills (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    amount INTEGER NOT NULL,  -- Store as cents
+    due_day INTEGER NOT NULL CHECK (due_day BETWEEN 1 AND 31),
+    account_id TEXT,  -- Account the bill is paid from, NULL if unknown
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE SET NULL
+);
+
+CREATE TABLE llm_calls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    purpose TEXT NOT NULL,  -- What the call was for, e.g. categorize
+    command TEXT NOT NULL,  -- LLM command the prompt was piped to
+    prompt TEXT NOT NULL,
+    response TEXT NOT NULL DEFAULT '',  -- Raw response, empty if the call failed
+    error TEXT NOT NULL DEFAULT '',
+    latency_ms INTEGER NOT NULL,
+    prompt_tokens INTEGER NOT NULL,  -- Estimated, the command doesn't report usage
+    response_tokens INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Months locked by money close, with the category totals and ending
+-- balances they closed with
+CREATE TABLE closed_months (
+    month TEXT PRIMARY KEY,  -- YYYY-MM in the configured time zone
+    closed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE closed_month_categories (
+    month TEXT NOT NULL,
+    category TEXT NOT NULL,  -- Category name, Uncategorized for none
+    income INTEGER NOT NULL,  -- Store as cents
+    expenses INTEGER NOT NULL,  -- Store as cents, positive
+    PRIMARY KEY (month, category),
+    FOREIGN KEY (month) REFERENCES closed_months(month) ON DELETE CASCADE
+);
+
+CREATE TABLE closed_month_balances (
+    month TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    balance INTEGER NOT NULL,  -- Store as cents, as of the end of the month
+    PRIMARY KEY (month, account_id),
+    FOREIGN KEY (month) REFERENCES closed_months(month) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+-- One row per money fetch, so money diff can show what each one changed
+CREATE TABLE fetch_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NOT NULL,
+    replay BOOLEAN NOT NULL DEFAULT FALSE  -- Fed recorded responses instead of contacting SimpleFIN
+);
+
+-- Budget thresholds already notified, so a category crossing 80% or 100% of
+-- its budget is only sent once a month
+CREATE TABLE budget_notifications (
+    category_id INTEGER NOT NULL,
+    month TEXT NOT NULL,  -- YYYY-MM
+    percent INTEGER NOT NULL,  -- Threshold crossed, e.g. 80 or 100
+    sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (category_id, month, percent),
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+-- YNAB accounts, categories and transactions money ynab syncs with
+CREATE TABLE ynab_accounts (
+    account_id TEXT PRIMARY KEY,
+    ynab_account_id TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE ynab_categories (
+    category_id INTEGER PRIMARY KEY,
+    ynab_category_id TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE TABLE ynab_transactions (
+    transaction_id TEXT PRIMARY KEY,
+    ynab_transaction_id TEXT NOT NULL UNIQUE,
+    synced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+-- Actual Budget accounts money actual pushes accounts to
+CREATE TABLE actual_accounts (
+    account_id TEXT PRIMARY KEY,
+    actual_account_id TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
 );
 
 -- Indexes for performance
-CREATE INDEX idx_transactions_account_id ON transactions(account_id);
+CREATE INDEX idx_transactions_account_posted ON transactions(account_id, posted);
+CREATE INDEX idx_transactions_category_posted ON transactions(category_id, posted);
 CREATE INDEX idx_transactions_posted ON transactions(posted);
-CREATE INDEX idx_transactions_category_id ON transactions(category_id);
+CREATE INDEX idx_transactions_description ON transactions(description COLLATE NOCASE);
 CREATE INDEX idx_categories_is_internal ON categories(is_internal);
 CREATE INDEX idx_accounts_org_id ON accounts(org_id);
 CREATE INDEX idx_balance_history_account_id ON balance_history(account_id);
 CREATE INDEX idx_balance_history_recorded_at ON balance_history(recorded_at);
-CREATE INDEX idx_properties_account_id ON properties(account_id);
\ No newline at end of file
+CREATE INDEX idx_properties_account_id ON properties(account_id);
+CREATE INDEX idx_category_operations_batch_id ON category_operations(batch_id);
+CREATE INDEX idx_transaction_edits_transaction_id ON transaction_edits(transaction_id);
+CREATE INDEX idx_holdings_account_id ON holdings(account_id);
+CREATE INDEX idx_lots_account_id ON lots(account_id);
\ No newline at end of file
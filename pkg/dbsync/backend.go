@@ -0,0 +1,176 @@
+package dbsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ObjectName is the file the snapshot is stored as when a remote names a
+// directory, and inside git repositories
+const ObjectName = "money.db.enc"
+
+// ErrNotFound is returned by Backend.Get when nothing has been pushed yet
+var ErrNotFound = errors.New("no snapshot on the remote")
+
+// ErrConflict is returned by Backend.Put when the remote changed since the
+// version the caller read
+var ErrConflict = errors.New("the remote changed since it was read")
+
+// Backend stores the encrypted snapshot somewhere both machines can reach.
+// Versions are opaque strings, like an ETag or a commit, that Put compares
+// against so two machines pushing at once can't overwrite each other.
+type Backend interface {
+	// Get returns the stored snapshot and its version, or ErrNotFound
+	Get(ctx context.Context) (data []byte, version string, err error)
+	// Put stores data if the remote is still at version, "" meaning
+	// nothing stored yet, and returns ErrConflict otherwise
+	Put(ctx context.Context, data []byte, version string) error
+	// String returns the remote's address, without credentials
+	String() string
+}
+
+// NewBackend returns the backend for a remote address:
+//
+//	s3://bucket/key             an S3 object (or S3 compatible, see S3)
+//	davs://host/path/file       a file on a WebDAV server over HTTPS
+//	dav://host/path/file        the same over plain HTTP
+//	git+<url>                   money.db.enc in a git repository
+//	/path/file or file:///path  a file, e.g. in a synced folder
+//
+// A remote ending in "/" stores ObjectName in that directory. gitDir is
+// where git remotes are checked out.
+func NewBackend(remote, gitDir string) (Backend, error) {
+	if remote == "" {
+		return nil, fmt.Errorf("no sync remote; set MONEY_SYNC_REMOTE or pass --remote")
+	}
+
+	if repo, ok := strings.CutPrefix(remote, "git+"); ok {
+		return &Git{URL: repo, Dir: gitDir}, nil
+	}
+	if strings.HasPrefix(remote, "/") || strings.HasPrefix(remote, "~") || strings.HasPrefix(remote, ".") {
+		return newFileBackend(remote)
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync remote: %w", err)
+	}
+	if u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + ObjectName
+	}
+
+	switch u.Scheme {
+	case "file":
+		return newFileBackend(u.Path)
+	case "s3":
+		return NewS3(u.Host, strings.TrimPrefix(u.Path, "/"))
+	case "dav", "davs":
+		scheme := "https"
+		if u.Scheme == "dav" {
+			scheme = "http"
+		}
+		password, _ := u.User.Password()
+		target := *u
+		target.Scheme = scheme
+		target.User = nil
+		return &WebDAV{URL: target.String(), Username: u.User.Username(), Password: password}, nil
+	}
+	return nil, fmt.Errorf("unsupported sync remote %q: use s3://, dav://, davs://, git+ or a file path", remote)
+}
+
+// File stores the snapshot as a local file, which can sit in a folder
+// another tool syncs, or on a mounted drive
+type File struct {
+	Path string
+}
+
+func newFileBackend(path string) (*File, error) {
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if strings.HasSuffix(path, "/") {
+		path = filepath.Join(path, ObjectName)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: abs}, nil
+}
+
+func (f *File) String() string { return f.Path }
+
+// Get returns the file, versioned by the SHA-256 of its contents
+func (f *File) Get(ctx context.Context) ([]byte, string, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	return data, contentVersion(data), nil
+}
+
+// Put replaces the file through a rename, so a reader never sees it half
+// written. The version check and the rename aren't atomic, which is fine
+// for a file only this user's machines write.
+func (f *File) Put(ctx context.Context, data []byte, version string) error {
+	current, err := os.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if version != "" {
+			return ErrConflict
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", f.Path, err)
+	case contentVersion(current) != version:
+		return ErrConflict
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(f.Path), err)
+	}
+	return writeFileAtomic(f.Path, data)
+}
+
+// contentVersion versions backends that have no version of their own by the
+// hash of what they store
+func contentVersion(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package dbsync
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// magic starts every encrypted snapshot, so other files are recognized
+// before any decryption is attempted
+const magic = "MNYSYNC1"
+
+const (
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32 // AES-256
+)
+
+// kdfIterations is how many PBKDF2-SHA256 rounds stretch the passphrase.
+// It is written into every snapshot, so raising it doesn't break older ones.
+// Tests lower it to keep them fast.
+var kdfIterations = 600000
+
+// minKDFIterations and maxKDFIterations bound the count a snapshot may ask
+// for: fewer rounds would make a forged snapshot cheap to brute force, and
+// a count past the maximum would stall a pull for hours
+var minKDFIterations = 100000
+
+const maxKDFIterations = 10000000
+
+// ErrWrongPassphrase is returned when a snapshot can't be decrypted, either
+// because the passphrase differs or the file was tampered with
+var ErrWrongPassphrase = errors.New("wrong sync passphrase, or the snapshot was modified")
+
+// Snapshot describes one pushed copy of the database
+type Snapshot struct {
+	ID       string    `json:"id"`               // random, new for every push
+	Parent   string    `json:"parent,omitempty"` // snapshot it replaced on the remote
+	Host     string    `json:"host"`             // machine that pushed it
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// newSnapshotID returns a random snapshot ID
+func newSnapshotID() string {
+	return fmt.Sprintf("%x", randomBytes(8))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// Seal encrypts a snapshot of the database with AES-256-GCM, under a key
+// stretched from passphrase with a fresh salt. The snapshot's metadata is
+// encrypted along with the database, so the remote only ever sees
+// ciphertext.
+//
+// The layout is magic, the PBKDF2 iteration count, salt, nonce, then the
+// ciphertext of the metadata as a JSON line followed by the database. The
+// header is authenticated as additional data.
+func Seal(snapshot Snapshot, database []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("sync passphrase is empty")
+	}
+
+	meta, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	header := make([]byte, 0, len(magic)+4+saltSize+nonceSize)
+	header = append(header, magic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(kdfIterations))
+	header = append(header, randomBytes(saltSize)...)
+	header = append(header, randomBytes(nonceSize)...)
+
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, 0, len(meta)+1+len(database))
+	plaintext = append(plaintext, meta...)
+	plaintext = append(plaintext, '\n')
+	plaintext = append(plaintext, database...)
+
+	nonce := header[len(header)-nonceSize:]
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// Open decrypts a snapshot sealed with Seal, returning its metadata and the
+// database
+func Open(sealed []byte, passphrase string) (Snapshot, []byte, error) {
+	headerSize := len(magic) + 4 + saltSize + nonceSize
+	if len(sealed) < headerSize || string(sealed[:len(magic)]) != magic {
+		return Snapshot{}, nil, fmt.Errorf("not a money sync snapshot")
+	}
+	header := sealed[:headerSize]
+
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+
+	nonce := header[headerSize-nonceSize:]
+	plaintext, err := aead.Open(nil, nonce, sealed[headerSize:], header)
+	if err != nil {
+		return Snapshot{}, nil, ErrWrongPassphrase
+	}
+
+	meta, database, found := bytes.Cut(plaintext, []byte{'\n'})
+	if !found {
+		return Snapshot{}, nil, fmt.Errorf("snapshot is missing its metadata")
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(meta, &snapshot); err != nil {
+		return Snapshot{}, nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return snapshot, database, nil
+}
+
+// newAEAD derives the key for a snapshot header, which carries the
+// iteration count and salt, and returns the cipher
+func newAEAD(passphrase string, header []byte) (cipher.AEAD, error) {
+	iterations := binary.BigEndian.Uint32(header[len(magic):])
+	if iterations < uint32(minKDFIterations) || iterations > maxKDFIterations {
+		return nil, fmt.Errorf("invalid snapshot header: %d key derivation rounds, want %d to %d", iterations, minKDFIterations, maxKDFIterations)
+	}
+	salt := header[len(magic)+4 : len(magic)+4+saltSize]
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, int(iterations), keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,158 @@
+package dbsync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Git stores the snapshot as ObjectName in a git repository, committing each
+// push. Any URL git itself accepts works, including SSH remotes, with git's
+// own credentials. The repository is checked out under Dir.
+type Git struct {
+	URL string
+	Dir string
+}
+
+func (g *Git) String() string { return "git+" + g.URL }
+
+// Get updates the checkout to the remote's branch and returns the snapshot,
+// versioned by the commit it was read at
+func (g *Git) Get(ctx context.Context) ([]byte, string, error) {
+	if err := g.update(ctx); err != nil {
+		return nil, "", err
+	}
+
+	head, err := g.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		// An empty repository has no commits yet
+		return nil, "", ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(g.Dir, ObjectName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", ObjectName, err)
+	}
+	return data, head, nil
+}
+
+// Put commits the snapshot on top of version and pushes it. The push is
+// rejected as a non-fast-forward when another machine pushed in between.
+func (g *Git) Put(ctx context.Context, data []byte, version string) error {
+	if err := g.update(ctx); err != nil {
+		return err
+	}
+	head, _ := g.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if head != version {
+		return ErrConflict
+	}
+
+	if err := writeFileAtomic(filepath.Join(g.Dir, ObjectName), data); err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "add", ObjectName); err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, g.identity(ctx, "commit", "--quiet", "-m", "Update money snapshot")...); err != nil {
+		return err
+	}
+
+	branch, err := g.branch(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		if strings.Contains(err.Error(), "rejected") || strings.Contains(err.Error(), "fetch first") {
+			// The next update resets away the unpushed commit
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// update clones the repository on first use, and otherwise resets the
+// checkout to the remote's branch, discarding anything a failed push left
+func (g *Git) update(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(g.Dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(g.Dir), 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(g.Dir), err)
+		}
+		if _, err := runGit(ctx, "", "clone", "--quiet", g.URL, g.Dir); err != nil {
+			return fmt.Errorf("failed to clone %s: %w", g.URL, err)
+		}
+		return nil
+	}
+
+	if _, err := g.git(ctx, "remote", "set-url", "origin", g.URL); err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "fetch", "--quiet", "origin"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", g.URL, err)
+	}
+	branch, err := g.branch(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "rev-parse", "--verify", "--quiet", "origin/"+branch); err != nil {
+		// Nothing pushed to the branch yet
+		return nil
+	}
+	if _, err := g.git(ctx, "reset", "--quiet", "--hard", "origin/"+branch); err != nil {
+		return err
+	}
+	return nil
+}
+
+// branch returns the checked out branch, which for a fresh clone is the
+// remote's default
+func (g *Git) branch(ctx context.Context) (string, error) {
+	branch, err := g.git(ctx, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the branch of %s: %w", g.Dir, err)
+	}
+	return branch, nil
+}
+
+// identity prefixes args with a committer identity when git has none
+// configured, so committing doesn't fail on a fresh machine
+func (g *Git) identity(ctx context.Context, args ...string) []string {
+	if _, err := g.git(ctx, "config", "user.email"); err == nil {
+		return args
+	}
+	return append([]string{"-c", "user.name=money", "-c", "user.email=money@localhost"}, args...)
+}
+
+func (g *Git) git(ctx context.Context, args ...string) (string, error) {
+	return runGit(ctx, g.Dir, args...)
+}
+
+// runGit runs git in dir and returns its trimmed output, or an error
+// carrying what it printed to stderr
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		name := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			name = args[i+2]
+		}
+		return "", fmt.Errorf("git %s: %s", name, message)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,243 @@
+package dbsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 stores the snapshot as an object in an S3 bucket. Credentials and the
+// region come from the standard AWS environment variables. Setting
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL points it at an S3 compatible
+// service instead, like MinIO, Cloudflare R2 or Backblaze B2, addressed
+// path-style.
+type S3 struct {
+	Bucket       string
+	Key          string
+	Region       string
+	Endpoint     string // e.g. https://s3.us-east-1.amazonaws.com, without the bucket
+	PathStyle    bool   // put the bucket in the path instead of the host
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // for temporary credentials
+}
+
+// NewS3 returns an S3 backend configured from the environment
+func NewS3(bucket, key string) (*S3, error) {
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("S3 remotes look like s3://bucket/path/to/money.db.enc")
+	}
+
+	s := &S3{
+		Bucket:       bucket,
+		Key:          key,
+		Region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return nil, fmt.Errorf("S3 remotes need AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		s.Endpoint = strings.TrimSuffix(endpoint, "/")
+		s.PathStyle = true
+	} else {
+		s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+	}
+	return s, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (s *S3) String() string { return "s3://" + s.Bucket + "/" + s.Key }
+
+// objectURL returns the object's URL, virtual-hosted or path-style
+func (s *S3) objectURL() (*url.URL, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if s.PathStyle {
+		endpoint.Path = "/" + s.Bucket + "/" + s.Key
+	} else {
+		endpoint.Host = s.Bucket + "." + endpoint.Host
+		endpoint.Path = "/" + s.Key
+	}
+	// Escape the path the way the signature does, so the request sends
+	// exactly the path that was signed
+	endpoint.RawPath = awsEscapePath(endpoint.Path)
+	return endpoint, nil
+}
+
+// awsEscapePath percent-encodes every byte of path except unreserved
+// characters and slashes, as Signature Version 4 requires
+func awsEscapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// Get downloads the object, versioned by its ETag
+func (s *S3) Get(ctx context.Context) ([]byte, string, error) {
+	resp, err := s.do(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", s3Error("GET", resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download snapshot: %w", err)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Put uploads the object as a conditional write, If-Match or If-None-Match
+// for a first push, which S3 rejects when another machine pushed in between
+func (s *S3) Put(ctx context.Context, data []byte, version string) error {
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	if version == "" {
+		headers["If-None-Match"] = "*"
+	} else {
+		headers["If-Match"] = version
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, data, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 409 is S3's answer to two conditional writes racing each other
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("PUT", resp)
+	}
+	return nil
+}
+
+func (s *S3) do(ctx context.Context, method string, body []byte, headers map[string]string) (*http.Response, error) {
+	target, err := s.objectURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.sign(req, fmt.Sprintf("%x", sha256.Sum256(body)), time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+// s3Error reports a failed request with the code from S3's XML error body
+func s3Error(method string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	code := ""
+	if start := bytes.Index(body, []byte("<Code>")); start >= 0 {
+		rest := body[start+len("<Code>"):]
+		if end := bytes.Index(rest, []byte("</Code>")); end >= 0 {
+			code = ": " + string(rest[:end])
+		}
+	}
+	return fmt.Errorf("S3 %s failed with status %d%s", method, resp.StatusCode, code)
+}
+
+// sign adds AWS Signature Version 4 headers to req, signing the host and
+// every header already set
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsEscapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,186 @@
+// Package dbsync moves the database between machines through a remote the
+// user supplies. Every push uploads the whole database, encrypted with the
+// user's passphrase, so the remote only ever holds ciphertext. Each machine
+// remembers the last snapshot it pushed or pulled, which is how it notices
+// that another machine pushed since, or that its own database changed, and
+// refuses to overwrite either without --force.
+package dbsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrRemoteAhead is returned by Push when another machine pushed a snapshot
+// this one hasn't pulled, which the push would overwrite
+var ErrRemoteAhead = errors.New("the remote has a snapshot this machine hasn't pulled")
+
+// ErrLocalChanges is returned by Pull when the local database changed since
+// it was last synced, which the pull would overwrite
+var ErrLocalChanges = errors.New("the local database changed since it was last synced")
+
+// sqliteHeader starts every SQLite database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// State is what this machine remembers about its last sync
+type State struct {
+	Remote       string    `json:"remote"`
+	SnapshotID   string    `json:"snapshot_id"`   // last snapshot pushed or pulled
+	DatabaseHash string    `json:"database_hash"` // HashDatabase of the database at that point
+	SyncedAt     time.Time `json:"synced_at"`
+}
+
+// HashDatabase returns the hash sync compares databases by, of a copy made
+// with database.DB.Backup. The database file itself can't be hashed: SQLite
+// rewrites its header on writes that change nothing.
+func HashDatabase(database []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(database))
+}
+
+// Syncer pushes and pulls the database through a backend
+type Syncer struct {
+	Backend    Backend
+	Passphrase string
+	StatePath  string // where State is kept, e.g. $MONEY_DIR/sync.json
+	Host       string // recorded in pushed snapshots
+
+	state State
+}
+
+// LoadState reads the state of the last sync with s.Backend. A state left
+// by a different remote is ignored, since nothing was synced with this one.
+func (s *Syncer) LoadState() (State, error) {
+	s.state = State{}
+	data, err := os.ReadFile(s.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return s.state, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to parse %s: %w", s.StatePath, err)
+	}
+	if state.Remote == s.Backend.String() {
+		s.state = state
+	}
+	return s.state, nil
+}
+
+// Record saves that the database, hashed with HashDatabase, matches the
+// snapshot with id
+func (s *Syncer) Record(snapshotID, databaseHash string) error {
+	s.state = State{
+		Remote:       s.Backend.String(),
+		SnapshotID:   snapshotID,
+		DatabaseHash: databaseHash,
+		SyncedAt:     time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	return writeFileAtomic(s.StatePath, append(data, '\n'))
+}
+
+// Remote fetches and decrypts the snapshot on the remote, returning
+// ErrNotFound when nothing has been pushed. version is the backend's, to
+// pass to Put.
+func (s *Syncer) Remote(ctx context.Context) (snapshot Snapshot, database []byte, version string, err error) {
+	sealed, version, err := s.Backend.Get(ctx)
+	if err != nil {
+		return Snapshot{}, nil, "", err
+	}
+	snapshot, database, err = Open(sealed, s.Passphrase)
+	if err != nil {
+		return Snapshot{}, nil, "", err
+	}
+	return snapshot, database, version, nil
+}
+
+// Push uploads database, a copy made with database.DB.Backup, unless the
+// remote already holds it, in which case it returns the remote's snapshot
+// and false. Without force it fails with ErrRemoteAhead rather than replace
+// a snapshot this machine hasn't pulled.
+func (s *Syncer) Push(ctx context.Context, database []byte, force bool) (Snapshot, bool, error) {
+	if _, err := s.LoadState(); err != nil {
+		return Snapshot{}, false, err
+	}
+	hash := HashDatabase(database)
+
+	remote, _, version, err := s.Remote(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// First push to this remote
+	case err != nil && !force:
+		return Snapshot{}, false, err
+	case err != nil:
+		// Forced over a snapshot that can't be read, e.g. one encrypted
+		// with an old passphrase; its version is still needed to replace it
+		_, version, err = s.Backend.Get(ctx)
+		if err != nil {
+			return Snapshot{}, false, err
+		}
+	case remote.ID == s.state.SnapshotID && hash == s.state.DatabaseHash:
+		return remote, false, nil
+	case remote.ID != s.state.SnapshotID && !force:
+		return remote, false, ErrRemoteAhead
+	}
+
+	snapshot := Snapshot{
+		ID:       newSnapshotID(),
+		Parent:   remote.ID,
+		Host:     s.Host,
+		PushedAt: time.Now().UTC(),
+	}
+	sealed, err := Seal(snapshot, database, s.Passphrase)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	if err := s.Backend.Put(ctx, sealed, version); err != nil {
+		if errors.Is(err, ErrConflict) {
+			// Another machine pushed between the Get and the Put
+			return Snapshot{}, false, ErrRemoteAhead
+		}
+		return Snapshot{}, false, err
+	}
+
+	if err := s.Record(snapshot.ID, hash); err != nil {
+		return Snapshot{}, false, err
+	}
+	return snapshot, true, nil
+}
+
+// Pull downloads the remote's snapshot for the caller to install, then
+// Record, or returns a nil database when this machine already has it.
+// localHash is the HashDatabase of the local database, "" when there is
+// none. Without force it fails with ErrLocalChanges rather than replace
+// local changes that were never pushed.
+func (s *Syncer) Pull(ctx context.Context, localHash string, force bool) (Snapshot, []byte, error) {
+	if _, err := s.LoadState(); err != nil {
+		return Snapshot{}, nil, err
+	}
+
+	remote, database, _, err := s.Remote(ctx)
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+	if remote.ID == s.state.SnapshotID && !force {
+		return remote, nil, nil
+	}
+	if localHash != "" && localHash != s.state.DatabaseHash && !force {
+		return remote, nil, ErrLocalChanges
+	}
+	if !bytes.HasPrefix(database, []byte(sqliteHeader)) {
+		return Snapshot{}, nil, fmt.Errorf("the snapshot doesn't contain a database")
+	}
+	return remote, database, nil
+}
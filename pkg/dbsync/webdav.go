@@ -0,0 +1,112 @@
+package dbsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpClient is shared by the HTTP backends. Snapshots can be large, so the
+// timeout is generous.
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// WebDAV stores the snapshot as a file on a WebDAV server, such as
+// Nextcloud, ownCloud or Apache mod_dav. The folder it goes in must exist.
+type WebDAV struct {
+	URL      string
+	Username string // leave empty for servers without authentication
+	Password string
+}
+
+func (w *WebDAV) String() string { return w.URL }
+
+// Get downloads the file, versioned by its ETag, or by its contents when the
+// server sends none
+func (w *WebDAV) Get(ctx context.Context) ([]byte, string, error) {
+	resp, err := w.do(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("WebDAV GET failed with status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download snapshot: %w", err)
+	}
+
+	version := resp.Header.Get("ETag")
+	if version == "" {
+		version = contentVersion(data)
+	}
+	return data, version, nil
+}
+
+// Put uploads the file with If-Match, or If-None-Match for a first push, so
+// the server rejects it when another machine pushed in between. Servers
+// without ETags are checked by downloading the file first.
+func (w *WebDAV) Put(ctx context.Context, data []byte, version string) error {
+	headers := map[string]string{"Content-Type": "application/octet-stream"}
+	switch {
+	case version == "":
+		headers["If-None-Match"] = "*"
+	case strings.HasPrefix(version, "sha256:"):
+		_, current, err := w.Get(ctx)
+		if err != nil {
+			return err
+		}
+		if current != version {
+			return ErrConflict
+		}
+	default:
+		headers["If-Match"] = version
+	}
+
+	resp, err := w.do(ctx, http.MethodPut, data, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return ErrConflict
+	case resp.StatusCode == http.StatusConflict:
+		return fmt.Errorf("WebDAV PUT failed: the folder for %s doesn't exist", w.URL)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("WebDAV PUT failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *WebDAV) do(ctx context.Context, method string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebDAV request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if w.Username != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV %s failed: %w", method, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebDAV %s was refused with status %d; check the username and password in the remote", method, resp.StatusCode)
+	}
+	return resp, nil
+}
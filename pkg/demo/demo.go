@@ -0,0 +1,324 @@
+// Package demo fills a database with a year of realistic but made-up
+// finances, so every command can be tried, screenshotted, and tested
+// without linking a real bank.
+package demo
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// seed makes the generated data the same on every run, apart from dates
+const seed = 20240101
+
+// uncategorizedDays is how many recent days of transactions are left
+// uncategorized, as if they had just been fetched
+const uncategorizedDays = 10
+
+// pendingDays is how many recent days of transactions are still pending
+const pendingDays = 2
+
+// Summary counts what Generate created
+type Summary struct {
+	Accounts     int
+	Transactions int
+	Snapshots    int
+}
+
+type organization struct {
+	id, name, url string
+}
+
+type account struct {
+	id, orgID, name, accountType string
+	opening                      int64 // balance a year ago, in cents
+}
+
+var organizations = []organization{
+	{"demo-org-bank", "Maple Street Bank", "https://maplestreet.example"},
+	{"demo-org-card", "Northwind Card Services", "https://northwind.example"},
+	{"demo-org-invest", "Evergreen Investments", "https://evergreen.example"},
+}
+
+const (
+	checking  = "demo-checking"
+	savings   = "demo-savings"
+	credit    = "demo-credit"
+	brokerage = "demo-brokerage"
+	autoLoan  = "demo-auto-loan"
+)
+
+var accounts = []account{
+	{checking, "demo-org-bank", "Everyday Checking", "checking", 320000},
+	{savings, "demo-org-bank", "High Yield Savings", "savings", 1200000},
+	{credit, "demo-org-card", "Rewards Visa", "credit", -85000},
+	{brokerage, "demo-org-invest", "Index Fund Brokerage", "investment", 4500000},
+	{autoLoan, "demo-org-bank", "Auto Loan", "loan", -1450000},
+}
+
+var (
+	grocers     = []string{"WHOLE PANTRY #112", "GREENLEAF MARKET", "SAVEWAY 0423"}
+	restaurants = []string{"BLUE DOOR CAFE", "TACO TRUCK 9", "PIZZERIA NAPOLI", "SUSHI GO", "CORNER BAKERY"}
+	shops       = []string{"AMAZING.COM MKTPLACE", "HOMEGOODS STORE", "TARGETED 0091", "BOOKNOOK"}
+	venues      = []string{"CINEMAPLEX 12", "CITY BOWLING", "RIVERSIDE CONCERTS"}
+	clinics     = []string{"MAPLE PHARMACY", "FAMILY MEDICAL GROUP", "BRIGHT SMILE DENTAL"}
+)
+
+// budgets are the monthly targets set for the demo, in cents
+var budgets = map[string]int64{
+	"Groceries":     60000,
+	"Dining Out":    35000,
+	"Shopping":      25000,
+	"Entertainment": 10000,
+	"Subscriptions": 3000,
+}
+
+type transaction struct {
+	accountID   string
+	posted      time.Time
+	amount      int64
+	description string
+	category    string
+}
+
+type snapshot struct {
+	accountID  string
+	balance    int64
+	recordedAt time.Time
+}
+
+type generator struct {
+	rng       *rand.Rand
+	balances  map[string]int64
+	txns      []transaction
+	snapshots []snapshot
+}
+
+// Generate fills db with organizations, accounts, a year of transactions
+// ending at now, daily balance history, budgets, and bills. Transactions from
+// the last few days are left uncategorized so categorization can be tried.
+func Generate(db *database.DB, now time.Time) (Summary, error) {
+	g := &generator{
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		balances: make(map[string]int64),
+	}
+	for _, acc := range accounts {
+		g.balances[acc.id] = acc.opening
+	}
+
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(-1, 0, 0)
+	for day, index := start, 0; !day.After(today); day, index = day.AddDate(0, 0, 1), index+1 {
+		g.generateDay(day, index)
+
+		// The market moves the brokerage balance without any transactions
+		g.balances[brokerage] += int64(float64(g.balances[brokerage]) * (0.0003 + 0.008*g.rng.NormFloat64()))
+
+		recordedAt := day.Add(20 * time.Hour)
+		if recordedAt.After(now) {
+			recordedAt = now
+		}
+		for _, acc := range accounts {
+			g.snapshots = append(g.snapshots, snapshot{acc.id, g.balances[acc.id], recordedAt})
+		}
+	}
+
+	if err := g.save(db, now, today); err != nil {
+		return Summary{}, err
+	}
+
+	return Summary{
+		Accounts:     len(accounts),
+		Transactions: len(g.txns),
+		Snapshots:    len(g.snapshots),
+	}, nil
+}
+
+// generateDay adds the transactions posted on day, the index-th day of the year
+func (g *generator) generateDay(day time.Time, index int) {
+	posted := day.Add(12 * time.Hour)
+	dom := day.Day()
+
+	// Fixed income and bills
+	if index%14 == 4 {
+		g.add(checking, posted, 284615, "ACME CORP PAYROLL PPD", "Income")
+	}
+	switch dom {
+	case 1:
+		g.add(checking, posted, -225000, "OAKWOOD APTS RENT", "Housing")
+	case 3:
+		g.add(credit, posted, -1549, "STREAMFLIX.COM", "Subscriptions")
+	case 5:
+		g.transfer(checking, autoLoan, posted, 38500, "MAPLE ST AUTO LOAN PMT", "PAYMENT RECEIVED")
+	case 8:
+		g.add(checking, posted, -g.between(9000, 16000), "CITY POWER & LIGHT", "Bills & Services")
+	case 9:
+		g.add(credit, posted, -1099, "TUNESTREAM MUSIC", "Subscriptions")
+	case 12:
+		g.add(credit, posted, -7000, "FASTNET INTERNET", "Bills & Services")
+	case 15:
+		g.add(credit, posted, -5500, "CELLCO WIRELESS", "Bills & Services")
+	case 18:
+		g.add(credit, posted, -3500, "CLIP JOINT SALON", "Personal Care")
+	case 20:
+		g.transfer(checking, savings, posted, 80000, "ONLINE TRANSFER TO SAVINGS", "ONLINE TRANSFER FROM CHECKING")
+	case 22:
+		// The card is paid off in full each month
+		if owed := -g.balances[credit]; owed > 0 {
+			g.transfer(checking, credit, posted, owed, "NORTHWIND CARD ONLINE PMT", "PAYMENT - THANK YOU")
+		}
+	case 25:
+		g.transfer(checking, brokerage, posted, 40000, "EVERGREEN INVEST CONTRIB", "CONTRIBUTION")
+	}
+	if day.AddDate(0, 0, 1).Day() == 1 {
+		interest := g.balances[savings] * 4 / 100 / 12
+		g.add(savings, posted, interest, "INTEREST PAYMENT", "Income")
+	}
+
+	// Everyday spending on the card
+	if day.Weekday() == time.Saturday {
+		g.add(credit, posted, -g.between(6000, 18000), g.pick(grocers), "Groceries")
+	}
+	if index%9 == 2 {
+		g.add(credit, posted, -g.between(3500, 6000), "SHELLBY GAS 221", "Transportation")
+	}
+	if g.chance(0.3) {
+		g.add(credit, posted, -g.between(1200, 7500), g.pick(restaurants), "Dining Out")
+	}
+	if g.chance(0.08) {
+		g.add(credit, posted, -g.between(2000, 20000), g.pick(shops), "Shopping")
+	}
+	if g.chance(0.04) {
+		g.add(credit, posted, -g.between(1500, 6000), g.pick(venues), "Entertainment")
+	}
+	if g.chance(0.02) {
+		g.add(credit, posted, -g.between(2500, 18000), g.pick(clinics), "Healthcare")
+	}
+	if g.chance(0.01) {
+		g.add(checking, posted, -300, "ATM FEE", "Fees")
+	}
+
+	// One trip during the year
+	switch index {
+	case 200:
+		g.add(credit, posted, -42000, "SKYWAY AIRLINES", "Travel")
+	case 205:
+		g.add(credit, posted, -61000, "HARBOR HOTEL", "Travel")
+	}
+}
+
+func (g *generator) add(accountID string, posted time.Time, amount int64, description, category string) {
+	g.balances[accountID] += amount
+	g.txns = append(g.txns, transaction{accountID, posted, amount, description, category})
+}
+
+// transfer moves amount between two of the demo accounts, filing both sides
+// under the internal Transfers category
+func (g *generator) transfer(from, to string, posted time.Time, amount int64, outDescription, inDescription string) {
+	g.add(from, posted, -amount, outDescription, "Transfers")
+	g.add(to, posted, amount, inDescription, "Transfers")
+}
+
+// between returns a random amount in cents from min to max inclusive
+func (g *generator) between(min, max int64) int64 {
+	return min + g.rng.Int64N(max-min+1)
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+func (g *generator) pick(options []string) string {
+	return options[g.rng.IntN(len(options))]
+}
+
+func (g *generator) save(db *database.DB, now, today time.Time) error {
+	if err := db.SeedDefaultCategories(); err != nil {
+		return err
+	}
+	categories, err := db.GetCategories()
+	if err != nil {
+		return err
+	}
+	categoryIDs := make(map[string]int)
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+
+	for _, org := range organizations {
+		if err := db.SaveOrganization(org.id, org.name, org.url); err != nil {
+			return err
+		}
+	}
+
+	balanceDate := now.Format(time.RFC3339)
+	for _, acc := range accounts {
+		if err := db.SaveAccount(acc.id, acc.orgID, acc.name, "USD", g.balances[acc.id], nil, balanceDate); err != nil {
+			return err
+		}
+		if err := db.SetAccountType(acc.id, acc.accountType); err != nil {
+			return err
+		}
+	}
+
+	uncategorizedFrom := today.AddDate(0, 0, -uncategorizedDays)
+	pendingFrom := today.AddDate(0, 0, -pendingDays)
+	idsByCategory := make(map[int][]string)
+	for i, txn := range g.txns {
+		id := fmt.Sprintf("demo-txn-%05d", i+1)
+		posted := txn.posted
+		if posted.After(now) {
+			posted = now
+		}
+		pending := !txn.posted.Before(pendingFrom)
+		if err := db.SaveTransaction(id, txn.accountID, posted.Format(time.RFC3339), txn.amount, txn.description, pending); err != nil {
+			return err
+		}
+
+		if txn.posted.Before(uncategorizedFrom) {
+			categoryID, ok := categoryIDs[txn.category]
+			if !ok {
+				return fmt.Errorf("demo category %q does not exist", txn.category)
+			}
+			idsByCategory[categoryID] = append(idsByCategory[categoryID], id)
+		}
+	}
+	for categoryID, ids := range idsByCategory {
+		if _, err := db.UpdateTransactionCategories(ids, categoryID); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range g.snapshots {
+		if err := db.SaveBalanceHistoryAt(s.accountID, s.balance, nil, s.recordedAt); err != nil {
+			return err
+		}
+	}
+
+	for name, amount := range budgets {
+		if err := db.SetBudget(categoryIDs[name], amount); err != nil {
+			return err
+		}
+	}
+	if _, err := db.SaveBill("Rent", 225000, 1, checking); err != nil {
+		return err
+	}
+	if _, err := db.SaveBill("Internet", 7000, 12, credit); err != nil {
+		return err
+	}
+	if err := db.SetLowBalanceThreshold(checking, 100000); err != nil {
+		return err
+	}
+	if err := db.SetCreditLimit(credit, 800000); err != nil {
+		return err
+	}
+	if err := db.SetStatementDueDay(credit, 25); err != nil {
+		return err
+	}
+
+	return nil
+}
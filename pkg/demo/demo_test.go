@@ -0,0 +1,67 @@
+package demo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2024, 6, 15, 18, 0, 0, 0, time.UTC)
+	start := time.Now()
+	summary, err := Generate(db, now)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	t.Logf("generated %+v in %v", summary, time.Since(start))
+
+	if summary.Accounts != len(accounts) || summary.Snapshots != 367*len(accounts) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	count, err := db.CountTransactions(database.TransactionFilter{})
+	if err != nil || count != summary.Transactions {
+		t.Errorf("CountTransactions = %d, %v; want %d", count, err, summary.Transactions)
+	}
+
+	uncategorized, err := db.GetUncategorizedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uncategorized) == 0 || len(uncategorized) == summary.Transactions {
+		t.Errorf("expected only recent transactions to be uncategorized, got %d of %d", len(uncategorized), summary.Transactions)
+	}
+
+	// The last snapshot of each account matches its balance
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := db.GetAllBalanceHistory(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := make(map[string]int64)
+	for _, h := range history {
+		latest[h.AccountID] = h.Balance
+	}
+	for _, account := range accounts {
+		if latest[account.ID] != account.Balance {
+			t.Errorf("%s: last snapshot %d, balance %d", account.ID, latest[account.ID], account.Balance)
+		}
+	}
+}
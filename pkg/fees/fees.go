@@ -0,0 +1,58 @@
+// Package fees recognizes interest charges and bank fees by their
+// descriptions, so they can be filed under their own categories before
+// the LLM sees them.
+package fees
+
+import (
+	"regexp"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// InterestCategory is the category interest charged on balances is filed
+// under
+const InterestCategory = "Interest"
+
+// FeesCategory is the category bank and card fees are filed under
+const FeesCategory = "Fees"
+
+var (
+	// interestPattern matches interest charged on a card or loan balance.
+	// Interest earned is a deposit, so only withdrawals are checked.
+	interestPattern = regexp.MustCompile(`(?i)\b(interest|finance charges?)\b`)
+
+	// feePattern matches fees and their refunds, like "LATE FEE" or
+	// "ATM FEE REBATE", so refunds net against the fees they return
+	feePattern = regexp.MustCompile(`(?i)\b(fees?|service charges?|late charges?|overdraft|nsf|returned item charges?)\b`)
+)
+
+// Category returns the category a transaction belongs in when its
+// description, or the display description rename rules gave it, reads
+// like interest or a fee, and false otherwise
+func Category(txn database.Transaction) (string, bool) {
+	for _, description := range []string{txn.DisplayDescription(), txn.Description} {
+		if txn.Amount < 0 && interestPattern.MatchString(description) {
+			return InterestCategory, true
+		}
+		if feePattern.MatchString(description) {
+			return FeesCategory, true
+		}
+	}
+	return "", false
+}
+
+// Match groups the transactions that read like interest or fees by the
+// category they belong in. Pending transactions are left out, since their
+// descriptions often change when they post.
+func Match(transactions []database.Transaction) map[string][]database.Transaction {
+	matches := make(map[string][]database.Transaction)
+	for _, txn := range transactions {
+		if txn.Pending || txn.Amount == 0 {
+			continue
+		}
+		if category, ok := Category(txn); ok {
+			matches[category] = append(matches[category], txn)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,53 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestCategory(t *testing.T) {
+	renamed := "Overdraft Fee"
+	tests := []struct {
+		name       string
+		txn        database.Transaction
+		category   string
+		recognized bool
+	}{
+		{"card interest", database.Transaction{Description: "INTEREST CHARGE ON PURCHASES", Amount: -4312}, InterestCategory, true},
+		{"finance charge", database.Transaction{Description: "FINANCE CHARGE", Amount: -1500}, InterestCategory, true},
+		{"interest earned", database.Transaction{Description: "INTEREST PAYMENT", Amount: 350}, "", false},
+		{"atm fee", database.Transaction{Description: "ATM FEE", Amount: -300}, FeesCategory, true},
+		{"fee refund", database.Transaction{Description: "ATM FEE REBATE", Amount: 300}, FeesCategory, true},
+		{"service charge", database.Transaction{Description: "MONTHLY SERVICE CHARGE", Amount: -1200}, FeesCategory, true},
+		{"renamed", database.Transaction{Description: "ODP XFER 0042", CleanDescription: &renamed, Amount: -3500}, FeesCategory, true},
+		{"coffee", database.Transaction{Description: "COFFEE HOUSE", Amount: -450}, "", false},
+		{"feed store", database.Transaction{Description: "FEED & SEED SUPPLY", Amount: -2500}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, recognized := Category(tt.txn)
+			if category != tt.category || recognized != tt.recognized {
+				t.Errorf("Category(%q) = %q, %v; want %q, %v", tt.txn.Description, category, recognized, tt.category, tt.recognized)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	transactions := []database.Transaction{
+		{ID: "interest", Description: "PURCHASE INTEREST CHARGE", Amount: -2500},
+		{ID: "late", Description: "LATE FEE", Amount: -3900},
+		{ID: "pending", Description: "FOREIGN TRANSACTION FEE", Amount: -120, Pending: true},
+		{ID: "groceries", Description: "GREENLEAF MARKET", Amount: -6000},
+	}
+
+	matches := Match(transactions)
+	if len(matches) != 2 || len(matches[InterestCategory]) != 1 || len(matches[FeesCategory]) != 1 {
+		t.Fatalf("Expected one interest charge and one fee, got %+v", matches)
+	}
+	if matches[FeesCategory][0].ID != "late" {
+		t.Errorf("Expected the late fee, got %s", matches[FeesCategory][0].ID)
+	}
+}
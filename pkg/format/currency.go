@@ -2,27 +2,57 @@ package format
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 )
 
-func Currency(cents int, currency string) string {
-	symbol := currencySymbol(currency)
-	var wholePart int64
-	var decimalPart int
-	var negative bool
+// baseCurrency is the currency of amounts that aren't one account's, such as
+// totals across accounts and budgets
+var baseCurrency = "USD"
 
-	if cents < 0 {
-		negative = true
-		cents = -cents
+// SetBaseCurrency sets the currency of amounts that aren't one account's,
+// from the currency setting when the CLI starts. An empty code is USD.
+func SetBaseCurrency(code string) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		code = "USD"
 	}
+	baseCurrency = code
+}
 
-	wholePart = int64(cents / 100)
-	decimalPart = cents % 100
-	wholeStr := withCommas(wholePart)
+// BaseCurrency returns the currency of amounts that aren't one account's
+func BaseCurrency() string {
+	return baseCurrency
+}
+
+// Currency formats cents as an amount of currency, with the symbol placed
+// the way that currency writes it and the separators of the configured
+// locale. Negative amounts get a minus sign, or parentheses when accounting
+// negatives are on. An empty currency is the base currency.
+func Currency(cents int64, currency string) string {
+	if currency == "" {
+		currency = baseCurrency
+	}
+	negative := cents < 0
 	if negative {
-		return fmt.Sprintf("-%s%s.%02d", symbol, wholeStr, decimalPart)
-	} else {
-		return fmt.Sprintf("%s%s.%02d", symbol, wholeStr, decimalPart)
+		cents = -cents
+	}
+
+	number := groupDigits(cents/100, locale.Group) + locale.Decimal + fmt.Sprintf("%02d", cents%100)
+	symbol, after := currencySymbol(currency)
+	amount := symbol + number
+	if after {
+		amount = number + symbol
+	}
+
+	switch {
+	case !negative:
+		return amount
+	case accounting:
+		return "(" + amount + ")"
+	default:
+		return "-" + amount
 	}
 }
 
@@ -31,6 +61,11 @@ func WithCommas(n int64) string {
 }
 
 func withCommas(n int64) string {
+	return groupDigits(n, ",")
+}
+
+// groupDigits writes n with sep between each group of three digits
+func groupDigits(n int64, sep string) string {
 	if n == 0 {
 		return "0"
 	}
@@ -45,24 +80,104 @@ func withCommas(n int64) string {
 		parts = append([]string{str[start:i]}, parts...)
 	}
 
-	return strings.Join(parts, ",")
+	return strings.Join(parts, sep)
 }
 
-func currencySymbol(currency string) string {
-	switch strings.ToUpper(currency) {
-	case "USD":
-		return "$"
-	case "EUR":
-		return "€"
-	case "GBP":
-		return "£"
-	case "JPY":
-		return "¥"
-	case "CAD":
-		return "C$"
-	case "AUD":
-		return "A$"
-	default:
-		return currency + " "
+// ParseCents parses a user-entered amount such as "1,234.56" or "$20" into
+// cents, rounding to the nearest cent. Separators are read the way the
+// current locale writes them, so "1.234,56" is the same amount in de-DE.
+func ParseCents(amount string) (int64, error) {
+	cleaned := strings.TrimSpace(amount)
+	cleaned = strings.TrimPrefix(cleaned, "$")
+	if strings.HasPrefix(cleaned, "-$") {
+		cleaned = "-" + cleaned[2:]
+	}
+
+	// Drop group separators, including the plain space or apostrophe
+	// people type for the ones that are hard to type, then use a point
+	// for ParseFloat
+	cleaned = strings.ReplaceAll(cleaned, locale.Group, "")
+	switch locale.Group {
+	case "\u00a0":
+		cleaned = strings.ReplaceAll(cleaned, " ", "")
+	case "\u2019":
+		cleaned = strings.ReplaceAll(cleaned, "'", "")
+	}
+	cleaned = strings.ReplaceAll(cleaned, locale.Decimal, ".")
+
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid amount: %s", amount)
+	}
+
+	return int64(math.Round(value * 100)), nil
+}
+
+// ParseCurrency parses an amount written by Currency with the current
+// locale and negative style back into cents. Any currency symbol is
+// ignored.
+func ParseCurrency(amount string) (int64, error) {
+	cleaned := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(cleaned, "-") ||
+		(strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")"))
+
+	// Keep the digits and the decimal separator, dropping symbols, signs
+	// and group separators
+	var digits strings.Builder
+	for len(cleaned) > 0 {
+		if strings.HasPrefix(cleaned, locale.Decimal) {
+			digits.WriteByte('.')
+			cleaned = cleaned[len(locale.Decimal):]
+			continue
+		}
+		if c := cleaned[0]; c >= '0' && c <= '9' {
+			digits.WriteByte(c)
+		}
+		cleaned = cleaned[1:]
+	}
+
+	value, err := strconv.ParseFloat(digits.String(), 64)
+	if err != nil || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid amount: %s", amount)
+	}
+	cents := int64(math.Round(value * 100))
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// currencySymbols are the symbols of known currencies, and whether the
+// symbol goes after the number
+var currencySymbols = map[string]struct {
+	symbol string
+	after  bool
+}{
+	"USD": {"$", false},
+	"EUR": {"€", false},
+	"GBP": {"£", false},
+	"JPY": {"¥", false},
+	"CNY": {"¥", false},
+	"CAD": {"C$", false},
+	"AUD": {"A$", false},
+	"NZD": {"NZ$", false},
+	"MXN": {"MX$", false},
+	"BRL": {"R$", false},
+	"INR": {"₹", false},
+	"KRW": {"₩", false},
+	"CHF": {"CHF ", false},
+	"SEK": {" kr", true},
+	"NOK": {" kr", true},
+	"DKK": {" kr", true},
+	"PLN": {" zł", true},
+	"CZK": {" Kč", true},
+}
+
+// currencySymbol returns the symbol for a currency code and whether it goes
+// after the number. Unknown currencies are written with their code first.
+func currencySymbol(currency string) (string, bool) {
+	if known, ok := currencySymbols[strings.ToUpper(currency)]; ok {
+		return known.symbol, known.after
 	}
+	return currency + " ", false
 }
@@ -5,7 +5,7 @@ import "testing"
 func TestCurrency(t *testing.T) {
 	tests := []struct {
 		name     string
-		cents    int
+		cents    int64
 		currency string
 		expected string
 	}{
@@ -33,6 +33,12 @@ func TestCurrency(t *testing.T) {
 			currency: "USD",
 			expected: "$1,234,567.89",
 		},
+		{
+			name:     "amount beyond 32 bits",
+			cents:    987654321012,
+			currency: "USD",
+			expected: "$9,876,543,210.12",
+		},
 		{
 			name:     "EUR currency",
 			cents:    50000,
@@ -57,6 +63,30 @@ func TestCurrency(t *testing.T) {
 	}
 }
 
+func TestBaseCurrency(t *testing.T) {
+	defer SetBaseCurrency("")
+
+	if got := Currency(150, ""); got != "$1.50" {
+		t.Errorf("Currency(150, \"\") = %q; want %q", got, "$1.50")
+	}
+
+	SetBaseCurrency(" eur ")
+	if got := BaseCurrency(); got != "EUR" {
+		t.Errorf("BaseCurrency() = %q; want %q", got, "EUR")
+	}
+	if got := Currency(150, ""); got != "€1.50" {
+		t.Errorf("Currency(150, \"\") = %q; want %q", got, "€1.50")
+	}
+	if got := Currency(150, "GBP"); got != "£1.50" {
+		t.Errorf("Currency(150, \"GBP\") = %q; want %q", got, "£1.50")
+	}
+
+	SetBaseCurrency("")
+	if got := BaseCurrency(); got != "USD" {
+		t.Errorf("BaseCurrency() after reset = %q; want %q", got, "USD")
+	}
+}
+
 func TestWithCommas(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -99,3 +129,73 @@ func TestWithCommas(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCents(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"20", 2000, false},
+		{"$1,234.56", 123456, false},
+		{"0.1", 10, false},
+		{"19.99", 1999, false},
+		{"-45.50", -4550, false},
+		{"-$45.50", -4550, false},
+		{" 12 ", 1200, false},
+		{"45,000,000.01", 4500000001, false},
+		{"abc", 0, true},
+		{"", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseCents(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseCents(%q) expected error, got %d", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCents(%q) unexpected error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseCents(%q) = %d; want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCentsLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	tests := []struct {
+		locale   string
+		input    string
+		expected int64
+	}{
+		{"de-DE", "1,50", 150},
+		{"de-DE", "1.234,56", 123456},
+		{"de-DE", "-45,5", -4550},
+		{"fr-FR", "1\u00a0234,56", 123456},
+		{"fr-FR", "1 234,56", 123456},
+		{"de-CH", "1'234.56", 123456},
+		{"en-US", "1,234.56", 123456},
+	}
+
+	for _, tt := range tests {
+		if err := SetLocale(tt.locale); err != nil {
+			t.Fatalf("SetLocale(%q): %v", tt.locale, err)
+		}
+		result, err := ParseCents(tt.input)
+		if err != nil {
+			t.Errorf("ParseCents(%q) in %s unexpected error: %v", tt.input, tt.locale, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("ParseCents(%q) in %s = %d; want %d", tt.input, tt.locale, result, tt.expected)
+		}
+	}
+}
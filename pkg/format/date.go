@@ -2,8 +2,66 @@ package format
 
 import "time"
 
+// location is the time zone dates are shown, filtered and grouped in
+var location = time.Local
+
+// SetLocation sets the time zone dates are shown, filtered and grouped in,
+// from the timezone setting when the CLI starts
+func SetLocation(loc *time.Location) {
+	location = loc
+}
+
+// Location returns the time zone dates are shown, filtered and grouped in
+func Location() *time.Location {
+	return location
+}
+
+// Now returns the current time in the configured time zone
+func Now() time.Time {
+	return time.Now().In(location)
+}
+
+// PostedTime parses a stored posted time, RFC3339 in UTC, into the
+// configured time zone. A bare YYYY-MM-DD date is midnight of that day.
+func PostedTime(posted string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, posted); err == nil {
+		return t.In(location), nil
+	}
+	return time.ParseInLocation("2006-01-02", posted, location)
+}
+
+// PostedDate returns the YYYY-MM-DD day a stored posted time falls on in the
+// configured time zone, or posted unchanged when it can't be parsed
+func PostedDate(posted string) string {
+	t, err := PostedTime(posted)
+	if err != nil {
+		return posted
+	}
+	return t.Format("2006-01-02")
+}
+
+// PostedMonth returns the YYYY-MM month a stored posted time falls in in the
+// configured time zone, or "" when it can't be parsed
+func PostedMonth(posted string) string {
+	t, err := PostedTime(posted)
+	if err != nil {
+		return ""
+	}
+	return t.Format("2006-01")
+}
+
+// DayStart returns the instant a YYYY-MM-DD date begins in the configured
+// time zone, formatted like stored posted times so the two compare as strings
+func DayStart(date string) (string, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, location)
+	if err != nil {
+		return "", err
+	}
+	return day.UTC().Format(time.RFC3339), nil
+}
+
 func DateRange(args []string) (startDate, endDate string) {
-	now := time.Now()
+	now := Now()
 	for i, arg := range args {
 		if (arg == "--start" || arg == "-s") && i+1 < len(args) {
 			startDate = args[i+1]
@@ -2,6 +2,7 @@ package format
 
 import (
 	"testing"
+	"time"
 )
 
 func TestDateRange(t *testing.T) {
@@ -74,3 +75,39 @@ func TestDateForDisplay(t *testing.T) {
 		})
 	}
 }
+
+func TestPostedDateInLocation(t *testing.T) {
+	defer SetLocation(Location())
+	SetLocation(time.FixedZone("EST", -5*60*60))
+
+	tests := []struct {
+		posted string
+		date   string
+		month  string
+	}{
+		// A late-evening purchase is on the previous day in UTC-5
+		{"2024-02-01T04:30:00Z", "2024-01-31", "2024-01"},
+		{"2024-02-01T05:00:00Z", "2024-02-01", "2024-02"},
+		{"2024-02-01", "2024-02-01", "2024-02"},
+		{"not a date", "not a date", ""},
+	}
+	for _, tt := range tests {
+		if got := PostedDate(tt.posted); got != tt.date {
+			t.Errorf("PostedDate(%q) = %q; want %q", tt.posted, got, tt.date)
+		}
+		if got := PostedMonth(tt.posted); got != tt.month {
+			t.Errorf("PostedMonth(%q) = %q; want %q", tt.posted, got, tt.month)
+		}
+	}
+
+	start, err := DayStart("2024-01-31")
+	if err != nil {
+		t.Fatalf("DayStart: %v", err)
+	}
+	if start != "2024-01-31T05:00:00Z" {
+		t.Errorf("DayStart(2024-01-31) = %q; want 2024-01-31T05:00:00Z", start)
+	}
+	if _, err := DayStart("01/31/2024"); err == nil {
+		t.Error("DayStart should reject dates that aren't YYYY-MM-DD")
+	}
+}
@@ -0,0 +1,105 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale is how a locale writes numbers: the separator between groups of
+// thousands and the one before the fractional part
+type Locale struct {
+	Name    string
+	Group   string
+	Decimal string
+}
+
+// locales are the supported locales by lowercase name
+var locales = buildLocales()
+
+func buildLocales() map[string]Locale {
+	locales := make(map[string]Locale)
+	for _, group := range []struct {
+		group, decimal string
+		names          []string
+	}{
+		{",", ".", []string{"en-US", "en-GB", "en-CA", "en-AU", "en-NZ", "en-IE", "ja-JP", "ko-KR", "zh-CN", "he-IL", "th-TH"}},
+		{".", ",", []string{"de-DE", "de-AT", "es-ES", "it-IT", "nl-NL", "pt-BR", "da-DK", "tr-TR", "id-ID", "el-GR"}},
+		{"\u00a0", ",", []string{"fr-FR", "fr-CA", "pt-PT", "sv-SE", "nb-NO", "fi-FI", "pl-PL", "cs-CZ", "sk-SK", "ru-RU", "uk-UA", "hu-HU"}},
+		{"\u2019", ".", []string{"de-CH", "fr-CH", "it-CH"}},
+	} {
+		for _, name := range group.names {
+			locales[strings.ToLower(name)] = Locale{Name: name, Group: group.group, Decimal: group.decimal}
+		}
+	}
+	return locales
+}
+
+// languageLocales picks the locale for a bare language such as "de"
+var languageLocales = map[string]string{
+	"en": "en-US", "ja": "ja-JP", "ko": "ko-KR", "zh": "zh-CN", "he": "he-IL", "th": "th-TH",
+	"de": "de-DE", "es": "es-ES", "it": "it-IT", "nl": "nl-NL", "pt": "pt-BR", "da": "da-DK",
+	"tr": "tr-TR", "id": "id-ID", "el": "el-GR", "fr": "fr-FR", "sv": "sv-SE", "nb": "nb-NO",
+	"no": "nb-NO", "fi": "fi-FI", "pl": "pl-PL", "cs": "cs-CZ", "sk": "sk-SK", "ru": "ru-RU",
+	"uk": "uk-UA", "hu": "hu-HU",
+}
+
+// DefaultLocale is the locale amounts are written in when none is set
+const DefaultLocale = "en-US"
+
+// locale is the locale amounts are written in
+var locale = locales["en-us"]
+
+// accounting writes negative amounts in parentheses instead of with a
+// minus sign
+var accounting bool
+
+// LookupLocale returns the locale with the given name, such as "de-DE",
+// "de_DE" or just "de". Case doesn't matter, and an encoding suffix like
+// ".UTF-8" is ignored, so $LANG values work.
+func LookupLocale(name string) (Locale, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	normalized, _, _ = strings.Cut(normalized, ".")
+	if l, ok := locales[normalized]; ok {
+		return l, nil
+	}
+	if full, ok := languageLocales[normalized]; ok {
+		return locales[strings.ToLower(full)], nil
+	}
+	return Locale{}, fmt.Errorf("unsupported locale %q, use one of %s", name, strings.Join(LocaleNames(), ", "))
+}
+
+// LocaleNames returns the names of the supported locales, sorted
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for _, l := range locales {
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetLocale sets the locale amounts are written in, from the locale
+// setting when the CLI starts. An empty name is DefaultLocale.
+func SetLocale(name string) error {
+	if name == "" {
+		name = DefaultLocale
+	}
+	l, err := LookupLocale(name)
+	if err != nil {
+		return err
+	}
+	locale = l
+	return nil
+}
+
+// CurrentLocale returns the locale amounts are written in
+func CurrentLocale() Locale {
+	return locale
+}
+
+// SetAccountingNegatives sets whether negative amounts are written in
+// parentheses, as in accounting, instead of with a minus sign
+func SetAccountingNegatives(enabled bool) {
+	accounting = enabled
+}
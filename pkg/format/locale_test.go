@@ -0,0 +1,74 @@
+package format
+
+import "testing"
+
+func TestCurrencyLocale(t *testing.T) {
+	defer SetAccountingNegatives(false)
+	defer SetLocale(DefaultLocale)
+
+	tests := []struct {
+		locale     string
+		accounting bool
+		cents      int64
+		currency   string
+		expected   string
+	}{
+		{"en-US", false, -123456, "USD", "-$1,234.56"},
+		{"en-US", true, -123456, "USD", "($1,234.56)"},
+		{"en-US", true, 123456, "USD", "$1,234.56"},
+		{"de-DE", false, 123456789, "EUR", "€1.234.567,89"},
+		{"de_DE.UTF-8", true, -50, "EUR", "(€0,50)"},
+		{"fr", false, 123456, "EUR", "€1\u00a0234,56"},
+		{"sv-SE", false, -123456, "SEK", "-1\u00a0234,56 kr"},
+		{"de-CH", false, 100000, "CHF", "CHF 1\u2019000.00"},
+		{"en-US", false, 995, "PLN", "9.95 zł"},
+		{"en-US", false, 10000, "XYZ", "XYZ 100.00"},
+	}
+
+	for _, tt := range tests {
+		if err := SetLocale(tt.locale); err != nil {
+			t.Fatalf("SetLocale(%q): %v", tt.locale, err)
+		}
+		SetAccountingNegatives(tt.accounting)
+
+		result := Currency(tt.cents, tt.currency)
+		if result != tt.expected {
+			t.Errorf("Currency(%d, %s) in %s = %q; want %q", tt.cents, tt.currency, tt.locale, result, tt.expected)
+		}
+
+		cents, err := ParseCurrency(result)
+		if err != nil || cents != tt.cents {
+			t.Errorf("ParseCurrency(%q) in %s = %d, %v; want %d", result, tt.locale, cents, err, tt.cents)
+		}
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	if err := SetLocale("xx-YY"); err == nil {
+		t.Error("SetLocale should fail for an unknown locale")
+	}
+	if got := CurrentLocale().Name; got != DefaultLocale {
+		t.Errorf("locale after a failed SetLocale = %s; want %s", got, DefaultLocale)
+	}
+
+	if err := SetLocale("PT"); err != nil {
+		t.Fatalf("SetLocale(PT): %v", err)
+	}
+	if got := CurrentLocale(); got.Name != "pt-BR" || got.Group != "." || got.Decimal != "," {
+		t.Errorf("SetLocale(PT) = %+v; want pt-BR", got)
+	}
+
+	if err := SetLocale(""); err != nil || CurrentLocale().Name != DefaultLocale {
+		t.Errorf("SetLocale(\"\") = %v, locale %s; want %s", err, CurrentLocale().Name, DefaultLocale)
+	}
+}
+
+func TestParseCurrencyInvalid(t *testing.T) {
+	for _, amount := range []string{"-", "N/A", ""} {
+		if _, err := ParseCurrency(amount); err == nil {
+			t.Errorf("ParseCurrency(%q) should fail", amount)
+		}
+	}
+}
@@ -0,0 +1,574 @@
+// Package gnucash writes the money database as a GnuCash XML book, so the
+// accounts and categorized transactions can be opened in GnuCash or handed
+// to an accountant who uses it.
+package gnucash
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// Options picks the transactions a book covers
+type Options struct {
+	StartDate string // YYYY-MM-DD in the configured time zone, inclusive; empty for all history
+	EndDate   string // YYYY-MM-DD in the configured time zone, inclusive; empty for today
+}
+
+// Book is a GnuCash book built from a money database, ready to write
+type Book struct {
+	currencies   []string
+	accounts     []account
+	transactions []transaction
+}
+
+// GnuCash account types
+const (
+	typeRoot      = "ROOT"
+	typeBank      = "BANK"
+	typeCredit    = "CREDIT"
+	typeAsset     = "ASSET"
+	typeLiability = "LIABILITY"
+	typeIncome    = "INCOME"
+	typeExpense   = "EXPENSE"
+	typeEquity    = "EQUITY"
+)
+
+// defaultCurrency is the currency accounts without one are kept in, and the
+// one category accounts aren't suffixed with
+const defaultCurrency = "USD"
+
+type account struct {
+	id          string
+	name        string
+	kind        string
+	currency    string
+	code        string // the money account ID, for matching the two up
+	description string
+	parent      string
+	placeholder bool
+}
+
+type transaction struct {
+	id          string
+	currency    string
+	posted      time.Time
+	description string
+	splits      []split
+}
+
+type split struct {
+	id         string
+	account    string
+	amount     int64 // cents
+	reconciled string
+}
+
+// guid returns a stable GnuCash GUID for a money object, so exporting the
+// same data twice gives the same book
+func guid(kind, id string) string {
+	sum := md5.Sum([]byte("money:" + kind + ":" + id))
+	return hex.EncodeToString(sum[:])
+}
+
+// accountType maps a money account type to a GnuCash account type and
+// whether it goes under Liabilities
+func accountType(accountType *string) (string, bool) {
+	if accountType == nil {
+		return typeAsset, false
+	}
+	switch *accountType {
+	case "checking", "savings":
+		return typeBank, false
+	case "credit":
+		return typeCredit, true
+	case "loan":
+		return typeLiability, true
+	}
+	return typeAsset, false
+}
+
+// accountName makes name safe for GnuCash, which uses : to separate account
+// paths
+func accountName(name string) string {
+	return strings.ReplaceAll(name, ":", "-")
+}
+
+// Build reads the accounts and posted transactions in db into a book.
+// Every bank account becomes a GnuCash account under Assets or Liabilities
+// and every category one under Income or Expenses, depending on which way
+// its money went over the period. Internal categories become asset accounts
+// that the two sides of a transfer pass through. Each account gets an
+// opening balance from Equity so its GnuCash balance at the end of the
+// period matches money's. Pending transactions are left out.
+func Build(db *database.DB, opts Options) (*Book, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := db.GetTransactions(database.TransactionFilter{
+		StartDate:  opts.StartDate,
+		EndDate:    opts.EndDate,
+		PostedOnly: true,
+		Ascending:  true,
+	}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	// Everything posted since the start, including after the end, is what
+	// separates the current balances from the opening ones
+	since, err := db.GetTransactions(database.TransactionFilter{
+		StartDate:  opts.StartDate,
+		PostedOnly: true,
+	}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	b := &Book{}
+	root := account{id: guid("root", ""), name: "Root Account", kind: typeRoot, currency: defaultCurrency}
+	b.accounts = append(b.accounts, root)
+	parents := make(map[string]string)
+	for _, top := range []struct{ name, kind string }{
+		{"Assets", typeAsset},
+		{"Liabilities", typeLiability},
+		{"Income", typeIncome},
+		{"Expenses", typeExpense},
+		{"Equity", typeEquity},
+	} {
+		parents[top.kind] = guid("top", top.name)
+		b.accounts = append(b.accounts, account{
+			id:          parents[top.kind],
+			name:        top.name,
+			kind:        top.kind,
+			currency:    defaultCurrency,
+			parent:      root.id,
+			placeholder: true,
+		})
+	}
+
+	currencies := map[string]bool{defaultCurrency: true}
+	byID := make(map[string]account, len(accounts))
+	names := make(map[string]int)
+	for _, a := range accounts {
+		kind, liability := accountType(a.AccountType)
+		parent := parents[typeAsset]
+		if liability {
+			parent = parents[typeLiability]
+		}
+		currency := a.Currency
+		if currency == "" {
+			currency = defaultCurrency
+		}
+		currencies[currency] = true
+
+		// Sibling accounts need distinct names for GnuCash's account paths
+		name := accountName(a.DisplayName())
+		names[parent+name]++
+		if names[parent+name] > 1 {
+			name = fmt.Sprintf("%s (%s)", name, a.ID)
+		}
+
+		acct := account{
+			id:       guid("account", a.ID),
+			name:     name,
+			kind:     kind,
+			currency: currency,
+			code:     a.ID,
+			parent:   parent,
+		}
+		if a.DisplayName() != a.Name {
+			acct.description = a.Name // the bank's name, under the nickname
+		}
+		byID[a.ID] = acct
+		b.accounts = append(b.accounts, acct)
+	}
+
+	categoryByID := make(map[int]database.Category, len(categories))
+	for _, c := range categories {
+		categoryByID[c.ID] = c
+	}
+	categoryName := func(txn database.Transaction) (string, bool) {
+		if txn.CategoryID == nil {
+			return "Uncategorized", false
+		}
+		c, ok := categoryByID[*txn.CategoryID]
+		if !ok {
+			return "Uncategorized", false
+		}
+		return c.Name, c.IsInternal
+	}
+
+	// A category's account is under Income if more came in than went out
+	// over the period, and one is made per currency it was used in
+	type categoryKey struct{ name, currency string }
+	net := make(map[categoryKey]int64)
+	internal := make(map[categoryKey]bool)
+	var keys []categoryKey
+	for _, txn := range transactions {
+		a, ok := byID[txn.AccountID]
+		if !ok {
+			continue
+		}
+		name, isInternal := categoryName(txn)
+		key := categoryKey{name, a.currency}
+		if _, seen := net[key]; !seen {
+			keys = append(keys, key)
+		}
+		net[key] += txn.Amount
+		internal[key] = isInternal
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	categoryAccounts := make(map[categoryKey]string, len(keys))
+	for _, key := range keys {
+		name := accountName(key.name)
+		if key.currency != defaultCurrency {
+			name = fmt.Sprintf("%s (%s)", name, key.currency)
+		}
+		acct := account{
+			id:       guid("category", key.name+":"+key.currency),
+			name:     name,
+			currency: key.currency,
+		}
+		switch {
+		case internal[key]:
+			acct.kind = typeAsset
+			acct.parent = parents[typeAsset]
+			acct.description = "Internal category, nets to zero once both sides of each transfer are in"
+		case net[key] > 0:
+			acct.kind = typeIncome
+			acct.parent = parents[typeIncome]
+		default:
+			acct.kind = typeExpense
+			acct.parent = parents[typeExpense]
+		}
+		names[acct.parent+acct.name]++
+		if names[acct.parent+acct.name] > 1 {
+			acct.name += " (category)"
+		}
+		categoryAccounts[key] = acct.id
+		b.accounts = append(b.accounts, acct)
+	}
+
+	// Opening balances: what each account held before the period, dated at
+	// its start or, for all history, at the account's first transaction
+	moved := make(map[string]int64)
+	first := make(map[string]time.Time)
+	var earliest time.Time
+	for _, txn := range since {
+		moved[txn.AccountID] += txn.Amount
+	}
+	for _, txn := range transactions {
+		posted, err := format.PostedTime(txn.Posted)
+		if err != nil {
+			continue
+		}
+		if _, ok := first[txn.AccountID]; !ok {
+			first[txn.AccountID] = posted
+		}
+		if earliest.IsZero() {
+			earliest = posted
+		}
+	}
+	openingDate := earliest
+	if opts.StartDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", opts.StartDate, format.Location())
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date %q: %w", opts.StartDate, err)
+		}
+		openingDate = start
+	}
+	if openingDate.IsZero() {
+		openingDate = format.Now()
+	}
+
+	equity := make(map[string]string)
+	var openings []transaction
+	for _, a := range accounts {
+		acct := byID[a.ID]
+		opening := a.Balance - moved[a.ID]
+		if opening == 0 {
+			continue
+		}
+		if equity[acct.currency] == "" {
+			name := "Opening Balances"
+			if acct.currency != defaultCurrency {
+				name = fmt.Sprintf("%s (%s)", name, acct.currency)
+			}
+			equity[acct.currency] = guid("equity", acct.currency)
+			b.accounts = append(b.accounts, account{
+				id:       equity[acct.currency],
+				name:     name,
+				kind:     typeEquity,
+				currency: acct.currency,
+				parent:   parents[typeEquity],
+			})
+		}
+		date := openingDate
+		if opts.StartDate == "" && !first[a.ID].IsZero() {
+			date = first[a.ID]
+		}
+		openings = append(openings, transaction{
+			id:          guid("opening", a.ID),
+			currency:    acct.currency,
+			posted:      date,
+			description: "Opening Balance",
+			splits: []split{
+				{id: guid("opening-split", a.ID), account: acct.id, amount: opening, reconciled: "c"},
+				{id: guid("opening-equity", a.ID), account: equity[acct.currency], amount: -opening, reconciled: "n"},
+			},
+		})
+	}
+	b.transactions = append(b.transactions, openings...)
+
+	for _, txn := range transactions {
+		acct, ok := byID[txn.AccountID]
+		if !ok {
+			continue
+		}
+		posted, err := format.PostedTime(txn.Posted)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s has an invalid posted time %q: %w", txn.ID, txn.Posted, err)
+		}
+		name, _ := categoryName(txn)
+		b.transactions = append(b.transactions, transaction{
+			id:          guid("transaction", txn.ID),
+			currency:    acct.currency,
+			posted:      posted,
+			description: txn.DisplayDescription(),
+			splits: []split{
+				{id: guid("split", txn.ID), account: acct.id, amount: txn.Amount, reconciled: "c"},
+				{id: guid("category-split", txn.ID), account: categoryAccounts[categoryKey{name, acct.currency}], amount: -txn.Amount, reconciled: "n"},
+			},
+		})
+	}
+
+	for currency := range currencies {
+		b.currencies = append(b.currencies, currency)
+	}
+	sort.Strings(b.currencies)
+	return b, nil
+}
+
+// Accounts returns how many accounts the book has, including the root and
+// top-level placeholders
+func (b *Book) Accounts() int {
+	return len(b.accounts)
+}
+
+// Transactions returns how many transactions the book has, including
+// opening balances
+func (b *Book) Transactions() int {
+	return len(b.transactions)
+}
+
+// The XML below is the uncompressed GnuCash v2 file format, which GnuCash
+// opens directly; it only needs the elements a book of accounts and
+// transactions uses.
+
+type xmlFile struct {
+	XMLName xml.Name `xml:"gnc-v2"`
+	Gnc     string   `xml:"xmlns:gnc,attr"`
+	Act     string   `xml:"xmlns:act,attr"`
+	BookNS  string   `xml:"xmlns:book,attr"`
+	Cd      string   `xml:"xmlns:cd,attr"`
+	Cmdty   string   `xml:"xmlns:cmdty,attr"`
+	Slot    string   `xml:"xmlns:slot,attr"`
+	Split   string   `xml:"xmlns:split,attr"`
+	Trn     string   `xml:"xmlns:trn,attr"`
+	Ts      string   `xml:"xmlns:ts,attr"`
+	Count   xmlCount `xml:"gnc:count-data"`
+	Book    xmlBook  `xml:"gnc:book"`
+}
+
+type xmlCount struct {
+	Type  string `xml:"cd:type,attr"`
+	Count int    `xml:",chardata"`
+}
+
+type xmlGUID struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlCommodity struct {
+	Space string `xml:"cmdty:space"`
+	ID    string `xml:"cmdty:id"`
+}
+
+type xmlBookCommodity struct {
+	Version string `xml:"version,attr"`
+	xmlCommodity
+}
+
+type xmlBook struct {
+	Version      string             `xml:"version,attr"`
+	ID           xmlGUID            `xml:"book:id"`
+	Counts       []xmlCount         `xml:"gnc:count-data"`
+	Commodities  []xmlBookCommodity `xml:"gnc:commodity"`
+	Accounts     []xmlAccount       `xml:"gnc:account"`
+	Transactions []xmlTransaction   `xml:"gnc:transaction"`
+}
+
+type xmlSlots struct {
+	Slots []xmlSlot `xml:"slot"`
+}
+
+type xmlSlot struct {
+	Key   string       `xml:"slot:key"`
+	Value xmlSlotValue `xml:"slot:value"`
+}
+
+type xmlSlotValue struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlAccount struct {
+	Version      string        `xml:"version,attr"`
+	Name         string        `xml:"act:name"`
+	ID           xmlGUID       `xml:"act:id"`
+	Type         string        `xml:"act:type"`
+	Commodity    *xmlCommodity `xml:"act:commodity,omitempty"`
+	CommoditySCU int           `xml:"act:commodity-scu,omitempty"`
+	Code         string        `xml:"act:code,omitempty"`
+	Description  string        `xml:"act:description,omitempty"`
+	Slots        *xmlSlots     `xml:"act:slots,omitempty"`
+	Parent       *xmlGUID      `xml:"act:parent,omitempty"`
+}
+
+type xmlDate struct {
+	Date string `xml:"ts:date"`
+}
+
+type xmlTransaction struct {
+	Version     string       `xml:"version,attr"`
+	ID          xmlGUID      `xml:"trn:id"`
+	Currency    xmlCommodity `xml:"trn:currency"`
+	DatePosted  xmlDate      `xml:"trn:date-posted"`
+	DateEntered xmlDate      `xml:"trn:date-entered"`
+	Description string       `xml:"trn:description"`
+	Splits      []xmlSplit   `xml:"trn:splits>trn:split"`
+}
+
+type xmlSplit struct {
+	ID              xmlGUID `xml:"split:id"`
+	ReconciledState string  `xml:"split:reconciled-state"`
+	Value           string  `xml:"split:value"`
+	Quantity        string  `xml:"split:quantity"`
+	Account         xmlGUID `xml:"split:account"`
+}
+
+func currency(id string) xmlCommodity {
+	return xmlCommodity{Space: "CURRENCY", ID: id}
+}
+
+// amount formats cents as the fraction GnuCash stores amounts as
+func amount(cents int64) string {
+	return fmt.Sprintf("%d/100", cents)
+}
+
+// date formats t the way GnuCash stores dates
+func date(t time.Time) xmlDate {
+	return xmlDate{Date: t.Format("2006-01-02 15:04:05 -0700")}
+}
+
+// Write writes the book to w as an uncompressed GnuCash XML file
+func (b *Book) Write(w io.Writer) error {
+	const ns = "http://www.gnucash.org/XML/"
+	file := xmlFile{
+		Gnc:    ns + "gnc",
+		Act:    ns + "act",
+		BookNS: ns + "book",
+		Cd:     ns + "cd",
+		Cmdty:  ns + "cmdty",
+		Slot:   ns + "slot",
+		Split:  ns + "split",
+		Trn:    ns + "trn",
+		Ts:     ns + "ts",
+		Count:  xmlCount{Type: "book", Count: 1},
+		Book: xmlBook{
+			Version: "2.0.0",
+			ID:      xmlGUID{Type: "guid", Value: guid("book", "")},
+			Counts: []xmlCount{
+				{Type: "commodity", Count: len(b.currencies)},
+				{Type: "account", Count: len(b.accounts)},
+				{Type: "transaction", Count: len(b.transactions)},
+			},
+		},
+	}
+
+	for _, c := range b.currencies {
+		file.Book.Commodities = append(file.Book.Commodities, xmlBookCommodity{Version: "2.0.0", xmlCommodity: currency(c)})
+	}
+	for _, a := range b.accounts {
+		x := xmlAccount{
+			Version:     "2.0.0",
+			Name:        a.name,
+			ID:          xmlGUID{Type: "guid", Value: a.id},
+			Type:        a.kind,
+			Code:        a.code,
+			Description: a.description,
+		}
+		if a.kind != typeRoot {
+			c := currency(a.currency)
+			x.Commodity = &c
+			x.CommoditySCU = 100
+			x.Parent = &xmlGUID{Type: "guid", Value: a.parent}
+		}
+		if a.placeholder {
+			x.Slots = &xmlSlots{Slots: []xmlSlot{{Key: "placeholder", Value: xmlSlotValue{Type: "string", Value: "true"}}}}
+		}
+		file.Book.Accounts = append(file.Book.Accounts, x)
+	}
+	for _, t := range b.transactions {
+		x := xmlTransaction{
+			Version:     "2.0.0",
+			ID:          xmlGUID{Type: "guid", Value: t.id},
+			Currency:    currency(t.currency),
+			DatePosted:  date(t.posted),
+			DateEntered: date(t.posted),
+			Description: t.description,
+		}
+		for _, s := range t.splits {
+			x.Splits = append(x.Splits, xmlSplit{
+				ID:              xmlGUID{Type: "guid", Value: s.id},
+				ReconciledState: s.reconciled,
+				Value:           amount(s.amount),
+				Quantity:        amount(s.amount),
+				Account:         xmlGUID{Type: "guid", Value: s.account},
+			})
+		}
+		file.Book.Transactions = append(file.Book.Transactions, x)
+	}
+
+	if _, err := io.WriteString(w, "<?xml version=\"1.0\" encoding=\"utf-8\" ?>\n"); err != nil {
+		return fmt.Errorf("failed to write GnuCash book: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return fmt.Errorf("failed to write GnuCash book: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write GnuCash book: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package gnucash
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// parsedBook is what a test needs from a written book
+type parsedBook struct {
+	accounts     map[string]string // name by GUID
+	types        map[string]string // GnuCash type by account name
+	balances     map[string]int64  // cents by account name
+	transactions int
+	unbalanced   []string // descriptions of transactions whose splits don't sum to zero
+}
+
+// parse reads back a book written by Write
+func parse(t *testing.T, data []byte) parsedBook {
+	t.Helper()
+	const ns = "http://www.gnucash.org/XML/"
+	book := parsedBook{accounts: map[string]string{}, types: map[string]string{}, balances: map[string]int64{}}
+	type splitValue struct {
+		account string
+		value   int64
+	}
+	var (
+		path        []xml.Name
+		accountName string
+		description string
+		splits      []splitValue
+		current     splitValue
+	)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Book isn't well-formed XML: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			path = append(path, tok.Name)
+		case xml.EndElement:
+			path = path[:len(path)-1]
+			switch tok.Name {
+			case xml.Name{Space: ns + "split", Local: "account"}:
+				// value and account are both read by now
+				splits = append(splits, current)
+			case xml.Name{Space: ns + "gnc", Local: "transaction"}:
+				book.transactions++
+				var sum int64
+				for _, s := range splits {
+					sum += s.value
+				}
+				if sum != 0 {
+					book.unbalanced = append(book.unbalanced, description)
+				}
+				for _, s := range splits {
+					book.balances[s.account] += s.value
+				}
+				splits = nil
+			}
+		case xml.CharData:
+			if len(path) == 0 {
+				continue
+			}
+			text := strings.TrimSpace(string(tok))
+			switch path[len(path)-1] {
+			case xml.Name{Space: ns + "act", Local: "name"}:
+				accountName = text
+			case xml.Name{Space: ns + "act", Local: "id"}:
+				book.accounts[text] = accountName
+			case xml.Name{Space: ns + "act", Local: "type"}:
+				book.types[accountName] = text
+			case xml.Name{Space: ns + "trn", Local: "description"}:
+				description = text
+			case xml.Name{Space: ns + "split", Local: "value"}:
+				var cents, denominator int64
+				if _, err := fmt.Sscanf(text, "%d/%d", &cents, &denominator); err != nil || denominator != 100 {
+					t.Fatalf("Unexpected split value %q", text)
+				}
+				current.value = cents
+			case xml.Name{Space: ns + "split", Local: "account"}:
+				current.account = book.accounts[text]
+			}
+		}
+	}
+	return book
+}
+
+func TestBuild(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("checking", "org-1", "Checking", "USD", 150000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveAccount("card", "org-1", "Card: Rewards", "USD", -5000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SetAccountType("checking", "checking"); err != nil {
+		t.Fatalf("Failed to set account type: %v", err)
+	}
+	if err := db.SetAccountType("card", "credit"); err != nil {
+		t.Fatalf("Failed to set account type: %v", err)
+	}
+	salary, err := db.SaveCategory("Salary")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	groceries, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	transfers, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id, account, posted string
+		amount              int64
+		category            int
+	}{
+		{"t1", "checking", "2025-01-02T17:00:00Z", 200000, salary},
+		{"t2", "card", "2025-01-05T17:00:00Z", -4000, groceries},
+		{"t3", "checking", "2025-02-01T17:00:00Z", -3000, transfers},
+		{"t4", "card", "2025-02-02T17:00:00Z", 3000, transfers},
+		{"t5", "checking", "2025-02-10T17:00:00Z", -1000, 0},
+		{"t6", "checking", "2025-02-11T17:00:00Z", -500, 0},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, txn.account, txn.posted, txn.amount, strings.ToUpper(txn.id), false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.category != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.category); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+	// Still pending, so left out
+	if err := db.SaveTransaction("p1", "checking", "2025-02-12T17:00:00Z", -999, "PENDING", true); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	book, err := Build(db, Options{})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), "<gnc-v2") {
+		t.Fatalf("Expected a GnuCash v2 file, got:\n%s", buf.String())
+	}
+
+	parsed := parse(t, buf.Bytes())
+	if len(parsed.unbalanced) > 0 {
+		t.Errorf("Transactions with unbalanced splits: %v", parsed.unbalanced)
+	}
+	// Six transactions and an opening balance for each account
+	if parsed.transactions != 8 || book.Transactions() != 8 {
+		t.Errorf("Expected 8 transactions, got %d written and %d built", parsed.transactions, book.Transactions())
+	}
+
+	wantBalances := map[string]int64{
+		"Checking":         150000,
+		"Card- Rewards":    -5000,
+		"Salary":           -200000,
+		"Groceries":        4000,
+		"Transfers":        0,
+		"Uncategorized":    1500,
+		"Opening Balances": -150000 + 5000 + 200000 - 4000 - 1500,
+	}
+	for name, want := range wantBalances {
+		if got := parsed.balances[name]; got != want {
+			t.Errorf("Balance of %s = %d, want %d", name, got, want)
+		}
+	}
+
+	wantTypes := map[string]string{
+		"Root Account":  "ROOT",
+		"Checking":      "BANK",
+		"Card- Rewards": "CREDIT",
+		"Salary":        "INCOME",
+		"Groceries":     "EXPENSE",
+		"Transfers":     "ASSET",
+		"Uncategorized": "EXPENSE",
+	}
+	for name, want := range wantTypes {
+		if got := parsed.types[name]; got != want {
+			t.Errorf("Type of %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The same data gives the same file
+	again, err := Build(db, Options{})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	var second bytes.Buffer
+	if err := again.Write(&second); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if second.String() != buf.String() {
+		t.Error("Expected exporting twice to give the same book")
+	}
+}
+
+func TestBuildPeriod(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("checking", "org-1", "Checking", "USD", 10000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	for _, txn := range []struct {
+		id, posted string
+		amount     int64
+	}{
+		{"before", "2024-12-20T17:00:00Z", -2000},
+		{"during", "2025-03-15T17:00:00Z", -3000},
+		{"after", "2026-01-10T17:00:00Z", 5000},
+	} {
+		if err := db.SaveTransaction(txn.id, "checking", txn.posted, txn.amount, txn.id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	book, err := Build(db, Options{StartDate: "2025-01-01", EndDate: "2025-12-31"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	parsed := parse(t, buf.Bytes())
+
+	// The account held 10000 - 5000 after the period, and 3000 more before
+	// the one transaction in it
+	if got := parsed.balances["Checking"]; got != 5000 {
+		t.Errorf("Balance of Checking at the end of 2025 = %d, want 5000", got)
+	}
+	if got := parsed.balances["Uncategorized"]; got != 3000 {
+		t.Errorf("Balance of Uncategorized = %d, want 3000", got)
+	}
+	if parsed.transactions != 2 {
+		t.Errorf("Expected the opening balance and one transaction, got %d", parsed.transactions)
+	}
+}
@@ -0,0 +1,247 @@
+package gocardless
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// Bank is a bank linked through a requisition
+type Bank struct {
+	RequisitionID   string
+	InstitutionID   string
+	InstitutionName string
+}
+
+// FetchOptions controls a fetch
+type FetchOptions struct {
+	// Since is the first day of transactions to read (YYYY-MM-DD), or ""
+	// for all the history the bank gives
+	Since string
+	// Known are the accounts money already has, by ID, whose name and
+	// currency are kept so their details, which banks only allow a few
+	// reads of a day, aren't read again
+	Known map[string]database.Account
+	// BalancesOnly skips the transactions
+	BalancesOnly bool
+}
+
+// balanceTypes are the balances used as an account's balance, in order of
+// preference; banks report different subsets
+var balanceTypes = []string{"interimBooked", "closingBooked", "expected", "interimAvailable", "openingBooked"}
+
+// availableBalanceTypes are the balances used as the available balance
+var availableBalanceTypes = []string{"interimAvailable", "forwardAvailable"}
+
+// hashID returns a short stable ID for key, prefixed so GoCardless IDs
+// can't collide with SimpleFIN's
+func hashID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "gc_" + hex.EncodeToString(sum[:])[:16]
+}
+
+// OrganizationID returns the ID money stores a bank's organization under
+func OrganizationID(institutionID string) string {
+	return "gc_" + institutionID
+}
+
+// AccountID returns the ID money stores an account under. GoCardless gives
+// an account a new ID each time access is granted again, so the ID comes
+// from the IBAN when there is one and survives reconnecting.
+func AccountID(institutionID string, metadata AccountMetadata) string {
+	if metadata.IBAN != "" {
+		return hashID(institutionID + ":" + metadata.IBAN)
+	}
+	return hashID("account:" + metadata.ID)
+}
+
+// Fetch reads the accounts of each linked bank in the shape SimpleFIN
+// reports them, so money fetch saves both the same way. Banks and accounts
+// that fail, for example because the bank's daily request limit was
+// reached, are reported in the response's Errors instead of failing the
+// whole fetch.
+func Fetch(client *Client, banks []Bank, opts FetchOptions) *simplefin.AccountsResponse {
+	resp := &simplefin.AccountsResponse{}
+	for _, bank := range banks {
+		requisition, err := client.Requisition(bank.RequisitionID)
+		if err != nil {
+			resp.Errors = append(resp.Errors, fmt.Sprintf("%s: %v", bank.InstitutionName, err))
+			continue
+		}
+		if requisition.Status != StatusLinked {
+			resp.Errors = append(resp.Errors, fmt.Sprintf("%s: access is %s, reconnect with 'money init gocardless --institution %s'",
+				bank.InstitutionName, statusName(requisition.Status), bank.InstitutionID))
+			continue
+		}
+
+		org := simplefin.Organization{ID: OrganizationID(bank.InstitutionID), Name: bank.InstitutionName}
+		for _, id := range requisition.Accounts {
+			account, err := fetchAccount(client, org, bank, id, opts)
+			if err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("%s account %s: %v", bank.InstitutionName, id, err))
+				continue
+			}
+			resp.Accounts = append(resp.Accounts, *account)
+		}
+	}
+	return resp
+}
+
+// statusName describes a requisition status
+func statusName(status string) string {
+	switch status {
+	case StatusExpired:
+		return "expired"
+	case StatusRejected:
+		return "rejected"
+	case StatusSuspended:
+		return "suspended"
+	}
+	return "not granted yet"
+}
+
+func fetchAccount(client *Client, org simplefin.Organization, bank Bank, id string, opts FetchOptions) (*simplefin.Account, error) {
+	metadata, err := client.AccountMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+	account := &simplefin.Account{
+		ID:  AccountID(bank.InstitutionID, *metadata),
+		Org: org,
+	}
+
+	if known, ok := opts.Known[account.ID]; ok {
+		account.Name = known.Name
+		account.Currency = known.Currency
+	} else {
+		details, err := client.AccountDetails(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get details: %w", err)
+		}
+		account.Name = accountName(bank, *metadata, details)
+		account.Currency = details.Currency
+	}
+
+	balances, err := client.AccountBalances(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	balance := pickBalance(balances, balanceTypes)
+	if balance == nil {
+		return nil, fmt.Errorf("the bank reported no balance")
+	}
+	account.Balance = balance.BalanceAmount.Amount
+	if balance.BalanceAmount.Currency != "" {
+		account.Currency = balance.BalanceAmount.Currency
+	}
+	if available := pickBalance(balances, availableBalanceTypes); available != nil {
+		account.AvailableBalance = &available.BalanceAmount.Amount
+	}
+	if day, err := time.ParseInLocation("2006-01-02", balance.ReferenceDate, format.Location()); err == nil {
+		balanceDate := day.Unix()
+		account.BalanceDate = &balanceDate
+	}
+
+	if opts.BalancesOnly {
+		return account, nil
+	}
+	transactions, err := client.AccountTransactions(id, opts.Since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	seen := make(map[string]int)
+	for _, t := range transactions {
+		txn, err := convertTransaction(account.ID, t, seen)
+		if err != nil {
+			return nil, err
+		}
+		account.Transactions = append(account.Transactions, txn)
+	}
+	return account, nil
+}
+
+// accountName names a new account from its details, or from the bank and
+// the end of the IBAN when the bank gives no name
+func accountName(bank Bank, metadata AccountMetadata, details *AccountDetails) string {
+	for _, name := range []string{details.DisplayName, details.Name, details.Product} {
+		if name != "" {
+			return name
+		}
+	}
+	if len(metadata.IBAN) >= 4 {
+		return fmt.Sprintf("%s ...%s", bank.InstitutionName, metadata.IBAN[len(metadata.IBAN)-4:])
+	}
+	return bank.InstitutionName
+}
+
+// pickBalance returns the first balance of the types given, in order
+func pickBalance(balances []Balance, types []string) *Balance {
+	for _, balanceType := range types {
+		for i := range balances {
+			if balances[i].BalanceType == balanceType {
+				return &balances[i]
+			}
+		}
+	}
+	return nil
+}
+
+// convertTransaction converts a booked transaction. Banks don't all give
+// transactions an ID, so those without one are identified by their date,
+// amount and description, counting repeats in seen.
+func convertTransaction(accountID string, t Transaction, seen map[string]int) (simplefin.Transaction, error) {
+	date := t.BookingDate
+	if date == "" {
+		date = t.ValueDate
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, format.Location())
+	if err != nil {
+		return simplefin.Transaction{}, fmt.Errorf("transaction has an invalid booking date %q: %w", date, err)
+	}
+
+	description := transactionDescription(t)
+	key := t.TransactionID
+	if key == "" {
+		key = t.InternalTransactionID
+	}
+	if key == "" {
+		key = fmt.Sprintf("%s|%s|%s", date, t.TransactionAmount.Amount, description)
+		seen[key]++
+		key = fmt.Sprintf("%s|%d", key, seen[key])
+	}
+
+	pending := false
+	return simplefin.Transaction{
+		ID:          hashID(accountID + ":" + key),
+		Posted:      day.Unix(),
+		Amount:      t.TransactionAmount.Amount,
+		Description: description,
+		Pending:     &pending,
+	}, nil
+}
+
+// transactionDescription describes a transaction by who was on the other
+// side of it, falling back to the payment reference
+func transactionDescription(t Transaction) string {
+	names := []string{t.CreditorName, t.DebtorName}
+	if !strings.HasPrefix(t.TransactionAmount.Amount, "-") {
+		// Money coming in names the debtor first
+		names[0], names[1] = names[1], names[0]
+	}
+	candidates := append(names,
+		t.RemittanceInformationUnstructured,
+		strings.Join(t.RemittanceInformationUnstructuredArray, " "),
+		t.AdditionalInformation)
+	for _, candidate := range candidates {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			return candidate
+		}
+	}
+	return "Bank transaction"
+}
@@ -0,0 +1,415 @@
+// Package gocardless reads European bank accounts through GoCardless Bank
+// Account Data (formerly Nordigen), the PSD2 open banking API, for banks
+// SimpleFIN doesn't reach.
+package gocardless
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// BaseURL is the GoCardless Bank Account Data API
+const BaseURL = "https://bankaccountdata.gocardless.com/api/v2"
+
+// tokenMargin is how long before it expires a token is treated as expired,
+// so it doesn't run out during a fetch
+const tokenMargin = time.Minute
+
+// Requisition statuses; a requisition is usable once it's linked
+const (
+	StatusLinked    = "LN"
+	StatusExpired   = "EX"
+	StatusRejected  = "RJ"
+	StatusSuspended = "SU"
+)
+
+// Tokens are the access token requests are made with and the refresh
+// token that renews it. Secret keys are exchanged for both; the access
+// token lasts a day and the refresh token a month.
+type Tokens struct {
+	Access         string
+	AccessExpires  time.Time
+	Refresh        string
+	RefreshExpires time.Time
+}
+
+// Client represents a GoCardless Bank Account Data API client
+type Client struct {
+	BaseURL    string
+	SecretID   string
+	SecretKey  string
+	HTTPClient *http.Client
+
+	tokens  Tokens
+	changed bool
+	now     func() time.Time
+}
+
+// NewClient creates a client authenticating with a user secret, starting
+// from tokens saved by an earlier client, which may be empty
+func NewClient(secretID, secretKey string, tokens Tokens) *Client {
+	return &Client{
+		BaseURL:   BaseURL,
+		SecretID:  secretID,
+		SecretKey: secretKey,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		tokens: tokens,
+		now:    time.Now,
+	}
+}
+
+// Tokens returns the client's current tokens and whether they changed
+// since it was created, so the caller knows to save them
+func (c *Client) Tokens() (Tokens, bool) {
+	return c.tokens, c.changed
+}
+
+// apiError is the body GoCardless returns with a failed request
+type apiError struct {
+	Summary string `json:"summary"`
+	Detail  string `json:"detail"`
+}
+
+// send makes a request with body encoded as JSON when it isn't nil and
+// decodes the response into out, authenticating with token when it isn't
+// empty
+func (c *Client) send(method, path, token string, query url.Values, body, out interface{}) error {
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "money-cli/1.0")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make GoCardless request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e apiError
+		if json.Unmarshal(data, &e) == nil && (e.Summary != "" || e.Detail != "") {
+			if e.Detail == "" || e.Detail == e.Summary {
+				return fmt.Errorf("GoCardless request failed with status %d: %s", resp.StatusCode, e.Summary)
+			}
+			return fmt.Errorf("GoCardless request failed with status %d: %s: %s", resp.StatusCode, e.Summary, e.Detail)
+		}
+		return fmt.Errorf("GoCardless request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse GoCardless response: %w", err)
+	}
+	return nil
+}
+
+// tokenResponse is returned when tokens are created or refreshed, with
+// lifetimes in seconds
+type tokenResponse struct {
+	Access         string `json:"access"`
+	AccessExpires  int    `json:"access_expires"`
+	Refresh        string `json:"refresh"`
+	RefreshExpires int    `json:"refresh_expires"`
+}
+
+// NewTokens exchanges the user secret for new tokens, which also checks
+// that the secret is valid
+func (c *Client) NewTokens() error {
+	body := map[string]string{"secret_id": c.SecretID, "secret_key": c.SecretKey}
+	var resp tokenResponse
+	if err := c.send("POST", "/token/new/", "", nil, body, &resp); err != nil {
+		return fmt.Errorf("failed to get GoCardless access token: %w", err)
+	}
+
+	now := c.now()
+	c.tokens = Tokens{
+		Access:         resp.Access,
+		AccessExpires:  now.Add(time.Duration(resp.AccessExpires) * time.Second),
+		Refresh:        resp.Refresh,
+		RefreshExpires: now.Add(time.Duration(resp.RefreshExpires) * time.Second),
+	}
+	c.changed = true
+	return nil
+}
+
+// accessToken returns a current access token, refreshing it with the
+// refresh token, or failing that the secret, when it has expired
+func (c *Client) accessToken() (string, error) {
+	now := c.now()
+	if c.tokens.Access != "" && now.Add(tokenMargin).Before(c.tokens.AccessExpires) {
+		return c.tokens.Access, nil
+	}
+
+	if c.tokens.Refresh != "" && now.Add(tokenMargin).Before(c.tokens.RefreshExpires) {
+		var resp tokenResponse
+		err := c.send("POST", "/token/refresh/", "", nil, map[string]string{"refresh": c.tokens.Refresh}, &resp)
+		if err == nil {
+			c.tokens.Access = resp.Access
+			c.tokens.AccessExpires = now.Add(time.Duration(resp.AccessExpires) * time.Second)
+			c.changed = true
+			return c.tokens.Access, nil
+		}
+	}
+
+	if err := c.NewTokens(); err != nil {
+		return "", err
+	}
+	return c.tokens.Access, nil
+}
+
+// do makes an authenticated request
+func (c *Client) do(method, path string, query url.Values, body, out interface{}) error {
+	token, err := c.accessToken()
+	if err != nil {
+		return err
+	}
+	return c.send(method, path, token, query, body, out)
+}
+
+// Institution is a bank GoCardless can connect to
+type Institution struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	BIC       string   `json:"bic"`
+	Countries []string `json:"countries"`
+	// Days of transaction history and of access the bank allows, which
+	// GoCardless sends as strings
+	TransactionTotalDays  string `json:"transaction_total_days"`
+	MaxAccessValidForDays string `json:"max_access_valid_for_days"`
+}
+
+// HistoryDays returns how many days of transactions the bank gives, 90 when
+// it doesn't say
+func (i Institution) HistoryDays() int {
+	if days, err := strconv.Atoi(i.TransactionTotalDays); err == nil && days > 0 {
+		return days
+	}
+	return 90
+}
+
+// AccessDays returns how many days access to the bank can be granted for,
+// 90 when it doesn't say
+func (i Institution) AccessDays() int {
+	if days, err := strconv.Atoi(i.MaxAccessValidForDays); err == nil && days > 0 {
+		return days
+	}
+	return 90
+}
+
+// Institutions returns the banks GoCardless connects to in a country, by
+// its two letter ISO code
+func (c *Client) Institutions(country string) ([]Institution, error) {
+	var institutions []Institution
+	if err := c.do("GET", "/institutions/", url.Values{"country": {country}}, nil, &institutions); err != nil {
+		return nil, err
+	}
+	return institutions, nil
+}
+
+// Institution returns a bank by its GoCardless ID, like "REVOLUT_REVOLT21"
+func (c *Client) Institution(id string) (*Institution, error) {
+	var institution Institution
+	if err := c.do("GET", "/institutions/"+url.PathEscape(id)+"/", nil, nil, &institution); err != nil {
+		return nil, err
+	}
+	return &institution, nil
+}
+
+// Agreement is the end user agreement a requisition asks the account
+// holder to accept: how much history and for how long
+type Agreement struct {
+	ID                 string `json:"id"`
+	InstitutionID      string `json:"institution_id"`
+	MaxHistoricalDays  int    `json:"max_historical_days"`
+	AccessValidForDays int    `json:"access_valid_for_days"`
+}
+
+// Requisition is a request for access to a bank's accounts. The account
+// holder grants it by following Link; once linked it lists the accounts.
+type Requisition struct {
+	ID            string   `json:"id"`
+	Status        string   `json:"status"`
+	InstitutionID string   `json:"institution_id"`
+	Agreement     string   `json:"agreement"`
+	Reference     string   `json:"reference"`
+	Accounts      []string `json:"accounts"`
+	Link          string   `json:"link"`
+}
+
+// CreateRequisition asks for as much history and as long an access to the
+// institution's accounts as it allows, returning to redirect once the
+// account holder has granted it
+func (c *Client) CreateRequisition(institution Institution, redirect, reference string) (*Requisition, *Agreement, error) {
+	var agreement Agreement
+	err := c.do("POST", "/agreements/enduser/", nil, map[string]interface{}{
+		"institution_id":        institution.ID,
+		"max_historical_days":   institution.HistoryDays(),
+		"access_valid_for_days": institution.AccessDays(),
+		"access_scope":          []string{"balances", "details", "transactions"},
+	}, &agreement)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create agreement: %w", err)
+	}
+
+	var requisition Requisition
+	err = c.do("POST", "/requisitions/", nil, map[string]interface{}{
+		"redirect":       redirect,
+		"institution_id": institution.ID,
+		"agreement":      agreement.ID,
+		"reference":      reference,
+		"user_language":  "EN",
+	}, &requisition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create requisition: %w", err)
+	}
+	return &requisition, &agreement, nil
+}
+
+// Requisition returns a requisition, to check whether it's been granted
+func (c *Client) Requisition(id string) (*Requisition, error) {
+	var requisition Requisition
+	if err := c.do("GET", "/requisitions/"+url.PathEscape(id)+"/", nil, nil, &requisition); err != nil {
+		return nil, err
+	}
+	return &requisition, nil
+}
+
+// DeleteRequisition revokes a requisition and the access it granted
+func (c *Client) DeleteRequisition(id string) error {
+	return c.do("DELETE", "/requisitions/"+url.PathEscape(id)+"/", nil, nil, nil)
+}
+
+// AccountMetadata is what GoCardless knows about an account without asking
+// the bank, so reading it doesn't count against the bank's rate limit
+type AccountMetadata struct {
+	ID            string `json:"id"`
+	IBAN          string `json:"iban"`
+	InstitutionID string `json:"institution_id"`
+	Status        string `json:"status"`
+	OwnerName     string `json:"owner_name"`
+}
+
+// AccountDetails is the bank's description of an account
+type AccountDetails struct {
+	ResourceID      string `json:"resourceId"`
+	IBAN            string `json:"iban"`
+	Currency        string `json:"currency"`
+	Name            string `json:"name"`
+	DisplayName     string `json:"displayName"`
+	Product         string `json:"product"`
+	CashAccountType string `json:"cashAccountType"`
+}
+
+// Amount is an amount of money in a currency, as a decimal string
+type Amount struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Balance is one of the balances a bank reports for an account, such as
+// "interimBooked" or "interimAvailable"
+type Balance struct {
+	BalanceAmount Amount `json:"balanceAmount"`
+	BalanceType   string `json:"balanceType"`
+	ReferenceDate string `json:"referenceDate"`
+}
+
+// Transaction is a transaction as banks report them under PSD2
+type Transaction struct {
+	TransactionID                          string   `json:"transactionId"`
+	InternalTransactionID                  string   `json:"internalTransactionId"`
+	BookingDate                            string   `json:"bookingDate"`
+	ValueDate                              string   `json:"valueDate"`
+	TransactionAmount                      Amount   `json:"transactionAmount"`
+	CreditorName                           string   `json:"creditorName"`
+	DebtorName                             string   `json:"debtorName"`
+	RemittanceInformationUnstructured      string   `json:"remittanceInformationUnstructured"`
+	RemittanceInformationUnstructuredArray []string `json:"remittanceInformationUnstructuredArray"`
+	AdditionalInformation                  string   `json:"additionalInformation"`
+}
+
+// AccountMetadata returns an account's metadata
+func (c *Client) AccountMetadata(id string) (*AccountMetadata, error) {
+	var metadata AccountMetadata
+	if err := c.do("GET", "/accounts/"+url.PathEscape(id)+"/", nil, nil, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// AccountDetails returns the bank's details of an account
+func (c *Client) AccountDetails(id string) (*AccountDetails, error) {
+	var resp struct {
+		Account AccountDetails `json:"account"`
+	}
+	if err := c.do("GET", "/accounts/"+url.PathEscape(id)+"/details/", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Account, nil
+}
+
+// AccountBalances returns the balances the bank reports for an account
+func (c *Client) AccountBalances(id string) ([]Balance, error) {
+	var resp struct {
+		Balances []Balance `json:"balances"`
+	}
+	if err := c.do("GET", "/accounts/"+url.PathEscape(id)+"/balances/", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Balances, nil
+}
+
+// AccountTransactions returns an account's booked transactions from since
+// on, or all the bank gives when since is empty (YYYY-MM-DD)
+func (c *Client) AccountTransactions(id, since string) ([]Transaction, error) {
+	query := url.Values{}
+	if since != "" {
+		query.Set("date_from", since)
+	}
+	var resp struct {
+		Transactions struct {
+			Booked []Transaction `json:"booked"`
+		} `json:"transactions"`
+	}
+	if err := c.do("GET", "/accounts/"+url.PathEscape(id)+"/transactions/", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Transactions.Booked, nil
+}
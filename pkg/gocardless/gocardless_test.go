@@ -0,0 +1,206 @@
+package gocardless
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// fakeGoCardless serves a bank with a linked requisition for two accounts
+// and an expired one, counting requests by path
+type fakeGoCardless struct {
+	t        *testing.T
+	requests map[string]int
+	issued   int
+}
+
+func (f *fakeGoCardless) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests[r.URL.Path]++
+	reply := func(data interface{}) {
+		json.NewEncoder(w).Encode(data)
+	}
+
+	switch r.URL.Path {
+	case "/token/new/":
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["secret_id"] != "secret-id" || body["secret_key"] != "secret-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			reply(map[string]string{"summary": "Authentication failed", "detail": "No active account found with the given credentials"})
+			return
+		}
+		f.issued++
+		reply(map[string]interface{}{"access": "access-1", "access_expires": 86400, "refresh": "refresh-1", "refresh_expires": 2592000})
+		return
+	case "/token/refresh/":
+		reply(map[string]interface{}{"access": "access-2", "access_expires": 86400})
+		return
+	}
+
+	if auth := r.Header.Get("Authorization"); auth != "Bearer access-1" && auth != "Bearer access-2" {
+		w.WriteHeader(http.StatusUnauthorized)
+		reply(map[string]string{"summary": "Invalid token"})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/requisitions/req-1/":
+		reply(Requisition{ID: "req-1", Status: StatusLinked, Accounts: []string{"acc-1", "acc-2"}})
+	case "/requisitions/req-2/":
+		reply(Requisition{ID: "req-2", Status: StatusExpired})
+	case "/accounts/acc-1/":
+		reply(AccountMetadata{ID: "acc-1", IBAN: "DE89370400440532013000"})
+	case "/accounts/acc-1/details/":
+		reply(map[string]interface{}{"account": AccountDetails{Currency: "EUR", Product: "Girokonto"}})
+	case "/accounts/acc-1/balances/":
+		reply(map[string]interface{}{"balances": []Balance{
+			{BalanceAmount: Amount{"1200.50", "EUR"}, BalanceType: "interimAvailable", ReferenceDate: "2025-03-04"},
+			{BalanceAmount: Amount{"1000.50", "EUR"}, BalanceType: "closingBooked", ReferenceDate: "2025-03-03"},
+		}})
+	case "/accounts/acc-1/transactions/":
+		if since := r.URL.Query().Get("date_from"); since != "2025-03-01" {
+			f.t.Errorf("Expected transactions from 2025-03-01, got %q", since)
+		}
+		reply(map[string]interface{}{"transactions": map[string]interface{}{
+			"booked": []Transaction{
+				{TransactionID: "tx-1", BookingDate: "2025-03-02", TransactionAmount: Amount{"-45.10", "EUR"}, CreditorName: "REWE", DebtorName: "Max Mustermann"},
+				{BookingDate: "2025-03-03", TransactionAmount: Amount{"2500.00", "EUR"}, CreditorName: "Max Mustermann", DebtorName: "ACME GmbH"},
+				{BookingDate: "2025-03-03", TransactionAmount: Amount{"-3.00", "EUR"}, RemittanceInformationUnstructuredArray: []string{"Coffee", "Bar"}},
+				{BookingDate: "2025-03-03", TransactionAmount: Amount{"-3.00", "EUR"}, RemittanceInformationUnstructuredArray: []string{"Coffee", "Bar"}},
+			},
+			"pending": []Transaction{
+				{BookingDate: "2025-03-04", TransactionAmount: Amount{"-9.99", "EUR"}},
+			},
+		}})
+	case "/accounts/acc-2/":
+		w.WriteHeader(http.StatusTooManyRequests)
+		reply(map[string]string{"summary": "Rate limit exceeded", "detail": "The daily request limit for this account has been reached"})
+	default:
+		f.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeClient(t *testing.T, tokens Tokens) (*Client, *fakeGoCardless) {
+	fake := &fakeGoCardless{t: t, requests: make(map[string]int)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := NewClient("secret-id", "secret-key", tokens)
+	client.BaseURL = server.URL
+	return client, fake
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	client, fake := newFakeClient(t, Tokens{})
+	client.now = func() time.Time { return now }
+	if _, err := client.Requisition("req-1"); err != nil {
+		t.Fatalf("Requisition failed: %v", err)
+	}
+	if _, err := client.Requisition("req-1"); err != nil {
+		t.Fatalf("Requisition failed: %v", err)
+	}
+	tokens, changed := client.Tokens()
+	if !changed || fake.issued != 1 || tokens.Access != "access-1" || !tokens.AccessExpires.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Expected one new token reused for both requests, got %+v after %d", tokens, fake.issued)
+	}
+
+	// An expired access token is refreshed, keeping the refresh token
+	client, fake = newFakeClient(t, tokens)
+	client.now = func() time.Time { return now.Add(25 * time.Hour) }
+	if _, err := client.Requisition("req-1"); err != nil {
+		t.Fatalf("Requisition failed: %v", err)
+	}
+	refreshed, changed := client.Tokens()
+	if !changed || fake.issued != 0 || fake.requests["/token/refresh/"] != 1 || refreshed.Access != "access-2" || refreshed.Refresh != "refresh-1" {
+		t.Errorf("Expected the access token to be refreshed, got %+v", refreshed)
+	}
+
+	// Saved tokens that are still current are used as they are
+	client, fake = newFakeClient(t, refreshed)
+	client.now = func() time.Time { return now.Add(26 * time.Hour) }
+	if _, err := client.Requisition("req-1"); err != nil {
+		t.Fatalf("Requisition failed: %v", err)
+	}
+	if _, changed := client.Tokens(); changed || fake.issued != 0 || fake.requests["/token/refresh/"] != 0 {
+		t.Error("Expected current tokens to be used without renewing them")
+	}
+
+	client, _ = newFakeClient(t, Tokens{})
+	client.SecretKey = "wrong"
+	if err := client.NewTokens(); err == nil || !strings.Contains(err.Error(), "401: Authentication failed: No active account") {
+		t.Errorf("Expected GoCardless's error, got %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	client, fake := newFakeClient(t, Tokens{})
+	banks := []Bank{
+		{RequisitionID: "req-1", InstitutionID: "DEUTSCHE_DEUTDEFF", InstitutionName: "Deutsche Bank"},
+		{RequisitionID: "req-2", InstitutionID: "REVOLUT_REVOLT21", InstitutionName: "Revolut"},
+	}
+
+	resp := Fetch(client, banks, FetchOptions{Since: "2025-03-01"})
+	if len(resp.Accounts) != 1 {
+		t.Fatalf("Expected one account, got %d", len(resp.Accounts))
+	}
+	if len(resp.Errors) != 2 || !strings.Contains(resp.Errors[0], "Rate limit exceeded") ||
+		!strings.Contains(resp.Errors[1], "Revolut: access is expired, reconnect with 'money init gocardless --institution REVOLUT_REVOLT21'") {
+		t.Errorf("Unexpected errors %q", resp.Errors)
+	}
+
+	account := resp.Accounts[0]
+	if account.ID != AccountID("DEUTSCHE_DEUTDEFF", AccountMetadata{ID: "another-id", IBAN: "DE89370400440532013000"}) {
+		t.Errorf("Expected the account ID to come from the IBAN, got %s", account.ID)
+	}
+	if account.Org.ID != "gc_DEUTSCHE_DEUTDEFF" || account.Org.Name != "Deutsche Bank" {
+		t.Errorf("Unexpected organization %+v", account.Org)
+	}
+	if account.Name != "Girokonto" || account.Currency != "EUR" || account.Balance != "1000.50" ||
+		account.AvailableBalance == nil || *account.AvailableBalance != "1200.50" {
+		t.Errorf("Unexpected account %+v", account)
+	}
+
+	if len(account.Transactions) != 4 {
+		t.Fatalf("Expected the four booked transactions, got %d", len(account.Transactions))
+	}
+	descriptions := make([]string, len(account.Transactions))
+	ids := make(map[string]bool)
+	for i, txn := range account.Transactions {
+		descriptions[i] = txn.Description
+		ids[txn.ID] = true
+	}
+	if got := strings.Join(descriptions, ", "); got != "REWE, ACME GmbH, Coffee Bar, Coffee Bar" {
+		t.Errorf("Unexpected descriptions %s", got)
+	}
+	if len(ids) != 4 {
+		t.Error("Expected identical transactions without IDs to get different IDs")
+	}
+
+	// The same transactions get the same IDs every fetch, and known
+	// accounts keep their name without their details being read again
+	known := map[string]database.Account{account.ID: {ID: account.ID, Name: "Joint", Currency: "EUR"}}
+	again := Fetch(client, banks, FetchOptions{Since: "2025-03-01", Known: known})
+	if len(again.Accounts) != 1 || again.Accounts[0].Name != "Joint" || fake.requests["/accounts/acc-1/details/"] != 1 {
+		t.Errorf("Expected the known account's name to be kept, got %+v", again.Accounts)
+	}
+	for i, txn := range again.Accounts[0].Transactions {
+		if txn.ID != account.Transactions[i].ID {
+			t.Errorf("Transaction %d changed ID from %s to %s", i, account.Transactions[i].ID, txn.ID)
+		}
+	}
+}
+
+func TestAccountIDWithoutIBAN(t *testing.T) {
+	a := AccountID("BANK", AccountMetadata{ID: "acc-1"})
+	b := AccountID("BANK", AccountMetadata{ID: "acc-2"})
+	if a == b || !strings.HasPrefix(a, "gc_") {
+		t.Errorf("Expected distinct prefixed IDs, got %s and %s", a, b)
+	}
+}
@@ -0,0 +1,205 @@
+package holdings
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// Gain is a realized gain on a sold lot or an unrealized gain on shares
+// still held. Amounts are in cents.
+type Gain struct {
+	AccountID string
+	Symbol    string
+	LotID     int // 0 for a SimpleFIN holding without lots
+	Shares    float64
+	Acquired  string // YYYY-MM-DD, empty when unknown
+	Sold      string // YYYY-MM-DD, empty while held
+	CostBasis *int64 // nil when unknown
+	Value     int64  // sale proceeds, or current market value
+	Priced    bool   // false for held lots with no holding to price them
+}
+
+// Amount returns the gain, or false when the cost basis or value is unknown
+func (g Gain) Amount() (int64, bool) {
+	if g.CostBasis == nil || !g.Priced {
+		return 0, false
+	}
+	return g.Value - *g.CostBasis, true
+}
+
+// LongTerm reports whether the shares were held for more than a year by the
+// sale, or by asOf while still held. Unknown purchase dates count as short
+// term.
+func (g Gain) LongTerm(asOf time.Time) bool {
+	acquired, err := time.Parse("2006-01-02", g.Acquired)
+	if err != nil {
+		return false
+	}
+	end := asOf
+	if g.Sold != "" {
+		if sold, err := time.Parse("2006-01-02", g.Sold); err == nil {
+			end = sold
+		}
+	}
+	return end.After(acquired.AddDate(1, 0, 0))
+}
+
+// GainsReport holds the gains realized in a year and the unrealized gains on
+// everything still held
+type GainsReport struct {
+	Year       int
+	AsOf       time.Time
+	Realized   []Gain
+	Unrealized []Gain
+}
+
+// Totals returns the short and long term totals of gains, skipping those
+// with an unknown amount
+func (r *GainsReport) Totals(gains []Gain) (shortTerm, longTerm int64) {
+	for _, gain := range gains {
+		amount, ok := gain.Amount()
+		if !ok {
+			continue
+		}
+		if gain.LongTerm(r.AsOf) {
+			longTerm += amount
+		} else {
+			shortTerm += amount
+		}
+	}
+	return shortTerm, longTerm
+}
+
+// AddLot records a purchase of shares entered by hand
+func (s *Service) AddLot(lot database.Lot) (int, error) {
+	if _, err := s.db.GetAccountByID(lot.AccountID); err != nil {
+		return 0, err
+	}
+	lot.Symbol = strings.ToUpper(strings.TrimSpace(lot.Symbol))
+	if lot.Symbol == "" {
+		return 0, fmt.Errorf("symbol cannot be empty")
+	}
+	if lot.Shares <= 0 {
+		return 0, fmt.Errorf("shares must be positive")
+	}
+	if lot.CostBasis < 0 {
+		return 0, fmt.Errorf("cost basis cannot be negative")
+	}
+	if _, err := time.Parse("2006-01-02", lot.Acquired); err != nil {
+		return 0, fmt.Errorf("invalid purchase date %q, expected YYYY-MM-DD", lot.Acquired)
+	}
+	lot.Sold = nil
+	lot.Proceeds = nil
+	return s.db.AddLot(lot)
+}
+
+// SellLot records selling shares of a lot on a date for proceeds, splitting
+// the lot when only some of its shares are sold
+func (s *Service) SellLot(id int, shares float64, sold string, proceeds int64) (int, error) {
+	if _, err := time.Parse("2006-01-02", sold); err != nil {
+		return 0, fmt.Errorf("invalid sale date %q, expected YYYY-MM-DD", sold)
+	}
+	lot, err := s.db.GetLot(id)
+	if err != nil {
+		return 0, err
+	}
+	if sold < lot.Acquired {
+		return 0, fmt.Errorf("lot %d was bought on %s, after %s", id, lot.Acquired, sold)
+	}
+	if shares == 0 {
+		shares = lot.Shares
+	}
+	return s.db.SellLot(id, shares, sold, proceeds)
+}
+
+// RemoveLot deletes a lot entered by mistake
+func (s *Service) RemoveLot(id int) error {
+	return s.db.DeleteLot(id)
+}
+
+// ListLots returns the lots of one account, or every account when accountID
+// is empty
+func (s *Service) ListLots(accountID string) ([]database.Lot, error) {
+	return s.db.GetLots(accountID)
+}
+
+// GetGains returns the gains realized on lots sold in year, and the
+// unrealized gains as of now. Held shares are valued at their holding's
+// current price per share. A holding with lots entered for its symbol is
+// broken down into those lots; otherwise it's one position with the cost
+// basis SimpleFIN reported.
+func (s *Service) GetGains(year int, now time.Time) (*GainsReport, error) {
+	lots, err := s.db.GetLots("")
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.db.GetHoldings("")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GainsReport{Year: year, AsOf: now}
+
+	type position struct{ accountID, symbol string }
+	prices := make(map[position]float64)
+	for _, holding := range holdings {
+		if holding.Shares != 0 && holding.Symbol != "" {
+			prices[position{holding.AccountID, strings.ToUpper(holding.Symbol)}] = float64(holding.MarketValue) / holding.Shares
+		}
+	}
+
+	lotted := make(map[position]bool)
+	for _, lot := range lots {
+		key := position{lot.AccountID, lot.Symbol}
+		costBasis := lot.CostBasis
+		gain := Gain{
+			AccountID: lot.AccountID,
+			Symbol:    lot.Symbol,
+			LotID:     lot.ID,
+			Shares:    lot.Shares,
+			Acquired:  lot.Acquired,
+			CostBasis: &costBasis,
+		}
+
+		if lot.Sold != nil {
+			if !strings.HasPrefix(*lot.Sold, fmt.Sprintf("%04d-", year)) {
+				continue
+			}
+			gain.Sold = *lot.Sold
+			if lot.Proceeds != nil {
+				gain.Value = *lot.Proceeds
+			}
+			gain.Priced = true
+			report.Realized = append(report.Realized, gain)
+			continue
+		}
+
+		lotted[key] = true
+		if price, ok := prices[key]; ok {
+			gain.Value = int64(math.Round(price * lot.Shares))
+			gain.Priced = true
+		}
+		report.Unrealized = append(report.Unrealized, gain)
+	}
+
+	for _, holding := range holdings {
+		if lotted[position{holding.AccountID, strings.ToUpper(holding.Symbol)}] {
+			continue
+		}
+		report.Unrealized = append(report.Unrealized, Gain{
+			AccountID: holding.AccountID,
+			Symbol:    holding.Symbol,
+			Shares:    holding.Shares,
+			Acquired:  holding.Acquired,
+			CostBasis: holding.CostBasis,
+			Value:     holding.MarketValue,
+			Priced:    true,
+		})
+	}
+
+	return report, nil
+}
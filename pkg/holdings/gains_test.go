@@ -0,0 +1,118 @@
+package holdings
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestGetGains(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org1", "Brokerage", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("brokerage", "org1", "Brokerage", "USD", 500000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	costBasis := int64(150000)
+	err = db.SaveHoldings("brokerage", []database.Holding{
+		{ID: "h1", Symbol: "VTI", Shares: 10, MarketValue: 250000},
+		{ID: "h2", Symbol: "AAPL", Shares: 10, MarketValue: 200000, CostBasis: &costBasis, Acquired: "2025-02-01"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save holdings: %v", err)
+	}
+
+	service := NewService(db)
+	if _, err := service.AddLot(database.Lot{AccountID: "missing", Symbol: "VTI", Shares: 1, Acquired: "2020-01-01"}); err == nil {
+		t.Error("Expected an error for an unknown account")
+	}
+	if _, err := service.AddLot(database.Lot{AccountID: "brokerage", Symbol: "VTI", Shares: 1, Acquired: "soon"}); err == nil {
+		t.Error("Expected an error for an invalid date")
+	}
+
+	// 20 VTI bought in 2020, 10 of them sold in 2025
+	oldLot, err := service.AddLot(database.Lot{AccountID: "brokerage", Symbol: "vti", Shares: 20, CostBasis: 300000, Acquired: "2020-01-15"})
+	if err != nil {
+		t.Fatalf("Failed to add lot: %v", err)
+	}
+	soldLot, err := service.SellLot(oldLot, 10, "2025-03-01", 230000)
+	if err != nil {
+		t.Fatalf("Failed to sell lot: %v", err)
+	}
+	if soldLot == oldLot {
+		t.Fatal("Expected a partial sale to split the lot")
+	}
+	if _, err := service.SellLot(soldLot, 0, "2025-04-01", 1); err == nil {
+		t.Error("Expected an error selling a sold lot")
+	}
+	if _, err := service.SellLot(oldLot, 0, "2019-01-01", 1); err == nil {
+		t.Error("Expected an error selling before the purchase")
+	}
+
+	// A lot bought and sold within 2025 is short term
+	shortLot, err := service.AddLot(database.Lot{AccountID: "brokerage", Symbol: "VTI", Shares: 1, CostBasis: 24000, Acquired: "2025-01-10"})
+	if err != nil {
+		t.Fatalf("Failed to add lot: %v", err)
+	}
+	if _, err := service.SellLot(shortLot, 0, "2025-06-10", 23000); err != nil {
+		t.Fatalf("Failed to sell lot: %v", err)
+	}
+
+	now := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	report, err := service.GetGains(2025, now)
+	if err != nil {
+		t.Fatalf("GetGains failed: %v", err)
+	}
+
+	if len(report.Realized) != 2 {
+		t.Fatalf("Expected 2 realized gains, got %+v", report.Realized)
+	}
+	shortTerm, longTerm := report.Totals(report.Realized)
+	if longTerm != 80000 || shortTerm != -1000 {
+		t.Errorf("Expected realized long term 80000 and short term -1000, got %d and %d", longTerm, shortTerm)
+	}
+
+	// The remaining VTI lot replaces the VTI holding; AAPL uses SimpleFIN's
+	// cost basis
+	if len(report.Unrealized) != 2 {
+		t.Fatalf("Expected 2 unrealized gains, got %+v", report.Unrealized)
+	}
+	for _, gain := range report.Unrealized {
+		amount, ok := gain.Amount()
+		switch gain.Symbol {
+		case "VTI":
+			if gain.LotID != oldLot || !ok || amount != 100000 || !gain.LongTerm(now) {
+				t.Errorf("Expected a long term gain of 100000 on the VTI lot, got %+v", gain)
+			}
+		case "AAPL":
+			if !ok || amount != 50000 || gain.LongTerm(now) {
+				t.Errorf("Expected a short term gain of 50000 on AAPL, got %+v", gain)
+			}
+		default:
+			t.Errorf("Unexpected gain %+v", gain)
+		}
+	}
+
+	report, err = service.GetGains(2024, now)
+	if err != nil {
+		t.Fatalf("GetGains failed: %v", err)
+	}
+	if len(report.Realized) != 0 {
+		t.Errorf("Expected no gains realized in 2024, got %+v", report.Realized)
+	}
+}
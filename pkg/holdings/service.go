@@ -0,0 +1,170 @@
+// Package holdings revalues investment accounts from market quotes between
+// SimpleFIN syncs, using the holdings SimpleFIN last reported.
+package holdings
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/quote"
+)
+
+type Service struct {
+	db     *database.DB
+	source quote.Source
+}
+
+// NewService creates a holdings service that fetches prices from the
+// configured quote source
+func NewService(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// NewServiceWithSource creates a holdings service that fetches prices from
+// source
+func NewServiceWithSource(db *database.DB, source quote.Source) *Service {
+	return &Service{db: db, source: source}
+}
+
+// quoteSource returns the source prices come from, set up from the config
+// the first time it's needed
+func (s *Service) quoteSource() (quote.Source, error) {
+	if s.source == nil {
+		cfg := s.db.GetConfig()
+		source, err := quote.New(cfg.QuoteSource, cfg.AlphaVantageAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		s.source = source
+	}
+	return s.source, nil
+}
+
+// ListHoldings returns the holdings of one account, or of every account when
+// accountID is empty
+func (s *Service) ListHoldings(accountID string) ([]database.Holding, error) {
+	return s.db.GetHoldings(accountID)
+}
+
+// AccountRefresh is an investment account revalued by Refresh
+type AccountRefresh struct {
+	Account    database.Account
+	OldBalance int64
+	NewBalance int64
+	Repriced   int // holdings revalued from a quote
+	Unpriced   int // holdings kept at their last value
+}
+
+// RefreshSummary reports what Refresh changed
+type RefreshSummary struct {
+	Accounts []AccountRefresh
+	// Failed maps symbols that couldn't be priced to the reason
+	Failed map[string]error
+}
+
+// Refresh revalues every holding with a ticker from the latest quote and
+// moves its account's balance by the change, so cash and holdings without a
+// quote keep the value SimpleFIN last reported. Each symbol is quoted once.
+func (s *Service) Refresh() (*RefreshSummary, error) {
+	source, err := s.quoteSource()
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, err := s.db.GetHoldings("")
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &RefreshSummary{Failed: make(map[string]error)}
+	if len(holdings) == 0 {
+		return summary, nil
+	}
+
+	accounts, err := s.db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountsByID := make(map[string]database.Account, len(accounts))
+	for _, account := range accounts {
+		accountsByID[account.ID] = account
+	}
+
+	// Holdings are ordered by account, so each account is finished before
+	// the next one starts
+	prices := make(map[string]float64)
+	var refresh *AccountRefresh
+	for _, holding := range holdings {
+		if refresh == nil || refresh.Account.ID != holding.AccountID {
+			if err := s.finish(summary, refresh); err != nil {
+				return summary, err
+			}
+			refresh = nil
+			// Crypto accounts are valued by 'money crypto refresh'
+			account, ok := accountsByID[holding.AccountID]
+			if !ok || (account.AccountType != nil && *account.AccountType == "crypto") {
+				continue
+			}
+			refresh = &AccountRefresh{
+				Account:    account,
+				OldBalance: account.Balance,
+				NewBalance: account.Balance,
+			}
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(holding.Symbol))
+		if symbol == "" || holding.Shares == 0 {
+			refresh.Unpriced++
+			continue
+		}
+
+		price, ok := prices[symbol]
+		if !ok {
+			if _, failed := summary.Failed[symbol]; failed {
+				refresh.Unpriced++
+				continue
+			}
+			price, err = source.Price(symbol)
+			if err != nil {
+				summary.Failed[symbol] = err
+				refresh.Unpriced++
+				continue
+			}
+			prices[symbol] = price
+		}
+
+		value := int64(math.Round(holding.Shares * price * 100))
+		if err := s.db.UpdateHoldingValue(holding.ID, value); err != nil {
+			return summary, err
+		}
+		refresh.NewBalance += value - holding.MarketValue
+		refresh.Repriced++
+	}
+
+	if err := s.finish(summary, refresh); err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// finish saves a revalued account's new balance and adds it to the summary
+func (s *Service) finish(summary *RefreshSummary, refresh *AccountRefresh) error {
+	if refresh == nil {
+		return nil
+	}
+
+	if refresh.NewBalance != refresh.OldBalance {
+		if err := s.db.UpdateAccountBalance(refresh.Account.ID, refresh.NewBalance); err != nil {
+			return fmt.Errorf("failed to update account balance: %w", err)
+		}
+		if err := s.db.SaveBalanceHistory(refresh.Account.ID, refresh.NewBalance, refresh.Account.AvailableBalance); err != nil {
+			return fmt.Errorf("failed to save balance history: %w", err)
+		}
+	}
+
+	summary.Accounts = append(summary.Accounts, *refresh)
+	return nil
+}
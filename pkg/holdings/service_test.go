@@ -0,0 +1,122 @@
+package holdings
+
+import (
+	"os"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/quote"
+)
+
+// fakeSource returns fixed prices and counts lookups
+type fakeSource struct {
+	prices map[string]float64
+	calls  int
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Price(symbol string) (float64, error) {
+	f.calls++
+	price, ok := f.prices[symbol]
+	if !ok {
+		return 0, quote.ErrNoQuote
+	}
+	return price, nil
+}
+
+func TestRefresh(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org1", "Brokerage", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	// The brokerage account holds $500 of cash besides its holdings
+	if err := db.SaveAccount("brokerage", "org1", "Brokerage", "USD", 350000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveAccount("ira", "org1", "IRA", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	err = db.SaveHoldings("brokerage", []database.Holding{
+		{ID: "h1", Symbol: "VTI", Shares: 10, MarketValue: 200000},
+		{ID: "h2", Symbol: "", Description: "Private fund", Shares: 1, MarketValue: 100000},
+		{ID: "h3", Symbol: "GONE", Shares: 5, MarketValue: 0},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save holdings: %v", err)
+	}
+	err = db.SaveHoldings("ira", []database.Holding{
+		{ID: "h4", Symbol: "vti", Shares: 2.5, MarketValue: 50000},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save holdings: %v", err)
+	}
+
+	source := &fakeSource{prices: map[string]float64{"VTI": 210.5}}
+	summary, err := NewServiceWithSource(db, source).Refresh()
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if source.calls != 2 {
+		t.Errorf("Expected each symbol to be quoted once, got %d calls", source.calls)
+	}
+	if len(summary.Failed) != 1 || summary.Failed["GONE"] == nil {
+		t.Errorf("Expected GONE to fail, got %v", summary.Failed)
+	}
+	if len(summary.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts refreshed, got %d", len(summary.Accounts))
+	}
+
+	brokerage := summary.Accounts[0]
+	if brokerage.Account.ID != "brokerage" || brokerage.Repriced != 1 || brokerage.Unpriced != 2 {
+		t.Errorf("Unexpected brokerage refresh: %+v", brokerage)
+	}
+	// VTI moved from $2,000 to $2,105; cash and the private fund are unchanged
+	if brokerage.NewBalance != 360500 {
+		t.Errorf("Expected brokerage balance 360500, got %d", brokerage.NewBalance)
+	}
+
+	account, err := db.GetAccountByID("ira")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.Balance != 102625 {
+		t.Errorf("Expected IRA balance 102625, got %d", account.Balance)
+	}
+
+	list, err := db.GetHoldings("ira")
+	if err != nil {
+		t.Fatalf("Failed to get holdings: %v", err)
+	}
+	if len(list) != 1 || list[0].MarketValue != 52625 || list[0].PriceUpdatedAt == nil {
+		t.Errorf("Expected the IRA holding to be repriced, got %+v", list)
+	}
+
+	// A sync replaces the refreshed values with the reported ones
+	err = db.SaveHoldings("ira", []database.Holding{
+		{ID: "h4", Symbol: "VTI", Shares: 2.5, MarketValue: 52000},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save holdings: %v", err)
+	}
+	list, err = db.GetHoldings("ira")
+	if err != nil {
+		t.Fatalf("Failed to get holdings: %v", err)
+	}
+	if len(list) != 1 || list[0].MarketValue != 52000 || list[0].PriceUpdatedAt != nil {
+		t.Errorf("Expected the reported value after a sync, got %+v", list)
+	}
+}
@@ -0,0 +1,208 @@
+// Package ical writes bills, credit card statement due dates and expected
+// paydays as an iCalendar (RFC 5545) file, so a phone or calendar app can
+// subscribe to them.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/alerts"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/report"
+)
+
+// DefaultPaydayMonths is how many months of expected paydays a calendar
+// covers by default
+const DefaultPaydayMonths = 3
+
+// refreshInterval is how often subscribed calendar apps are asked to fetch
+// the calendar again
+const refreshInterval = "PT12H"
+
+// Options controls what a calendar covers
+type Options struct {
+	// PaydayMonths is how many months ahead expected paydays are listed
+	PaydayMonths int
+}
+
+// Event is an all-day calendar event
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Date        time.Time
+	// DueDay, when set, repeats the event every month on that day, or on
+	// the last day of months too short for it
+	DueDay int
+}
+
+// Calendar is the events built from a money database, ready to write
+type Calendar struct {
+	Events []Event
+	stamp  time.Time
+}
+
+// Build builds a calendar of the bills, which repeat monthly from their
+// due date this month, the statement due dates of accounts that have one
+// set, likewise, and the paydays the current pay schedules expect over the
+// next opts.PaydayMonths months. Paydays are dated one by one, since they
+// are only estimates that improve as paychecks come in.
+func Build(db *database.DB, opts Options) (*Calendar, error) {
+	if opts.PaydayMonths <= 0 {
+		opts.PaydayMonths = DefaultPaydayMonths
+	}
+	now := format.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, format.Location())
+	calendar := &Calendar{stamp: now}
+
+	bills, err := db.GetBills()
+	if err != nil {
+		return nil, err
+	}
+	for _, bill := range bills {
+		calendar.Events = append(calendar.Events, Event{
+			UID:     fmt.Sprintf("bill-%d@money", bill.ID),
+			Summary: fmt.Sprintf("%s due: %s", bill.Name, format.Currency(bill.Amount, format.BaseCurrency())),
+			Date:    alerts.NextDueDate(bill.DueDay, monthStart),
+			DueDay:  bill.DueDay,
+		})
+	}
+
+	dueDays, err := db.GetStatementDueDays()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].DisplayName() < accounts[j].DisplayName()
+	})
+	for _, account := range accounts {
+		dueDay, exists := dueDays[account.ID]
+		if !exists {
+			continue
+		}
+		calendar.Events = append(calendar.Events, Event{
+			UID:     fmt.Sprintf("statement-%s@money", account.ID),
+			Summary: fmt.Sprintf("%s payment due", account.DisplayName()),
+			Date:    alerts.NextDueDate(dueDay, monthStart),
+			DueDay:  dueDay,
+		})
+	}
+
+	schedules, err := report.CurrentPaySchedules(db, now)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, format.Location())
+	until := today.AddDate(0, opts.PaydayMonths, 0)
+	for _, schedule := range schedules {
+		for _, payday := range schedule.Next(today.AddDate(0, 0, -1), until) {
+			calendar.Events = append(calendar.Events, Event{
+				UID:         fmt.Sprintf("payday-%s-%s@money", uidPart(schedule.Payer), payday.Format("20060102")),
+				Summary:     fmt.Sprintf("Payday: %s", schedule.Payer),
+				Description: fmt.Sprintf("Expected about %s, going by recent paychecks", format.Currency(schedule.Amount, format.BaseCurrency())),
+				Date:        payday,
+			})
+		}
+	}
+	return calendar, nil
+}
+
+// uidPart reduces s to the letters and digits of a readable UID
+func uidPart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// monthlyRule returns the RRULE of an event due on day every month. A day
+// past 28 takes the last of the days up to it each month has, so the 31st
+// falls on the 30th, or February's last day, in shorter months, the way
+// alerts.NextDueDate counts it.
+func monthlyRule(day int) string {
+	if day <= 28 {
+		return fmt.Sprintf("FREQ=MONTHLY;BYMONTHDAY=%d", day)
+	}
+	days := make([]string, 0, day-27)
+	for d := 28; d <= day; d++ {
+		days = append(days, strconv.Itoa(d))
+	}
+	return fmt.Sprintf("FREQ=MONTHLY;BYMONTHDAY=%s;BYSETPOS=-1", strings.Join(days, ","))
+}
+
+// escape escapes text for a property value
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// writeLine writes a content line, folded into lines of at most 75 octets
+// without splitting a character, and ended with CRLF
+func writeLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts
+		limit = 74
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// Write writes the calendar to w as an iCalendar file
+func (c *Calendar) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	stamp := c.stamp.UTC().Format("20060102T150405Z")
+
+	writeLine(out, "BEGIN:VCALENDAR")
+	writeLine(out, "VERSION:2.0")
+	writeLine(out, "PRODID:-//money//bills//EN")
+	writeLine(out, "CALSCALE:GREGORIAN")
+	writeLine(out, "METHOD:PUBLISH")
+	writeLine(out, "X-WR-CALNAME:Bills and paydays")
+	writeLine(out, "REFRESH-INTERVAL;VALUE=DURATION:"+refreshInterval)
+	writeLine(out, "X-PUBLISHED-TTL:"+refreshInterval)
+	for _, event := range c.Events {
+		writeLine(out, "BEGIN:VEVENT")
+		writeLine(out, "UID:"+event.UID)
+		writeLine(out, "DTSTAMP:"+stamp)
+		writeLine(out, "DTSTART;VALUE=DATE:"+event.Date.Format("20060102"))
+		if event.DueDay > 0 {
+			writeLine(out, "RRULE:"+monthlyRule(event.DueDay))
+		}
+		writeLine(out, "SUMMARY:"+escape(event.Summary))
+		if event.Description != "" {
+			writeLine(out, "DESCRIPTION:"+escape(event.Description))
+		}
+		writeLine(out, "TRANSP:TRANSPARENT")
+		writeLine(out, "END:VEVENT")
+	}
+	writeLine(out, "END:VCALENDAR")
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
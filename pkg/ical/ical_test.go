@@ -0,0 +1,138 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestBuild(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	for id, name := range map[string]string{"checking": "Checking", "card": "Card"} {
+		if err := db.SaveAccount(id, "org-1", name, "USD", 100000, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+	if err := db.SetStatementDueDay("card", 22); err != nil {
+		t.Fatalf("Failed to set statement due day: %v", err)
+	}
+	if _, err := db.SaveBill("Rent, Apt 4", 150000, 31, "checking"); err != nil {
+		t.Fatalf("Failed to save bill: %v", err)
+	}
+
+	// Paid every two weeks up to three days ago, and by an old job until two
+	// months ago
+	today := format.Now()
+	for i := 0; i < 6; i++ {
+		posted := today.AddDate(0, 0, -3-14*i).UTC().Format(time.RFC3339)
+		if err := db.SaveTransaction(fmt.Sprintf("pay-%d", i), "checking", posted, 250000, "ACME CORP PAYROLL PPD", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		posted = today.AddDate(0, -2, -14*i).UTC().Format(time.RFC3339)
+		if err := db.SaveTransaction(fmt.Sprintf("old-%d", i), "checking", posted, 200000, "OLDJOB PAYROLL PPD", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	calendar, err := Build(db, Options{PaydayMonths: 1})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	ics := buf.String()
+
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected a calendar with CRLF line endings, got %q", ics)
+	}
+	for _, want := range []string{
+		"UID:bill-1@money\r\n",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1\r\n",
+		`SUMMARY:Rent\, Apt 4 due: $1\,500.00` + "\r\n",
+		"UID:statement-card@money\r\n",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=22\r\n",
+		"SUMMARY:Card payment due\r\n",
+		"SUMMARY:Payday: Acme Corp Payroll\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected %q in the calendar:\n%s", want, ics)
+		}
+	}
+	if strings.Contains(ics, "Oldjob") {
+		t.Errorf("Expected no paydays from a payer that stopped paying:\n%s", ics)
+	}
+
+	// Paydays are the next ones after the latest paycheck, 11 days on and
+	// every two weeks after, within the month
+	var paydays []string
+	for _, event := range calendar.Events {
+		if strings.HasPrefix(event.UID, "payday-") {
+			paydays = append(paydays, event.Date.Format("2006-01-02"))
+		}
+	}
+	latest := today.AddDate(0, 0, -3)
+	var want []string
+	for day := latest.AddDate(0, 0, 14); !day.After(today.AddDate(0, 1, 0)); day = day.AddDate(0, 0, 14) {
+		want = append(want, day.Format("2006-01-02"))
+	}
+	if fmt.Sprint(paydays) != fmt.Sprint(want) {
+		t.Errorf("Expected paydays %v, got %v", want, paydays)
+	}
+}
+
+func TestMonthlyRule(t *testing.T) {
+	tests := map[int]string{
+		1:  "FREQ=MONTHLY;BYMONTHDAY=1",
+		28: "FREQ=MONTHLY;BYMONTHDAY=28",
+		30: "FREQ=MONTHLY;BYMONTHDAY=28,29,30;BYSETPOS=-1",
+	}
+	for day, want := range tests {
+		if got := monthlyRule(day); got != want {
+			t.Errorf("monthlyRule(%d) = %q, want %q", day, got, want)
+		}
+	}
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	calendar := &Calendar{Events: []Event{{
+		UID:         "long@money",
+		Summary:     "Payday",
+		Description: strings.Repeat("é", 100),
+		Date:        time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+	}}}
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var description string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+		if strings.HasPrefix(line, "DESCRIPTION:") {
+			description = strings.TrimPrefix(line, "DESCRIPTION:")
+		} else if strings.HasPrefix(line, " ") {
+			description += line[1:]
+		}
+	}
+	if description != strings.Repeat("é", 100) {
+		t.Errorf("Expected the description to unfold intact, got %q", description)
+	}
+}
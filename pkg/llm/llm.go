@@ -3,16 +3,27 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/arjungandhi/money/internal/dbutil"
 	"github.com/arjungandhi/money/pkg/config"
 	"github.com/arjungandhi/money/pkg/database"
 )
 
+// Client runs the configured LLM command. It is safe for concurrent use;
+// LLMRequestsPerMinute limits how often the command is started.
 type Client struct {
-	config *config.Config
+	config   *config.Config
+	auditLog *database.DB
+
+	mu   sync.Mutex
+	next time.Time // when the next command may be started
 }
 
 func NewClient() *Client {
@@ -27,11 +38,9 @@ func NewClientWithConfig(cfg *config.Config) *Client {
 	}
 }
 
-// TransferSuggestion represents a suggested inter-account transfer
-type TransferSuggestion struct {
-	TransactionID string `json:"transaction_id"`
-	IsTransfer    bool   `json:"is_transfer"`
-	Reasoning     string `json:"reasoning"`
+// SetAuditLog records calls in db instead of the shared handle from dbutil
+func (c *Client) SetAuditLog(db *database.DB) {
+	c.auditLog = db
 }
 
 // CategorySuggestion represents a suggested category for a transaction
@@ -42,11 +51,6 @@ type CategorySuggestion struct {
 	Reasoning     string  `json:"reasoning"`
 }
 
-// TransferAnalysisResult contains the results of transfer identification
-type TransferAnalysisResult struct {
-	Suggestions []TransferSuggestion `json:"suggestions"`
-}
-
 // CategoryAnalysisResult contains the results of transaction categorization
 type CategoryAnalysisResult struct {
 	Suggestions []CategorySuggestion `json:"suggestions"`
@@ -55,7 +59,7 @@ type CategoryAnalysisResult struct {
 func (c *Client) CategorizeTransactionsWithExamples(ctx context.Context, transactions []TransactionData, categories []database.Category, accounts []AccountData, examples []CategorizedExample) (*CategoryAnalysisResult, error) {
 	prompt := buildCategorizationPrompt(transactions, categories, accounts, examples)
 
-	response, err := c.runLLMCommand(ctx, prompt)
+	response, err := c.runLLMCommand(ctx, "categorize", prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to run LLM command for categorization: %w", err)
 	}
@@ -69,7 +73,72 @@ func (c *Client) CategorizeTransactionsWithExamples(ctx context.Context, transac
 	return &result, nil
 }
 
-func (c *Client) runLLMCommand(ctx context.Context, prompt string) (string, error) {
+// SummarizeSpendingAnomalies asks the LLM to explain in a few sentences why
+// month's spending looks different, given the anomalies that were detected
+func (c *Client) SummarizeSpendingAnomalies(ctx context.Context, month string, anomalies []SpendingAnomaly) (string, error) {
+	prompt := buildAnomalySummaryPrompt(month, anomalies)
+
+	response, err := c.runLLMCommand(ctx, "anomaly_summary", prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to run LLM command for anomaly summary: %w", err)
+	}
+
+	return response, nil
+}
+
+// SQLQuery is a parameterized query generated from a natural-language question
+type SQLQuery struct {
+	SQL         string        `json:"sql"`
+	Params      []interface{} `json:"params"`
+	Explanation string        `json:"explanation"`
+}
+
+// GenerateSQL asks the LLM to answer question with a single parameterized
+// SELECT statement against schema
+func (c *Client) GenerateSQL(ctx context.Context, question, schema string) (*SQLQuery, error) {
+	prompt := buildSQLPrompt(question, schema)
+
+	response, err := c.runLLMCommand(ctx, "ask", prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run LLM command for query generation: %w", err)
+	}
+
+	var query SQLQuery
+	err = json.Unmarshal([]byte(response), &query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response for query: %w", err)
+	}
+	if strings.TrimSpace(query.SQL) == "" {
+		return nil, fmt.Errorf("LLM did not return a query")
+	}
+
+	return &query, nil
+}
+
+// runLLMCommand pipes prompt to the LLM command and returns its output.
+// Every call is recorded in the audit log, including failed ones.
+func (c *Client) runLLMCommand(ctx context.Context, purpose, prompt string) (response string, err error) {
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	defer func() {
+		call := database.LLMCall{
+			Purpose:        purpose,
+			Command:        c.config.LLMPromptCmd,
+			Prompt:         prompt,
+			Response:       response,
+			LatencyMs:      int(time.Since(start).Milliseconds()),
+			PromptTokens:   estimateTokens(prompt),
+			ResponseTokens: estimateTokens(response),
+		}
+		if err != nil {
+			call.Error = err.Error()
+		}
+		slog.Debug("LLM call finished", "purpose", purpose, "command", call.Command, "latency_ms", call.LatencyMs, "err", err)
+		c.logCall(call)
+	}()
 
 	parts := strings.Fields(c.config.LLMPromptCmd)
 	if len(parts) == 0 {
@@ -90,18 +159,73 @@ func (c *Client) runLLMCommand(ctx context.Context, prompt string) (string, erro
 
 	output, err := cmd.Output()
 	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("failed to execute LLM command: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
 		return "", fmt.Errorf("failed to execute LLM command: %w", err)
 	}
 
 	return strings.TrimSpace(string(output)), nil
 }
 
+// wait blocks until the next command may be started under
+// LLMRequestsPerMinute, or ctx is done
+func (c *Client) wait(ctx context.Context) error {
+	perMinute := c.config.LLMRequestsPerMinute
+	if perMinute <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next
+	if start.Before(now) {
+		start = now
+	}
+	c.next = start.Add(time.Minute / time.Duration(perMinute))
+	c.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// logCall saves call to the audit log. Logging failures only print a
+// warning so they never break the command that called the LLM.
+func (c *Client) logCall(call database.LLMCall) {
+	db := c.auditLog
+	if db == nil {
+		shared, err := dbutil.Open()
+		if err != nil {
+			slog.Warn("failed to open database for the LLM log", "err", err)
+			return
+		}
+		db = shared
+	}
+
+	if _, err := db.SaveLLMCall(call); err != nil {
+		slog.Warn("failed to save LLM call to the log", "err", err)
+	}
+}
+
+// estimateTokens approximates the token count of text at about four
+// characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
 // TransactionData represents transaction data for LLM processing
 type TransactionData struct {
 	ID          string `json:"id"`
 	AccountID   string `json:"account_id"`
 	Posted      string `json:"posted"`
-	Amount      int    `json:"amount"`
+	Amount      int64  `json:"amount"`
 	Description string `json:"description"`
 	Pending     bool   `json:"pending"`
 }
@@ -109,8 +233,18 @@ type TransactionData struct {
 // CategorizedExample represents an example of a previously categorized transaction
 type CategorizedExample struct {
 	Description string `json:"description"`
-	Amount      int    `json:"amount"`
+	Amount      int64  `json:"amount"`
+	Category    string `json:"category"`
+}
+
+// SpendingAnomaly represents a category spike or unusual transaction for LLM processing
+type SpendingAnomaly struct {
+	Kind        string `json:"kind"` // "category_spike" or "unusual_transaction"
 	Category    string `json:"category"`
+	Description string `json:"description,omitempty"`
+	Date        string `json:"date,omitempty"`
+	Amount      int64  `json:"amount"`
+	Typical     int64  `json:"typical"`
 }
 
 // AccountData represents account data for LLM processing
@@ -121,80 +255,6 @@ type AccountData struct {
 	AccountType string `json:"account_type,omitempty"`
 }
 
-func buildTransferIdentificationPrompt(transactions []TransactionData, accounts []AccountData) string {
-	var prompt strings.Builder
-
-	prompt.WriteString(`You are a financial transaction analyzer. Your task is to identify inter-account transfers from the following transactions.
-
-WHAT TO LOOK FOR:
-Inter-account transfers are pairs of transactions that move money between the user's own accounts. Look for:
-
-1. MATCHING PAIRS: Two transactions with opposite amounts (+$X and -$X) on the same date or within 1-2 days
-2. TRANSFER DESCRIPTIONS: Keywords like "transfer", "move", "deposit from", "withdrawal to", "transfer to savings", "transfer from checking"
-3. INTERNAL MOVEMENT: Money moving between accounts the user owns (not payments to external merchants)
-4. ROUND AMOUNTS: Often round numbers like $100.00, $500.00, $1000.00
-
-EXAMPLES OF TRANSFERS:
-- Transaction A: -$500.00 "Transfer to Savings" (from checking)
-- Transaction B: +$500.00 "Transfer from Checking" (to savings)
-→ Both should be marked as transfers
-
-EXAMPLES OF NON-TRANSFERS:
-- "Starbucks Coffee" -$5.50 → Regular merchant purchase
-- "Salary Deposit" +$2500.00 → Income from employer
-- "Electric Bill" -$120.00 → Payment to utility company
-
-ACCOUNTS:
-`)
-
-	for _, account := range accounts {
-		displayName := account.Name
-		if account.Nickname != "" {
-			displayName = account.Nickname
-		}
-		prompt.WriteString(fmt.Sprintf("- %s (%s) - Type: %s\n", account.ID, displayName, account.AccountType))
-	}
-
-	prompt.WriteString("\nTRANSACTIONS:\n")
-	for _, tx := range transactions {
-		amountDollars := float64(tx.Amount) / 100.0
-		prompt.WriteString(fmt.Sprintf("ID: %s, Account: %s, Date: %s, Amount: $%.2f, Description: %s\n",
-			tx.ID, tx.AccountID, tx.Posted, amountDollars, tx.Description))
-	}
-
-	prompt.WriteString(`
-ANALYSIS TASK:
-Examine each transaction above and determine if it's an inter-account transfer. For each transaction you identify as a transfer, include it in the JSON response.
-
-IMPORTANT RULES:
-- Only return transactions that are CLEARLY transfers (descriptions with "transfer", matching amounts between accounts, etc.)
-- Be conservative - it's better to miss some transfers than mark regular purchases as transfers
-- If unsure, don't include the transaction
-- Look for PAIRS of transactions that match (one negative, one positive with same amount)
-
-CRITICAL: Return ONLY raw JSON with no additional text, explanations, markdown formatting, or code blocks. Do not wrap in code blocks. Return the JSON object directly.
-
-Required JSON format:
-{
-  "suggestions": [
-    {
-      "transaction_id": "tx1",
-      "is_transfer": true,
-      "reasoning": "Transfer to Savings - clear transfer description with matching amount"
-    },
-    {
-      "transaction_id": "tx2",
-      "is_transfer": true,
-      "reasoning": "Transfer from Checking - matches the previous transfer amount"
-    }
-  ]
-}
-
-Return ONLY the raw JSON object with no markdown formatting:`)
-
-	return prompt.String()
-}
-
 func buildCategorizationPrompt(transactions []TransactionData, categories []database.Category, accounts []AccountData, examples []CategorizedExample) string {
 	var prompt strings.Builder
 
@@ -313,3 +373,79 @@ Return ONLY the raw JSON object with no markdown formatting:`)
 
 	return prompt.String()
 }
+
+func buildAnomalySummaryPrompt(month string, anomalies []SpendingAnomaly) string {
+	var prompt strings.Builder
+
+	prompt.WriteString(fmt.Sprintf(`You are a personal finance assistant. The user's spending in %s was flagged as unusual compared to the previous months.
+
+FLAGGED ANOMALIES:
+`, month))
+
+	for _, anomaly := range anomalies {
+		amountDollars := float64(anomaly.Amount) / 100.0
+		typicalDollars := float64(anomaly.Typical) / 100.0
+		switch anomaly.Kind {
+		case "category_spike":
+			prompt.WriteString(fmt.Sprintf("- Category spike: %s spending was $%.2f this month vs a typical $%.2f per month\n",
+				anomaly.Category, amountDollars, typicalDollars))
+		default:
+			prompt.WriteString(fmt.Sprintf("- Unusual transaction: %s on %s for $%.2f in %s (typical transaction in this category: $%.2f)\n",
+				anomaly.Description, anomaly.Date, amountDollars, anomaly.Category, typicalDollars))
+		}
+	}
+
+	prompt.WriteString(`
+TASK:
+In 2-4 plain sentences, summarize why this month looks different. Point out which anomalies drive most of the difference and whether they look like one-off purchases or a change in habits. Do not invent transactions that are not listed above.
+
+Return plain text only, with no markdown formatting:`)
+
+	return prompt.String()
+}
+
+func buildSQLPrompt(question, schema string) string {
+	var prompt strings.Builder
+
+	prompt.WriteString(`You are a SQLite expert helping a user query their personal finance database. Translate the user's question into ONE read-only SQLite SELECT statement.
+
+DATABASE SCHEMA:
+`)
+	prompt.WriteString(schema)
+
+	prompt.WriteString(`
+
+DATA CONVENTIONS:
+- All money amounts and balances are INTEGER cents. Report money in dollars with ROUND(x / 100.0, 2) and a clear column alias
+- transactions.amount is negative for expenses and positive for income; use -amount or ABS(amount) for spending totals
+- transactions.posted is RFC3339 text (e.g. 2023-05-14T00:00:00Z); filter by year with strftime('%Y', posted) or compare against 'YYYY-MM-DD' strings
+- Prefer COALESCE(display_description, description) for transaction descriptions
+- Transactions in categories with is_internal = 1 are transfers between the user's own accounts and should be excluded from income and spending unless asked
+- Match category names case-insensitively (e.g. lower(c.name) = lower(?))
+- accounts.nickname, when set, is the name the user knows the account by
+
+RULES:
+- Return exactly one SELECT (or WITH ... SELECT) statement with no trailing semicolon
+- Never modify data
+- Put every literal value that comes from the question in params and use ? placeholders in the SQL
+- Keep results small: aggregate when the question asks for totals and add a LIMIT when listing rows
+
+USER QUESTION:
+`)
+	prompt.WriteString(question)
+
+	prompt.WriteString(`
+
+CRITICAL: Return ONLY raw JSON with no additional text, explanations, markdown formatting, or code blocks. Do not wrap in code blocks. Return the JSON object directly.
+
+Required JSON format:
+{
+  "sql": "SELECT ROUND(SUM(-t.amount) / 100.0, 2) AS spent FROM transactions t JOIN categories c ON t.category_id = c.id WHERE lower(c.name) = lower(?) AND strftime('%Y', t.posted) = ? AND t.amount < 0",
+  "params": ["Travel", "2023"],
+  "explanation": "Total spending in the Travel category during 2023"
+}
+
+Return ONLY the raw JSON object with no markdown formatting:`)
+
+	return prompt.String()
+}
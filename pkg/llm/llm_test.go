@@ -1,9 +1,13 @@
 package llm
 
 import (
+	"context"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/arjungandhi/money/pkg/config"
 	"github.com/arjungandhi/money/pkg/database"
 )
 
@@ -18,31 +22,6 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
-func TestBuildTransferIdentificationPrompt(t *testing.T) {
-	transactions := []TransactionData{
-		{ID: "tx1", AccountID: "acc1", Description: "Transfer to savings", Amount: -5000},
-		{ID: "tx2", AccountID: "acc2", Description: "Transfer from checking", Amount: 5000},
-	}
-
-	accounts := []AccountData{
-		{ID: "acc1", Name: "Checking Account", AccountType: "checking"},
-		{ID: "acc2", Name: "Savings Account", AccountType: "savings"},
-	}
-
-	prompt := buildTransferIdentificationPrompt(transactions, accounts)
-
-	if prompt == "" {
-		t.Error("buildTransferIdentificationPrompt should return non-empty prompt")
-	}
-
-	expectedElements := []string{"transactions", "accounts", "transfer", "JSON"}
-	for _, element := range expectedElements {
-		if !containsIgnoreCase(prompt, element) {
-			t.Errorf("Prompt should contain '%s'", element)
-		}
-	}
-}
-
 func TestBuildCategorizationPrompt(t *testing.T) {
 	transactions := []TransactionData{
 		{ID: "tx1", Description: "Starbucks Coffee", Amount: -500},
@@ -76,6 +55,120 @@ func TestBuildCategorizationPrompt(t *testing.T) {
 	}
 }
 
+func TestBuildAnomalySummaryPrompt(t *testing.T) {
+	anomalies := []SpendingAnomaly{
+		{Kind: "category_spike", Category: "Dining Out", Amount: 45000, Typical: 12000},
+		{Kind: "unusual_transaction", Category: "Shopping", Description: "Best Buy", Date: "2024-07-10", Amount: 120000, Typical: 4000},
+	}
+
+	prompt := buildAnomalySummaryPrompt("July 2024", anomalies)
+
+	expectedElements := []string{"July 2024", "Dining Out", "$450.00", "Best Buy", "$1200.00", "plain text"}
+	for _, element := range expectedElements {
+		if !containsIgnoreCase(prompt, element) {
+			t.Errorf("Prompt should contain '%s'", element)
+		}
+	}
+}
+
+func TestBuildSQLPrompt(t *testing.T) {
+	schema := "CREATE TABLE transactions (id TEXT PRIMARY KEY, amount INTEGER NOT NULL);"
+
+	prompt := buildSQLPrompt("how much did I spend on travel in 2023?", schema)
+
+	expectedElements := []string{schema, "how much did I spend on travel in 2023?", "cents", "params", "JSON"}
+	for _, element := range expectedElements {
+		if !containsIgnoreCase(prompt, element) {
+			t.Errorf("Prompt should contain '%s'", element)
+		}
+	}
+}
+
+func TestRunLLMCommandAuditLog(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	cfg := config.New()
+	cfg.SetLLMPromptCmd("cat")
+	client := NewClientWithConfig(cfg)
+	client.SetAuditLog(db)
+
+	response, err := client.runLLMCommand(context.Background(), "test", "echo this prompt")
+	if err != nil {
+		t.Fatalf("runLLMCommand failed: %v", err)
+	}
+	if response != "echo this prompt" {
+		t.Errorf("Unexpected response %q", response)
+	}
+
+	cfg.SetLLMPromptCmd("false")
+	if _, err := client.runLLMCommand(context.Background(), "test", "fails"); err == nil {
+		t.Error("Expected failing command to return an error")
+	}
+
+	calls, err := db.GetLLMCalls(10, false)
+	if err != nil {
+		t.Fatalf("Failed to get LLM calls: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 logged calls, got %d", len(calls))
+	}
+
+	failed, succeeded := calls[0], calls[1]
+	if succeeded.Prompt != "echo this prompt" || succeeded.Response != "echo this prompt" || succeeded.Error != "" {
+		t.Errorf("Unexpected successful call: %+v", succeeded)
+	}
+	if succeeded.PromptTokens == 0 || succeeded.Command != "cat" {
+		t.Errorf("Expected token estimate and command, got %+v", succeeded)
+	}
+	if failed.Error == "" || failed.Response != "" {
+		t.Errorf("Expected the failure to be logged, got %+v", failed)
+	}
+
+	failures, err := db.GetLLMCalls(10, true)
+	if err != nil || len(failures) != 1 {
+		t.Errorf("Expected 1 failed call, got %d (%v)", len(failures), err)
+	}
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	cfg := config.New()
+	cfg.LLMRequestsPerMinute = 600 // one every 100ms
+	client := NewClientWithConfig(cfg)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := client.wait(context.Background()); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected 3 starts to take at least 200ms, took %s", elapsed)
+	}
+
+	// The next start is still 100ms off
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.wait(ctx); err == nil {
+		t.Error("Expected a canceled wait to return an error")
+	}
+
+	cfg.LLMRequestsPerMinute = 0
+	start = time.Now()
+	if err := client.wait(context.Background()); err != nil || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("Expected no wait without a limit, got %v after %s", err, time.Since(start))
+	}
+}
+
 // Helper function to check if string contains substring (case insensitive)
 func containsIgnoreCase(s, substr string) bool {
 	s = strings.ToLower(s)
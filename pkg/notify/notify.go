@@ -0,0 +1,167 @@
+// Package notify delivers messages, such as alerts and digests, to
+// external notification sinks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/config"
+)
+
+// Message is a notification with a short title and a plain text body
+type Message struct {
+	Title string
+	Body  string
+}
+
+// Sink delivers messages to one destination
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromConfig returns the sinks enabled in cfg
+func FromConfig(cfg *config.Config) []Sink {
+	var sinks []Sink
+	if cfg.NotifyWebhookURL != "" {
+		sinks = append(sinks, &Webhook{URL: cfg.NotifyWebhookURL})
+	}
+	if cfg.NotifyNtfyURL != "" {
+		sinks = append(sinks, &Ntfy{URL: cfg.NotifyNtfyURL, Token: cfg.NotifyNtfyToken})
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" && len(cfg.SMTPTo) > 0 {
+		sinks = append(sinks, &Email{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			To:       cfg.SMTPTo,
+		})
+	}
+	return sinks
+}
+
+// Send delivers msg to every sink, returning the failures joined together
+func Send(ctx context.Context, sinks []Sink, msg Message) error {
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// httpClient is shared by the HTTP sinks
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Webhook POSTs messages as JSON objects with title and body fields
+type Webhook struct {
+	URL string
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"title": msg.Title,
+		"body":  msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(req)
+}
+
+// Ntfy publishes messages to an ntfy topic (https://ntfy.sh)
+type Ntfy struct {
+	URL   string // topic URL, e.g. https://ntfy.sh/my-money
+	Token string // optional access token for protected topics
+}
+
+func (n *Ntfy) Name() string { return "ntfy" }
+
+func (n *Ntfy) Send(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	return doRequest(req)
+}
+
+func doRequest(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Email sends messages as plain text email through an SMTP server
+type Email struct {
+	Host     string
+	Port     int
+	Username string // leave empty for servers that don't require authentication
+	Password string
+	From     string
+	To       []string
+}
+
+// sendMail is swapped out in tests
+var sendMail = smtp.SendMail
+
+func (e *Email) Name() string { return "email" }
+
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	addr := e.Host + ":" + strconv.Itoa(e.Port)
+	if err := sendMail(addr, auth, e.From, e.To, buildEmail(e.From, e.To, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildEmail formats msg as an RFC 5322 message
+func buildEmail(from string, to []string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + strings.ReplaceAll(msg.Title, "\n", " ") + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
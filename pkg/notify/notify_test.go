@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/config"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	sink := &Webhook{URL: server.URL}
+	if err := sink.Send(context.Background(), Message{Title: "Alerts", Body: "Low balance"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["title"] != "Alerts" || got["body"] != "Low balance" {
+		t.Errorf("Unexpected payload: %v", got)
+	}
+}
+
+func TestNtfySend(t *testing.T) {
+	var title, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	sink := &Ntfy{URL: server.URL + "/money", Token: "tk_secret"}
+	if err := sink.Send(context.Background(), Message{Title: "Fetch failed", Body: "timeout"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if title != "Fetch failed" || auth != "Bearer tk_secret" || body != "timeout" {
+		t.Errorf("Unexpected request: title=%q auth=%q body=%q", title, auth, body)
+	}
+}
+
+func TestSendJoinsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+
+	sinks := []Sink{&Ntfy{URL: server.URL}, &Webhook{URL: ok.URL}}
+	err := Send(context.Background(), sinks, Message{Title: "t", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "ntfy") || strings.Contains(err.Error(), "webhook") {
+		t.Errorf("Expected only the ntfy failure, got %v", err)
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	var gotAddr string
+	var gotMsg []byte
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr = addr
+		gotMsg = msg
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+
+	sink := &Email{Host: "smtp.example.com", Port: 587, From: "money@example.com", To: []string{"me@example.com"}}
+	if err := sink.Send(context.Background(), Message{Title: "Weekly digest", Body: "line 1\nline 2"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("Unexpected address %q", gotAddr)
+	}
+	for _, want := range []string{"Subject: Weekly digest\r\n", "To: me@example.com\r\n", "\r\n\r\nline 1\r\nline 2\r\n"} {
+		if !strings.Contains(string(gotMsg), want) {
+			t.Errorf("Email should contain %q, got %q", want, gotMsg)
+		}
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		NotifyNtfyURL: "https://ntfy.sh/money",
+		SMTPHost:      "smtp.example.com",
+		SMTPFrom:      "money@example.com", // no recipients, so email stays off
+	}
+
+	sinks := FromConfig(cfg)
+	if len(sinks) != 1 || sinks[0].Name() != "ntfy" {
+		t.Errorf("Expected only the ntfy sink, got %v", sinks)
+	}
+}
@@ -0,0 +1,108 @@
+package property
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// Equity is a property's value less the loans secured by it, in cents
+type Equity struct {
+	Property  database.Property
+	Name      string
+	Value     int64
+	Mortgages []database.Account
+	Owed      int64 // outstanding balance of the linked loans
+}
+
+// Equity returns the value left after paying off the linked loans
+func (e Equity) Equity() int64 {
+	return e.Value - e.Owed
+}
+
+// LoanToValue returns the owed balance as a percentage of the value, or
+// false when the property has no value yet
+func (e Equity) LoanToValue() (float64, bool) {
+	if e.Value <= 0 {
+		return 0, false
+	}
+	return float64(e.Owed) / float64(e.Value) * 100, true
+}
+
+// loanOwed returns how much is owed on a loan. Banks report loan balances
+// as either negative or positive amounts, so the sign is ignored.
+func loanOwed(balance int64) int64 {
+	if balance < 0 {
+		return -balance
+	}
+	return balance
+}
+
+// LinkMortgage links a loan account to the property it's secured by
+func (s *Service) LinkMortgage(propertyAccountID, loanAccountID string) error {
+	if _, err := s.db.GetProperty(propertyAccountID); err != nil {
+		return err
+	}
+
+	loan, err := s.db.GetAccountByID(loanAccountID)
+	if err != nil {
+		return err
+	}
+	if loan.AccountType == nil || *loan.AccountType != "loan" {
+		return fmt.Errorf("%s is not a loan account. Set its type with 'money accounts type set %s loan'", loanAccountID, loanAccountID)
+	}
+
+	return s.db.LinkMortgage(propertyAccountID, loanAccountID)
+}
+
+// UnlinkMortgage removes a loan's link to its property
+func (s *Service) UnlinkMortgage(loanAccountID string) error {
+	return s.db.UnlinkMortgage(loanAccountID)
+}
+
+// GetEquity returns the equity in every property, ordered by address
+func (s *Service) GetEquity() ([]Equity, error) {
+	properties, err := s.db.GetAllProperties()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties: %w", err)
+	}
+
+	links, err := s.db.GetMortgageLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountsByID := make(map[string]database.Account, len(accounts))
+	for _, account := range accounts {
+		accountsByID[account.ID] = account
+	}
+
+	var equities []Equity
+	for _, property := range properties {
+		account, ok := accountsByID[property.AccountID]
+		if !ok {
+			continue
+		}
+
+		equity := Equity{
+			Property: property,
+			Name:     account.DisplayName(),
+			Value:    account.Balance,
+		}
+		for _, loanID := range links[property.AccountID] {
+			loan, ok := accountsByID[loanID]
+			if !ok {
+				continue
+			}
+			equity.Mortgages = append(equity.Mortgages, loan)
+			equity.Owed += loanOwed(loan.Balance)
+		}
+		equities = append(equities, equity)
+	}
+
+	return equities, nil
+}
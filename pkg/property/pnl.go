@@ -0,0 +1,174 @@
+package property
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// PnL is a property's rental income and expenses over a period, from the
+// transactions tagged to it, in cents
+type PnL struct {
+	Property database.Property
+	Name     string
+	Start    time.Time
+	End      time.Time // inclusive
+
+	Income            map[string]int64 // by category name
+	OperatingExpenses map[string]int64 // by category name, negative
+	// DebtService is the total of internal-category transactions such as
+	// mortgage payments, which count against cash flow but not NOI
+	DebtService int64
+
+	Value  int64 // current value
+	Equity int64 // current value less linked mortgages
+}
+
+// Days returns the number of days the period covers
+func (p PnL) Days() int {
+	return int(p.End.Sub(p.Start).Hours()/24) + 1
+}
+
+// TotalIncome returns the income across all categories
+func (p PnL) TotalIncome() int64 {
+	return sumValues(p.Income)
+}
+
+// TotalOperatingExpenses returns the operating expenses across all categories
+func (p PnL) TotalOperatingExpenses() int64 {
+	return sumValues(p.OperatingExpenses)
+}
+
+// NOI returns the net operating income: income less operating expenses
+func (p PnL) NOI() int64 {
+	return p.TotalIncome() + p.TotalOperatingExpenses()
+}
+
+// CashFlow returns the NOI less debt service
+func (p PnL) CashFlow() int64 {
+	return p.NOI() + p.DebtService
+}
+
+// CapRate returns the annualized NOI as a percentage of the current value,
+// or false when the property has no value yet
+func (p PnL) CapRate() (float64, bool) {
+	if p.Value <= 0 {
+		return 0, false
+	}
+	return p.annualized(p.NOI()) / float64(p.Value) * 100, true
+}
+
+// ROI returns the annualized cash flow as a percentage of the current
+// equity (cash-on-cash return), or false when there's no equity
+func (p PnL) ROI() (float64, bool) {
+	if p.Equity <= 0 {
+		return 0, false
+	}
+	return p.annualized(p.CashFlow()) / float64(p.Equity) * 100, true
+}
+
+// annualized scales an amount for the period to a full year
+func (p PnL) annualized(amount int64) float64 {
+	return float64(amount) * 365 / float64(p.Days())
+}
+
+func sumValues(values map[string]int64) int64 {
+	var total int64
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// TagTransactions tags transactions to a property as its income or expenses
+func (s *Service) TagTransactions(accountID string, transactionIDs []string) error {
+	if _, err := s.db.GetProperty(accountID); err != nil {
+		return err
+	}
+	return s.db.TagPropertyTransactions(accountID, transactionIDs)
+}
+
+// UntagTransaction removes a transaction's property tag
+func (s *Service) UntagTransaction(transactionID string) error {
+	return s.db.UntagPropertyTransaction(transactionID)
+}
+
+// GetPnL returns a property's P&L for a calendar year. The current year runs
+// to today, and its ratios are annualized from the days so far.
+func (s *Service) GetPnL(accountID string, year int, now time.Time) (*PnL, error) {
+	if year > now.Year() {
+		return nil, fmt.Errorf("year %d hasn't started yet", year)
+	}
+
+	property, err := s.db.GetProperty(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if year == now.Year() {
+		end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	pnl := &PnL{
+		Property:          *property,
+		Start:             start,
+		End:               end,
+		Income:            make(map[string]int64),
+		OperatingExpenses: make(map[string]int64),
+	}
+
+	equities, err := s.GetEquity()
+	if err != nil {
+		return nil, err
+	}
+	for _, equity := range equities {
+		if equity.Property.AccountID == accountID {
+			pnl.Name = equity.Name
+			pnl.Value = equity.Value
+			pnl.Equity = equity.Equity()
+		}
+	}
+
+	categories, err := s.db.GetCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	categoriesByID := make(map[int]database.Category, len(categories))
+	for _, category := range categories {
+		categoriesByID[category.ID] = category
+	}
+
+	filter := database.TransactionFilter{
+		PropertyID: accountID,
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.Format("2006-01-02"),
+		Ascending:  true,
+	}
+	err = s.db.ForEachTransaction(filter, func(txn database.Transaction) error {
+		categoryName := "Uncategorized"
+		if txn.CategoryID != nil {
+			if category, ok := categoriesByID[*txn.CategoryID]; ok {
+				if category.IsInternal {
+					pnl.DebtService += txn.Amount
+					return nil
+				}
+				categoryName = category.Name
+			}
+		}
+
+		if txn.Amount > 0 {
+			pnl.Income[categoryName] += txn.Amount
+		} else {
+			pnl.OperatingExpenses[categoryName] += txn.Amount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property transactions: %w", err)
+	}
+
+	return pnl, nil
+}
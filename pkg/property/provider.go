@@ -0,0 +1,137 @@
+package property
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arjungandhi/money/pkg/attom"
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/rentcast"
+)
+
+// Valuation providers a property can be set to use
+const (
+	ProviderRentCast = "rentcast"
+	ProviderATTOM    = "attom"
+	ProviderManual   = "manual" // values are only ever set with set-value
+)
+
+// DefaultProvider values properties that haven't chosen a provider
+const DefaultProvider = ProviderRentCast
+
+// ProviderNames lists every provider a property can be set to use
+var ProviderNames = []string{ProviderRentCast, ProviderATTOM, ProviderManual}
+
+// ErrManualValuation is returned when updating a property whose value is
+// only set by hand
+var ErrManualValuation = errors.New("property is valued manually, use 'money property set-value'")
+
+// Valuation is a provider's estimate for a property, in cents. Either value
+// is nil when the provider has no estimate for it.
+type Valuation struct {
+	Value *int64
+	Rent  *int64
+}
+
+// Provider estimates property values from an outside data source
+type Provider interface {
+	Name() string
+	Estimate(property database.Property) (*Valuation, error)
+}
+
+// IsValidProvider reports whether name is a provider a property can use
+func IsValidProvider(name string) bool {
+	for _, provider := range ProviderNames {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderName returns the provider that values property, applying the default
+func ProviderName(property database.Property) string {
+	if property.ValuationProvider == "" {
+		return DefaultProvider
+	}
+	return property.ValuationProvider
+}
+
+// notConfiguredError explains how to set up a provider that has no API key
+func notConfiguredError(name string) error {
+	switch name {
+	case ProviderRentCast:
+		return fmt.Errorf("RentCast API key not configured. Run 'money init rentcast' to set your API key")
+	case ProviderATTOM:
+		return fmt.Errorf("ATTOM API key not configured. Run 'money config set attom_api_key <key>' to set your API key")
+	}
+	return fmt.Errorf("unknown valuation provider: %s", name)
+}
+
+// dollarsToCents converts an optional whole-dollar amount to cents
+func dollarsToCents(dollars *int) *int64 {
+	if dollars == nil {
+		return nil
+	}
+	cents := int64(*dollars) * 100
+	return &cents
+}
+
+type rentcastProvider struct {
+	client *rentcast.Client
+}
+
+func (p *rentcastProvider) Name() string {
+	return ProviderRentCast
+}
+
+func (p *rentcastProvider) Estimate(property database.Property) (*Valuation, error) {
+	req := rentcast.ValueEstimateRequest{
+		Address:      property.Address,
+		City:         property.City,
+		State:        property.State,
+		ZipCode:      property.ZipCode,
+		PropertyType: property.PropertyType,
+		Latitude:     property.Latitude,
+		Longitude:    property.Longitude,
+	}
+
+	valueResp, err := p.client.GetValueEstimate(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get value estimate: %w", err)
+	}
+
+	rentResp, err := p.client.GetRentEstimate(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent estimate: %w", err)
+	}
+
+	return &Valuation{
+		Value: dollarsToCents(valueResp.Price),
+		Rent:  dollarsToCents(rentResp.Rent),
+	}, nil
+}
+
+// attomProvider values properties with ATTOM's AVM. ATTOM's rent estimates
+// are a separate product, so it only provides values.
+type attomProvider struct {
+	client *attom.Client
+}
+
+func (p *attomProvider) Name() string {
+	return ProviderATTOM
+}
+
+func (p *attomProvider) Estimate(property database.Property) (*Valuation, error) {
+	resp, err := p.client.GetValueEstimate(attom.AVMRequest{
+		Address: property.Address,
+		City:    property.City,
+		State:   property.State,
+		ZipCode: property.ZipCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get value estimate: %w", err)
+	}
+
+	return &Valuation{Value: dollarsToCents(resp.Value())}, nil
+}
@@ -0,0 +1,54 @@
+package property
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/rentcast"
+)
+
+// ErrNoPropertyRecord is returned when RentCast has no public record for a
+// property's address
+var ErrNoPropertyRecord = errors.New("no public record found for the address")
+
+// UpdatePropertyRecord fetches a property's public record details (beds,
+// baths, square footage, lot size and year built) from RentCast and stores
+// them with the property
+func (s *Service) UpdatePropertyRecord(accountID string) (*database.Property, error) {
+	if s.rentcastClient == nil {
+		return nil, notConfiguredError(ProviderRentCast)
+	}
+
+	property, err := s.db.GetProperty(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property details: %w", err)
+	}
+
+	record, err := s.rentcastClient.GetPropertyRecord(rentcast.ValueEstimateRequest{
+		Address: property.Address,
+		City:    property.City,
+		State:   property.State,
+		ZipCode: property.ZipCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property record: %w", err)
+	}
+	if record == nil {
+		return nil, ErrNoPropertyRecord
+	}
+
+	err = s.db.UpdatePropertyRecord(accountID, database.PropertyRecord{
+		PropertyType:  record.PropertyType,
+		Bedrooms:      record.Bedrooms,
+		Bathrooms:     record.Bathrooms,
+		SquareFootage: record.SquareFootage,
+		LotSize:       record.LotSize,
+		YearBuilt:     record.YearBuilt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetProperty(accountID)
+}
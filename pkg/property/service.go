@@ -3,33 +3,70 @@ package property
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/arjungandhi/money/pkg/attom"
 	"github.com/arjungandhi/money/pkg/database"
 	"github.com/arjungandhi/money/pkg/rentcast"
 )
 
 type Service struct {
-	db             *database.DB
+	db        *database.DB
+	providers map[string]Provider
+
+	// rentcastClient also fetches public records; nil without an API key
 	rentcastClient *rentcast.Client
 }
 
+// NewService sets up every valuation provider that has an API key
 func NewService(db *database.DB) *Service {
-	var client *rentcast.Client
+	providers := make(map[string]Provider)
+	var rentcastClient *rentcast.Client
 	if apiKey, err := db.GetRentCastAPIKey(); err == nil {
-		client = rentcast.NewClient(apiKey)
+		rentcastClient = rentcast.NewClient(apiKey)
 	} else if apiKey := os.Getenv("RENTCAST_API_KEY"); apiKey != "" {
-		client = rentcast.NewClient(apiKey)
+		rentcastClient = rentcast.NewClient(apiKey)
+	}
+	if rentcastClient != nil {
+		rentcastClient.RequestsPerSecond = db.GetConfig().RentCastRequestsPerSecond
+		providers[ProviderRentCast] = &rentcastProvider{client: rentcastClient}
+	}
+	if apiKey := db.GetConfig().ATTOMAPIKey; apiKey != "" {
+		providers[ProviderATTOM] = &attomProvider{client: attom.NewClient(apiKey)}
 	}
 
 	return &Service{
 		db:             db,
-		rentcastClient: client,
+		providers:      providers,
+		rentcastClient: rentcastClient,
 	}
 }
 
+// HasProviders reports whether any valuation provider is configured
+func (s *Service) HasProviders() bool {
+	return len(s.providers) > 0
+}
+
+// Providers returns the names of the configured valuation providers
+func (s *Service) Providers() []string {
+	var names []string
+	for _, name := range ProviderNames {
+		if _, ok := s.providers[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (s *Service) CreatePropertyAccount(orgID, name, address, city, state, zipCode string, propertyType *string, latitude, longitude *float64) (string, error) {
 	accountID := fmt.Sprintf("property_%s_%s_%s", state, city, zipCode)
 
+	if err := s.db.SaveOrganization(orgID, orgID, ""); err != nil {
+		return "", fmt.Errorf("failed to save organization: %w", err)
+	}
+
 	err := s.db.SaveAccount(accountID, orgID, name, "USD", 0, nil, "")
 	if err != nil {
 		return "", fmt.Errorf("failed to create property account: %w", err)
@@ -48,58 +85,65 @@ func (s *Service) CreatePropertyAccount(orgID, name, address, city, state, zipCo
 	return accountID, nil
 }
 
-func (s *Service) UpdatePropertyValuation(accountID string) error {
-	if s.rentcastClient == nil {
-		return fmt.Errorf("RentCast API key not configured. Run 'money property config' to set your API key")
+// provider returns the provider that values property
+func (s *Service) provider(property database.Property) (Provider, error) {
+	name := ProviderName(property)
+	if name == ProviderManual {
+		return nil, ErrManualValuation
+	}
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, notConfiguredError(name)
 	}
+	return provider, nil
+}
 
+func (s *Service) UpdatePropertyValuation(accountID string) error {
 	property, err := s.db.GetProperty(accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get property details: %w", err)
 	}
 
-	req := rentcast.ValueEstimateRequest{
-		Address:      property.Address,
-		City:         property.City,
-		State:        property.State,
-		ZipCode:      property.ZipCode,
-		PropertyType: property.PropertyType,
-		Latitude:     property.Latitude,
-		Longitude:    property.Longitude,
-	}
-
-	valueResp, err := s.rentcastClient.GetValueEstimate(req)
+	valuation, err := s.estimate(*property)
 	if err != nil {
-		return fmt.Errorf("failed to get value estimate: %w", err)
+		return err
 	}
+	return s.saveValuation(*property, valuation)
+}
 
-	rentResp, err := s.rentcastClient.GetRentEstimate(req)
+// estimate asks property's provider for a valuation. It only calls the
+// provider, so several can run at once.
+func (s *Service) estimate(property database.Property) (*Valuation, error) {
+	provider, err := s.provider(property)
 	if err != nil {
-		return fmt.Errorf("failed to get rent estimate: %w", err)
+		return nil, err
 	}
+	return provider.Estimate(property)
+}
 
-	var valueEstimate, rentEstimate *int
-	if valueResp.Price != nil {
-		value := (*valueResp.Price) * 100
-		valueEstimate = &value
-	}
-	if rentResp.Rent != nil {
-		rent := (*rentResp.Rent) * 100
-		rentEstimate = &rent
+// saveValuation records valuation as property's value, balance and balance
+// history
+func (s *Service) saveValuation(property database.Property, valuation *Valuation) error {
+	accountID := property.AccountID
+
+	// Keep the last rent estimate when the provider doesn't estimate rent
+	rentEstimate := valuation.Rent
+	if rentEstimate == nil {
+		rentEstimate = property.LastRentEstimate
 	}
 
-	err = s.db.UpdatePropertyValuation(accountID, valueEstimate, rentEstimate)
+	err := s.db.UpdatePropertyValuation(accountID, valuation.Value, rentEstimate)
 	if err != nil {
 		return fmt.Errorf("failed to update property valuation: %w", err)
 	}
 
-	if valueEstimate != nil {
-		err = s.db.UpdateAccountBalance(accountID, *valueEstimate)
+	if valuation.Value != nil {
+		err = s.db.UpdateAccountBalance(accountID, *valuation.Value)
 		if err != nil {
 			return fmt.Errorf("failed to update account balance: %w", err)
 		}
 
-		err = s.db.SaveBalanceHistory(accountID, *valueEstimate, nil)
+		err = s.db.SaveBalanceHistory(accountID, *valuation.Value, nil)
 		if err != nil {
 			return fmt.Errorf("failed to save balance history: %w", err)
 		}
@@ -108,29 +152,117 @@ func (s *Service) UpdatePropertyValuation(accountID string) error {
 	return nil
 }
 
-func (s *Service) UpdateAllPropertyValuations() error {
-	if s.rentcastClient == nil {
-		return fmt.Errorf("RentCast API key not configured. Run 'money property config' to set your API key")
+// updateWorkers is how many properties UpdateAllPropertyValuations values at
+// once; the providers' clients keep to their own rate limits
+const updateWorkers = 4
+
+// UpdateSummary reports what UpdateAllPropertyValuations did with each property
+type UpdateSummary struct {
+	Refreshed []database.Property
+	Skipped   []database.Property // valued within the max age
+	Manual    []database.Property
+	Failed    []UpdateFailure
+}
+
+// UpdateFailure is a property UpdateAllPropertyValuations couldn't value
+type UpdateFailure struct {
+	Property database.Property
+	Err      error
+}
+
+// UpdateAllPropertyValuations updates every property from its provider,
+// skipping properties that are valued manually and, when maxAge is set,
+// properties valued more recently than maxAge. Providers are called
+// concurrently; a property that fails is listed in the summary's Failed
+// without stopping the others, so the error is only for not being able to
+// start.
+func (s *Service) UpdateAllPropertyValuations(maxAge time.Duration) (*UpdateSummary, error) {
+	if !s.HasProviders() {
+		return nil, fmt.Errorf("no valuation provider configured. Run 'money init rentcast' or 'money config set attom_api_key <key>'")
 	}
 
 	properties, err := s.db.GetAllProperties()
 	if err != nil {
-		return fmt.Errorf("failed to get properties: %w", err)
+		return nil, fmt.Errorf("failed to get properties: %w", err)
 	}
 
-	var errors []string
+	summary := &UpdateSummary{}
+	now := time.Now()
+	var due []database.Property
 	for _, property := range properties {
-		err := s.UpdatePropertyValuation(property.AccountID)
+		if ProviderName(property) == ProviderManual {
+			summary.Manual = append(summary.Manual, property)
+			continue
+		}
+		if maxAge > 0 {
+			if updated, ok := LastUpdated(property); ok && now.Sub(updated) < maxAge {
+				summary.Skipped = append(summary.Skipped, property)
+				continue
+			}
+		}
+		due = append(due, property)
+	}
+
+	valuations := make([]*Valuation, len(due))
+	errs := make([]error, len(due))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < updateWorkers && i < len(due); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				valuations[job], errs[job] = s.estimate(due[job])
+			}
+		}()
+	}
+	for job := range due {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Saved one at a time, since SQLite takes one writer
+	for i, property := range due {
+		err := errs[i]
+		if err == nil {
+			err = s.saveValuation(property, valuations[i])
+		}
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("failed to update %s: %v", property.Address, err))
+			summary.Failed = append(summary.Failed, UpdateFailure{Property: property, Err: err})
+			continue
+		}
+		summary.Refreshed = append(summary.Refreshed, property)
+	}
+
+	return summary, nil
+}
+
+// LastUpdated returns when a property was last valued, or false if it never was
+func LastUpdated(property database.Property) (time.Time, bool) {
+	if property.LastUpdated == nil {
+		return time.Time{}, false
+	}
+	// last_updated is set with SQLite's CURRENT_TIMESTAMP, which is UTC. The
+	// driver reads DATETIME columns back as RFC 3339.
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if updated, err := time.Parse(layout, *property.LastUpdated); err == nil {
+			return updated, true
 		}
 	}
+	return time.Time{}, false
+}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("some property valuations failed: %v", errors)
+// SetValuationProvider chooses the provider a property is valued with.
+// "default" goes back to the default provider.
+func (s *Service) SetValuationProvider(accountID, name string) error {
+	if name == "default" {
+		name = ""
+	} else if !IsValidProvider(name) {
+		return fmt.Errorf("unknown valuation provider %q (expected one of: %s, default)", name, strings.Join(ProviderNames, ", "))
 	}
 
-	return nil
+	return s.db.SetPropertyValuationProvider(accountID, name)
 }
 
 func (s *Service) GetPropertyDetails(accountID string) (*database.Property, error) {
@@ -141,7 +273,7 @@ func (s *Service) ListAllProperties() ([]database.Property, error) {
 	return s.db.GetAllProperties()
 }
 
-func (s *Service) SetPropertyValue(accountID string, valueInCents int) error {
+func (s *Service) SetPropertyValue(accountID string, valueInCents int64) error {
 	err := s.db.UpdateAccountBalance(accountID, valueInCents)
 	if err != nil {
 		return fmt.Errorf("failed to update account balance: %w", err)
@@ -1,9 +1,17 @@
 package property
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/rentcast"
 )
 
 func TestNewService(t *testing.T) {
@@ -19,8 +27,424 @@ func TestNewService(t *testing.T) {
 	}
 }
 
-// Note: More comprehensive tests would require either:
-// 1. A test database setup
-// 2. Mocking the database interface
-// 3. Integration tests with a real database
-// For now, we just test the basic structure
+type fakeProvider struct {
+	name  string
+	value int64
+	fail  map[string]error // errors by address
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Estimate(property database.Property) (*Valuation, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	if err := p.fail[property.Address]; err != nil {
+		return nil, err
+	}
+	return &Valuation{Value: &p.value}, nil
+}
+
+func TestValuationProviderSelection(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("Property", "Property", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+
+	rentcast := &fakeProvider{name: ProviderRentCast, value: 40000000}
+	attom := &fakeProvider{name: ProviderATTOM, value: 41000000}
+	service := &Service{db: db, providers: map[string]Provider{
+		ProviderRentCast: rentcast,
+		ProviderATTOM:    attom,
+	}}
+
+	house, err := service.CreatePropertyAccount("Property", "House", "1 Elm St", "Austin", "TX", "78701", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+	condo, err := service.CreatePropertyAccount("Property", "Condo", "2 Oak St", "Denver", "CO", "80202", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+	cabin, err := service.CreatePropertyAccount("Property", "Cabin", "3 Pine Rd", "Aspen", "CO", "81611", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+
+	if err := service.SetValuationProvider(condo, ProviderATTOM); err != nil {
+		t.Fatalf("Failed to set provider: %v", err)
+	}
+	if err := service.SetValuationProvider(cabin, ProviderManual); err != nil {
+		t.Fatalf("Failed to set provider: %v", err)
+	}
+	if err := service.SetValuationProvider(house, "zillow"); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+
+	if _, err := service.UpdateAllPropertyValuations(0); err != nil {
+		t.Fatalf("Failed to update valuations: %v", err)
+	}
+	if rentcast.calls != 1 || attom.calls != 1 {
+		t.Errorf("Expected one call per provider, got rentcast=%d attom=%d", rentcast.calls, attom.calls)
+	}
+
+	// Valuations from a moment ago are younger than the max age
+	summary, err := service.UpdateAllPropertyValuations(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to update valuations: %v", err)
+	}
+	if len(summary.Refreshed) != 0 || len(summary.Skipped) != 2 || len(summary.Manual) != 1 {
+		t.Errorf("Expected 0 refreshed, 2 skipped and 1 manual, got %d, %d and %d", len(summary.Refreshed), len(summary.Skipped), len(summary.Manual))
+	}
+	if rentcast.calls != 1 || attom.calls != 1 {
+		t.Errorf("Expected skipped properties not to call their providers, got rentcast=%d attom=%d", rentcast.calls, attom.calls)
+	}
+
+	for accountID, want := range map[string]int64{house: 40000000, condo: 41000000, cabin: 0} {
+		account, err := db.GetAccountByID(accountID)
+		if err != nil {
+			t.Fatalf("Failed to get account: %v", err)
+		}
+		if account.Balance != want {
+			t.Errorf("%s: expected balance %d, got %d", accountID, want, account.Balance)
+		}
+	}
+
+	if err := service.UpdatePropertyValuation(cabin); err != ErrManualValuation {
+		t.Errorf("Expected ErrManualValuation for a manual property, got %v", err)
+	}
+
+	// Going back to the default provider
+	if err := service.SetValuationProvider(condo, "default"); err != nil {
+		t.Fatalf("Failed to reset provider: %v", err)
+	}
+	prop, err := service.GetPropertyDetails(condo)
+	if err != nil {
+		t.Fatalf("Failed to get property: %v", err)
+	}
+	if ProviderName(*prop) != DefaultProvider {
+		t.Errorf("Expected default provider, got %s", ProviderName(*prop))
+	}
+
+	// A provider without an API key can't update its properties
+	delete(service.providers, ProviderRentCast)
+	if err := service.UpdatePropertyValuation(house); err == nil {
+		t.Error("Expected an error when the property's provider isn't configured")
+	}
+}
+
+func TestUpdateAllPropertyValuationsFailures(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("Property", "Property", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+
+	rentcast := &fakeProvider{name: ProviderRentCast, value: 40000000, fail: map[string]error{
+		"3 Elm St": errors.New("API request failed with status 404"),
+	}}
+	service := &Service{db: db, providers: map[string]Provider{ProviderRentCast: rentcast}}
+	for i, zip := range []string{"78701", "78702", "78703", "78704", "78705", "78706"} {
+		address := fmt.Sprintf("%d Elm St", i+1)
+		if _, err := service.CreatePropertyAccount("Property", address, address, "Austin", "TX", zip, nil, nil, nil); err != nil {
+			t.Fatalf("Failed to create property: %v", err)
+		}
+	}
+
+	summary, err := service.UpdateAllPropertyValuations(0)
+	if err != nil {
+		t.Fatalf("Expected a failing property not to fail the batch, got %v", err)
+	}
+	if rentcast.calls != 6 || len(summary.Refreshed) != 5 || len(summary.Failed) != 1 {
+		t.Fatalf("Expected 6 calls, 5 refreshed and 1 failed, got %d, %d and %d", rentcast.calls, len(summary.Refreshed), len(summary.Failed))
+	}
+	if failure := summary.Failed[0]; failure.Property.Address != "3 Elm St" || failure.Err == nil {
+		t.Errorf("Expected 3 Elm St to fail with its error, got %+v", failure)
+	}
+
+	properties, err := service.ListAllProperties()
+	if err != nil {
+		t.Fatalf("Failed to list properties: %v", err)
+	}
+	for _, prop := range properties {
+		account, err := db.GetAccountByID(prop.AccountID)
+		if err != nil {
+			t.Fatalf("Failed to get account: %v", err)
+		}
+		want := int64(40000000)
+		if prop.Address == "3 Elm St" {
+			want = 0
+		}
+		if account.Balance != want {
+			t.Errorf("%s: expected balance %d, got %d", prop.Address, want, account.Balance)
+		}
+	}
+}
+
+func TestGetEquity(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("Property", "Property", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveOrganization("bank", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+
+	service := &Service{db: db}
+	house, err := service.CreatePropertyAccount("Property", "House", "1 Elm St", "Austin", "TX", "78701", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+	if err := service.SetPropertyValue(house, 50000000); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	// Banks report loan balances with either sign
+	for id, balance := range map[string]int64{"mortgage": -30000000, "heloc": 2500000, "checking": 100000} {
+		if err := db.SaveAccount(id, "bank", id, "USD", balance, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+	for _, id := range []string{"mortgage", "heloc"} {
+		if err := db.SetAccountType(id, "loan"); err != nil {
+			t.Fatalf("Failed to set account type: %v", err)
+		}
+	}
+
+	if err := service.LinkMortgage(house, "checking"); err == nil {
+		t.Error("Expected an error linking a non-loan account")
+	}
+	if err := service.LinkMortgage(house, "mortgage"); err != nil {
+		t.Fatalf("Failed to link mortgage: %v", err)
+	}
+	if err := service.LinkMortgage(house, "heloc"); err != nil {
+		t.Fatalf("Failed to link heloc: %v", err)
+	}
+
+	equities, err := service.GetEquity()
+	if err != nil {
+		t.Fatalf("Failed to get equity: %v", err)
+	}
+	if len(equities) != 1 {
+		t.Fatalf("Expected 1 property, got %d", len(equities))
+	}
+	equity := equities[0]
+	if equity.Owed != 32500000 || equity.Equity() != 17500000 || len(equity.Mortgages) != 2 {
+		t.Errorf("Expected owed 32500000 and equity 17500000 from 2 loans, got %d, %d from %d", equity.Owed, equity.Equity(), len(equity.Mortgages))
+	}
+	if ltv, ok := equity.LoanToValue(); !ok || ltv != 65 {
+		t.Errorf("Expected LTV 65, got %v", ltv)
+	}
+
+	if err := service.UnlinkMortgage("heloc"); err != nil {
+		t.Fatalf("Failed to unlink heloc: %v", err)
+	}
+	if err := service.UnlinkMortgage("heloc"); err == nil {
+		t.Error("Expected an error unlinking a loan that isn't linked")
+	}
+	equities, err = service.GetEquity()
+	if err != nil {
+		t.Fatalf("Failed to get equity: %v", err)
+	}
+	if equities[0].Owed != 30000000 {
+		t.Errorf("Expected owed 30000000 after unlinking, got %d", equities[0].Owed)
+	}
+}
+
+func TestGetPnL(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SeedDefaultCategories(); err != nil {
+		t.Fatalf("Failed to seed categories: %v", err)
+	}
+	if err := db.SaveOrganization("Property", "Property", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveOrganization("bank", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("checking", "bank", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	service := &Service{db: db}
+	rental, err := service.CreatePropertyAccount("Property", "Rental", "1 Elm St", "Austin", "TX", "78701", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+	if err := service.SetPropertyValue(rental, 40000000); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	categoryIDs := make(map[string]int)
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatalf("Failed to get categories: %v", err)
+	}
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+
+	transactions := []struct {
+		id, posted, category string
+		amount               int64
+	}{
+		{"rent-jan", "2024-01-01T12:00:00Z", "Income", 250000},
+		{"rent-feb", "2024-02-01T12:00:00Z", "Income", 250000},
+		{"repair", "2024-01-15T12:00:00Z", "Housing", -60000},
+		{"mortgage", "2024-01-05T12:00:00Z", "Transfers", -150000},
+		{"rent-2023", "2023-12-01T12:00:00Z", "Income", 250000},
+		{"groceries", "2024-01-20T12:00:00Z", "Groceries", -8000},
+	}
+	var tagged []string
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "checking", txn.posted, txn.amount, txn.id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.UpdateTransactionCategory(txn.id, categoryIDs[txn.category]); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+		if txn.id != "groceries" {
+			tagged = append(tagged, txn.id)
+		}
+	}
+
+	if err := service.TagTransactions(rental, tagged); err != nil {
+		t.Fatalf("Failed to tag transactions: %v", err)
+	}
+	if err := service.TagTransactions(rental, []string{"missing"}); err == nil {
+		t.Error("Expected an error tagging a missing transaction")
+	}
+
+	pnl, err := service.GetPnL(rental, 2024, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get P&L: %v", err)
+	}
+
+	if pnl.TotalIncome() != 500000 {
+		t.Errorf("Expected income 500000, got %d", pnl.TotalIncome())
+	}
+	if pnl.TotalOperatingExpenses() != -60000 {
+		t.Errorf("Expected operating expenses -60000, got %d", pnl.TotalOperatingExpenses())
+	}
+	if pnl.DebtService != -150000 {
+		t.Errorf("Expected debt service -150000, got %d", pnl.DebtService)
+	}
+	if pnl.NOI() != 440000 || pnl.CashFlow() != 290000 {
+		t.Errorf("Expected NOI 440000 and cash flow 290000, got %d and %d", pnl.NOI(), pnl.CashFlow())
+	}
+	if pnl.Days() != 366 {
+		t.Errorf("Expected 366 days in 2024, got %d", pnl.Days())
+	}
+	if capRate, ok := pnl.CapRate(); !ok || capRate < 1.09 || capRate > 1.1 {
+		t.Errorf("Expected a cap rate of about 1.1%%, got %v", capRate)
+	}
+
+	if err := service.UntagTransaction("repair"); err != nil {
+		t.Fatalf("Failed to untag transaction: %v", err)
+	}
+	pnl, err = service.GetPnL(rental, 2024, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get P&L: %v", err)
+	}
+	if pnl.TotalOperatingExpenses() != 0 {
+		t.Errorf("Expected no operating expenses after untagging, got %d", pnl.TotalOperatingExpenses())
+	}
+
+	if _, err := service.GetPnL(rental, 2026, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected an error for a future year")
+	}
+}
+
+func TestUpdatePropertyRecord(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("Property", "Property", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"propertyType":"Single Family","bedrooms":3,"bathrooms":2.5,"squareFootage":1878,"lotSize":8850,"yearBuilt":1973}]`))
+	}))
+	defer server.Close()
+
+	client := rentcast.NewClient("test-key")
+	client.BaseURL = server.URL
+	service := &Service{db: db, rentcastClient: client}
+
+	house, err := service.CreatePropertyAccount("Property", "House", "1 Elm St", "Austin", "TX", "78701", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create property: %v", err)
+	}
+
+	prop, err := service.UpdatePropertyRecord(house)
+	if err != nil {
+		t.Fatalf("Failed to update property record: %v", err)
+	}
+	if prop.PropertyType == nil || *prop.PropertyType != "Single Family" {
+		t.Errorf("Expected the property type to be filled in, got %v", prop.PropertyType)
+	}
+	if prop.Bedrooms == nil || *prop.Bedrooms != 3 || prop.Bathrooms == nil || *prop.Bathrooms != 2.5 {
+		t.Errorf("Expected 3 bedrooms and 2.5 bathrooms, got %v and %v", prop.Bedrooms, prop.Bathrooms)
+	}
+	if prop.SquareFootage == nil || *prop.SquareFootage != 1878 || prop.LotSize == nil || *prop.LotSize != 8850 || prop.YearBuilt == nil || *prop.YearBuilt != 1973 {
+		t.Errorf("Unexpected record details: %+v", prop)
+	}
+
+	service.rentcastClient = nil
+	if _, err := service.UpdatePropertyRecord(house); err == nil {
+		t.Error("Expected an error without a RentCast API key")
+	}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// ReadFunc reads a source's accounts with their transactions from since on
+// (all of them when since is zero), or with their balances only
+type ReadFunc func(since time.Time, balancesOnly bool) (*simplefin.AccountsResponse, error)
+
+// Aggregator is a Provider for sources that report accounts and their
+// transactions together, as SimpleFIN's /accounts does. Transactions reads
+// both and keeps the accounts for the Accounts call that follows it, so the
+// source is only asked once; Accounts on its own reads balances only.
+type Aggregator struct {
+	read     ReadFunc
+	accounts []simplefin.Account
+}
+
+// NewAggregator returns a provider reading through read
+func NewAggregator(read ReadFunc) *Aggregator {
+	return &Aggregator{read: read}
+}
+
+// Accounts returns the accounts the last Transactions call read, or reads
+// their balances
+func (a *Aggregator) Accounts() ([]simplefin.Account, error) {
+	accounts := a.accounts
+	a.accounts = nil
+	if accounts == nil {
+		resp, err := a.read(time.Time{}, true)
+		if err != nil {
+			return nil, err
+		}
+		accounts = resp.Accounts
+	}
+
+	result := make([]simplefin.Account, len(accounts))
+	for i, account := range accounts {
+		account.Transactions = nil
+		result[i] = account
+	}
+	return result, nil
+}
+
+// Transactions reads the accounts with their transactions from since on
+func (a *Aggregator) Transactions(since time.Time) (map[string][]simplefin.Transaction, error) {
+	resp, err := a.read(since, false)
+	if err != nil {
+		return nil, err
+	}
+	a.accounts = resp.Accounts
+	if a.accounts == nil {
+		a.accounts = []simplefin.Account{}
+	}
+
+	transactions := make(map[string][]simplefin.Transaction, len(resp.Accounts))
+	for _, account := range resp.Accounts {
+		transactions[account.ID] = account.Transactions
+	}
+	return transactions, nil
+}
+
+// SimpleFIN returns a provider reading a SimpleFIN bridge's accounts
+func SimpleFIN(client *simplefin.Client) *Aggregator {
+	return NewAggregator(func(since time.Time, balancesOnly bool) (*simplefin.AccountsResponse, error) {
+		if balancesOnly {
+			return client.GetAccountsWithOptions(&simplefin.AccountsOptions{BalancesOnly: true})
+		}
+		if since.IsZero() {
+			return client.GetAccountsWithOptions(nil)
+		}
+		return client.GetAccountsWithOptions(&simplefin.AccountsOptions{StartDate: &since})
+	})
+}
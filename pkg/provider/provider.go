@@ -0,0 +1,111 @@
+// Package provider is what money fetch reads accounts and transactions
+// through, so aggregators like SimpleFIN, GoCardless and Teller, and
+// importers, plug into the same sync. Each kind of source registers an
+// Opener, which returns a Provider for every connection of that kind set up
+// in the database.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// Provider is one connection to a source of accounts and transactions. Its
+// accounts and transactions are in the shape SimpleFIN reports them, which
+// is what money fetch saves.
+type Provider interface {
+	// Accounts returns the connection's accounts with their organization
+	// and balances, and no transactions
+	Accounts() ([]simplefin.Account, error)
+	// Transactions returns the transactions posted from since on by
+	// account ID, or all the history the source gives when since is zero
+	Transactions(since time.Time) (map[string][]simplefin.Transaction, error)
+}
+
+// Connection is a provider and the key it's known by, like "simplefin" or
+// "teller:enr_123"
+type Connection struct {
+	Key      string
+	Name     string // shown while fetching, like "Chase (Teller)"
+	Provider Provider
+}
+
+// Options are how money fetch was asked to read its sources
+type Options struct {
+	// RecordDir is where sources that can record their raw responses
+	// save them
+	RecordDir string
+	// ReplayDir holds recorded responses to read instead of contacting
+	// sources; sources that can't replay open no connections
+	ReplayDir string
+}
+
+// Opener returns the connections of one kind of source set up in the
+// database, none when it isn't set up
+type Opener func(db *database.DB, opts Options) ([]Connection, error)
+
+type registration struct {
+	kind string
+	open Opener
+}
+
+var registry []registration
+
+// Register adds a kind of source, keyed by name, whose connections Open
+// returns. It's meant to be called from init functions.
+func Register(kind string, open Opener) {
+	for _, r := range registry {
+		if r.kind == kind {
+			panic(fmt.Sprintf("provider: %s registered twice", kind))
+		}
+	}
+	registry = append(registry, registration{kind: kind, open: open})
+}
+
+// Kinds returns the registered kinds of source in the order they were
+// registered
+func Kinds() []string {
+	kinds := make([]string, len(registry))
+	for i, r := range registry {
+		kinds[i] = r.kind
+	}
+	return kinds
+}
+
+// Open returns the connections of every registered kind of source, in the
+// order the kinds were registered. A kind that fails to open is reported in
+// the returned error alongside the connections the others opened.
+func Open(db *database.DB, opts Options) ([]Connection, error) {
+	var connections []Connection
+	var errs []error
+	for _, r := range registry {
+		opened, err := r.open(db, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to open %s: %w", r.kind, err))
+			continue
+		}
+		connections = append(connections, opened...)
+	}
+	return connections, errors.Join(errs...)
+}
+
+// Read reads a provider's transactions from since on and then its accounts,
+// returning the accounts with their transactions
+func Read(p Provider, since time.Time) ([]simplefin.Account, error) {
+	transactions, err := p.Transactions(since)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := p.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		accounts[i].Transactions = transactions[accounts[i].ID]
+	}
+	return accounts, nil
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// fakeSource reports a checking account with one transaction, counting how
+// it was read
+type fakeSource struct {
+	reads        []string
+	balancesOnly int
+}
+
+func (f *fakeSource) read(since time.Time, balancesOnly bool) (*simplefin.AccountsResponse, error) {
+	if balancesOnly {
+		f.balancesOnly++
+		return &simplefin.AccountsResponse{Accounts: []simplefin.Account{{ID: "checking", Balance: "100.00"}}}, nil
+	}
+	f.reads = append(f.reads, since.Format("2006-01-02"))
+	return &simplefin.AccountsResponse{Accounts: []simplefin.Account{{
+		ID:           "checking",
+		Balance:      "100.00",
+		Transactions: []simplefin.Transaction{{ID: "t-1", Amount: "-5.00"}},
+	}}}, nil
+}
+
+func TestAggregator(t *testing.T) {
+	source := &fakeSource{}
+	aggregator := NewAggregator(source.read)
+
+	accounts, err := Read(aggregator, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(source.reads) != 1 || source.reads[0] != "2025-03-01" || source.balancesOnly != 0 {
+		t.Errorf("Expected one read since 2025-03-01, got %v and %d balances-only", source.reads, source.balancesOnly)
+	}
+	if len(accounts) != 1 || len(accounts[0].Transactions) != 1 || accounts[0].Transactions[0].ID != "t-1" {
+		t.Errorf("Expected the account with its transaction, got %+v", accounts)
+	}
+
+	// Accounts on its own reads balances only
+	accounts, err = aggregator.Accounts()
+	if err != nil {
+		t.Fatalf("Accounts failed: %v", err)
+	}
+	if source.balancesOnly != 1 || len(accounts) != 1 || accounts[0].Transactions != nil {
+		t.Errorf("Expected a balances-only read without transactions, got %+v", accounts)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	source := &fakeSource{}
+	Register("first", func(db *database.DB, opts Options) ([]Connection, error) {
+		if opts.ReplayDir != "" {
+			return nil, nil
+		}
+		return []Connection{
+			{Key: "first:a", Name: "A", Provider: NewAggregator(source.read)},
+			{Key: "first:b", Name: "B", Provider: NewAggregator(source.read)},
+		}, nil
+	})
+	Register("broken", func(db *database.DB, opts Options) ([]Connection, error) {
+		return nil, errors.New("not set up")
+	})
+	Register("second", func(db *database.DB, opts Options) ([]Connection, error) {
+		return []Connection{{Key: "second", Name: "Second", Provider: NewAggregator(source.read)}}, nil
+	})
+
+	if kinds := Kinds(); !reflect.DeepEqual(kinds, []string{"first", "broken", "second"}) {
+		t.Errorf("Unexpected kinds %v", kinds)
+	}
+
+	connections, err := Open(nil, Options{})
+	if err == nil || !strings.Contains(err.Error(), "failed to open broken: not set up") {
+		t.Errorf("Expected the broken kind's error, got %v", err)
+	}
+	var keys []string
+	for _, connection := range connections {
+		keys = append(keys, connection.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"first:a", "first:b", "second"}) {
+		t.Errorf("Expected the connections in registration order, got %v", keys)
+	}
+
+	if connections, _ := Open(nil, Options{ReplayDir: "recordings"}); len(connections) != 1 {
+		t.Errorf("Expected only the source that replays, got %d connections", len(connections))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a kind twice to panic")
+		}
+	}()
+	Register("first", nil)
+}
+
+func TestSimpleFIN(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		json.NewEncoder(w).Encode(simplefin.AccountsResponse{Accounts: []simplefin.Account{{
+			ID:           "ACT-1",
+			Balance:      "10.00",
+			Transactions: []simplefin.Transaction{{ID: "TRN-1", Amount: "-1.00"}},
+		}}})
+	}))
+	defer server.Close()
+	p := SimpleFIN(simplefin.NewClient(server.URL, "user", "pass"))
+
+	since := time.Unix(1740787200, 0)
+	accounts, err := Read(p, since)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(accounts) != 1 || len(accounts[0].Transactions) != 1 {
+		t.Errorf("Unexpected accounts %+v", accounts)
+	}
+	if _, err := Read(p, time.Time{}); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if _, err := p.Accounts(); err != nil {
+		t.Fatalf("Accounts failed: %v", err)
+	}
+
+	want := []string{"start-date=1740787200", "", "balances-only=1"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("Expected one request per read %q, got %q", want, queries)
+	}
+}
@@ -0,0 +1,98 @@
+// Package quote fetches latest market prices for stocks and funds from
+// pluggable sources: Stooq, Yahoo Finance, and Alpha Vantage.
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Source names accepted by New
+const (
+	SourceStooq        = "stooq"
+	SourceYahoo        = "yahoo"
+	SourceAlphaVantage = "alphavantage"
+
+	// DefaultSource needs no API key
+	DefaultSource = SourceStooq
+)
+
+// SourceNames lists every quote source
+var SourceNames = []string{SourceStooq, SourceYahoo, SourceAlphaVantage}
+
+// ErrNoQuote is returned when a source has no price for a symbol
+var ErrNoQuote = errors.New("no quote available")
+
+// Source fetches the latest price of a security
+type Source interface {
+	Name() string
+	// Price returns the latest price per share of symbol, in the currency
+	// it trades in
+	Price(symbol string) (float64, error)
+}
+
+// IsValidSource reports whether name is one of SourceNames
+func IsValidSource(name string) bool {
+	for _, source := range SourceNames {
+		if name == source {
+			return true
+		}
+	}
+	return false
+}
+
+// New returns the named quote source. Alpha Vantage needs an API key; the
+// others ignore it.
+func New(name, apiKey string) (Source, error) {
+	switch name {
+	case "", SourceStooq:
+		return NewStooq(), nil
+	case SourceYahoo:
+		return NewYahoo(), nil
+	case SourceAlphaVantage:
+		if apiKey == "" {
+			return nil, fmt.Errorf("the alphavantage quote source needs an API key. Set it with 'money config set alpha_vantage_api_key <key>'")
+		}
+		return NewAlphaVantage(apiKey), nil
+	}
+	return nil, fmt.Errorf("unknown quote source: %s. Valid sources are: %v", name, SourceNames)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
+
+// get fetches url and returns the response body, failing on non-200 statuses
+func get(client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "money-cli/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make quote request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNoQuote
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
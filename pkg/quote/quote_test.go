@@ -0,0 +1,126 @@
+package quote
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStooqPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/q/l/" {
+			t.Errorf("Expected path /q/l/, got %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("s") {
+		case "vti.us":
+			w.Write([]byte("Symbol,Date,Time,Close\r\nVTI.US,2026-10-15,22:00:19,301.42\r\n"))
+		case "vod.uk":
+			w.Write([]byte("Symbol,Date,Time,Close\r\nVOD.UK,2026-10-15,17:35:00,71.5\r\n"))
+		default:
+			w.Write([]byte("Symbol,Date,Time,Close\r\nNOPE.US,N/D,N/D,N/D\r\n"))
+		}
+	}))
+	defer server.Close()
+
+	stooq := NewStooq()
+	stooq.BaseURL = server.URL
+
+	price, err := stooq.Price("VTI")
+	if err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if price != 301.42 {
+		t.Errorf("Expected 301.42, got %v", price)
+	}
+
+	if price, err := stooq.Price("VOD.UK"); err != nil || price != 71.5 {
+		t.Errorf("Expected 71.5 for a symbol with a market suffix, got %v (%v)", price, err)
+	}
+
+	if _, err := stooq.Price("NOPE"); !errors.Is(err, ErrNoQuote) {
+		t.Errorf("Expected ErrNoQuote for an unknown symbol, got %v", err)
+	}
+}
+
+func TestYahooPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v8/finance/chart/VTSAX":
+			w.Write([]byte(`{"chart":{"result":[{"meta":{"symbol":"VTSAX","regularMarketPrice":142.17}}],"error":null}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`))
+		}
+	}))
+	defer server.Close()
+
+	yahoo := NewYahoo()
+	yahoo.BaseURL = server.URL
+
+	price, err := yahoo.Price("vtsax")
+	if err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if price != 142.17 {
+		t.Errorf("Expected 142.17, got %v", price)
+	}
+
+	if _, err := yahoo.Price("NOPE"); !errors.Is(err, ErrNoQuote) {
+		t.Errorf("Expected ErrNoQuote for an unknown symbol, got %v", err)
+	}
+}
+
+func TestAlphaVantagePrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("function") != "GLOBAL_QUOTE" || query.Get("apikey") != "test-key" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		switch query.Get("symbol") {
+		case "AAPL":
+			w.Write([]byte(`{"Global Quote":{"01. symbol":"AAPL","05. price":"189.8400"}}`))
+		case "LIMIT":
+			w.Write([]byte(`{"Note":"Thank you for using Alpha Vantage! Our standard API rate limit is 25 requests per day."}`))
+		default:
+			w.Write([]byte(`{"Global Quote":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	alphaVantage := NewAlphaVantage("test-key")
+	alphaVantage.BaseURL = server.URL
+
+	price, err := alphaVantage.Price("aapl")
+	if err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if price != 189.84 {
+		t.Errorf("Expected 189.84, got %v", price)
+	}
+
+	if _, err := alphaVantage.Price("LIMIT"); err == nil || errors.Is(err, ErrNoQuote) {
+		t.Errorf("Expected the rate limit message as an error, got %v", err)
+	}
+
+	if _, err := alphaVantage.Price("NOPE"); !errors.Is(err, ErrNoQuote) {
+		t.Errorf("Expected ErrNoQuote for an unknown symbol, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", SourceStooq, SourceYahoo} {
+		if _, err := New(name, ""); err != nil {
+			t.Errorf("New(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := New(SourceAlphaVantage, ""); err == nil {
+		t.Error("Expected an error for alphavantage without an API key")
+	}
+	if source, err := New(SourceAlphaVantage, "key"); err != nil || source.Name() != SourceAlphaVantage {
+		t.Errorf("Expected an alphavantage source, got %v (%v)", source, err)
+	}
+	if _, err := New("bloomberg", ""); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
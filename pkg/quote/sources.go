@@ -0,0 +1,176 @@
+package quote
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	StooqBaseURL        = "https://stooq.com"
+	YahooBaseURL        = "https://query1.finance.yahoo.com"
+	AlphaVantageBaseURL = "https://www.alphavantage.co"
+)
+
+// Stooq fetches quotes from Stooq's free CSV endpoint. It needs no API key.
+type Stooq struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewStooq creates a Stooq quote source
+func NewStooq() *Stooq {
+	return &Stooq{BaseURL: StooqBaseURL, HTTPClient: newHTTPClient()}
+}
+
+func (s *Stooq) Name() string { return SourceStooq }
+
+// Price returns the latest close for symbol. Stooq suffixes tickers with
+// their market, so symbols without one are looked up as US listings.
+func (s *Stooq) Price(symbol string) (float64, error) {
+	ticker := strings.ToLower(symbol)
+	if !strings.Contains(ticker, ".") {
+		ticker += ".us"
+	}
+
+	params := url.Values{}
+	params.Set("s", ticker)
+	params.Set("f", "sd2t2c")
+	params.Set("h", "")
+	params.Set("e", "csv")
+
+	body, err := get(s.HTTPClient, fmt.Sprintf("%s/q/l/?%s", s.BaseURL, params.Encode()))
+	if err != nil {
+		return 0, err
+	}
+
+	// Symbol,Date,Time,Close with N/D for unknown symbols
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse quote response: %w", err)
+	}
+	if len(records) < 2 || len(records[1]) < 4 {
+		return 0, ErrNoQuote
+	}
+	return parsePrice(records[1][3])
+}
+
+// Yahoo fetches quotes from Yahoo Finance's chart API. It needs no API key,
+// but is unofficial and may change without notice.
+type Yahoo struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewYahoo creates a Yahoo Finance quote source
+func NewYahoo() *Yahoo {
+	return &Yahoo{BaseURL: YahooBaseURL, HTTPClient: newHTTPClient()}
+}
+
+func (y *Yahoo) Name() string { return SourceYahoo }
+
+// yahooChartResponse represents the parts of the chart API response used
+type yahooChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				RegularMarketPrice *float64 `json:"regularMarketPrice"`
+			} `json:"meta"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// Price returns the regular market price for symbol
+func (y *Yahoo) Price(symbol string) (float64, error) {
+	params := url.Values{}
+	params.Set("range", "1d")
+	params.Set("interval", "1d")
+
+	body, err := get(y.HTTPClient, fmt.Sprintf("%s/v8/finance/chart/%s?%s", y.BaseURL, url.PathEscape(strings.ToUpper(symbol)), params.Encode()))
+	if err != nil {
+		return 0, err
+	}
+
+	var chart yahooChartResponse
+	if err := json.Unmarshal(body, &chart); err != nil {
+		return 0, fmt.Errorf("failed to parse quote response: %w", err)
+	}
+	if chart.Chart.Error != nil {
+		return 0, fmt.Errorf("%w: %s", ErrNoQuote, chart.Chart.Error.Description)
+	}
+	if len(chart.Chart.Result) == 0 || chart.Chart.Result[0].Meta.RegularMarketPrice == nil {
+		return 0, ErrNoQuote
+	}
+	return *chart.Chart.Result[0].Meta.RegularMarketPrice, nil
+}
+
+// AlphaVantage fetches quotes from Alpha Vantage's GLOBAL_QUOTE endpoint.
+// The free tier allows 25 requests a day.
+type AlphaVantage struct {
+	APIKey     string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewAlphaVantage creates an Alpha Vantage quote source
+func NewAlphaVantage(apiKey string) *AlphaVantage {
+	return &AlphaVantage{APIKey: apiKey, BaseURL: AlphaVantageBaseURL, HTTPClient: newHTTPClient()}
+}
+
+func (a *AlphaVantage) Name() string { return SourceAlphaVantage }
+
+// alphaVantageQuoteResponse represents the GLOBAL_QUOTE response. Rate
+// limited and rejected requests return 200 with a Note or Information
+// message instead of a quote.
+type alphaVantageQuoteResponse struct {
+	GlobalQuote struct {
+		Price string `json:"05. price"`
+	} `json:"Global Quote"`
+	Note         string `json:"Note"`
+	Information  string `json:"Information"`
+	ErrorMessage string `json:"Error Message"`
+}
+
+// Price returns the latest price for symbol
+func (a *AlphaVantage) Price(symbol string) (float64, error) {
+	params := url.Values{}
+	params.Set("function", "GLOBAL_QUOTE")
+	params.Set("symbol", strings.ToUpper(symbol))
+	params.Set("apikey", a.APIKey)
+
+	body, err := get(a.HTTPClient, fmt.Sprintf("%s/query?%s", a.BaseURL, params.Encode()))
+	if err != nil {
+		return 0, err
+	}
+
+	var quote alphaVantageQuoteResponse
+	if err := json.Unmarshal(body, &quote); err != nil {
+		return 0, fmt.Errorf("failed to parse quote response: %w", err)
+	}
+	for _, message := range []string{quote.Note, quote.Information, quote.ErrorMessage} {
+		if message != "" {
+			return 0, fmt.Errorf("alpha vantage: %s", message)
+		}
+	}
+	if quote.GlobalQuote.Price == "" {
+		return 0, ErrNoQuote
+	}
+	return parsePrice(quote.GlobalQuote.Price)
+}
+
+// parsePrice parses a positive price, treating anything else as no quote
+func parsePrice(value string) (float64, error) {
+	price, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || price <= 0 {
+		return 0, ErrNoQuote
+	}
+	return price, nil
+}
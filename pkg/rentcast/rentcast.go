@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -14,10 +15,27 @@ const (
 	BaseURL = "https://api.rentcast.io/v1"
 )
 
-// Client represents a RentCast API client
+const (
+	// rateLimitAttempts is how many times a request is sent while RentCast
+	// answers 429 Too Many Requests
+	rateLimitAttempts = 3
+
+	// maxRetryAfter caps how long a 429's Retry-After is waited out
+	maxRetryAfter = 30 * time.Second
+)
+
+// Client represents a RentCast API client. It is safe for concurrent use.
 type Client struct {
 	APIKey     string
+	BaseURL    string // defaults to BaseURL
 	HTTPClient *http.Client
+
+	// RequestsPerSecond spaces requests out to stay within the API key's
+	// rate limit; 0 for no limit
+	RequestsPerSecond int
+
+	mu   sync.Mutex
+	next time.Time // when the next request may be sent
 }
 
 // NewClient creates a new RentCast API client
@@ -77,7 +95,7 @@ func (c *Client) GetValueEstimate(req ValueEstimateRequest) (*ValueEstimateRespo
 		params.Set("propertyType", *req.PropertyType)
 	}
 
-	url := fmt.Sprintf("%s/avm/value?%s", BaseURL, params.Encode())
+	url := fmt.Sprintf("%s/avm/value?%s", c.baseURL(), params.Encode())
 
 	resp, err := c.makeRequest("GET", url)
 	if err != nil {
@@ -120,7 +138,7 @@ func (c *Client) GetRentEstimate(req ValueEstimateRequest) (*RentEstimateRespons
 		params.Set("propertyType", *req.PropertyType)
 	}
 
-	url := fmt.Sprintf("%s/avm/rent/long-term?%s", BaseURL, params.Encode())
+	url := fmt.Sprintf("%s/avm/rent/long-term?%s", c.baseURL(), params.Encode())
 
 	resp, err := c.makeRequest("GET", url)
 	if err != nil {
@@ -145,6 +163,63 @@ func (c *Client) GetRentEstimate(req ValueEstimateRequest) (*RentEstimateRespons
 	return &rentResp, nil
 }
 
+// PropertyRecord represents a property's public record from the properties API
+type PropertyRecord struct {
+	FormattedAddress string   `json:"formattedAddress"`
+	PropertyType     *string  `json:"propertyType"`
+	Bedrooms         *int     `json:"bedrooms"`
+	Bathrooms        *float64 `json:"bathrooms"`
+	SquareFootage    *int     `json:"squareFootage"`
+	LotSize          *int     `json:"lotSize"`
+	YearBuilt        *int     `json:"yearBuilt"`
+}
+
+// GetPropertyRecord gets a property's public record using the RentCast API.
+// It returns nil when RentCast has no record for the address.
+func (c *Client) GetPropertyRecord(req ValueEstimateRequest) (*PropertyRecord, error) {
+	params := url.Values{}
+	params.Set("address", fmt.Sprintf("%s, %s, %s %s", req.Address, req.City, req.State, req.ZipCode))
+	params.Set("limit", "1")
+
+	url := fmt.Sprintf("%s/properties?%s", c.baseURL(), params.Encode())
+
+	resp, err := c.makeRequest("GET", url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make property record request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var records []PropertyRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse property record response: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return &records[0], nil
+}
+
+// baseURL returns the API base URL, which tests point at a local server
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return BaseURL
+}
+
 // makeRequest makes an HTTP request with the API key header
 func (c *Client) makeRequest(method, url string) (*http.Response, error) {
 	req, err := http.NewRequest(method, url, nil)
@@ -156,5 +231,36 @@ func (c *Client) makeRequest(method, url string) (*http.Response, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "money-cli/1.0")
 
-	return c.HTTPClient.Do(req)
+	for attempt := 1; ; attempt++ {
+		c.wait()
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == rateLimitAttempts {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		delay := time.Duration(attempt) * time.Second
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay = min(time.Duration(seconds)*time.Second, maxRetryAfter)
+		}
+		time.Sleep(delay)
+	}
+}
+
+// wait blocks until the next request may be sent under RequestsPerSecond
+func (c *Client) wait() {
+	if c.RequestsPerSecond <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	send := c.next
+	if send.Before(now) {
+		send = now
+	}
+	c.next = send.Add(time.Second / time.Duration(c.RequestsPerSecond))
+	c.mu.Unlock()
+
+	time.Sleep(time.Until(send))
 }
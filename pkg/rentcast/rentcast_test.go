@@ -1,7 +1,10 @@
 package rentcast
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -66,3 +69,88 @@ func TestRentEstimateResponse(t *testing.T) {
 		t.Error("Rent field should be set correctly")
 	}
 }
+
+func TestGetPropertyRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/properties" {
+			t.Errorf("Expected path /properties, got %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("address"); got != "123 Main St, Austin, TX 78701" {
+			t.Errorf("Expected full address, got '%s'", got)
+		}
+		if r.Header.Get("X-Api-Key") != "test-key" {
+			t.Error("Expected the API key header")
+		}
+		w.Write([]byte(`[{"formattedAddress":"123 Main St, Austin, TX 78701","propertyType":"Single Family","bedrooms":3,"bathrooms":2.5,"squareFootage":1878,"lotSize":8850,"yearBuilt":1973}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key")
+	client.BaseURL = server.URL
+
+	record, err := client.GetPropertyRecord(ValueEstimateRequest{Address: "123 Main St", City: "Austin", State: "TX", ZipCode: "78701"})
+	if err != nil {
+		t.Fatalf("GetPropertyRecord failed: %v", err)
+	}
+	if record == nil {
+		t.Fatal("Expected a property record")
+	}
+	if *record.Bedrooms != 3 || *record.Bathrooms != 2.5 || *record.SquareFootage != 1878 || *record.LotSize != 8850 || *record.YearBuilt != 1973 {
+		t.Errorf("Unexpected property record: %+v", record)
+	}
+}
+
+func TestGetPropertyRecordNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key")
+	client.BaseURL = server.URL
+
+	record, err := client.GetPropertyRecord(ValueEstimateRequest{Address: "1 Nowhere Rd"})
+	if err != nil {
+		t.Fatalf("GetPropertyRecord failed: %v", err)
+	}
+	if record != nil {
+		t.Errorf("Expected no record, got %+v", record)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"price": 500000}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key")
+	client.BaseURL = server.URL
+	client.RequestsPerSecond = 20
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.GetValueEstimate(ValueEstimateRequest{Address: "123 Main St"})
+		if err != nil {
+			t.Fatalf("GetValueEstimate failed: %v", err)
+		}
+		if resp.Price == nil || *resp.Price != 500000 {
+			t.Errorf("Expected price 500000, got %v", resp.Price)
+		}
+	}
+
+	// The 429 was retried, and the four requests were spaced 50ms apart
+	if requests != 4 {
+		t.Errorf("Expected 4 requests, got %d", requests)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the requests to take at least 150ms, took %s", elapsed)
+	}
+}
@@ -0,0 +1,205 @@
+// Package report builds reports that summarize spending across months.
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+const (
+	// DefaultLookbackMonths is how many months before the reported month form the baseline
+	DefaultLookbackMonths = 6
+
+	// DefaultSpikeFactor is how many times its usual amount spending must
+	// reach to be flagged
+	DefaultSpikeFactor = 3.0
+
+	// minBaselineMonths is the months of history a category needs before
+	// its spending can spike
+	minBaselineMonths = 3
+
+	// minBaselineTransactions is the expenses a category needs before a
+	// single transaction in it can stand out
+	minBaselineTransactions = 5
+
+	// minSpikeAmount ignores spikes smaller than this many cents
+	minSpikeAmount = 5000
+
+	// outlierScore is the modified z-score above which a transaction is an outlier
+	outlierScore = 3.5
+)
+
+// AnomalyOptions tunes anomaly detection
+type AnomalyOptions struct {
+	LookbackMonths int
+	SpikeFactor    float64
+}
+
+// CategorySpike is a category whose spending in the month is well above its
+// median monthly spending
+type CategorySpike struct {
+	Category string
+	Spent    int64 // cents spent in the month
+	Median   int64 // median cents spent per month over the lookback
+	Ratio    float64
+}
+
+// UnusualTransaction is an expense much larger than the usual expense in its category
+type UnusualTransaction struct {
+	Transaction database.Transaction
+	Category    string
+	Median      int64 // median size of an expense in the category, in cents
+}
+
+// Anomalies are the spikes and unusual transactions found in a month
+type Anomalies struct {
+	Month        time.Time
+	Spikes       []CategorySpike
+	Transactions []UnusualTransaction
+}
+
+// DetectAnomalies flags category spikes and unusual expenses in month,
+// comparing against the preceding months. byCategory holds transactions
+// keyed by category name, as returned by GetTransactionsByCategory.
+func DetectAnomalies(byCategory map[string][]database.Transaction, month time.Time, opts AnomalyOptions) Anomalies {
+	if opts.LookbackMonths <= 0 {
+		opts.LookbackMonths = DefaultLookbackMonths
+	}
+	if opts.SpikeFactor <= 0 {
+		opts.SpikeFactor = DefaultSpikeFactor
+	}
+
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthKey := month.Format("2006-01")
+
+	// Months before the first transaction aren't history, just missing data
+	firstKey := ""
+	for _, transactions := range byCategory {
+		for _, txn := range transactions {
+			if key := monthOf(txn); key != "" && (firstKey == "" || key < firstKey) {
+				firstKey = key
+			}
+		}
+	}
+
+	var baselineKeys []string
+	for i := 1; i <= opts.LookbackMonths; i++ {
+		key := month.AddDate(0, -i, 0).Format("2006-01")
+		if key >= firstKey {
+			baselineKeys = append(baselineKeys, key)
+		}
+	}
+
+	result := Anomalies{Month: month}
+	for category, transactions := range byCategory {
+		spentByMonth := make(map[string]int64)
+		var baselineExpenses []int64
+		var monthExpenses []database.Transaction
+
+		for _, txn := range transactions {
+			if txn.Amount >= 0 {
+				continue
+			}
+			key := monthOf(txn)
+			spentByMonth[key] += -txn.Amount
+			if key == monthKey {
+				monthExpenses = append(monthExpenses, txn)
+			} else if containsKey(baselineKeys, key) {
+				baselineExpenses = append(baselineExpenses, -txn.Amount)
+			}
+		}
+
+		if len(baselineKeys) >= minBaselineMonths {
+			monthly := make([]int64, len(baselineKeys))
+			for i, key := range baselineKeys {
+				monthly[i] = spentByMonth[key]
+			}
+			median := medianOf(monthly)
+			spent := spentByMonth[monthKey]
+			if median > 0 && spent >= minSpikeAmount && float64(spent) >= opts.SpikeFactor*float64(median) {
+				result.Spikes = append(result.Spikes, CategorySpike{
+					Category: category,
+					Spent:    spent,
+					Median:   median,
+					Ratio:    float64(spent) / float64(median),
+				})
+			}
+		}
+
+		if len(baselineExpenses) >= minBaselineTransactions {
+			median := medianOf(baselineExpenses)
+			mad := medianAbsoluteDeviation(baselineExpenses, median)
+			for _, txn := range monthExpenses {
+				if isOutlier(-txn.Amount, median, mad, opts.SpikeFactor) {
+					result.Transactions = append(result.Transactions, UnusualTransaction{
+						Transaction: txn,
+						Category:    category,
+						Median:      median,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(result.Spikes, func(i, j int) bool {
+		return result.Spikes[i].Ratio > result.Spikes[j].Ratio
+	})
+	sort.Slice(result.Transactions, func(i, j int) bool {
+		return result.Transactions[i].Transaction.Amount < result.Transactions[j].Transaction.Amount
+	})
+
+	return result
+}
+
+// isOutlier reports whether an expense of amount is both factor times the
+// median and far outside the usual spread of expenses
+func isOutlier(amount, median int64, mad float64, factor float64) bool {
+	if float64(amount) < factor*float64(median) {
+		return false
+	}
+	if mad == 0 {
+		// Every usual expense is the same size, so any large jump stands out
+		return true
+	}
+	return 0.6745*(float64(amount)-float64(median))/mad > outlierScore
+}
+
+// monthOf returns the YYYY-MM month txn was posted in, in the configured
+// time zone
+func monthOf(txn database.Transaction) string {
+	return format.PostedMonth(txn.Posted)
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func medianOf(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func medianAbsoluteDeviation(values []int64, median int64) float64 {
+	deviations := make([]int64, len(values))
+	for i, v := range values {
+		deviations[i] = int64(math.Abs(float64(v - median)))
+	}
+	return float64(medianOf(deviations))
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func expense(id, posted string, cents int64) database.Transaction {
+	return database.Transaction{ID: id, Posted: posted + "T12:00:00Z", Amount: -cents, Description: id}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	byCategory := map[string][]database.Transaction{}
+
+	// Six months of steady dining and groceries
+	for m := 1; m <= 6; m++ {
+		for w := 0; w < 4; w++ {
+			day := fmt.Sprintf("2024-%02d-%02d", m, 1+w*7)
+			byCategory["Dining Out"] = append(byCategory["Dining Out"], expense(fmt.Sprintf("d-%d-%d", m, w), day, 2000+int64(w)*100))
+			byCategory["Groceries"] = append(byCategory["Groceries"], expense(fmt.Sprintf("g-%d-%d", m, w), day, 10000+int64(w)*500))
+		}
+	}
+
+	// July: dining spikes across many meals, groceries has one huge purchase
+	for w := 0; w < 12; w++ {
+		byCategory["Dining Out"] = append(byCategory["Dining Out"], expense(fmt.Sprintf("d-7-%d", w), fmt.Sprintf("2024-07-%02d", 1+w*2), 2200))
+	}
+	byCategory["Groceries"] = append(byCategory["Groceries"],
+		expense("g-7-0", "2024-07-03", 10500),
+		expense("g-7-big", "2024-07-10", 90000),
+	)
+	// Income and new categories don't count
+	byCategory["Income"] = []database.Transaction{{ID: "pay", Posted: "2024-07-01T00:00:00Z", Amount: 500000}}
+	byCategory["Travel"] = []database.Transaction{expense("trip", "2024-07-15", 150000)}
+
+	month := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	anomalies := DetectAnomalies(byCategory, month, AnomalyOptions{})
+
+	if len(anomalies.Spikes) != 1 || anomalies.Spikes[0].Category != "Dining Out" {
+		t.Fatalf("Expected a Dining Out spike, got %+v", anomalies.Spikes)
+	}
+	if anomalies.Spikes[0].Spent != 12*2200 {
+		t.Errorf("Expected %d spent, got %d", 12*2200, anomalies.Spikes[0].Spent)
+	}
+
+	if len(anomalies.Transactions) != 1 || anomalies.Transactions[0].Transaction.ID != "g-7-big" {
+		t.Fatalf("Expected the large grocery purchase to be flagged, got %+v", anomalies.Transactions)
+	}
+
+	// A higher factor suppresses the dining spike
+	anomalies = DetectAnomalies(byCategory, month, AnomalyOptions{SpikeFactor: 5})
+	if len(anomalies.Spikes) != 0 {
+		t.Errorf("Expected no spikes at 5x, got %+v", anomalies.Spikes)
+	}
+}
+
+func TestDetectAnomaliesNeedsHistory(t *testing.T) {
+	byCategory := map[string][]database.Transaction{
+		"Dining Out": {
+			expense("a", "2024-06-01", 1000),
+			expense("b", "2024-07-01", 9000),
+		},
+	}
+
+	anomalies := DetectAnomalies(byCategory, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), AnomalyOptions{})
+	if len(anomalies.Spikes) != 0 || len(anomalies.Transactions) != 0 {
+		t.Errorf("Expected no anomalies with one month of history, got %+v", anomalies)
+	}
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// uncategorized is the name budgets and reports give transactions without a
+// category
+const uncategorized = "Uncategorized"
+
+// CategoryDetail is one category's transactions over a period, with
+// subtotals by merchant
+type CategoryDetail struct {
+	Category     string
+	StartDate    string                 // YYYY-MM-DD, inclusive
+	EndDate      string                 // YYYY-MM-DD, inclusive
+	Transactions []database.Transaction // newest first
+	Merchants    []MerchantTotal        // largest first
+	Total        int64                  // cents, negative when more was spent than refunded
+}
+
+// CategoryDrillDown gathers the transactions in a category, or the
+// uncategorized ones for "Uncategorized", between startDate and endDate
+func CategoryDrillDown(db *database.DB, category, startDate, endDate string) (*CategoryDetail, error) {
+	filter := database.TransactionFilter{StartDate: startDate, EndDate: endDate}
+	if category == uncategorized {
+		filter.UncategorizedOnly = true
+	} else {
+		c, err := db.GetCategoryByName(category)
+		if err != nil {
+			return nil, err
+		}
+		category = c.Name
+		filter.CategoryID = c.ID
+	}
+
+	transactions, err := db.GetTransactions(filter, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	detail := &CategoryDetail{
+		Category:     category,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Transactions: transactions,
+		Merchants:    MerchantTotals(transactions),
+	}
+	for _, txn := range transactions {
+		detail.Total += txn.Amount
+	}
+	return detail, nil
+}
+
+// DefaultUsageMonths is how many months a category's usage covers by
+// default
+const DefaultUsageMonths = 24
+
+// CategoryUsage is how much a category was used each month, for deciding
+// whether it's worth keeping, splitting or merging
+type CategoryUsage struct {
+	Category string
+	Months   []MonthUsage // oldest first, ending with the current month
+}
+
+// MonthUsage is one month of a category's transactions
+type MonthUsage struct {
+	Month string // YYYY-MM
+	Count int
+	Total int64 // cents, negative when more was spent than refunded
+}
+
+// ActiveMonths returns how many months had any transactions
+func (u CategoryUsage) ActiveMonths() int {
+	active := 0
+	for _, month := range u.Months {
+		if month.Count > 0 {
+			active++
+		}
+	}
+	return active
+}
+
+// CategoryUsageByMonth counts and totals a category's transactions, or the
+// uncategorized ones for "Uncategorized", in each of the months months up
+// to and including the month of end. Pending transactions are included.
+func CategoryUsageByMonth(db *database.DB, category string, end time.Time, months int) (*CategoryUsage, error) {
+	if months <= 0 {
+		months = DefaultUsageMonths
+	}
+
+	current := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, format.Location())
+	first := current.AddDate(0, -(months - 1), 0)
+	filter := database.TransactionFilter{
+		StartDate: first.Format("2006-01-02"),
+		EndDate:   current.AddDate(0, 1, -1).Format("2006-01-02"),
+	}
+	if category == uncategorized {
+		filter.UncategorizedOnly = true
+	} else {
+		c, err := db.GetCategoryByName(category)
+		if err != nil {
+			return nil, err
+		}
+		category = c.Name
+		filter.CategoryID = c.ID
+	}
+
+	transactions, err := db.GetTransactions(filter, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	usage := &CategoryUsage{Category: category, Months: make([]MonthUsage, months)}
+	index := make(map[string]int, months)
+	for i := range usage.Months {
+		key := first.AddDate(0, i, 0).Format("2006-01")
+		usage.Months[i].Month = key
+		index[key] = i
+	}
+	for _, txn := range transactions {
+		if i, ok := index[format.PostedMonth(txn.Posted)]; ok {
+			usage.Months[i].Count++
+			usage.Months[i].Total += txn.Amount
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,187 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestMerchantName(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"SQ *BLUE BOTTLE #0123 OAKLAND CA", "Blue Bottle Oakland"},
+		{"SQ *BLUE BOTTLE #0456", "Blue Bottle"},
+		{"AMAZON.COM*2K3LM1ZX0 AMZN.COM/BILL WA", "Amazon.com"},
+		{"TST* SWEETGREEN 1234", "Sweetgreen"},
+		{"POS DEBIT SAFEWAY 0987 03/14", "Safeway"},
+		{"7-ELEVEN 34512", "7-Eleven"},
+		{"ACME CORP PAYROLL PPD", "Acme Corp Payroll"},
+		{"Netflix", "Netflix"},
+		{"123456", "123456"},
+	}
+	for _, tt := range tests {
+		if got := MerchantName(tt.description); got != tt.want {
+			t.Errorf("MerchantName(%q) = %q; want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryDrillDown(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	shoppingID, err := db.SaveCategory("Shopping")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id          string
+		posted      string
+		amount      int64
+		description string
+		categoryID  int
+	}{
+		{"amazon-1", "2024-03-02T00:00:00Z", -4000, "AMAZON.COM*111AAA", shoppingID},
+		{"amazon-2", "2024-03-09T00:00:00Z", -6000, "AMAZON.COM*222BBB", shoppingID},
+		{"amazon-return", "2024-03-12T00:00:00Z", 1500, "AMAZON.COM*333CCC", shoppingID},
+		{"target", "2024-03-20T00:00:00Z", -12000, "TARGET 00012345", shoppingID},
+		{"april", "2024-04-01T00:00:00Z", -9900, "TARGET 00012345", shoppingID},
+		{"loose", "2024-03-15T00:00:00Z", -700, "CORNER STORE", 0},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, txn.description, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	detail, err := CategoryDrillDown(db, "Shopping", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Failed to drill down: %v", err)
+	}
+	if len(detail.Transactions) != 4 || detail.Total != -20500 {
+		t.Errorf("Expected 4 transactions totaling -20500, got %d totaling %d", len(detail.Transactions), detail.Total)
+	}
+	want := []MerchantTotal{
+		{Merchant: "Target", Count: 1, Total: -12000, Example: "TARGET 00012345"},
+		{Merchant: "Amazon.com", Count: 3, Total: -8500, Example: "AMAZON.COM*333CCC"},
+	}
+	if len(detail.Merchants) != len(want) {
+		t.Fatalf("Expected merchants %+v, got %+v", want, detail.Merchants)
+	}
+	for i := range want {
+		if detail.Merchants[i] != want[i] {
+			t.Errorf("Merchant %d: expected %+v, got %+v", i, want[i], detail.Merchants[i])
+		}
+	}
+
+	detail, err = CategoryDrillDown(db, "Uncategorized", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Failed to drill down into uncategorized: %v", err)
+	}
+	if len(detail.Transactions) != 1 || detail.Transactions[0].ID != "loose" {
+		t.Errorf("Expected only the uncategorized transaction, got %+v", detail.Transactions)
+	}
+
+	if _, err := CategoryDrillDown(db, "Nonexistent", "2024-03-01", "2024-03-31"); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+func TestCategoryUsageByMonth(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+	t.Setenv("MONEY_DIR", t.TempDir())
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	diningID, err := db.SaveCategory("Dining")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id     string
+		posted string
+		amount int64
+	}{
+		{"too-old", "2023-12-31T23:00:00Z", -1000}, // the month before the window
+		{"jan-1", "2024-01-05T00:00:00Z", -2500},
+		{"jan-2", "2024-01-20T00:00:00Z", -1500},
+		{"mar", "2024-03-10T00:00:00Z", -4000},
+		{"mar-refund", "2024-03-12T00:00:00Z", 1000},
+		{"current", "2024-06-01T00:00:00Z", -800},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, "RESTAURANT", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.UpdateTransactionCategory(txn.id, diningID); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+	}
+
+	usage, err := CategoryUsageByMonth(db, "Dining", time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC), 6)
+	if err != nil {
+		t.Fatalf("CategoryUsageByMonth failed: %v", err)
+	}
+	want := []MonthUsage{
+		{Month: "2024-01", Count: 2, Total: -4000},
+		{Month: "2024-02"},
+		{Month: "2024-03", Count: 2, Total: -3000},
+		{Month: "2024-04"},
+		{Month: "2024-05"},
+		{Month: "2024-06", Count: 1, Total: -800},
+	}
+	if len(usage.Months) != len(want) {
+		t.Fatalf("Expected months %+v, got %+v", want, usage.Months)
+	}
+	for i := range want {
+		if usage.Months[i] != want[i] {
+			t.Errorf("Month %d: expected %+v, got %+v", i, want[i], usage.Months[i])
+		}
+	}
+	if active := usage.ActiveMonths(); active != 3 {
+		t.Errorf("Expected 3 active months, got %d", active)
+	}
+
+	if _, err := CategoryUsageByMonth(db, "Nonexistent", time.Now(), 6); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
@@ -0,0 +1,244 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// monthBounds returns when a YYYY-MM month starts and when the next one
+// starts in the configured time zone
+func monthBounds(month string) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation("2006-01", month, format.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q, use YYYY-MM", month)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// MonthTotals adds up each category's income and spending in a YYYY-MM
+// month, ordered by category name. Internal categories and pending
+// transactions are left out, so the totals match a statement.
+func MonthTotals(db *database.DB, month string) ([]database.ClosedCategoryTotal, error) {
+	start, end, err := monthBounds(month)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory, err := db.GetTransactionsByCategory(start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	var totals []database.ClosedCategoryTotal
+	for category, transactions := range byCategory {
+		total := database.ClosedCategoryTotal{Category: category}
+		for _, txn := range transactions {
+			if txn.Pending {
+				continue
+			}
+			if txn.Amount > 0 {
+				total.Income += txn.Amount
+			} else {
+				total.Expenses += -txn.Amount
+			}
+		}
+		if total.Income != 0 || total.Expenses != 0 {
+			totals = append(totals, total)
+		}
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Category < totals[j].Category
+	})
+	return totals, nil
+}
+
+// EndingBalances returns each account's last recorded balance before a
+// YYYY-MM month ended, ordered by account ID. Accounts with no balance
+// history by then are left out.
+func EndingBalances(db *database.DB, month string) ([]database.ClosedBalance, error) {
+	_, end, err := monthBounds(month)
+	if err != nil {
+		return nil, err
+	}
+
+	byAccount, err := db.GetBalancesBefore(end)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]database.ClosedBalance, 0, len(byAccount))
+	for accountID, balance := range byAccount {
+		balances = append(balances, database.ClosedBalance{AccountID: accountID, Balance: balance})
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].AccountID < balances[j].AccountID
+	})
+	return balances, nil
+}
+
+// CloseMonth snapshots a YYYY-MM month's category totals and ending
+// balances and locks it against category changes. Only months that have
+// ended by now can be closed.
+func CloseMonth(db *database.DB, month string, now time.Time) (*database.ClosedMonth, error) {
+	_, end, err := monthBounds(month)
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(end) {
+		return nil, fmt.Errorf("%s hasn't ended yet", month)
+	}
+
+	totals, err := MonthTotals(db, month)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := EndingBalances(db, month)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.CloseMonth(month, totals, balances); err != nil {
+		return nil, err
+	}
+	return db.GetClosedMonth(month)
+}
+
+// CategoryComparison is a category's totals when its month was closed and
+// now, in cents
+type CategoryComparison struct {
+	Category       string
+	ClosedIncome   int64
+	ClosedExpenses int64
+	Income         int64
+	Expenses       int64
+}
+
+// Changed reports whether the category's totals moved since the close
+func (c CategoryComparison) Changed() bool {
+	return c.Income != c.ClosedIncome || c.Expenses != c.ClosedExpenses
+}
+
+// BalanceComparison is an account's ending balance when its month was
+// closed and now, in cents
+type BalanceComparison struct {
+	AccountID string
+	Account   string
+	Closed    int64
+	Current   int64
+}
+
+// Changed reports whether the ending balance moved since the close
+func (b BalanceComparison) Changed() bool {
+	return b.Current != b.Closed
+}
+
+// ClosedComparison compares a closed month's snapshot with what the month
+// adds up to now
+type ClosedComparison struct {
+	Month      string
+	ClosedAt   string
+	Categories []CategoryComparison // ordered by category name
+	Balances   []BalanceComparison  // ordered by account name
+}
+
+// Changed reports whether anything moved since the close
+func (c *ClosedComparison) Changed() bool {
+	for _, category := range c.Categories {
+		if category.Changed() {
+			return true
+		}
+	}
+	for _, balance := range c.Balances {
+		if balance.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareClosedMonth recomputes a closed YYYY-MM month's totals and ending
+// balances and lines them up against its snapshot
+func CompareClosedMonth(db *database.DB, month string) (*ClosedComparison, error) {
+	closed, err := db.GetClosedMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	if closed == nil {
+		return nil, fmt.Errorf("%s isn't closed, close it with 'money close %s'", month, month)
+	}
+
+	totals, err := MonthTotals(db, month)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := EndingBalances(db, month)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &ClosedComparison{Month: month, ClosedAt: closed.ClosedAt}
+
+	categories := make(map[string]*CategoryComparison)
+	categoryOf := func(name string) *CategoryComparison {
+		if categories[name] == nil {
+			categories[name] = &CategoryComparison{Category: name}
+		}
+		return categories[name]
+	}
+	for _, total := range closed.Categories {
+		c := categoryOf(total.Category)
+		c.ClosedIncome = total.Income
+		c.ClosedExpenses = total.Expenses
+	}
+	for _, total := range totals {
+		c := categoryOf(total.Category)
+		c.Income = total.Income
+		c.Expenses = total.Expenses
+	}
+	for _, c := range categories {
+		comparison.Categories = append(comparison.Categories, *c)
+	}
+	sort.Slice(comparison.Categories, func(i, j int) bool {
+		return comparison.Categories[i].Category < comparison.Categories[j].Category
+	})
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountNames := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		accountNames[account.ID] = account.DisplayName()
+	}
+
+	byAccount := make(map[string]*BalanceComparison)
+	balanceOf := func(accountID string) *BalanceComparison {
+		if byAccount[accountID] == nil {
+			name := accountNames[accountID]
+			if name == "" {
+				name = accountID
+			}
+			byAccount[accountID] = &BalanceComparison{AccountID: accountID, Account: name}
+		}
+		return byAccount[accountID]
+	}
+	for _, balance := range closed.Balances {
+		balanceOf(balance.AccountID).Closed = balance.Balance
+	}
+	for _, balance := range balances {
+		balanceOf(balance.AccountID).Current = balance.Balance
+	}
+	for _, b := range byAccount {
+		comparison.Balances = append(comparison.Balances, *b)
+	}
+	sort.Slice(comparison.Balances, func(i, j int) bool {
+		return comparison.Balances[i].Account < comparison.Balances[j].Account
+	})
+
+	return comparison, nil
+}
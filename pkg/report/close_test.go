@@ -0,0 +1,154 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestCloseMonth(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	salaryID, err := db.SaveCategory("Salary")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	transfersID, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id         string
+		posted     string
+		amount     int64
+		pending    bool
+		categoryID int
+	}{
+		{"groceries", "2024-01-05T00:00:00Z", -5000, false, groceriesID},
+		{"refund", "2024-01-06T00:00:00Z", 1000, false, groceriesID},
+		{"salary", "2024-01-15T00:00:00Z", 300000, false, salaryID},
+		{"transfer", "2024-01-20T00:00:00Z", -20000, false, transfersID},
+		{"pending", "2024-01-30T00:00:00Z", -700, true, groceriesID},
+		{"february", "2024-02-01T00:00:00Z", -9000, false, groceriesID},
+		{"unknown", "2024-01-25T00:00:00Z", -300, false, 0},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, txn.id, txn.pending); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+	if err := db.SaveBalanceHistoryAt("acc-1", 250000, nil, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+	if err := db.SaveBalanceHistoryAt("acc-1", 240000, nil, time.Date(2024, 2, 2, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to save balance history: %v", err)
+	}
+
+	if _, err := CloseMonth(db, "2024-01", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected error closing a month that hasn't ended")
+	}
+	if _, err := CloseMonth(db, "January", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected error closing an invalid month")
+	}
+
+	closed, err := CloseMonth(db, "2024-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to close month: %v", err)
+	}
+
+	// Internal and pending transactions and February are left out
+	want := []database.ClosedCategoryTotal{
+		{Category: "Groceries", Income: 1000, Expenses: 5000},
+		{Category: "Salary", Income: 300000},
+		{Category: "Uncategorized", Expenses: 300},
+	}
+	if len(closed.Categories) != len(want) {
+		t.Fatalf("Expected %d category totals, got %+v", len(want), closed.Categories)
+	}
+	for i, total := range want {
+		if closed.Categories[i] != total {
+			t.Errorf("Expected %+v, got %+v", total, closed.Categories[i])
+		}
+	}
+	if len(closed.Balances) != 1 || closed.Balances[0].Balance != 250000 {
+		t.Errorf("Expected an ending balance of 250000, got %+v", closed.Balances)
+	}
+
+	comparison, err := CompareClosedMonth(db, "2024-01")
+	if err != nil {
+		t.Fatalf("Failed to compare closed month: %v", err)
+	}
+	if comparison.Changed() {
+		t.Errorf("Expected no changes right after closing, got %+v", comparison)
+	}
+
+	// A late transaction and a forced recategorization show up as changes
+	if err := db.SaveTransaction("late", "acc-1", "2024-01-28T00:00:00Z", -400, "late", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	db.AllowClosedMonthEdits(true)
+	if err := db.UpdateTransactionCategory("unknown", groceriesID); err != nil {
+		t.Fatalf("Failed to force a category change: %v", err)
+	}
+	db.AllowClosedMonthEdits(false)
+
+	comparison, err = CompareClosedMonth(db, "2024-01")
+	if err != nil {
+		t.Fatalf("Failed to compare closed month: %v", err)
+	}
+	if !comparison.Changed() {
+		t.Fatal("Expected changes after editing the closed month")
+	}
+	byCategory := make(map[string]CategoryComparison)
+	for _, c := range comparison.Categories {
+		byCategory[c.Category] = c
+	}
+	if groceries := byCategory["Groceries"]; groceries.ClosedExpenses != 5000 || groceries.Expenses != 5300 {
+		t.Errorf("Expected Groceries expenses to go from 5000 to 5300, got %+v", groceries)
+	}
+	if uncategorized := byCategory["Uncategorized"]; uncategorized.ClosedExpenses != 300 || uncategorized.Expenses != 400 {
+		t.Errorf("Expected Uncategorized expenses to go from 300 to 400, got %+v", uncategorized)
+	}
+	if byCategory["Salary"].Changed() {
+		t.Errorf("Expected Salary to be unchanged, got %+v", byCategory["Salary"])
+	}
+	if len(comparison.Balances) != 1 || comparison.Balances[0].Account != "Checking" || comparison.Balances[0].Changed() {
+		t.Errorf("Expected Checking's balance to be unchanged, got %+v", comparison.Balances)
+	}
+
+	if _, err := CompareClosedMonth(db, "2024-02"); err == nil {
+		t.Error("Expected error comparing a month that isn't closed")
+	}
+}
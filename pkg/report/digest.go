@@ -0,0 +1,290 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// notableCount is how many of the largest transactions a digest lists
+const notableCount = 5
+
+// CategoryTotal is the amount spent in a category, in cents
+type CategoryTotal struct {
+	Category string
+	Amount   int64
+}
+
+// NotableTransaction is one of the largest transactions in a digest
+type NotableTransaction struct {
+	Transaction database.Transaction
+	Category    string
+	Currency    string // of the transaction's account
+}
+
+// BalanceChange is how an account's balance moved over a digest's period
+type BalanceChange struct {
+	Account  string
+	Currency string
+	Start    int64
+	End      int64
+}
+
+// Digest is a compact summary of a period's activity
+type Digest struct {
+	Start          time.Time
+	End            time.Time
+	Income         int64
+	Expenses       int64 // positive cents spent
+	Spending       []CategoryTotal
+	Notable        []NotableTransaction
+	BalanceChanges []BalanceChange
+	Uncategorized  int
+	EmergencyFund  *EmergencyFund
+}
+
+// BuildDigest summarizes activity from start until end. Internal categories
+// are left out of the income, spending, and notable transactions. The
+// emergency fund is measured as of end against the configured target.
+func BuildDigest(db *database.DB, start, end time.Time) (*Digest, error) {
+	digest := &Digest{Start: start, End: end}
+
+	byCategory, err := db.GetTransactionsByCategory(start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	var all []NotableTransaction
+	for category, transactions := range byCategory {
+		var spent int64
+		for _, txn := range transactions {
+			if txn.Amount < 0 {
+				spent += -txn.Amount
+			} else {
+				digest.Income += txn.Amount
+			}
+			all = append(all, NotableTransaction{Transaction: txn, Category: category})
+		}
+		if spent > 0 {
+			digest.Spending = append(digest.Spending, CategoryTotal{Category: category, Amount: spent})
+			digest.Expenses += spent
+		}
+	}
+
+	sort.Slice(digest.Spending, func(i, j int) bool {
+		if digest.Spending[i].Amount != digest.Spending[j].Amount {
+			return digest.Spending[i].Amount > digest.Spending[j].Amount
+		}
+		return digest.Spending[i].Category < digest.Spending[j].Category
+	})
+
+	sort.Slice(all, func(i, j int) bool {
+		return abs(all[i].Transaction.Amount) > abs(all[j].Transaction.Amount)
+	})
+	if len(all) > notableCount {
+		all = all[:notableCount]
+	}
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	currencies := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		currencies[account.ID] = account.Currency
+	}
+	for i := range all {
+		all[i].Currency = currencies[all[i].Transaction.AccountID]
+	}
+	digest.Notable = all
+
+	digest.BalanceChanges, err = balanceChanges(db, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Uncategorized, err = db.CountTransactions(database.TransactionFilter{
+		UncategorizedOnly: true,
+		StartDate:         start.In(format.Location()).Format("2006-01-02"),
+		EndDate:           end.In(format.Location()).Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count uncategorized transactions: %w", err)
+	}
+
+	digest.EmergencyFund, err = BuildEmergencyFund(db, end, db.GetConfig().EmergencyFundMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+// balanceChanges compares each account's first recorded balance in the
+// period with its current balance, keeping the accounts that moved
+func balanceChanges(db *database.DB, start, end time.Time) ([]BalanceChange, error) {
+	days := int(math.Ceil(time.Since(start).Hours()/24)) + 1
+	history, err := db.GetAllBalanceHistory(days)
+	if err != nil {
+		return nil, err
+	}
+
+	// History is ordered oldest first, so the first entry per account wins
+	startBalances := make(map[string]int64)
+	startKey := start.UTC().Format("2006-01-02 15:04:05")
+	for _, bh := range history {
+		if _, seen := startBalances[bh.AccountID]; seen || bh.RecordedAt < startKey {
+			continue
+		}
+		startBalances[bh.AccountID] = bh.Balance
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	var changes []BalanceChange
+	for _, account := range accounts {
+		startBalance, exists := startBalances[account.ID]
+		if !exists || startBalance == account.Balance {
+			continue
+		}
+		changes = append(changes, BalanceChange{
+			Account:  account.DisplayName(),
+			Currency: account.Currency,
+			Start:    startBalance,
+			End:      account.Balance,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return abs(changes[i].End-changes[i].Start) > abs(changes[j].End-changes[j].Start)
+	})
+	return changes, nil
+}
+
+// Title is a one-line heading for the digest
+func (d *Digest) Title() string {
+	return fmt.Sprintf("Money digest: %s – %s", d.Start.Format("Jan 2"), d.End.Format("Jan 2, 2006"))
+}
+
+// Text renders the digest as plain text
+func (d *Digest) Text() string {
+	var b strings.Builder
+	b.WriteString(d.Title() + "\n\n")
+
+	b.WriteString(fmt.Sprintf("Income:   %s\n", format.Currency(d.Income, format.BaseCurrency())))
+	b.WriteString(fmt.Sprintf("Expenses: %s\n", format.Currency(d.Expenses, format.BaseCurrency())))
+	b.WriteString(fmt.Sprintf("Net:      %s\n", signedCurrency(d.Income-d.Expenses, format.BaseCurrency())))
+	if d.EmergencyFund != nil {
+		b.WriteString(fmt.Sprintf("\nEmergency fund: %s\n", d.EmergencyFund.Summary()))
+		if d.EmergencyFund.BelowTarget() {
+			b.WriteString(fmt.Sprintf("  Below target: %s short\n", format.Currency(d.EmergencyFund.Shortfall(), format.BaseCurrency())))
+		}
+	}
+
+	if len(d.Spending) > 0 {
+		b.WriteString("\nSpending by category:\n")
+		for _, total := range d.Spending {
+			b.WriteString(fmt.Sprintf("  %-24s %s\n", total.Category, format.Currency(total.Amount, format.BaseCurrency())))
+		}
+	}
+
+	if len(d.Notable) > 0 {
+		b.WriteString("\nNotable transactions:\n")
+		for _, notable := range d.Notable {
+			txn := notable.Transaction
+			b.WriteString(fmt.Sprintf("  %s  %s  %s (%s)\n", postedDate(txn.Posted),
+				signedCurrency(txn.Amount, notable.Currency), txn.DisplayDescription(), notable.Category))
+		}
+	}
+
+	if len(d.BalanceChanges) > 0 {
+		b.WriteString("\nBalance changes:\n")
+		for _, change := range d.BalanceChanges {
+			b.WriteString(fmt.Sprintf("  %-24s %s → %s (%s)\n", change.Account,
+				format.Currency(change.Start, change.Currency), format.Currency(change.End, change.Currency),
+				signedCurrency(change.End-change.Start, change.Currency)))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("\nUncategorized transactions: %d\n", d.Uncategorized))
+	return b.String()
+}
+
+// Markdown renders the digest as Markdown
+func (d *Digest) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# " + d.Title() + "\n\n")
+
+	b.WriteString(fmt.Sprintf("- **Income:** %s\n", format.Currency(d.Income, format.BaseCurrency())))
+	b.WriteString(fmt.Sprintf("- **Expenses:** %s\n", format.Currency(d.Expenses, format.BaseCurrency())))
+	b.WriteString(fmt.Sprintf("- **Net:** %s\n", signedCurrency(d.Income-d.Expenses, format.BaseCurrency())))
+	b.WriteString(fmt.Sprintf("- **Uncategorized transactions:** %d\n", d.Uncategorized))
+	if d.EmergencyFund != nil {
+		b.WriteString(fmt.Sprintf("- **Emergency fund:** %s", d.EmergencyFund.Summary()))
+		if d.EmergencyFund.BelowTarget() {
+			b.WriteString(fmt.Sprintf(", **below target** by %s", format.Currency(d.EmergencyFund.Shortfall(), format.BaseCurrency())))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(d.Spending) > 0 {
+		b.WriteString("\n## Spending by category\n\n")
+		b.WriteString("| Category | Spent |\n|---|---:|\n")
+		for _, total := range d.Spending {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", escapeMarkdownCell(total.Category), format.Currency(total.Amount, format.BaseCurrency())))
+		}
+	}
+
+	if len(d.Notable) > 0 {
+		b.WriteString("\n## Notable transactions\n\n")
+		b.WriteString("| Date | Description | Category | Amount |\n|---|---|---|---:|\n")
+		for _, notable := range d.Notable {
+			txn := notable.Transaction
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", postedDate(txn.Posted),
+				escapeMarkdownCell(txn.DisplayDescription()), escapeMarkdownCell(notable.Category),
+				signedCurrency(txn.Amount, notable.Currency)))
+		}
+	}
+
+	if len(d.BalanceChanges) > 0 {
+		b.WriteString("\n## Balance changes\n\n")
+		b.WriteString("| Account | Start | End | Change |\n|---|---:|---:|---:|\n")
+		for _, change := range d.BalanceChanges {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", escapeMarkdownCell(change.Account),
+				format.Currency(change.Start, change.Currency), format.Currency(change.End, change.Currency),
+				signedCurrency(change.End-change.Start, change.Currency)))
+		}
+	}
+
+	return b.String()
+}
+
+// signedCurrency formats cents with an explicit + for gains
+func signedCurrency(cents int64, currency string) string {
+	if cents > 0 {
+		return "+" + format.Currency(cents, currency)
+	}
+	return format.Currency(cents, currency)
+}
+
+func postedDate(posted string) string {
+	return format.PostedDate(posted)
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestBuildDigest(t *testing.T) {
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	groceries, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	now := time.Now().UTC()
+	recent := now.AddDate(0, 0, -2).Format(time.RFC3339)
+	old := now.AddDate(0, 0, -30).Format(time.RFC3339)
+	transactions := []struct {
+		id     string
+		posted string
+		amount int64
+	}{
+		{"tx-1", recent, -8000},
+		{"tx-2", recent, -2000},
+		{"tx-3", recent, 250000},
+		{"tx-old", old, -99999},
+	}
+	for _, tx := range transactions {
+		if err := db.SaveTransaction(tx.id, "acc-1", tx.posted, tx.amount, "Store "+tx.id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	if err := db.UpdateTransactionCategory("tx-1", groceries); err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+
+	digest, err := BuildDigest(db, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		t.Fatalf("Failed to build digest: %v", err)
+	}
+
+	if digest.Income != 250000 || digest.Expenses != 10000 {
+		t.Errorf("Expected income 250000 and expenses 10000, got %d and %d", digest.Income, digest.Expenses)
+	}
+	if len(digest.Spending) != 2 || digest.Spending[0].Category != "Groceries" {
+		t.Errorf("Expected Groceries to lead spending, got %+v", digest.Spending)
+	}
+	if digest.Uncategorized != 2 {
+		t.Errorf("Expected 2 uncategorized transactions, got %d", digest.Uncategorized)
+	}
+	if len(digest.Notable) != 3 || digest.Notable[0].Transaction.ID != "tx-3" {
+		t.Errorf("Expected the paycheck to be the most notable transaction, got %+v", digest.Notable)
+	}
+
+	if digest.EmergencyFund == nil || digest.EmergencyFund.Target != 6 {
+		t.Errorf("Expected the emergency fund against the default target, got %+v", digest.EmergencyFund)
+	}
+
+	text := digest.Text()
+	for _, want := range []string{"Groceries", "$80.00", "+$2,500.00", "Uncategorized transactions: 2", "Emergency fund:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text digest should contain %q:\n%s", want, text)
+		}
+	}
+
+	markdown := digest.Markdown()
+	for _, want := range []string{"# Money digest", "| Groceries | $80.00 |", "**Uncategorized transactions:** 2", "**Emergency fund:**"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown digest should contain %q:\n%s", want, markdown)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// EmergencyFundHistoryMonths is how many complete months expenses are
+// averaged over for emergency fund coverage
+const EmergencyFundHistoryMonths = 6
+
+// liquidAccountTypes are the account types whose balances count as cash
+// on hand
+var liquidAccountTypes = map[string]bool{
+	"checking": true,
+	"savings":  true,
+}
+
+// EmergencyFund is how long cash on hand would cover spending with no
+// income
+type EmergencyFund struct {
+	Cash            int64 // cents in checking and savings accounts
+	MonthlyExpenses int64 // cents, averaged over EmergencyFundHistoryMonths
+	Target          int   // months of expenses cash should cover, 0 for none
+}
+
+// Months returns how many months of expenses cash covers, or +Inf
+// without any expenses
+func (f *EmergencyFund) Months() float64 {
+	if f.MonthlyExpenses <= 0 {
+		return math.Inf(1)
+	}
+	return float64(f.Cash) / float64(f.MonthlyExpenses)
+}
+
+// BelowTarget reports whether cash covers fewer months than the target
+func (f *EmergencyFund) BelowTarget() bool {
+	return f.Target > 0 && f.Months() < float64(f.Target)
+}
+
+// Shortfall returns the cents cash is short of the target, 0 when it's met
+func (f *EmergencyFund) Shortfall() int64 {
+	return max(0, int64(f.Target)*f.MonthlyExpenses-f.Cash)
+}
+
+// Summary describes the coverage in a sentence, with the target when
+// there is one
+func (f *EmergencyFund) Summary() string {
+	if f.MonthlyExpenses <= 0 {
+		return fmt.Sprintf("no spending in the last %d months to measure against", EmergencyFundHistoryMonths)
+	}
+	summary := fmt.Sprintf("%.1f months of expenses", f.Months())
+	if f.Target > 0 {
+		summary += fmt.Sprintf(" (target %d)", f.Target)
+	}
+	return summary
+}
+
+// BuildEmergencyFund measures cash in checking and savings accounts
+// against average monthly spending over the EmergencyFundHistoryMonths
+// complete months before the month of now, leaving out internal
+// categories.
+func BuildEmergencyFund(db *database.DB, now time.Time, target int) (*EmergencyFund, error) {
+	_, expenses, err := MonthlyCashFlow(db, now, EmergencyFundHistoryMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	fund := &EmergencyFund{MonthlyExpenses: expenses, Target: target}
+	for _, account := range accounts {
+		if account.AccountType != nil && liquidAccountTypes[*account.AccountType] {
+			fund.Cash += account.Balance
+		}
+	}
+	return fund, nil
+}
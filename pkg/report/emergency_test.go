@@ -0,0 +1,94 @@
+package report
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestBuildEmergencyFund(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	accounts := []struct {
+		id          string
+		balance     int64
+		accountType string
+	}{
+		{"checking", 600000, "checking"},
+		{"savings", 300000, "savings"},
+		{"card", -200000, "credit"},
+		{"brokerage", 5000000, "investment"},
+		{"untyped", 100000, ""},
+	}
+	for _, account := range accounts {
+		if err := db.SaveAccount(account.id, "org-1", account.id, "USD", account.balance, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+		if account.accountType != "" {
+			if err := db.SetAccountType(account.id, account.accountType); err != nil {
+				t.Fatalf("Failed to set account type: %v", err)
+			}
+		}
+	}
+
+	// $4,000 of spending a month for the six months before May
+	for month := 11; month <= 16; month++ {
+		posted := time.Date(2023, time.Month(month), 10, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		if err := db.SaveTransaction(posted, "checking", posted, -400000, "RENT", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+
+	fund, err := BuildEmergencyFund(db, time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatalf("Failed to build emergency fund: %v", err)
+	}
+	if fund.Cash != 900000 || fund.MonthlyExpenses != 400000 {
+		t.Errorf("Expected cash 900000 and expenses 400000, got %d and %d", fund.Cash, fund.MonthlyExpenses)
+	}
+	if fund.Months() != 2.25 {
+		t.Errorf("Expected 2.25 months covered, got %g", fund.Months())
+	}
+	if !fund.BelowTarget() || fund.Shortfall() != 300000 {
+		t.Errorf("Expected to be $3,000 short of 3 months, got below=%v short=%d", fund.BelowTarget(), fund.Shortfall())
+	}
+	if summary := fund.Summary(); summary != "2.2 months of expenses (target 3)" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+
+	fund.Target = 2
+	if fund.BelowTarget() || fund.Shortfall() != 0 {
+		t.Errorf("Expected 2 months to be met, got below=%v short=%d", fund.BelowTarget(), fund.Shortfall())
+	}
+	fund.Target = 0
+	if fund.BelowTarget() {
+		t.Error("Expected no warning without a target")
+	}
+
+	// Without spending to measure against, cash covers forever
+	fund, err = BuildEmergencyFund(db, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), 6)
+	if err != nil {
+		t.Fatalf("Failed to build emergency fund: %v", err)
+	}
+	if !math.IsInf(fund.Months(), 1) || fund.BelowTarget() {
+		t.Errorf("Expected infinite coverage, got %g", fund.Months())
+	}
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/fees"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// DefaultFeesYears is how many years, the current one included, the fees
+// report covers
+const DefaultFeesYears = 3
+
+// FeeTotal is the interest or fees one account paid each year of a report
+type FeeTotal struct {
+	AccountID string
+	Account   string
+	Category  string  // fees.InterestCategory or fees.FeesCategory
+	Totals    []int64 // positive cents paid per year, refunds netted out
+}
+
+// Total returns the cents paid over every year
+func (f FeeTotal) Total() int64 {
+	var total int64
+	for _, amount := range f.Totals {
+		total += amount
+	}
+	return total
+}
+
+// FeesReport is the interest and fees paid per account per year
+type FeesReport struct {
+	Years []int
+	Rows  []FeeTotal // largest total first
+}
+
+// CategoryTotals returns the cents of category paid across accounts,
+// per year
+func (r *FeesReport) CategoryTotals(category string) []int64 {
+	totals := make([]int64, len(r.Years))
+	for _, row := range r.Rows {
+		if row.Category != category {
+			continue
+		}
+		for i, amount := range row.Totals {
+			totals[i] += amount
+		}
+	}
+	return totals
+}
+
+// BuildFeesReport totals the transactions in the Interest and Fees
+// categories by account and year, over the years calendar years up to and
+// including now's
+func BuildFeesReport(db *database.DB, now time.Time, years int) (*FeesReport, error) {
+	if years <= 0 {
+		return nil, fmt.Errorf("years must be positive, got %d", years)
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	names := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		names[account.ID] = account.DisplayName()
+	}
+
+	last := now.In(format.Location()).Year()
+	feesReport := &FeesReport{}
+	yearIndex := make(map[int]int, years)
+	for year := last - years + 1; year <= last; year++ {
+		yearIndex[year] = len(feesReport.Years)
+		feesReport.Years = append(feesReport.Years, year)
+	}
+
+	for _, name := range []string{fees.InterestCategory, fees.FeesCategory} {
+		category, err := db.GetCategoryByName(name)
+		if err != nil {
+			// Nothing has been filed under it yet
+			continue
+		}
+
+		transactions, err := db.GetTransactions(database.TransactionFilter{
+			CategoryID: category.ID,
+			StartDate:  strconv.Itoa(feesReport.Years[0]) + "-01-01",
+			EndDate:    strconv.Itoa(last) + "-12-31",
+		}, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s transactions: %w", name, err)
+		}
+
+		byAccount := make(map[string]*FeeTotal)
+		for _, txn := range transactions {
+			posted, err := format.PostedTime(txn.Posted)
+			if err != nil {
+				continue
+			}
+			i, inRange := yearIndex[posted.Year()]
+			if !inRange {
+				continue
+			}
+			row, exists := byAccount[txn.AccountID]
+			if !exists {
+				row = &FeeTotal{
+					AccountID: txn.AccountID,
+					Account:   names[txn.AccountID],
+					Category:  category.Name,
+					Totals:    make([]int64, years),
+				}
+				byAccount[txn.AccountID] = row
+			}
+			row.Totals[i] -= txn.Amount
+		}
+		for _, row := range byAccount {
+			feesReport.Rows = append(feesReport.Rows, *row)
+		}
+	}
+
+	sort.Slice(feesReport.Rows, func(i, j int) bool {
+		a, b := feesReport.Rows[i], feesReport.Rows[j]
+		if a.Total() != b.Total() {
+			return a.Total() > b.Total()
+		}
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Category < b.Category
+	})
+	return feesReport, nil
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/fees"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestBuildFeesReport(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	for _, id := range []string{"card", "checking"} {
+		if err := db.SaveAccount(id, "org-1", id, "USD", 0, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+	}
+	interestID, err := db.SaveCategory(fees.InterestCategory)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	feesID, err := db.SaveCategory(fees.FeesCategory)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id         string
+		accountID  string
+		posted     string
+		amount     int64
+		categoryID int
+	}{
+		{"old-interest", "card", "2021-06-01T12:00:00Z", -9999, interestID}, // before the report
+		{"interest-1", "card", "2023-01-15T12:00:00Z", -4000, interestID},
+		{"interest-2", "card", "2024-02-15T12:00:00Z", -4500, interestID},
+		{"late-fee", "card", "2024-02-20T12:00:00Z", -3900, feesID},
+		{"atm-fee", "checking", "2022-03-01T12:00:00Z", -300, feesID},
+		{"atm-fee-2", "checking", "2024-03-01T12:00:00Z", -300, feesID},
+		{"atm-rebate", "checking", "2024-03-05T12:00:00Z", 300, feesID},
+		{"coffee", "checking", "2024-03-05T12:00:00Z", -450, 0},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, txn.accountID, txn.posted, txn.amount, "TXN", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	feesReport, err := BuildFeesReport(db, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatalf("Failed to build fees report: %v", err)
+	}
+	if len(feesReport.Years) != 3 || feesReport.Years[0] != 2022 || feesReport.Years[2] != 2024 {
+		t.Fatalf("Expected 2022 to 2024, got %v", feesReport.Years)
+	}
+
+	want := []struct {
+		accountID string
+		category  string
+		totals    []int64
+	}{
+		{"card", fees.InterestCategory, []int64{0, 4000, 4500}},
+		{"card", fees.FeesCategory, []int64{0, 0, 3900}},
+		{"checking", fees.FeesCategory, []int64{300, 0, 0}},
+	}
+	if len(feesReport.Rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %+v", len(want), feesReport.Rows)
+	}
+	for i, w := range want {
+		row := feesReport.Rows[i]
+		if row.AccountID != w.accountID || row.Category != w.category ||
+			row.Totals[0] != w.totals[0] || row.Totals[1] != w.totals[1] || row.Totals[2] != w.totals[2] {
+			t.Errorf("Row %d: expected %s %s %v, got %+v", i, w.accountID, w.category, w.totals, row)
+		}
+	}
+
+	if totals := feesReport.CategoryTotals(fees.FeesCategory); totals[0] != 300 || totals[2] != 3900 {
+		t.Errorf("Expected fees of 300 in 2022 and 3900 in 2024, got %v", totals)
+	}
+}
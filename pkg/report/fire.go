@@ -0,0 +1,192 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+const (
+	// DefaultFIREReturn is the yearly return, in percent after inflation,
+	// projections assume
+	DefaultFIREReturn = 5.0
+	// DefaultWithdrawalRate is the percent of net worth a year that can be
+	// spent without running out, the 4% rule
+	DefaultWithdrawalRate = 4.0
+	// DefaultFIREHistoryMonths is how many complete months savings and
+	// spending are averaged over
+	DefaultFIREHistoryMonths = 12
+	// defaultFIREYears is how far a projection that never reaches
+	// independence is drawn
+	defaultFIREYears = 30
+	// maxFIREYears is how far ahead independence is looked for
+	maxFIREYears = 100
+)
+
+// FIREOptions are the assumptions a projection is made under
+type FIREOptions struct {
+	ReturnRate     float64 // percent a year, after inflation
+	WithdrawalRate float64 // percent of net worth spent a year once independent
+	HistoryMonths  int     // complete months income and spending are averaged over
+	Years          int     // years to project, 0 for until independence
+}
+
+// FIREProjection is net worth projected forward at the current savings
+// rate until it can pay for the current spending indefinitely
+type FIREProjection struct {
+	Start           time.Time // first day of the month the projection starts in
+	NetWorth        int64     // cents, today
+	MonthlyIncome   int64     // cents, averaged over the history
+	MonthlyExpenses int64     // cents, averaged over the history
+	Target          int64     // net worth in cents whose withdrawals cover a year of spending
+	Options         FIREOptions
+	Yearly          []int64 // net worth in cents now and at the end of each year
+	Months          int     // months until independence, -1 when it's never reached
+}
+
+// MonthlySavings returns the average cents saved a month
+func (p *FIREProjection) MonthlySavings() int64 {
+	return p.MonthlyIncome - p.MonthlyExpenses
+}
+
+// SavingsRate returns the percent of income saved, or 0 without income
+func (p *FIREProjection) SavingsRate() float64 {
+	if p.MonthlyIncome <= 0 {
+		return 0
+	}
+	return float64(p.MonthlySavings()) / float64(p.MonthlyIncome) * 100
+}
+
+// IndependenceDate returns the month net worth reaches the target, and
+// false when it never does
+func (p *FIREProjection) IndependenceDate() (time.Time, bool) {
+	if p.Months < 0 {
+		return time.Time{}, false
+	}
+	return p.Start.AddDate(0, p.Months, 0), true
+}
+
+// ProjectFIRE projects net worth, the total of every account's balance,
+// from the month of now. Income and spending are averaged over the
+// complete months before it (see MonthlyCashFlow), and the difference is
+// saved every month. Savings and net worth grow at the return rate,
+// compounded monthly, until the withdrawal rate of net worth covers a year
+// of spending.
+func ProjectFIRE(db *database.DB, now time.Time, opts FIREOptions) (*FIREProjection, error) {
+	if opts.HistoryMonths <= 0 {
+		opts.HistoryMonths = DefaultFIREHistoryMonths
+	}
+	if opts.WithdrawalRate <= 0 {
+		return nil, fmt.Errorf("withdrawal rate must be positive, got %g", opts.WithdrawalRate)
+	}
+
+	income, expenses, err := MonthlyCashFlow(db, now, opts.HistoryMonths)
+	if err != nil {
+		return nil, err
+	}
+	if expenses == 0 {
+		return nil, fmt.Errorf("no spending in the last %d months to project from", opts.HistoryMonths)
+	}
+
+	netWorth, err := NetWorth(db)
+	if err != nil {
+		return nil, err
+	}
+
+	projection := &FIREProjection{
+		Start:           monthStart(now),
+		NetWorth:        netWorth,
+		MonthlyIncome:   income,
+		MonthlyExpenses: expenses,
+		Options:         opts,
+	}
+	projection.Target = int64(math.Round(float64(projection.MonthlyExpenses*12) / (opts.WithdrawalRate / 100)))
+	projection.Yearly, projection.Months = projectNetWorth(projection.NetWorth, projection.MonthlySavings(), opts.ReturnRate, projection.Target, opts.Years)
+	return projection, nil
+}
+
+// MonthlyCashFlow returns the average monthly income and spending, in
+// positive cents, over the months complete months before the month of now.
+// Internal categories are left out, like the budget does.
+func MonthlyCashFlow(db *database.DB, now time.Time, months int) (income, expenses int64, err error) {
+	if months <= 0 {
+		return 0, 0, fmt.Errorf("months must be positive, got %d", months)
+	}
+
+	start := monthStart(now)
+	first := start.AddDate(0, -months, 0)
+	byCategory, err := db.GetTransactionsByCategory(first.UTC().Format(time.RFC3339), start.UTC().Format(time.RFC3339), true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	for _, transactions := range byCategory {
+		for _, txn := range transactions {
+			if txn.Amount > 0 {
+				income += txn.Amount
+			} else {
+				expenses -= txn.Amount
+			}
+		}
+	}
+	return income / int64(months), expenses / int64(months), nil
+}
+
+// NetWorth returns the total of every account's balance in cents
+func NetWorth(db *database.DB) (int64, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	var total int64
+	for _, account := range accounts {
+		total += account.Balance
+	}
+	return total, nil
+}
+
+// monthStart returns midnight on the first of t's month in the configured
+// time zone
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, format.Location())
+}
+
+// projectNetWorth grows netWorth by monthlySavings a month and returnRate
+// percent a year, compounded monthly. It returns net worth now and at the
+// end of each of years years, or of each year until target is reached when
+// years is 0, and the months until target is reached, -1 if that's more
+// than maxFIREYears away.
+func projectNetWorth(netWorth, monthlySavings int64, returnRate float64, target int64, years int) ([]int64, int) {
+	monthlyReturn := math.Pow(1+returnRate/100, 1.0/12) - 1
+
+	reached := -1
+	value := float64(netWorth)
+	for month := 0; month <= maxFIREYears*12; month++ {
+		if value >= float64(target) {
+			reached = month
+			break
+		}
+		value = value*(1+monthlyReturn) + float64(monthlySavings)
+	}
+
+	if years <= 0 {
+		years = defaultFIREYears
+		if reached >= 0 {
+			years = max(1, (reached+11)/12)
+		}
+	}
+
+	yearly := []int64{netWorth}
+	value = float64(netWorth)
+	for month := 1; month <= years*12; month++ {
+		value = value*(1+monthlyReturn) + float64(monthlySavings)
+		if month%12 == 0 {
+			yearly = append(yearly, int64(math.Round(value)))
+		}
+	}
+	return yearly, reached
+}
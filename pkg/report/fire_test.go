@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestProjectNetWorth(t *testing.T) {
+	tests := []struct {
+		name           string
+		netWorth       int64
+		monthlySavings int64
+		returnRate     float64
+		target         int64
+		years          int
+		yearly         []int64
+		months         int
+	}{
+		{"savings only", 0, 1000, 0, 24000, 0, []int64{0, 12000, 24000}, 24},
+		{"partial year rounds up", 0, 1000, 0, 13000, 0, []int64{0, 12000, 24000}, 13},
+		{"growth only", 100000, 0, 7, 107000, 0, []int64{100000, 107000}, 12},
+		{"fixed horizon", 0, 1000, 0, 24000, 3, []int64{0, 12000, 24000, 36000}, 24},
+		{"already there", 50000, 1000, 0, 40000, 0, []int64{50000, 62000}, 0},
+		{"never", 0, -1000, 0, 40000, 2, []int64{0, -12000, -24000}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yearly, months := projectNetWorth(tt.netWorth, tt.monthlySavings, tt.returnRate, tt.target, tt.years)
+			if months != tt.months {
+				t.Errorf("months = %d; want %d", months, tt.months)
+			}
+			if fmt.Sprint(yearly) != fmt.Sprint(tt.yearly) {
+				t.Errorf("yearly = %v; want %v", yearly, tt.yearly)
+			}
+		})
+	}
+
+	// A projection that never gets there is drawn for defaultFIREYears
+	if yearly, _ := projectNetWorth(0, -1000, 0, 40000, 0); len(yearly) != defaultFIREYears+1 {
+		t.Errorf("Expected %d years, got %d", defaultFIREYears, len(yearly)-1)
+	}
+}
+
+func TestProjectFIRE(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("checking", "org-1", "Checking", "USD", 5000000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	if err := db.SaveAccount("card", "org-1", "Card", "USD", -1000000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	transfersID, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	// Earn $5,000 and spend $4,000 each month of the half year before May
+	for month := 11; month <= 16; month++ {
+		posted := time.Date(2023, time.Month(month), 10, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		if err := db.SaveTransaction(fmt.Sprintf("pay-%d", month), "checking", posted, 500000, "PAYROLL", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.SaveTransaction(fmt.Sprintf("rent-%d", month), "checking", posted, -400000, "RENT", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	// Card payments and this month's spending don't count
+	if err := db.SaveTransaction("card-payment", "checking", "2024-03-20T12:00:00Z", -300000, "CARD PAYMENT", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+	if err := db.UpdateTransactionCategory("card-payment", transfersID); err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+	if err := db.SaveTransaction("this-month", "checking", "2024-05-02T12:00:00Z", -900000, "TV", false); err != nil {
+		t.Fatalf("Failed to save transaction: %v", err)
+	}
+
+	projection, err := ProjectFIRE(db, time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC), FIREOptions{
+		ReturnRate:     0,
+		WithdrawalRate: 4,
+		HistoryMonths:  6,
+	})
+	if err != nil {
+		t.Fatalf("Failed to project: %v", err)
+	}
+
+	if projection.NetWorth != 4000000 || projection.MonthlyIncome != 500000 || projection.MonthlyExpenses != 400000 {
+		t.Errorf("Expected net worth 4000000, income 500000, expenses 400000, got %+v", projection)
+	}
+	if rate := projection.SavingsRate(); rate != 20 {
+		t.Errorf("Expected a 20%% savings rate, got %.1f", rate)
+	}
+	// $48,000 a year at 4% needs $1.2M: $1.16M more at $1,000 a month
+	if projection.Target != 120000000 || projection.Months != 1160 {
+		t.Errorf("Expected target 120000000 in 1160 months, got %d in %d", projection.Target, projection.Months)
+	}
+	if date, ok := projection.IndependenceDate(); !ok || date.Format("2006-01") != "2121-01" {
+		t.Errorf("Expected independence in 2121-01, got %v", date)
+	}
+	if len(projection.Yearly) != 98 {
+		t.Errorf("Expected 97 years projected, got %d", len(projection.Yearly)-1)
+	}
+
+	if _, err := ProjectFIRE(db, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), FIREOptions{WithdrawalRate: 4}); err == nil {
+		t.Error("Expected an error without any spending to project from")
+	}
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// HeatmapLevels is how many shades of spending a heatmap distinguishes,
+// besides days with none
+const HeatmapLevels = 4
+
+// DaySpending is the amount spent on a day, in cents
+type DaySpending struct {
+	Date   time.Time
+	Amount int64
+}
+
+// Heatmap is daily spending over whole weeks, starting on a Monday
+type Heatmap struct {
+	Start    time.Time // the Monday the first week starts on
+	End      time.Time // the last day with spending data, later days are blank
+	Category string    // the category spending was limited to, if any
+	Days     []int64   // cents spent each day from Start, seven per week
+
+	// thresholds split the days with spending into levels, so one big
+	// purchase doesn't wash out every other day
+	thresholds [HeatmapLevels - 1]int64
+}
+
+// BuildHeatmap adds up each day's spending for the weeks weeks ending with
+// the week of end, optionally limited to one category. Income, refunds, and
+// internal categories are left out.
+func BuildHeatmap(db *database.DB, end time.Time, weeks int, category string) (*Heatmap, error) {
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, format.Location())
+	monday := end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+	start := monday.AddDate(0, 0, -7*(weeks-1))
+
+	byCategory, err := db.GetTransactionsByCategory(start.Format("2006-01-02"), end.Format("2006-01-02"), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	heatmap := &Heatmap{
+		Start:    start,
+		End:      end,
+		Category: category,
+		Days:     make([]int64, 7*weeks),
+	}
+	for name, transactions := range byCategory {
+		if category != "" && name != category {
+			continue
+		}
+		for _, txn := range transactions {
+			if txn.Amount >= 0 {
+				continue
+			}
+			posted, err := time.ParseInLocation("2006-01-02", format.PostedDate(txn.Posted), format.Location())
+			if err != nil {
+				continue
+			}
+			if day := heatmap.dayIndex(posted); day >= 0 && day < len(heatmap.Days) {
+				heatmap.Days[day] += -txn.Amount
+			}
+		}
+	}
+
+	heatmap.setThresholds()
+	return heatmap, nil
+}
+
+// dayIndex returns how many days date is after Start, counting calendar
+// days so daylight saving changes don't shift it
+func (h *Heatmap) dayIndex(date time.Time) int {
+	start := time.Date(h.Start.Year(), h.Start.Month(), h.Start.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(start).Hours() / 24)
+}
+
+// setThresholds splits the days with spending into equal-sized levels
+func (h *Heatmap) setThresholds() {
+	var amounts []int64
+	for _, amount := range h.Days {
+		if amount > 0 {
+			amounts = append(amounts, amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i] < amounts[j] })
+	for i := range h.thresholds {
+		h.thresholds[i] = amounts[(i+1)*len(amounts)/HeatmapLevels]
+	}
+}
+
+// Weeks is how many weeks the heatmap spans
+func (h *Heatmap) Weeks() int {
+	return len(h.Days) / 7
+}
+
+// Date returns the date of a day in the heatmap
+func (h *Heatmap) Date(day int) time.Time {
+	return h.Start.AddDate(0, 0, day)
+}
+
+// InRange reports whether a day falls on or before End, so it has data
+func (h *Heatmap) InRange(day int) bool {
+	return !h.Date(day).After(h.End)
+}
+
+// Level returns how heavy a day's spending was, from 0 for none up to
+// HeatmapLevels
+func (h *Heatmap) Level(day int) int {
+	amount := h.Days[day]
+	if amount <= 0 {
+		return 0
+	}
+	level := 1
+	for _, threshold := range h.thresholds {
+		if amount >= threshold {
+			level++
+		}
+	}
+	return level
+}
+
+// Total returns the cents spent over the whole heatmap
+func (h *Heatmap) Total() int64 {
+	var total int64
+	for _, amount := range h.Days {
+		total += amount
+	}
+	return total
+}
+
+// WeekdayAverages returns the average spent on each weekday, Monday first,
+// over the days up to End
+func (h *Heatmap) WeekdayAverages() [7]int64 {
+	var totals [7]int64
+	var counts [7]int64
+	for day, amount := range h.Days {
+		if !h.InRange(day) {
+			continue
+		}
+		totals[day%7] += amount
+		counts[day%7]++
+	}
+
+	var averages [7]int64
+	for i := range averages {
+		if counts[i] > 0 {
+			averages[i] = totals[i] / counts[i]
+		}
+	}
+	return averages
+}
+
+// BusiestDays returns up to n days with the most spending, most first
+func (h *Heatmap) BusiestDays(n int) []DaySpending {
+	var days []DaySpending
+	for day, amount := range h.Days {
+		if amount > 0 {
+			days = append(days, DaySpending{Date: h.Date(day), Amount: amount})
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Amount > days[j].Amount
+	})
+	if len(days) > n {
+		days = days[:n]
+	}
+	return days
+}
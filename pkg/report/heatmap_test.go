@@ -0,0 +1,124 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestBuildHeatmap(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	transfersID, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id         string
+		posted     string
+		amount     int64
+		categoryID int
+	}{
+		{"before", "2024-01-07T12:00:00Z", -700, 0},
+		{"monday-1", "2024-01-08T09:00:00Z", -1000, 0},
+		{"monday-2", "2024-01-08T18:00:00Z", -500, 0},
+		{"paycheck", "2024-01-09T12:00:00Z", 5000, 0},
+		{"transfer", "2024-01-10T12:00:00Z", -9999, transfersID},
+		{"saturday", "2024-01-13T12:00:00Z", -8000, groceriesID},
+		{"sunday", "2024-01-14T12:00:00Z", -2000, 0},
+		{"tuesday", "2024-01-16T12:00:00Z", -300, 0},
+		{"after", "2024-01-18T12:00:00Z", -400, 0},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, txn.id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	// Wednesday of the second week
+	heatmap, err := BuildHeatmap(db, time.Date(2024, 1, 17, 15, 0, 0, 0, time.UTC), 2, "")
+	if err != nil {
+		t.Fatalf("Failed to build heatmap: %v", err)
+	}
+
+	if !heatmap.Start.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the heatmap to start on Monday Jan 8, got %s", heatmap.Start)
+	}
+	if heatmap.Weeks() != 2 {
+		t.Errorf("Expected 2 weeks, got %d", heatmap.Weeks())
+	}
+
+	// Income, internal categories, and days outside the weeks are left out
+	expected := map[int]int64{0: 1500, 5: 8000, 6: 2000, 8: 300}
+	for day, amount := range heatmap.Days {
+		if amount != expected[day] {
+			t.Errorf("Day %d: expected %d spent, got %d", day, expected[day], amount)
+		}
+	}
+	if heatmap.Total() != 11800 {
+		t.Errorf("Expected a total of 11800, got %d", heatmap.Total())
+	}
+
+	// Each day with spending lands in its own quarter
+	levels := map[int]int{0: 2, 1: 0, 5: 4, 6: 3, 8: 1}
+	for day, level := range levels {
+		if got := heatmap.Level(day); got != level {
+			t.Errorf("Day %d: expected level %d, got %d", day, level, got)
+		}
+	}
+
+	if !heatmap.InRange(9) || heatmap.InRange(10) {
+		t.Error("Expected days after Wednesday Jan 17 to be out of range")
+	}
+
+	averages := heatmap.WeekdayAverages()
+	expectedAverages := [7]int64{750, 150, 0, 0, 0, 8000, 2000}
+	if averages != expectedAverages {
+		t.Errorf("Expected weekday averages %v, got %v", expectedAverages, averages)
+	}
+
+	busiest := heatmap.BusiestDays(2)
+	if len(busiest) != 2 || busiest[0].Amount != 8000 || busiest[0].Date.Day() != 13 || busiest[1].Amount != 2000 {
+		t.Errorf("Expected Jan 13 and Jan 14 as the busiest days, got %+v", busiest)
+	}
+
+	groceries, err := BuildHeatmap(db, time.Date(2024, 1, 17, 15, 0, 0, 0, time.UTC), 2, "Groceries")
+	if err != nil {
+		t.Fatalf("Failed to build heatmap: %v", err)
+	}
+	if groceries.Total() != 8000 || groceries.Days[5] != 8000 {
+		t.Errorf("Expected only the Groceries purchase, got %v", groceries.Days)
+	}
+}
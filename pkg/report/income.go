@@ -0,0 +1,386 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// DefaultIncomeMonths is how many months, the current one included, an
+// income report covers by default
+const DefaultIncomeMonths = 6
+
+// paycheckLookbackMonths is the least history paychecks are detected in, so
+// a report of a month or two still recognizes them
+const paycheckLookbackMonths = 6
+
+const (
+	// minPaychecks is how many similar deposits from one payer make a pay
+	// schedule
+	minPaychecks = 3
+	// minPaycheckAmount is the smallest typical deposit, in cents, counted
+	// as a paycheck, so interest and cashback aren't
+	minPaycheckAmount = 20000
+	// paycheckTolerance is how far, as a fraction of the typical deposit,
+	// a paycheck can stray and still count toward the schedule
+	paycheckTolerance = 0.25
+	// minPayPeriodDays and maxPayPeriodDays bound the typical days between
+	// paychecks: weekly to monthly
+	minPayPeriodDays = 6
+	maxPayPeriodDays = 35
+)
+
+// IncomeKind is what a deposit is: earned income, money coming back, or
+// money moving between accounts
+type IncomeKind int
+
+const (
+	IncomePaycheck IncomeKind = iota
+	IncomeOther
+	IncomeRefund
+	IncomeTransfer
+)
+
+func (k IncomeKind) String() string {
+	switch k {
+	case IncomePaycheck:
+		return "Paycheck"
+	case IncomeRefund:
+		return "Refund"
+	case IncomeTransfer:
+		return "Transfer"
+	default:
+		return "Other income"
+	}
+}
+
+// IncomeSource is the deposits from one source: the payer for paychecks,
+// the category for everything else
+type IncomeSource struct {
+	Name   string
+	Kind   IncomeKind
+	Count  int
+	Totals []int64 // cents deposited each month of the report
+}
+
+// Total returns the cents deposited over the whole report
+func (s IncomeSource) Total() int64 {
+	var total int64
+	for _, amount := range s.Totals {
+		total += amount
+	}
+	return total
+}
+
+// IncomeReport is the money coming into accounts each month, by source
+type IncomeReport struct {
+	Months  []string       // YYYY-MM, oldest first; the last may be in progress
+	Sources []IncomeSource // by kind, paychecks first, then largest first
+}
+
+// KindTotals returns the cents deposited each month by sources of kind
+func (r *IncomeReport) KindTotals(kind IncomeKind) []int64 {
+	totals := make([]int64, len(r.Months))
+	for _, source := range r.Sources {
+		if source.Kind != kind {
+			continue
+		}
+		for i, amount := range source.Totals {
+			totals[i] += amount
+		}
+	}
+	return totals
+}
+
+// BuildIncomeReport totals the deposits of each of the months months up to
+// and including the month of end, by source. Deposits in internal
+// categories are transfers. A payer whose deposits recur like a paycheck
+// (see DetectPaychecks) is a paycheck source, unless its deposits were
+// filed under a spending category. Other deposits in a category that spent
+// more than it earned are refunds; the rest are other income.
+func BuildIncomeReport(db *database.DB, end time.Time, months int) (*IncomeReport, error) {
+	if months <= 0 {
+		months = DefaultIncomeMonths
+	}
+
+	first := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, format.Location()).AddDate(0, 1-months, 0)
+	next := first.AddDate(0, months, 0)
+	lookback := first
+	if months < paycheckLookbackMonths {
+		lookback = next.AddDate(0, -paycheckLookbackMonths, 0)
+	}
+
+	monthIndex := make(map[string]int, months)
+	incomeReport := &IncomeReport{Months: make([]string, months)}
+	for i := range incomeReport.Months {
+		incomeReport.Months[i] = first.AddDate(0, i, 0).Format("2006-01")
+		monthIndex[incomeReport.Months[i]] = i
+	}
+
+	byCategory, err := db.GetTransactionsByCategory(lookback.UTC().Format(time.RFC3339), next.UTC().Format(time.RFC3339), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	internal, err := internalCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	candidates, spending := paycheckCandidates(byCategory, internal)
+	paychecks := DetectPaychecks(candidates)
+
+	type sourceKey struct {
+		name string
+		kind IncomeKind
+	}
+	bySource := make(map[sourceKey]*IncomeSource)
+	for category, transactions := range byCategory {
+		for _, txn := range transactions {
+			i, ok := monthIndex[format.PostedMonth(txn.Posted)]
+			if !ok || txn.Amount <= 0 {
+				continue
+			}
+
+			key := sourceKey{category, IncomeOther}
+			switch payer := MerchantName(txn.DisplayDescription()); {
+			case internal[category]:
+				key.kind = IncomeTransfer
+			case spending[category]:
+				key.kind = IncomeRefund
+			case paychecks[payer]:
+				key = sourceKey{payer, IncomePaycheck}
+			}
+
+			source, ok := bySource[key]
+			if !ok {
+				source = &IncomeSource{Name: key.name, Kind: key.kind, Totals: make([]int64, months)}
+				bySource[key] = source
+			}
+			source.Count++
+			source.Totals[i] += txn.Amount
+		}
+	}
+
+	for _, source := range bySource {
+		incomeReport.Sources = append(incomeReport.Sources, *source)
+	}
+	sort.Slice(incomeReport.Sources, func(i, j int) bool {
+		a, b := incomeReport.Sources[i], incomeReport.Sources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Total() != b.Total() {
+			return a.Total() > b.Total()
+		}
+		return a.Name < b.Name
+	})
+	return incomeReport, nil
+}
+
+// internalCategories returns whether each category is internal, by name
+func internalCategories(db *database.DB) (map[string]bool, error) {
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	internal := make(map[string]bool)
+	for _, category := range categories {
+		internal[category.Name] = category.IsInternal
+	}
+	return internal, nil
+}
+
+// paycheckCandidates returns the deposits that could be paychecks, and the
+// spending categories: those that spent more than they earned, whose
+// deposits are refunds rather than income. Deposits in internal and
+// spending categories aren't candidates.
+func paycheckCandidates(byCategory map[string][]database.Transaction, internal map[string]bool) ([]database.Transaction, map[string]bool) {
+	spending := make(map[string]bool)
+	var candidates []database.Transaction
+	for category, transactions := range byCategory {
+		var net int64
+		for _, txn := range transactions {
+			net += txn.Amount
+		}
+		spending[category] = category != uncategorized && net < 0
+		if internal[category] || spending[category] {
+			continue
+		}
+		for _, txn := range transactions {
+			if txn.Amount > 0 {
+				candidates = append(candidates, txn)
+			}
+		}
+	}
+	return candidates, spending
+}
+
+// PaySchedule is how a payer's paychecks arrive
+type PaySchedule struct {
+	Payer      string
+	Amount     int64     // typical paycheck in cents
+	PeriodDays int       // typical days between paychecks
+	Last       time.Time // when the latest paycheck was posted
+}
+
+// Monthly reports whether the payer pays once a month, on about the same
+// day, rather than every PeriodDays days
+func (s PaySchedule) Monthly() bool {
+	return s.PeriodDays >= 28
+}
+
+// Next returns the paydays the schedule expects after after, up to and
+// including until. Monthly pay falls on the day of the month of the latest
+// paycheck, or the last day of shorter months.
+func (s PaySchedule) Next(after, until time.Time) []time.Time {
+	last := time.Date(s.Last.Year(), s.Last.Month(), s.Last.Day(), 0, 0, 0, 0, format.Location())
+	var paydays []time.Time
+	for i := 1; ; i++ {
+		payday := last.AddDate(0, 0, i*s.PeriodDays)
+		if s.Monthly() {
+			monthStart := time.Date(last.Year(), last.Month()+time.Month(i), 1, 0, 0, 0, 0, last.Location())
+			day := last.Day()
+			if lastDay := monthStart.AddDate(0, 1, -1).Day(); day > lastDay {
+				day = lastDay
+			}
+			payday = monthStart.AddDate(0, 0, day-1)
+		}
+		if payday.After(until) {
+			return paydays
+		}
+		if payday.After(after) {
+			paydays = append(paydays, payday)
+		}
+	}
+}
+
+// DetectPaychecks returns the payers, by MerchantName, whose deposits look
+// like paychecks (see DetectPaySchedules)
+func DetectPaychecks(deposits []database.Transaction) map[string]bool {
+	paychecks := make(map[string]bool)
+	for _, schedule := range DetectPaySchedules(deposits) {
+		paychecks[schedule.Payer] = true
+	}
+	return paychecks
+}
+
+// DetectPaySchedules returns the schedules, by payer, of deposits that look
+// like paychecks: at least minPaychecks deposits from a payer, by
+// MerchantName, within paycheckTolerance of their typical amount, which is
+// at least minPaycheckAmount, arriving typically every minPayPeriodDays to
+// maxPayPeriodDays days. Deposits that stray further, like bonuses, don't
+// stop a payer counting.
+func DetectPaySchedules(deposits []database.Transaction) []PaySchedule {
+	byPayer := make(map[string][]database.Transaction)
+	for _, txn := range deposits {
+		if txn.Amount > 0 {
+			payer := MerchantName(txn.DisplayDescription())
+			byPayer[payer] = append(byPayer[payer], txn)
+		}
+	}
+
+	var schedules []PaySchedule
+	for payer, transactions := range byPayer {
+		if len(transactions) < minPaychecks {
+			continue
+		}
+
+		amounts := make([]int64, len(transactions))
+		for i, txn := range transactions {
+			amounts[i] = txn.Amount
+		}
+		typical := medianOf(amounts)
+		if typical < minPaycheckAmount {
+			continue
+		}
+
+		var days []time.Time
+		for _, txn := range transactions {
+			if math.Abs(float64(txn.Amount-typical)) > paycheckTolerance*float64(typical) {
+				continue
+			}
+			if posted, err := format.PostedTime(txn.Posted); err == nil {
+				days = append(days, posted)
+			}
+		}
+		if len(days) < minPaychecks {
+			continue
+		}
+
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+		gaps := make([]int64, len(days)-1)
+		for i := range gaps {
+			gaps[i] = int64(math.Round(days[i+1].Sub(days[i]).Hours() / 24))
+		}
+		if period := medianOf(gaps); period >= minPayPeriodDays && period <= maxPayPeriodDays {
+			schedules = append(schedules, PaySchedule{
+				Payer:      payer,
+				Amount:     typical,
+				PeriodDays: int(period),
+				Last:       days[len(days)-1],
+			})
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Payer < schedules[j].Payer })
+	return schedules
+}
+
+// CurrentPaySchedules returns the pay schedules of the paychecks deposited
+// in the paycheckLookbackMonths before now, leaving out payers that have
+// missed two paydays in a row, who've likely stopped paying
+func CurrentPaySchedules(db *database.DB, now time.Time) ([]PaySchedule, error) {
+	from := now.AddDate(0, -paycheckLookbackMonths, 0)
+	byCategory, err := db.GetTransactionsByCategory(from.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	internal, err := internalCategories(db)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, _ := paycheckCandidates(byCategory, internal)
+	var current []PaySchedule
+	for _, schedule := range DetectPaySchedules(candidates) {
+		if len(schedule.Next(schedule.Last, now)) < 2 {
+			current = append(current, schedule)
+		}
+	}
+	return current, nil
+}
+
+// AverageIncome returns each category's average monthly income in cents
+// over the months complete months before the month of month, counted the
+// way money budget counts income: deposits outside internal categories.
+// Months without deposits count toward the average, so a slow month pulls
+// it down.
+func AverageIncome(db *database.DB, month time.Time, months int) (map[string]int64, error) {
+	if months <= 0 {
+		return nil, fmt.Errorf("months must be positive, got %d", months)
+	}
+
+	last := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, format.Location())
+	first := last.AddDate(0, -months, 0)
+	byCategory, err := db.GetTransactionsByCategory(first.UTC().Format(time.RFC3339), last.UTC().Format(time.RFC3339), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	averages := make(map[string]int64)
+	for category, transactions := range byCategory {
+		var total int64
+		for _, txn := range transactions {
+			if txn.Amount > 0 {
+				total += txn.Amount
+			}
+		}
+		if total > 0 {
+			averages[category] = total / int64(months)
+		}
+	}
+	return averages, nil
+}
@@ -0,0 +1,246 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestDetectPaychecks(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	start := time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)
+	deposit := func(day int, amount int64, description string) database.Transaction {
+		posted := start.AddDate(0, 0, day).Format(time.RFC3339)
+		return database.Transaction{ID: fmt.Sprintf("%s-%d", description, day), Posted: posted, Amount: amount, Description: description}
+	}
+
+	var deposits []database.Transaction
+	for i := 0; i < 6; i++ {
+		// Biweekly pay, with a bonus in the middle
+		amount := int64(250000 + i*1000)
+		if i == 3 {
+			amount = 900000
+		}
+		deposits = append(deposits, deposit(i*14, amount, "ACME CORP PAYROLL PPD"))
+		// Monthly interest, too small to be pay
+		deposits = append(deposits, deposit(i*30, 350, "INTEREST PAYMENT"))
+	}
+	// Large deposits, but only two of them
+	deposits = append(deposits, deposit(10, 120000, "VENMO CASHOUT"), deposit(40, 120000, "VENMO CASHOUT"))
+	// Similar large deposits, but years apart
+	deposits = append(deposits, deposit(0, 500000, "TAX REFUND"), deposit(365, 500000, "TAX REFUND"), deposit(730, 500000, "TAX REFUND"))
+
+	paychecks := DetectPaychecks(deposits)
+	if len(paychecks) != 1 || !paychecks["Acme Corp Payroll"] {
+		t.Errorf("Expected only Acme Corp Payroll, got %v", paychecks)
+	}
+
+	schedules := DetectPaySchedules(deposits)
+	want := PaySchedule{Payer: "Acme Corp Payroll", Amount: 253000, PeriodDays: 14, Last: start.AddDate(0, 0, 70)}
+	if len(schedules) != 1 || schedules[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, schedules)
+	}
+}
+
+func TestPayScheduleNext(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	days := func(paydays []time.Time) string {
+		var formatted []string
+		for _, payday := range paydays {
+			formatted = append(formatted, payday.Format("2006-01-02"))
+		}
+		return fmt.Sprint(formatted)
+	}
+
+	biweekly := PaySchedule{PeriodDays: 14, Last: day(time.March, 15).Add(9 * time.Hour)}
+	if got := days(biweekly.Next(day(time.March, 20), day(time.April, 26))); got != "[2024-03-29 2024-04-12 2024-04-26]" {
+		t.Errorf("Unexpected biweekly paydays %s", got)
+	}
+
+	// Monthly pay on the 31st falls on the last day of shorter months
+	monthly := PaySchedule{PeriodDays: 30, Last: day(time.January, 31)}
+	if got := days(monthly.Next(monthly.Last, day(time.April, 29))); got != "[2024-02-29 2024-03-31]" {
+		t.Errorf("Unexpected monthly paydays %s", got)
+	}
+}
+
+func TestBuildIncomeReport(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	incomeID, err := db.SaveCategory("Income")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	shoppingID, err := db.SaveCategory("Shopping")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	transfersID, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	type txn struct {
+		id          string
+		posted      string
+		amount      int64
+		description string
+		categoryID  int
+	}
+	var transactions []txn
+	// Paid on the 1st and 15th since January, filed under Income until March
+	for month := 1; month <= 4; month++ {
+		for _, day := range []int{1, 15} {
+			categoryID := incomeID
+			if month == 4 {
+				categoryID = 0
+			}
+			transactions = append(transactions, txn{
+				fmt.Sprintf("pay-%d-%d", month, day), fmt.Sprintf("2024-%02d-%02dT12:00:00Z", month, day),
+				300000, "ACME CORP PAYROLL PPD", categoryID,
+			})
+		}
+	}
+	transactions = append(transactions,
+		txn{"purchase", "2024-03-03T12:00:00Z", -20000, "TARGET 00012345", shoppingID},
+		txn{"return", "2024-03-10T12:00:00Z", 5000, "TARGET 00012345", shoppingID},
+		txn{"savings", "2024-04-02T12:00:00Z", 100000, "TRANSFER FROM SAVINGS", transfersID},
+		txn{"gift", "2024-04-20T12:00:00Z", 15000, "ZELLE FROM MOM", 0},
+	)
+	for _, tt := range transactions {
+		if err := db.SaveTransaction(tt.id, "acc-1", tt.posted, tt.amount, tt.description, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if tt.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(tt.id, tt.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	// Two months still see the paychecks before them
+	incomeReport, err := BuildIncomeReport(db, time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC), 2)
+	if err != nil {
+		t.Fatalf("Failed to build income report: %v", err)
+	}
+	if len(incomeReport.Months) != 2 || incomeReport.Months[0] != "2024-03" || incomeReport.Months[1] != "2024-04" {
+		t.Fatalf("Expected March and April, got %v", incomeReport.Months)
+	}
+
+	want := []struct {
+		name   string
+		kind   IncomeKind
+		count  int
+		totals []int64
+	}{
+		{"Acme Corp Payroll", IncomePaycheck, 4, []int64{600000, 600000}},
+		{"Uncategorized", IncomeOther, 1, []int64{0, 15000}},
+		{"Shopping", IncomeRefund, 1, []int64{5000, 0}},
+		{"Transfers", IncomeTransfer, 1, []int64{0, 100000}},
+	}
+	if len(incomeReport.Sources) != len(want) {
+		t.Fatalf("Expected %d sources, got %+v", len(want), incomeReport.Sources)
+	}
+	for i, w := range want {
+		source := incomeReport.Sources[i]
+		if source.Name != w.name || source.Kind != w.kind || source.Count != w.count ||
+			source.Totals[0] != w.totals[0] || source.Totals[1] != w.totals[1] {
+			t.Errorf("Source %d: expected %s %s x%d %v, got %+v", i, w.name, w.kind, w.count, w.totals, source)
+		}
+	}
+
+	if totals := incomeReport.KindTotals(IncomePaycheck); totals[0] != 600000 || totals[1] != 600000 {
+		t.Errorf("Expected 600000 in paychecks each month, got %v", totals)
+	}
+}
+
+func TestAverageIncome(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	clientsID, err := db.SaveCategory("Client Work")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	transfersID, err := db.SaveCategoryWithInternal("Transfers", true)
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id         string
+		posted     string
+		amount     int64
+		categoryID int
+	}{
+		{"dec", "2023-12-20T12:00:00Z", 900000, clientsID}, // before the window
+		{"jan", "2024-01-10T12:00:00Z", 600000, clientsID},
+		{"feb-refund", "2024-02-12T12:00:00Z", 3000, 0},
+		{"mar", "2024-03-05T12:00:00Z", 300000, clientsID},
+		{"mar-fee", "2024-03-06T12:00:00Z", -2500, clientsID},
+		{"mar-savings", "2024-03-07T12:00:00Z", 500000, transfersID},
+		{"apr", "2024-04-02T12:00:00Z", 100000, clientsID}, // the month itself
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, "DEPOSIT", false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.categoryID != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	averages, err := AverageIncome(db, time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatalf("Failed to average income: %v", err)
+	}
+	if len(averages) != 2 || averages["Client Work"] != 300000 || averages["Uncategorized"] != 1000 {
+		t.Errorf("Expected Client Work 300000 and Uncategorized 1000, got %v", averages)
+	}
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// processorPrefixes are the payment processor and card network markers
+// banks put in front of the merchant's name
+var processorPrefixes = []string{
+	"SQ *", "SQ*", "TST* ", "TST*", "SP * ", "SP *", "PAYPAL *", "PP*",
+	"POS DEBIT ", "POS ", "DEBIT CARD PURCHASE ", "CHECKCARD ", "PURCHASE ",
+}
+
+// achCodes are the ACH entry class codes banks append to direct deposits
+// and debits, like "ACME CORP PAYROLL PPD"
+var achCodes = map[string]bool{"PPD": true, "CCD": true, "WEB": true, "ACH": true}
+
+// usStates are the state codes banks append after the city
+var usStates = map[string]bool{
+	"AL": true, "AK": true, "AZ": true, "AR": true, "CA": true, "CO": true, "CT": true,
+	"DE": true, "DC": true, "FL": true, "GA": true, "HI": true, "ID": true, "IL": true,
+	"IN": true, "IA": true, "KS": true, "KY": true, "LA": true, "ME": true, "MD": true,
+	"MA": true, "MI": true, "MN": true, "MS": true, "MO": true, "MT": true, "NE": true,
+	"NV": true, "NH": true, "NJ": true, "NM": true, "NY": true, "NC": true, "ND": true,
+	"OH": true, "OK": true, "OR": true, "PA": true, "RI": true, "SC": true, "SD": true,
+	"TN": true, "TX": true, "UT": true, "VT": true, "VA": true, "WA": true, "WV": true,
+	"WI": true, "WY": true,
+}
+
+// MerchantName reduces a transaction description to the merchant it was
+// paid to, so "SQ *BLUE BOTTLE #0123 OAKLAND CA" and "SQ *BLUE BOTTLE
+// #0456" both become "Blue Bottle Oakland" and "Blue Bottle". Processor
+// prefixes, store and reference numbers, and a trailing ACH code or state
+// code are dropped; what's left is title-cased.
+func MerchantName(description string) string {
+	name := strings.ToUpper(strings.TrimSpace(description))
+	for _, prefix := range processorPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimSpace(name[len(prefix):])
+			break
+		}
+	}
+	// Amazon and others append an order reference after a '*'
+	name, _, _ = strings.Cut(name, "*")
+
+	var words []string
+	for _, word := range strings.Fields(name) {
+		if strings.HasPrefix(word, "#") || digitCount(word) >= 3 {
+			continue
+		}
+		words = append(words, word)
+	}
+	if len(words) > 1 && achCodes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) > 1 && usStates[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return strings.TrimSpace(description)
+	}
+
+	for i, word := range words {
+		words[i] = titleCase(word)
+	}
+	return strings.Join(words, " ")
+}
+
+func digitCount(s string) int {
+	count := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// titleCase upper-cases the first letter of word and lower-cases the rest
+func titleCase(word string) string {
+	runes := []rune(strings.ToLower(word))
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+			break
+		}
+	}
+	return string(runes)
+}
+
+// MerchantTotal is the transactions with one merchant added up
+type MerchantTotal struct {
+	Merchant string
+	Count    int
+	Total    int64  // cents, negative when more was spent than refunded
+	Example  string // raw bank description of the first transaction
+}
+
+// MerchantTotals groups transactions by MerchantName of their display
+// description, largest total (by size) first
+func MerchantTotals(transactions []database.Transaction) []MerchantTotal {
+	byMerchant := make(map[string]*MerchantTotal)
+	var totals []*MerchantTotal
+	for _, txn := range transactions {
+		merchant := MerchantName(txn.DisplayDescription())
+		total, ok := byMerchant[merchant]
+		if !ok {
+			total = &MerchantTotal{Merchant: merchant, Example: txn.Description}
+			byMerchant[merchant] = total
+			totals = append(totals, total)
+		}
+		total.Count++
+		total.Total += txn.Amount
+	}
+
+	merchants := make([]MerchantTotal, len(totals))
+	for i, total := range totals {
+		merchants[i] = *total
+	}
+	sort.SliceStable(merchants, func(i, j int) bool {
+		if a, b := abs(merchants[i].Total), abs(merchants[j].Total); a != b {
+			return a > b
+		}
+		return merchants[i].Merchant < merchants[j].Merchant
+	})
+	return merchants
+}
@@ -0,0 +1,116 @@
+package report
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultVolatility is the standard deviation of yearly returns, in
+	// percent, simulations assume: roughly a stock-heavy portfolio's
+	DefaultVolatility = 15.0
+	// DefaultSimulationRuns is how many trajectories a simulation draws
+	DefaultSimulationRuns = 1000
+	// DefaultSimulationYears is how far a simulation runs
+	DefaultSimulationYears = 30
+)
+
+// SimulationPercentiles are the outcomes a simulation reports, worst first
+var SimulationPercentiles = []float64{10, 25, 50, 75, 90}
+
+// SimulationOptions are the assumptions a simulation is run under
+type SimulationOptions struct {
+	ReturnRate         float64 // expected return, percent a year after inflation
+	Volatility         float64 // standard deviation of yearly returns, percent
+	Contribution       int64   // cents added each month, negative for withdrawals
+	ContributionGrowth float64 // percent contributions grow each year
+	Runs               int
+	Years              int
+	Seed               uint64 // the same seed draws the same trajectories
+}
+
+// Simulation is the spread of net worth outcomes over many trajectories
+// with random monthly returns
+type Simulation struct {
+	Start    time.Time // first day of the month the simulation starts in
+	NetWorth int64     // cents, today
+	Options  SimulationOptions
+	// Yearly holds net worth in cents at each of SimulationPercentiles, now
+	// and at the end of each year
+	Yearly [][]int64
+}
+
+// At returns net worth in cents at each of SimulationPercentiles after
+// years years
+func (s *Simulation) At(years int) []int64 {
+	return s.Yearly[years]
+}
+
+// Simulate draws opts.Runs trajectories of netWorth from the month of now.
+// Each month, net worth grows by a return drawn from a normal distribution
+// with the expected return and volatility scaled to a month, and the
+// contribution is added; contributions grow by ContributionGrowth at the
+// end of each year.
+func Simulate(netWorth int64, now time.Time, opts SimulationOptions) *Simulation {
+	if opts.Runs <= 0 {
+		opts.Runs = DefaultSimulationRuns
+	}
+	if opts.Years <= 0 {
+		opts.Years = DefaultSimulationYears
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+	monthlyReturn := math.Pow(1+opts.ReturnRate/100, 1.0/12) - 1
+	monthlyVolatility := opts.Volatility / 100 / math.Sqrt(12)
+
+	// outcomes[year][run] is a run's net worth at the end of year
+	outcomes := make([][]float64, opts.Years+1)
+	for year := range outcomes {
+		outcomes[year] = make([]float64, opts.Runs)
+	}
+	for run := 0; run < opts.Runs; run++ {
+		value := float64(netWorth)
+		contribution := float64(opts.Contribution)
+		outcomes[0][run] = value
+		for month := 1; month <= opts.Years*12; month++ {
+			growth := monthlyReturn
+			if monthlyVolatility > 0 {
+				growth += rng.NormFloat64() * monthlyVolatility
+			}
+			value = value*(1+growth) + contribution
+			if month%12 == 0 {
+				outcomes[month/12][run] = value
+				contribution *= 1 + opts.ContributionGrowth/100
+			}
+		}
+	}
+
+	simulation := &Simulation{
+		Start:    monthStart(now),
+		NetWorth: netWorth,
+		Options:  opts,
+		Yearly:   make([][]int64, len(outcomes)),
+	}
+	for year, values := range outcomes {
+		sort.Float64s(values)
+		simulation.Yearly[year] = make([]int64, len(SimulationPercentiles))
+		for i, p := range SimulationPercentiles {
+			simulation.Yearly[year][i] = int64(math.Round(percentile(values, p)))
+		}
+	}
+	return simulation
+}
+
+// percentile returns the pth percentile of sorted values, interpolating
+// between the two nearest
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := min(lower+1, len(sorted)-1)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulateWithoutVolatility(t *testing.T) {
+	now := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	simulation := Simulate(1000000, now, SimulationOptions{
+		ReturnRate:   5,
+		Contribution: 50000,
+		Runs:         10,
+		Years:        10,
+	})
+
+	// Every run takes the same path as the deterministic projection
+	want, _ := projectNetWorth(1000000, 50000, 5, 0, 10)
+	if len(simulation.Yearly) != len(want) {
+		t.Fatalf("Expected %d years, got %d", len(want)-1, len(simulation.Yearly)-1)
+	}
+	for year, values := range simulation.Yearly {
+		for i, value := range values {
+			if value != want[year] {
+				t.Errorf("Year %d, percentile %g: expected %d, got %d", year, SimulationPercentiles[i], want[year], value)
+			}
+		}
+	}
+}
+
+func TestSimulateSpread(t *testing.T) {
+	now := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	opts := SimulationOptions{
+		ReturnRate: 5,
+		Volatility: 15,
+		Runs:       2000,
+		Years:      20,
+		Seed:       42,
+	}
+	simulation := Simulate(10000000, now, opts)
+
+	for year := 1; year <= opts.Years; year++ {
+		values := simulation.At(year)
+		for i := 1; i < len(values); i++ {
+			if values[i] < values[i-1] {
+				t.Fatalf("Year %d: percentiles out of order: %v", year, values)
+			}
+		}
+	}
+
+	// The median lands near the expected growth, with a wide spread around it
+	expected := 10000000 * 2.6533 // 1.05^20
+	median := float64(simulation.At(20)[2])
+	if median < expected*0.7 || median > expected*1.1 {
+		t.Errorf("Expected a median near %.0f, got %.0f", expected, median)
+	}
+	if p10, p90 := simulation.At(20)[0], simulation.At(20)[4]; float64(p90) < 2*float64(p10) {
+		t.Errorf("Expected a wide spread at 20 years, got %d to %d", p10, p90)
+	}
+
+	// The same seed draws the same outcomes
+	again := Simulate(10000000, now, opts)
+	if again.At(20)[2] != simulation.At(20)[2] {
+		t.Errorf("Expected the same median from the same seed, got %d and %d", simulation.At(20)[2], again.At(20)[2])
+	}
+}
+
+func TestSimulateContributionGrowth(t *testing.T) {
+	now := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	simulation := Simulate(0, now, SimulationOptions{
+		Contribution:       1000,
+		ContributionGrowth: 10,
+		Runs:               1,
+		Years:              2,
+	})
+	// $10 a month, then $11 a month in the second year
+	if got := simulation.At(2)[2]; got != 12000+13200 {
+		t.Errorf("Expected 25200 after two years, got %d", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{10, 20, 30, 40, 50}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 10},
+		{50, 30},
+		{90, 46},
+		{100, 50},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(%g) = %g; want %g", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// TaxLine is a line on a tax form that categories can be mapped to
+type TaxLine struct {
+	Key    string // e.g. c:supplies
+	Form   string
+	Line   string // line number on the form
+	Name   string
+	Income bool // income lines total deposits, the rest total expenses
+}
+
+// TaxLines are the Schedule A (itemized deductions) and Schedule C (sole
+// proprietor business) lines categories can be mapped to
+var TaxLines = []TaxLine{
+	{Key: "a:medical", Form: "Schedule A", Line: "1", Name: "Medical and dental expenses"},
+	{Key: "a:taxes", Form: "Schedule A", Line: "5", Name: "State and local taxes"},
+	{Key: "a:mortgage-interest", Form: "Schedule A", Line: "8a", Name: "Home mortgage interest"},
+	{Key: "a:charity-cash", Form: "Schedule A", Line: "11", Name: "Gifts to charity by cash or check"},
+	{Key: "a:charity-noncash", Form: "Schedule A", Line: "12", Name: "Gifts to charity other than by cash or check"},
+	{Key: "a:casualty", Form: "Schedule A", Line: "15", Name: "Casualty and theft losses"},
+	{Key: "a:other", Form: "Schedule A", Line: "16", Name: "Other itemized deductions"},
+	{Key: "c:gross-receipts", Form: "Schedule C", Line: "1", Name: "Gross receipts or sales", Income: true},
+	{Key: "c:advertising", Form: "Schedule C", Line: "8", Name: "Advertising"},
+	{Key: "c:car", Form: "Schedule C", Line: "9", Name: "Car and truck expenses"},
+	{Key: "c:commissions", Form: "Schedule C", Line: "10", Name: "Commissions and fees"},
+	{Key: "c:contract-labor", Form: "Schedule C", Line: "11", Name: "Contract labor"},
+	{Key: "c:insurance", Form: "Schedule C", Line: "15", Name: "Insurance (other than health)"},
+	{Key: "c:interest", Form: "Schedule C", Line: "16b", Name: "Interest (other)"},
+	{Key: "c:legal", Form: "Schedule C", Line: "17", Name: "Legal and professional services"},
+	{Key: "c:office", Form: "Schedule C", Line: "18", Name: "Office expense"},
+	{Key: "c:rent", Form: "Schedule C", Line: "20b", Name: "Rent or lease of other business property"},
+	{Key: "c:repairs", Form: "Schedule C", Line: "21", Name: "Repairs and maintenance"},
+	{Key: "c:supplies", Form: "Schedule C", Line: "22", Name: "Supplies"},
+	{Key: "c:taxes-licenses", Form: "Schedule C", Line: "23", Name: "Taxes and licenses"},
+	{Key: "c:travel", Form: "Schedule C", Line: "24a", Name: "Travel"},
+	{Key: "c:meals", Form: "Schedule C", Line: "24b", Name: "Deductible meals"},
+	{Key: "c:utilities", Form: "Schedule C", Line: "25", Name: "Utilities"},
+	{Key: "c:wages", Form: "Schedule C", Line: "26", Name: "Wages"},
+	{Key: "c:other", Form: "Schedule C", Line: "27a", Name: "Other expenses"},
+}
+
+// LookupTaxLine returns the tax line with key, or false when there's none
+func LookupTaxLine(key string) (TaxLine, bool) {
+	for _, line := range TaxLines {
+		if line.Key == key {
+			return line, true
+		}
+	}
+	return TaxLine{}, false
+}
+
+// TaxLineKeys returns the keys of every tax line, for completion and errors
+func TaxLineKeys() []string {
+	keys := make([]string, len(TaxLines))
+	for i, line := range TaxLines {
+		keys[i] = line.Key
+	}
+	return keys
+}
+
+// TaxLineTotal is what a tax line adds up to over a year, broken down by the
+// categories mapped to it
+type TaxLineTotal struct {
+	Line       TaxLine
+	Categories []CategoryTotal
+	Total      int64
+}
+
+// TaxTransaction is a transaction counted towards a tax line
+type TaxTransaction struct {
+	Transaction database.Transaction
+	Category    string
+	Account     string
+	Line        TaxLine
+}
+
+// TaxReport totals a year's transactions in categories mapped to tax lines
+type TaxReport struct {
+	Year         int
+	Lines        []TaxLineTotal // in TaxLines order, only lines with transactions
+	Transactions []TaxTransaction
+}
+
+// BuildTaxReport totals the posted transactions of year by tax line.
+// Expense lines add up money spent, less refunds; income lines add up
+// deposits, less reversals. Mapped categories count even when they're
+// internal.
+func BuildTaxReport(db *database.DB, year int) (*TaxReport, error) {
+	mappings, err := db.GetTaxMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := fmt.Sprintf("%04d-01-01", year)
+	endDate := fmt.Sprintf("%04d-12-31", year)
+	byCategory, err := db.GetTransactionsByCategory(startDate, endDate, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	accountNames := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		accountNames[account.ID] = account.DisplayName()
+	}
+
+	lineOf := make(map[string]TaxLine, len(mappings))
+	for _, mapping := range mappings {
+		if line, ok := LookupTaxLine(mapping.TaxLine); ok {
+			lineOf[mapping.CategoryName] = line
+		}
+	}
+
+	report := &TaxReport{Year: year}
+	totals := make(map[string]*TaxLineTotal)
+	for category, transactions := range byCategory {
+		line, mapped := lineOf[category]
+		if !mapped {
+			continue
+		}
+
+		var sum int64
+		for _, txn := range transactions {
+			if txn.Pending {
+				continue
+			}
+			sum += txn.Amount
+			report.Transactions = append(report.Transactions, TaxTransaction{
+				Transaction: txn,
+				Category:    category,
+				Account:     accountNames[txn.AccountID],
+				Line:        line,
+			})
+		}
+		if !line.Income {
+			sum = -sum
+		}
+
+		total, ok := totals[line.Key]
+		if !ok {
+			total = &TaxLineTotal{Line: line}
+			totals[line.Key] = total
+		}
+		total.Categories = append(total.Categories, CategoryTotal{Category: category, Amount: sum})
+		total.Total += sum
+	}
+
+	for _, line := range TaxLines {
+		total, ok := totals[line.Key]
+		if !ok {
+			continue
+		}
+		sort.Slice(total.Categories, func(i, j int) bool {
+			return total.Categories[i].Category < total.Categories[j].Category
+		})
+		report.Lines = append(report.Lines, *total)
+	}
+
+	order := make(map[string]int, len(TaxLines))
+	for i, line := range TaxLines {
+		order[line.Key] = i
+	}
+	sort.SliceStable(report.Transactions, func(i, j int) bool {
+		a, b := report.Transactions[i], report.Transactions[j]
+		if a.Line.Key != b.Line.Key {
+			return order[a.Line.Key] < order[b.Line.Key]
+		}
+		return a.Transaction.Posted < b.Transaction.Posted
+	})
+
+	return report, nil
+}
+
+// WriteCSV writes one row per transaction counted towards a tax line, with
+// amounts as plain decimals so spreadsheets can sum them. Expenses are
+// positive on expense lines, as they're deducted.
+func (r *TaxReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"Form", "Line", "Tax Line", "Date", "Description", "Category", "Account", "Amount"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, tt := range r.Transactions {
+		amount := tt.Transaction.Amount
+		if !tt.Line.Income {
+			amount = -amount
+		}
+		record := []string{
+			tt.Line.Form,
+			tt.Line.Line,
+			tt.Line.Name,
+			postedDate(tt.Transaction.Posted),
+			tt.Transaction.DisplayDescription(),
+			tt.Category,
+			tt.Account,
+			decimal(amount),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// decimal formats cents as a plain decimal like -1234.50
+func decimal(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return sign + strconv.FormatInt(cents/100, 10) + "." + fmt.Sprintf("%02d", cents%100)
+}
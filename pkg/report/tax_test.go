@@ -0,0 +1,126 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestBuildTaxReport(t *testing.T) {
+	// The year is split at midnight UTC, putting red-cross in 2024
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+
+	categories := make(map[string]int)
+	for _, name := range []string{"Supplies", "Software", "Donations", "Consulting", "Groceries"} {
+		id, err := db.SaveCategory(name)
+		if err != nil {
+			t.Fatalf("Failed to save category: %v", err)
+		}
+		categories[name] = id
+	}
+	for name, line := range map[string]string{
+		"Supplies":   "c:supplies",
+		"Software":   "c:supplies",
+		"Donations":  "a:charity-cash",
+		"Consulting": "c:gross-receipts",
+	} {
+		if err := db.SetTaxLine(categories[name], line); err != nil {
+			t.Fatalf("Failed to set tax line: %v", err)
+		}
+	}
+
+	transactions := []struct {
+		id       string
+		posted   string
+		amount   int64
+		pending  bool
+		category string
+	}{
+		{"paper", "2024-03-01T12:00:00Z", -5000, false, "Supplies"},
+		{"paper-refund", "2024-03-05T12:00:00Z", 1000, false, "Supplies"},
+		{"editor", "2024-06-01T12:00:00Z", -12000, false, "Software"},
+		{"red-cross", "2024-12-31T20:00:00Z", -25000, false, "Donations"},
+		{"client", "2024-04-01T12:00:00Z", 300000, false, "Consulting"},
+		{"pending", "2024-12-30T12:00:00Z", -9999, true, "Supplies"},
+		{"last-year", "2023-12-31T12:00:00Z", -7777, false, "Supplies"},
+		{"food", "2024-05-01T12:00:00Z", -4000, false, "Groceries"},
+	}
+	for _, tx := range transactions {
+		if err := db.SaveTransaction(tx.id, "acc-1", tx.posted, tx.amount, "Payee "+tx.id, tx.pending); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.UpdateTransactionCategory(tx.id, categories[tx.category]); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+	}
+
+	tax, err := BuildTaxReport(db, 2024)
+	if err != nil {
+		t.Fatalf("BuildTaxReport failed: %v", err)
+	}
+
+	want := []struct {
+		key   string
+		total int64
+	}{
+		{"a:charity-cash", 25000},
+		{"c:gross-receipts", 300000},
+		{"c:supplies", 16000},
+	}
+	if len(tax.Lines) != len(want) {
+		t.Fatalf("Expected %d tax lines, got %+v", len(want), tax.Lines)
+	}
+	for i, w := range want {
+		if tax.Lines[i].Line.Key != w.key || tax.Lines[i].Total != w.total {
+			t.Errorf("Expected line %d to be %s totaling %d, got %s totaling %d", i, w.key, w.total, tax.Lines[i].Line.Key, tax.Lines[i].Total)
+		}
+	}
+	supplies := tax.Lines[2].Categories
+	if len(supplies) != 2 || supplies[0].Category != "Software" || supplies[1].Amount != 4000 {
+		t.Errorf("Expected Software and Supplies on the supplies line, got %+v", supplies)
+	}
+
+	var buf bytes.Buffer
+	if err := tax.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected a header and 5 rows, got %d records", len(records))
+	}
+	donation := records[1]
+	if donation[0] != "Schedule A" || donation[1] != "11" || donation[3] != "2024-12-31" || donation[6] != "Checking" || donation[7] != "250.00" {
+		t.Errorf("Unexpected donation row: %v", donation)
+	}
+	if refund := records[4]; refund[4] != "Payee paper-refund" || refund[7] != "-10.00" {
+		t.Errorf("Expected the refund to reduce the deduction, got %v", refund)
+	}
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// DefaultTrendMonths is how many months a category trend covers by default
+const DefaultTrendMonths = 12
+
+// flatTrendPercent is how little, as a percent of the monthly average, a
+// trend can move over its months and still count as flat
+const flatTrendPercent = 5.0
+
+// CategoryTrend is a category's monthly totals over a run of months
+type CategoryTrend struct {
+	Category string
+	Income   bool     // totals are income rather than spending
+	Months   []string // YYYY-MM, oldest first
+	Totals   []int64  // cents spent (or earned, for income) each month, net of refunds
+}
+
+// Average returns the mean monthly total in cents
+func (t CategoryTrend) Average() int64 {
+	if len(t.Totals) == 0 {
+		return 0
+	}
+	var total int64
+	for _, amount := range t.Totals {
+		total += amount
+	}
+	return total / int64(len(t.Totals))
+}
+
+// Change returns how much the least squares line through the monthly
+// totals rises from the first month to the last, in cents
+func (t CategoryTrend) Change() int64 {
+	n := float64(len(t.Totals))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, amount := range t.Totals {
+		x, y := float64(i), float64(amount)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	return int64(slope * (n - 1))
+}
+
+// ChangePercent returns Change as a percent of the monthly average, or 0
+// when there's no average to compare against
+func (t CategoryTrend) ChangePercent() float64 {
+	average := t.Average()
+	if average == 0 {
+		return 0
+	}
+	return float64(t.Change()) / float64(abs(average)) * 100
+}
+
+// Direction is 1 when the trend is rising, -1 when it's falling, and 0 when
+// it moves less than flatTrendPercent of the average
+func (t CategoryTrend) Direction() int {
+	percent := t.ChangePercent()
+	switch {
+	case percent >= flatTrendPercent:
+		return 1
+	case percent <= -flatTrendPercent:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// CategoryTrends totals each category's transactions for each of the months
+// months before the month of end, so a month still in progress doesn't drag
+// the trend down. A category that earned more than it spent over the
+// months is totaled as income. Pending transactions are included.
+func CategoryTrends(db *database.DB, categories []string, end time.Time, months int) ([]CategoryTrend, error) {
+	if months <= 0 {
+		months = DefaultTrendMonths
+	}
+
+	last := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, format.Location())
+	first := last.AddDate(0, -months, 0)
+
+	monthKeys := make([]string, months)
+	for i := range monthKeys {
+		monthKeys[i] = first.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	byCategory, err := db.GetTransactionsByCategory(first.UTC().Format(time.RFC3339), last.UTC().Format(time.RFC3339), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	trends := make([]CategoryTrend, 0, len(categories))
+	for _, category := range categories {
+		trend := CategoryTrend{
+			Category: category,
+			Months:   monthKeys,
+			Totals:   make([]int64, months),
+		}
+
+		var net int64
+		for _, txn := range byCategory[category] {
+			month := format.PostedMonth(txn.Posted)
+			for i, key := range monthKeys {
+				if key == month {
+					trend.Totals[i] -= txn.Amount
+					net += txn.Amount
+					break
+				}
+			}
+		}
+
+		if net > 0 {
+			trend.Income = true
+			for i := range trend.Totals {
+				trend.Totals[i] = -trend.Totals[i]
+			}
+		}
+		trends = append(trends, trend)
+	}
+	return trends, nil
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+func TestCategoryTrendDirection(t *testing.T) {
+	tests := []struct {
+		name      string
+		totals    []int64
+		average   int64
+		change    int64
+		direction int
+	}{
+		{"rising", []int64{10000, 11000, 12000, 13000}, 11500, 3000, 1},
+		{"falling", []int64{13000, 12000, 11000, 10000}, 11500, -3000, -1},
+		{"flat with noise", []int64{10000, 10500, 9500, 10000}, 10000, -300, 0},
+		{"single month", []int64{10000}, 10000, 0, 0},
+		{"nothing", []int64{0, 0, 0}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := CategoryTrend{Totals: tt.totals}
+			if got := trend.Average(); got != tt.average {
+				t.Errorf("Average() = %d; want %d", got, tt.average)
+			}
+			if got := trend.Change(); got != tt.change {
+				t.Errorf("Change() = %d; want %d", got, tt.change)
+			}
+			if got := trend.Direction(); got != tt.direction {
+				t.Errorf("Direction() = %d; want %d", got, tt.direction)
+			}
+		})
+	}
+}
+
+func TestCategoryTrends(t *testing.T) {
+	defer format.SetLocation(format.Location())
+	format.SetLocation(time.UTC)
+
+	tempDir := t.TempDir()
+
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", tempDir)
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("acc-1", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	groceriesID, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	salaryID, err := db.SaveCategory("Salary")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	transactions := []struct {
+		id         string
+		posted     string
+		amount     int64
+		categoryID int
+	}{
+		{"too-early", "2023-12-20T00:00:00Z", -9000, groceriesID},
+		{"jan", "2024-01-10T00:00:00Z", -10000, groceriesID},
+		{"jan-refund", "2024-01-12T00:00:00Z", 1000, groceriesID},
+		{"feb", "2024-02-10T00:00:00Z", -12000, groceriesID},
+		{"mar", "2024-03-10T00:00:00Z", -14000, groceriesID},
+		{"in-progress", "2024-04-02T00:00:00Z", -500, groceriesID},
+		{"salary-jan", "2024-01-31T00:00:00Z", 300000, salaryID},
+		{"salary-mar", "2024-03-31T00:00:00Z", 300000, salaryID},
+	}
+	for _, txn := range transactions {
+		if err := db.SaveTransaction(txn.id, "acc-1", txn.posted, txn.amount, txn.id, false); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if err := db.UpdateTransactionCategory(txn.id, txn.categoryID); err != nil {
+			t.Fatalf("Failed to categorize transaction: %v", err)
+		}
+	}
+
+	trends, err := CategoryTrends(db, []string{"Groceries", "Salary"}, time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), 3)
+	if err != nil {
+		t.Fatalf("Failed to get category trends: %v", err)
+	}
+	if len(trends) != 2 {
+		t.Fatalf("Expected 2 trends, got %d", len(trends))
+	}
+
+	// The month in progress and months before the range are left out, and
+	// refunds reduce spending
+	groceries := trends[0]
+	wantMonths := []string{"2024-01", "2024-02", "2024-03"}
+	wantTotals := []int64{9000, 12000, 14000}
+	for i := range wantMonths {
+		if groceries.Months[i] != wantMonths[i] || groceries.Totals[i] != wantTotals[i] {
+			t.Errorf("Month %d: expected %s %d, got %s %d", i, wantMonths[i], wantTotals[i], groceries.Months[i], groceries.Totals[i])
+		}
+	}
+	if groceries.Income || groceries.Direction() != 1 {
+		t.Errorf("Expected rising grocery spending, got %+v", groceries)
+	}
+
+	// Categories that mostly earn are totaled as income
+	salary := trends[1]
+	if !salary.Income || salary.Totals[0] != 300000 || salary.Totals[1] != 0 || salary.Totals[2] != 300000 {
+		t.Errorf("Expected salary to be totaled as income, got %+v", salary)
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// CardUtilization is how much of a credit account's limit is in use
+type CardUtilization struct {
+	Account database.Account
+	Owed    int64 // positive cents owed, 0 when the balance is paid off
+	Limit   int64 // cents, 0 when no limit is set
+}
+
+// HasLimit reports whether the card has a credit limit to measure against
+func (c CardUtilization) HasLimit() bool {
+	return c.Limit > 0
+}
+
+// Available returns the cents of the limit left to spend
+func (c CardUtilization) Available() int64 {
+	return c.Limit - c.Owed
+}
+
+// Percent returns the percent of the limit owed, or 0 without a limit
+func (c CardUtilization) Percent() float64 {
+	if !c.HasLimit() {
+		return 0
+	}
+	return float64(c.Owed) / float64(c.Limit) * 100
+}
+
+// Utilization is credit in use across credit accounts
+type Utilization struct {
+	Cards     []CardUtilization // highest utilization first, cards without a limit last
+	Threshold int               // percent above which a card is flagged
+}
+
+// Totals returns the cents owed and the credit limit summed over cards
+// with a limit
+func (u *Utilization) Totals() (owed, limit int64) {
+	for _, card := range u.Cards {
+		if card.HasLimit() {
+			owed += card.Owed
+			limit += card.Limit
+		}
+	}
+	return owed, limit
+}
+
+// Percent returns overall utilization across cards with a limit, or 0
+// without any
+func (u *Utilization) Percent() float64 {
+	owed, limit := u.Totals()
+	if limit == 0 {
+		return 0
+	}
+	return float64(owed) / float64(limit) * 100
+}
+
+// Flagged reports whether a card's utilization is above the threshold
+func (u *Utilization) Flagged(card CardUtilization) bool {
+	return card.HasLimit() && card.Percent() > float64(u.Threshold)
+}
+
+// BuildUtilization measures the balance of every credit account, and any
+// other account with a credit limit, against its limit. Cards above
+// threshold percent are flagged.
+func BuildUtilization(db *database.DB, threshold int) (*Utilization, error) {
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	limits, err := db.GetCreditLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	utilization := &Utilization{Threshold: threshold}
+	for _, account := range accounts {
+		limit, hasLimit := limits[account.ID]
+		isCredit := account.AccountType != nil && *account.AccountType == "credit"
+		if !isCredit && !hasLimit {
+			continue
+		}
+		utilization.Cards = append(utilization.Cards, CardUtilization{
+			Account: account,
+			Owed:    max(0, -account.Balance),
+			Limit:   limit,
+		})
+	}
+
+	sort.SliceStable(utilization.Cards, func(i, j int) bool {
+		a, b := utilization.Cards[i], utilization.Cards[j]
+		if a.HasLimit() != b.HasLimit() {
+			return a.HasLimit()
+		}
+		if a.Percent() != b.Percent() {
+			return a.Percent() > b.Percent()
+		}
+		return a.Account.DisplayName() < b.Account.DisplayName()
+	})
+	return utilization, nil
+}
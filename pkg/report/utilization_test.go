@@ -0,0 +1,87 @@
+package report
+
+import (
+	"os"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestBuildUtilization(t *testing.T) {
+	oldMoneyDir := os.Getenv("MONEY_DIR")
+	os.Setenv("MONEY_DIR", t.TempDir())
+	defer os.Setenv("MONEY_DIR", oldMoneyDir)
+
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Test Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	accounts := []struct {
+		id          string
+		balance     int64
+		accountType string
+		limit       int64
+	}{
+		{"travel", -450000, "credit", 1000000},
+		{"grocery", -50000, "credit", 500000},
+		{"overpaid", 2500, "credit", 200000},
+		{"store", -30000, "credit", 0},
+		{"heloc", -2000000, "loan", 5000000},
+		{"checking", 100000, "checking", 0},
+	}
+	for _, account := range accounts {
+		if err := db.SaveAccount(account.id, "org-1", account.id, "USD", account.balance, nil, ""); err != nil {
+			t.Fatalf("Failed to save account: %v", err)
+		}
+		if err := db.SetAccountType(account.id, account.accountType); err != nil {
+			t.Fatalf("Failed to set account type: %v", err)
+		}
+		if account.limit > 0 {
+			if err := db.SetCreditLimit(account.id, account.limit); err != nil {
+				t.Fatalf("Failed to set credit limit: %v", err)
+			}
+		}
+	}
+
+	utilization, err := BuildUtilization(db, 30)
+	if err != nil {
+		t.Fatalf("Failed to build utilization: %v", err)
+	}
+
+	// Highest first, the card without a limit last, checking left out
+	want := []struct {
+		id      string
+		owed    int64
+		percent float64
+		flagged bool
+	}{
+		{"travel", 450000, 45, true},
+		{"heloc", 2000000, 40, true},
+		{"grocery", 50000, 10, false},
+		{"overpaid", 0, 0, false},
+		{"store", 30000, 0, false},
+	}
+	if len(utilization.Cards) != len(want) {
+		t.Fatalf("Expected %d cards, got %+v", len(want), utilization.Cards)
+	}
+	for i, w := range want {
+		card := utilization.Cards[i]
+		if card.Account.ID != w.id || card.Owed != w.owed || card.Percent() != w.percent || utilization.Flagged(card) != w.flagged {
+			t.Errorf("Card %d: expected %s owing %d at %g%% (flagged %v), got %s owing %d at %g%% (flagged %v)",
+				i, w.id, w.owed, w.percent, w.flagged, card.Account.ID, card.Owed, card.Percent(), utilization.Flagged(card))
+		}
+	}
+
+	// $25,000 owed of $67,000, the card without a limit left out
+	if owed, limit := utilization.Totals(); owed != 2500000 || limit != 6700000 {
+		t.Errorf("Expected 2500000 owed of 6700000, got %d of %d", owed, limit)
+	}
+	if percent := utilization.Percent(); percent < 37.31 || percent > 37.32 {
+		t.Errorf("Expected 37.3%% overall, got %g", percent)
+	}
+}
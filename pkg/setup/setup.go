@@ -0,0 +1,262 @@
+// Package setup exports and imports the hand-made configuration of a
+// money database, its categories, budgets and rules, as YAML that can be
+// version-controlled and used to seed another machine.
+package setup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/report"
+)
+
+// Setup is everything Export writes and Import reads
+type Setup struct {
+	Categories               []Category               `yaml:"categories,omitempty"`
+	RenameRules              []RenameRule             `yaml:"rename_rules,omitempty"`
+	AccountDefaultCategories []AccountDefaultCategory `yaml:"account_default_categories,omitempty"`
+}
+
+// Category is a category with its flags, style, budget and tax line
+type Category struct {
+	Name     string `yaml:"name"`
+	Internal bool   `yaml:"internal,omitempty"`
+	Color    string `yaml:"color,omitempty"`
+	Emoji    string `yaml:"emoji,omitempty"`
+	Budget   string `yaml:"budget,omitempty"` // monthly target in dollars, e.g. "450.00"
+	TaxLine  string `yaml:"tax_line,omitempty"`
+}
+
+// RenameRule is a rule rewriting bank descriptions, in the order applied
+type RenameRule struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+// AccountDefaultCategory files an account's new transactions under a
+// category during fetch
+type AccountDefaultCategory struct {
+	Account  string `yaml:"account"`
+	Category string `yaml:"category"`
+}
+
+// Result counts what Import changed
+type Result struct {
+	Categories  int
+	Budgets     int
+	TaxLines    int
+	RenameRules int
+	Defaults    int
+	// MissingAccounts are accounts with a default category that aren't in
+	// the database, e.g. before the first fetch; their defaults are skipped
+	MissingAccounts []string
+}
+
+// Export reads the setup out of db
+func Export(db *database.DB) (*Setup, error) {
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := db.GetBudgets()
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := db.GetTaxMappings()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := db.GetRenameRules()
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := db.GetAccountDefaultCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[int]int64, len(budgets))
+	for _, b := range budgets {
+		amounts[b.CategoryID] = b.Amount
+	}
+	taxLines := make(map[int]string, len(mappings))
+	for _, m := range mappings {
+		taxLines[m.CategoryID] = m.TaxLine
+	}
+
+	s := &Setup{}
+	for _, c := range categories {
+		category := Category{
+			Name:     c.Name,
+			Internal: c.IsInternal,
+			Color:    c.Color,
+			Emoji:    c.Emoji,
+			TaxLine:  taxLines[c.ID],
+		}
+		if amount, ok := amounts[c.ID]; ok {
+			category.Budget = fmt.Sprintf("%d.%02d", amount/100, amount%100)
+		}
+		s.Categories = append(s.Categories, category)
+	}
+	for _, rule := range rules {
+		s.RenameRules = append(s.RenameRules, RenameRule{Pattern: rule.Pattern, Replacement: rule.Replacement})
+	}
+	for _, d := range defaults {
+		s.AccountDefaultCategories = append(s.AccountDefaultCategories, AccountDefaultCategory{Account: d.AccountID, Category: d.CategoryName})
+	}
+	return s, nil
+}
+
+// Marshal returns s as YAML
+func (s *Setup) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode setup: %w", err)
+	}
+	return data, nil
+}
+
+// Parse reads a setup from YAML, rejecting unknown keys so a typo isn't
+// silently dropped
+func Parse(data []byte) (*Setup, error) {
+	var s Setup
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse setup: %w", err)
+	}
+	return &s, nil
+}
+
+// Validate checks everything Import would write, so a bad entry is caught
+// before anything is changed
+func (s *Setup) Validate() error {
+	names := make(map[string]bool, len(s.Categories))
+	for _, c := range s.Categories {
+		if strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("category with no name")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("category %q is listed twice", c.Name)
+		}
+		names[c.Name] = true
+		if c.Budget != "" {
+			amount, err := format.ParseCents(c.Budget)
+			if err != nil {
+				return fmt.Errorf("category %q: invalid budget: %w", c.Name, err)
+			}
+			if amount <= 0 {
+				return fmt.Errorf("category %q: budget must be positive", c.Name)
+			}
+		}
+		if c.TaxLine != "" {
+			if _, ok := report.LookupTaxLine(c.TaxLine); !ok {
+				return fmt.Errorf("category %q: unknown tax line %q", c.Name, c.TaxLine)
+			}
+		}
+	}
+	for _, rule := range s.RenameRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid rename pattern %q: %w", rule.Pattern, err)
+		}
+	}
+	for _, d := range s.AccountDefaultCategories {
+		if d.Account == "" || d.Category == "" {
+			return fmt.Errorf("account default category needs both an account and a category")
+		}
+	}
+	return nil
+}
+
+// Import writes s into db, adding categories and rules that are missing
+// and updating the ones that exist. Nothing is removed, so importing into
+// a database that already has its own setup merges the two. Default
+// categories may name categories that are only in db.
+func Import(db *database.DB, s *Setup) (*Result, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	accounts, err := db.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+	accountIDs := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		accountIDs[account.ID] = true
+	}
+
+	// Default categories may name categories only in db; check them all
+	// before writing anything
+	listed := make(map[string]bool, len(s.Categories))
+	for _, c := range s.Categories {
+		listed[c.Name] = true
+	}
+	for _, d := range s.AccountDefaultCategories {
+		if !listed[d.Category] {
+			if _, err := db.GetCategoryByName(d.Category); err != nil {
+				return nil, fmt.Errorf("default category for account %s: %w", d.Account, err)
+			}
+		}
+	}
+
+	result := &Result{}
+	for _, c := range s.Categories {
+		id, err := db.SaveCategoryWithInternal(c.Name, c.Internal)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.SetCategoryInternal(id, c.Internal); err != nil {
+			return nil, err
+		}
+		if err := db.SetCategoryStyle(id, c.Color, c.Emoji); err != nil {
+			return nil, err
+		}
+		result.Categories++
+
+		if c.Budget != "" {
+			amount, _ := format.ParseCents(c.Budget)
+			if err := db.SetBudget(id, amount); err != nil {
+				return nil, err
+			}
+			result.Budgets++
+		}
+		if c.TaxLine != "" {
+			if err := db.SetTaxLine(id, c.TaxLine); err != nil {
+				return nil, err
+			}
+			result.TaxLines++
+		}
+	}
+
+	for _, rule := range s.RenameRules {
+		if _, err := db.AddRenameRule(rule.Pattern, rule.Replacement); err != nil {
+			return nil, err
+		}
+		result.RenameRules++
+	}
+
+	for _, d := range s.AccountDefaultCategories {
+		if !accountIDs[d.Account] {
+			result.MissingAccounts = append(result.MissingAccounts, d.Account)
+			continue
+		}
+		category, err := db.GetCategoryByName(d.Category)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.SetAccountDefaultCategory(d.Account, category.ID); err != nil {
+			return nil, err
+		}
+		result.Defaults++
+	}
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package setup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	source, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer source.Close()
+
+	if err := source.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := source.SaveAccount("mortgage", "org-1", "Mortgage", "USD", 0, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	groceries, err := source.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	housing, err := source.SaveCategory("Housing")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	if _, err := source.SaveCategoryWithInternal("Transfers", true); err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	if err := source.SetCategoryStyle(groceries, "green", "🛒"); err != nil {
+		t.Fatalf("Failed to style category: %v", err)
+	}
+	if err := source.SetBudget(groceries, 45050); err != nil {
+		t.Fatalf("Failed to set budget: %v", err)
+	}
+	if err := source.SetTaxLine(housing, "a:mortgage-interest"); err != nil {
+		t.Fatalf("Failed to set tax line: %v", err)
+	}
+	if _, err := source.AddRenameRule(`SQ \*(.*)`, "$1"); err != nil {
+		t.Fatalf("Failed to add rename rule: %v", err)
+	}
+	if err := source.SetAccountDefaultCategory("mortgage", housing); err != nil {
+		t.Fatalf("Failed to set default category: %v", err)
+	}
+
+	exported, err := Export(source)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := exported.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{"name: Groceries", "budget: \"450.50\"", "internal: true", "tax_line: a:mortgage-interest", "account: mortgage"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected the YAML to contain %q:\n%s", want, data)
+		}
+	}
+
+	// A fresh machine, before its first fetch
+	source.Close()
+	t.Setenv("MONEY_DIR", t.TempDir())
+	target, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer target.Close()
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	result, err := Import(target, parsed)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Categories != 3 || result.Budgets != 1 || result.TaxLines != 1 || result.RenameRules != 1 || result.Defaults != 0 {
+		t.Errorf("Unexpected import counts %+v", result)
+	}
+	if len(result.MissingAccounts) != 1 || result.MissingAccounts[0] != "mortgage" {
+		t.Errorf("Expected the mortgage default to be skipped, got %v", result.MissingAccounts)
+	}
+
+	imported, err := Export(target)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	again, err := imported.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	// Everything but the skipped default category came across
+	withoutDefaults := strings.Split(string(data), "account_default_categories:")[0]
+	if string(again) != withoutDefaults {
+		t.Errorf("Expected the imported setup to export as\n%s\ngot\n%s", withoutDefaults, again)
+	}
+
+	// Importing again changes nothing
+	if _, err := Import(target, parsed); err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	categories, err := target.GetCategories()
+	if err != nil {
+		t.Fatalf("Failed to get categories: %v", err)
+	}
+	if len(categories) != 3 {
+		t.Errorf("Expected 3 categories after importing twice, got %d", len(categories))
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	tests := map[string]string{
+		"unknown key":      "categories:\n  - name: Food\n    colour: red\n",
+		"bad budget":       "categories:\n  - name: Food\n    budget: lots\n",
+		"bad tax line":     "categories:\n  - name: Food\n    tax_line: z:nope\n",
+		"duplicate":        "categories:\n  - name: Food\n  - name: Food\n",
+		"bad pattern":      "categories:\n  - name: Food\nrename_rules:\n  - pattern: \"(\"\n    replacement: x\n",
+		"unknown category": "account_default_categories:\n  - account: acc-1\n    category: Nowhere\n",
+	}
+	for name, yaml := range tests {
+		s, err := Parse([]byte(yaml))
+		if err == nil {
+			_, err = Import(db, s)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Nothing was written by the rejected files
+	categories, err := db.GetCategories()
+	if err != nil {
+		t.Fatalf("Failed to get categories: %v", err)
+	}
+	if len(categories) != 0 {
+		t.Errorf("Expected no categories after failed imports, got %+v", categories)
+	}
+
+	if _, err := Parse(nil); err != nil {
+		t.Errorf("Expected an empty file to parse, got %v", err)
+	}
+}
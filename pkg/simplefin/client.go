@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -18,6 +20,26 @@ type Client struct {
 	accessURL  string
 	username   string
 	password   string
+	retry      RetryPolicy
+	requests   int // requests made so far, counted against retry.MaxRequests
+}
+
+// RetryPolicy controls how account requests that fail with a 429 or 5xx
+// status, or a network error, are retried
+type RetryPolicy struct {
+	MaxAttempts int           // attempts per request, including the first
+	BaseDelay   time.Duration // delay before the first retry, doubled for each one after
+	MaxDelay    time.Duration // longest wait between attempts
+	MaxRequests int           // requests the client may make in total, retries included; 0 for no limit
+}
+
+// DefaultRetryPolicy rides out a briefly unavailable bridge while keeping a
+// fetch well within SimpleFIN's daily request quota
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    30 * time.Second,
+	MaxRequests: 10,
 }
 
 func NewClient(accessURL, username, password string) *Client {
@@ -26,6 +48,7 @@ func NewClient(accessURL, username, password string) *Client {
 		accessURL:  accessURL,
 		username:   username,
 		password:   password,
+		retry:      DefaultRetryPolicy,
 	}
 }
 
@@ -170,7 +193,8 @@ func (c *Client) GetAccountsWithOptions(opts *AccountsOptions) (*AccountsRespons
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "money-cli/1.0")
 
-	resp, err := c.httpClient.Do(req)
+	slog.Debug("requesting SimpleFIN accounts", "url", parsedURL.Redacted())
+	resp, err := c.doWithRetry(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
 	}
@@ -192,12 +216,165 @@ func (c *Client) GetAccountsWithOptions(opts *AccountsOptions) (*AccountsRespons
 	}
 
 	if len(accountsResponse.Errors) > 0 {
-		fmt.Printf("Warning - some institutions had errors: %v\n", accountsResponse.Errors)
+		slog.Warn("some institutions had errors", "errors", accountsResponse.Errors)
 	}
 
 	return &accountsResponse, nil
 }
 
+// doWithRetry sends req, retrying 429 and 5xx responses and network errors
+// with exponential backoff and jitter until the retry policy's attempts or
+// request budget run out. The last response or error is returned.
+func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if c.retry.MaxRequests > 0 && c.requests >= c.retry.MaxRequests {
+			return nil, fmt.Errorf("request budget of %d SimpleFIN requests used up", c.retry.MaxRequests)
+		}
+		c.requests++
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			slog.Debug("SimpleFIN response", "url", req.URL.Redacted(), "status", resp.StatusCode, "latency", time.Since(start))
+		}
+
+		if !shouldRetry(resp, err) || attempt >= c.retry.MaxAttempts ||
+			(c.retry.MaxRequests > 0 && c.requests >= c.retry.MaxRequests) {
+			return resp, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if resp != nil {
+			if wait, ok := retryAfter(resp); ok {
+				if wait > c.retry.MaxDelay {
+					// The bridge wants us to wait longer than we're willing to
+					return resp, nil
+				}
+				delay = max(delay, wait)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err != nil {
+			slog.Warn("SimpleFIN request failed, retrying", "attempt", attempt, "err", err, "delay", delay)
+		} else {
+			slog.Warn("SimpleFIN request failed, retrying", "attempt", attempt, "status", resp.StatusCode, "delay", delay)
+		}
+		time.Sleep(delay)
+	}
+}
+
+// shouldRetry reports whether a request may succeed if sent again: network
+// errors, rate limiting, and server errors
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before retry number attempt: the base delay
+// doubled for each earlier retry, capped at MaxDelay, with random jitter
+// taking off up to half so clients don't retry in lockstep
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// SetRetryPolicy replaces the client's retry policy
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// SupportedVersions are the SimpleFIN protocol versions this client
+// implements. Bridges speaking a newer minor version of the same major
+// version are expected to stay compatible.
+var SupportedVersions = []string{"1.0"}
+
+// Info describes a SimpleFIN bridge, as returned by its /info endpoint
+type Info struct {
+	Versions []string `json:"versions"`
+}
+
+// GetInfo asks the bridge which protocol versions it supports. The /info
+// endpoint needs no credentials, so this also works when they have expired.
+func (c *Client) GetInfo() (*Info, error) {
+	if c.accessURL == "" {
+		return nil, fmt.Errorf("access URL not set - call ExchangeToken first or create client with credentials")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(c.accessURL, "/")+"/info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create info request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "money-cli/1.0")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bridge info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("info request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse info JSON response: %w", err)
+	}
+	return &info, nil
+}
+
+// Compatible reports whether the bridge speaks a protocol version this
+// client handles
+func (i *Info) Compatible() bool {
+	return len(i.UnsupportedVersions()) < len(i.Versions)
+}
+
+// UnsupportedVersions returns the versions the bridge speaks that this
+// client doesn't handle
+func (i *Info) UnsupportedVersions() []string {
+	var unsupported []string
+	for _, version := range i.Versions {
+		if !versionSupported(version) {
+			unsupported = append(unsupported, version)
+		}
+	}
+	return unsupported
+}
+
+// versionSupported compares major versions, so "1.1" is handled like "1.0"
+func versionSupported(version string) bool {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	for _, supported := range SupportedVersions {
+		if supportedMajor, _, _ := strings.Cut(supported, "."); major == supportedMajor {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Client) GetCredentials() (accessURL, username, password string) {
 	return c.accessURL, c.username, c.password
 }
@@ -260,7 +437,7 @@ type Holding struct {
 	Created       *int64 `json:"created,omitempty"`
 }
 
-func ParseAmountToCents(amountStr string) (int, error) {
+func ParseAmountToCents(amountStr string) (int64, error) {
 	if amountStr == "" {
 		return 0, nil
 	}
@@ -276,7 +453,7 @@ func ParseAmountToCents(amountStr string) (int, error) {
 	} else {
 		cents -= 0.5
 	}
-	return int(cents), nil
+	return int64(cents), nil
 }
 
 func UnixTimestampToISO(unixTimestamp int64) string {
@@ -220,7 +220,7 @@ func TestExchangeTokenInvalidBase64(t *testing.T) {
 func TestParseAmountToCents(t *testing.T) {
 	testCases := []struct {
 		input    string
-		expected int
+		expected int64
 		hasError bool
 	}{
 		{"123.45", 12345, false},
@@ -268,3 +268,130 @@ func TestUnixTimestampToISO(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAccountsRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, "bridge unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"errors": [], "accounts": []}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user", "pass")
+	client.SetHTTPClient(createTestHTTPClient())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	if _, err := client.GetAccounts(); err != nil {
+		t.Fatalf("GetAccounts failed after retries: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+}
+
+func TestGetAccountsRetryLimits(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("account") == "bad-request" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "bridge unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user", "pass")
+	client.SetHTTPClient(createTestHTTPClient())
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, MaxRequests: 4})
+
+	// Client errors are not retried
+	if _, err := client.GetAccountsWithOptions(&AccountsOptions{AccountID: "bad-request"}); err == nil || requests != 1 {
+		t.Errorf("expected one failed request, got %d requests, err %v", requests, err)
+	}
+
+	// Server errors are retried up to MaxAttempts
+	if _, err := client.GetAccounts(); err == nil || !strings.Contains(err.Error(), "502") || requests != 4 {
+		t.Errorf("expected the 502 after 3 attempts, got %d requests, err %v", requests, err)
+	}
+
+	// The request budget is shared by every call
+	if _, err := client.GetAccounts(); err == nil || !strings.Contains(err.Error(), "budget") || requests != 4 {
+		t.Errorf("expected the request budget to be used up, got %d requests, err %v", requests, err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{4, 2500 * time.Millisecond, 5 * time.Second},
+		{100, 2500 * time.Millisecond, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if delay := policy.backoff(tt.attempt); delay < tt.min || delay > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, delay, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simplefin/info" {
+			t.Errorf("Expected /simplefin/info, got %s", r.URL.Path)
+		}
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Error("info request should not send credentials")
+		}
+		fmt.Fprint(w, `{"versions": ["1.0", "2.0"]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/simplefin/", "user", "pass")
+	client.SetHTTPClient(createTestHTTPClient())
+
+	info, err := client.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if len(info.Versions) != 2 || !info.Compatible() {
+		t.Errorf("unexpected info %+v, compatible %v", info, info.Compatible())
+	}
+	if unsupported := info.UnsupportedVersions(); len(unsupported) != 1 || unsupported[0] != "2.0" {
+		t.Errorf("UnsupportedVersions = %v, want [2.0]", unsupported)
+	}
+}
+
+func TestInfoCompatible(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     bool
+	}{
+		{[]string{"1.0"}, true},
+		{[]string{"1.2"}, true},
+		{[]string{"2.0"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		info := &Info{Versions: tt.versions}
+		if got := info.Compatible(); got != tt.want {
+			t.Errorf("Compatible(%v) = %v, want %v", tt.versions, got, tt.want)
+		}
+	}
+}
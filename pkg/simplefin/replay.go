@@ -0,0 +1,195 @@
+package simplefin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// replayAccessURL is the access URL of replay clients. Requests never leave
+// the process, so the host only has to be valid.
+const replayAccessURL = "https://replay.simplefin.invalid/simplefin"
+
+// Recording is one SimpleFIN response saved to disk by a recording client.
+// Credentials are never saved: the Authorization header is dropped and the
+// access URL is reduced to its path.
+type Recording struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// RecordTo makes the client save every response it receives to dir, one
+// numbered JSON file per request, for replaying later with NewReplayClient.
+// Recordings contain full account and transaction data, so the files are
+// only readable by the current user.
+func (c *Client) RecordTo(dir string) {
+	httpClient := *c.httpClient
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	httpClient.Transport = &recordingTransport{dir: dir, next: next}
+	c.httpClient = &httpClient
+}
+
+// NewReplayClient returns a client that answers requests from the
+// recordings in dir instead of a SimpleFIN bridge. Recordings are replayed
+// in the order they were made; each request uses the next recording for the
+// same method and endpoint, whatever its query or the bridge's base path.
+func NewReplayClient(dir string) (*Client, error) {
+	transport, err := newReplayTransport(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(replayAccessURL, "replay", "replay")
+	client.SetHTTPClient(&http.Client{Transport: transport})
+	return client, nil
+}
+
+type recordingTransport struct {
+	mu   sync.Mutex
+	dir  string
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	recording := Recording{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Status: resp.StatusCode,
+		Body:   string(body),
+	}
+	if err := t.save(recording); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes recording to the next free numbered file in the directory, so
+// several recorded fetches can share one directory
+func (t *recordingTransport) save(recording Recording) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	existing, err := recordingFiles(t.dir)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+
+	name := fmt.Sprintf("%03d-%s.json", len(existing)+1, recordingName(recording.Path))
+	if err := os.WriteFile(filepath.Join(t.dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// recordingName turns a request path into a file name part, e.g.
+// "/simplefin/accounts" into "accounts"
+func recordingName(urlPath string) string {
+	name := path.Base(urlPath)
+	if name == "/" || name == "." {
+		return "root"
+	}
+	return name
+}
+
+// recordingFiles lists the recordings in dir in the order they were made
+func recordingFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+type replayTransport struct {
+	mu         sync.Mutex
+	recordings []Recording
+	used       []bool
+}
+
+func newReplayTransport(dir string) (*replayTransport, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to open recordings: %w", err)
+	}
+	files, err := recordingFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s", dir)
+	}
+
+	t := &replayTransport{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+		var recording Recording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			return nil, fmt.Errorf("failed to parse recording %s: %w", filepath.Base(file), err)
+		}
+		t.recordings = append(t.recordings, recording)
+	}
+	t.used = make([]bool, len(t.recordings))
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, recording := range t.recordings {
+		if t.used[i] || recording.Method != req.Method || path.Base(recording.Path) != path.Base(req.URL.Path) {
+			continue
+		}
+		t.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recording.Status, http.StatusText(recording.Status)),
+			StatusCode:    recording.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(recording.Body)),
+			ContentLength: int64(len(recording.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded response left for %s %s", req.Method, req.URL.Path)
+}
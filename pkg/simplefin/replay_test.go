@@ -0,0 +1,75 @@
+package simplefin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"errors": [], "accounts": [{"id": "acc-%d", "name": "Checking", "org": {"id": "org-1", "name": "Test Bank"}, "currency": "USD", "balance": "100.50", "transactions": []}]}`, requests)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "recordings")
+	client := NewClient(server.URL+"/simplefin", "user", "secret")
+	client.SetHTTPClient(createTestHTTPClient())
+	client.RecordTo(dir)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetAccounts(); err != nil {
+			t.Fatalf("GetAccounts failed: %v", err)
+		}
+	}
+
+	files, err := recordingFiles(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected 2 recordings, got %v, %v", files, err)
+	}
+	if filepath.Base(files[0]) != "001-accounts.json" {
+		t.Errorf("unexpected recording name %s", filepath.Base(files[0]))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("%s contains credentials", file)
+		}
+	}
+
+	replay, err := NewReplayClient(dir)
+	if err != nil {
+		t.Fatalf("NewReplayClient failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		resp, err := replay.GetAccounts()
+		if err != nil {
+			t.Fatalf("replayed GetAccounts failed: %v", err)
+		}
+		if want := fmt.Sprintf("acc-%d", i); len(resp.Accounts) != 1 || resp.Accounts[0].ID != want {
+			t.Errorf("replay %d returned %+v, want account %s", i, resp.Accounts, want)
+		}
+	}
+	if _, err := replay.GetAccounts(); err == nil {
+		t.Error("expected an error once the recordings are used up")
+	}
+	if requests != 2 {
+		t.Errorf("replay reached the server: %d requests", requests)
+	}
+}
+
+func TestNewReplayClientEmpty(t *testing.T) {
+	if _, err := NewReplayClient(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without recordings")
+	}
+	if _, err := NewReplayClient(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,211 @@
+package table
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// ColumnType hints what a column holds, which sets its default alignment
+// and whether AddTotals sums it
+type ColumnType int
+
+const (
+	// ColumnText is free text, left-aligned
+	ColumnText ColumnType = iota
+	// ColumnNumber is a count or quantity, right-aligned
+	ColumnNumber
+	// ColumnCurrency is a dollar amount as format.Currency writes it,
+	// right-aligned and summed by AddTotals
+	ColumnCurrency
+)
+
+// Align is how a column's cells line up
+type Align int
+
+const (
+	// AlignDefault follows the column type
+	AlignDefault Align = iota
+	AlignLeft
+	AlignRight
+)
+
+// SetColumnTypes sets the type of each column, in header order. Columns
+// left out are text.
+func (t *Table) SetColumnTypes(types ...ColumnType) *Table {
+	t.types = types
+	return t
+}
+
+// SetAlign overrides the alignment of column i
+func (t *Table) SetAlign(i int, align Align) *Table {
+	for len(t.aligns) <= i {
+		t.aligns = append(t.aligns, AlignDefault)
+	}
+	t.aligns[i] = align
+	return t
+}
+
+// SetFooter sets a row shown below the others, set apart by a separator
+// line, such as totals
+func (t *Table) SetFooter(columns ...string) *Table {
+	footer := make([]string, len(t.headers))
+	copy(footer, columns)
+	t.footer = footer
+	return t
+}
+
+// AddTotals sets the footer to label in the first column and the sum of
+// each currency column's amounts under it. Cells that aren't amounts, such
+// as "-", are skipped.
+func (t *Table) AddTotals(label string) *Table {
+	footer := make([]string, len(t.headers))
+	if len(footer) > 0 {
+		footer[0] = label
+	}
+	for i := range footer {
+		if t.columnType(i) != ColumnCurrency {
+			continue
+		}
+		var total int64
+		for _, row := range t.rows {
+			if cents, err := format.ParseCurrency(stripANSI(row[i])); err == nil {
+				total += cents
+			}
+		}
+		footer[i] = format.Currency(total, format.BaseCurrency())
+	}
+	t.footer = footer
+	return t
+}
+
+func (t *Table) columnType(i int) ColumnType {
+	if i < len(t.types) {
+		return t.types[i]
+	}
+	return ColumnText
+}
+
+// rightAligned reports whether column i lines up on the right
+func (t *Table) rightAligned(i int) bool {
+	if i < len(t.aligns) && t.aligns[i] != AlignDefault {
+		return t.aligns[i] == AlignRight
+	}
+	return t.columnType(i) != ColumnText
+}
+
+// visibleWidth is how many columns s takes up in a terminal, ignoring
+// color codes
+func visibleWidth(s string) int {
+	return runewidth.StringWidth(stripANSI(s))
+}
+
+// Direction is the order SortBy puts rows in
+type Direction int
+
+const (
+	Ascending Direction = iota
+	Descending
+)
+
+// Reverse returns the opposite direction
+func (d Direction) Reverse() Direction {
+	if d == Ascending {
+		return Descending
+	}
+	return Ascending
+}
+
+// RowStyleFunc picks the color of a cell from its row, as it was added,
+// and its column. Returning nil leaves the cell as it is. Styles only apply
+// to text output.
+type RowStyleFunc func(row []string, column int) *color.Color
+
+// SetRowStyle sets the function that colors cells as rows are rendered
+func (t *Table) SetRowStyle(style RowStyleFunc) *Table {
+	t.rowStyle = style
+	return t
+}
+
+// SortBy sorts the rows by column i when the table is rendered. Number and
+// currency columns sort by value, with cells that aren't numbers, like "-",
+// last in either direction; text columns sort alphabetically, ignoring case.
+// Rows that tie keep the order they were added in.
+func (t *Table) SortBy(i int, dir Direction) *Table {
+	t.sortBy = i
+	t.sortDir = dir
+	return t
+}
+
+// ColumnIndex returns the index of the column whose header matches name,
+// ignoring case, or -1 if there's none
+func (t *Table) ColumnIndex(name string) int {
+	for i, header := range t.headers {
+		if strings.EqualFold(stripANSI(header), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedRows returns the rows in the order SortBy asks for
+func (t *Table) sortedRows() [][]string {
+	if t.sortBy < 0 || t.sortBy >= len(t.headers) {
+		return t.rows
+	}
+
+	column := t.sortBy
+	columnType := t.columnType(column)
+	rows := slices.Clone(t.rows)
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, aOK := cellValue(rows[i][column], columnType)
+		b, bOK := cellValue(rows[j][column], columnType)
+		if aOK != bOK {
+			return aOK
+		}
+		if aOK {
+			if t.sortDir == Descending {
+				return a > b
+			}
+			return a < b
+		}
+
+		x := strings.ToLower(stripANSI(rows[i][column]))
+		y := strings.ToLower(stripANSI(rows[j][column]))
+		if t.sortDir == Descending {
+			return x > y
+		}
+		return x < y
+	})
+	return rows
+}
+
+// leadingNumber matches the number a cell like "~1,200", "1.5s" or "45.2%"
+// starts with
+var leadingNumber = regexp.MustCompile(`^[~+]?(-?[0-9][0-9,]*(\.[0-9]+)?)`)
+
+// cellValue returns the number in a number or currency cell, and false for
+// text columns and cells that aren't numbers
+func cellValue(cell string, columnType ColumnType) (float64, bool) {
+	cell = strings.TrimSpace(stripANSI(cell))
+	switch columnType {
+	case ColumnCurrency:
+		cents, err := format.ParseCurrency(cell)
+		return float64(cents), err == nil
+	case ColumnNumber:
+		match := leadingNumber.FindStringSubmatch(cell)
+		if match == nil {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		return value, err == nil
+	}
+	return 0, false
+}
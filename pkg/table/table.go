@@ -1,22 +1,28 @@
 package table
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/fatih/color"
 )
 
 // Table represents a table with headers, rows and configuration
 type Table struct {
-	headers   []string
-	rows      [][]string
-	writer    io.Writer
-	config    Config
-	tabwriter *tabwriter.Writer
+	headers  []string
+	rows     [][]string
+	footer   []string
+	types    []ColumnType
+	aligns   []Align
+	sortBy   int // column to sort rows by, or -1 to keep them in order
+	sortDir  Direction
+	rowStyle RowStyleFunc
+	writer   io.Writer
+	config   Config
 }
 
 // Config holds table configuration options
@@ -33,19 +39,75 @@ type Config struct {
 	MaxColumnWidth int
 	// MinColumnWidth minimum width for each column
 	MinColumnWidth int
-	// UseTabwriter whether to use tabwriter for alignment (recommended)
+	// UseTabwriter whether to align columns the way text/tabwriter does
+	// (recommended), rather than with single spaces and MinColumnWidth
 	UseTabwriter bool
-	// TabwriterConfig for fine-tuning tabwriter behavior
+	// TabwriterConfig for fine-tuning tabwriter-style alignment
 	TabwriterConfig TabwriterConfig
+	// Format selects aligned text or a machine-readable rendering
+	Format Format
 }
 
-// TabwriterConfig holds tabwriter-specific configuration
+// Format is how a table is rendered
+type Format int
+
+const (
+	// FormatText aligns columns for reading in a terminal
+	FormatText Format = iota
+	// FormatCSV writes comma-separated values
+	FormatCSV
+	// FormatTSV writes tab-separated values
+	FormatTSV
+	// FormatMarkdown writes a Markdown table
+	FormatMarkdown
+)
+
+// Formats lists the names ParseFormat accepts, in the order of the constants
+var Formats = []string{"text", "csv", "tsv", "markdown"}
+
+// String returns the format's name
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(Formats) {
+		return Formats[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat returns the format with the given name; "md" is short for
+// markdown
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text":
+		return FormatText, nil
+	case "csv":
+		return FormatCSV, nil
+	case "tsv":
+		return FormatTSV, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	}
+	return FormatText, fmt.Errorf("unknown format %q, use %s", name, strings.Join(Formats, ", "))
+}
+
+// defaultFormat is the Format DefaultConfig starts from
+var defaultFormat = FormatText
+
+// SetDefaultFormat sets the format every table built from DefaultConfig
+// renders in, so a single --format flag covers every command
+func SetDefaultFormat(f Format) {
+	defaultFormat = f
+}
+
+// DefaultFormat returns the format set with SetDefaultFormat
+func DefaultFormat() Format {
+	return defaultFormat
+}
+
+// TabwriterConfig holds the text/tabwriter settings alignment follows
 type TabwriterConfig struct {
-	MinWidth int  // minimum cell width
-	TabWidth int  // tab width
+	MinWidth int  // minimum cell width, padding included
 	Padding  int  // padding added to a cell before computing its width
 	PadChar  byte // padding character (usually ' ')
-	Flags    uint // formatting flags
 }
 
 // DefaultConfig returns a sensible default configuration
@@ -59,11 +121,10 @@ func DefaultConfig() Config {
 		UseTabwriter:   true,
 		TabwriterConfig: TabwriterConfig{
 			MinWidth: 0,
-			TabWidth: 8,
 			Padding:  1,
 			PadChar:  ' ',
-			Flags:    0,
 		},
+		Format: defaultFormat,
 	}
 }
 
@@ -74,24 +135,18 @@ func New(headers ...string) *Table {
 
 // NewWithConfig creates a new table with custom configuration
 func NewWithConfig(config Config, headers ...string) *Table {
-	t := &Table{
+	return &Table{
 		headers: headers,
 		rows:    make([][]string, 0),
+		sortBy:  -1,
 		writer:  os.Stdout,
 		config:  config,
 	}
+}
 
-	if config.UseTabwriter {
-		t.tabwriter = tabwriter.NewWriter(
-			t.writer,
-			config.TabwriterConfig.MinWidth,
-			config.TabwriterConfig.TabWidth,
-			config.TabwriterConfig.Padding,
-			config.TabwriterConfig.PadChar,
-			config.TabwriterConfig.Flags,
-		)
-	}
-
+// SetWriter sets where the table is rendered, which is os.Stdout by default
+func (t *Table) SetWriter(w io.Writer) *Table {
+	t.writer = w
 	return t
 }
 
@@ -110,17 +165,44 @@ func (t *Table) AddRow(columns ...string) *Table {
 
 // truncateString truncates a string to maxLength characters, adding "..." if truncated
 func truncateString(s string, maxLength int) string {
-	if maxLength <= 0 || len(s) <= maxLength {
+	runes := []rune(s)
+	if maxLength <= 0 || len(runes) <= maxLength {
 		return s
 	}
 	if maxLength <= 3 {
-		return s[:maxLength]
+		return string(runes[:maxLength])
+	}
+	return string(runes[:maxLength-3]) + "..."
+}
+
+// truncateCell truncates a cell to maxLength visible characters. A cell
+// that has to be cut loses its colors, so no color code is cut in half.
+func truncateCell(s string, maxLength int) string {
+	if maxLength <= 0 || visibleWidth(s) <= maxLength {
+		return s
 	}
-	return s[:maxLength-3] + "..."
+	return truncateString(stripANSI(s), maxLength)
+}
+
+// ansiEscape matches the color codes cells may carry for the terminal
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes terminal color codes from s
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
 }
 
 // Render prints the table to the configured writer
 func (t *Table) Render() error {
+	switch t.config.Format {
+	case FormatCSV:
+		return t.renderDelimited(',')
+	case FormatTSV:
+		return t.renderDelimited('\t')
+	case FormatMarkdown:
+		return t.renderMarkdown()
+	}
+
 	// Show title if configured
 	if t.config.Title != "" {
 		fmt.Fprintf(t.writer, "%s\n", t.config.Title)
@@ -132,10 +214,10 @@ func (t *Table) Render() error {
 	return t.renderWithFixedWidth()
 }
 
-// renderWithTabwriter renders using text/tabwriter for alignment
+// renderWithTabwriter lines columns up the way text/tabwriter does. The
+// padding is worked out here rather than by tabwriter, which counts color
+// codes as text and would misalign rows that are styled differently.
 func (t *Table) renderWithTabwriter() error {
-	w := t.tabwriter
-
 	// Calculate separator length for title section
 	sepLength := 50
 	if t.config.Title != "" {
@@ -145,140 +227,206 @@ func (t *Table) renderWithTabwriter() error {
 		fmt.Fprintf(t.writer, "%s\n", strings.Repeat(t.config.SeparatorChar, sepLength))
 	}
 
-	// Render headers
-	if t.config.ShowHeaders && len(t.headers) > 0 {
-		headerRow := make([]string, len(t.headers))
-		for i, header := range t.headers {
-			headerText := truncateString(header, t.config.MaxColumnWidth)
-			if t.config.BoldHeaders {
-				bold := color.New(color.Bold).SprintFunc()
-				headerText = bold(headerText)
-			}
-			headerRow[i] = headerText
-		}
-		fmt.Fprintf(w, "%s\n", strings.Join(headerRow, "\t"))
-	}
-
-	// Render rows
-	for _, row := range t.rows {
-		renderRow := make([]string, len(row))
-		for i, cell := range row {
-			renderRow[i] = truncateString(cell, t.config.MaxColumnWidth)
-		}
-		fmt.Fprintf(w, "%s\n", strings.Join(renderRow, "\t"))
+	tw := t.config.TabwriterConfig
+	gap := strings.Repeat(string(tw.PadChar), tw.Padding)
+	lines, _ := t.alignedLines(tw.MinWidth-tw.Padding, gap)
+	for _, line := range lines {
+		fmt.Fprintf(t.writer, "%s\n", line)
 	}
-
-	return w.Flush()
+	return nil
 }
 
-// renderWithFixedWidth renders using fixed-width printf formatting
+// renderWithFixedWidth renders columns at least MinColumnWidth wide,
+// separated by single spaces
 func (t *Table) renderWithFixedWidth() error {
 	if len(t.headers) == 0 {
 		return fmt.Errorf("no headers defined")
 	}
 
-	// Calculate column widths
-	colWidths := make([]int, len(t.headers))
+	lines, widths := t.alignedLines(t.config.MinColumnWidth, " ")
 
-	// Start with header widths (use visible text length, not formatted length)
-	for i, header := range t.headers {
-		headerText := truncateString(header, t.config.MaxColumnWidth)
-		colWidths[i] = len(headerText) // Use visible length, not bold-formatted length
-		if t.config.MinColumnWidth > colWidths[i] {
-			colWidths[i] = t.config.MinColumnWidth
+	// Title separator as wide as the table
+	if t.config.Title != "" {
+		totalWidth := len(widths) - 1
+		for _, width := range widths {
+			totalWidth += width
 		}
+		fmt.Fprintf(t.writer, "%s\n", strings.Repeat(t.config.SeparatorChar, totalWidth))
 	}
 
-	// Update widths based on row data
-	for _, row := range t.rows {
-		for i, cell := range row {
-			if i < len(colWidths) {
-				cellLen := len(truncateString(cell, t.config.MaxColumnWidth))
-				if cellLen > colWidths[i] {
-					colWidths[i] = cellLen
-				}
-			}
-		}
+	for _, line := range lines {
+		fmt.Fprintf(t.writer, "%s\n", line)
 	}
+	return nil
+}
 
-	// Apply max column width constraint
-	for i := range colWidths {
-		if t.config.MaxColumnWidth > 0 && colWidths[i] > t.config.MaxColumnWidth {
-			colWidths[i] = t.config.MaxColumnWidth
+// alignedLines lays out the header, rows and footer as lines of text, with
+// each column padded to its widest cell (at least minWidth, and at most
+// MaxColumnWidth) and columns joined by gap. It also returns the column
+// widths.
+func (t *Table) alignedLines(minWidth int, gap string) ([]string, []int) {
+	rows := t.sortedRows()
+
+	truncate := func(cells []string) []string {
+		truncated := make([]string, len(cells))
+		for i, cell := range cells {
+			truncated[i] = truncateCell(cell, t.config.MaxColumnWidth)
 		}
+		return truncated
 	}
-
-	// Calculate total width for separator
-	totalWidth := 0
-	for _, width := range colWidths {
-		totalWidth += width + 1 // +1 for space
+	headers := truncate(t.headers)
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = truncate(row)
 	}
-	if totalWidth > 0 {
-		totalWidth-- // Remove last space
+	var footer []string
+	if t.footer != nil {
+		footer = truncate(t.footer)
 	}
 
-	// Title separator
-	if t.config.Title != "" {
-		fmt.Fprintf(t.writer, "%s\n", strings.Repeat(t.config.SeparatorChar, totalWidth))
+	widths := make([]int, len(t.headers))
+	for i := range widths {
+		widths[i] = max(0, minWidth)
+		if t.config.ShowHeaders {
+			widths[i] = max(widths[i], visibleWidth(headers[i]))
+		}
+		for _, row := range cells {
+			widths[i] = max(widths[i], visibleWidth(row[i]))
+		}
+		if footer != nil {
+			widths[i] = max(widths[i], visibleWidth(footer[i]))
+		}
 	}
 
-	// Render headers
-	if t.config.ShowHeaders {
-		formatStr := ""
-		for i, width := range colWidths {
-			if i > 0 {
-				formatStr += " "
+	// Padding goes outside any color so only the text is styled, and the
+	// trailing padding of the last columns is dropped
+	line := func(cells []string, style func(i int, cell string) string) string {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			pad := strings.Repeat(" ", max(0, widths[i]-visibleWidth(cell)))
+			if style != nil {
+				cell = style(i, cell)
 			}
-			formatStr += fmt.Sprintf("%%-%ds", width)
-		}
-
-		headerVals := make([]interface{}, len(t.headers))
-		for i, header := range t.headers {
-			headerText := truncateString(header, colWidths[i])
-			if t.config.BoldHeaders {
-				bold := color.New(color.Bold).SprintFunc()
-				// For fixed-width formatting, we need to pad the visible text, then apply bold
-				paddedText := fmt.Sprintf("%-*s", colWidths[i], headerText)
-				headerVals[i] = bold(paddedText)
+			if t.rightAligned(i) {
+				padded[i] = pad + cell
 			} else {
-				headerVals[i] = headerText
+				padded[i] = cell + pad
 			}
 		}
+		return strings.TrimRight(strings.Join(padded, gap), " ")
+	}
 
+	var lines []string
+	if t.config.ShowHeaders && len(headers) > 0 {
+		var bold func(i int, cell string) string
 		if t.config.BoldHeaders {
-			// With bold headers, we need special formatting since padding is already included
-			for i, val := range headerVals {
-				if i > 0 {
-					fmt.Fprintf(t.writer, " ")
+			bold = func(i int, cell string) string { return color.New(color.Bold).Sprint(cell) }
+		}
+		lines = append(lines, line(headers, bold))
+	}
+	for r, row := range cells {
+		var style func(i int, cell string) string
+		if t.rowStyle != nil {
+			style = func(i int, cell string) string {
+				if c := t.rowStyle(rows[r], i); c != nil {
+					return c.Sprint(cell)
 				}
-				fmt.Fprintf(t.writer, "%s", val)
+				return cell
 			}
-			fmt.Fprintf(t.writer, "\n")
-		} else {
-			fmt.Fprintf(t.writer, formatStr+"\n", headerVals...)
+		}
+		lines = append(lines, line(row, style))
+	}
+	if footer != nil {
+		separator := make([]string, len(widths))
+		for i, width := range widths {
+			separator[i] = strings.Repeat(t.config.SeparatorChar, width)
+		}
+		lines = append(lines, line(separator, nil), line(footer, nil))
+	}
+	return lines, widths
+}
+
+// plainRows returns the headers and rows, footer included, with color
+// codes removed, for the machine-readable formats. Cells are never
+// truncated or padded there.
+func (t *Table) plainRows() ([]string, [][]string) {
+	headers := make([]string, len(t.headers))
+	for i, header := range t.headers {
+		headers[i] = stripANSI(header)
+	}
+	sorted := t.sortedRows()
+	rows := make([][]string, len(sorted))
+	for i, row := range sorted {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = stripANSI(cell)
 		}
 	}
+	if t.footer != nil {
+		footer := make([]string, len(t.footer))
+		for i, cell := range t.footer {
+			footer[i] = stripANSI(cell)
+		}
+		rows = append(rows, footer)
+	}
+	return headers, rows
+}
 
-	// Render rows
-	formatStr := ""
-	for i, width := range colWidths {
-		if i > 0 {
-			formatStr += " "
+// renderDelimited renders CSV or TSV, leaving out the title so the output
+// can be loaded as is
+func (t *Table) renderDelimited(comma rune) error {
+	headers, rows := t.plainRows()
+
+	w := csv.NewWriter(t.writer)
+	w.Comma = comma
+	if t.config.ShowHeaders && len(headers) > 0 {
+		if err := w.Write(headers); err != nil {
+			return fmt.Errorf("failed to write headers: %w", err)
 		}
-		formatStr += fmt.Sprintf("%%-%ds", width)
 	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
 
-	for _, row := range t.rows {
-		rowVals := make([]interface{}, len(colWidths))
-		for i := range colWidths {
-			if i < len(row) {
-				rowVals[i] = truncateString(row[i], colWidths[i])
-			} else {
-				rowVals[i] = ""
-			}
+// renderMarkdown renders a Markdown table, with the title as a heading
+// above it. Markdown tables need a header row, so one is always written.
+func (t *Table) renderMarkdown() error {
+	headers, rows := t.plainRows()
+	if len(headers) == 0 {
+		return fmt.Errorf("no headers defined")
+	}
+
+	if t.config.Title != "" {
+		fmt.Fprintf(t.writer, "### %s\n\n", markdownCell(stripANSI(t.config.Title)))
+	}
+
+	writeRow := func(cells []string) {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = markdownCell(cell)
 		}
-		fmt.Fprintf(t.writer, formatStr+"\n", rowVals...)
+		fmt.Fprintf(t.writer, "| %s |\n", strings.Join(escaped, " | "))
 	}
 
+	writeRow(headers)
+	separators := make([]string, len(headers))
+	for i := range separators {
+		separators[i] = "---"
+		if t.rightAligned(i) {
+			separators[i] = "---:"
+		}
+	}
+	fmt.Fprintf(t.writer, "| %s |\n", strings.Join(separators, " | "))
+	for _, row := range rows {
+		writeRow(row)
+	}
 	return nil
 }
+
+// markdownCell escapes pipes and folds newlines so a value stays in its cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,250 @@
+package table
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+	}{
+		{"text", FormatText},
+		{"CSV", FormatCSV},
+		{"tsv", FormatTSV},
+		{"markdown", FormatMarkdown},
+		{"md", FormatMarkdown},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if err != nil {
+			t.Errorf("ParseFormat(%q): %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := ParseFormat("json"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	red := color.New(color.FgRed).SprintFunc()
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatCSV, "Name,Amount\n\"Coffee, large\",$4.50\nRent | June,-$1200.00\n"},
+		{FormatTSV, "Name\tAmount\nCoffee, large\t$4.50\nRent | June\t-$1200.00\n"},
+		{FormatMarkdown, "### Spending\n\n| Name | Amount |\n| --- | --- |\n| Coffee, large | $4.50 |\n| Rent \\| June | -$1200.00 |\n"},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Title = "Spending"
+		config.MaxColumnWidth = 5
+		config.Format = tt.format
+
+		var out bytes.Buffer
+		table := NewWithConfig(config, "Name", "Amount").SetWriter(&out)
+		table.AddRow("Coffee, large", "$4.50")
+		table.AddRow("Rent | June", red("-$1200.00"))
+		if err := table.Render(); err != nil {
+			t.Fatalf("Render(%v): %v", tt.format, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("Render(%v) =\n%q\nwant\n%q", tt.format, out.String(), tt.want)
+		}
+	}
+}
+
+func TestRenderTextWriter(t *testing.T) {
+	var out bytes.Buffer
+	table := New("Name", "Amount").SetWriter(&out)
+	table.AddRow("Coffee", "$4.50")
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "Name   Amount\nCoffee $4.50\n"; out.String() != want {
+		t.Errorf("Render = %q, want %q", out.String(), want)
+	}
+}
+
+func TestDefaultFormat(t *testing.T) {
+	defer SetDefaultFormat(FormatText)
+
+	SetDefaultFormat(FormatTSV)
+	if got := DefaultConfig().Format; got != FormatTSV {
+		t.Errorf("DefaultConfig().Format = %v, want tsv", got)
+	}
+}
+
+func TestRenderAlignedTotals(t *testing.T) {
+	var out bytes.Buffer
+	table := New("Category", "Count", "Spent").SetWriter(&out)
+	table.SetColumnTypes(ColumnText, ColumnNumber, ColumnCurrency)
+	table.AddRow("Groceries", "12", "$1,204.50")
+	table.AddRow("Dining Out", "3", "$86.00")
+	table.AddRow("Refunds", "1", "-$20.00")
+	table.AddRow("Pending", "0", "-")
+	table.AddTotals("Total")
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "Category   Count     Spent\n" +
+		"Groceries     12 $1,204.50\n" +
+		"Dining Out     3    $86.00\n" +
+		"Refunds        1   -$20.00\n" +
+		"Pending        0         -\n" +
+		"────────── ───── ─────────\n" +
+		"Total            $1,270.50\n"
+	if out.String() != want {
+		t.Errorf("Render =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRenderWideCharacters(t *testing.T) {
+	var out bytes.Buffer
+	table := New("Category", "Spent").SetWriter(&out)
+	table.SetColumnTypes(ColumnText, ColumnCurrency)
+	table.AddRow("🛒 Groceries", "$1,204.50")
+	table.AddRow("Rent", "$1,200.00")
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// The emoji takes two columns
+	want := "Category         Spent\n" +
+		"🛒 Groceries $1,204.50\n" +
+		"Rent         $1,200.00\n"
+	if out.String() != want {
+		t.Errorf("Render =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRenderAlignOverride(t *testing.T) {
+	config := DefaultConfig()
+	config.UseTabwriter = false
+
+	var out bytes.Buffer
+	table := NewWithConfig(config, "Name", "Amount").SetWriter(&out)
+	table.SetColumnTypes(ColumnText, ColumnCurrency).SetAlign(0, AlignRight).SetAlign(1, AlignLeft)
+	table.AddRow("Rent", "$1,200.00")
+	table.SetFooter("Total", "$1,200.00")
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := " Name Amount\n" +
+		" Rent $1,200.00\n" +
+		"───── ─────────\n" +
+		"Total $1,200.00\n"
+	if out.String() != want {
+		t.Errorf("Render =\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestRenderFooterFormats(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatCSV, "Name,Amount\nRent,\"$1,200.00\"\nTotal,\"$1,200.00\"\n"},
+		{FormatMarkdown, "| Name | Amount |\n| --- | ---: |\n| Rent | $1,200.00 |\n| Total | $1,200.00 |\n"},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Format = tt.format
+
+		var out bytes.Buffer
+		table := NewWithConfig(config, "Name", "Amount").SetWriter(&out)
+		table.SetColumnTypes(ColumnText, ColumnCurrency)
+		table.AddRow("Rent", "$1,200.00")
+		table.AddTotals("Total")
+		if err := table.Render(); err != nil {
+			t.Fatalf("Render(%v): %v", tt.format, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("Render(%v) =\n%q\nwant\n%q", tt.format, out.String(), tt.want)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	tests := []struct {
+		column int
+		dir    Direction
+		want   []string
+	}{
+		{0, Ascending, []string{"apples", "Bananas", "cherries", "dates"}},
+		{1, Descending, []string{"cherries", "apples", "Bananas", "dates"}},
+		{1, Ascending, []string{"Bananas", "apples", "cherries", "dates"}},
+		{2, Ascending, []string{"dates", "cherries", "Bananas", "apples"}},
+	}
+	for _, tt := range tests {
+		table := New("Fruit", "Cost", "Count")
+		table.SetColumnTypes(ColumnText, ColumnCurrency, ColumnNumber)
+		table.AddRow("cherries", "$1,200.00", "~2")
+		table.AddRow("apples", "$15.50", "10")
+		table.AddRow("dates", "-", "1.5")
+		table.AddRow("Bananas", "-$3.00", "3")
+		table.SortBy(tt.column, tt.dir)
+
+		var got []string
+		for _, row := range table.sortedRows() {
+			got = append(got, row[0])
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SortBy(%d, %v) = %v, want %v", tt.column, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestRowStyle(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	red := color.New(color.FgRed)
+
+	var out bytes.Buffer
+	table := New("Account", "Balance").SetWriter(&out)
+	table.SetColumnTypes(ColumnText, ColumnCurrency)
+	table.SetRowStyle(func(row []string, column int) *color.Color {
+		if column == 1 && strings.HasPrefix(row[1], "-") {
+			return red
+		}
+		return nil
+	})
+	table.AddRow("Checking", "$950.00")
+	table.AddRow("Visa", "-$1,457.10")
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// Colored cells are padded by what's visible, so the rows line up
+	want := "Account     Balance\n" +
+		"Checking    $950.00\n" +
+		"Visa     " + red.Sprint("-$1,457.10") + "\n"
+	if out.String() != want {
+		t.Errorf("Render =\n%q\nwant\n%q", out.String(), want)
+	}
+
+	// Machine-readable formats stay plain
+	out.Reset()
+	table.config.Format = FormatCSV
+	if err := table.Render(); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "Account,Balance\nChecking,$950.00\nVisa,\"-$1,457.10\"\n"; out.String() != want {
+		t.Errorf("Render(csv) = %q, want %q", out.String(), want)
+	}
+}
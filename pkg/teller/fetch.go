@@ -0,0 +1,165 @@
+package teller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/format"
+	"github.com/arjungandhi/money/pkg/simplefin"
+)
+
+// Enrollment is a bank enrolled through Teller Connect
+type Enrollment struct {
+	ID              string
+	AccessToken     string
+	InstitutionName string
+}
+
+// FetchOptions controls a fetch
+type FetchOptions struct {
+	// Since is the first day of transactions to read (YYYY-MM-DD), or ""
+	// for all the history the banks give
+	Since string
+	// BalancesOnly skips the transactions
+	BalancesOnly bool
+}
+
+// OrganizationID returns the ID money stores a bank's organization under
+func OrganizationID(institutionID string) string {
+	return "teller_" + institutionID
+}
+
+// Fetch reads the open accounts of each enrollment in the shape SimpleFIN
+// reports them, so money fetch saves both the same way. Enrollments and
+// accounts that fail are reported in the response's Errors instead of
+// failing the whole fetch.
+func Fetch(client *Client, enrollments []Enrollment, opts FetchOptions) *simplefin.AccountsResponse {
+	resp := &simplefin.AccountsResponse{}
+	for _, enrollment := range enrollments {
+		accounts, err := client.Accounts(enrollment.AccessToken)
+		if err != nil {
+			var tellerErr *Error
+			if errors.As(err, &tellerErr) && tellerErr.Disconnected() {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("%s: the bank disconnected (%s), enroll it again with 'money init teller'",
+					enrollment.InstitutionName, tellerErr.Message))
+				continue
+			}
+			resp.Errors = append(resp.Errors, fmt.Sprintf("%s: %v", enrollment.InstitutionName, err))
+			continue
+		}
+
+		for _, account := range accounts {
+			if account.Status == "closed" {
+				continue
+			}
+			converted, err := fetchAccount(client, enrollment, account, opts)
+			if err != nil {
+				resp.Errors = append(resp.Errors, fmt.Sprintf("%s account %s: %v", enrollment.InstitutionName, account.Name, err))
+				continue
+			}
+			resp.Accounts = append(resp.Accounts, *converted)
+		}
+	}
+	return resp
+}
+
+func fetchAccount(client *Client, enrollment Enrollment, account Account, opts FetchOptions) (*simplefin.Account, error) {
+	balances, err := client.Balances(enrollment.AccessToken, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	converted := &simplefin.Account{
+		ID:       account.ID,
+		Org:      simplefin.Organization{ID: OrganizationID(account.Institution.ID), Name: account.Institution.Name},
+		Name:     accountName(account),
+		Currency: account.Currency,
+	}
+	if converted.Org.Name == "" {
+		converted.Org.Name = enrollment.InstitutionName
+	}
+	if account.Type == "credit" {
+		// Teller reports what's owed; money, like SimpleFIN, keeps debt
+		// negative. The available credit isn't an available balance.
+		converted.Balance = negate(balances.Ledger)
+	} else {
+		converted.Balance = balances.Ledger
+		if balances.Available != "" {
+			available := balances.Available
+			converted.AvailableBalance = &available
+		}
+	}
+	balanceDate := format.Now().Unix()
+	converted.BalanceDate = &balanceDate
+
+	if opts.BalancesOnly {
+		return converted, nil
+	}
+	transactions, err := client.Transactions(enrollment.AccessToken, account.ID, opts.Since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	for _, t := range transactions {
+		// Banks often give a pending transaction a new ID once it posts,
+		// so only posted ones are kept
+		if t.Status != "posted" {
+			continue
+		}
+		txn, err := convertTransaction(t)
+		if err != nil {
+			return nil, err
+		}
+		converted.Transactions = append(converted.Transactions, txn)
+	}
+	return converted, nil
+}
+
+// accountName names an account, adding the last four digits of its number
+// so same-named accounts can be told apart
+func accountName(account Account) string {
+	name := account.Name
+	if name == "" {
+		name = strings.ReplaceAll(account.Subtype, "_", " ")
+	}
+	if account.LastFour != "" {
+		name = fmt.Sprintf("%s ...%s", name, account.LastFour)
+	}
+	return name
+}
+
+// negate flips the sign of a decimal string
+func negate(amount string) string {
+	if rest, ok := strings.CutPrefix(amount, "-"); ok {
+		return rest
+	}
+	if amount == "" {
+		return amount
+	}
+	return "-" + amount
+}
+
+func convertTransaction(t Transaction) (simplefin.Transaction, error) {
+	day, err := time.ParseInLocation("2006-01-02", t.Date, format.Location())
+	if err != nil {
+		return simplefin.Transaction{}, fmt.Errorf("transaction has an invalid date %q: %w", t.Date, err)
+	}
+
+	description := strings.TrimSpace(t.Description)
+	if description == "" {
+		description = t.Details.Counterparty.Name
+	}
+	if description == "" {
+		description = "Bank transaction"
+	}
+
+	pending := false
+	return simplefin.Transaction{
+		ID:          t.ID,
+		Posted:      day.Unix(),
+		Amount:      t.Amount,
+		Description: description,
+		Pending:     &pending,
+	}, nil
+}
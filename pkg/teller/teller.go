@@ -0,0 +1,223 @@
+// Package teller reads US bank accounts through Teller, an aggregator that
+// connects to banks' own APIs, for banks it covers better than SimpleFIN.
+package teller
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BaseURL is the Teller API
+const BaseURL = "https://api.teller.io"
+
+// pageSize is how many transactions are requested at a time
+const pageSize = 100
+
+// Client represents a Teller API client. Teller identifies the application
+// by the client certificate it issued, and the user by the access token an
+// enrollment gave, which each request takes.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client presenting the certificate and private key in
+// the PEM files given
+func NewClient(certificateFile, privateKeyFile string) (*Client, error) {
+	certificate, err := tls.LoadX509KeyPair(certificateFile, privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Teller certificate: %w", err)
+	}
+	return &Client{
+		BaseURL: BaseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{certificate}},
+			},
+		},
+	}, nil
+}
+
+// Error is an error Teller returned. Codes starting with
+// "enrollment.disconnected" mean the user has to enroll the bank again.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("Teller request failed with status %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("Teller request failed with status %d: %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Disconnected reports whether the enrollment needs to be enrolled again
+func (e *Error) Disconnected() bool {
+	return strings.HasPrefix(e.Code, "enrollment.disconnected")
+}
+
+// get makes a request with the enrollment's access token and decodes the
+// response into out
+func (c *Client) get(token, path string, query url.Values, out interface{}) error {
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(token, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "money-cli/1.0")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make Teller request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+			return &Error{Status: resp.StatusCode, Code: body.Error.Code, Message: body.Error.Message}
+		}
+		return &Error{Status: resp.StatusCode, Message: string(data)}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse Teller response: %w", err)
+	}
+	return nil
+}
+
+// Institution is a bank Teller connects to
+type Institution struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Account is an account of an enrollment
+type Account struct {
+	ID           string      `json:"id"`
+	EnrollmentID string      `json:"enrollment_id"`
+	Name         string      `json:"name"`
+	Type         string      `json:"type"`    // "depository" or "credit"
+	Subtype      string      `json:"subtype"` // e.g. "checking", "credit_card"
+	Currency     string      `json:"currency"`
+	LastFour     string      `json:"last_four"`
+	Status       string      `json:"status"` // "open" or "closed"
+	Institution  Institution `json:"institution"`
+}
+
+// Balances are an account's balances as decimal strings. For credit
+// accounts the ledger balance is what's owed and the available balance the
+// credit left.
+type Balances struct {
+	AccountID string `json:"account_id"`
+	Ledger    string `json:"ledger"`
+	Available string `json:"available"`
+}
+
+// Counterparty is who was on the other side of a transaction
+type Counterparty struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TransactionDetails is what Teller worked out about a transaction
+type TransactionDetails struct {
+	Category     string       `json:"category"`
+	Counterparty Counterparty `json:"counterparty"`
+}
+
+// Transaction is an account's transaction, with a signed decimal amount
+type Transaction struct {
+	ID          string             `json:"id"`
+	AccountID   string             `json:"account_id"`
+	Amount      string             `json:"amount"`
+	Date        string             `json:"date"` // YYYY-MM-DD
+	Description string             `json:"description"`
+	Status      string             `json:"status"` // "posted" or "pending"
+	Type        string             `json:"type"`
+	Details     TransactionDetails `json:"details"`
+}
+
+// Accounts returns the enrollment's accounts
+func (c *Client) Accounts(token string) ([]Account, error) {
+	var accounts []Account
+	if err := c.get(token, "/accounts", nil, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Balances returns an account's balances
+func (c *Client) Balances(token, accountID string) (*Balances, error) {
+	var balances Balances
+	if err := c.get(token, "/accounts/"+url.PathEscape(accountID)+"/balances", nil, &balances); err != nil {
+		return nil, err
+	}
+	return &balances, nil
+}
+
+// Transactions returns an account's transactions from since on (YYYY-MM-DD),
+// or all the bank gives when since is empty. Teller returns them a page at
+// a time, newest first, so paging stops at the first page reaching back
+// before since.
+func (c *Client) Transactions(token, accountID, since string) ([]Transaction, error) {
+	var transactions []Transaction
+	fromID := ""
+	for {
+		query := url.Values{"count": {strconv.Itoa(pageSize)}}
+		if fromID != "" {
+			query.Set("from_id", fromID)
+		}
+		var page []Transaction
+		if err := c.get(token, "/accounts/"+url.PathEscape(accountID)+"/transactions", query, &page); err != nil {
+			return nil, err
+		}
+		// A short page is the last one
+		done := len(page) < pageSize
+		// Skip the transaction the page started from if it's repeated
+		if fromID != "" && len(page) > 0 && page[0].ID == fromID {
+			page = page[1:]
+		}
+		if len(page) == 0 {
+			return transactions, nil
+		}
+
+		for _, txn := range page {
+			if since != "" && txn.Date < since {
+				done = true
+				break
+			}
+			transactions = append(transactions, txn)
+		}
+		if done {
+			return transactions, nil
+		}
+		fromID = page[len(page)-1].ID
+	}
+}
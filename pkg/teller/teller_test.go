@@ -0,0 +1,151 @@
+package teller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeTeller serves an enrollment with a checking account, a credit card
+// and a closed account, and a disconnected enrollment
+type fakeTeller struct {
+	t        *testing.T
+	checking []Transaction
+	pages    int
+}
+
+func (f *fakeTeller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, _, _ := r.BasicAuth()
+	reply := func(data interface{}) {
+		json.NewEncoder(w).Encode(data)
+	}
+	fail := func(status int, code, message string) {
+		w.WriteHeader(status)
+		reply(map[string]interface{}{"error": map[string]string{"code": code, "message": message}})
+	}
+
+	switch token {
+	case "token-ok":
+	case "token-disconnected":
+		fail(http.StatusNotFound, "enrollment.disconnected.credentials_invalid", "The credentials are no longer valid")
+		return
+	default:
+		fail(http.StatusUnauthorized, "", "Missing or invalid access token")
+		return
+	}
+
+	bank := Institution{ID: "chase", Name: "Chase"}
+	switch r.URL.Path {
+	case "/accounts":
+		reply([]Account{
+			{ID: "acc_checking", EnrollmentID: "enr_1", Name: "Total Checking", Type: "depository", Subtype: "checking", Currency: "USD", LastFour: "1234", Status: "open", Institution: bank},
+			{ID: "acc_card", EnrollmentID: "enr_1", Name: "Sapphire", Type: "credit", Subtype: "credit_card", Currency: "USD", LastFour: "9876", Status: "open", Institution: bank},
+			{ID: "acc_closed", EnrollmentID: "enr_1", Name: "Old Savings", Type: "depository", Status: "closed", Institution: bank},
+		})
+	case "/accounts/acc_checking/balances":
+		reply(Balances{AccountID: "acc_checking", Ledger: "1500.25", Available: "1400.25"})
+	case "/accounts/acc_card/balances":
+		reply(Balances{AccountID: "acc_card", Ledger: "320.10", Available: "4679.90"})
+	case "/accounts/acc_checking/transactions":
+		f.pages++
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		start := 0
+		if fromID := r.URL.Query().Get("from_id"); fromID != "" {
+			for i, txn := range f.checking {
+				if txn.ID == fromID {
+					start = i
+				}
+			}
+		}
+		end := start + count
+		if end > len(f.checking) {
+			end = len(f.checking)
+		}
+		reply(f.checking[start:end])
+	case "/accounts/acc_card/transactions":
+		reply([]Transaction{
+			{ID: "txn_pending", Amount: "-12.00", Date: "2025-03-05", Description: "COFFEE", Status: "pending"},
+			{ID: "txn_card", Amount: "-54.30", Date: "2025-03-04", Description: "", Status: "posted", Details: TransactionDetails{Counterparty: Counterparty{Name: "Trader Joe's"}}},
+		})
+	default:
+		f.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	// Newest first, one page and a bit of them
+	fake := &fakeTeller{t: t}
+	for i := 0; i < pageSize+20; i++ {
+		fake.checking = append(fake.checking, Transaction{
+			ID:          fmt.Sprintf("txn_%03d", i),
+			Amount:      "-1.00",
+			Date:        fmt.Sprintf("2025-03-%02d", 28-i/5),
+			Description: "PURCHASE",
+			Status:      "posted",
+		})
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client := &Client{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	resp := Fetch(client, []Enrollment{
+		{ID: "enr_1", AccessToken: "token-ok", InstitutionName: "Chase"},
+		{ID: "enr_2", AccessToken: "token-disconnected", InstitutionName: "Wells Fargo"},
+	}, FetchOptions{})
+
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0], "Wells Fargo: the bank disconnected (The credentials are no longer valid), enroll it again") {
+		t.Errorf("Unexpected errors %q", resp.Errors)
+	}
+	if len(resp.Accounts) != 2 {
+		t.Fatalf("Expected the two open accounts, got %d", len(resp.Accounts))
+	}
+
+	checking, card := resp.Accounts[0], resp.Accounts[1]
+	if checking.ID != "acc_checking" || checking.Name != "Total Checking ...1234" || checking.Org.ID != "teller_chase" ||
+		checking.Balance != "1500.25" || checking.AvailableBalance == nil || *checking.AvailableBalance != "1400.25" {
+		t.Errorf("Unexpected checking account %+v", checking)
+	}
+	if len(checking.Transactions) != pageSize+20 || fake.pages != 2 {
+		t.Errorf("Expected every transaction over two pages, got %d over %d", len(checking.Transactions), fake.pages)
+	}
+
+	if card.Balance != "-320.10" || card.AvailableBalance != nil {
+		t.Errorf("Expected the card's debt to be negative with no available balance, got %+v", card)
+	}
+	if len(card.Transactions) != 1 || card.Transactions[0].ID != "txn_card" || card.Transactions[0].Description != "Trader Joe's" ||
+		card.Transactions[0].Amount != "-54.30" || *card.Transactions[0].Pending {
+		t.Errorf("Expected only the posted card transaction, got %+v", card.Transactions)
+	}
+
+	// Paging stops once it reaches back before since
+	fake.pages = 0
+	transactions, err := client.Transactions("token-ok", "acc_checking", "2025-03-20")
+	if err != nil {
+		t.Fatalf("Transactions failed: %v", err)
+	}
+	if len(transactions) != 45 || fake.pages != 1 {
+		t.Errorf("Expected the 45 transactions since 2025-03-20 from one page, got %d from %d", len(transactions), fake.pages)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(&fakeTeller{t: t})
+	defer server.Close()
+	client := &Client{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	_, err := client.Accounts("wrong")
+	if err == nil || !strings.Contains(err.Error(), "401: Missing or invalid access token") {
+		t.Errorf("Expected Teller's error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if _, err := NewClient(filepath.Join(dir, "certificate.pem"), filepath.Join(dir, "private_key.pem")); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+}
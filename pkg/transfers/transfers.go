@@ -0,0 +1,91 @@
+// Package transfers matches transactions that move money between the
+// user's own accounts, so both sides can be filed under an internal
+// category and left out of income and spending.
+package transfers
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// CategoryName is the internal category matched transfers are filed under
+const CategoryName = "Transfers"
+
+// DefaultWindowDays is how far apart the two sides of a transfer may post
+const DefaultWindowDays = 3
+
+// Pair is a matched transfer: an amount leaving one account and the same
+// amount arriving in another
+type Pair struct {
+	Out database.Transaction
+	In  database.Transaction
+}
+
+// Match pairs each outflow with an inflow of the same size into a different
+// account posted within windowDays of it, preferring the closest in time.
+// Pending transactions are skipped since their IDs change once they post,
+// and each transaction is used in at most one pair.
+func Match(transactions []database.Transaction, windowDays int) []Pair {
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	type candidate struct {
+		txn    database.Transaction
+		posted time.Time
+	}
+
+	var outflows []candidate
+	inflows := make(map[int64][]candidate) // by amount
+	for _, txn := range transactions {
+		if txn.Pending || txn.Amount == 0 {
+			continue
+		}
+		posted, err := time.Parse(time.RFC3339, txn.Posted)
+		if err != nil {
+			continue
+		}
+		if txn.Amount < 0 {
+			outflows = append(outflows, candidate{txn, posted})
+		} else {
+			inflows[txn.Amount] = append(inflows[txn.Amount], candidate{txn, posted})
+		}
+	}
+
+	sort.Slice(outflows, func(i, j int) bool {
+		if !outflows[i].posted.Equal(outflows[j].posted) {
+			return outflows[i].posted.Before(outflows[j].posted)
+		}
+		return outflows[i].txn.ID < outflows[j].txn.ID
+	})
+
+	used := make(map[string]bool)
+	var pairs []Pair
+	for _, out := range outflows {
+		best := -1
+		var bestGap time.Duration
+		for i, in := range inflows[-out.txn.Amount] {
+			if used[in.txn.ID] || in.txn.AccountID == out.txn.AccountID {
+				continue
+			}
+			gap := time.Duration(math.Abs(float64(in.posted.Sub(out.posted))))
+			if gap > window {
+				continue
+			}
+			if best == -1 || gap < bestGap {
+				best = i
+				bestGap = gap
+			}
+		}
+
+		if best == -1 {
+			continue
+		}
+		in := inflows[-out.txn.Amount][best]
+		used[in.txn.ID] = true
+		pairs = append(pairs, Pair{Out: out.txn, In: in.txn})
+	}
+
+	return pairs
+}
@@ -0,0 +1,55 @@
+package transfers
+
+import (
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+func txn(id, accountID, posted string, amount int64) database.Transaction {
+	return database.Transaction{ID: id, AccountID: accountID, Posted: posted + "T00:00:00Z", Amount: amount}
+}
+
+func TestMatch(t *testing.T) {
+	transactions := []database.Transaction{
+		txn("out-1", "checking", "2024-03-01", -50000),
+		txn("in-far", "savings", "2024-03-09", 50000), // too far apart
+		txn("in-1", "savings", "2024-03-02", 50000),
+		txn("out-2", "checking", "2024-03-05", -12000),
+		txn("in-same", "checking", "2024-03-05", 12000), // same account, a refund
+		txn("pay-card", "checking", "2024-03-10", -30000),
+		txn("card-paid", "card", "2024-03-12", 30000),
+		txn("coffee", "checking", "2024-03-10", -500),
+	}
+
+	pending := txn("pending", "card", "2024-03-02", 500)
+	pending.Pending = true
+	transactions = append(transactions, pending)
+
+	pairs := Match(transactions, DefaultWindowDays)
+	if len(pairs) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d: %+v", len(pairs), pairs)
+	}
+	if pairs[0].Out.ID != "out-1" || pairs[0].In.ID != "in-1" {
+		t.Errorf("Expected out-1 → in-1, got %s → %s", pairs[0].Out.ID, pairs[0].In.ID)
+	}
+	if pairs[1].Out.ID != "pay-card" || pairs[1].In.ID != "card-paid" {
+		t.Errorf("Expected pay-card → card-paid, got %s → %s", pairs[1].Out.ID, pairs[1].In.ID)
+	}
+}
+
+func TestMatchUsesEachInflowOnce(t *testing.T) {
+	transactions := []database.Transaction{
+		txn("out-1", "checking", "2024-03-01", -10000),
+		txn("out-2", "checking", "2024-03-03", -10000),
+		txn("in-1", "savings", "2024-03-03", 10000),
+	}
+
+	pairs := Match(transactions, DefaultWindowDays)
+	if len(pairs) != 1 {
+		t.Fatalf("Expected 1 pair, got %d", len(pairs))
+	}
+	if pairs[0].Out.ID != "out-1" {
+		t.Errorf("Expected the earlier outflow to claim the inflow, got %s", pairs[0].Out.ID)
+	}
+}
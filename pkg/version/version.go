@@ -1,5 +1,56 @@
 package version
 
+import (
+	"fmt"
+	"runtime/debug"
+)
+
 // Version is the version of the build.
 // by default it is set to dev but should be overridden at build time.
-var Version string = "dev"
\ No newline at end of file
+var Version string = "dev"
+
+// Commit and Date describe the source the binary was built from. They are
+// set at build time like Version; when they are not, Commit falls back to
+// the VCS information recorded by the Go toolchain.
+var (
+	Commit = ""
+	Date   = ""
+)
+
+func init() {
+	if Commit != "" {
+		return
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			Commit = setting.Value
+		case "vcs.time":
+			if Date == "" {
+				Date = setting.Value
+			}
+		}
+	}
+}
+
+// String returns the version with the commit and build date when known,
+// e.g. "v1.2.0 (commit 3d5aa70, built 2024-07-01T12:00:00Z)"
+func String() string {
+	commit := Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	switch {
+	case commit != "" && Date != "":
+		return fmt.Sprintf("%s (commit %s, built %s)", Version, commit, Date)
+	case commit != "":
+		return fmt.Sprintf("%s (commit %s)", Version, commit)
+	default:
+		return Version
+	}
+}
@@ -0,0 +1,553 @@
+package ynab
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/money/pkg/database"
+	"github.com/arjungandhi/money/pkg/format"
+)
+
+// DefaultSinceDays is how many days back push and pull look by default
+const DefaultSinceDays = 30
+
+// matchWindowDays is how many days apart a transaction in money and one in
+// YNAB may be dated and still be taken for the same one, e.g. when YNAB's
+// own bank import already brought it in
+const matchWindowDays = 3
+
+// maxPayeeLength is the longest payee name YNAB accepts
+const maxPayeeLength = 200
+
+// Options controls a push or pull
+type Options struct {
+	Since     string // YYYY-MM-DD; transactions dated before it are left alone
+	Overwrite bool   // replace categories that differ instead of only filling in missing ones
+	Import    bool   // pull: add cleared YNAB transactions that match nothing in money
+}
+
+// Result counts what a push or pull changed
+type Result struct {
+	Created     int // transactions copied to the other side
+	Linked      int // transactions matched to one already on the other side
+	Categorized int // categories set on the other side
+	Conflicts   int // categories that differ on the two sides, left alone without Overwrite
+	Closed      int // pull: category changes skipped because the month is closed
+	Unmatched   int // pull without Import: cleared YNAB transactions not in money
+	// Unmapped are the categories, money's on push and YNAB's on pull,
+	// that have no counterpart on the other side, sorted
+	Unmapped []string
+}
+
+// ImportID returns the import ID a money transaction is pushed to YNAB with,
+// at most the 36 characters YNAB allows, so YNAB refuses to create it twice
+func ImportID(transactionID string) string {
+	sum := sha1.Sum([]byte(transactionID))
+	return "MONEY:" + hex.EncodeToString(sum[:])[:30]
+}
+
+// FindAccount returns the YNAB account with the ID or, ignoring case, the
+// name given
+func FindAccount(accounts []Account, nameOrID string) (*Account, error) {
+	var found *Account
+	for i, account := range accounts {
+		if account.ID == nameOrID {
+			return &accounts[i], nil
+		}
+		if strings.EqualFold(account.Name, nameOrID) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one YNAB account is named %q, use its ID", nameOrID)
+			}
+			found = &accounts[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("YNAB account not found: %s", nameOrID)
+	}
+	return found, nil
+}
+
+// FindCategory returns the YNAB category with the ID or, ignoring case, the
+// name given, which may be prefixed with its group as "Group: Name"
+func FindCategory(categories []Category, nameOrID string) (*Category, error) {
+	var found *Category
+	for i, category := range categories {
+		if category.ID == nameOrID {
+			return &categories[i], nil
+		}
+		if strings.EqualFold(category.Name, nameOrID) || strings.EqualFold(category.Group+": "+category.Name, nameOrID) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one YNAB category is named %q, use \"Group: Name\" or its ID", nameOrID)
+			}
+			found = &categories[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("YNAB category not found: %s", nameOrID)
+	}
+	return found, nil
+}
+
+// categoryMap pairs money's categories with YNAB's: the mapped ones, then
+// those with the same name on both sides
+type categoryMap struct {
+	toYNAB   map[int]string
+	toMoney  map[string]int
+	money    map[int]database.Category
+	ynabName map[string]string // by YNAB category ID
+}
+
+func newCategoryMap(db *database.DB, ynabCategories []Category, mapped map[int]string) (*categoryMap, error) {
+	categories, err := db.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &categoryMap{
+		toYNAB:   make(map[int]string),
+		toMoney:  make(map[string]int),
+		money:    make(map[int]database.Category, len(categories)),
+		ynabName: make(map[string]string, len(ynabCategories)),
+	}
+	for _, c := range categories {
+		m.money[c.ID] = c
+	}
+	for _, c := range ynabCategories {
+		m.ynabName[c.ID] = c.Name
+	}
+	for categoryID, ynabID := range mapped {
+		m.toYNAB[categoryID] = ynabID
+		m.toMoney[ynabID] = categoryID
+	}
+
+	// Same names pair up when neither side is mapped and the name isn't
+	// used by more than one YNAB category
+	byName := make(map[string][]string)
+	for _, c := range ynabCategories {
+		name := strings.ToLower(c.Name)
+		byName[name] = append(byName[name], c.ID)
+	}
+	for _, c := range categories {
+		if _, ok := m.toYNAB[c.ID]; ok {
+			continue
+		}
+		ids := byName[strings.ToLower(c.Name)]
+		if len(ids) != 1 {
+			continue
+		}
+		if _, taken := m.toMoney[ids[0]]; taken {
+			continue
+		}
+		m.toYNAB[c.ID] = ids[0]
+		m.toMoney[ids[0]] = c.ID
+	}
+	return m, nil
+}
+
+// MatchCategories returns the money category each YNAB category is synced
+// with, by YNAB category ID: the mapped ones, and otherwise the one with the
+// same name
+func MatchCategories(db *database.DB, ynabCategories []Category) (map[string]int, error) {
+	links, err := db.GetYNABLinks()
+	if err != nil {
+		return nil, err
+	}
+	m, err := newCategoryMap(db, ynabCategories, links.Categories)
+	if err != nil {
+		return nil, err
+	}
+	return m.toMoney, nil
+}
+
+// syncer holds what push and pull both work from: the mapped accounts'
+// transactions on both sides since the start date, and which are linked
+type syncer struct {
+	db         *database.DB
+	client     *Client
+	opts       Options
+	links      *database.YNABLinks
+	categories *categoryMap
+
+	money      []database.Transaction
+	moneyByID  map[string]*database.Transaction
+	ynab       []Transaction
+	ynabByID   map[string]*Transaction
+	ynabLinked map[string]string // money transaction ID by YNAB transaction ID
+	moneyAcct  map[string]string // money account ID by YNAB account ID
+	unmapped   map[string]bool
+	result     *Result
+}
+
+func newSyncer(db *database.DB, client *Client, opts Options) (*syncer, error) {
+	links, err := db.GetYNABLinks()
+	if err != nil {
+		return nil, err
+	}
+	if len(links.Accounts) == 0 {
+		return nil, fmt.Errorf("no accounts are mapped to YNAB; map one with 'money ynab map account <account-id> <ynab-account>'")
+	}
+
+	accounts, err := client.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	ynabAccounts := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		ynabAccounts[account.ID] = true
+	}
+	s := &syncer{
+		db:         db,
+		client:     client,
+		opts:       opts,
+		links:      links,
+		moneyByID:  make(map[string]*database.Transaction),
+		ynabByID:   make(map[string]*Transaction),
+		ynabLinked: make(map[string]string, len(links.Transactions)),
+		moneyAcct:  make(map[string]string, len(links.Accounts)),
+		unmapped:   make(map[string]bool),
+		result:     &Result{},
+	}
+	for accountID, ynabID := range links.Accounts {
+		if !ynabAccounts[ynabID] {
+			return nil, fmt.Errorf("account %s is mapped to a YNAB account that no longer exists; map it again", accountID)
+		}
+		s.moneyAcct[ynabID] = accountID
+	}
+	for transactionID, ynabID := range links.Transactions {
+		s.ynabLinked[ynabID] = transactionID
+	}
+
+	ynabCategories, err := client.Categories()
+	if err != nil {
+		return nil, err
+	}
+	if s.categories, err = newCategoryMap(db, ynabCategories, links.Categories); err != nil {
+		return nil, err
+	}
+
+	transactions, err := db.GetTransactions(database.TransactionFilter{
+		StartDate:  opts.Since,
+		PostedOnly: true,
+		Ascending:  true,
+	}, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	for _, txn := range transactions {
+		if _, ok := links.Accounts[txn.AccountID]; ok {
+			s.money = append(s.money, txn)
+		}
+	}
+	for i := range s.money {
+		s.moneyByID[s.money[i].ID] = &s.money[i]
+	}
+
+	ynabTransactions, err := client.Transactions(opts.Since)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range ynabTransactions {
+		if _, ok := s.moneyAcct[txn.AccountID]; ok {
+			s.ynab = append(s.ynab, txn)
+		}
+	}
+	for i := range s.ynab {
+		s.ynabByID[s.ynab[i].ID] = &s.ynab[i]
+	}
+	return s, nil
+}
+
+// link records that a money and a YNAB transaction are the same one
+func (s *syncer) link(transactionID, ynabID string) error {
+	if err := s.db.LinkYNABTransaction(transactionID, ynabID); err != nil {
+		return err
+	}
+	s.links.Transactions[transactionID] = ynabID
+	s.ynabLinked[ynabID] = transactionID
+	return nil
+}
+
+// daysApart returns how many days apart two YYYY-MM-DD dates are
+func daysApart(a, b string) int {
+	dayA, errA := time.Parse("2006-01-02", a)
+	dayB, errB := time.Parse("2006-01-02", b)
+	if errA != nil || errB != nil {
+		return matchWindowDays + 1
+	}
+	days := int(dayA.Sub(dayB).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
+
+// matchYNAB finds the unlinked YNAB transaction that is txn: the one pushed
+// with its import ID, or else the closest in date with the same amount in
+// the mapped account
+func (s *syncer) matchYNAB(txn database.Transaction) *Transaction {
+	date := format.PostedDate(txn.Posted)
+	importID := ImportID(txn.ID)
+	var best *Transaction
+	for i := range s.ynab {
+		y := &s.ynab[i]
+		if _, linked := s.ynabLinked[y.ID]; linked || y.AccountID != s.links.Accounts[txn.AccountID] {
+			continue
+		}
+		if y.ImportID == importID {
+			return y
+		}
+		if y.Amount != txn.Amount*10 || daysApart(y.Date, date) > matchWindowDays {
+			continue
+		}
+		if best == nil || daysApart(y.Date, date) < daysApart(best.Date, date) {
+			best = y
+		}
+	}
+	return best
+}
+
+// matchMoney finds the unlinked money transaction that is y, the same way
+// matchYNAB does
+func (s *syncer) matchMoney(y Transaction) *database.Transaction {
+	accountID := s.moneyAcct[y.AccountID]
+	var best *database.Transaction
+	var bestDays int
+	for i := range s.money {
+		txn := &s.money[i]
+		if _, linked := s.links.Transactions[txn.ID]; linked || txn.AccountID != accountID {
+			continue
+		}
+		if y.ImportID != "" && y.ImportID == ImportID(txn.ID) {
+			return txn
+		}
+		days := daysApart(y.Date, format.PostedDate(txn.Posted))
+		if txn.Amount*10 != y.Amount || days > matchWindowDays {
+			continue
+		}
+		if best == nil || days < bestDays {
+			best, bestDays = txn, days
+		}
+	}
+	return best
+}
+
+// ynabCategory returns the YNAB category a money transaction's category
+// maps to, noting categories that don't map to any. Internal categories
+// aren't noted: YNAB records transfers with a transfer payee, not a
+// category.
+func (s *syncer) ynabCategory(txn database.Transaction) (string, bool) {
+	if txn.CategoryID == nil {
+		return "", false
+	}
+	if id, ok := s.categories.toYNAB[*txn.CategoryID]; ok {
+		return id, true
+	}
+	if c, ok := s.categories.money[*txn.CategoryID]; ok && !c.IsInternal {
+		s.unmapped[c.Name] = true
+	}
+	return "", false
+}
+
+// moneyCategory returns the money category a YNAB transaction's category
+// maps to, noting categories that don't map to any
+func (s *syncer) moneyCategory(y Transaction) (int, bool) {
+	if y.CategoryID == "" {
+		return 0, false
+	}
+	if id, ok := s.categories.toMoney[y.CategoryID]; ok {
+		return id, true
+	}
+	name := y.CategoryName
+	if name == "" {
+		name = s.categories.ynabName[y.CategoryID]
+	}
+	s.unmapped[name] = true
+	return 0, false
+}
+
+// finish sorts the unmapped categories into the result
+func (s *syncer) finish() *Result {
+	for name := range s.unmapped {
+		s.result.Unmapped = append(s.result.Unmapped, name)
+	}
+	sort.Strings(s.result.Unmapped)
+	return s.result
+}
+
+// payee returns a transaction's description cut to the length YNAB accepts
+func payee(description string) string {
+	runes := []rune(description)
+	if len(runes) > maxPayeeLength {
+		return string(runes[:maxPayeeLength])
+	}
+	return description
+}
+
+// Push copies posted transactions in the mapped accounts since opts.Since
+// to YNAB as cleared, unapproved transactions, so they show up for review
+// the way YNAB's own bank import does. Transactions YNAB already has, by
+// import ID or by amount within a few days, are linked instead, and their
+// YNAB category is filled in from money's when YNAB has none (or always
+// with Overwrite).
+func Push(db *database.DB, client *Client, opts Options) (*Result, error) {
+	s, err := newSyncer(db, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var creates []NewTransaction
+	pushed := make(map[string]string) // money transaction ID by import ID
+	var updates []CategoryUpdate
+	for _, txn := range s.money {
+		var y *Transaction
+		if ynabID, linked := s.links.Transactions[txn.ID]; linked {
+			if y = s.ynabByID[ynabID]; y == nil {
+				continue // deleted in YNAB, or dated before the start
+			}
+		} else if y = s.matchYNAB(txn); y != nil {
+			if err := s.link(txn.ID, y.ID); err != nil {
+				return nil, err
+			}
+			s.result.Linked++
+		}
+
+		want, mapped := s.ynabCategory(txn)
+		if y == nil {
+			importID := ImportID(txn.ID)
+			pushed[importID] = txn.ID
+			creates = append(creates, NewTransaction{
+				AccountID:  s.links.Accounts[txn.AccountID],
+				Date:       format.PostedDate(txn.Posted),
+				Amount:     txn.Amount * 10,
+				PayeeName:  payee(txn.DisplayDescription()),
+				CategoryID: want,
+				Cleared:    "cleared",
+				ImportID:   importID,
+			})
+			continue
+		}
+
+		// Transfers between YNAB accounts can't have a category
+		if !mapped || y.TransferAccountID != "" || y.CategoryID == want {
+			continue
+		}
+		if y.CategoryID != "" && !opts.Overwrite {
+			s.result.Conflicts++
+			continue
+		}
+		updates = append(updates, CategoryUpdate{ID: y.ID, CategoryID: want})
+	}
+
+	created, err := client.CreateTransactions(creates)
+	if err != nil {
+		return nil, err
+	}
+	for _, y := range created {
+		transactionID, ok := pushed[y.ImportID]
+		if !ok {
+			continue
+		}
+		if err := s.link(transactionID, y.ID); err != nil {
+			return nil, err
+		}
+		s.result.Created++
+	}
+
+	if err := client.UpdateCategories(updates); err != nil {
+		return nil, err
+	}
+	s.result.Categorized = len(updates)
+	return s.finish(), nil
+}
+
+// Pull brings cleared YNAB transactions in the mapped accounts since
+// opts.Since into money: each is linked to the money transaction it
+// matches, by import ID or by amount within a few days, and its money
+// category is filled in from YNAB's when money has none (or always with
+// Overwrite). YNAB transactions that match nothing, such as cash spending
+// entered by hand, are added to money with Import and counted otherwise.
+func Pull(db *database.DB, client *Client, opts Options) (*Result, error) {
+	s, err := newSyncer(db, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, y := range s.ynab {
+		if !y.IsCleared() {
+			continue
+		}
+
+		var txn *database.Transaction
+		if transactionID, linked := s.ynabLinked[y.ID]; linked {
+			if txn = s.moneyByID[transactionID]; txn == nil {
+				continue // deleted in money, or dated before the start
+			}
+		} else if txn = s.matchMoney(y); txn != nil {
+			if err := s.link(txn.ID, y.ID); err != nil {
+				return nil, err
+			}
+			s.result.Linked++
+		} else if opts.Import {
+			if txn, err = s.importTransaction(y); err != nil {
+				return nil, err
+			}
+			s.result.Created++
+		} else {
+			s.result.Unmatched++
+			continue
+		}
+
+		want, mapped := s.moneyCategory(y)
+		if !mapped || (txn.CategoryID != nil && *txn.CategoryID == want) {
+			continue
+		}
+		if txn.CategoryID != nil && !opts.Overwrite {
+			s.result.Conflicts++
+			continue
+		}
+		if err := db.UpdateTransactionCategory(txn.ID, want); err != nil {
+			if errors.Is(err, database.ErrMonthClosed) {
+				s.result.Closed++
+				continue
+			}
+			return nil, err
+		}
+		s.result.Categorized++
+	}
+	return s.finish(), nil
+}
+
+// importTransaction adds a YNAB transaction to its mapped money account
+func (s *syncer) importTransaction(y Transaction) (*database.Transaction, error) {
+	posted, err := format.DayStart(y.Date)
+	if err != nil {
+		return nil, fmt.Errorf("YNAB transaction %s has an invalid date %q: %w", y.ID, y.Date, err)
+	}
+	description := y.PayeeName
+	if description == "" {
+		description = y.Memo
+	}
+	if description == "" {
+		description = "YNAB transaction"
+	}
+
+	txn := database.Transaction{
+		ID:          "ynab_" + y.ID,
+		AccountID:   s.moneyAcct[y.AccountID],
+		Posted:      posted,
+		Amount:      y.Amount / 10,
+		Description: description,
+	}
+	if err := s.db.SaveTransaction(txn.ID, txn.AccountID, txn.Posted, txn.Amount, txn.Description, false); err != nil {
+		return nil, err
+	}
+	if err := s.link(txn.ID, y.ID); err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
@@ -0,0 +1,274 @@
+// Package ynab talks to the YNAB API and syncs cleared transactions and
+// their categories between money and a YNAB budget.
+package ynab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	BaseURL = "https://api.ynab.com/v1"
+
+	// LastUsedBudget selects the budget last opened in YNAB
+	LastUsedBudget = "last-used"
+)
+
+// Client represents a YNAB API client for one budget
+type Client struct {
+	Token      string
+	Budget     string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a YNAB API client authenticating with a personal access
+// token, for budget or the last used budget when budget is empty
+func NewClient(token, budget string) *Client {
+	if budget == "" {
+		budget = LastUsedBudget
+	}
+	return &Client{
+		Token:   token,
+		Budget:  budget,
+		BaseURL: BaseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Account is a YNAB account. Balances are in milliunits.
+type Account struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	OnBudget bool   `json:"on_budget"`
+	Closed   bool   `json:"closed"`
+	Balance  int64  `json:"balance"`
+	Deleted  bool   `json:"deleted"`
+}
+
+// Category is a YNAB category with the name of the group it's in
+type Category struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Group   string `json:"-"`
+	Hidden  bool   `json:"hidden"`
+	Deleted bool   `json:"deleted"`
+}
+
+// Transaction is a YNAB transaction. Amounts are in milliunits, a
+// thousandth of the currency, so a cent is 10.
+type Transaction struct {
+	ID                string `json:"id"`
+	Date              string `json:"date"` // YYYY-MM-DD
+	Amount            int64  `json:"amount"`
+	Memo              string `json:"memo"`
+	Cleared           string `json:"cleared"` // cleared, uncleared or reconciled
+	Approved          bool   `json:"approved"`
+	AccountID         string `json:"account_id"`
+	PayeeName         string `json:"payee_name"`
+	CategoryID        string `json:"category_id"`
+	CategoryName      string `json:"category_name"`
+	TransferAccountID string `json:"transfer_account_id"`
+	ImportID          string `json:"import_id"`
+	Deleted           bool   `json:"deleted"`
+}
+
+// IsCleared reports whether the transaction has cleared the bank
+func (t Transaction) IsCleared() bool {
+	return t.Cleared == "cleared" || t.Cleared == "reconciled"
+}
+
+// NewTransaction is a transaction to create in YNAB
+type NewTransaction struct {
+	AccountID  string `json:"account_id"`
+	Date       string `json:"date"`
+	Amount     int64  `json:"amount"`
+	PayeeName  string `json:"payee_name,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+	Memo       string `json:"memo,omitempty"`
+	Cleared    string `json:"cleared"`
+	Approved   bool   `json:"approved"`
+	ImportID   string `json:"import_id,omitempty"`
+}
+
+// CategoryUpdate sets the category of an existing YNAB transaction
+type CategoryUpdate struct {
+	ID         string `json:"id"`
+	CategoryID string `json:"category_id"`
+}
+
+// errorResponse is the body YNAB returns with a failed request
+type errorResponse struct {
+	Error struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Detail string `json:"detail"`
+	} `json:"error"`
+}
+
+// do sends a request to path under the budget, with body encoded as JSON
+// when it isn't nil, and decodes the response's data into out
+func (c *Client) do(method, path string, query url.Values, body, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/budgets/%s%s", c.BaseURL, url.PathEscape(c.Budget), path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "money-cli/1.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make YNAB request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr errorResponse
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Detail != "" {
+			return fmt.Errorf("YNAB request failed with status %d: %s", resp.StatusCode, apiErr.Error.Detail)
+		}
+		return fmt.Errorf("YNAB request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse YNAB response: %w", err)
+	}
+	return nil
+}
+
+// Accounts returns the budget's accounts, leaving out deleted ones
+func (c *Client) Accounts() ([]Account, error) {
+	var data struct {
+		Accounts []Account `json:"accounts"`
+	}
+	if err := c.do("GET", "/accounts", nil, nil, &data); err != nil {
+		return nil, err
+	}
+
+	var accounts []Account
+	for _, account := range data.Accounts {
+		if !account.Deleted {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+// Categories returns the budget's categories in YNAB's order, leaving out
+// deleted ones
+func (c *Client) Categories() ([]Category, error) {
+	var data struct {
+		CategoryGroups []struct {
+			Name       string     `json:"name"`
+			Deleted    bool       `json:"deleted"`
+			Categories []Category `json:"categories"`
+		} `json:"category_groups"`
+	}
+	if err := c.do("GET", "/categories", nil, nil, &data); err != nil {
+		return nil, err
+	}
+
+	var categories []Category
+	for _, group := range data.CategoryGroups {
+		if group.Deleted {
+			continue
+		}
+		for _, category := range group.Categories {
+			if category.Deleted {
+				continue
+			}
+			category.Group = group.Name
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+// Transactions returns the budget's transactions dated on or after
+// sinceDate (YYYY-MM-DD), leaving out deleted ones
+func (c *Client) Transactions(sinceDate string) ([]Transaction, error) {
+	query := url.Values{}
+	if sinceDate != "" {
+		query.Set("since_date", sinceDate)
+	}
+
+	var data struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	if err := c.do("GET", "/transactions", query, nil, &data); err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	for _, txn := range data.Transactions {
+		if !txn.Deleted {
+			transactions = append(transactions, txn)
+		}
+	}
+	return transactions, nil
+}
+
+// CreateTransactions creates transactions and returns the ones YNAB saved.
+// Transactions whose import ID YNAB already has are skipped.
+func (c *Client) CreateTransactions(transactions []NewTransaction) ([]Transaction, error) {
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	body := struct {
+		Transactions []NewTransaction `json:"transactions"`
+	}{transactions}
+	var data struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	if err := c.do("POST", "/transactions", nil, body, &data); err != nil {
+		return nil, err
+	}
+	return data.Transactions, nil
+}
+
+// UpdateCategories sets the categories of existing transactions
+func (c *Client) UpdateCategories(updates []CategoryUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	body := struct {
+		Transactions []CategoryUpdate `json:"transactions"`
+	}{updates}
+	return c.do("PATCH", "/transactions", nil, body, nil)
+}
@@ -0,0 +1,298 @@
+package ynab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/money/pkg/database"
+)
+
+// fakeYNAB is an in-memory budget served over the YNAB API
+type fakeYNAB struct {
+	t            *testing.T
+	accounts     []Account
+	categories   []Category
+	transactions []Transaction
+	nextID       int
+}
+
+func (f *fakeYNAB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"id":"401","name":"unauthorized","detail":"Unauthorized"}}`))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/budgets/last-used/") {
+		f.t.Errorf("Unexpected path %s", r.URL.Path)
+	}
+
+	var data interface{}
+	switch r.Method + " " + strings.TrimPrefix(r.URL.Path, "/budgets/last-used") {
+	case "GET /accounts":
+		data = map[string]interface{}{"accounts": f.accounts}
+	case "GET /categories":
+		data = map[string]interface{}{"category_groups": []map[string]interface{}{
+			{"name": "Everyday", "categories": f.categories},
+		}}
+	case "GET /transactions":
+		since := r.URL.Query().Get("since_date")
+		var transactions []Transaction
+		for _, txn := range f.transactions {
+			if txn.Date >= since {
+				transactions = append(transactions, txn)
+			}
+		}
+		data = map[string]interface{}{"transactions": transactions}
+	case "POST /transactions":
+		var body struct {
+			Transactions []NewTransaction `json:"transactions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Fatalf("Failed to decode created transactions: %v", err)
+		}
+		var created []Transaction
+		for _, n := range body.Transactions {
+			f.nextID++
+			txn := Transaction{
+				ID:         "y-new-" + string(rune('0'+f.nextID)),
+				Date:       n.Date,
+				Amount:     n.Amount,
+				Cleared:    n.Cleared,
+				AccountID:  n.AccountID,
+				PayeeName:  n.PayeeName,
+				CategoryID: n.CategoryID,
+				ImportID:   n.ImportID,
+			}
+			f.transactions = append(f.transactions, txn)
+			created = append(created, txn)
+		}
+		data = map[string]interface{}{"transactions": created}
+	case "PATCH /transactions":
+		var body struct {
+			Transactions []CategoryUpdate `json:"transactions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Fatalf("Failed to decode updates: %v", err)
+		}
+		for _, update := range body.Transactions {
+			for i := range f.transactions {
+				if f.transactions[i].ID == update.ID {
+					f.transactions[i].CategoryID = update.CategoryID
+				}
+			}
+		}
+		data = map[string]interface{}{}
+	default:
+		f.t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func (f *fakeYNAB) transaction(id string) *Transaction {
+	for i := range f.transactions {
+		if f.transactions[i].ID == id {
+			return &f.transactions[i]
+		}
+	}
+	return nil
+}
+
+func TestPushAndPull(t *testing.T) {
+	t.Setenv("MONEY_DIR", t.TempDir())
+	db, err := database.New()
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveOrganization("org-1", "Bank", ""); err != nil {
+		t.Fatalf("Failed to save organization: %v", err)
+	}
+	if err := db.SaveAccount("checking", "org-1", "Checking", "USD", 100000, nil, ""); err != nil {
+		t.Fatalf("Failed to save account: %v", err)
+	}
+	groceries, err := db.SaveCategory("Groceries")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	dining, err := db.SaveCategory("Dining Out")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+	rent, err := db.SaveCategory("Rent")
+	if err != nil {
+		t.Fatalf("Failed to save category: %v", err)
+	}
+
+	for _, txn := range []struct {
+		id, posted string
+		amount     int64
+		category   int
+		pending    bool
+	}{
+		{"m-groceries", "2025-03-02T17:00:00Z", -4500, groceries, false},
+		{"m-rent", "2025-03-01T17:00:00Z", -150000, rent, false},
+		{"m-coffee", "2025-03-04T17:00:00Z", -550, 0, false},
+		{"m-pending", "2025-03-05T17:00:00Z", -999, 0, true},
+	} {
+		if err := db.SaveTransaction(txn.id, "checking", txn.posted, txn.amount, strings.ToUpper(txn.id), txn.pending); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+		if txn.category != 0 {
+			if err := db.UpdateTransactionCategory(txn.id, txn.category); err != nil {
+				t.Fatalf("Failed to categorize transaction: %v", err)
+			}
+		}
+	}
+
+	fake := &fakeYNAB{
+		t:        t,
+		accounts: []Account{{ID: "y-checking", Name: "Joint Checking", Type: "checking", OnBudget: true}},
+		categories: []Category{
+			{ID: "y-groceries", Name: "groceries"},
+			{ID: "y-eating-out", Name: "Eating Out"},
+			{ID: "y-housing", Name: "Housing"},
+		},
+		transactions: []Transaction{
+			// YNAB's own bank import brought the coffee in a day later,
+			// and the YNAB user filed it
+			{ID: "y-coffee", Date: "2025-03-05", Amount: -5500, Cleared: "cleared", AccountID: "y-checking", PayeeName: "Coffee", CategoryID: "y-eating-out", CategoryName: "Eating Out"},
+			// Cash entered by hand in YNAB
+			{ID: "y-cash", Date: "2025-03-06", Amount: -20000, Cleared: "cleared", AccountID: "y-checking", PayeeName: "Farmers Market", CategoryID: "y-groceries", CategoryName: "groceries"},
+			// Not cleared yet, so not pulled
+			{ID: "y-uncleared", Date: "2025-03-07", Amount: -1000, Cleared: "uncleared", AccountID: "y-checking", PayeeName: "Pizza"},
+		},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client := NewClient("test-token", "")
+	client.BaseURL = server.URL
+
+	if _, err := Push(db, client, Options{Since: "2025-03-01"}); err == nil || !strings.Contains(err.Error(), "map one") {
+		t.Fatalf("Expected an error before any account is mapped, got %v", err)
+	}
+	if err := db.SetYNABAccount("checking", "y-checking"); err != nil {
+		t.Fatalf("Failed to map account: %v", err)
+	}
+	if err := db.SetYNABCategory(dining, "y-eating-out"); err != nil {
+		t.Fatalf("Failed to map category: %v", err)
+	}
+
+	result, err := Push(db, client, Options{Since: "2025-03-01"})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if result.Created != 2 || result.Linked != 1 || result.Categorized != 0 {
+		t.Errorf("Unexpected push result %+v", result)
+	}
+	if len(result.Unmapped) != 1 || result.Unmapped[0] != "Rent" {
+		t.Errorf("Expected Rent to be unmapped, got %v", result.Unmapped)
+	}
+	links, err := db.GetYNABLinks()
+	if err != nil {
+		t.Fatalf("Failed to get links: %v", err)
+	}
+	if links.Transactions["m-coffee"] != "y-coffee" {
+		t.Errorf("Expected the coffee to be linked to YNAB's, got %q", links.Transactions["m-coffee"])
+	}
+	pushed := fake.transaction(links.Transactions["m-groceries"])
+	if pushed == nil || pushed.Amount != -45000 || pushed.Date != "2025-03-02" || pushed.CategoryID != "y-groceries" || pushed.ImportID != ImportID("m-groceries") {
+		t.Errorf("Unexpected pushed groceries %+v", pushed)
+	}
+	if len(fake.transactions) != 5 {
+		t.Errorf("Expected 5 YNAB transactions after the push, got %d", len(fake.transactions))
+	}
+
+	// Pushing again changes nothing
+	if result, err = Push(db, client, Options{Since: "2025-03-01"}); err != nil {
+		t.Fatalf("Second push failed: %v", err)
+	}
+	if result.Created != 0 || result.Linked != 0 || len(fake.transactions) != 5 {
+		t.Errorf("Expected a second push to change nothing, got %+v", result)
+	}
+
+	// Without --import the cash is only counted
+	if result, err = Pull(db, client, Options{Since: "2025-03-01"}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if result.Unmatched != 1 || result.Created != 0 || result.Categorized != 1 {
+		t.Errorf("Unexpected pull result %+v", result)
+	}
+	coffee, err := db.GetTransactionByID("m-coffee")
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if coffee.CategoryID == nil || *coffee.CategoryID != dining {
+		t.Errorf("Expected the coffee to get YNAB's Eating Out as Dining Out, got %v", coffee.CategoryID)
+	}
+
+	if result, err = Pull(db, client, Options{Since: "2025-03-01", Import: true}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if result.Created != 1 || result.Unmatched != 0 {
+		t.Errorf("Unexpected pull result with import %+v", result)
+	}
+	cash, err := db.GetTransactionByID("ynab_y-cash")
+	if err != nil {
+		t.Fatalf("Expected the cash to be imported: %v", err)
+	}
+	if cash.Amount != -2000 || cash.Description != "Farmers Market" || cash.CategoryID == nil || *cash.CategoryID != groceries {
+		t.Errorf("Unexpected imported cash %+v", cash)
+	}
+
+	// A category changed on both sides is left alone unless overwritten
+	fake.transaction(links.Transactions["m-groceries"]).CategoryID = "y-housing"
+	if result, err = Push(db, client, Options{Since: "2025-03-01"}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if result.Conflicts != 1 || fake.transaction(links.Transactions["m-groceries"]).CategoryID != "y-housing" {
+		t.Errorf("Expected a conflict to be left alone, got %+v", result)
+	}
+	if result, err = Push(db, client, Options{Since: "2025-03-01", Overwrite: true}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if result.Categorized != 1 || fake.transaction(links.Transactions["m-groceries"]).CategoryID != "y-groceries" {
+		t.Errorf("Expected --overwrite to set YNAB's category, got %+v", result)
+	}
+}
+
+func TestFind(t *testing.T) {
+	accounts := []Account{{ID: "a1", Name: "Checking"}, {ID: "a2", Name: "Savings"}, {ID: "a3", Name: "savings"}}
+	if account, err := FindAccount(accounts, "checking"); err != nil || account.ID != "a1" {
+		t.Errorf("FindAccount by name = %v, %v", account, err)
+	}
+	if account, err := FindAccount(accounts, "a3"); err != nil || account.ID != "a3" {
+		t.Errorf("FindAccount by ID = %v, %v", account, err)
+	}
+	if _, err := FindAccount(accounts, "Savings"); err == nil {
+		t.Error("Expected an error for an ambiguous account name")
+	}
+
+	categories := []Category{{ID: "c1", Name: "Fun", Group: "Wants"}, {ID: "c2", Name: "Fun", Group: "Kids"}}
+	if category, err := FindCategory(categories, "kids: fun"); err != nil || category.ID != "c2" {
+		t.Errorf("FindCategory by group and name = %v, %v", category, err)
+	}
+	if _, err := FindCategory(categories, "Fun"); err == nil {
+		t.Error("Expected an error for an ambiguous category name")
+	}
+	if _, err := FindCategory(categories, "Rent"); err == nil {
+		t.Error("Expected an error for a missing category")
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(&fakeYNAB{t: t})
+	defer server.Close()
+	client := NewClient("wrong-token", "")
+	client.BaseURL = server.URL
+
+	_, err := client.Accounts()
+	if err == nil || !strings.Contains(err.Error(), "401: Unauthorized") {
+		t.Errorf("Expected YNAB's error detail, got %v", err)
+	}
+}